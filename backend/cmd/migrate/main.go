@@ -82,6 +82,24 @@ func migrateUp(db *sql.DB) error {
 		{9, "create_dictionary_tables", getSQL("009_create_dictionary_tables.up.sql")},
 		{10, "create_pattern_tables", getSQL("010_create_pattern_tables.up.sql")},
 		{11, "create_teacher_mode_tables", getSQL("011_create_teacher_mode_tables.up.sql")},
+		{12, "add_ocr_layout_to_pages", getSQL("012_add_ocr_layout_to_pages.up.sql")},
+		{13, "add_ocr_job_queue", getSQL("013_add_ocr_job_queue.up.sql")},
+		{14, "add_ocr_provider_to_pages", getSQL("014_add_ocr_provider_to_pages.up.sql")},
+		{15, "add_detect_orientation_to_ocr_jobs", getSQL("015_add_detect_orientation_to_ocr_jobs.up.sql")},
+		{16, "create_phrases_table", getSQL("016_create_phrases_table.up.sql")},
+		{17, "create_ocr_corrections", getSQL("017_create_ocr_corrections.up.sql")},
+		{18, "add_review_scheduler", getSQL("018_add_review_scheduler.up.sql")},
+		{19, "add_fsrs_state", getSQL("019_add_fsrs_state.up.sql")},
+		{20, "add_review_limits", getSQL("020_add_review_limits.up.sql")},
+		{21, "add_review_card_types", getSQL("021_add_review_card_types.up.sql")},
+		{22, "add_review_import", getSQL("022_add_review_import.up.sql")},
+		{23, "create_review_sessions", getSQL("023_create_review_sessions.up.sql")},
+		{24, "add_review_leeches", getSQL("024_add_review_leeches.up.sql")},
+		{25, "add_review_reminders", getSQL("025_add_review_reminders.up.sql")},
+		{26, "add_ocr_jobs_active_page_index", getSQL("026_add_ocr_jobs_active_page_index.up.sql")},
+		{27, "add_ocr_job_kind", getSQL("027_add_ocr_job_kind.up.sql")},
+		{28, "add_review_optimize_claim", getSQL("028_add_review_optimize_claim.up.sql")},
+		{29, "create_review_session_states", getSQL("029_create_review_session_states.up.sql")},
 	}
 
 	// Also include subscription and stats tables
@@ -145,6 +163,24 @@ func migrateDown(db *sql.DB) error {
 		name    string
 		sql     string
 	}{
+		{29, "create_review_session_states", getSQL("029_create_review_session_states.down.sql")},
+		{28, "add_review_optimize_claim", getSQL("028_add_review_optimize_claim.down.sql")},
+		{27, "add_ocr_job_kind", getSQL("027_add_ocr_job_kind.down.sql")},
+		{26, "add_ocr_jobs_active_page_index", getSQL("026_add_ocr_jobs_active_page_index.down.sql")},
+		{25, "add_review_reminders", getSQL("025_add_review_reminders.down.sql")},
+		{24, "add_review_leeches", getSQL("024_add_review_leeches.down.sql")},
+		{23, "create_review_sessions", getSQL("023_create_review_sessions.down.sql")},
+		{22, "add_review_import", getSQL("022_add_review_import.down.sql")},
+		{21, "add_review_card_types", getSQL("021_add_review_card_types.down.sql")},
+		{20, "add_review_limits", getSQL("020_add_review_limits.down.sql")},
+		{19, "add_fsrs_state", getSQL("019_add_fsrs_state.down.sql")},
+		{18, "add_review_scheduler", getSQL("018_add_review_scheduler.down.sql")},
+		{17, "create_ocr_corrections", getSQL("017_create_ocr_corrections.down.sql")},
+		{16, "create_phrases_table", getSQL("016_create_phrases_table.down.sql")},
+		{15, "add_detect_orientation_to_ocr_jobs", getSQL("015_add_detect_orientation_to_ocr_jobs.down.sql")},
+		{14, "add_ocr_provider_to_pages", getSQL("014_add_ocr_provider_to_pages.down.sql")},
+		{13, "add_ocr_job_queue", getSQL("013_add_ocr_job_queue.down.sql")},
+		{12, "add_ocr_layout_to_pages", getSQL("012_add_ocr_layout_to_pages.down.sql")},
 		{11, "create_teacher_mode_tables", getSQL("011_create_teacher_mode_tables.down.sql")},
 		{10, "create_pattern_tables", getSQL("010_create_pattern_tables.down.sql")},
 		{9, "create_dictionary_tables", getSQL("009_create_dictionary_tables.down.sql")},
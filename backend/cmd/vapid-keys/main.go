@@ -0,0 +1,21 @@
+// vapid-keys はWeb Pushの復習リマインダーに使用するVAPIDの鍵ペアを生成する
+//
+//	go run ./cmd/vapid-keys >> .env
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/clearclown/HaiLanGo/backend/internal/service/notification"
+)
+
+func main() {
+	keys, err := notification.GenerateVAPIDKeys()
+	if err != nil {
+		log.Fatalf("failed to generate vapid keys: %v", err)
+	}
+
+	fmt.Printf("VAPID_PUBLIC_KEY=%s\n", keys.PublicKey)
+	fmt.Printf("VAPID_PRIVATE_KEY=%s\n", keys.PrivateKey)
+}
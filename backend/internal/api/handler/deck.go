@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/clearclown/HaiLanGo/backend/pkg/anki"
+	"github.com/gin-gonic/gin"
+)
+
+// Deck formats accepted by the import/export endpoints
+const (
+	deckFormatAnki = "apkg" // Anki package (SQLite collection + media in a zip)
+	deckFormatText = "text" // Anki plain text notes (tab/comma separated)
+
+	// maxDeckSize caps uploaded decks (media-heavy shared decks are the largest)
+	maxDeckSize = 100 << 20
+)
+
+// readDeckUpload reads the multipart "file" field and resolves its format from the
+// "format" parameter or, when omitted, the file extension.
+// It writes the error response itself and returns ok=false on failure.
+func readDeckUpload(c *gin.Context) (data []byte, format string, ok bool) {
+	header, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Deck file is required"})
+		return nil, "", false
+	}
+	if header.Size > maxDeckSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Deck file is too large"})
+		return nil, "", false
+	}
+
+	format = c.PostForm("format")
+	if format == "" {
+		format = c.Query("format")
+	}
+	if format == "" {
+		switch strings.ToLower(filepath.Ext(header.Filename)) {
+		case ".apkg":
+			format = deckFormatAnki
+		case ".txt", ".tsv", ".csv":
+			format = deckFormatText
+		}
+	}
+	if format != deckFormatAnki && format != deckFormatText {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unsupported deck format (use apkg or text)"})
+		return nil, "", false
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read deck file"})
+		return nil, "", false
+	}
+	defer file.Close()
+
+	data, err = io.ReadAll(io.LimitReader(file, maxDeckSize))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read deck file"})
+		return nil, "", false
+	}
+	return data, format, true
+}
+
+// writeDeckImportError maps deck parsing errors to 400 and everything else to 500
+func writeDeckImportError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, anki.ErrUnsupportedFormat):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unsupported Anki package format: the package was exported by a newer Anki version"})
+	case errors.Is(err, anki.ErrInvalidPackage), errors.Is(err, anki.ErrInvalidDatabase), errors.Is(err, anki.ErrInvalidTextDeck):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid deck file", "details": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import deck"})
+	}
+}
+
+// exportDeckFormat returns the requested export format (default: apkg), writing a 400 for unknown formats
+func exportDeckFormat(c *gin.Context) (string, bool) {
+	format := c.DefaultQuery("format", deckFormatAnki)
+	if format != deckFormatAnki && format != deckFormatText {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unsupported deck format (use apkg or text)"})
+		return "", false
+	}
+	return format, true
+}
+
+// sendDeck sends an exported deck as a file download named <name>.apkg or <name>.txt
+func sendDeck(c *gin.Context, data []byte, format, name string) {
+	contentType, ext := "application/zip", ".apkg"
+	if format == deckFormatText {
+		contentType, ext = "text/plain; charset=utf-8", ".txt"
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name+ext))
+	c.Data(http.StatusOK, contentType, data)
+}
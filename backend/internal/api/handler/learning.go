@@ -1,18 +1,25 @@
 package handler
 
 import (
+	"context"
+	"log"
 	"net/http"
 	"strconv"
 
 	"github.com/clearclown/HaiLanGo/backend/internal/models"
 	"github.com/clearclown/HaiLanGo/backend/internal/repository"
+	srsservice "github.com/clearclown/HaiLanGo/backend/internal/service/srs"
+	"github.com/clearclown/HaiLanGo/backend/pkg/translate"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
 
 // LearningHandler handles learning-related HTTP requests
 type LearningHandler struct {
-	repo repository.LearningRepositoryInterface
+	repo       repository.LearningRepositoryInterface
+	translator translate.Client
+	bookRepo   repository.BookRepository
+	generator  *srsservice.ReviewItemGenerator
 }
 
 // NewLearningHandler creates a new learning handler
@@ -22,6 +29,18 @@ func NewLearningHandler(repo repository.LearningRepositoryInterface) *LearningHa
 	}
 }
 
+// SetTranslator sets the client used to fill in missing page and phrase translations.
+// The book repository provides the target and native languages of the book.
+func (h *LearningHandler) SetTranslator(translator translate.Client, bookRepo repository.BookRepository) {
+	h.translator = translator
+	h.bookRepo = bookRepo
+}
+
+// SetReviewItemGenerator sets the generator that turns the phrases of a completed page into review items.
+func (h *LearningHandler) SetReviewItemGenerator(generator *srsservice.ReviewItemGenerator) {
+	h.generator = generator
+}
+
 // GetPageLearning handles GET /api/v1/learning/books/:bookId/pages/:pageNumber
 // @Summary Get learning page data
 // @Description Get page data for learning including OCR, phrases, vocabulary
@@ -70,9 +89,52 @@ func (h *LearningHandler) GetPageLearning(c *gin.Context) {
 		return
 	}
 
+	h.fillTranslations(c.Request.Context(), bookID, pageLearning)
+
 	c.JSON(http.StatusOK, pageLearning)
 }
 
+// fillTranslations translates the page text and phrases that have no translation yet.
+// Translation failures are logged and leave the fields empty.
+func (h *LearningHandler) fillTranslations(ctx context.Context, bookID uuid.UUID, pageLearning *models.PageLearning) {
+	if h.translator == nil || h.bookRepo == nil {
+		return
+	}
+
+	var texts []string
+	var targets []*string
+	if pageLearning.Page.Translation == "" && pageLearning.Page.OCRText != "" {
+		texts = append(texts, pageLearning.Page.OCRText)
+		targets = append(targets, &pageLearning.Page.Translation)
+	}
+	for i := range pageLearning.Phrases {
+		phrase := &pageLearning.Phrases[i]
+		if phrase.Translation == "" && phrase.Text != "" {
+			texts = append(texts, phrase.Text)
+			targets = append(targets, &phrase.Translation)
+		}
+	}
+	if len(texts) == 0 {
+		return
+	}
+
+	book, err := h.bookRepo.GetByID(ctx, bookID)
+	if err != nil || book == nil {
+		return
+	}
+
+	translations, err := h.translator.Translate(ctx, texts, book.TargetLanguage, book.NativeLanguage)
+	if err != nil {
+		log.Printf("failed to translate page %d of book %s: %v", pageLearning.Page.PageNumber, bookID, err)
+		return
+	}
+	for i, translation := range translations {
+		if i < len(targets) {
+			*targets[i] = translation.Text
+		}
+	}
+}
+
 // CompletePage handles POST /api/v1/learning/books/:bookId/pages/:pageNumber/complete
 // @Summary Mark page as completed
 // @Description Mark a learning page as completed
@@ -133,6 +195,15 @@ func (h *LearningHandler) CompletePage(c *gin.Context) {
 		NextPage: pageNumber + 1,
 	}
 
+	// Queue the page's phrases for review. The page is already completed, so a failure is only logged.
+	if h.generator != nil {
+		added, err := h.generator.FromCompletedPage(c.Request.Context(), userID.String(), bookID, pageNumber)
+		if err != nil {
+			log.Printf("failed to create review items for book %s page %d: %v", bookID, pageNumber, err)
+		}
+		response.ReviewItemsAdded = len(added)
+	}
+
 	c.JSON(http.StatusOK, response)
 }
 
@@ -2,6 +2,7 @@ package handler
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -10,8 +11,12 @@ import (
 
 	"github.com/clearclown/HaiLanGo/backend/internal/models"
 	"github.com/clearclown/HaiLanGo/backend/internal/repository"
+	srsservice "github.com/clearclown/HaiLanGo/backend/internal/service/srs"
+	"github.com/clearclown/HaiLanGo/backend/pkg/translate"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func setupLearningTestRouter() (*gin.Engine, *repository.InMemoryLearningRepository) {
@@ -282,3 +287,103 @@ func TestInvalidPageNumber(t *testing.T) {
 
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 }
+
+func TestGetPageLearningTranslatesPhrases(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctx := context.Background()
+	bookID := uuid.MustParse("550e8400-e29b-41d4-a716-446655440000")
+
+	// OCRで分割したフレーズのうち訳のないものを翻訳する
+	phraseRepo := repository.NewInMemoryPhraseRepository()
+	require.NoError(t, phraseRepo.ReplacePagePhrases(ctx, uuid.New(), []*models.PhraseRecord{
+		{ID: uuid.New(), BookID: bookID, PageNumber: 3, Position: 0, Text: "Как дела?"},
+		{ID: uuid.New(), BookID: bookID, PageNumber: 3, Position: 1, Text: "Спасибо, хорошо.", Translation: "元気です"},
+	}))
+	repo := repository.NewInMemoryLearningRepository()
+	repo.SetPhraseRepository(phraseRepo)
+
+	bookRepo := repository.NewInMemoryBookRepository()
+	require.NoError(t, bookRepo.Create(ctx, &models.Book{ID: bookID, Title: "ロシア語入門", TargetLanguage: "ru", NativeLanguage: "ja"}))
+
+	handler := NewLearningHandler(repo)
+	handler.SetTranslator(translate.NewMockClient(), bookRepo)
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("user_id", "550e8400-e29b-41d4-a716-446655440000")
+		c.Next()
+	})
+	handler.RegisterRoutes(r.Group("/api/v1"))
+
+	req, _ := http.NewRequest(http.MethodGet, "/api/v1/learning/books/"+bookID.String()+"/pages/3", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var pageLearning models.PageLearning
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &pageLearning))
+	require.Len(t, pageLearning.Phrases, 2)
+	assert.Equal(t, "元気ですか？", pageLearning.Phrases[0].Translation)
+	assert.Equal(t, "元気です", pageLearning.Phrases[1].Translation)
+}
+
+func TestCompletePageCreatesReviewItems(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctx := context.Background()
+	userID := "550e8400-e29b-41d4-a716-446655440000"
+	bookID := uuid.MustParse("550e8400-e29b-41d4-a716-446655440000")
+
+	phraseRepo := repository.NewInMemoryPhraseRepository()
+	require.NoError(t, phraseRepo.ReplacePagePhrases(ctx, uuid.New(), []*models.PhraseRecord{
+		{ID: uuid.New(), BookID: bookID, PageNumber: 4, Position: 0, Text: "Урок 4"},
+		{ID: uuid.New(), BookID: bookID, PageNumber: 4, Position: 1, Text: "Как дела?", Translation: "元気ですか？"},
+		{ID: uuid.New(), BookID: bookID, PageNumber: 4, Position: 2, Text: "Спасибо, хорошо.", Translation: "元気です"},
+	}))
+
+	reviewRepo := repository.NewInMemoryReviewRepository()
+	generator := srsservice.NewReviewItemGenerator(srsservice.NewSRSService(reviewRepo))
+	generator.SetPhraseRepository(phraseRepo)
+
+	handler := NewLearningHandler(repository.NewInMemoryLearningRepository())
+	handler.SetReviewItemGenerator(generator)
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("user_id", userID)
+		c.Next()
+	})
+	handler.RegisterRoutes(r.Group("/api/v1"))
+
+	complete := func() models.CompletePageResponse {
+		bodyBytes, _ := json.Marshal(models.CompletePageRequest{StudyTime: 120})
+		req, _ := http.NewRequest(http.MethodPost, "/api/v1/learning/books/"+bookID.String()+"/pages/4/complete", bytes.NewBuffer(bodyBytes))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response models.CompletePageResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return response
+	}
+
+	// 訳のあるフレーズだけを復習項目にする（フレーズごとに4種類のカード）
+	assert.Equal(t, 8, complete().ReviewItemsAdded)
+
+	items, err := reviewRepo.FindByUserID(ctx, userID)
+	require.NoError(t, err)
+	var added []*models.ReviewItem
+	for _, item := range items {
+		if item.BookID == bookID.String() {
+			added = append(added, item)
+		}
+	}
+	require.Len(t, added, 8)
+	for _, item := range added {
+		assert.Equal(t, models.ReviewItemTypePhrase, item.Type)
+		assert.Equal(t, 4, item.PageNumber)
+	}
+
+	// 同じページをもう一度完了しても重複して作成しない
+	assert.Equal(t, 0, complete().ReviewItemsAdded)
+}
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -18,7 +19,14 @@ import (
 type OCRHandler struct {
 	repo       repository.OCRRepositoryInterface
 	ocrService *ocrservice.OCRService
+	jobQueue   *ocrservice.JobQueue
 	wsHub      *websocket.Hub
+
+	// OCR結果の手動修正・自動修正（SetCorrectionServices で設定）
+	editor   *ocrservice.EditorService
+	learner  *ocrservice.CorrectionLearner
+	pageRepo repository.PageRepository
+	bookRepo repository.BookRepository
 }
 
 // NewOCRHandler はOCRハンドラーを作成
@@ -30,6 +38,17 @@ func NewOCRHandler(repo repository.OCRRepositoryInterface, ocrService *ocrservic
 	}
 }
 
+// SetBookRepository は書籍の所有者の確認に使う書籍リポジトリを設定する
+func (h *OCRHandler) SetBookRepository(bookRepo repository.BookRepository) {
+	h.bookRepo = bookRepo
+}
+
+// SetJobQueue は永続OCRジョブキューを設定する
+// 設定されている場合、バッチOCR処理はキューに登録されワーカーが処理する
+func (h *OCRHandler) SetJobQueue(queue *ocrservice.JobQueue) {
+	h.jobQueue = queue
+}
+
 // RegisterRoutes はOCR APIのルートを登録
 func (h *OCRHandler) RegisterRoutes(rg *gin.RouterGroup) {
 	ocr := rg.Group("/ocr")
@@ -49,6 +68,27 @@ func (h *OCRHandler) RegisterRoutes(rg *gin.RouterGroup) {
 		// 書籍のOCRジョブ一覧
 		ocr.GET("/books/:bookId/jobs", h.GetBookJobs)
 
+		// 書籍のOCRジョブキューの状態・キャンセル・dead letter一覧
+		ocr.GET("/books/:bookId/queue", h.GetQueueStatus)
+		ocr.POST("/books/:bookId/cancel", h.CancelBook)
+		ocr.GET("/books/:bookId/dead-letter", h.GetDeadLetterJobs)
+
+		// dead letter・キャンセル済みジョブの再実行
+		ocr.POST("/jobs/:jobId/retry", h.RetryJob)
+
+		// ページのOCRレイアウト（単語・行・ブロックの矩形領域）
+		ocr.GET("/pages/:pageId/layout", h.GetPageLayout)
+
+		// OCR結果の手動修正と修正履歴
+		ocr.PUT("/pages/:pageId/text", h.UpdatePageText)
+		ocr.GET("/pages/:pageId/corrections", h.GetPageCorrections)
+
+		// 手動修正から学習した自動修正の確認・取り消しと置換ルール一覧
+		ocr.GET("/books/:bookId/auto-corrections", h.GetAutoCorrections)
+		ocr.GET("/books/:bookId/correction-rules", h.GetCorrectionRules)
+		ocr.POST("/auto-corrections/:correctionId/undo", h.UndoAutoCorrection)
+		ocr.POST("/auto-corrections/:correctionId/accept", h.AcceptAutoCorrection)
+
 		// OCR統計情報
 		ocr.GET("/statistics", h.GetStatistics)
 	}
@@ -146,6 +186,33 @@ func (h *OCRHandler) BatchProcess(c *gin.Context) {
 		return
 	}
 
+	// 永続ジョブキューが設定されている場合は登録済みページをキューに投入する
+	if h.jobQueue != nil {
+		options := req.Options
+		if len(options.Languages) == 0 {
+			options.Languages = []string{req.Language}
+		}
+
+		jobs, totalPages, err := h.jobQueue.EnqueueBookPages(c.Request.Context(), userID, bookID, options)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to enqueue OCR jobs"})
+			return
+		}
+
+		jobIDs := make([]string, 0, len(jobs))
+		for _, job := range jobs {
+			jobIDs = append(jobIDs, job.ID.String())
+		}
+
+		c.JSON(http.StatusAccepted, &models.BatchOCRResponse{
+			BookID:     bookID.String(),
+			TotalPages: totalPages,
+			JobIDs:     jobIDs,
+			CreatedAt:  time.Now(),
+		})
+		return
+	}
+
 	// 実際の実装では書籍のページ数を取得
 	totalPages := 150 // サンプルデータ
 
@@ -325,3 +392,192 @@ func (h *OCRHandler) GetStatistics(c *gin.Context) {
 
 	c.JSON(http.StatusOK, stats)
 }
+
+// GetPageLayout はページのOCRレイアウトを取得
+// GET /api/v1/ocr/pages/:pageId/layout
+func (h *OCRHandler) GetPageLayout(c *gin.Context) {
+	userID, ok := h.ownerID(c)
+	if !ok {
+		return
+	}
+
+	pageID, err := uuid.Parse(c.Param("pageId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid page ID"})
+		return
+	}
+
+	layout, err := h.ocrService.GetPageLayout(c.Request.Context(), pageID)
+	if err != nil {
+		if errors.Is(err, ocrservice.ErrPageNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Page not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get OCR layout"})
+		return
+	}
+
+	// 他のユーザーの書籍のページは返さない
+	bookID, err := uuid.Parse(layout.BookID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Book not found"})
+		return
+	}
+	if _, ok := h.ownedBook(c, userID, bookID); !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, layout)
+}
+
+// ownerID は認証済みのユーザーIDを返す
+// 書籍の所有者を確認できない（書籍リポジトリが未設定の）場合はエラーを返す
+func (h *OCRHandler) ownerID(c *gin.Context) (uuid.UUID, bool) {
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return uuid.Nil, false
+	}
+
+	if h.bookRepo == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Book ownership cannot be verified"})
+		return uuid.Nil, false
+	}
+
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return uuid.Nil, false
+	}
+
+	return userID, true
+}
+
+// GetQueueStatus は書籍のOCRジョブキューの状態を取得
+// GET /api/v1/ocr/books/:bookId/queue
+func (h *OCRHandler) GetQueueStatus(c *gin.Context) {
+	bookID, ok := h.queueBookID(c)
+	if !ok {
+		return
+	}
+
+	status, err := h.jobQueue.GetBookStatus(c.Request.Context(), bookID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get OCR queue status"})
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// CancelBook は書籍の処理待ち・処理中のOCRジョブをキャンセル
+// POST /api/v1/ocr/books/:bookId/cancel
+func (h *OCRHandler) CancelBook(c *gin.Context) {
+	bookID, ok := h.queueBookID(c)
+	if !ok {
+		return
+	}
+
+	cancelled, err := h.jobQueue.CancelBook(c.Request.Context(), bookID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel OCR jobs"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"book_id":   bookID.String(),
+		"cancelled": cancelled,
+	})
+}
+
+// GetDeadLetterJobs はリトライ上限に達したOCRジョブ一覧を取得
+// GET /api/v1/ocr/books/:bookId/dead-letter
+func (h *OCRHandler) GetDeadLetterJobs(c *gin.Context) {
+	bookID, ok := h.queueBookID(c)
+	if !ok {
+		return
+	}
+
+	jobs, err := h.jobQueue.ListDeadLetters(c.Request.Context(), bookID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get dead letter jobs"})
+		return
+	}
+
+	c.JSON(http.StatusOK, jobs)
+}
+
+// RetryJob はdead letter・キャンセル済みのOCRジョブを再実行キューに戻す
+// POST /api/v1/ocr/jobs/:jobId/retry
+func (h *OCRHandler) RetryJob(c *gin.Context) {
+	userID, ok := h.ownerID(c)
+	if !ok {
+		return
+	}
+
+	if h.jobQueue == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "OCR job queue is not available"})
+		return
+	}
+
+	jobID, err := uuid.Parse(c.Param("jobId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid job ID"})
+		return
+	}
+
+	job, err := h.jobQueue.GetJob(c.Request.Context(), jobID)
+	if err != nil {
+		if errors.Is(err, repository.ErrOCRJobNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get OCR job"})
+		return
+	}
+	if _, ok := h.ownedBook(c, userID, job.BookID); !ok {
+		return
+	}
+
+	if err := h.jobQueue.RetryJob(c.Request.Context(), jobID); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrOCRJobNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+		case errors.Is(err, repository.ErrOCRJobNotOwned):
+			c.JSON(http.StatusConflict, gin.H{"error": "Only dead letter or cancelled jobs can be retried"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retry OCR job"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"job_id": jobID.String(),
+		"status": models.OCRStatusPending,
+	})
+}
+
+// queueBookID は認証・ジョブキューの有無・書籍の所有者を確認し、パスの書籍IDを返す
+func (h *OCRHandler) queueBookID(c *gin.Context) (uuid.UUID, bool) {
+	userID, ok := h.ownerID(c)
+	if !ok {
+		return uuid.Nil, false
+	}
+
+	if h.jobQueue == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "OCR job queue is not available"})
+		return uuid.Nil, false
+	}
+
+	bookID, err := uuid.Parse(c.Param("bookId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid book ID"})
+		return uuid.Nil, false
+	}
+
+	if _, ok := h.ownedBook(c, userID, bookID); !ok {
+		return uuid.Nil, false
+	}
+
+	return bookID, true
+}
@@ -0,0 +1,314 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/clearclown/HaiLanGo/backend/internal/models"
+	"github.com/clearclown/HaiLanGo/backend/internal/repository"
+	ocrservice "github.com/clearclown/HaiLanGo/backend/internal/service/ocr"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// SetCorrectionServices はOCR結果の手動修正と、修正から学習した自動修正の確認・取り消しを有効にする
+func (h *OCRHandler) SetCorrectionServices(
+	editor *ocrservice.EditorService,
+	learner *ocrservice.CorrectionLearner,
+	pageRepo repository.PageRepository,
+	bookRepo repository.BookRepository,
+) {
+	h.editor = editor
+	h.learner = learner
+	h.pageRepo = pageRepo
+	h.bookRepo = bookRepo
+}
+
+// UpdatePageText はページのOCR結果を手動で修正する
+// 修正内容は置換ルールとして学習され、以降のページのOCR結果に自動で適用される
+// PUT /api/v1/ocr/pages/:pageId/text
+func (h *OCRHandler) UpdatePageText(c *gin.Context) {
+	userID, page, ok := h.correctionPage(c)
+	if !ok {
+		return
+	}
+
+	var req models.UpdateOCRTextRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	correction, err := h.editor.UpdateOCRText(c.Request.Context(), page.BookID, page.ID, userID, req.CorrectedText)
+	if err != nil {
+		switch {
+		case errors.Is(err, ocrservice.ErrInvalidCorrectedText), errors.Is(err, ocrservice.ErrTextTooLong):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, ocrservice.ErrPageNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Page not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update OCR text"})
+		}
+		return
+	}
+
+	h.resegmentPage(c.Request.Context(), page.ID)
+
+	c.JSON(http.StatusOK, models.UpdateOCRTextResponse{
+		Success:    true,
+		Correction: *correction,
+	})
+}
+
+// GetPageCorrections はページの手動修正の履歴を取得する
+// GET /api/v1/ocr/pages/:pageId/corrections
+func (h *OCRHandler) GetPageCorrections(c *gin.Context) {
+	userID, page, ok := h.correctionPage(c)
+	if !ok {
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	history, err := h.editor.GetCorrectionHistory(c.Request.Context(), page.BookID, page.ID, userID, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get correction history"})
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
+
+// GetAutoCorrections は書籍のページに自動で適用された修正の一覧を取得する
+// GET /api/v1/ocr/books/:bookId/auto-corrections?status=applied
+func (h *OCRHandler) GetAutoCorrections(c *gin.Context) {
+	_, book, ok := h.correctionBook(c)
+	if !ok {
+		return
+	}
+
+	status := models.OCRAutoCorrectionStatus(c.Query("status"))
+	switch status {
+	case "", models.OCRAutoCorrectionApplied, models.OCRAutoCorrectionAccepted, models.OCRAutoCorrectionReverted:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status"})
+		return
+	}
+
+	corrections, err := h.learner.ListAutoCorrections(c.Request.Context(), book.ID, status)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get auto corrections"})
+		return
+	}
+	if corrections == nil {
+		corrections = []*models.OCRAutoCorrection{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"book_id":     book.ID.String(),
+		"corrections": corrections,
+		"total":       len(corrections),
+	})
+}
+
+// GetCorrectionRules は書籍に適用される置換ルール（書籍単位・ユーザー全体）の一覧を取得する
+// GET /api/v1/ocr/books/:bookId/correction-rules
+func (h *OCRHandler) GetCorrectionRules(c *gin.Context) {
+	userID, book, ok := h.correctionBook(c)
+	if !ok {
+		return
+	}
+
+	rules, err := h.learner.ListRules(c.Request.Context(), userID, book.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get correction rules"})
+		return
+	}
+	if rules == nil {
+		rules = []*models.OCRCorrectionRule{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"book_id": book.ID.String(),
+		"rules":   rules,
+	})
+}
+
+// UndoAutoCorrection は自動修正を取り消し、ページのテキストを元に戻す
+// disable_rule を指定すると、以降のページにもそのルールを適用しない
+// POST /api/v1/ocr/auto-corrections/:correctionId/undo
+func (h *OCRHandler) UndoAutoCorrection(c *gin.Context) {
+	userID, correctionID, ok := h.autoCorrectionID(c)
+	if !ok {
+		return
+	}
+
+	var req models.UndoOCRAutoCorrectionRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			return
+		}
+	}
+
+	correction, err := h.learner.Undo(c.Request.Context(), userID, correctionID, req.DisableRule)
+	if err != nil {
+		h.respondAutoCorrectionError(c, err)
+		return
+	}
+
+	h.resegmentPage(c.Request.Context(), correction.PageID)
+
+	c.JSON(http.StatusOK, correction)
+}
+
+// AcceptAutoCorrection は自動修正を確認済みにする
+// POST /api/v1/ocr/auto-corrections/:correctionId/accept
+func (h *OCRHandler) AcceptAutoCorrection(c *gin.Context) {
+	userID, correctionID, ok := h.autoCorrectionID(c)
+	if !ok {
+		return
+	}
+
+	correction, err := h.learner.Accept(c.Request.Context(), userID, correctionID)
+	if err != nil {
+		h.respondAutoCorrectionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, correction)
+}
+
+// respondAutoCorrectionError は自動修正の操作のエラーをレスポンスに変換する
+func (h *OCRHandler) respondAutoCorrectionError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ocrservice.ErrAutoCorrectionNotFound), errors.Is(err, ocrservice.ErrPageNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Auto correction not found"})
+	case errors.Is(err, ocrservice.ErrUnauthorized):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+	case errors.Is(err, ocrservice.ErrAutoCorrectionReverted), errors.Is(err, ocrservice.ErrAutoCorrectionConflict):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update auto correction"})
+	}
+}
+
+// resegmentPage は修正後のテキストでページの対訳フレーズを作り直す
+func (h *OCRHandler) resegmentPage(ctx context.Context, pageID uuid.UUID) {
+	page, err := h.pageRepo.FindByID(ctx, pageID)
+	if err != nil || page == nil {
+		return
+	}
+	if _, err := h.ocrService.SegmentPage(ctx, page); err != nil && !errors.Is(err, ocrservice.ErrSameScriptLanguages) {
+		log.Printf("failed to segment phrases for page %s: %v", page.ID, err)
+	}
+}
+
+// correctionUserID は認証と修正機能の有無を確認し、ユーザーIDを返す
+func (h *OCRHandler) correctionUserID(c *gin.Context) (uuid.UUID, bool) {
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return uuid.Nil, false
+	}
+
+	if h.editor == nil || h.learner == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "OCR correction is not available"})
+		return uuid.Nil, false
+	}
+
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return uuid.Nil, false
+	}
+
+	return userID, true
+}
+
+// correctionBook はパスの書籍を取得し、ユーザーの書籍であることを確認する
+func (h *OCRHandler) correctionBook(c *gin.Context) (uuid.UUID, *models.Book, bool) {
+	userID, ok := h.correctionUserID(c)
+	if !ok {
+		return uuid.Nil, nil, false
+	}
+
+	bookID, err := uuid.Parse(c.Param("bookId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid book ID"})
+		return uuid.Nil, nil, false
+	}
+
+	book, ok := h.ownedBook(c, userID, bookID)
+	return userID, book, ok
+}
+
+// correctionPage はパスのページを取得し、ユーザーの書籍のページであることを確認する
+func (h *OCRHandler) correctionPage(c *gin.Context) (uuid.UUID, *models.Page, bool) {
+	userID, ok := h.correctionUserID(c)
+	if !ok {
+		return uuid.Nil, nil, false
+	}
+
+	pageID, err := uuid.Parse(c.Param("pageId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid page ID"})
+		return uuid.Nil, nil, false
+	}
+
+	page, err := h.pageRepo.FindByID(c.Request.Context(), pageID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get page"})
+		return uuid.Nil, nil, false
+	}
+	if page == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Page not found"})
+		return uuid.Nil, nil, false
+	}
+
+	if _, ok := h.ownedBook(c, userID, page.BookID); !ok {
+		return uuid.Nil, nil, false
+	}
+
+	return userID, page, true
+}
+
+// ownedBook は書籍を取得し、ユーザーの書籍であることを確認する
+func (h *OCRHandler) ownedBook(c *gin.Context, userID, bookID uuid.UUID) (*models.Book, bool) {
+	book, err := h.bookRepo.GetByID(c.Request.Context(), bookID)
+	if err != nil || book == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Book not found"})
+		return nil, false
+	}
+	if book.UserID != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		return nil, false
+	}
+	return book, true
+}
+
+// autoCorrectionID は認証と修正機能の有無を確認し、パスの自動修正IDを返す
+func (h *OCRHandler) autoCorrectionID(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	userID, ok := h.correctionUserID(c)
+	if !ok {
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	correctionID, err := uuid.Parse(c.Param("correctionId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid correction ID"})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return userID, correctionID, true
+}
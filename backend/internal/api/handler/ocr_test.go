@@ -14,6 +14,7 @@ import (
 	"github.com/clearclown/HaiLanGo/backend/internal/websocket"
 	"github.com/clearclown/HaiLanGo/backend/pkg/cache"
 	"github.com/clearclown/HaiLanGo/backend/pkg/ocr"
+	"github.com/clearclown/HaiLanGo/backend/pkg/storage"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
@@ -350,3 +351,309 @@ func TestOCRInvalidPageNumber(t *testing.T) {
 
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 }
+
+// TestGetPageLayout はページのOCRレイアウト取得のテスト
+func TestGetPageLayout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	ocrClient, _ := ocr.NewOCRClient()
+	ocrSvc := ocrservice.NewOCRService(ocrClient, cache.NewMockCache())
+	pageRepo := repository.NewMockPageRepository()
+	ocrSvc.SetPageRepository(pageRepo)
+
+	pageID := uuid.New()
+	page, err := ocrSvc.ProcessPage(context.Background(), pageID, []byte("test image data"), []string{"ru"})
+	assert.NoError(t, err)
+	userID := uuid.MustParse("550e8400-e29b-41d4-a716-446655440000")
+	bookRepo := repository.NewInMemoryBookRepository()
+	book := &models.Book{ID: uuid.New(), UserID: userID, Title: "ロシア語入門"}
+	otherBook := &models.Book{ID: uuid.New(), UserID: uuid.New(), Title: "他人の書籍"}
+	assert.NoError(t, bookRepo.Create(context.Background(), book))
+	assert.NoError(t, bookRepo.Create(context.Background(), otherBook))
+
+	page.BookID = book.ID
+	page.PageNumber = 1
+	assert.NoError(t, pageRepo.Create(context.Background(), page))
+	otherPage := &models.Page{ID: uuid.New(), BookID: otherBook.ID, PageNumber: 1, OCRText: "text"}
+	assert.NoError(t, pageRepo.Create(context.Background(), otherPage))
+
+	ocrHandler := NewOCRHandler(repository.NewInMemoryOCRRepository(), ocrSvc, websocket.NewHub())
+	ocrHandler.SetBookRepository(bookRepo)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("user_id", "550e8400-e29b-41d4-a716-446655440000")
+		c.Next()
+	})
+	ocrHandler.RegisterRoutes(r.Group("/api/v1"))
+
+	req, _ := http.NewRequest(http.MethodGet, "/api/v1/ocr/pages/"+pageID.String()+"/layout", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response models.PageOCRLayoutResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, pageID.String(), response.PageID)
+	assert.NotNil(t, response.Result)
+	assert.NotEmpty(t, response.Result.Words)
+	assert.NotEmpty(t, response.Result.Blocks)
+
+	// 他のユーザーの書籍のページ
+	req, _ = http.NewRequest(http.MethodGet, "/api/v1/ocr/pages/"+otherPage.ID.String()+"/layout", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// 存在しないページ
+	req, _ = http.NewRequest(http.MethodGet, "/api/v1/ocr/pages/"+uuid.New().String()+"/layout", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// 無効なページID
+	req, _ = http.NewRequest(http.MethodGet, "/api/v1/ocr/pages/invalid-uuid/layout", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+// TestOCRQueueEndpoints は永続ジョブキューのエンドポイントのテスト
+func TestOCRQueueEndpoints(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctx := context.Background()
+
+	store := storage.NewLocalStorage(t.TempDir())
+	pageRepo := repository.NewMockPageRepository()
+	ocrSvc := ocrservice.NewOCRService(ocr.NewMockOCRClient(), cache.NewMockCache())
+	ocrSvc.SetPageRepository(pageRepo)
+
+	userID := uuid.MustParse("550e8400-e29b-41d4-a716-446655440000")
+	bookID := uuid.New()
+	bookRepo := repository.NewInMemoryBookRepository()
+	otherBook := &models.Book{ID: uuid.New(), UserID: uuid.New(), Title: "他人の書籍"}
+	assert.NoError(t, bookRepo.Create(ctx, &models.Book{ID: bookID, UserID: userID, Title: "ロシア語入門"}))
+	assert.NoError(t, bookRepo.Create(ctx, otherBook))
+	assert.NoError(t, pageRepo.Create(ctx, &models.Page{
+		ID:         uuid.New(),
+		BookID:     otherBook.ID,
+		PageNumber: 1,
+		ImageURL:   "other.jpg",
+		OCRStatus:  models.OCRStatusPending,
+	}))
+	for i := 1; i <= 2; i++ {
+		path, err := store.SaveFile(ctx, userID, bookID, "page.jpg", bytes.NewReader([]byte{byte(i)}))
+		assert.NoError(t, err)
+		assert.NoError(t, pageRepo.Create(ctx, &models.Page{
+			ID:         uuid.New(),
+			BookID:     bookID,
+			PageNumber: i,
+			ImageURL:   path,
+			OCRStatus:  models.OCRStatusPending,
+		}))
+	}
+
+	config := ocrservice.DefaultQueueConfig()
+	config.RetryBackoff = 0
+	queue := ocrservice.NewJobQueue(repository.NewInMemoryOCRJobQueueRepository(), ocrSvc, store, config)
+
+	ocrHandler := NewOCRHandler(repository.NewInMemoryOCRRepository(), ocrSvc, websocket.NewHub())
+	ocrHandler.SetJobQueue(queue)
+	ocrHandler.SetBookRepository(bookRepo)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("user_id", userID.String())
+		c.Next()
+	})
+	ocrHandler.RegisterRoutes(r.Group("/api/v1"))
+
+	// バッチ登録
+	body, _ := json.Marshal(models.BatchOCRRequest{BookID: bookID.String(), Language: "ru"})
+	req, _ := http.NewRequest(http.MethodPost, "/api/v1/ocr/books/"+bookID.String()+"/batch", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusAccepted, w.Code)
+
+	var batch models.BatchOCRResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &batch))
+	assert.Equal(t, 2, batch.TotalPages)
+	assert.Len(t, batch.JobIDs, 2)
+
+	// キューの状態
+	req, _ = http.NewRequest(http.MethodGet, "/api/v1/ocr/books/"+bookID.String()+"/queue", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var status models.OCRQueueStatus
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &status))
+	assert.Equal(t, 2, status.Pending)
+
+	// キャンセル
+	req, _ = http.NewRequest(http.MethodPost, "/api/v1/ocr/books/"+bookID.String()+"/cancel", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"cancelled":2`)
+
+	// キャンセル済みジョブの再実行
+	req, _ = http.NewRequest(http.MethodPost, "/api/v1/ocr/jobs/"+batch.JobIDs[0]+"/retry", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusAccepted, w.Code)
+
+	// 処理待ちのジョブは再実行できない
+	req, _ = http.NewRequest(http.MethodPost, "/api/v1/ocr/jobs/"+batch.JobIDs[0]+"/retry", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	// 存在しないジョブ
+	req, _ = http.NewRequest(http.MethodPost, "/api/v1/ocr/jobs/"+uuid.New().String()+"/retry", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// dead letter一覧
+	req, _ = http.NewRequest(http.MethodGet, "/api/v1/ocr/books/"+bookID.String()+"/dead-letter", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, "[]", w.Body.String())
+
+	// 他のユーザーの書籍のキューは操作できない
+	otherJobs, _, err := queue.EnqueueBookPages(ctx, otherBook.UserID, otherBook.ID, models.OCROptions{Languages: []string{"ru"}})
+	assert.NoError(t, err)
+	assert.Len(t, otherJobs, 1)
+	for _, path := range []string{
+		"/api/v1/ocr/books/" + otherBook.ID.String() + "/queue",
+		"/api/v1/ocr/books/" + otherBook.ID.String() + "/dead-letter",
+	} {
+		req, _ = http.NewRequest(http.MethodGet, path, nil)
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusForbidden, w.Code, path)
+	}
+	for _, path := range []string{
+		"/api/v1/ocr/books/" + otherBook.ID.String() + "/cancel",
+		"/api/v1/ocr/jobs/" + otherJobs[0].ID.String() + "/retry",
+	} {
+		req, _ = http.NewRequest(http.MethodPost, path, nil)
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusForbidden, w.Code, path)
+	}
+	otherStatus, err := queue.GetBookStatus(ctx, otherBook.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, otherStatus.Pending)
+}
+
+// TestOCRQueueEndpoints_Unavailable はジョブキュー未設定時のテスト
+func TestOCRQueueEndpoints_Unavailable(t *testing.T) {
+	router, _ := setupOCRTestRouter()
+
+	req, _ := http.NewRequest(http.MethodGet, "/api/v1/ocr/books/"+uuid.New().String()+"/queue", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
+
+// TestOCRCorrectionEndpoints は手動修正と自動修正の確認・取り消しのエンドポイントのテスト
+func TestOCRCorrectionEndpoints(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctx := context.Background()
+
+	pageRepo := repository.NewMockPageRepository()
+	bookRepo := repository.NewInMemoryBookRepository()
+	ocrSvc := ocrservice.NewOCRService(ocr.NewMockOCRClient(), cache.NewMockCache())
+	ocrSvc.SetPageRepository(pageRepo)
+
+	userID := uuid.MustParse("550e8400-e29b-41d4-a716-446655440000")
+	book := &models.Book{ID: uuid.New(), UserID: userID, Title: "ロシア語入門", TargetLanguage: "ru", NativeLanguage: "ja"}
+	otherBook := &models.Book{ID: uuid.New(), UserID: uuid.New(), Title: "他人の書籍"}
+	assert.NoError(t, bookRepo.Create(ctx, book))
+	assert.NoError(t, bookRepo.Create(ctx, otherBook))
+
+	page1 := &models.Page{ID: uuid.New(), BookID: book.ID, PageNumber: 1, OCRText: "Здравствуите! Как дела?"}
+	otherPage := &models.Page{ID: uuid.New(), BookID: otherBook.ID, PageNumber: 1, OCRText: "text"}
+	assert.NoError(t, pageRepo.Create(ctx, page1))
+	assert.NoError(t, pageRepo.Create(ctx, otherPage))
+
+	correctionRepo := repository.NewInMemoryOCRCorrectionRepository()
+	pageStore := ocrservice.NewPageStore(pageRepo)
+	learner := ocrservice.NewCorrectionLearner(pageStore, correctionRepo)
+	editor := ocrservice.NewEditorService(pageStore, correctionRepo)
+	editor.SetCorrectionLearner(learner)
+
+	ocrHandler := NewOCRHandler(repository.NewInMemoryOCRRepository(), ocrSvc, websocket.NewHub())
+	ocrHandler.SetCorrectionServices(editor, learner, pageRepo, bookRepo)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("user_id", userID.String())
+		c.Next()
+	})
+	ocrHandler.RegisterRoutes(r.Group("/api/v1"))
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, "/api/v1/ocr"+path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	// 手動修正
+	w := do(http.MethodPut, "/pages/"+page1.ID.String()+"/text", `{"corrected_text":"Здравствуйте! Как дела?"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "Здравствуйте! Как дела?", page1.Text())
+
+	w = do(http.MethodGet, "/pages/"+page1.ID.String()+"/corrections", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	var history models.OCRCorrectionHistory
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &history))
+	assert.Equal(t, 1, history.TotalCount)
+
+	// 他のユーザーの書籍のページは修正できない
+	w = do(http.MethodPut, "/pages/"+otherPage.ID.String()+"/text", `{"corrected_text":"edited"}`)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// 次のページには学習した修正が自動で適用される
+	page2 := &models.Page{ID: uuid.New(), BookID: book.ID, PageNumber: 2, OCRText: "Здравствуите, Анна!"}
+	applied, err := learner.Apply(ctx, page2, userID)
+	assert.NoError(t, err)
+	assert.NoError(t, pageRepo.Create(ctx, page2))
+	assert.NoError(t, learner.SaveAutoCorrections(ctx, page2.ID, applied))
+
+	w = do(http.MethodGet, "/books/"+book.ID.String()+"/auto-corrections?status=applied", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	var listed struct {
+		Corrections []models.OCRAutoCorrection `json:"corrections"`
+		Total       int                        `json:"total"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &listed))
+	assert.Equal(t, 1, listed.Total)
+	assert.Equal(t, "Здравствуите", listed.Corrections[0].Original)
+
+	w = do(http.MethodGet, "/books/"+book.ID.String()+"/correction-rules", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = do(http.MethodGet, "/books/"+book.ID.String()+"/auto-corrections?status=unknown", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = do(http.MethodGet, "/books/"+otherBook.ID.String()+"/auto-corrections", "")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// 自動修正の取り消し
+	correctionPath := "/auto-corrections/" + listed.Corrections[0].ID.String()
+	w = do(http.MethodPost, correctionPath+"/undo", `{"disable_rule":true}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "Здравствуите, Анна!", page2.Text())
+
+	w = do(http.MethodPost, correctionPath+"/undo", "")
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = do(http.MethodPost, "/auto-corrections/"+uuid.New().String()+"/accept", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
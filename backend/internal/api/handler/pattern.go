@@ -1,19 +1,27 @@
 package handler
 
 import (
+	"context"
+	"log"
 	"net/http"
 	"strconv"
 	"time"
 
+	"github.com/clearclown/HaiLanGo/backend/internal/models"
 	"github.com/clearclown/HaiLanGo/backend/internal/repository"
 	"github.com/clearclown/HaiLanGo/backend/internal/service/pattern"
+	srsservice "github.com/clearclown/HaiLanGo/backend/internal/service/srs"
+	"github.com/clearclown/HaiLanGo/backend/pkg/translate"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
 
 // PatternHandler はパターンAPIのハンドラー
 type PatternHandler struct {
-	repo repository.PatternRepositoryInterface
+	repo       repository.PatternRepositoryInterface
+	phraseRepo repository.PhraseRepository
+	translator translate.Client
+	generator  *srsservice.ReviewItemGenerator
 }
 
 // NewPatternHandler はパターンハンドラーを作成
@@ -23,6 +31,22 @@ func NewPatternHandler(repo repository.PatternRepositoryInterface) *PatternHandl
 	}
 }
 
+// SetPhraseRepository は対訳フレーズのリポジトリを設定する
+// 設定すると書籍のOCR結果から分割したフレーズをパターン抽出に使用する
+func (h *PatternHandler) SetPhraseRepository(repo repository.PhraseRepository) {
+	h.phraseRepo = repo
+}
+
+// SetTranslator は訳のないフレーズの翻訳に使用する翻訳クライアントを設定する
+func (h *PatternHandler) SetTranslator(translator translate.Client) {
+	h.translator = translator
+}
+
+// SetReviewItemGenerator は練習したパターンを復習項目にするジェネレーターを設定する
+func (h *PatternHandler) SetReviewItemGenerator(generator *srsservice.ReviewItemGenerator) {
+	h.generator = generator
+}
+
 // RegisterRoutes はパターンAPIのルートを登録
 func (h *PatternHandler) RegisterRoutes(rg *gin.RouterGroup) {
 	patterns := rg.Group("/patterns")
@@ -51,7 +75,6 @@ func (h *PatternHandler) RegisterRoutes(rg *gin.RouterGroup) {
 type ExtractPatternsRequest struct {
 	BookID       uuid.UUID `json:"book_id" binding:"required"`
 	MinFrequency int       `json:"min_frequency"`
-	// 実際のページデータは省略（モック実装のため）
 }
 
 // ExtractPatterns はパターンを抽出
@@ -74,23 +97,24 @@ func (h *PatternHandler) ExtractPatterns(c *gin.Context) {
 		req.MinFrequency = 2
 	}
 
-	// サンプルページデータ（実際はDBから取得）
-	samplePages := []pattern.PageText{
-		{
-			PageNumber:  1,
-			Text:        "Здравствуйте! Как дела? Здравствуйте!",
-			Translation: "こんにちは！調子はどう？こんにちは！",
-		},
-		{
-			PageNumber:  2,
-			Text:        "Спасибо, хорошо. А у вас? Здравствуйте!",
-			Translation: "ありがとう、元気です。あなたは？こんにちは！",
-		},
+	// 書籍の対訳フレーズを取得（未登録の場合はサンプルデータ）
+	var pages []pattern.PageText
+	if h.phraseRepo != nil {
+		phrases, err := h.phraseRepo.FindByBook(c.Request.Context(), req.BookID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load phrases"})
+			return
+		}
+		h.translatePhrases(c.Request.Context(), phrases)
+		pages = pattern.PageTextsFromPhrases(phrases)
+	}
+	if len(pages) == 0 {
+		pages = samplePatternPages
 	}
 
 	startTime := time.Now()
 
-	patterns, err := h.repo.ExtractPatterns(c.Request.Context(), req.BookID, samplePages, req.MinFrequency)
+	patterns, err := h.repo.ExtractPatterns(c.Request.Context(), req.BookID, pages, req.MinFrequency)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to extract patterns"})
 		return
@@ -101,11 +125,66 @@ func (h *PatternHandler) ExtractPatterns(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"patterns":        patterns,
 		"total_found":     len(patterns),
-		"processed_pages": len(samplePages),
+		"processed_pages": countPages(pages),
 		"duration_ms":     duration.Milliseconds(),
 	})
 }
 
+// translatePhrases は訳のないフレーズを翻訳する（パターンの使用例に訳を付けるため）
+// 翻訳に失敗した場合は訳のないまま抽出する
+func (h *PatternHandler) translatePhrases(ctx context.Context, phrases []*models.PhraseRecord) {
+	if h.translator == nil {
+		return
+	}
+
+	var untranslated []*models.PhraseRecord
+	var texts []string
+	for _, phrase := range phrases {
+		if phrase.Translation == "" {
+			untranslated = append(untranslated, phrase)
+			texts = append(texts, phrase.Text)
+		}
+	}
+	if len(untranslated) == 0 {
+		return
+	}
+
+	// フレーズの言語は書籍単位で共通
+	translations, err := h.translator.Translate(ctx, texts, untranslated[0].TargetLanguage, untranslated[0].NativeLanguage)
+	if err != nil {
+		log.Printf("failed to translate phrases for pattern extraction: %v", err)
+		return
+	}
+	for i, translation := range translations {
+		if i < len(untranslated) {
+			untranslated[i].Translation = translation.Text
+		}
+	}
+}
+
+// countPages はページテキストに含まれるページ数を数える
+func countPages(pages []pattern.PageText) int {
+	seen := make(map[int]bool)
+	for _, page := range pages {
+		seen[page.PageNumber] = true
+	}
+	return len(seen)
+}
+
+// samplePatternPages は対訳フレーズがない書籍に使用するサンプルページデータ
+var samplePatternPages = []pattern.PageText{
+	{
+		PageNumber:  1,
+		Text:        "Здравствуйте! Как дела? Здравствуйте!",
+		Translation: "こんにちは！調子はどう？こんにちは！",
+	},
+	{
+		PageNumber:  2,
+		Text:        "Спасибо, хорошо. А у вас? Здравствуйте!",
+		Translation: "ありがとう、元気です。あなたは？こんにちは！",
+	},
+}
+
 // GetPatternsByBook は書籍のパターン一覧を取得
 // GET /api/v1/patterns/books/:book_id
 func (h *PatternHandler) GetPatternsByBook(c *gin.Context) {
@@ -278,5 +357,12 @@ func (h *PatternHandler) UpdatePatternProgress(c *gin.Context) {
 		return
 	}
 
+	// 練習したパターンを復習項目に追加する（進捗は更新済みのため、失敗してもエラーにはしない）
+	if h.generator != nil {
+		if _, err := h.generator.FromPracticedPattern(c.Request.Context(), userID.String(), patternID); err != nil {
+			log.Printf("failed to create review item for pattern %s: %v", patternID, err)
+		}
+	}
+
 	c.JSON(http.StatusOK, progress)
 }
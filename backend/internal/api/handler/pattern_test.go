@@ -2,6 +2,7 @@ package handler
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -9,9 +10,11 @@ import (
 
 	"github.com/clearclown/HaiLanGo/backend/internal/models"
 	"github.com/clearclown/HaiLanGo/backend/internal/repository"
+	"github.com/clearclown/HaiLanGo/backend/pkg/translate"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func setupPatternTestRouter() (*gin.Engine, repository.PatternRepositoryInterface) {
@@ -62,6 +65,51 @@ func TestExtractPatterns(t *testing.T) {
 	assert.NotNil(t, response["duration_ms"])
 }
 
+// TestExtractPatternsFromPhrases は書籍の対訳フレーズからのパターン抽出のテスト
+func TestExtractPatternsFromPhrases(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctx := context.Background()
+	bookID := uuid.New()
+
+	phraseRepo := repository.NewInMemoryPhraseRepository()
+	for page := 1; page <= 3; page++ {
+		require.NoError(t, phraseRepo.ReplacePagePhrases(ctx, uuid.New(), []*models.PhraseRecord{
+			{ID: uuid.New(), BookID: bookID, PageNumber: page, Text: "Как дела?", TargetLanguage: "ru", NativeLanguage: "ja"},
+		}))
+	}
+
+	patternRepo := repository.NewInMemoryPatternRepository()
+	patternHandler := NewPatternHandler(patternRepo)
+	patternHandler.SetPhraseRepository(phraseRepo)
+	patternHandler.SetTranslator(translate.NewMockClient())
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("user_id", "550e8400-e29b-41d4-a716-446655440000")
+		c.Next()
+	})
+	patternHandler.RegisterRoutes(r.Group("/api/v1"))
+
+	body, _ := json.Marshal(ExtractPatternsRequest{BookID: bookID, MinFrequency: 2})
+	req, _ := http.NewRequest(http.MethodPost, "/api/v1/patterns/extract", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		Patterns       []models.Pattern `json:"patterns"`
+		ProcessedPages int              `json:"processed_pages"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, 3, response.ProcessedPages)
+	require.NotEmpty(t, response.Patterns)
+	for _, pattern := range response.Patterns {
+		assert.Equal(t, bookID, pattern.BookID)
+		assert.Equal(t, "元気ですか？", pattern.Translation)
+	}
+}
+
 // TestExtractPatternsInvalidRequest は無効なリクエストのテスト
 func TestExtractPatternsInvalidRequest(t *testing.T) {
 	router, _ := setupPatternTestRouter()
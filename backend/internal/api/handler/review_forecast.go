@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	srsservice "github.com/clearclown/HaiLanGo/backend/internal/service/srs"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// GetForecast godoc
+// @Summary Forecast the review workload and retention
+// @Description Simulates the user's scheduler forward over their review items, optionally with a book's phrases or a number of new items added, and returns per-day due counts, reviews, new cards and expected retention (averages over several simulated runs)
+// @Tags review
+// @Produce json
+// @Security BearerAuth
+// @Param days query int false "Days to forecast (default 30, max 365)"
+// @Param new_items query int false "Number of hypothetical learning items to add"
+// @Param book_id query string false "Book whose phrases not yet in the review set are added"
+// @Success 200 {object} srsservice.Forecast
+// @Failure 400 {object} map[string]string
+// @Router /api/v1/review/forecast [get]
+func (h *ReviewHandler) GetForecast(c *gin.Context) {
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	req := &srsservice.ForecastRequest{}
+	var err error
+	if days := c.Query("days"); days != "" {
+		if req.Days, err = strconv.Atoi(days); err != nil || req.Days <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid days"})
+			return
+		}
+	}
+	if newItems := c.Query("new_items"); newItems != "" {
+		if req.NewItems, err = strconv.Atoi(newItems); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid new_items"})
+			return
+		}
+	}
+	if bookID := c.Query("book_id"); bookID != "" {
+		if req.BookID, err = uuid.Parse(bookID); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid book_id"})
+			return
+		}
+	}
+
+	forecast, err := h.srsService.Forecast(c.Request.Context(), userIDStr.(string), req, time.Now())
+	if err != nil {
+		if errors.Is(err, srsservice.ErrInvalidForecast) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid forecast request"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to forecast reviews"})
+		return
+	}
+
+	c.JSON(http.StatusOK, forecast)
+}
@@ -1,31 +1,42 @@
 package handler
 
 import (
+	"encoding/base64"
+	"errors"
 	"net/http"
 	"time"
 
 	"github.com/clearclown/HaiLanGo/backend/internal/models"
 	"github.com/clearclown/HaiLanGo/backend/internal/repository"
-	"github.com/clearclown/HaiLanGo/backend/internal/service"
+	"github.com/clearclown/HaiLanGo/backend/internal/service/notification"
+	srsservice "github.com/clearclown/HaiLanGo/backend/internal/service/srs"
 	"github.com/clearclown/HaiLanGo/backend/internal/websocket"
+	"github.com/clearclown/HaiLanGo/backend/pkg/srs"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
 
 type ReviewHandler struct {
-	repo    repository.ReviewRepository
-	srsAlgo *service.SM2Algorithm
-	wsHub   *websocket.Hub
+	repo       repository.ReviewRepository
+	srsService *srsservice.SRSService
+	wsHub      *websocket.Hub
+	webPush    *notification.WebPushNotifier
 }
 
 func NewReviewHandler(repo repository.ReviewRepository, wsHub *websocket.Hub) *ReviewHandler {
 	return &ReviewHandler{
-		repo:    repo,
-		srsAlgo: service.NewSM2Algorithm(),
-		wsHub:   wsHub,
+		repo:       repo,
+		srsService: srsservice.NewSRSService(repo),
+		wsHub:      wsHub,
 	}
 }
 
+// SetSRSService sets the SRS service shared with the rest of the app
+// (configured with the audio synthesizer and pronunciation evaluator for listening/speaking cards)
+func (h *ReviewHandler) SetSRSService(service *srsservice.SRSService) {
+	h.srsService = service
+}
+
 // GetStats godoc
 // @Summary Get review statistics
 // @Tags review
@@ -42,73 +53,36 @@ func (h *ReviewHandler) GetStats(c *gin.Context) {
 	}
 
 	userID := userIDStr.(string)
+	now := time.Now()
 
-	// 今日の開始時刻
-	todayStart := time.Now().Truncate(24 * time.Hour)
-
-	// すべての復習アイテムを取得
-	items, err := h.repo.FindByUserID(c.Request.Context(), userID)
+	// 優先度別の件数と今日・今週の完了数
+	stats, err := h.srsService.GetStats(c.Request.Context(), userID, now)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch review items"})
 		return
 	}
 
-	stats := models.ReviewStats{
-		UrgentCount:      0,
-		RecommendedCount: 0,
-		OptionalCount:    0,
-	}
-
-	// 優先度別にカウント
-	for _, item := range items {
-		priority := h.srsAlgo.CalculatePriority(item.NextReview)
-		switch priority {
-		case "urgent":
-			stats.UrgentCount++
-		case "recommended":
-			stats.RecommendedCount++
-		case "optional":
-			stats.OptionalCount++
-		}
-	}
-
-	// 今日完了した復習数を取得
-	stats.TotalCompletedToday, err = h.repo.CountCompletedToday(c.Request.Context(), userID, todayStart)
-	if err != nil {
-		stats.TotalCompletedToday = 0
-	}
-
-	// 今週の完了率を計算
-	weekStart := todayStart.Add(-7 * 24 * time.Hour)
-	weeklyCompleted, _ := h.repo.CountCompletedSince(c.Request.Context(), userID, weekStart)
-	weeklyTarget := len(items) * 7 // 1日1回 × 7日
-	if weeklyTarget > 0 {
-		stats.WeeklyCompletionRate = float64(weeklyCompleted) / float64(weeklyTarget) * 100
-	}
-
 	// WebSocket通知: 緊急の復習がある場合に通知
 	if stats.UrgentCount > 0 && h.wsHub != nil {
 		userUUID, err := uuid.Parse(userID)
-		if err == nil {
+		items, itemsErr := h.srsService.GetReviewItems(c.Request.Context(), userID, now)
+		if err == nil && itemsErr == nil {
 			// ReviewReminderMessageを送信
 			wsReviewItems := []websocket.ReviewItem{}
-			for _, item := range items {
-				priority := h.srsAlgo.CalculatePriority(item.NextReview)
-				if priority == "urgent" {
-					// UUIDに変換
-					itemUUID, err := uuid.Parse(item.ID)
-					if err != nil {
-						continue
-					}
-					wsItem := websocket.ReviewItem{
-						ID:          itemUUID,
-						Content:     item.Text,
-						Translation: item.Translation,
-						DueDate:     item.NextReview,
-						Priority:    priority,
-					}
-					wsReviewItems = append(wsReviewItems, wsItem)
+			for _, item := range items.UrgentItems {
+				// UUIDに変換
+				itemUUID, err := uuid.Parse(item.ID)
+				if err != nil {
+					continue
 				}
+				wsItem := websocket.ReviewItem{
+					ID:          itemUUID,
+					Content:     item.Text,
+					Translation: item.Translation,
+					DueDate:     item.NextReview,
+					Priority:    item.Priority,
+				}
+				wsReviewItems = append(wsReviewItems, wsItem)
 			}
 
 			message, err := websocket.NewReviewReminderMessage(
@@ -125,13 +99,14 @@ func (h *ReviewHandler) GetStats(c *gin.Context) {
 }
 
 // GetItems godoc
-// @Summary Get review items by priority
+// @Summary Get today's review queue, or review items by priority
+// @Description Without a priority filter, returns today's session-ordered queue limited by the user's daily caps
 // @Tags review
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param priority query string false "Priority filter (urgent, recommended, optional)"
-// @Success 200 {object} map[string][]models.ReviewItem
+// @Success 200 {object} srsservice.ReviewQueue
 // @Router /api/v1/review/items [get]
 func (h *ReviewHandler) GetItems(c *gin.Context) {
 	userIDStr, exists := c.Get("user_id")
@@ -143,19 +118,34 @@ func (h *ReviewHandler) GetItems(c *gin.Context) {
 	userID := userIDStr.(string)
 	priorityFilter := c.Query("priority")
 
+	// 優先度の指定がない場合は今日の復習セッションの出題順
+	if priorityFilter == "" {
+		queue, err := h.srsService.BuildQueue(c.Request.Context(), userID, time.Now())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build review queue"})
+			return
+		}
+		h.srsService.AttachAudio(c.Request.Context(), queue.Items)
+		c.JSON(http.StatusOK, queue)
+		return
+	}
+
 	items, err := h.repo.FindByUserID(c.Request.Context(), userID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch review items"})
 		return
 	}
 
-	// 優先度でフィルタリング
+	// 優先度と想起確率の予測を設定し、優先度でフィルタリング
+	now := time.Now()
 	var filteredItems []*models.ReviewItem
 	for _, item := range items {
-		priority := h.srsAlgo.CalculatePriority(item.NextReview)
-		item.Priority = priority
+		if item.Suspended {
+			continue
+		}
+		srsservice.Annotate(item, now)
 
-		if priorityFilter == "" || priority == priorityFilter {
+		if item.Priority == priorityFilter {
 			filteredItems = append(filteredItems, item)
 		}
 	}
@@ -191,74 +181,239 @@ func (h *ReviewHandler) SubmitReview(c *gin.Context) {
 
 	userID := userIDStr.(string)
 
-	// 復習アイテムを取得
-	item, err := h.repo.FindByID(c.Request.Context(), result.ItemID)
+	// ユーザーが選択したアルゴリズムで次の復習日時を計算し、習熟度と履歴を更新
+	item, err := h.srsService.CompleteReview(c.Request.Context(), userID, result.ItemID, result.Score, 0)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Review item not found"})
+		switch {
+		case errors.Is(err, repository.ErrReviewItemNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Review item not found"})
+		case errors.Is(err, srsservice.ErrForbidden):
+			c.JSON(http.StatusForbidden, gin.H{"error": "Forbidden"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update review item"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":     true,
+		"next_review": item.NextReview.Format(time.RFC3339),
+		"leech":       item.LeechedAt != nil,
+	})
+}
+
+// SubmitSpeakingReview godoc
+// @Summary Submit a recorded answer for a speaking card
+// @Description Scores the recording with the pronunciation evaluator and uses the total score (0-100) as the review score
+// @Tags review
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param answer body models.SpeakingReviewRequest true "Speaking answer"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/review/submit/speaking [post]
+func (h *ReviewHandler) SubmitSpeakingReview(c *gin.Context) {
+	var req models.SpeakingReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
 		return
 	}
 
-	// 所有権チェック
-	if item.UserID != userID {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Forbidden"})
+	audioData, err := base64.StdEncoding.DecodeString(req.AudioData)
+	if err != nil || len(audioData) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid audio data"})
 		return
 	}
 
-	// SRSアルゴリズムで次の復習日時を計算
-	nextInterval, nextEaseFactor, nextReview := h.srsAlgo.CalculateNextReview(
-		item.EaseFactor,
-		item.IntervalDays,
-		result.Score,
-	)
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
 
-	// 習熟度を更新
-	newMasteryLevel := item.MasteryLevel
-	if result.Score >= 70 {
-		newMasteryLevel += 10
-		if newMasteryLevel > 100 {
-			newMasteryLevel = 100
+	item, score, err := h.srsService.CompleteSpeakingReview(c.Request.Context(), userIDStr.(string), req.ItemID, audioData, req.TimeSpentSec)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrReviewItemNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Review item not found"})
+		case errors.Is(err, srsservice.ErrForbidden):
+			c.JSON(http.StatusForbidden, gin.H{"error": "Forbidden"})
+		case errors.Is(err, srsservice.ErrNotSpeakingCard):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Review item is not a speaking card"})
+		case errors.Is(err, srsservice.ErrPronunciationUnavailable):
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Pronunciation evaluation is not available"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to evaluate pronunciation"})
 		}
-	} else if result.Score < 50 {
-		newMasteryLevel -= 5
-		if newMasteryLevel < 0 {
-			newMasteryLevel = 0
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":     true,
+		"next_review": item.NextReview.Format(time.RFC3339),
+		"leech":       item.LeechedAt != nil,
+		"score":       score,
+	})
+}
+
+// GetSettings godoc
+// @Summary Get review settings
+// @Tags review
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/review/settings [get]
+func (h *ReviewHandler) GetSettings(c *gin.Context) {
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	settings, err := h.srsService.GetSettings(c.Request.Context(), userIDStr.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch review settings"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"settings":   settings,
+		"algorithms": srs.Algorithms(),
+	})
+}
+
+// UpdateSettings godoc
+// @Summary Update review settings (SRS algorithm, daily limits)
+// @Tags review
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param settings body models.UpdateReviewSettingsRequest true "Review settings"
+// @Success 200 {object} models.ReviewSettings
+// @Router /api/v1/review/settings [put]
+func (h *ReviewHandler) UpdateSettings(c *gin.Context) {
+	var req models.UpdateReviewSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	settings, err := h.srsService.UpdateSettings(c.Request.Context(), userIDStr.(string), &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, srs.ErrUnknownAlgorithm):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown algorithm"})
+			return
+		case errors.Is(err, srsservice.ErrInvalidReviewLimit):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid daily review limit"})
+			return
+		case errors.Is(err, srsservice.ErrInvalidLeechSettings):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid leech settings"})
+			return
+		case errors.Is(err, srsservice.ErrInvalidReminderSettings):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid reminder settings"})
+			return
 		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update review settings"})
+		return
 	}
 
-	// アイテムを更新
-	item.MasteryLevel = newMasteryLevel
-	item.IntervalDays = nextInterval
-	item.EaseFactor = nextEaseFactor
-	item.LastReviewed = time.Now()
-	item.NextReview = nextReview
-	item.ReviewCount++
+	c.JSON(http.StatusOK, settings)
+}
 
-	if err := h.repo.Update(c.Request.Context(), item); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update review item"})
+// ImportDeck godoc
+// @Summary Import an Anki deck as review items
+// @Description Imports an Anki package (.apkg) or plain text deck. Each note becomes a learning item with one card per card type;
+// @Description intervals, ease, FSRS state and review logs of matching Anki cards are carried over (text decks import as new cards).
+// @Tags review
+// @Accept multipart/form-data
+// @Produce json
+// @Security BearerAuth
+// @Param file formData file true "Deck file (.apkg, .txt, .tsv, .csv)"
+// @Param format formData string false "Deck format (apkg, text); inferred from the file extension when omitted"
+// @Param language formData string false "Learning language for notes without a Language field"
+// @Param book_id formData string false "Book to attach the imported items to"
+// @Success 200 {object} srsservice.ImportResult
+// @Router /api/v1/review/import [post]
+func (h *ReviewHandler) ImportDeck(c *gin.Context) {
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
 		return
 	}
 
-	// 履歴を保存
-	history := &models.ReviewHistory{
-		ReviewItemID: item.ID,
-		UserID:       userID,
-		Score:        result.Score,
-		ReviewedAt:   time.Now(),
+	opts := srsservice.ImportOptions{
+		Language: c.PostForm("language"),
+		BookID:   c.PostForm("book_id"),
+	}
+	if opts.BookID != "" {
+		if _, err := uuid.Parse(opts.BookID); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid book_id"})
+			return
+		}
 	}
 
-	if err := h.repo.SaveHistory(c.Request.Context(), history); err != nil {
-		// エラーログは出すが、レスポンスは成功を返す
-		c.JSON(http.StatusOK, gin.H{
-			"success":     true,
-			"next_review": nextReview.Format(time.RFC3339),
-		})
+	data, format, ok := readDeckUpload(c)
+	if !ok {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"success":     true,
-		"next_review": nextReview.Format(time.RFC3339),
-	})
+	var result *srsservice.ImportResult
+	var err error
+	if format == deckFormatAnki {
+		result, err = h.srsService.ImportAnkiPackage(c.Request.Context(), userIDStr.(string), data, opts)
+	} else {
+		result, err = h.srsService.ImportTextDeck(c.Request.Context(), userIDStr.(string), data, opts)
+	}
+	if err != nil {
+		writeDeckImportError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// ExportDeck godoc
+// @Summary Export review items as an Anki deck
+// @Description apkg exports one note per learning item with its cards, scheduling and review history; text exports one row per learning item
+// @Tags review
+// @Produce application/zip
+// @Produce text/plain
+// @Security BearerAuth
+// @Param format query string false "Deck format (apkg, text)" default(apkg)
+// @Success 200 {file} file
+// @Router /api/v1/review/export [get]
+func (h *ReviewHandler) ExportDeck(c *gin.Context) {
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	format, ok := exportDeckFormat(c)
+	if !ok {
+		return
+	}
+
+	var data []byte
+	var err error
+	if format == deckFormatAnki {
+		data, err = h.srsService.ExportAnkiPackage(c.Request.Context(), userIDStr.(string))
+	} else {
+		data, err = h.srsService.ExportTextDeck(c.Request.Context(), userIDStr.(string))
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export review items"})
+		return
+	}
+
+	sendDeck(c, data, format, "hailango-review")
 }
 
 // RegisterRoutes registers review routes
@@ -268,5 +423,25 @@ func (h *ReviewHandler) RegisterRoutes(rg *gin.RouterGroup) {
 		review.GET("/stats", h.GetStats)
 		review.GET("/items", h.GetItems)
 		review.POST("/submit", h.SubmitReview)
+		review.POST("/submit/speaking", h.SubmitSpeakingReview)
+		review.GET("/settings", h.GetSettings)
+		review.PUT("/settings", h.UpdateSettings)
+		review.POST("/import", h.ImportDeck)
+		review.GET("/export", h.ExportDeck)
+		review.GET("/forecast", h.GetForecast)
+
+		review.GET("/leeches/:id/remediation", h.GetRemediation)
+		review.DELETE("/leeches/:id", h.ClearLeech)
+
+		review.GET("/reminders/push/key", h.GetPushKey)
+		review.POST("/reminders/push/subscriptions", h.SubscribePush)
+		review.DELETE("/reminders/push/subscriptions", h.UnsubscribePush)
+
+		sessions := review.Group("/sessions")
+		sessions.POST("", h.StartSession)
+		sessions.GET("/:id/next", h.NextCard)
+		sessions.POST("/:id/answer", h.AnswerCard)
+		sessions.POST("/:id/undo", h.UndoAnswer)
+		sessions.POST("/:id/finish", h.FinishSession)
 	}
 }
@@ -3,7 +3,9 @@ package handler
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -11,7 +13,11 @@ import (
 
 	"github.com/clearclown/HaiLanGo/backend/internal/models"
 	"github.com/clearclown/HaiLanGo/backend/internal/repository"
+	"github.com/clearclown/HaiLanGo/backend/internal/service/notification"
+	srsservice "github.com/clearclown/HaiLanGo/backend/internal/service/srs"
 	"github.com/clearclown/HaiLanGo/backend/internal/websocket"
+	"github.com/clearclown/HaiLanGo/backend/pkg/anki"
+	"github.com/clearclown/HaiLanGo/backend/pkg/srs"
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 )
@@ -76,23 +82,58 @@ func TestGetItems(t *testing.T) {
 	// レスポンスを確認
 	assert.Equal(t, http.StatusOK, w.Code)
 
-	var response map[string][]*models.ReviewItem
-	err := json.Unmarshal(w.Body.Bytes(), &response)
+	var queue srsservice.ReviewQueue
+	err := json.Unmarshal(w.Body.Bytes(), &queue)
 	assert.NoError(t, err)
 
-	items := response["items"]
-	assert.NotNil(t, items)
-	assert.Equal(t, 12, len(items)) // 3緊急 + 5推奨 + 4余裕 = 12
+	// 優先度の指定がない場合は今日の出題分（期限切れの3個）だけを返す
+	assert.Equal(t, 3, len(queue.Items))
+	assert.Equal(t, 3, queue.ReviewCount)
+	assert.Equal(t, 0, queue.NewCount)
 
-	t.Logf("Items count: %d", len(items))
+	t.Logf("Items count: %d", len(queue.Items))
 
-	// 優先度が設定されているか確認
-	for _, item := range items {
-		assert.NotEmpty(t, item.Priority)
+	// 優先度と想起確率の予測が設定されているか確認
+	for _, item := range queue.Items {
+		assert.Equal(t, srs.PriorityUrgent, item.Priority)
+		assert.NotNil(t, item.PredictedRetention)
 		t.Logf("Item: %s - %s (priority: %s)", item.Text, item.Translation, item.Priority)
 	}
 }
 
+func TestGetItemsWithDailyLimit(t *testing.T) {
+	router, _ := setupReviewTestRouter()
+
+	// 1日の復習数の上限を2にする
+	req, _ := http.NewRequest("PUT", "/review/settings", bytes.NewBufferString(`{"reviews_per_day":2}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var settings models.ReviewSettings
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &settings))
+	assert.Equal(t, "sm2", settings.Algorithm) // 指定していない項目は変更しない
+	assert.Equal(t, 2, settings.ReviewsPerDay)
+
+	req, _ = http.NewRequest("GET", "/review/items", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var queue srsservice.ReviewQueue
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &queue))
+	assert.Len(t, queue.Items, 2)
+	assert.Equal(t, 1, queue.DeferredReviewCount)
+
+	// 上限は1以上
+	req, _ = http.NewRequest("PUT", "/review/settings", bytes.NewBufferString(`{"reviews_per_day":0}`))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
 func TestGetItemsWithPriorityFilter(t *testing.T) {
 	router, _ := setupReviewTestRouter()
 
@@ -277,3 +318,418 @@ func TestSubmitReview_Unauthorized(t *testing.T) {
 	// レスポンスを確認（403 Forbidden）
 	assert.Equal(t, http.StatusForbidden, w.Code)
 }
+
+func TestReviewSettings(t *testing.T) {
+	router, repo := setupReviewTestRouter()
+
+	// デフォルトはSM-2
+	req, _ := http.NewRequest("GET", "/review/settings", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		Settings   models.ReviewSettings `json:"settings"`
+		Algorithms []string              `json:"algorithms"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "sm2", response.Settings.Algorithm)
+	assert.Contains(t, response.Algorithms, "ladder")
+
+	// 未対応のアルゴリズムは選択できない
+	req, _ = http.NewRequest("PUT", "/review/settings", bytes.NewBufferString(`{"algorithm":"leitner"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// 固定間隔に切り替えると、復習結果の送信でも固定間隔で計算する
+	req, _ = http.NewRequest("PUT", "/review/settings", bytes.NewBufferString(`{"algorithm":"ladder"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	ctx := context.Background()
+	items, err := repo.FindByUserID(ctx, "550e8400-e29b-41d4-a716-446655440001")
+	assert.NoError(t, err)
+	testItem := items[0]
+	reviewCount := testItem.ReviewCount
+
+	body, _ := json.Marshal(models.ReviewResult{ItemID: testItem.ID, Score: 75, CompletedAt: time.Now()})
+	req, _ = http.NewRequest("POST", "/review/submit", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	updatedItem, err := repo.FindByID(ctx, testItem.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, srs.GetBaseInterval(reviewCount), updatedItem.IntervalDays)
+	assert.Equal(t, reviewCount+1, updatedItem.ReviewCount)
+}
+
+// speakingTestEvaluator はテスト用の発音の採点
+type speakingTestEvaluator struct{}
+
+func (speakingTestEvaluator) EvaluatePronunciation(ctx context.Context, expectedText string, audioData []byte, language string) (*models.PronunciationScore, error) {
+	return &models.PronunciationScore{TotalScore: 40, ExpectedText: expectedText}, nil
+}
+
+func TestSubmitSpeakingReview(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+
+	userID := "550e8400-e29b-41d4-a716-446655440001"
+	repo := repository.NewInMemoryReviewRepository()
+	service := srsservice.NewSRSService(repo)
+	handler := NewReviewHandler(repo, websocket.NewHub())
+	handler.SetSRSService(service)
+
+	r.Use(func(c *gin.Context) {
+		c.Set("user_id", userID)
+		c.Next()
+	})
+	handler.RegisterRoutes(r.Group("/"))
+
+	ctx := context.Background()
+	cards, err := service.AddReviewItems(ctx, userID, []*models.ReviewItem{
+		{Type: models.ReviewItemTypeWord, Text: "молоко", Translation: "牛乳", Language: "ru"},
+	})
+	assert.NoError(t, err)
+	var speaking, recognition *models.ReviewItem
+	for _, card := range cards {
+		switch card.CardType {
+		case models.CardTypeSpeaking:
+			speaking = card
+		case models.CardTypeRecognition:
+			recognition = card
+		}
+	}
+
+	submit := func(itemID, audioData string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(models.SpeakingReviewRequest{ItemID: itemID, AudioData: audioData, TimeSpentSec: 6})
+		req, _ := http.NewRequest("POST", "/review/submit/speaking", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	audio := base64.StdEncoding.EncodeToString([]byte("recorded audio"))
+
+	// 発音の採点が未設定
+	assert.Equal(t, http.StatusServiceUnavailable, submit(speaking.ID, audio).Code)
+
+	service.SetPronunciationEvaluator(speakingTestEvaluator{})
+	assert.Equal(t, http.StatusBadRequest, submit(speaking.ID, "not base64!").Code)
+	assert.Equal(t, http.StatusBadRequest, submit(recognition.ID, audio).Code)
+	assert.Equal(t, http.StatusNotFound, submit("missing", audio).Code)
+
+	w := submit(speaking.ID, audio)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response struct {
+		Success bool                      `json:"success"`
+		Score   models.PronunciationScore `json:"score"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.True(t, response.Success)
+	assert.Equal(t, 40, response.Score.TotalScore)
+
+	histories, err := repo.FindHistoryByItemID(ctx, speaking.ID)
+	assert.NoError(t, err)
+	if assert.Len(t, histories, 1) {
+		assert.Equal(t, 40, histories[0].Score)
+	}
+}
+
+// deckUploadRequest はデッキのファイルをアップロードするリクエストを作成する
+func deckUploadRequest(t *testing.T, url, filename string, data []byte, fields map[string]string) *http.Request {
+	t.Helper()
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("file", filename)
+	assert.NoError(t, err)
+	_, err = part.Write(data)
+	assert.NoError(t, err)
+	for key, value := range fields {
+		assert.NoError(t, writer.WriteField(key, value))
+	}
+	assert.NoError(t, writer.Close())
+
+	req, _ := http.NewRequest("POST", url, &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	return req
+}
+
+func TestImportAndExportDeck(t *testing.T) {
+	router, repo := setupReviewTestRouter()
+	userID := "550e8400-e29b-41d4-a716-446655440001"
+	before, err := repo.FindByUserID(context.Background(), userID)
+	assert.NoError(t, err)
+
+	// テキスト形式（拡張子から判定）
+	deck := []byte("#columns:Text\tTranslation\nкнига\t本\n")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, deckUploadRequest(t, "/review/import", "deck.txt", deck, map[string]string{"language": "ru"}))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var result srsservice.ImportResult
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+	assert.Equal(t, 1, result.Notes)
+	assert.Equal(t, len(models.CardTypes), result.Cards)
+
+	after, err := repo.FindByUserID(context.Background(), userID)
+	assert.NoError(t, err)
+	assert.Len(t, after, len(before)+len(models.CardTypes))
+
+	// 不正なパッケージ・形式
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, deckUploadRequest(t, "/review/import", "deck.apkg", []byte("not a zip"), nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, deckUploadRequest(t, "/review/import", "deck.pdf", deck, nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, deckUploadRequest(t, "/review/import", "deck.txt", deck, map[string]string{"book_id": "not-a-uuid"}))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// パッケージの書き出し
+	req, _ := http.NewRequest("GET", "/review/export", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/zip", w.Header().Get("Content-Type"))
+	assert.Equal(t, `attachment; filename="hailango-review.apkg"`, w.Header().Get("Content-Disposition"))
+
+	collection, err := anki.ReadPackage(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+	assert.NoError(t, err)
+	assert.NotEmpty(t, collection.Notes)
+
+	req, _ = http.NewRequest("GET", "/review/export?format=text", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "книга\t本\tword\tru\n")
+
+	req, _ = http.NewRequest("GET", "/review/export?format=xlsx", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestReviewSession(t *testing.T) {
+	router, repo := setupReviewTestRouter()
+
+	post := func(path string, body interface{}) *httptest.ResponseRecorder {
+		var payload []byte
+		if body != nil {
+			payload, _ = json.Marshal(body)
+		}
+		req, _ := http.NewRequest("POST", path, bytes.NewBuffer(payload))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	next := func(sessionID string) (models.ReviewSession, *models.ReviewItem) {
+		req, _ := http.NewRequest("GET", "/review/sessions/"+sessionID+"/next", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response struct {
+			Session models.ReviewSession `json:"session"`
+			Item    *models.ReviewItem   `json:"item"`
+		}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return response.Session, response.Item
+	}
+
+	// セッションを開始
+	w := post("/review/sessions", nil)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var session models.ReviewSession
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &session))
+	assert.NotEmpty(t, session.ID)
+	assert.Greater(t, session.Total, 0)
+
+	// 出題中のカードに回答して取り消す
+	_, card := next(session.ID)
+	assert.NotNil(t, card)
+	w = post("/review/sessions/"+session.ID+"/answer", models.ReviewSessionAnswer{ItemID: card.ID, Score: 90})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	histories, err := repo.FindHistoryByItemID(context.Background(), card.ID)
+	assert.NoError(t, err)
+	assert.Len(t, histories, 1)
+
+	w = post("/review/sessions/"+session.ID+"/undo", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	histories, err = repo.FindHistoryByItemID(context.Background(), card.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, histories)
+
+	w = post("/review/sessions/"+session.ID+"/undo", nil)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	// 取り消したカードをもう一度出題する
+	status, again := next(session.ID)
+	assert.Equal(t, card.ID, again.ID)
+	assert.Equal(t, session.Total, status.Remaining)
+
+	w = post("/review/sessions/"+session.ID+"/answer", models.ReviewSessionAnswer{ItemID: "another-card", Score: 90})
+	assert.Equal(t, http.StatusConflict, w.Code)
+	w = post("/review/sessions/"+session.ID+"/answer", models.ReviewSessionAnswer{ItemID: card.ID, Score: 40})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// セッションを終了すると集計を返し、それ以降は操作できない
+	w = post("/review/sessions/"+session.ID+"/finish", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var summary models.ReviewSessionSummary
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &summary))
+	assert.Equal(t, 1, summary.Reviewed)
+	assert.Equal(t, 0, summary.Correct)
+
+	w = post("/review/sessions/"+session.ID+"/finish", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestLeechRemediation(t *testing.T) {
+	router, repo := setupReviewTestRouter()
+
+	ctx := context.Background()
+	items, err := repo.FindByUserID(ctx, "550e8400-e29b-41d4-a716-446655440001")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, items)
+	testItem := items[0]
+
+	// 忘却を繰り返すとリーチになる
+	leech := false
+	for i := 0; i < srsservice.DefaultLeechThreshold+2 && !leech; i++ {
+		body, _ := json.Marshal(models.ReviewResult{ItemID: testItem.ID, Score: 30, CompletedAt: time.Now()})
+		req, _ := http.NewRequest("POST", "/review/submit", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response map[string]interface{}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		leech, _ = response["leech"].(bool)
+	}
+	assert.True(t, leech)
+
+	// 学び直しの教材を取得
+	req, _ := http.NewRequest("GET", "/review/leeches/"+testItem.ID+"/remediation", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var remediation models.LeechRemediation
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &remediation))
+	assert.Equal(t, testItem.ID, remediation.Item.ID)
+	assert.NotNil(t, remediation.Item.LeechedAt)
+
+	// リーチを解除
+	req, _ = http.NewRequest("DELETE", "/review/leeches/"+testItem.ID, nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	updated, err := repo.FindByID(ctx, testItem.ID)
+	assert.NoError(t, err)
+	assert.Nil(t, updated.LeechedAt)
+	assert.False(t, updated.Suspended)
+
+	req, _ = http.NewRequest("DELETE", "/review/leeches/unknown", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestPushSubscription(t *testing.T) {
+	router, _ := setupReviewTestRouter()
+
+	// Web Pushを設定していない場合は503
+	req, _ := http.NewRequest("GET", "/review/reminders/push/key", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	keys, err := notification.GenerateVAPIDKeys()
+	assert.NoError(t, err)
+	webPush, err := notification.NewWebPushNotifier(notification.WebPushConfig{Keys: keys}, repository.NewInMemoryPushSubscriptionRepository())
+	assert.NoError(t, err)
+
+	gin.SetMode(gin.TestMode)
+	router = gin.New()
+	handler := NewReviewHandler(repository.NewInMemoryReviewRepository(), websocket.NewHub())
+	handler.SetWebPush(webPush)
+	router.Use(func(c *gin.Context) {
+		c.Set("user_id", "550e8400-e29b-41d4-a716-446655440001")
+		c.Next()
+	})
+	handler.RegisterRoutes(router.Group("/"))
+
+	req, _ = http.NewRequest("GET", "/review/reminders/push/key", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), keys.PublicKey)
+
+	// ブラウザの購読を登録・削除
+	browserKeys, err := notification.GenerateVAPIDKeys()
+	assert.NoError(t, err)
+	subscription := map[string]interface{}{
+		"endpoint": "https://push.example.com/send/abc",
+		"keys": map[string]string{
+			"p256dh": browserKeys.PublicKey,
+			"auth":   base64.RawURLEncoding.EncodeToString([]byte("0123456789abcdef")),
+		},
+	}
+	body, _ := json.Marshal(subscription)
+	req, _ = http.NewRequest("POST", "/review/reminders/push/subscriptions", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	subscription["keys"] = map[string]string{"p256dh": "invalid", "auth": "invalid"}
+	body, _ = json.Marshal(subscription)
+	req, _ = http.NewRequest("POST", "/review/reminders/push/subscriptions", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	body, _ = json.Marshal(map[string]string{"endpoint": "https://push.example.com/send/abc"})
+	req, _ = http.NewRequest("DELETE", "/review/reminders/push/subscriptions", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestGetForecast(t *testing.T) {
+	router, _ := setupReviewTestRouter()
+
+	req, _ := http.NewRequest("GET", "/review/forecast?days=7&new_items=3", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var forecast srsservice.Forecast
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &forecast))
+	assert.Len(t, forecast.Days, 7)
+	assert.Equal(t, 3, forecast.AddedItems)
+	assert.Greater(t, forecast.TotalNew, 0.0)
+
+	for _, query := range []string{"days=0", "days=366", "days=abc", "new_items=-1", "book_id=abc"} {
+		req, _ = http.NewRequest("GET", "/review/forecast?"+query, nil)
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+}
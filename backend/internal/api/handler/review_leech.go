@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/clearclown/HaiLanGo/backend/internal/repository"
+	srsservice "github.com/clearclown/HaiLanGo/backend/internal/service/srs"
+	"github.com/gin-gonic/gin"
+)
+
+// GetRemediation godoc
+// @Summary Get re-teaching material for a leech
+// @Description Returns the item's source page (its bilingual phrases), the dictionary entry for words and the book's usage examples for patterns
+// @Tags review
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Review item ID"
+// @Success 200 {object} models.LeechRemediation
+// @Router /api/v1/review/leeches/{id}/remediation [get]
+func (h *ReviewHandler) GetRemediation(c *gin.Context) {
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	remediation, err := h.srsService.Remediation(c.Request.Context(), userIDStr.(string), c.Param("id"))
+	if err != nil {
+		writeLeechError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, remediation)
+}
+
+// ClearLeech godoc
+// @Summary Clear a leech
+// @Description Unsuspends the item and makes it due now; lapses before this point no longer count towards leech detection
+// @Tags review
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Review item ID"
+// @Success 200 {object} models.ReviewItem
+// @Router /api/v1/review/leeches/{id} [delete]
+func (h *ReviewHandler) ClearLeech(c *gin.Context) {
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	item, err := h.srsService.ClearLeech(c.Request.Context(), userIDStr.(string), c.Param("id"), time.Now())
+	if err != nil {
+		writeLeechError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, item)
+}
+
+// writeLeechError maps leech errors to HTTP responses
+func writeLeechError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, repository.ErrReviewItemNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Review item not found"})
+	case errors.Is(err, srsservice.ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Forbidden"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process leech"})
+	}
+}
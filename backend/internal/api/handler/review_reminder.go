@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/clearclown/HaiLanGo/backend/internal/models"
+	"github.com/clearclown/HaiLanGo/backend/internal/repository"
+	"github.com/clearclown/HaiLanGo/backend/internal/service/notification"
+	"github.com/gin-gonic/gin"
+)
+
+// SetWebPush enables Web Push review reminders (push subscription endpoints return 503 without it)
+func (h *ReviewHandler) SetWebPush(webPush *notification.WebPushNotifier) {
+	h.webPush = webPush
+}
+
+// GetPushKey godoc
+// @Summary Get the Web Push application server key
+// @Description Returns the VAPID public key to pass to pushManager.subscribe as applicationServerKey
+// @Tags review
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]string
+// @Failure 503 {object} map[string]string
+// @Router /api/v1/review/reminders/push/key [get]
+func (h *ReviewHandler) GetPushKey(c *gin.Context) {
+	if h.webPush == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Web Push is not configured"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"public_key": h.webPush.PublicKey()})
+}
+
+// SubscribePush godoc
+// @Summary Subscribe a browser to review reminders
+// @Description Saves the browser's push subscription (PushSubscription.toJSON()); reminders are pushed when "push" is one of the reminder channels
+// @Tags review
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param subscription body models.PushSubscriptionRequest true "Push subscription"
+// @Success 201 {object} models.PushSubscription
+// @Failure 400 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 503 {object} map[string]string
+// @Router /api/v1/review/reminders/push/subscriptions [post]
+func (h *ReviewHandler) SubscribePush(c *gin.Context) {
+	var req models.PushSubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	if h.webPush == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Web Push is not configured"})
+		return
+	}
+
+	subscription, err := h.webPush.Subscribe(c.Request.Context(), userIDStr.(string), &req)
+	if errors.Is(err, notification.ErrInvalidSubscription) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid push subscription"})
+		return
+	}
+	if errors.Is(err, repository.ErrPushEndpointInUse) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Push endpoint is subscribed by another user"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save push subscription"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, subscription)
+}
+
+// UnsubscribePush godoc
+// @Summary Unsubscribe a browser from review reminders
+// @Tags review
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param subscription body object true "Endpoint of the subscription" example({"endpoint": "https://push.example.com/..."})
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 503 {object} map[string]string
+// @Router /api/v1/review/reminders/push/subscriptions [delete]
+func (h *ReviewHandler) UnsubscribePush(c *gin.Context) {
+	var req struct {
+		Endpoint string `json:"endpoint" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	if h.webPush == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Web Push is not configured"})
+		return
+	}
+
+	if err := h.webPush.Unsubscribe(c.Request.Context(), userIDStr.(string), req.Endpoint); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete push subscription"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Unsubscribed"})
+}
@@ -0,0 +1,203 @@
+package handler
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/clearclown/HaiLanGo/backend/internal/models"
+	"github.com/clearclown/HaiLanGo/backend/internal/repository"
+	srsservice "github.com/clearclown/HaiLanGo/backend/internal/service/srs"
+	"github.com/gin-gonic/gin"
+)
+
+// StartSession godoc
+// @Summary Start a review session
+// @Description Starts a server-side session over today's review queue. Cards are served one at a time;
+// @Description response time is measured from when a card is served until it is answered.
+// @Tags review
+// @Produce json
+// @Security BearerAuth
+// @Success 201 {object} models.ReviewSession
+// @Router /api/v1/review/sessions [post]
+func (h *ReviewHandler) StartSession(c *gin.Context) {
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	session, err := h.srsService.StartSession(c.Request.Context(), userIDStr.(string), time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start review session"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, session)
+}
+
+// NextCard godoc
+// @Summary Get the current card of a review session
+// @Description Returns the card to answer next; item is null once every card has been answered
+// @Tags review
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Session ID"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/review/sessions/{id}/next [get]
+func (h *ReviewHandler) NextCard(c *gin.Context) {
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	session, item, err := h.srsService.NextCard(c.Request.Context(), userIDStr.(string), c.Param("id"), time.Now())
+	if err != nil {
+		writeSessionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"session": session,
+		"item":    item,
+	})
+}
+
+// AnswerCard godoc
+// @Summary Answer the current card of a review session
+// @Description Speaking cards may send the recording as audio_data instead of a score; it is scored with the pronunciation evaluator
+// @Tags review
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Session ID"
+// @Param answer body models.ReviewSessionAnswer true "Answer"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/review/sessions/{id}/answer [post]
+func (h *ReviewHandler) AnswerCard(c *gin.Context) {
+	var req models.ReviewSessionAnswer
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	userID := userIDStr.(string)
+	now := time.Now()
+
+	var session *models.ReviewSession
+	var item *models.ReviewItem
+	var score *models.PronunciationScore
+	var err error
+	if req.AudioData != "" {
+		audioData, decodeErr := base64.StdEncoding.DecodeString(req.AudioData)
+		if decodeErr != nil || len(audioData) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid audio data"})
+			return
+		}
+		session, item, score, err = h.srsService.AnswerSpeakingCard(ctx, userID, c.Param("id"), req.ItemID, audioData, now)
+	} else {
+		session, item, err = h.srsService.AnswerCard(ctx, userID, c.Param("id"), req.ItemID, req.Score, now)
+	}
+	if err != nil {
+		writeSessionError(c, err)
+		return
+	}
+
+	response := gin.H{
+		"session":     session,
+		"next_review": item.NextReview.Format(time.RFC3339),
+		"leech":       item.LeechedAt != nil,
+	}
+	if score != nil {
+		response["score"] = score
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// UndoAnswer godoc
+// @Summary Undo the last answer of a review session
+// @Description Restores the card's scheduling state, deletes its review history entry and serves the card again
+// @Tags review
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Session ID"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/review/sessions/{id}/undo [post]
+func (h *ReviewHandler) UndoAnswer(c *gin.Context) {
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	session, item, err := h.srsService.UndoAnswer(c.Request.Context(), userIDStr.(string), c.Param("id"), time.Now())
+	if err != nil {
+		writeSessionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"session": session,
+		"item":    item,
+	})
+}
+
+// FinishSession godoc
+// @Summary Finish a review session
+// @Description Ends the session and records its summary (cards, accuracy, duration, response time) in the learning stats
+// @Tags review
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Session ID"
+// @Success 200 {object} models.ReviewSessionSummary
+// @Router /api/v1/review/sessions/{id}/finish [post]
+func (h *ReviewHandler) FinishSession(c *gin.Context) {
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	summary, err := h.srsService.FinishSession(c.Request.Context(), userIDStr.(string), c.Param("id"), time.Now())
+	if err != nil {
+		writeSessionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, summary)
+}
+
+// writeSessionError maps review session errors to HTTP responses
+func writeSessionError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, srsservice.ErrSessionNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Review session not found"})
+	case errors.Is(err, srsservice.ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Forbidden"})
+	case errors.Is(err, repository.ErrReviewItemNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Review item not found"})
+	case errors.Is(err, srsservice.ErrNotCurrentCard):
+		c.JSON(http.StatusConflict, gin.H{"error": "Review item is not the current card"})
+	case errors.Is(err, srsservice.ErrNothingToUndo):
+		c.JSON(http.StatusConflict, gin.H{"error": "Nothing to undo"})
+	case errors.Is(err, srsservice.ErrAnswerChanged):
+		c.JSON(http.StatusConflict, gin.H{"error": "Review item was changed after the answer"})
+	case errors.Is(err, srsservice.ErrSessionBusy):
+		c.JSON(http.StatusConflict, gin.H{"error": "Review session is being updated by another request"})
+	case errors.Is(err, srsservice.ErrNotSpeakingCard):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Review item is not a speaking card"})
+	case errors.Is(err, srsservice.ErrPronunciationUnavailable):
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Pronunciation evaluation is not available"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update review session"})
+	}
+}
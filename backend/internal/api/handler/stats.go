@@ -1,9 +1,11 @@
 package handler
 
 import (
+	"context"
 	"net/http"
 	"strconv"
 
+	"github.com/clearclown/HaiLanGo/backend/internal/models"
 	"github.com/clearclown/HaiLanGo/backend/internal/repository"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -11,7 +13,13 @@ import (
 
 // StatsHandler handles statistics-related HTTP requests
 type StatsHandler struct {
-	repo repository.StatsRepositoryInterface
+	repo    repository.StatsRepositoryInterface
+	leeches LeechLister
+}
+
+// LeechLister lists review items the learner keeps failing (implemented by srs.SRSService)
+type LeechLister interface {
+	Leeches(ctx context.Context, userID string, limit int) ([]models.LeechItem, error)
 }
 
 // NewStatsHandler creates a new stats handler
@@ -21,6 +29,11 @@ func NewStatsHandler(repo repository.StatsRepositoryInterface) *StatsHandler {
 	}
 }
 
+// SetLeechLister sets the source of the leeches listed with the weak points
+func (h *StatsHandler) SetLeechLister(leeches LeechLister) {
+	h.leeches = leeches
+}
+
 // GetDashboard handles GET /api/v1/stats/dashboard
 // @Summary Get dashboard statistics
 // @Description Get overall learning statistics for dashboard
@@ -164,9 +177,57 @@ func (h *StatsHandler) GetWeakPoints(c *gin.Context) {
 		return
 	}
 
+	if h.leeches != nil {
+		leeches, err := h.leeches.Leeches(c.Request.Context(), userID.String(), limit)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get leeches"})
+			return
+		}
+		data.Leeches = leeches
+	}
+
 	c.JSON(http.StatusOK, data)
 }
 
+// GetLeeches handles GET /api/v1/stats/weak-points/leeches
+// @Summary Get leeches
+// @Description Get review items that were failed repeatedly (leeches), most lapses first
+// @Tags stats
+// @Accept json
+// @Produce json
+// @Param limit query int false "Limit (0 for all)" default(0)
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/v1/stats/weak-points/leeches [get]
+func (h *StatsHandler) GetLeeches(c *gin.Context) {
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	limit := 0
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
+			limit = l
+		}
+	}
+
+	leeches := []models.LeechItem{}
+	if h.leeches != nil {
+		var err error
+		leeches, err = h.leeches.Leeches(c.Request.Context(), userIDStr.(string), limit)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get leeches"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"leeches": leeches})
+}
+
 // RegisterRoutes registers stats routes
 func (h *StatsHandler) RegisterRoutes(rg *gin.RouterGroup) {
 	stats := rg.Group("/stats")
@@ -175,5 +236,6 @@ func (h *StatsHandler) RegisterRoutes(rg *gin.RouterGroup) {
 		stats.GET("/learning-time", h.GetLearningTime)
 		stats.GET("/progress", h.GetProgress)
 		stats.GET("/weak-points", h.GetWeakPoints)
+		stats.GET("/weak-points/leeches", h.GetLeeches)
 	}
 }
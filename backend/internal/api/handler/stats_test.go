@@ -1,10 +1,12 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/clearclown/HaiLanGo/backend/internal/models"
 	"github.com/clearclown/HaiLanGo/backend/internal/repository"
@@ -176,6 +178,59 @@ func TestGetWeakPointsDefaultLimit(t *testing.T) {
 	assert.NotNil(t, data.WeakPhrases)
 }
 
+// fakeLeechLister はテスト用のリーチの一覧
+type fakeLeechLister struct {
+	leeches []models.LeechItem
+}
+
+func (f *fakeLeechLister) Leeches(ctx context.Context, userID string, limit int) ([]models.LeechItem, error) {
+	if limit > 0 && len(f.leeches) > limit {
+		return f.leeches[:limit], nil
+	}
+	return f.leeches, nil
+}
+
+func TestGetWeakPointsLeeches(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler := NewStatsHandler(repository.NewInMemoryStatsRepository())
+	handler.SetLeechLister(&fakeLeechLister{leeches: []models.LeechItem{
+		{ItemID: "item-1", Text: "книга", Lapses: 7, Suspended: true, LeechedAt: time.Now()},
+		{ItemID: "item-2", Text: "читать", Lapses: 5, LeechedAt: time.Now()},
+	}})
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("user_id", "550e8400-e29b-41d4-a716-446655440000")
+		c.Next()
+	})
+	handler.RegisterRoutes(r.Group("/api/v1"))
+
+	// 弱点の一覧にリーチを含める
+	req, _ := http.NewRequest(http.MethodGet, "/api/v1/stats/weak-points?limit=1", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var data models.WeakPointsData
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &data))
+	assert.Len(t, data.Leeches, 1)
+	assert.Equal(t, "item-1", data.Leeches[0].ItemID)
+
+	// リーチだけの一覧
+	req, _ = http.NewRequest(http.MethodGet, "/api/v1/stats/weak-points/leeches", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		Leeches []models.LeechItem `json:"leeches"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Len(t, response.Leeches, 2)
+	assert.Equal(t, 7, response.Leeches[0].Lapses)
+}
+
 func TestStatsUnauthorized(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
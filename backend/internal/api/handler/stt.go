@@ -11,7 +11,8 @@ import (
 
 // STTHandler はSTT APIのハンドラー
 type STTHandler struct {
-	repo repository.STTRepositoryInterface
+	repo      repository.STTRepositoryInterface
+	streaming StreamingEvaluator
 }
 
 // NewSTTHandler はSTTハンドラーを作成
@@ -28,6 +29,9 @@ func (h *STTHandler) RegisterRoutes(rg *gin.RouterGroup) {
 		// 音声認識・発音評価
 		stt.POST("/recognize", h.Recognize)
 
+		// ストリーミング音声認識・発音評価（WebSocket）
+		stt.GET("/stream", h.Stream)
+
 		// サポート言語一覧
 		stt.GET("/languages", h.GetLanguages)
 
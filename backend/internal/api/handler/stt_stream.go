@@ -0,0 +1,157 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	sttservice "github.com/clearclown/HaiLanGo/backend/internal/service/stt"
+	"github.com/clearclown/HaiLanGo/backend/internal/websocket"
+	"github.com/gin-gonic/gin"
+	gorillaws "github.com/gorilla/websocket"
+)
+
+// StreamingEvaluator はストリーミングの発音評価を開始する（sttservice.STTService が実装する）
+type StreamingEvaluator interface {
+	StartStreamingEvaluation(ctx context.Context, expectedText, language string, sampleRate int) (*sttservice.StreamingEvaluation, error)
+}
+
+// sttStreamControl はストリーミング認識でクライアントが送るテキストメッセージ
+type sttStreamControl struct {
+	Type string `json:"type"`
+}
+
+// sttStreamEnd は音声の送信を終えるメッセージのタイプ
+const sttStreamEnd = "end"
+
+// SetStreamingEvaluator はストリーミングの発音評価を設定する
+func (h *STTHandler) SetStreamingEvaluator(evaluator StreamingEvaluator) {
+	h.streaming = evaluator
+}
+
+// Stream はWebSocketで音声をストリーミングし、途中の認識結果と最終の発音評価を返す
+// クライアントは16bit・モノラルのPCMのチャンクをバイナリメッセージで送り、{"type":"end"} で送信を終える
+// サーバーは途中結果を stt_interim、最終結果と発音評価を stt_result で送り、接続を閉じる
+// GET /api/v1/stt/stream?language=en&reference_text=...&sample_rate=16000
+func (h *STTHandler) Stream(c *gin.Context) {
+	if _, exists := c.Get("user_id"); !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	if h.streaming == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Streaming recognition is not available"})
+		return
+	}
+
+	language := c.Query("language")
+	referenceText := c.Query("reference_text")
+	if language == "" || referenceText == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "language and reference_text are required"})
+		return
+	}
+
+	sampleRate := 0
+	if value := c.Query("sample_rate"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid sample_rate"})
+			return
+		}
+		sampleRate = parsed
+	}
+
+	// アップグレードの前に検証し、不正なパラメータはHTTPのエラーで返す
+	ctx := c.Request.Context()
+	evaluation, err := h.streaming.StartStreamingEvaluation(ctx, referenceText, language, sampleRate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer evaluation.Close()
+
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		log.Printf("Failed to upgrade to WebSocket: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	var writeMu sync.Mutex
+	write := func(msg websocket.Message, err error) {
+		if err != nil {
+			log.Printf("Failed to create STT stream message: %v", err)
+			return
+		}
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		conn.SetWriteDeadline(time.Now().Add(websocket.WriteWait))
+		if err := conn.WriteJSON(msg); err != nil {
+			log.Printf("Failed to write STT stream message: %v", err)
+		}
+	}
+
+	// 途中結果を送る（認識が終わると Interim が閉じる）
+	interimDone := make(chan struct{})
+	go func() {
+		defer close(interimDone)
+		for text := range evaluation.Interim() {
+			write(websocket.NewSTTInterimMessage(text))
+		}
+	}()
+
+	if !h.receiveAudio(conn, evaluation, write) {
+		evaluation.Close()
+		<-interimDone
+		return
+	}
+
+	result, score, err := evaluation.Finish(ctx)
+	<-interimDone
+	if err != nil {
+		write(websocket.NewErrorMessage("recognition_failed", "Failed to recognize speech", err.Error()))
+	} else {
+		write(websocket.NewSTTResultMessage(result, score))
+	}
+
+	writeMu.Lock()
+	conn.WriteControl(gorillaws.CloseMessage, gorillaws.FormatCloseMessage(gorillaws.CloseNormalClosure, ""), time.Now().Add(websocket.WriteWait))
+	writeMu.Unlock()
+}
+
+// receiveAudio は送信の終わりのメッセージまで音声のチャンクを受け取る
+// 終わりのメッセージを受け取った場合は true、接続が切れたか音声を受け付けられない場合は false を返す
+func (h *STTHandler) receiveAudio(conn *gorillaws.Conn, evaluation *sttservice.StreamingEvaluation, write func(websocket.Message, error)) bool {
+	conn.SetReadLimit(websocket.MaxMessageSize)
+	for {
+		conn.SetReadDeadline(time.Now().Add(websocket.PongWait))
+		messageType, data, err := conn.ReadMessage()
+		if err != nil {
+			var closeErr *gorillaws.CloseError
+			if !errors.As(err, &closeErr) {
+				log.Printf("STT stream read error: %v", err)
+			}
+			return false
+		}
+
+		switch messageType {
+		case gorillaws.BinaryMessage:
+			if err := evaluation.SendAudio(data); err != nil {
+				write(websocket.NewErrorMessage("invalid_audio", "Failed to process audio", err.Error()))
+				return false
+			}
+		case gorillaws.TextMessage:
+			var control sttStreamControl
+			if err := json.Unmarshal(data, &control); err != nil || control.Type != sttStreamEnd {
+				write(websocket.NewErrorMessage("invalid_message", "Unsupported message", string(data)))
+				continue
+			}
+			return true
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	sttservice "github.com/clearclown/HaiLanGo/backend/internal/service/stt"
+	"github.com/clearclown/HaiLanGo/backend/internal/websocket"
+	"github.com/gin-gonic/gin"
+	gorillaws "github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupSTTStreamTestRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	sttHandler := NewSTTHandler(nil)
+	sttHandler.SetStreamingEvaluator(sttservice.NewSTTService())
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("user_id", "550e8400-e29b-41d4-a716-446655440000")
+		c.Next()
+	})
+	sttHandler.RegisterRoutes(r.Group("/api/v1"))
+	return r
+}
+
+// dialSTTStream はストリーミング音声認識のWebSocketに接続する
+func dialSTTStream(t *testing.T, server *httptest.Server, query url.Values) *gorillaws.Conn {
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/v1/stt/stream?" + query.Encode()
+	conn, _, err := gorillaws.DefaultDialer.Dial(wsURL, nil)
+	require.NoError(t, err)
+	return conn
+}
+
+// readSTTStreamMessage はサーバーからのメッセージを1つ読む
+func readSTTStreamMessage(t *testing.T, conn *gorillaws.Conn) websocket.Message {
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	var msg websocket.Message
+	require.NoError(t, conn.ReadJSON(&msg))
+	return msg
+}
+
+// TestSTTStream はストリーミング音声認識・発音評価のテスト
+func TestSTTStream(t *testing.T) {
+	server := httptest.NewServer(setupSTTStreamTestRouter())
+	defer server.Close()
+
+	conn := dialSTTStream(t, server, url.Values{"language": {"en"}, "reference_text": {"Hello world"}})
+	defer conn.Close()
+
+	// 0.1秒ずつ1.5秒分の音声を送る
+	chunk := make([]byte, 3200)
+	for i := 0; i < 15; i++ {
+		require.NoError(t, conn.WriteMessage(gorillaws.BinaryMessage, chunk))
+	}
+	require.NoError(t, conn.WriteMessage(gorillaws.TextMessage, []byte(`{"type":"end"}`)))
+
+	var interims []string
+	var result websocket.STTResultPayload
+	for {
+		msg := readSTTStreamMessage(t, conn)
+		if msg.Type == websocket.MessageTypeSTTInterim {
+			var payload websocket.STTInterimPayload
+			require.NoError(t, json.Unmarshal(msg.Payload, &payload))
+			interims = append(interims, payload.Text)
+			continue
+		}
+		require.Equal(t, websocket.MessageTypeSTTResult, msg.Type)
+		require.NoError(t, json.Unmarshal(msg.Payload, &result))
+		break
+	}
+
+	assert.NotEmpty(t, interims)
+	require.NotNil(t, result.Result)
+	assert.Equal(t, "Hello, world!", result.Result.Text)
+	require.NotNil(t, result.Score)
+	assert.Equal(t, "Hello world", result.Score.ExpectedText)
+
+	// 最終結果の後に接続を閉じる
+	_, _, err := conn.ReadMessage()
+	assert.True(t, gorillaws.IsCloseError(err, gorillaws.CloseNormalClosure))
+}
+
+// TestSTTStream_UnsupportedMessage は送信の終わり以外のテキストメッセージのテスト
+func TestSTTStream_UnsupportedMessage(t *testing.T) {
+	server := httptest.NewServer(setupSTTStreamTestRouter())
+	defer server.Close()
+
+	conn := dialSTTStream(t, server, url.Values{"language": {"en"}, "reference_text": {"Hello"}})
+	defer conn.Close()
+
+	require.NoError(t, conn.WriteMessage(gorillaws.TextMessage, []byte(`{"type":"pause"}`)))
+	msg := readSTTStreamMessage(t, conn)
+	assert.Equal(t, websocket.MessageTypeError, msg.Type)
+
+	// エラーの後も音声を受け付ける
+	require.NoError(t, conn.WriteMessage(gorillaws.BinaryMessage, make([]byte, 32000)))
+	require.NoError(t, conn.WriteMessage(gorillaws.TextMessage, []byte(`{"type":"end"}`)))
+	for msg.Type != websocket.MessageTypeSTTResult {
+		msg = readSTTStreamMessage(t, conn)
+	}
+}
+
+// TestSTTStream_InvalidRequest はアップグレード前に検証するパラメータのテスト
+func TestSTTStream_InvalidRequest(t *testing.T) {
+	router := setupSTTStreamTestRouter()
+
+	tests := []struct {
+		name  string
+		query string
+	}{
+		{"言語なし", "reference_text=Hello"},
+		{"期待されるテキストなし", "language=en"},
+		{"無効な言語", "language=xx&reference_text=Hello"},
+		{"無効なサンプリングレート", "language=en&reference_text=Hello&sample_rate=abc"},
+		{"範囲外のサンプリングレート", "language=en&reference_text=Hello&sample_rate=1000"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, "/api/v1/stt/stream?"+tt.query, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+		})
+	}
+}
+
+// TestSTTStream_Unavailable はストリーミングの発音評価が設定されていない場合のテスト
+func TestSTTStream_Unavailable(t *testing.T) {
+	router, _ := setupSTTTestRouter()
+
+	req, _ := http.NewRequest(http.MethodGet, "/api/v1/stt/stream?language=en&reference_text=Hello", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
@@ -1,11 +1,14 @@
 package handler
 
 import (
+	"context"
 	"fmt"
+	"log"
 	"net/http"
 
 	"github.com/clearclown/HaiLanGo/backend/internal/models"
 	"github.com/clearclown/HaiLanGo/backend/internal/service"
+	ocrservice "github.com/clearclown/HaiLanGo/backend/internal/service/ocr"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
@@ -13,6 +16,7 @@ import (
 // UploadHandler はファイルアップロードのHTTPハンドラー
 type UploadHandler struct {
 	uploadService *service.UploadService
+	pdfIngestor   *ocrservice.PDFIngestor
 }
 
 // NewUploadHandler はUploadHandlerの新しいインスタンスを作成する
@@ -22,6 +26,12 @@ func NewUploadHandler(uploadService *service.UploadService) *UploadHandler {
 	}
 }
 
+// SetPDFIngestor はPDF取り込みサービスを設定する
+// 設定されている場合、アップロードされたPDFはページに分割されOCRに投入される
+func (h *UploadHandler) SetPDFIngestor(ingestor *ocrservice.PDFIngestor) {
+	h.pdfIngestor = ingestor
+}
+
 // CreateBook は新しい書籍を作成するハンドラー
 // POST /api/v1/books
 func (h *UploadHandler) CreateBook(c *gin.Context) {
@@ -64,6 +74,11 @@ func (h *UploadHandler) UploadFiles(c *gin.Context) {
 
 	// TODO: 実際の実装では認証ミドルウェアからユーザーIDを取得
 	userID := uuid.New()
+	if userIDStr, exists := c.Get("user_id"); exists {
+		if parsed, err := uuid.Parse(userIDStr.(string)); err == nil {
+			userID = parsed
+		}
+	}
 
 	// マルチパートフォームを解析
 	form, err := c.MultipartForm()
@@ -93,6 +108,9 @@ func (h *UploadHandler) UploadFiles(c *gin.Context) {
 		return
 	}
 
+	// PDFはOCRジョブキューのワーカーがページに分割してOCRに投入する（進捗はWebSocketで通知）
+	h.enqueuePDFs(c.Request.Context(), userID, bookID, bookFiles)
+
 	c.JSON(http.StatusOK, gin.H{
 		"message": "files uploaded successfully",
 		"files": bookFiles,
@@ -100,6 +118,23 @@ func (h *UploadHandler) UploadFiles(c *gin.Context) {
 	})
 }
 
+// enqueuePDFs はアップロードされたPDFのページ分割をOCRジョブキューに登録する
+// 分割はワーカーが行い、失敗した場合はキューのリトライに任せる
+func (h *UploadHandler) enqueuePDFs(ctx context.Context, userID, bookID uuid.UUID, bookFiles []*models.BookFile) {
+	if h.pdfIngestor == nil {
+		return
+	}
+
+	for _, bookFile := range bookFiles {
+		if bookFile.FileType != "pdf" {
+			continue
+		}
+		if _, err := h.pdfIngestor.EnqueuePDF(ctx, userID, bookID, bookFile.StoragePath, nil); err != nil {
+			log.Printf("failed to enqueue PDF %s: %v", bookFile.StoragePath, err)
+		}
+	}
+}
+
 // GetUploadProgress はアップロード進捗を取得するハンドラー
 // GET /api/v1/books/:book_id/upload-status
 func (h *UploadHandler) GetUploadProgress(c *gin.Context) {
@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/clearclown/HaiLanGo/backend/internal/models"
+	"github.com/clearclown/HaiLanGo/backend/internal/service/vocabulary"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// VocabularyHandler handles vocabulary deck import/export requests
+type VocabularyHandler struct {
+	service vocabulary.VocabularyService
+}
+
+// NewVocabularyHandler creates a new vocabulary handler
+func NewVocabularyHandler(service vocabulary.VocabularyService) *VocabularyHandler {
+	return &VocabularyHandler{
+		service: service,
+	}
+}
+
+// ImportDeck godoc
+// @Summary Import an Anki deck into the vocabulary
+// @Description Imports notes of an Anki package (.apkg) or plain text deck as words. Review counts, average score
+// @Description and last review date are derived from the Anki review log; words already in the vocabulary are skipped.
+// @Tags vocabulary
+// @Accept multipart/form-data
+// @Produce json
+// @Security BearerAuth
+// @Param file formData file true "Deck file (.apkg, .txt, .tsv, .csv)"
+// @Param format formData string false "Deck format (apkg, text); inferred from the file extension when omitted"
+// @Param language formData string false "Language code for notes without a Language field"
+// @Param book_id formData string false "Book to attach the imported words to"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/vocabulary/import [post]
+func (h *VocabularyHandler) ImportDeck(c *gin.Context) {
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	bookID := c.PostForm("book_id")
+	if bookID != "" {
+		if _, err := uuid.Parse(bookID); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid book_id"})
+			return
+		}
+	}
+	language := c.PostForm("language")
+
+	data, format, ok := readDeckUpload(c)
+	if !ok {
+		return
+	}
+
+	var imported int
+	var err error
+	if format == deckFormatAnki {
+		imported, err = h.service.ImportWordsFromAnki(c.Request.Context(), userIDStr.(string), bookID, language, data)
+	} else {
+		imported, err = h.service.ImportWordsFromText(c.Request.Context(), userIDStr.(string), bookID, language, data)
+	}
+	if err != nil {
+		writeDeckImportError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"imported": imported})
+}
+
+// ExportDeck godoc
+// @Summary Export the vocabulary as an Anki deck
+// @Tags vocabulary
+// @Produce application/zip
+// @Produce text/plain
+// @Security BearerAuth
+// @Param format query string false "Deck format (apkg, text)" default(apkg)
+// @Param book_id query string false "Only words of this book"
+// @Param language query string false "Only words of this language"
+// @Success 200 {file} file
+// @Router /api/v1/vocabulary/export [get]
+func (h *VocabularyHandler) ExportDeck(c *gin.Context) {
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	format, ok := exportDeckFormat(c)
+	if !ok {
+		return
+	}
+
+	filter := &models.WordFilter{
+		UserID:   userIDStr.(string),
+		BookID:   c.Query("book_id"),
+		Language: c.Query("language"),
+	}
+
+	var data []byte
+	var err error
+	if format == deckFormatAnki {
+		data, err = h.service.ExportWordsToAnki(c.Request.Context(), filter)
+	} else {
+		data, err = h.service.ExportWordsToText(c.Request.Context(), filter)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export vocabulary"})
+		return
+	}
+
+	sendDeck(c, data, format, "hailango-vocabulary")
+}
+
+// RegisterRoutes registers vocabulary routes
+func (h *VocabularyHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	vocab := rg.Group("/vocabulary")
+	{
+		vocab.POST("/import", h.ImportDeck)
+		vocab.GET("/export", h.ExportDeck)
+	}
+}
@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/clearclown/HaiLanGo/backend/internal/service/vocabulary"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupVocabularyTestRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+
+	handler := NewVocabularyHandler(vocabulary.NewMockVocabularyService())
+
+	// テスト用のミドルウェア：user_idを設定
+	r.Use(func(c *gin.Context) {
+		c.Set("user_id", "550e8400-e29b-41d4-a716-446655440001")
+		c.Next()
+	})
+
+	handler.RegisterRoutes(r.Group("/"))
+
+	return r
+}
+
+func TestVocabularyImportAndExportDeck(t *testing.T) {
+	router := setupVocabularyTestRouter()
+
+	deck := []byte("дом,house\nкот,cat\n")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, deckUploadRequest(t, "/vocabulary/import", "words.csv", deck, map[string]string{"language": "ru"}))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		Imported int `json:"imported"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, 2, response.Imported)
+
+	req, _ := http.NewRequest("GET", "/vocabulary/export?format=text&language=ru", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `attachment; filename="hailango-vocabulary.txt"`, w.Header().Get("Content-Disposition"))
+	assert.Contains(t, w.Body.String(), "дом\thouse\t\t\t\tru\t\n")
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, deckUploadRequest(t, "/vocabulary/import", "words.apkg", []byte("broken"), nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -1,18 +1,29 @@
 package router
 
 import (
+	"context"
 	"database/sql"
 	"log"
+	"os"
+	"time"
 
 	"github.com/clearclown/HaiLanGo/backend/internal/api/handler"
 	"github.com/clearclown/HaiLanGo/backend/internal/api/middleware"
 	"github.com/clearclown/HaiLanGo/backend/internal/repository"
 	"github.com/clearclown/HaiLanGo/backend/internal/service"
+	"github.com/clearclown/HaiLanGo/backend/internal/service/notification"
 	ocrservice "github.com/clearclown/HaiLanGo/backend/internal/service/ocr"
+	srsservice "github.com/clearclown/HaiLanGo/backend/internal/service/srs"
+	sttservice "github.com/clearclown/HaiLanGo/backend/internal/service/stt"
+	ttsservice "github.com/clearclown/HaiLanGo/backend/internal/service/tts"
+	"github.com/clearclown/HaiLanGo/backend/internal/service/vocabulary"
 	"github.com/clearclown/HaiLanGo/backend/internal/websocket"
 	"github.com/clearclown/HaiLanGo/backend/pkg/cache"
+	imageproc "github.com/clearclown/HaiLanGo/backend/pkg/image"
 	"github.com/clearclown/HaiLanGo/backend/pkg/ocr"
+	"github.com/clearclown/HaiLanGo/backend/pkg/pdf"
 	"github.com/clearclown/HaiLanGo/backend/pkg/storage"
+	"github.com/clearclown/HaiLanGo/backend/pkg/translate"
 	"github.com/gin-gonic/gin"
 )
 
@@ -52,33 +63,48 @@ func SetupRouter(
 	var statsRepo repository.StatsRepositoryInterface
 	var learningRepo repository.LearningRepositoryInterface
 	var ocrRepo repository.OCRRepositoryInterface
+	var ocrQueueRepo repository.OCRJobQueueRepository
 	var ttsRepo repository.TTSRepositoryInterface
 	var sttRepo repository.STTRepositoryInterface
 	var paymentRepo repository.PaymentRepositoryInterface
 	var dictionaryRepo repository.DictionaryRepositoryInterface
 	var patternRepo repository.PatternRepositoryInterface
+	var phraseRepo repository.PhraseRepository
+	var ocrCorrectionRepo repository.OCRCorrectionRepository
+	var pushSubscriptionRepo repository.PushSubscriptionRepository
+	var userRepo repository.UserRepository
 
 	if err := db.Ping(); err != nil {
 		log.Println("⚠️  データベース接続失敗 - すべてのリポジトリでInMemory実装を使用します")
 		reviewRepo = repository.NewInMemoryReviewRepository()
 		statsRepo = repository.NewInMemoryStatsRepository()
-		learningRepo = repository.NewInMemoryLearningRepository()
+		phraseRepo = repository.NewInMemoryPhraseRepository()
+		inMemoryLearningRepo := repository.NewInMemoryLearningRepository()
+		inMemoryLearningRepo.SetPhraseRepository(phraseRepo)
+		learningRepo = inMemoryLearningRepo
 		ocrRepo = repository.NewInMemoryOCRRepository()
 		ttsRepo = repository.NewInMemoryTTSRepository()
 		sttRepo = repository.NewInMemorySTTRepository()
 		paymentRepo = repository.NewInMemoryPaymentRepository()
 		dictionaryRepo = repository.NewInMemoryDictionaryRepository()
 		patternRepo = repository.NewInMemoryPatternRepository()
+		ocrCorrectionRepo = repository.NewInMemoryOCRCorrectionRepository()
+		pushSubscriptionRepo = repository.NewInMemoryPushSubscriptionRepository()
 	} else {
 		reviewRepo = repository.NewReviewRepositoryPostgres(db)
 		statsRepo = repository.NewStatsRepository(db)
+		phraseRepo = repository.NewPhraseRepositoryPostgres(db)
 		learningRepo = repository.NewLearningRepositoryPostgres(db)
 		ocrRepo = repository.NewOCRRepositoryPostgres(db)
+		ocrQueueRepo = repository.NewOCRJobQueueRepositoryPostgres(db)
 		ttsRepo = repository.NewTTSRepositoryPostgres(db)
 		sttRepo = repository.NewSTTRepositoryPostgres(db)
 		paymentRepo = repository.NewPaymentRepositoryPostgres(db)
 		dictionaryRepo = repository.NewDictionaryRepositoryPostgres(db)
 		patternRepo = repository.NewPatternRepositoryPostgres(db)
+		ocrCorrectionRepo = repository.NewOCRCorrectionRepositoryPostgres(db)
+		pushSubscriptionRepo = repository.NewPushSubscriptionRepositoryPostgres(db)
+		userRepo = repository.NewUserRepository(db)
 	}
 
 	// 以下はPostgreSQL実装のみ（InMemory実装なし）
@@ -100,8 +126,68 @@ func SetupRouter(
 	ocrSvc := ocrservice.NewOCRService(ocrClient, mockCache)
 	ocrSvc.SetPageRepository(pageRepo)
 
+	// OCR完了時に対訳ページを学習先言語と母国語のフレーズに分割する
+	ocrSvc.SetPhraseRepository(phraseRepo)
+	ocrSvc.SetBookRepository(bookRepo)
+
+	// OCR結果の手動修正から置換ルールを学習し、以降のページのOCR結果に自動で適用する
+	ocrPageStore := ocrservice.NewPageStore(pageRepo)
+	ocrCorrectionLearner := ocrservice.NewCorrectionLearner(ocrPageStore, ocrCorrectionRepo)
+	ocrEditor := ocrservice.NewEditorService(ocrPageStore, ocrCorrectionRepo)
+	ocrEditor.SetCorrectionLearner(ocrCorrectionLearner)
+	ocrSvc.SetCorrectionLearner(ocrCorrectionLearner)
+
+	// OCR前の画像前処理（EXIF回転・傾き補正・トリミング・コントラスト補正）
+	if os.Getenv("OCR_PREPROCESS") != "false" {
+		ocrSvc.SetPreprocessing(imageproc.DefaultPreprocessOptions())
+		ocrSvc.SetPreprocessDebugDir(os.Getenv("OCR_PREPROCESS_DEBUG_DIR"))
+	}
+
+	// 翻訳クライアントの初期化（学習ページ・教師モード・パターン抽出の母国語訳）
+	// 設定が不正な場合にモックの訳を返さないよう、起動を中止する
+	translateClient, err := translate.NewTranslateClient() // 環境変数に基づいて実際のAPIまたはモックを返す
+	if err != nil {
+		panic("Failed to initialize translation client: " + err.Error())
+	}
+	translator := translate.NewCachedClient(translateClient, mockCache, translate.DefaultCacheTTL)
+	teacherModeService.SetTranslator(translator)
+
+	// 復習ログからFSRSのパラメータを定期的に最適化する（SRS_OPTIMIZE_INTERVAL=0 で無効）
+	srsService := srsservice.NewSRSService(reviewRepo)
+	srsOptimizeInterval := srsservice.DefaultOptimizeInterval
+	if value := os.Getenv("SRS_OPTIMIZE_INTERVAL"); value != "" {
+		if interval, err := time.ParseDuration(value); err == nil {
+			srsOptimizeInterval = interval
+		} else {
+			log.Printf("⚠️  SRS_OPTIMIZE_INTERVAL が不正です (%s を使用): %v", srsOptimizeInterval, err)
+		}
+	}
+	if srsOptimizeInterval > 0 {
+		go srsService.RunOptimizer(context.Background(), srsOptimizeInterval)
+	}
+
+	// 聞き取りカードの音声合成と発音カードの採点
+	srsService.SetAudioSynthesizer(ttsservice.NewTTSService())
+	sttService := sttservice.NewSTTService()
+	srsService.SetPronunciationEvaluator(sttService)
+	// Ankiのデッキから取り込んだ音声の保存先
+	srsService.SetMediaStore(storage.NewAudioStorage())
+	// 復習セッションの集計を学習統計に記録
+	srsService.SetSessionRecorder(statsRepo)
+	// リーチの学び直しの教材（元のページ・辞書・パターンの使用例）
+	srsService.SetPhraseRepository(phraseRepo)
+	srsService.SetPatternRepository(patternRepo)
+	srsService.SetDictionary(dictionaryRepo)
+
+	// ページの完了・パターンの練習から復習項目を自動で作成する
+	reviewItemGenerator := srsservice.NewReviewItemGenerator(srsService)
+	reviewItemGenerator.SetPhraseRepository(phraseRepo)
+	reviewItemGenerator.SetPatternRepository(patternRepo)
+
+	// 単語帳（wordsテーブルは未作成のため、単語はメモリ内に保存する）
+	vocabularyService := vocabulary.NewVocabularyService(repository.NewMockWordRepository())
+
 	// statsService := stats.NewService(statsRepo) // TODO: 実装必要
-	// srsService := srs.NewSRSService(reviewRepo) // TODO: 実装必要
 
 	// WebSocketハブを初期化（先に初期化してサービスで使用できるようにする）
 	wsHub := websocket.NewHub()
@@ -111,21 +197,88 @@ func SetupRouter(
 	// OCRサービスにWebSocketハブを設定
 	ocrSvc.SetWebSocketHub(wsHub)
 
+	// 復習リマインダー（WebSocket・メール・Web Push、REVIEW_REMINDER_INTERVAL=0 で無効）
+	reviewURL := os.Getenv("REVIEW_REMINDER_URL")
+	reminderNotifiers := []notification.Notifier{notification.NewHubNotifier(wsHub)}
+	if smtpConfig, ok := notification.SMTPConfigFromEnv(); ok && userRepo != nil {
+		emailNotifier := notification.NewEmailNotifier(smtpConfig, userRepo)
+		emailNotifier.SetReviewURL(reviewURL)
+		reminderNotifiers = append(reminderNotifiers, emailNotifier)
+	}
+	var webPush *notification.WebPushNotifier
+	if webPushConfig, ok := notification.WebPushConfigFromEnv(); ok {
+		webPush, err = notification.NewWebPushNotifier(webPushConfig, pushSubscriptionRepo)
+		if err != nil {
+			log.Printf("⚠️  VAPIDの鍵が不正なためWeb Pushを無効にします: %v", err)
+		} else {
+			webPush.SetReviewURL(reviewURL)
+			reminderNotifiers = append(reminderNotifiers, webPush)
+		}
+	}
+	srsService.SetReminderNotifiers(reminderNotifiers...)
+
+	reminderInterval := srsservice.DefaultReminderInterval
+	if value := os.Getenv("REVIEW_REMINDER_INTERVAL"); value != "" {
+		if interval, err := time.ParseDuration(value); err == nil {
+			reminderInterval = interval
+		} else {
+			log.Printf("⚠️  REVIEW_REMINDER_INTERVAL が不正です (%s を使用): %v", reminderInterval, err)
+		}
+	}
+	if reminderInterval > 0 {
+		go srsService.RunReminders(context.Background(), reminderInterval)
+	}
+
+	// OCRジョブキューのワーカーを起動（再起動時は未完了のジョブから再開する）
+	// ページはPostgreSQLにのみ保存されるため、データベース接続時のみ有効にする
+	var ocrQueue *ocrservice.JobQueue
+	var pdfIngestor *ocrservice.PDFIngestor
+	if ocrQueueRepo != nil {
+		ocrQueue = ocrservice.NewJobQueue(ocrQueueRepo, ocrSvc, localStorage, ocrservice.DefaultQueueConfig())
+
+		// PDFのページ分割（テキストレイヤー抽出・ラスタライズ）もキューのワーカーで行う
+		pdfIngestor = ocrservice.NewPDFIngestor(pdf.NewPopplerExtractor(os.Getenv("POPPLER_PATH")), localStorage, ocrSvc, ocrQueue)
+		pdfIngestor.SetBookRepository(bookRepo)
+		ocrQueue.SetPDFIngestor(pdfIngestor)
+
+		go ocrQueue.Run(context.Background())
+	}
+
 	// ========================================
 	// ハンドラーの初期化
 	// ========================================
 	uploadHandler := handler.NewUploadHandler(uploadService)
+	if pdfIngestor != nil {
+		uploadHandler.SetPDFIngestor(pdfIngestor)
+	}
 	booksHandler := handler.NewBooksHandler(bookRepo, wsHub)
 	reviewHandler := handler.NewReviewHandler(reviewRepo, wsHub)
+	reviewHandler.SetSRSService(srsService)
+	if webPush != nil {
+		reviewHandler.SetWebPush(webPush)
+	}
 	statsHandler := handler.NewStatsHandler(statsRepo)
+	statsHandler.SetLeechLister(srsService)
 	learningHandler := handler.NewLearningHandler(learningRepo)
+	learningHandler.SetTranslator(translator, bookRepo)
+	learningHandler.SetReviewItemGenerator(reviewItemGenerator)
 	ocrHandler := handler.NewOCRHandler(ocrRepo, ocrSvc, wsHub)
+	if ocrQueue != nil {
+		ocrHandler.SetJobQueue(ocrQueue)
+	}
+	ocrHandler.SetBookRepository(bookRepo)
+	ocrHandler.SetCorrectionServices(ocrEditor, ocrCorrectionLearner, pageRepo, bookRepo)
 	ttsHandler := handler.NewTTSHandler(ttsRepo)
 	sttHandler := handler.NewSTTHandler(sttRepo)
+	sttHandler.SetStreamingEvaluator(sttService)
 	paymentHandler := handler.NewPaymentHandler(paymentRepo)
 	dictionaryHandler := handler.NewDictionaryHandler(dictionaryRepo)
 	patternHandler := handler.NewPatternHandler(patternRepo)
+	patternHandler.SetPhraseRepository(phraseRepo)
+	patternHandler.SetTranslator(translator)
+	patternHandler.SetReviewItemGenerator(reviewItemGenerator)
 	teacherModeHandler := handler.NewTeacherModeHandler(teacherModeService)
+	vocabularyHandler := handler.NewVocabularyHandler(vocabularyService)
 
 	// ========================================
 	// ヘルスチェックエンドポイント
@@ -192,6 +345,9 @@ func SetupRouter(
 			// Teacher Mode API
 			teacherModeHandler.RegisterRoutes(authenticated)
 
+			// Vocabulary API
+			vocabularyHandler.RegisterRoutes(authenticated)
+
 			// WebSocket API
 			wsHandler.RegisterRoutes(authenticated)
 
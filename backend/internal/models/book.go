@@ -41,6 +41,8 @@ const (
 	OCRStatusProcessing OCRStatus = "processing"  // 処理中
 	OCRStatusCompleted  OCRStatus = "completed"   // 完了
 	OCRStatusFailed     OCRStatus = "failed"      // 失敗
+	OCRStatusCancelled  OCRStatus = "cancelled"   // キャンセル
+	OCRStatusDeadLetter OCRStatus = "dead_letter" // リトライ上限到達（手動対応待ち）
 )
 
 // BookMetadata は書籍のメタデータを保持する
@@ -97,10 +99,21 @@ type Page struct {
 	DetectedLang  string     `json:"detected_lang" db:"detected_lang"`
 	OCRStatus     OCRStatus  `json:"ocr_status" db:"ocr_status"`
 	OCRError      *string    `json:"ocr_error,omitempty" db:"ocr_error"`
+	OCRLayout     []OCRBlock `json:"ocr_layout,omitempty" db:"ocr_layout"`         // ブロック→行→単語の階層レイアウト
+	OCRProvider   string     `json:"ocr_provider,omitempty" db:"ocr_provider"`     // 採用したOCR結果のプロバイダー
+	CorrectedText *string    `json:"corrected_text,omitempty" db:"corrected_text"` // 手動修正・自動修正後のテキスト（nilの場合はOCR結果のまま）
 	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
 	UpdatedAt     time.Time  `json:"updated_at" db:"updated_at"`
 }
 
+// Text はページの本文（修正済みテキストがあればそれ、なければOCR結果）を返す
+func (p *Page) Text() string {
+	if p.CorrectedText != nil {
+		return *p.CorrectedText
+	}
+	return p.OCRText
+}
+
 // OCRJob はOCR処理ジョブを表すモデル
 type OCRJob struct {
 	ID        uuid.UUID  `json:"id" db:"id"`
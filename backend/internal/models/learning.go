@@ -13,15 +13,17 @@ type PageLearning struct {
 
 // PageWithOCR はOCRデータを含むページ
 type PageWithOCR struct {
-	ID          string `json:"id"`
-	BookID      string `json:"book_id"`
-	PageNumber  int    `json:"page_number"`
-	ImageURL    string `json:"image_url"`
-	OCRText     string `json:"ocr_text"`
-	Translation string `json:"translation"`
-	Language    string `json:"language"`
-	HasAudio    bool   `json:"has_audio"`
-	AudioURL    string `json:"audio_url,omitempty"`
+	ID          string           `json:"id"`
+	BookID      string           `json:"book_id"`
+	PageNumber  int              `json:"page_number"`
+	ImageURL    string           `json:"image_url"`
+	OCRText     string           `json:"ocr_text"`
+	Translation string           `json:"translation"`
+	Language    string           `json:"language"`
+	HasAudio    bool             `json:"has_audio"`
+	AudioURL    string           `json:"audio_url,omitempty"`
+	HasRuby     bool             `json:"has_ruby"`       // ルビ（ふりがな）の有無
+	Ruby        []RubyAnnotation `json:"ruby,omitempty"` // ページテキスト上のルビ注記（ふりがなの表示切り替えに使用）
 }
 
 // PageProgressDetail はページ進捗の詳細
@@ -69,6 +71,8 @@ type CompletePageResponse struct {
 	Message  string             `json:"message"`
 	Progress PageProgressDetail `json:"progress"`
 	NextPage int                `json:"next_page"`
+	// ReviewItemsAdded はページのフレーズから新しく追加した復習項目の数
+	ReviewItemsAdded int `json:"review_items_added"`
 }
 
 // SessionRequest は学習セッションリクエスト
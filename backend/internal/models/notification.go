@@ -65,3 +65,22 @@ type ErrorData struct {
 	Message string `json:"message"`
 	Details string `json:"details,omitempty"`
 }
+
+// PushSubscription represents a browser's Web Push subscription
+type PushSubscription struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"user_id"`
+	Endpoint  string    `json:"endpoint"`
+	P256dh    string    `json:"p256dh"` // base64url-encoded P-256 public key of the browser
+	Auth      string    `json:"auth"`   // base64url-encoded authentication secret
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// PushSubscriptionRequest is the JSON form of PushSubscription.toJSON() in the browser
+type PushSubscriptionRequest struct {
+	Endpoint string `json:"endpoint" binding:"required,url,startswith=https://"`
+	Keys     struct {
+		P256dh string `json:"p256dh" binding:"required"`
+		Auth   string `json:"auth" binding:"required"`
+	} `json:"keys" binding:"required"`
+}
@@ -46,6 +46,7 @@ type OCRResult struct {
 	HasRuby          bool       `json:"has_ruby"`          // ルビ（ふりがな）の有無
 	Orientation      int        `json:"orientation"`       // 画像の向き（度数）
 	ProcessingTime   int        `json:"processing_time"`   // 処理時間（ミリ秒）
+	Provider         string     `json:"provider,omitempty"` // 結果を生成したOCRプロバイダー
 }
 
 // OCRWord は単語レベルのOCR結果
@@ -54,6 +55,23 @@ type OCRWord struct {
 	Confidence  float64     `json:"confidence"`
 	BoundingBox BoundingBox `json:"bounding_box"`
 	Language    string      `json:"language,omitempty"`
+	Ruby        []RubyText  `json:"ruby,omitempty"` // 単語内の漢字に付与されたルビ
+}
+
+// RubyText は単語内の親文字（漢字）とそのルビ（ふりがな・ピンイン）
+type RubyText struct {
+	Base    string `json:"base"`
+	Reading string `json:"reading"`
+	Offset  int    `json:"offset"` // 単語テキスト内での親文字の開始位置（文字単位）
+}
+
+// RubyAnnotation はページテキスト上のルビ注記
+// フロントエンドはOffset・Lengthの範囲にReadingを重ねて表示し、ふりがなの表示を切り替える
+type RubyAnnotation struct {
+	Base    string `json:"base"`
+	Reading string `json:"reading"`
+	Offset  int    `json:"offset"` // ページテキスト内での親文字の開始位置（文字単位）
+	Length  int    `json:"length"` // 親文字の文字数
 }
 
 // OCRLine は行レベルのOCR結果
@@ -81,6 +99,100 @@ type BoundingBox struct {
 	Height int `json:"height"`
 }
 
+// RubyAnnotationsFromLayout はレイアウトの単語に付与されたルビを、ページテキスト上の位置付きの注記に変換する
+// テキストが手動修正されている場合に備え、単語・親文字はテキスト中を先頭から順に検索して位置を決める
+func RubyAnnotationsFromLayout(text string, blocks []OCRBlock) []RubyAnnotation {
+	var annotations []RubyAnnotation
+	runes := []rune(text)
+	cursor := 0
+
+	for _, block := range blocks {
+		for _, line := range block.Lines {
+			for _, word := range line.Words {
+				wordStart := indexRunes(runes, []rune(word.Text), cursor)
+				if wordStart >= 0 {
+					cursor = wordStart + len([]rune(word.Text))
+				}
+
+				for _, ruby := range word.Ruby {
+					base := []rune(ruby.Base)
+					offset := -1
+					if wordStart >= 0 {
+						offset = wordStart + ruby.Offset
+					} else if offset = indexRunes(runes, base, cursor); offset >= 0 {
+						cursor = offset + len(base)
+					}
+					if offset < 0 {
+						continue
+					}
+
+					annotations = append(annotations, RubyAnnotation{
+						Base:    ruby.Base,
+						Reading: ruby.Reading,
+						Offset:  offset,
+						Length:  len(base),
+					})
+				}
+			}
+		}
+	}
+
+	return annotations
+}
+
+// indexRunes はfrom以降で最初にsubが現れる位置（文字単位）を返す。見つからない場合は-1
+func indexRunes(runes, sub []rune, from int) int {
+	if len(sub) == 0 {
+		return -1
+	}
+	for i := from; i+len(sub) <= len(runes); i++ {
+		if string(runes[i:i+len(sub)]) == string(sub) {
+			return i
+		}
+	}
+	return -1
+}
+
+// PageOCRLayoutResponse はページのOCRレイアウトレスポンス
+// 読み上げ中のフレーズをスキャン画像上でハイライトするために使用する
+type PageOCRLayoutResponse struct {
+	PageID     string     `json:"page_id"`
+	BookID     string     `json:"book_id"`
+	PageNumber int        `json:"page_number"`
+	ImageURL   string     `json:"image_url"`
+	Result     *OCRResult `json:"result"`
+}
+
+// NewOCRResultFromLayout はブロック階層からOCRResultを構築する
+// 行・単語はブロックの順序（読み順）でフラット化される
+func NewOCRResultFromLayout(text, language string, confidence float64, blocks []OCRBlock) *OCRResult {
+	result := &OCRResult{
+		Text:             text,
+		DetectedLanguage: language,
+		Confidence:       confidence,
+		Words:            []OCRWord{},
+		Lines:            []OCRLine{},
+		Blocks:           blocks,
+	}
+	if result.Blocks == nil {
+		result.Blocks = []OCRBlock{}
+	}
+
+	for _, block := range blocks {
+		for _, line := range block.Lines {
+			result.Lines = append(result.Lines, line)
+			result.Words = append(result.Words, line.Words...)
+			for _, word := range line.Words {
+				if len(word.Ruby) > 0 {
+					result.HasRuby = true
+				}
+			}
+		}
+	}
+
+	return result
+}
+
 // ProcessPageOCRRequest は特定ページのOCR処理リクエスト
 type ProcessPageOCRRequest struct {
 	PageNumber int        `json:"page_number" binding:"required,min=1"`
@@ -137,18 +249,52 @@ type OCRStatistics struct {
 	TotalProcessingTime int     `json:"total_processing_time"` // ミリ秒
 }
 
+// OCRJobKind は永続ジョブキューのジョブの種類
+type OCRJobKind string
+
+const (
+	OCRJobKindPage OCRJobKind = "page" // 1ページのOCR
+	OCRJobKindPDF  OCRJobKind = "pdf"  // アップロードされたPDFのページ分割（ImageURL はPDFのパス）
+)
+
 // OCRJobRecord はOCR処理ジョブのデータベースレコード
+// 永続ジョブキューの1ページ分のジョブ（またはPDFのページ分割のジョブ）を表す
 type OCRJobRecord struct {
-	ID          uuid.UUID
-	UserID      uuid.UUID
-	BookID      uuid.UUID
-	PageNumber  int
-	ImageURL    string
-	Status      OCRStatus
-	Progress    int
-	ResultJSON  string // JSON化されたOCRResult
-	Error       string
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
-	CompletedAt *time.Time
+	ID                uuid.UUID  `json:"id"`
+	Kind              OCRJobKind `json:"kind"`
+	UserID            uuid.UUID  `json:"user_id"`
+	BookID            uuid.UUID  `json:"book_id"`
+	PageID            uuid.UUID  `json:"page_id"`
+	PageNumber        int        `json:"page_number"`
+	ImageURL          string     `json:"image_url"` // ストレージ上の画像パス
+	Languages         []string   `json:"languages"`
+	DetectOrientation bool       `json:"detect_orientation"` // 前処理で文字の向きを検出して回転補正するか
+	Status            OCRStatus  `json:"status"`
+	Progress          int        `json:"progress"`
+	Attempts          int        `json:"attempts"`     // 取得（実行開始）された回数
+	MaxAttempts       int        `json:"max_attempts"` // この回数に達すると dead_letter になる
+	LockedBy          string     `json:"locked_by,omitempty"`
+	LockedAt          *time.Time `json:"locked_at,omitempty"`
+	NextAttemptAt     time.Time  `json:"next_attempt_at"`
+	ResultJSON        string     `json:"-"` // JSON化されたOCRResult
+	Error             string     `json:"error,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+	CompletedAt       *time.Time `json:"completed_at,omitempty"`
+}
+
+// OCRQueueStatus は書籍単位のOCRジョブキューの状態
+type OCRQueueStatus struct {
+	BookID     string `json:"book_id"`
+	TotalPages int    `json:"total_pages"`
+	Pending    int    `json:"pending"`
+	Processing int    `json:"processing"`
+	Completed  int    `json:"completed"`
+	Cancelled  int    `json:"cancelled"`
+	DeadLetter int    `json:"dead_letter"`
+}
+
+// IsFinished は処理待ち・処理中のジョブが残っていないかを返す
+func (s *OCRQueueStatus) IsFinished() bool {
+	return s.Pending == 0 && s.Processing == 0
 }
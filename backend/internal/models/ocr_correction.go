@@ -36,3 +36,46 @@ type UpdateOCRTextResponse struct {
 	Correction OCRTextCorrection `json:"correction"`
 	Message    string            `json:"message,omitempty"`
 }
+
+// OCRCorrectionRule is a substitution learned from manual OCR corrections
+type OCRCorrectionRule struct {
+	ID          uuid.UUID  `json:"id" db:"id"`
+	UserID      uuid.UUID  `json:"user_id" db:"user_id"`
+	BookID      *uuid.UUID `json:"book_id,omitempty" db:"book_id"` // nil for rules that apply to all of the user's books
+	Original    string     `json:"original" db:"original"`
+	Replacement string     `json:"replacement" db:"replacement"`
+	Occurrences int        `json:"occurrences" db:"occurrences"`
+	Disabled    bool       `json:"disabled" db:"disabled"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// OCRAutoCorrectionStatus represents the review state of an automatic correction
+type OCRAutoCorrectionStatus string
+
+const (
+	OCRAutoCorrectionApplied  OCRAutoCorrectionStatus = "applied"
+	OCRAutoCorrectionAccepted OCRAutoCorrectionStatus = "accepted"
+	OCRAutoCorrectionReverted OCRAutoCorrectionStatus = "reverted"
+)
+
+// OCRAutoCorrection records a learned substitution applied automatically to a page
+type OCRAutoCorrection struct {
+	ID          uuid.UUID               `json:"id" db:"id"`
+	RuleID      uuid.UUID               `json:"rule_id" db:"rule_id"`
+	UserID      uuid.UUID               `json:"user_id" db:"user_id"`
+	BookID      uuid.UUID               `json:"book_id" db:"book_id"`
+	PageID      uuid.UUID               `json:"page_id" db:"page_id"`
+	PageNumber  int                     `json:"page_number" db:"page_number"`
+	Original    string                  `json:"original" db:"original"`
+	Replacement string                  `json:"replacement" db:"replacement"`
+	Offset      int                     `json:"offset" db:"offset"` // rune offset of the replacement in the corrected page text
+	Status      OCRAutoCorrectionStatus `json:"status" db:"status"`
+	CreatedAt   time.Time               `json:"created_at" db:"created_at"`
+	ReviewedAt  *time.Time              `json:"reviewed_at,omitempty" db:"reviewed_at"`
+}
+
+// UndoOCRAutoCorrectionRequest represents a request to undo an automatic correction
+type UndoOCRAutoCorrectionRequest struct {
+	DisableRule bool `json:"disable_rule"` // also stop applying the rule to future pages
+}
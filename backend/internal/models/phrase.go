@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PhraseRecord はページから抽出した対訳フレーズ（学習先言語の文と母国語訳の組）
+// 対訳形式の教材をOCR結果のレイアウトと単語ごとの言語判定から分割して保存する
+type PhraseRecord struct {
+	ID             uuid.UUID `json:"id" db:"id"`
+	BookID         uuid.UUID `json:"book_id" db:"book_id"`
+	PageID         uuid.UUID `json:"page_id" db:"page_id"`
+	PageNumber     int       `json:"page_number" db:"page_number"`
+	Position       int       `json:"position" db:"position"`               // ページ内の順序（0から開始）
+	Text           string    `json:"text" db:"text"`                       // 学習先言語の文
+	Translation    string    `json:"translation" db:"translation"`         // 母国語訳（対応する訳がない場合は空）
+	TargetLanguage string    `json:"target_language" db:"target_language"` // 学習先言語
+	NativeLanguage string    `json:"native_language" db:"native_language"` // 母国語
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+}
+
+// ToPhrase は学習ページ用のフレーズに変換する
+func (p *PhraseRecord) ToPhrase() Phrase {
+	return Phrase{
+		ID:          p.ID.String(),
+		Text:        p.Text,
+		Translation: p.Translation,
+	}
+}
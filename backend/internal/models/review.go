@@ -2,12 +2,33 @@ package models
 
 import "time"
 
+// 復習項目の種類
+const (
+	ReviewItemTypeWord    = "word"
+	ReviewItemTypePhrase  = "phrase"
+	ReviewItemTypePattern = "pattern"
+)
+
+// カードの種類
+// 1つの学習項目（SourceID が共通）から種類ごとにカードを作成し、カードごとに復習の状態と履歴を持つ
+const (
+	CardTypeRecognition = "recognition" // 学習言語 → 母国語
+	CardTypeProduction  = "production"  // 母国語 → 学習言語
+	CardTypeListening   = "listening"   // 音声のみ（音声合成）→ 意味
+	CardTypeSpeaking    = "speaking"    // 母国語 → 発音（音声認識で採点）
+)
+
+// CardTypes はすべてのカードの種類（作成する順）
+var CardTypes = []string{CardTypeRecognition, CardTypeProduction, CardTypeListening, CardTypeSpeaking}
+
 type ReviewItem struct {
 	ID           string    `json:"id"`
 	UserID       string    `json:"user_id"`
 	BookID       string    `json:"book_id"`
 	PageNumber   int       `json:"page_number"`
-	Type         string    `json:"type"` // word, phrase
+	Type         string    `json:"type"`      // word, phrase, pattern
+	CardType     string    `json:"card_type"` // recognition, production, listening, speaking
+	SourceID     string    `json:"source_id"` // 同じ学習項目から作成したカードで共通のID
 	Text         string    `json:"text"`
 	Translation  string    `json:"translation"`
 	Language     string    `json:"language"`
@@ -17,11 +38,27 @@ type ReviewItem struct {
 	LastReviewed time.Time `json:"last_reviewed"`
 	NextReview   time.Time `json:"next_review"`
 	ReviewCount  int       `json:"-"`
-	Priority     string    `json:"priority"` // urgent, recommended, optional
-	CreatedAt    time.Time `json:"-"`
-	UpdatedAt    time.Time `json:"-"`
+	Stability    float64   `json:"-"`                   // FSRSの記憶の安定度（日数）
+	Difficulty   float64   `json:"-"`                   // FSRSの難易度（1-10）
+	Priority     string    `json:"priority"`            // urgent, recommended, optional
+	AudioURL     string    `json:"audio_url,omitempty"` // 聞き取りカードの出題音声（取り込んだ音声がなければ出題時に音声合成する）
+	// PredictedRetention は現時点で思い出せる確率の予測（0-1、未復習の項目は省略）
+	PredictedRetention *float64 `json:"predicted_retention,omitempty"`
+	// Suspended は出題を停止しているか、LeechedAt は何度も忘れる項目（リーチ）と判定した日時
+	Suspended bool       `json:"suspended"`
+	LeechedAt *time.Time `json:"leeched_at,omitempty"`
+	// LeechClearedAt はリーチを解除した日時（それ以前の忘却はリーチの判定に数えない）
+	LeechClearedAt *time.Time `json:"-"`
+	CreatedAt      time.Time  `json:"-"`
+	UpdatedAt      time.Time  `json:"-"`
 }
 
+// リーチ（何度も忘れる項目）の対処
+const (
+	LeechActionSuspend = "suspend" // 出題を停止する
+	LeechActionReteach = "reteach" // 出題を続け、元のページ・辞書・パターンの使用例で学び直す
+)
+
 type ReviewStats struct {
 	UrgentCount          int     `json:"urgent_count"`
 	RecommendedCount     int     `json:"recommended_count"`
@@ -36,10 +73,172 @@ type ReviewResult struct {
 	CompletedAt time.Time `json:"completed_at" binding:"required"`
 }
 
+// SpeakingReviewRequest は発音カードの回答（録音した音声を採点してスコアにする）
+type SpeakingReviewRequest struct {
+	ItemID       string `json:"item_id" binding:"required"`
+	AudioData    string `json:"audio_data" binding:"required"` // 録音した音声（Base64エンコード）
+	TimeSpentSec int    `json:"time_spent_sec" binding:"omitempty,min=0"`
+}
+
 type ReviewHistory struct {
 	ID           string    `json:"id"`
 	ReviewItemID string    `json:"review_item_id"`
 	UserID       string    `json:"user_id"`
 	Score        int       `json:"score"`
+	TimeSpentSec int       `json:"time_spent_sec"`
 	ReviewedAt   time.Time `json:"reviewed_at"`
 }
+
+// ReviewSettings はユーザーごとの復習の設定
+type ReviewSettings struct {
+	UserID    string `json:"user_id"`
+	Algorithm string `json:"algorithm"` // sm2, ladder, fsrs
+	// NewCardsPerDay は1日に新しく学習する項目数の上限
+	NewCardsPerDay int `json:"new_cards_per_day"`
+	// ReviewsPerDay は1日に復習する項目数の上限（新しく学習する項目は含まない）
+	ReviewsPerDay int `json:"reviews_per_day"`
+	// LeechThreshold 回の忘却（スコア50未満）が LeechWindowDays 日以内にあった項目をリーチとし、LeechAction で対処する
+	LeechThreshold  int    `json:"leech_threshold"`
+	LeechWindowDays int    `json:"leech_window_days"`
+	LeechAction     string `json:"leech_action"`
+	// ReminderEnabled のユーザーには、毎日 ReminderTimezone の ReminderTime（HH:MM）に今日の復習の件数を ReminderChannels で通知する
+	ReminderEnabled  bool     `json:"reminder_enabled"`
+	ReminderTime     string   `json:"reminder_time"`
+	ReminderTimezone string   `json:"reminder_timezone"`
+	ReminderChannels []string `json:"reminder_channels"`
+	// RemindedAt は最後にリマインダーを送った日時（1日に1回だけ送る）
+	RemindedAt *time.Time `json:"reminded_at,omitempty"`
+	// FSRSWeights は復習ログから最適化したFSRSの重み（未最適化の場合はデフォルトを使用）
+	FSRSWeights []float64  `json:"fsrs_weights,omitempty"`
+	OptimizedAt *time.Time `json:"optimized_at,omitempty"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// UpdateReviewSettingsRequest は復習の設定の更新リクエスト
+// 省略した項目は変更しない
+type UpdateReviewSettingsRequest struct {
+	Algorithm       string `json:"algorithm"`
+	NewCardsPerDay  *int   `json:"new_cards_per_day" binding:"omitempty,min=0,max=9999"`
+	ReviewsPerDay   *int   `json:"reviews_per_day" binding:"omitempty,min=1,max=9999"`
+	LeechThreshold  *int   `json:"leech_threshold" binding:"omitempty,min=1,max=99"`
+	LeechWindowDays *int   `json:"leech_window_days" binding:"omitempty,min=1,max=3650"`
+	LeechAction     string `json:"leech_action"`
+	// ReminderChannels は nil の場合は変更せず、空の場合はWebSocketだけで通知する
+	ReminderEnabled  *bool    `json:"reminder_enabled"`
+	ReminderTime     string   `json:"reminder_time"`
+	ReminderTimezone string   `json:"reminder_timezone"`
+	ReminderChannels []string `json:"reminder_channels"`
+}
+
+// 復習リマインダーの通知先
+const (
+	ReminderChannelWebSocket = "websocket"
+	ReminderChannelEmail     = "email"
+	ReminderChannelPush      = "push"
+)
+
+// ReviewReminder は復習リマインダーの内容
+type ReviewReminder struct {
+	UserID string `json:"user_id"`
+	// DueCount は今日の復習キューの項目数（NewCount は未学習の項目の数）
+	DueCount int `json:"due_count"`
+	NewCount int `json:"new_count"`
+	// Items は今日最初に出題する項目
+	Items  []*ReviewItem `json:"items"`
+	SentAt time.Time     `json:"sent_at"`
+}
+
+// LeechRemediation はリーチを学び直すための教材
+type LeechRemediation struct {
+	Item *ReviewItem `json:"item"`
+	// Page は項目を作成した本のページ（ページの対訳フレーズ）
+	Page *LeechSourcePage `json:"page,omitempty"`
+	// Dictionary は単語の辞書の項目
+	Dictionary *WordEntry `json:"dictionary,omitempty"`
+	// PatternExamples はパターンの本の中の使用例
+	PatternExamples []PatternExample `json:"pattern_examples,omitempty"`
+}
+
+// LeechSourcePage はリーチの項目を作成した本のページ
+type LeechSourcePage struct {
+	BookID     string          `json:"book_id"`
+	PageNumber int             `json:"page_number"`
+	Phrases    []*PhraseRecord `json:"phrases"`
+}
+
+// ReviewSession は復習セッションの進み具合
+// 出題順・出題した時刻・回答はサーバーで管理し、回答の取り消しと回答時間の記録に使う
+type ReviewSession struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"user_id"`
+	StartedAt time.Time `json:"started_at"`
+	Total     int       `json:"total"`     // 出題する項目の数（取り消した回答は含まない）
+	Reviewed  int       `json:"reviewed"`  // 回答した項目の数
+	Remaining int       `json:"remaining"` // まだ回答していない項目の数
+	CanUndo   bool      `json:"can_undo"`
+}
+
+// ReviewSessionState は進行中の復習セッションの状態
+// どのサーバーからでも続けられるよう、操作のたびにリポジトリに保存する（QueueIDs 以下は JSON で保存）
+type ReviewSessionState struct {
+	ID           string    `json:"-"`
+	UserID       string    `json:"-"`
+	StartedAt    time.Time `json:"-"`
+	LastActiveAt time.Time `json:"-"` // 最後に操作した時刻（期限切れの判定と、出題時刻が分からない場合の回答時間の計算に使う）
+	// QueueIDs は回答していないカードのID（先頭が出題中のカード）
+	QueueIDs []string `json:"queue_ids"`
+	// ShownAt は出題中のカードを出題した時刻（まだ出題していない場合は nil）
+	ShownAt *time.Time `json:"shown_at,omitempty"`
+	// Answers は回答の記録（取り消しは最後の回答から）
+	Answers []ReviewSessionAnswerState `json:"answers"`
+}
+
+// ReviewSessionAnswerState は回答の取り消しに必要な記録
+type ReviewSessionAnswerState struct {
+	ItemID string `json:"item_id"`
+	// Before は回答前の復習の状態、HistoryID は回答で記録した復習履歴
+	Before    ReviewItemState `json:"before"`
+	HistoryID string          `json:"history_id,omitempty"`
+	// UpdatedAt は回答で更新したカードの更新日時（その後に変更されていない場合だけ取り消す）
+	UpdatedAt    time.Time `json:"updated_at"`
+	Score        int       `json:"score"`
+	TimeSpentSec int       `json:"time_spent_sec"`
+}
+
+// ReviewItemState は回答で変わる復習項目の状態
+type ReviewItemState struct {
+	MasteryLevel int        `json:"mastery_level"`
+	IntervalDays int        `json:"interval_days"`
+	EaseFactor   float64    `json:"ease_factor"`
+	ReviewCount  int        `json:"review_count"`
+	LastReviewed time.Time  `json:"last_reviewed"`
+	NextReview   time.Time  `json:"next_review"`
+	Stability    float64    `json:"stability"`
+	Difficulty   float64    `json:"difficulty"`
+	Suspended    bool       `json:"suspended"`
+	LeechedAt    *time.Time `json:"leeched_at,omitempty"`
+}
+
+// ReviewSessionAnswer は復習セッションの回答
+// 発音カードは録音した音声（Base64エンコード）を送ると、採点結果をスコアにする
+type ReviewSessionAnswer struct {
+	ItemID    string `json:"item_id" binding:"required"`
+	Score     int    `json:"score" binding:"min=0,max=100"`
+	AudioData string `json:"audio_data"`
+}
+
+// ReviewSessionSummary は終了した復習セッションの集計
+type ReviewSessionSummary struct {
+	SessionID string `json:"session_id"`
+	UserID    string `json:"user_id"`
+	Reviewed  int    `json:"reviewed"`
+	Correct   int    `json:"correct"` // スコアが70以上（Good・Easy）の回答の数
+	// AccuracyRate は正答率（0-1）
+	AccuracyRate float64 `json:"accuracy_rate"`
+	// DurationSec はセッションの開始から終了までの秒数、TimeSpentSec は回答に要した秒数の合計
+	DurationSec    int       `json:"duration_sec"`
+	TimeSpentSec   int       `json:"time_spent_sec"`
+	AverageTimeSec float64   `json:"average_time_sec"`
+	StartedAt      time.Time `json:"started_at"`
+	FinishedAt     time.Time `json:"finished_at"`
+}
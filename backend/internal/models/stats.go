@@ -149,8 +149,9 @@ type TimeSeriesData struct {
 
 // WeakPointsData は弱点分析データ
 type WeakPointsData struct {
-	WeakWords   []WeakItem `json:"weak_words"`
-	WeakPhrases []WeakItem `json:"weak_phrases"`
+	WeakWords   []WeakItem  `json:"weak_words"`
+	WeakPhrases []WeakItem  `json:"weak_phrases"`
+	Leeches     []LeechItem `json:"leeches"`
 }
 
 type WeakItem struct {
@@ -162,6 +163,20 @@ type WeakItem struct {
 	LastAttempt  time.Time `json:"last_attempt"`
 }
 
+// LeechItem は何度も忘れる復習項目（リーチ）
+type LeechItem struct {
+	ItemID      string    `json:"item_id"`
+	Text        string    `json:"text"`
+	Translation string    `json:"translation"`
+	Type        string    `json:"type"`
+	CardType    string    `json:"card_type"`
+	Language    string    `json:"language"`
+	Lapses      int       `json:"lapses"` // これまでの忘却（スコア50未満）の回数
+	Suspended   bool      `json:"suspended"`
+	LeechedAt   time.Time `json:"leeched_at"`
+	LastLapseAt time.Time `json:"last_lapse_at"`
+}
+
 // UserProgressDaily はユーザーの日次進捗
 type UserProgressDaily struct {
 	ID                      uuid.UUID `json:"id"`
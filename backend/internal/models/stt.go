@@ -13,9 +13,16 @@ type STTResult struct {
 	Confidence float64   `json:"confidence"`  // 認識の信頼度（0.0-1.0）
 	Duration   float64   `json:"duration"`    // 音声の長さ（秒）
 	Words      []WordInfo `json:"words"`      // 単語レベルの情報
+	SpeechSegments []SpeechSegment `json:"speech_segments,omitempty"` // 発話区間（前処理で検出した場合）
 	CreatedAt  time.Time `json:"created_at"`
 }
 
+// SpeechSegment は発話区間を表す（時刻は前処理した音声の先頭からの秒）
+type SpeechSegment struct {
+	Start float64 `json:"start"` // 開始時間（秒）
+	End   float64 `json:"end"`   // 終了時間（秒）
+}
+
 // WordInfo は単語レベルの情報を表す
 type WordInfo struct {
 	Word       string  `json:"word"`       // 単語
@@ -46,6 +53,26 @@ type WordScore struct {
 	IsCorrect      bool    `json:"is_correct"`      // 正しく発音されたか
 	ExpectedWord   string  `json:"expected_word"`   // 期待される単語
 	RecognizedWord string  `json:"recognized_word"` // 認識された単語
+	ErrorType      PronunciationErrorType `json:"error_type,omitempty"`     // 単語の誤りの種類（正しい場合は空）
+	Phonemes       []string               `json:"phonemes,omitempty"`       // 期待される音素列
+	PhonemeErrors  []PhonemeError         `json:"phoneme_errors,omitempty"` // 音素の誤り
+}
+
+// PronunciationErrorType は発音の誤りの種類
+type PronunciationErrorType string
+
+const (
+	PronunciationErrorSubstitution PronunciationErrorType = "substitution" // 置換（別の単語・音素になった）
+	PronunciationErrorDeletion     PronunciationErrorType = "deletion"     // 脱落（発音されなかった）
+	PronunciationErrorInsertion    PronunciationErrorType = "insertion"    // 挿入（余分に発音された）
+)
+
+// PhonemeError は音素の誤りを表す
+type PhonemeError struct {
+	Type     PronunciationErrorType `json:"type"`               // 誤りの種類
+	Expected string                 `json:"expected,omitempty"` // 期待される音素（挿入の場合は空）
+	Actual   string                 `json:"actual,omitempty"`   // 認識された音素（脱落の場合は空）
+	Position int                    `json:"position"`           // 期待される音素列の位置（挿入の場合は直後の音素の位置）
 }
 
 // Feedback は発音評価のフィードバックを表す
@@ -55,6 +82,7 @@ type Feedback struct {
 	PositivePoints  []string `json:"positive_points"`  // 良かった点
 	Improvements    []string `json:"improvements"`     // 改善ポイント
 	SpecificAdvice  []string `json:"specific_advice"`  // 具体的なアドバイス
+	FocusPhonemes   []string `json:"focus_phonemes,omitempty"` // 重点的に練習する音素
 }
 
 // AudioProcessingResult は音声処理の結果を表す
@@ -63,7 +91,11 @@ type AudioProcessingResult struct {
 	SampleRate     int     `json:"sample_rate"`      // サンプリングレート
 	Channels       int     `json:"channels"`         // チャンネル数
 	Duration       float64 `json:"duration"`         // 長さ（秒）
-	NoiseLevel     float64 `json:"noise_level"`      // ノイズレベル
+	NoiseLevel     float64 `json:"noise_level"`      // ノイズレベル（ノイズの音声に対する振幅の比、0-1）
+	SNR            float64 `json:"snr"`              // 信号対雑音比（dB）
+	SpeechSegments []SpeechSegment `json:"speech_segments"` // 発話区間
+	SpeechDuration float64 `json:"speech_duration"`  // 発話区間の合計の長さ（秒）
+	TrimmedStart   float64 `json:"trimmed_start"`    // 先頭から削除した無音の長さ（秒）
 	IsLowQuality   bool    `json:"is_low_quality"`   // 低品質かどうか
 }
 
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 
@@ -23,9 +24,11 @@ type LearningRepositoryInterface interface {
 type InMemoryLearningRepository struct {
 	pages    map[string]*models.PageWithOCR
 	progress map[string]*models.PageProgressRecord // key: userID:bookID:pageNumber
-	phrases  map[string][]models.Phrase             // key: bookID:pageNumber
+	phrases  map[string][]models.Phrase            // key: bookID:pageNumber
 	sessions map[string]*models.SessionResponse
 	mu       sync.RWMutex
+
+	phraseRepo PhraseRepository // OCR結果から分割した対訳フレーズ（設定されている場合はサンプルより優先）
 }
 
 // NewInMemoryLearningRepository は新しいInMemoryLearningRepositoryを作成
@@ -43,6 +46,11 @@ func NewInMemoryLearningRepository() *InMemoryLearningRepository {
 	return repo
 }
 
+// SetPhraseRepository は対訳フレーズのリポジトリを設定する
+func (r *InMemoryLearningRepository) SetPhraseRepository(repo PhraseRepository) {
+	r.phraseRepo = repo
+}
+
 func (r *InMemoryLearningRepository) initSampleData() {
 	testBookID := "550e8400-e29b-41d4-a716-446655440000"
 	testUserID := "550e8400-e29b-41d4-a716-446655440000"
@@ -140,6 +148,15 @@ func (r *InMemoryLearningRepository) GetPageLearning(ctx context.Context, userID
 	// フレーズデータを取得
 	phrasesKey := fmt.Sprintf("%s:%d", bookID.String(), pageNumber)
 	phrases := r.phrases[phrasesKey]
+	if r.phraseRepo != nil {
+		records, err := r.phraseRepo.FindByPage(ctx, bookID, pageNumber)
+		if err != nil {
+			return nil, err
+		}
+		if len(records) > 0 {
+			phrases = phrasesFromRecords(records)
+		}
+	}
 	if phrases == nil {
 		phrases = []models.Phrase{}
 	}
@@ -168,8 +185,13 @@ func (r *InMemoryLearningRepository) GetPageLearning(ctx context.Context, userID
 		CurrentPage: pageNumber,
 	}
 
+	learningPage := *page
+	if learningPage.Translation == "" {
+		learningPage.Translation = joinPhraseTranslations(phrases)
+	}
+
 	return &models.PageLearning{
-		Page:       *page,
+		Page:       learningPage,
 		Progress:   progressDetail,
 		Phrases:    phrases,
 		Vocabulary: vocabulary,
@@ -177,6 +199,26 @@ func (r *InMemoryLearningRepository) GetPageLearning(ctx context.Context, userID
 	}, nil
 }
 
+// phrasesFromRecords は保存された対訳フレーズを学習ページ用のフレーズに変換する
+func phrasesFromRecords(records []*models.PhraseRecord) []models.Phrase {
+	phrases := make([]models.Phrase, 0, len(records))
+	for _, record := range records {
+		phrases = append(phrases, record.ToPhrase())
+	}
+	return phrases
+}
+
+// joinPhraseTranslations はフレーズの訳を連結してページ全体の訳とする
+func joinPhraseTranslations(phrases []models.Phrase) string {
+	translations := make([]string, 0, len(phrases))
+	for _, phrase := range phrases {
+		if phrase.Translation != "" {
+			translations = append(translations, phrase.Translation)
+		}
+	}
+	return strings.Join(translations, "\n")
+}
+
 func (r *InMemoryLearningRepository) CompletePage(ctx context.Context, userID, bookID uuid.UUID, pageNumber int, req *models.CompletePageRequest) (*models.PageProgressDetail, error) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
@@ -324,13 +366,14 @@ func NewLearningRepositoryPostgres(db *sql.DB) LearningRepositoryInterface {
 func (r *LearningRepositoryPostgres) GetPageLearning(ctx context.Context, userID, bookID uuid.UUID, pageNumber int) (*models.PageLearning, error) {
 	// Get page data
 	page := &models.PageWithOCR{}
+	var layout []byte
 	err := r.db.QueryRowContext(ctx, `
-		SELECT id, book_id, page_number, image_url, ocr_text, translation, language, has_audio, audio_url
+		SELECT id, book_id, page_number, image_url, COALESCE(corrected_text, ocr_text), translation, language, has_audio, audio_url, ocr_layout
 		FROM pages
 		WHERE book_id = $1 AND page_number = $2
 	`, bookID, pageNumber).Scan(
 		&page.ID, &page.BookID, &page.PageNumber, &page.ImageURL,
-		&page.OCRText, &page.Translation, &page.Language, &page.HasAudio, &page.AudioURL,
+		&page.OCRText, &page.Translation, &page.Language, &page.HasAudio, &page.AudioURL, &layout,
 	)
 	if err == sql.ErrNoRows {
 		return nil, fmt.Errorf("page not found")
@@ -339,6 +382,14 @@ func (r *LearningRepositoryPostgres) GetPageLearning(ctx context.Context, userID
 		return nil, err
 	}
 
+	// OCRレイアウトに付与されたルビをページテキスト上の注記に変換する
+	blocks, err := unmarshalOCRLayout(layout)
+	if err != nil {
+		return nil, err
+	}
+	page.Ruby = models.RubyAnnotationsFromLayout(page.OCRText, blocks)
+	page.HasRuby = len(page.Ruby) > 0
+
 	// Get progress data
 	progressDetail := models.PageProgressDetail{}
 	var studyTime sql.NullInt64
@@ -369,8 +420,15 @@ func (r *LearningRepositoryPostgres) GetPageLearning(ctx context.Context, userID
 		progressDetail.LastStudiedAt = &lastStudiedAt.Time
 	}
 
-	// Get phrases (mock for now)
-	phrases := []models.Phrase{}
+	// OCR結果から分割した対訳フレーズを取得する
+	records, err := NewPhraseRepositoryPostgres(r.db).FindByPage(ctx, bookID, pageNumber)
+	if err != nil {
+		return nil, err
+	}
+	phrases := phrasesFromRecords(records)
+	if page.Translation == "" {
+		page.Translation = joinPhraseTranslations(phrases)
+	}
 
 	// Get vocabulary (mock for now)
 	vocabulary := []models.VocabularyItem{}
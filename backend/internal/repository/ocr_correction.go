@@ -0,0 +1,429 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"sync"
+
+	"github.com/clearclown/HaiLanGo/backend/internal/models"
+	"github.com/google/uuid"
+)
+
+// OCRCorrectionRepository はOCR結果の手動修正履歴・学習した置換ルール・自動修正の記録のリポジトリ
+type OCRCorrectionRepository interface {
+	// Create は手動修正の履歴を保存する
+	Create(ctx context.Context, correction *models.OCRTextCorrection) error
+	// GetByPageID はページの手動修正の履歴を新しい順に取得する
+	GetByPageID(ctx context.Context, pageID uuid.UUID, limit, offset int) ([]models.OCRTextCorrection, error)
+	// CountByPageID はページの手動修正の件数を返す
+	CountByPageID(ctx context.Context, pageID uuid.UUID) (int, error)
+
+	// FindRules は書籍の置換ルールとユーザー全体の置換ルールを取得する
+	FindRules(ctx context.Context, userID, bookID uuid.UUID) ([]*models.OCRCorrectionRule, error)
+	// GetRule は置換ルールを取得する（存在しない場合は nil）
+	GetRule(ctx context.Context, ruleID uuid.UUID) (*models.OCRCorrectionRule, error)
+	// SaveRule は置換ルールを作成または更新する
+	SaveRule(ctx context.Context, rule *models.OCRCorrectionRule) error
+
+	// ReplacePageAutoCorrections はページの自動修正の記録を置き換える（再OCR時に古い記録を削除する）
+	ReplacePageAutoCorrections(ctx context.Context, pageID uuid.UUID, corrections []*models.OCRAutoCorrection) error
+	// GetAutoCorrection は自動修正を取得する（存在しない場合は nil）
+	GetAutoCorrection(ctx context.Context, correctionID uuid.UUID) (*models.OCRAutoCorrection, error)
+	// UpdateAutoCorrection は自動修正の状態・位置を更新する
+	UpdateAutoCorrection(ctx context.Context, correction *models.OCRAutoCorrection) error
+	// FindAutoCorrectionsByPage はページの自動修正を位置順に取得する
+	FindAutoCorrectionsByPage(ctx context.Context, pageID uuid.UUID) ([]*models.OCRAutoCorrection, error)
+	// FindAutoCorrectionsByBook は書籍の自動修正をページ・位置順に取得する（statusが空の場合はすべて）
+	FindAutoCorrectionsByBook(ctx context.Context, bookID uuid.UUID, status models.OCRAutoCorrectionStatus) ([]*models.OCRAutoCorrection, error)
+}
+
+// InMemoryOCRCorrectionRepository はインメモリのOCR修正リポジトリ
+type InMemoryOCRCorrectionRepository struct {
+	mu              sync.RWMutex
+	corrections     map[uuid.UUID][]models.OCRTextCorrection  // PageID -> 手動修正の履歴
+	rules           map[uuid.UUID]*models.OCRCorrectionRule   // RuleID -> ルール
+	autoCorrections map[uuid.UUID][]*models.OCRAutoCorrection // PageID -> 自動修正
+}
+
+// NewInMemoryOCRCorrectionRepository はインメモリのOCR修正リポジトリを作成する
+func NewInMemoryOCRCorrectionRepository() *InMemoryOCRCorrectionRepository {
+	return &InMemoryOCRCorrectionRepository{
+		corrections:     make(map[uuid.UUID][]models.OCRTextCorrection),
+		rules:           make(map[uuid.UUID]*models.OCRCorrectionRule),
+		autoCorrections: make(map[uuid.UUID][]*models.OCRAutoCorrection),
+	}
+}
+
+func (r *InMemoryOCRCorrectionRepository) Create(ctx context.Context, correction *models.OCRTextCorrection) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.corrections[correction.PageID] = append(r.corrections[correction.PageID], *correction)
+	return nil
+}
+
+func (r *InMemoryOCRCorrectionRepository) GetByPageID(ctx context.Context, pageID uuid.UUID, limit, offset int) ([]models.OCRTextCorrection, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	history := r.corrections[pageID]
+	result := make([]models.OCRTextCorrection, 0, len(history))
+	for i := len(history) - 1; i >= 0; i-- {
+		result = append(result, history[i])
+	}
+
+	if offset >= len(result) {
+		return []models.OCRTextCorrection{}, nil
+	}
+	end := len(result)
+	if limit > 0 && offset+limit < end {
+		end = offset + limit
+	}
+	return result[offset:end], nil
+}
+
+func (r *InMemoryOCRCorrectionRepository) CountByPageID(ctx context.Context, pageID uuid.UUID) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return len(r.corrections[pageID]), nil
+}
+
+func (r *InMemoryOCRCorrectionRepository) FindRules(ctx context.Context, userID, bookID uuid.UUID) ([]*models.OCRCorrectionRule, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var rules []*models.OCRCorrectionRule
+	for _, rule := range r.rules {
+		if rule.UserID != userID || (rule.BookID != nil && *rule.BookID != bookID) {
+			continue
+		}
+		copied := *rule
+		rules = append(rules, &copied)
+	}
+	sort.Slice(rules, func(i, j int) bool {
+		return rules[i].CreatedAt.Before(rules[j].CreatedAt)
+	})
+
+	return rules, nil
+}
+
+func (r *InMemoryOCRCorrectionRepository) GetRule(ctx context.Context, ruleID uuid.UUID) (*models.OCRCorrectionRule, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	rule, ok := r.rules[ruleID]
+	if !ok {
+		return nil, nil
+	}
+	copied := *rule
+	return &copied, nil
+}
+
+func (r *InMemoryOCRCorrectionRepository) SaveRule(ctx context.Context, rule *models.OCRCorrectionRule) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	copied := *rule
+	r.rules[rule.ID] = &copied
+	return nil
+}
+
+func (r *InMemoryOCRCorrectionRepository) ReplacePageAutoCorrections(ctx context.Context, pageID uuid.UUID, corrections []*models.OCRAutoCorrection) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(corrections) == 0 {
+		delete(r.autoCorrections, pageID)
+		return nil
+	}
+
+	stored := make([]*models.OCRAutoCorrection, 0, len(corrections))
+	for _, correction := range corrections {
+		copied := *correction
+		stored = append(stored, &copied)
+	}
+	r.autoCorrections[pageID] = stored
+
+	return nil
+}
+
+func (r *InMemoryOCRCorrectionRepository) GetAutoCorrection(ctx context.Context, correctionID uuid.UUID) (*models.OCRAutoCorrection, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, corrections := range r.autoCorrections {
+		for _, correction := range corrections {
+			if correction.ID == correctionID {
+				copied := *correction
+				return &copied, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+func (r *InMemoryOCRCorrectionRepository) UpdateAutoCorrection(ctx context.Context, correction *models.OCRAutoCorrection) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, stored := range r.autoCorrections[correction.PageID] {
+		if stored.ID == correction.ID {
+			stored.Offset = correction.Offset
+			stored.Status = correction.Status
+			stored.ReviewedAt = correction.ReviewedAt
+			return nil
+		}
+	}
+	return nil
+}
+
+func (r *InMemoryOCRCorrectionRepository) FindAutoCorrectionsByPage(ctx context.Context, pageID uuid.UUID) ([]*models.OCRAutoCorrection, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var result []*models.OCRAutoCorrection
+	for _, correction := range r.autoCorrections[pageID] {
+		copied := *correction
+		result = append(result, &copied)
+	}
+	sortAutoCorrections(result)
+
+	return result, nil
+}
+
+func (r *InMemoryOCRCorrectionRepository) FindAutoCorrectionsByBook(ctx context.Context, bookID uuid.UUID, status models.OCRAutoCorrectionStatus) ([]*models.OCRAutoCorrection, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var result []*models.OCRAutoCorrection
+	for _, corrections := range r.autoCorrections {
+		for _, correction := range corrections {
+			if correction.BookID != bookID || (status != "" && correction.Status != status) {
+				continue
+			}
+			copied := *correction
+			result = append(result, &copied)
+		}
+	}
+	sortAutoCorrections(result)
+
+	return result, nil
+}
+
+// sortAutoCorrections は自動修正をページ番号・位置の順に並べる
+func sortAutoCorrections(corrections []*models.OCRAutoCorrection) {
+	sort.Slice(corrections, func(i, j int) bool {
+		if corrections[i].PageNumber != corrections[j].PageNumber {
+			return corrections[i].PageNumber < corrections[j].PageNumber
+		}
+		return corrections[i].Offset < corrections[j].Offset
+	})
+}
+
+// PostgreSQL Implementation
+
+type ocrCorrectionRepositoryPostgres struct {
+	db *sql.DB
+}
+
+// NewOCRCorrectionRepositoryPostgres はPostgreSQL実装のOCR修正リポジトリを作成する
+func NewOCRCorrectionRepositoryPostgres(db *sql.DB) OCRCorrectionRepository {
+	return &ocrCorrectionRepositoryPostgres{db: db}
+}
+
+func (r *ocrCorrectionRepositoryPostgres) Create(ctx context.Context, correction *models.OCRTextCorrection) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO ocr_text_corrections (id, book_id, page_id, user_id, original_text, corrected_text, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`, correction.ID, correction.BookID, correction.PageID, correction.UserID,
+		correction.OriginalText, correction.CorrectedText, correction.CreatedAt, correction.UpdatedAt)
+	return err
+}
+
+func (r *ocrCorrectionRepositoryPostgres) GetByPageID(ctx context.Context, pageID uuid.UUID, limit, offset int) ([]models.OCRTextCorrection, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, book_id, page_id, user_id, original_text, corrected_text, created_at, updated_at
+		FROM ocr_text_corrections
+		WHERE page_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2 OFFSET $3
+	`, pageID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	corrections := []models.OCRTextCorrection{}
+	for rows.Next() {
+		var c models.OCRTextCorrection
+		if err := rows.Scan(
+			&c.ID, &c.BookID, &c.PageID, &c.UserID, &c.OriginalText, &c.CorrectedText, &c.CreatedAt, &c.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		corrections = append(corrections, c)
+	}
+
+	return corrections, rows.Err()
+}
+
+func (r *ocrCorrectionRepositoryPostgres) CountByPageID(ctx context.Context, pageID uuid.UUID) (int, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM ocr_text_corrections WHERE page_id = $1`, pageID).Scan(&count)
+	return count, err
+}
+
+const ocrCorrectionRuleColumns = `id, user_id, book_id, original, replacement, occurrences, disabled, created_at, updated_at`
+
+func scanOCRCorrectionRule(scanner interface{ Scan(dest ...any) error }) (*models.OCRCorrectionRule, error) {
+	rule := &models.OCRCorrectionRule{}
+	var bookID uuid.NullUUID
+	if err := scanner.Scan(
+		&rule.ID, &rule.UserID, &bookID, &rule.Original, &rule.Replacement,
+		&rule.Occurrences, &rule.Disabled, &rule.CreatedAt, &rule.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	if bookID.Valid {
+		rule.BookID = &bookID.UUID
+	}
+	return rule, nil
+}
+
+func (r *ocrCorrectionRepositoryPostgres) FindRules(ctx context.Context, userID, bookID uuid.UUID) ([]*models.OCRCorrectionRule, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+ocrCorrectionRuleColumns+`
+		FROM ocr_correction_rules
+		WHERE user_id = $1 AND (book_id = $2 OR book_id IS NULL)
+		ORDER BY created_at
+	`, userID, bookID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rules []*models.OCRCorrectionRule
+	for rows.Next() {
+		rule, err := scanOCRCorrectionRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+
+	return rules, rows.Err()
+}
+
+func (r *ocrCorrectionRepositoryPostgres) GetRule(ctx context.Context, ruleID uuid.UUID) (*models.OCRCorrectionRule, error) {
+	rule, err := scanOCRCorrectionRule(r.db.QueryRowContext(ctx, `
+		SELECT `+ocrCorrectionRuleColumns+`
+		FROM ocr_correction_rules
+		WHERE id = $1
+	`, ruleID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return rule, err
+}
+
+func (r *ocrCorrectionRepositoryPostgres) SaveRule(ctx context.Context, rule *models.OCRCorrectionRule) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO ocr_correction_rules (`+ocrCorrectionRuleColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (id) DO UPDATE
+		SET replacement = EXCLUDED.replacement, occurrences = EXCLUDED.occurrences,
+		    disabled = EXCLUDED.disabled, updated_at = EXCLUDED.updated_at
+	`, rule.ID, rule.UserID, rule.BookID, rule.Original, rule.Replacement,
+		rule.Occurrences, rule.Disabled, rule.CreatedAt, rule.UpdatedAt)
+	return err
+}
+
+const ocrAutoCorrectionColumns = `id, rule_id, user_id, book_id, page_id, page_number, original, replacement, "offset", status, created_at, reviewed_at`
+
+func (r *ocrCorrectionRepositoryPostgres) ReplacePageAutoCorrections(ctx context.Context, pageID uuid.UUID, corrections []*models.OCRAutoCorrection) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM ocr_auto_corrections WHERE page_id = $1`, pageID); err != nil {
+		return err
+	}
+
+	for _, c := range corrections {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO ocr_auto_corrections (`+ocrAutoCorrectionColumns+`)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		`, c.ID, c.RuleID, c.UserID, c.BookID, pageID, c.PageNumber, c.Original, c.Replacement,
+			c.Offset, c.Status, c.CreatedAt, c.ReviewedAt)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *ocrCorrectionRepositoryPostgres) GetAutoCorrection(ctx context.Context, correctionID uuid.UUID) (*models.OCRAutoCorrection, error) {
+	corrections, err := r.queryAutoCorrections(ctx, `
+		SELECT `+ocrAutoCorrectionColumns+`
+		FROM ocr_auto_corrections
+		WHERE id = $1
+	`, correctionID)
+	if err != nil || len(corrections) == 0 {
+		return nil, err
+	}
+	return corrections[0], nil
+}
+
+func (r *ocrCorrectionRepositoryPostgres) UpdateAutoCorrection(ctx context.Context, correction *models.OCRAutoCorrection) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE ocr_auto_corrections
+		SET "offset" = $1, status = $2, reviewed_at = $3
+		WHERE id = $4
+	`, correction.Offset, correction.Status, correction.ReviewedAt, correction.ID)
+	return err
+}
+
+func (r *ocrCorrectionRepositoryPostgres) FindAutoCorrectionsByPage(ctx context.Context, pageID uuid.UUID) ([]*models.OCRAutoCorrection, error) {
+	return r.queryAutoCorrections(ctx, `
+		SELECT `+ocrAutoCorrectionColumns+`
+		FROM ocr_auto_corrections
+		WHERE page_id = $1
+		ORDER BY "offset"
+	`, pageID)
+}
+
+func (r *ocrCorrectionRepositoryPostgres) FindAutoCorrectionsByBook(ctx context.Context, bookID uuid.UUID, status models.OCRAutoCorrectionStatus) ([]*models.OCRAutoCorrection, error) {
+	return r.queryAutoCorrections(ctx, `
+		SELECT `+ocrAutoCorrectionColumns+`
+		FROM ocr_auto_corrections
+		WHERE book_id = $1 AND ($2 = '' OR status = $2)
+		ORDER BY page_number, "offset"
+	`, bookID, string(status))
+}
+
+func (r *ocrCorrectionRepositoryPostgres) queryAutoCorrections(ctx context.Context, query string, args ...any) ([]*models.OCRAutoCorrection, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var corrections []*models.OCRAutoCorrection
+	for rows.Next() {
+		c := &models.OCRAutoCorrection{}
+		if err := rows.Scan(
+			&c.ID, &c.RuleID, &c.UserID, &c.BookID, &c.PageID, &c.PageNumber, &c.Original, &c.Replacement,
+			&c.Offset, &c.Status, &c.CreatedAt, &c.ReviewedAt,
+		); err != nil {
+			return nil, err
+		}
+		corrections = append(corrections, c)
+	}
+
+	return corrections, rows.Err()
+}
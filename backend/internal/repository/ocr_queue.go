@@ -0,0 +1,740 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/clearclown/HaiLanGo/backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+var (
+	// ErrOCRJobNotFound はOCRジョブが見つからないエラー
+	ErrOCRJobNotFound = errors.New("ocr job not found")
+	// ErrOCRJobNotOwned はジョブが既に他のワーカーに移った、またはキャンセルされたエラー
+	ErrOCRJobNotOwned = errors.New("ocr job is no longer owned by this worker")
+)
+
+// OCRJobQueueRepository は永続OCRジョブキューのリポジトリインターフェース
+type OCRJobQueueRepository interface {
+	// Enqueue はジョブを登録する
+	// 同じページに処理待ち・処理中・完了済みのジョブがある場合はスキップし、登録したジョブのみを返す
+	// （PDFのページ分割のジョブは重複を確認せずに登録する）
+	Enqueue(ctx context.Context, jobs []*models.OCRJobRecord) ([]*models.OCRJobRecord, error)
+
+	// Claim は実行可能なジョブを最大limit件取得し、workerIDでロックする
+	// リース期限切れの処理中ジョブ（クラッシュしたワーカーのジョブ）も再取得の対象とする
+	Claim(ctx context.Context, workerID string, limit int, leaseTimeout time.Duration) ([]*models.OCRJobRecord, error)
+
+	// ExtendLease は処理中のジョブのリースを延長する（長い処理を他のワーカーに再取得されないようにする）
+	// ジョブが既に他のワーカーに移った、またはキャンセルされた場合は ErrOCRJobNotOwned を返す
+	ExtendLease(ctx context.Context, jobID uuid.UUID, workerID string) error
+
+	// Complete はジョブを完了にし、結果を保存する
+	Complete(ctx context.Context, jobID uuid.UUID, workerID string, result *models.OCRResult) error
+
+	// Fail はジョブの失敗を記録する
+	// 試行回数が上限に達した場合は dead_letter、それ以外は nextAttemptAt 以降に再実行される
+	Fail(ctx context.Context, jobID uuid.UUID, workerID string, errMsg string, nextAttemptAt time.Time) (models.OCRStatus, error)
+
+	// Release は試行回数を消費せずにジョブを処理待ちに戻す（グレースフルシャットダウン用）
+	Release(ctx context.Context, jobID uuid.UUID, workerID string) error
+
+	// CancelBook は書籍の処理待ち・処理中ジョブをキャンセルする
+	CancelBook(ctx context.Context, bookID uuid.UUID) (int, error)
+
+	// Requeue はキャンセル・dead_letter のジョブを試行回数をリセットして処理待ちに戻す
+	Requeue(ctx context.Context, jobID uuid.UUID) error
+
+	// GetRecord はジョブを取得する
+	GetRecord(ctx context.Context, jobID uuid.UUID) (*models.OCRJobRecord, error)
+
+	// ListByStatus は書籍のジョブをステータスで絞り込んで取得する
+	ListByStatus(ctx context.Context, bookID uuid.UUID, status models.OCRStatus) ([]*models.OCRJobRecord, error)
+
+	// GetQueueStatus は書籍のジョブキューの状態を集計する
+	GetQueueStatus(ctx context.Context, bookID uuid.UUID) (*models.OCRQueueStatus, error)
+}
+
+// isActiveOCRStatus は再登録をスキップすべきステータスかを判定する
+func isActiveOCRStatus(status models.OCRStatus) bool {
+	return status == models.OCRStatusPending ||
+		status == models.OCRStatusProcessing ||
+		status == models.OCRStatusCompleted
+}
+
+// InMemoryOCRJobQueueRepository はインメモリOCRジョブキュー
+type InMemoryOCRJobQueueRepository struct {
+	mu   sync.Mutex
+	jobs map[uuid.UUID]*models.OCRJobRecord
+}
+
+// NewInMemoryOCRJobQueueRepository はインメモリOCRジョブキューを作成
+func NewInMemoryOCRJobQueueRepository() *InMemoryOCRJobQueueRepository {
+	return &InMemoryOCRJobQueueRepository{
+		jobs: make(map[uuid.UUID]*models.OCRJobRecord),
+	}
+}
+
+func (r *InMemoryOCRJobQueueRepository) Enqueue(ctx context.Context, jobs []*models.OCRJobRecord) ([]*models.OCRJobRecord, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	enqueued := make([]*models.OCRJobRecord, 0, len(jobs))
+	for _, job := range jobs {
+		if job.Kind != models.OCRJobKindPDF && r.hasActiveJobLocked(job.PageID) {
+			continue
+		}
+
+		stored := *job
+		if stored.Kind == "" {
+			stored.Kind = models.OCRJobKindPage
+		}
+		r.jobs[stored.ID] = &stored
+		copied := stored
+		enqueued = append(enqueued, &copied)
+	}
+
+	return enqueued, nil
+}
+
+func (r *InMemoryOCRJobQueueRepository) hasActiveJobLocked(pageID uuid.UUID) bool {
+	for _, existing := range r.jobs {
+		if existing.PageID == pageID && isActiveOCRStatus(existing.Status) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *InMemoryOCRJobQueueRepository) Claim(ctx context.Context, workerID string, limit int, leaseTimeout time.Duration) ([]*models.OCRJobRecord, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	cutoff := now.Add(-leaseTimeout)
+
+	candidates := make([]*models.OCRJobRecord, 0)
+	for _, job := range r.jobs {
+		expired := job.Status == models.OCRStatusProcessing && job.LockedAt != nil && job.LockedAt.Before(cutoff)
+
+		// リース期限切れかつ試行回数の上限に達したジョブは dead_letter へ
+		if expired && job.Attempts >= job.MaxAttempts {
+			job.Status = models.OCRStatusDeadLetter
+			job.Error = "lease expired after max attempts"
+			job.LockedBy = ""
+			job.LockedAt = nil
+			job.UpdatedAt = now
+			job.CompletedAt = &now
+			continue
+		}
+
+		ready := job.Status == models.OCRStatusPending && !job.NextAttemptAt.After(now)
+		if ready || expired {
+			candidates = append(candidates, job)
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if !candidates[i].CreatedAt.Equal(candidates[j].CreatedAt) {
+			return candidates[i].CreatedAt.Before(candidates[j].CreatedAt)
+		}
+		return candidates[i].PageNumber < candidates[j].PageNumber
+	})
+
+	if limit > 0 && len(candidates) > limit {
+		candidates = candidates[:limit]
+	}
+
+	claimed := make([]*models.OCRJobRecord, 0, len(candidates))
+	for _, job := range candidates {
+		lockedAt := now
+		job.Status = models.OCRStatusProcessing
+		job.Progress = 10
+		job.Attempts++
+		job.LockedBy = workerID
+		job.LockedAt = &lockedAt
+		job.UpdatedAt = now
+
+		copied := *job
+		claimed = append(claimed, &copied)
+	}
+
+	return claimed, nil
+}
+
+// ownedLocked はworkerIDが処理中としてロックしているジョブを返す
+func (r *InMemoryOCRJobQueueRepository) ownedLocked(jobID uuid.UUID, workerID string) (*models.OCRJobRecord, error) {
+	job, ok := r.jobs[jobID]
+	if !ok {
+		return nil, ErrOCRJobNotFound
+	}
+	if job.Status != models.OCRStatusProcessing || job.LockedBy != workerID {
+		return nil, ErrOCRJobNotOwned
+	}
+	return job, nil
+}
+
+func (r *InMemoryOCRJobQueueRepository) ExtendLease(ctx context.Context, jobID uuid.UUID, workerID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	job, err := r.ownedLocked(jobID, workerID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	job.LockedAt = &now
+	job.UpdatedAt = now
+
+	return nil
+}
+
+func (r *InMemoryOCRJobQueueRepository) Complete(ctx context.Context, jobID uuid.UUID, workerID string, result *models.OCRResult) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	job, err := r.ownedLocked(jobID, workerID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	job.Status = models.OCRStatusCompleted
+	job.Progress = 100
+	job.ResultJSON = resultToJSON(result)
+	job.Error = ""
+	job.LockedBy = ""
+	job.LockedAt = nil
+	job.UpdatedAt = now
+	job.CompletedAt = &now
+
+	return nil
+}
+
+func (r *InMemoryOCRJobQueueRepository) Fail(ctx context.Context, jobID uuid.UUID, workerID string, errMsg string, nextAttemptAt time.Time) (models.OCRStatus, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	job, err := r.ownedLocked(jobID, workerID)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	job.Error = errMsg
+	job.LockedBy = ""
+	job.LockedAt = nil
+	job.UpdatedAt = now
+
+	if job.Attempts >= job.MaxAttempts {
+		job.Status = models.OCRStatusDeadLetter
+		job.CompletedAt = &now
+	} else {
+		job.Status = models.OCRStatusPending
+		job.Progress = 0
+		job.NextAttemptAt = nextAttemptAt
+	}
+
+	return job.Status, nil
+}
+
+func (r *InMemoryOCRJobQueueRepository) Release(ctx context.Context, jobID uuid.UUID, workerID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	job, err := r.ownedLocked(jobID, workerID)
+	if err != nil {
+		return err
+	}
+
+	job.Status = models.OCRStatusPending
+	job.Progress = 0
+	if job.Attempts > 0 {
+		job.Attempts--
+	}
+	job.LockedBy = ""
+	job.LockedAt = nil
+	job.NextAttemptAt = time.Now()
+	job.UpdatedAt = time.Now()
+
+	return nil
+}
+
+func (r *InMemoryOCRJobQueueRepository) CancelBook(ctx context.Context, bookID uuid.UUID) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	cancelled := 0
+	for _, job := range r.jobs {
+		if job.BookID != bookID {
+			continue
+		}
+		if job.Status != models.OCRStatusPending && job.Status != models.OCRStatusProcessing {
+			continue
+		}
+		job.Status = models.OCRStatusCancelled
+		job.LockedBy = ""
+		job.LockedAt = nil
+		job.UpdatedAt = now
+		job.CompletedAt = &now
+		cancelled++
+	}
+
+	return cancelled, nil
+}
+
+func (r *InMemoryOCRJobQueueRepository) Requeue(ctx context.Context, jobID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	job, ok := r.jobs[jobID]
+	if !ok {
+		return ErrOCRJobNotFound
+	}
+	if job.Status != models.OCRStatusDeadLetter && job.Status != models.OCRStatusCancelled {
+		return ErrOCRJobNotOwned
+	}
+
+	job.Status = models.OCRStatusPending
+	job.Progress = 0
+	job.Attempts = 0
+	job.Error = ""
+	job.NextAttemptAt = time.Now()
+	job.UpdatedAt = time.Now()
+	job.CompletedAt = nil
+
+	return nil
+}
+
+func (r *InMemoryOCRJobQueueRepository) GetRecord(ctx context.Context, jobID uuid.UUID) (*models.OCRJobRecord, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	job, ok := r.jobs[jobID]
+	if !ok {
+		return nil, ErrOCRJobNotFound
+	}
+
+	copied := *job
+	return &copied, nil
+}
+
+func (r *InMemoryOCRJobQueueRepository) ListByStatus(ctx context.Context, bookID uuid.UUID, status models.OCRStatus) ([]*models.OCRJobRecord, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	jobs := make([]*models.OCRJobRecord, 0)
+	for _, job := range r.jobs {
+		if job.BookID == bookID && job.Status == status {
+			copied := *job
+			jobs = append(jobs, &copied)
+		}
+	}
+
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].PageNumber < jobs[j].PageNumber
+	})
+
+	return jobs, nil
+}
+
+func (r *InMemoryOCRJobQueueRepository) GetQueueStatus(ctx context.Context, bookID uuid.UUID) (*models.OCRQueueStatus, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	status := &models.OCRQueueStatus{BookID: bookID.String()}
+	pages := make(map[uuid.UUID]bool)
+	for _, job := range r.jobs {
+		if job.BookID != bookID || job.Kind == models.OCRJobKindPDF {
+			continue
+		}
+		pages[job.PageID] = true
+		countQueueStatus(status, job.Status, 1)
+	}
+	status.TotalPages = len(pages)
+
+	return status, nil
+}
+
+// countQueueStatus はステータスの件数に count を加算する
+func countQueueStatus(status *models.OCRQueueStatus, jobStatus models.OCRStatus, count int) {
+	switch jobStatus {
+	case models.OCRStatusPending:
+		status.Pending += count
+	case models.OCRStatusProcessing:
+		status.Processing += count
+	case models.OCRStatusCompleted:
+		status.Completed += count
+	case models.OCRStatusCancelled:
+		status.Cancelled += count
+	case models.OCRStatusDeadLetter:
+		status.DeadLetter += count
+	}
+}
+
+// PostgreSQL Implementation
+
+// ocrJobQueueColumns はジョブキューのSELECT対象カラム
+const ocrJobQueueColumns = `id, user_id, book_id, page_id, COALESCE(page_number, 0), COALESCE(image_url, ''), languages,
+	detect_orientation, status, progress, attempts, max_attempts, COALESCE(locked_by, ''), locked_at, next_attempt_at,
+	COALESCE(error_message, ''), created_at, updated_at, completed_at, kind`
+
+// OCRJobQueueRepositoryPostgres はPostgreSQLベースのOCRジョブキュー
+// ocr_jobs テーブルを SELECT ... FOR UPDATE SKIP LOCKED で複数ワーカーから安全に取得する
+type OCRJobQueueRepositoryPostgres struct {
+	db *sql.DB
+}
+
+// NewOCRJobQueueRepositoryPostgres はPostgreSQL実装のOCRジョブキューを作成
+func NewOCRJobQueueRepositoryPostgres(db *sql.DB) OCRJobQueueRepository {
+	return &OCRJobQueueRepositoryPostgres{db: db}
+}
+
+// scanOCRJobRecord は1行分のジョブを読み込む
+func scanOCRJobRecord(scanner interface{ Scan(dest ...any) error }) (*models.OCRJobRecord, error) {
+	var job models.OCRJobRecord
+	var status, kind string
+	var lockedAt, completedAt sql.NullTime
+
+	err := scanner.Scan(
+		&job.ID, &job.UserID, &job.BookID, &job.PageID, &job.PageNumber, &job.ImageURL, pq.Array(&job.Languages),
+		&job.DetectOrientation, &status, &job.Progress, &job.Attempts, &job.MaxAttempts, &job.LockedBy, &lockedAt, &job.NextAttemptAt,
+		&job.Error, &job.CreatedAt, &job.UpdatedAt, &completedAt, &kind,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	job.Status = models.OCRStatus(status)
+	job.Kind = models.OCRJobKind(kind)
+	if lockedAt.Valid {
+		job.LockedAt = &lockedAt.Time
+	}
+	if completedAt.Valid {
+		job.CompletedAt = &completedAt.Time
+	}
+
+	return &job, nil
+}
+
+func (r *OCRJobQueueRepositoryPostgres) Enqueue(ctx context.Context, jobs []*models.OCRJobRecord) ([]*models.OCRJobRecord, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	enqueued := make([]*models.OCRJobRecord, 0, len(jobs))
+	for _, job := range jobs {
+		kind := job.Kind
+		if kind == "" {
+			kind = models.OCRJobKindPage
+		}
+
+		// PDFのページ分割のジョブは page_id・page_number を NULL にする（重複の確認・一意制約の対象外）
+		var pageID any = job.PageID
+		var pageNumber any = job.PageNumber
+		if kind == models.OCRJobKindPDF {
+			pageID, pageNumber = nil, nil
+		}
+
+		result, err := tx.ExecContext(ctx, `
+			INSERT INTO ocr_jobs (id, user_id, book_id, page_id, page_number, image_url, languages, detect_orientation,
+			                      status, progress, attempts, max_attempts, next_attempt_at, created_at, updated_at, kind)
+			SELECT $1, $2, $3, $4, $5, $6, $7, $12, $8, 0, 0, $9, $10, $11, $11, $13
+			WHERE NOT EXISTS (
+				SELECT 1 FROM ocr_jobs
+				WHERE page_id = $4 AND status IN ('pending', 'processing', 'completed')
+			)
+			ON CONFLICT (book_id, page_number) WHERE status IN ('pending', 'processing') DO NOTHING
+		`, job.ID, job.UserID, job.BookID, pageID, pageNumber, job.ImageURL, pq.Array(job.Languages),
+			string(models.OCRStatusPending), job.MaxAttempts, job.NextAttemptAt, job.CreatedAt, job.DetectOrientation, string(kind))
+		if err != nil {
+			return nil, err
+		}
+
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return nil, err
+		}
+		if rows > 0 {
+			job.Kind = kind
+			enqueued = append(enqueued, job)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return enqueued, nil
+}
+
+func (r *OCRJobQueueRepositoryPostgres) Claim(ctx context.Context, workerID string, limit int, leaseTimeout time.Duration) ([]*models.OCRJobRecord, error) {
+	cutoff := time.Now().Add(-leaseTimeout)
+
+	// リース期限切れかつ試行回数の上限に達したジョブは dead_letter へ
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE ocr_jobs
+		SET status = 'dead_letter', error_message = 'lease expired after max attempts',
+		    locked_by = NULL, locked_at = NULL, updated_at = NOW(), completed_at = NOW()
+		WHERE status = 'processing' AND locked_at < $1 AND attempts >= max_attempts
+	`, cutoff)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		UPDATE ocr_jobs
+		SET status = 'processing', progress = 10, attempts = attempts + 1,
+		    locked_by = $1, locked_at = NOW(), updated_at = NOW()
+		WHERE id IN (
+			SELECT id FROM ocr_jobs
+			WHERE (status = 'pending' AND next_attempt_at <= NOW())
+			   OR (status = 'processing' AND locked_at < $2)
+			ORDER BY created_at, page_number
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+ocrJobQueueColumns, workerID, cutoff, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	claimed := make([]*models.OCRJobRecord, 0)
+	for rows.Next() {
+		job, err := scanOCRJobRecord(rows)
+		if err != nil {
+			return nil, err
+		}
+		claimed = append(claimed, job)
+	}
+
+	return claimed, rows.Err()
+}
+
+func (r *OCRJobQueueRepositoryPostgres) ExtendLease(ctx context.Context, jobID uuid.UUID, workerID string) error {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE ocr_jobs
+		SET locked_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND status = 'processing' AND locked_by = $2
+	`, jobID, workerID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrOCRJobNotOwned
+	}
+
+	return nil
+}
+
+func (r *OCRJobQueueRepositoryPostgres) Complete(ctx context.Context, jobID uuid.UUID, workerID string, result *models.OCRResult) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var pageNumber int
+	err = tx.QueryRowContext(ctx, `
+		UPDATE ocr_jobs
+		SET status = 'completed', progress = 100, error_message = NULL,
+		    locked_by = NULL, locked_at = NULL, updated_at = NOW(), completed_at = NOW()
+		WHERE id = $1 AND status = 'processing' AND locked_by = $2
+		RETURNING COALESCE(page_number, 0)
+	`, jobID, workerID).Scan(&pageNumber)
+	if err == sql.ErrNoRows {
+		return ErrOCRJobNotOwned
+	}
+	if err != nil {
+		return err
+	}
+
+	if result != nil {
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO ocr_results (job_id, page_number, original_text, translated_text, confidence, processing_time_ms)
+			VALUES ($1, $2, $3, $4, $5, $6)
+		`, jobID, pageNumber, result.Text, "", result.Confidence, result.ProcessingTime)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *OCRJobQueueRepositoryPostgres) Fail(ctx context.Context, jobID uuid.UUID, workerID string, errMsg string, nextAttemptAt time.Time) (models.OCRStatus, error) {
+	var status string
+	err := r.db.QueryRowContext(ctx, `
+		UPDATE ocr_jobs
+		SET status = CASE WHEN attempts >= max_attempts THEN 'dead_letter' ELSE 'pending' END,
+		    progress = CASE WHEN attempts >= max_attempts THEN progress ELSE 0 END,
+		    completed_at = CASE WHEN attempts >= max_attempts THEN NOW() ELSE NULL END,
+		    error_message = $3, next_attempt_at = $4,
+		    locked_by = NULL, locked_at = NULL, updated_at = NOW()
+		WHERE id = $1 AND status = 'processing' AND locked_by = $2
+		RETURNING status
+	`, jobID, workerID, errMsg, nextAttemptAt).Scan(&status)
+	if err == sql.ErrNoRows {
+		return "", ErrOCRJobNotOwned
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return models.OCRStatus(status), nil
+}
+
+func (r *OCRJobQueueRepositoryPostgres) Release(ctx context.Context, jobID uuid.UUID, workerID string) error {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE ocr_jobs
+		SET status = 'pending', progress = 0, attempts = GREATEST(attempts - 1, 0),
+		    locked_by = NULL, locked_at = NULL, next_attempt_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND status = 'processing' AND locked_by = $2
+	`, jobID, workerID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrOCRJobNotOwned
+	}
+
+	return nil
+}
+
+func (r *OCRJobQueueRepositoryPostgres) CancelBook(ctx context.Context, bookID uuid.UUID) (int, error) {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE ocr_jobs
+		SET status = 'cancelled', locked_by = NULL, locked_at = NULL,
+		    updated_at = NOW(), completed_at = NOW()
+		WHERE book_id = $1 AND status IN ('pending', 'processing')
+	`, bookID)
+	if err != nil {
+		return 0, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(rows), nil
+}
+
+func (r *OCRJobQueueRepositoryPostgres) Requeue(ctx context.Context, jobID uuid.UUID) error {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE ocr_jobs
+		SET status = 'pending', progress = 0, attempts = 0, error_message = NULL,
+		    next_attempt_at = NOW(), updated_at = NOW(), completed_at = NULL
+		WHERE id = $1 AND status IN ('dead_letter', 'cancelled')
+	`, jobID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		if _, err := r.GetRecord(ctx, jobID); err != nil {
+			return err
+		}
+		return ErrOCRJobNotOwned
+	}
+
+	return nil
+}
+
+func (r *OCRJobQueueRepositoryPostgres) GetRecord(ctx context.Context, jobID uuid.UUID) (*models.OCRJobRecord, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT `+ocrJobQueueColumns+` FROM ocr_jobs WHERE id = $1`, jobID)
+
+	job, err := scanOCRJobRecord(row)
+	if err == sql.ErrNoRows {
+		return nil, ErrOCRJobNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return job, nil
+}
+
+func (r *OCRJobQueueRepositoryPostgres) ListByStatus(ctx context.Context, bookID uuid.UUID, status models.OCRStatus) ([]*models.OCRJobRecord, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+ocrJobQueueColumns+`
+		FROM ocr_jobs
+		WHERE book_id = $1 AND status = $2
+		ORDER BY page_number ASC
+	`, bookID, string(status))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	jobs := make([]*models.OCRJobRecord, 0)
+	for rows.Next() {
+		job, err := scanOCRJobRecord(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+
+	return jobs, rows.Err()
+}
+
+func (r *OCRJobQueueRepositoryPostgres) GetQueueStatus(ctx context.Context, bookID uuid.UUID) (*models.OCRQueueStatus, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT status, COUNT(*)
+		FROM ocr_jobs
+		WHERE book_id = $1 AND page_id IS NOT NULL
+		GROUP BY status
+	`, bookID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	status := &models.OCRQueueStatus{BookID: bookID.String()}
+	for rows.Next() {
+		var jobStatus string
+		var count int
+		if err := rows.Scan(&jobStatus, &count); err != nil {
+			return nil, err
+		}
+		countQueueStatus(status, models.OCRStatus(jobStatus), count)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	err = r.db.QueryRowContext(ctx, `
+		SELECT COUNT(DISTINCT page_id) FROM ocr_jobs WHERE book_id = $1 AND page_id IS NOT NULL
+	`, bookID).Scan(&status.TotalPages)
+	if err != nil {
+		return nil, err
+	}
+
+	return status, nil
+}
@@ -3,6 +3,8 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"sync"
 
 	"github.com/clearclown/HaiLanGo/backend/internal/models"
 	"github.com/google/uuid"
@@ -13,6 +15,10 @@ type PageRepository interface {
 	// Create はページを作成する
 	Create(ctx context.Context, page *models.Page) error
 
+	// AppendPages は書籍の最後のページに続けてページ番号を振り、ページをまとめて作成する
+	// 同じ書籍に同時に追加されてもページ番号は重ならない
+	AppendPages(ctx context.Context, bookID uuid.UUID, pages []*models.Page) error
+
 	// Update はページを更新する
 	Update(ctx context.Context, page *models.Page) error
 
@@ -24,10 +30,14 @@ type PageRepository interface {
 
 	// UpdateOCRResult はOCR結果を更新する
 	UpdateOCRResult(ctx context.Context, pageID uuid.UUID, ocrText string, confidence float64, detectedLang string) error
+
+	// Delete はページを削除する
+	Delete(ctx context.Context, id uuid.UUID) error
 }
 
 // MockPageRepository はモックページリポジトリ
 type MockPageRepository struct {
+	mu    sync.Mutex
 	pages map[uuid.UUID]*models.Page
 }
 
@@ -40,18 +50,47 @@ func NewMockPageRepository() *MockPageRepository {
 
 // Create はページを作成する
 func (r *MockPageRepository) Create(ctx context.Context, page *models.Page) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
 	r.pages[page.ID] = page
 	return nil
 }
 
+// AppendPages は書籍の最後のページに続けてページ番号を振り、ページをまとめて作成する
+func (r *MockPageRepository) AppendPages(ctx context.Context, bookID uuid.UUID, pages []*models.Page) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	last := 0
+	for _, page := range r.pages {
+		if page.BookID == bookID {
+			last = max(last, page.PageNumber)
+		}
+	}
+
+	for i, page := range pages {
+		page.BookID = bookID
+		page.PageNumber = last + i + 1
+		r.pages[page.ID] = page
+	}
+	return nil
+}
+
 // Update はページを更新する
 func (r *MockPageRepository) Update(ctx context.Context, page *models.Page) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
 	r.pages[page.ID] = page
 	return nil
 }
 
 // FindByID はIDでページを取得する
 func (r *MockPageRepository) FindByID(ctx context.Context, id uuid.UUID) (*models.Page, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
 	page, ok := r.pages[id]
 	if !ok {
 		return nil, nil
@@ -61,6 +100,9 @@ func (r *MockPageRepository) FindByID(ctx context.Context, id uuid.UUID) (*model
 
 // FindByBookID は書籍IDで全ページを取得する
 func (r *MockPageRepository) FindByBookID(ctx context.Context, bookID uuid.UUID) ([]*models.Page, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
 	var pages []*models.Page
 	for _, page := range r.pages {
 		if page.BookID == bookID {
@@ -72,6 +114,9 @@ func (r *MockPageRepository) FindByBookID(ctx context.Context, bookID uuid.UUID)
 
 // UpdateOCRResult はOCR結果を更新する
 func (r *MockPageRepository) UpdateOCRResult(ctx context.Context, pageID uuid.UUID, ocrText string, confidence float64, detectedLang string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
 	page, ok := r.pages[pageID]
 	if !ok {
 		return nil
@@ -85,6 +130,15 @@ func (r *MockPageRepository) UpdateOCRResult(ctx context.Context, pageID uuid.UU
 	return nil
 }
 
+// Delete はページを削除する
+func (r *MockPageRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.pages, id)
+	return nil
+}
+
 // PostgreSQL Implementation
 
 // pageRepositoryPostgres はPostgreSQLベースのページリポジトリ実装
@@ -99,13 +153,60 @@ func NewPageRepositoryPostgres(db *sql.DB) PageRepository {
 
 // Create はページを作成する
 func (r *pageRepositoryPostgres) Create(ctx context.Context, page *models.Page) error {
+	return insertPage(ctx, r.db, page)
+}
+
+// AppendPages は書籍の最後のページに続けてページ番号を振り、ページをまとめて作成する
+// 書籍の行をロックして、同じ書籍へのページの追加を直列化する
+func (r *pageRepositoryPostgres) AppendPages(ctx context.Context, bookID uuid.UUID, pages []*models.Page) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var lockedID uuid.UUID
+	err = tx.QueryRowContext(ctx, `SELECT id FROM books WHERE id = $1 FOR UPDATE`, bookID).Scan(&lockedID)
+	if err == sql.ErrNoRows {
+		return ErrBookNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	var last int
+	err = tx.QueryRowContext(ctx, `SELECT COALESCE(MAX(page_number), 0) FROM pages WHERE book_id = $1`, bookID).Scan(&last)
+	if err != nil {
+		return err
+	}
+
+	for i, page := range pages {
+		page.BookID = bookID
+		page.PageNumber = last + i + 1
+		if err := insertPage(ctx, tx, page); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// insertPage はページを1行追加する（トランザクション内でも使う）
+func insertPage(ctx context.Context, db interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}, page *models.Page) error {
+	layout, err := marshalOCRLayout(page.OCRLayout)
+	if err != nil {
+		return err
+	}
+
 	query := `
 		INSERT INTO pages (id, book_id, page_number, image_url, ocr_text, ocr_confidence,
-		                  detected_lang, ocr_status, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		                  detected_lang, ocr_status, ocr_layout, ocr_provider, corrected_text, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
 	`
 
-	_, err := r.db.ExecContext(
+	_, err = db.ExecContext(
 		ctx,
 		query,
 		page.ID,
@@ -116,6 +217,9 @@ func (r *pageRepositoryPostgres) Create(ctx context.Context, page *models.Page)
 		page.OCRConfidence,
 		page.DetectedLang,
 		page.OCRStatus,
+		layout,
+		page.OCRProvider,
+		page.CorrectedText,
 		page.CreatedAt,
 		page.UpdatedAt,
 	)
@@ -125,11 +229,17 @@ func (r *pageRepositoryPostgres) Create(ctx context.Context, page *models.Page)
 
 // Update はページを更新する
 func (r *pageRepositoryPostgres) Update(ctx context.Context, page *models.Page) error {
+	layout, err := marshalOCRLayout(page.OCRLayout)
+	if err != nil {
+		return err
+	}
+
 	query := `
 		UPDATE pages
 		SET image_url = $1, ocr_text = $2, ocr_confidence = $3,
-		    detected_lang = $4, ocr_status = $5, updated_at = NOW()
-		WHERE id = $6
+		    detected_lang = $4, ocr_status = $5, ocr_layout = $6, ocr_provider = $7,
+		    corrected_text = $8, updated_at = NOW()
+		WHERE id = $9
 	`
 
 	result, err := r.db.ExecContext(
@@ -140,6 +250,9 @@ func (r *pageRepositoryPostgres) Update(ctx context.Context, page *models.Page)
 		page.OCRConfidence,
 		page.DetectedLang,
 		page.OCRStatus,
+		layout,
+		page.OCRProvider,
+		page.CorrectedText,
 		page.ID,
 	)
 
@@ -163,12 +276,14 @@ func (r *pageRepositoryPostgres) Update(ctx context.Context, page *models.Page)
 func (r *pageRepositoryPostgres) FindByID(ctx context.Context, id uuid.UUID) (*models.Page, error) {
 	query := `
 		SELECT id, book_id, page_number, image_url, ocr_text, ocr_confidence,
-		       detected_lang, ocr_status, created_at, updated_at
+		       detected_lang, ocr_status, ocr_layout, COALESCE(ocr_provider, ''), corrected_text,
+		       created_at, updated_at
 		FROM pages
 		WHERE id = $1
 	`
 
 	page := &models.Page{}
+	var layout []byte
 	err := r.db.QueryRowContext(ctx, query, id).Scan(
 		&page.ID,
 		&page.BookID,
@@ -178,6 +293,9 @@ func (r *pageRepositoryPostgres) FindByID(ctx context.Context, id uuid.UUID) (*m
 		&page.OCRConfidence,
 		&page.DetectedLang,
 		&page.OCRStatus,
+		&layout,
+		&page.OCRProvider,
+		&page.CorrectedText,
 		&page.CreatedAt,
 		&page.UpdatedAt,
 	)
@@ -189,6 +307,10 @@ func (r *pageRepositoryPostgres) FindByID(ctx context.Context, id uuid.UUID) (*m
 		return nil, err
 	}
 
+	if page.OCRLayout, err = unmarshalOCRLayout(layout); err != nil {
+		return nil, err
+	}
+
 	return page, nil
 }
 
@@ -196,7 +318,8 @@ func (r *pageRepositoryPostgres) FindByID(ctx context.Context, id uuid.UUID) (*m
 func (r *pageRepositoryPostgres) FindByBookID(ctx context.Context, bookID uuid.UUID) ([]*models.Page, error) {
 	query := `
 		SELECT id, book_id, page_number, image_url, ocr_text, ocr_confidence,
-		       detected_lang, ocr_status, created_at, updated_at
+		       detected_lang, ocr_status, ocr_layout, COALESCE(ocr_provider, ''), corrected_text,
+		       created_at, updated_at
 		FROM pages
 		WHERE book_id = $1
 		ORDER BY page_number ASC
@@ -211,6 +334,7 @@ func (r *pageRepositoryPostgres) FindByBookID(ctx context.Context, bookID uuid.U
 	var pages []*models.Page
 	for rows.Next() {
 		page := &models.Page{}
+		var layout []byte
 		err := rows.Scan(
 			&page.ID,
 			&page.BookID,
@@ -220,12 +344,18 @@ func (r *pageRepositoryPostgres) FindByBookID(ctx context.Context, bookID uuid.U
 			&page.OCRConfidence,
 			&page.DetectedLang,
 			&page.OCRStatus,
+			&layout,
+			&page.OCRProvider,
+			&page.CorrectedText,
 			&page.CreatedAt,
 			&page.UpdatedAt,
 		)
 		if err != nil {
 			return nil, err
 		}
+		if page.OCRLayout, err = unmarshalOCRLayout(layout); err != nil {
+			return nil, err
+		}
 		pages = append(pages, page)
 	}
 
@@ -270,3 +400,29 @@ func (r *pageRepositoryPostgres) UpdateOCRResult(ctx context.Context, pageID uui
 
 	return nil
 }
+
+// Delete はページを削除する
+func (r *pageRepositoryPostgres) Delete(ctx context.Context, id uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM pages WHERE id = $1`, id)
+	return err
+}
+
+// marshalOCRLayout はOCRレイアウトをJSONB列に保存する形式に変換する
+func marshalOCRLayout(layout []models.OCRBlock) ([]byte, error) {
+	if layout == nil {
+		return nil, nil
+	}
+	return json.Marshal(layout)
+}
+
+// unmarshalOCRLayout はJSONB列からOCRレイアウトを復元する
+func unmarshalOCRLayout(data []byte) ([]models.OCRBlock, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+	var layout []models.OCRBlock
+	if err := json.Unmarshal(data, &layout); err != nil {
+		return nil, err
+	}
+	return layout, nil
+}
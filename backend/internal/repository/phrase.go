@@ -0,0 +1,175 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"sync"
+
+	"github.com/clearclown/HaiLanGo/backend/internal/models"
+	"github.com/google/uuid"
+)
+
+// PhraseRepository はページから抽出した対訳フレーズのリポジトリ
+type PhraseRepository interface {
+	// ReplacePagePhrases はページのフレーズを置き換える（OCRの再処理時に古いフレーズを削除する）
+	ReplacePagePhrases(ctx context.Context, pageID uuid.UUID, phrases []*models.PhraseRecord) error
+
+	// FindByPage は書籍のページ番号でフレーズを取得する（ページ内の順序）
+	FindByPage(ctx context.Context, bookID uuid.UUID, pageNumber int) ([]*models.PhraseRecord, error)
+
+	// FindByBook は書籍のフレーズをページ順に取得する
+	FindByBook(ctx context.Context, bookID uuid.UUID) ([]*models.PhraseRecord, error)
+}
+
+// InMemoryPhraseRepository はインメモリのフレーズリポジトリ
+type InMemoryPhraseRepository struct {
+	mu      sync.RWMutex
+	phrases map[uuid.UUID][]*models.PhraseRecord // PageID -> Phrases
+}
+
+// NewInMemoryPhraseRepository はインメモリのフレーズリポジトリを作成する
+func NewInMemoryPhraseRepository() *InMemoryPhraseRepository {
+	return &InMemoryPhraseRepository{
+		phrases: make(map[uuid.UUID][]*models.PhraseRecord),
+	}
+}
+
+func (r *InMemoryPhraseRepository) ReplacePagePhrases(ctx context.Context, pageID uuid.UUID, phrases []*models.PhraseRecord) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored := make([]*models.PhraseRecord, 0, len(phrases))
+	for _, phrase := range phrases {
+		copied := *phrase
+		stored = append(stored, &copied)
+	}
+	r.phrases[pageID] = stored
+
+	return nil
+}
+
+func (r *InMemoryPhraseRepository) FindByPage(ctx context.Context, bookID uuid.UUID, pageNumber int) ([]*models.PhraseRecord, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var result []*models.PhraseRecord
+	for _, phrases := range r.phrases {
+		for _, phrase := range phrases {
+			if phrase.BookID == bookID && phrase.PageNumber == pageNumber {
+				copied := *phrase
+				result = append(result, &copied)
+			}
+		}
+	}
+	sortPhraseRecords(result)
+
+	return result, nil
+}
+
+func (r *InMemoryPhraseRepository) FindByBook(ctx context.Context, bookID uuid.UUID) ([]*models.PhraseRecord, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var result []*models.PhraseRecord
+	for _, phrases := range r.phrases {
+		for _, phrase := range phrases {
+			if phrase.BookID == bookID {
+				copied := *phrase
+				result = append(result, &copied)
+			}
+		}
+	}
+	sortPhraseRecords(result)
+
+	return result, nil
+}
+
+// sortPhraseRecords はフレーズをページ番号・ページ内の順序で並べる
+func sortPhraseRecords(phrases []*models.PhraseRecord) {
+	sort.Slice(phrases, func(i, j int) bool {
+		if phrases[i].PageNumber != phrases[j].PageNumber {
+			return phrases[i].PageNumber < phrases[j].PageNumber
+		}
+		return phrases[i].Position < phrases[j].Position
+	})
+}
+
+// PostgreSQL Implementation
+
+type phraseRepositoryPostgres struct {
+	db *sql.DB
+}
+
+// NewPhraseRepositoryPostgres はPostgreSQL実装のフレーズリポジトリを作成する
+func NewPhraseRepositoryPostgres(db *sql.DB) PhraseRepository {
+	return &phraseRepositoryPostgres{db: db}
+}
+
+func (r *phraseRepositoryPostgres) ReplacePagePhrases(ctx context.Context, pageID uuid.UUID, phrases []*models.PhraseRecord) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM phrases WHERE page_id = $1`, pageID); err != nil {
+		return err
+	}
+
+	for _, phrase := range phrases {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO phrases (id, book_id, page_id, page_number, position, text, translation,
+			                     target_language, native_language, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		`, phrase.ID, phrase.BookID, pageID, phrase.PageNumber, phrase.Position, phrase.Text, phrase.Translation,
+			phrase.TargetLanguage, phrase.NativeLanguage, phrase.CreatedAt)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *phraseRepositoryPostgres) FindByPage(ctx context.Context, bookID uuid.UUID, pageNumber int) ([]*models.PhraseRecord, error) {
+	return r.query(ctx, `
+		SELECT id, book_id, page_id, page_number, position, text, translation,
+		       target_language, native_language, created_at
+		FROM phrases
+		WHERE book_id = $1 AND page_number = $2
+		ORDER BY position
+	`, bookID, pageNumber)
+}
+
+func (r *phraseRepositoryPostgres) FindByBook(ctx context.Context, bookID uuid.UUID) ([]*models.PhraseRecord, error) {
+	return r.query(ctx, `
+		SELECT id, book_id, page_id, page_number, position, text, translation,
+		       target_language, native_language, created_at
+		FROM phrases
+		WHERE book_id = $1
+		ORDER BY page_number, position
+	`, bookID)
+}
+
+func (r *phraseRepositoryPostgres) query(ctx context.Context, query string, args ...any) ([]*models.PhraseRecord, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var phrases []*models.PhraseRecord
+	for rows.Next() {
+		phrase := &models.PhraseRecord{}
+		if err := rows.Scan(
+			&phrase.ID, &phrase.BookID, &phrase.PageID, &phrase.PageNumber, &phrase.Position,
+			&phrase.Text, &phrase.Translation, &phrase.TargetLanguage, &phrase.NativeLanguage, &phrase.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		phrases = append(phrases, phrase)
+	}
+
+	return phrases, rows.Err()
+}
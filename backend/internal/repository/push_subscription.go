@@ -0,0 +1,167 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/clearclown/HaiLanGo/backend/internal/models"
+	"github.com/google/uuid"
+)
+
+// ErrPushEndpointInUse は他のユーザーが同じエンドポイントを購読している場合のエラー
+var ErrPushEndpointInUse = &RepositoryError{
+	Code:    "PUSH_ENDPOINT_IN_USE",
+	Message: "push endpoint is subscribed by another user",
+}
+
+// PushSubscriptionRepository はブラウザのWeb Pushの購読のリポジトリ
+type PushSubscriptionRepository interface {
+	// Save は購読を保存する（同じユーザーの同じエンドポイントの購読は置き換える）
+	// 他のユーザーが購読しているエンドポイントは ErrPushEndpointInUse を返す
+	Save(ctx context.Context, subscription *models.PushSubscription) error
+
+	// FindByUserID はユーザーの購読を登録順に取得する
+	FindByUserID(ctx context.Context, userID string) ([]*models.PushSubscription, error)
+
+	// Delete はエンドポイントの購読を削除する
+	Delete(ctx context.Context, userID string, endpoint string) error
+}
+
+// InMemoryPushSubscriptionRepository はインメモリの購読リポジトリ
+type InMemoryPushSubscriptionRepository struct {
+	mu            sync.RWMutex
+	subscriptions map[string]*models.PushSubscription // Endpoint -> Subscription
+}
+
+// NewInMemoryPushSubscriptionRepository はインメモリの購読リポジトリを作成する
+func NewInMemoryPushSubscriptionRepository() *InMemoryPushSubscriptionRepository {
+	return &InMemoryPushSubscriptionRepository{
+		subscriptions: make(map[string]*models.PushSubscription),
+	}
+}
+
+func (r *InMemoryPushSubscriptionRepository) Save(ctx context.Context, subscription *models.PushSubscription) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if subscription.ID == "" {
+		subscription.ID = uuid.New().String()
+	}
+	if subscription.CreatedAt.IsZero() {
+		subscription.CreatedAt = time.Now()
+	}
+	if existing, ok := r.subscriptions[subscription.Endpoint]; ok && existing.UserID != subscription.UserID {
+		return ErrPushEndpointInUse
+	}
+	copied := *subscription
+	r.subscriptions[subscription.Endpoint] = &copied
+
+	return nil
+}
+
+func (r *InMemoryPushSubscriptionRepository) FindByUserID(ctx context.Context, userID string) ([]*models.PushSubscription, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var result []*models.PushSubscription
+	for _, subscription := range r.subscriptions {
+		if subscription.UserID == userID {
+			copied := *subscription
+			result = append(result, &copied)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].CreatedAt.Before(result[j].CreatedAt)
+	})
+
+	return result, nil
+}
+
+func (r *InMemoryPushSubscriptionRepository) Delete(ctx context.Context, userID string, endpoint string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if subscription, ok := r.subscriptions[endpoint]; ok && subscription.UserID == userID {
+		delete(r.subscriptions, endpoint)
+	}
+
+	return nil
+}
+
+// pushSubscriptionRepositoryPostgres はPostgreSQLの購読リポジトリ
+type pushSubscriptionRepositoryPostgres struct {
+	db *sql.DB
+}
+
+// NewPushSubscriptionRepositoryPostgres はPostgreSQLの購読リポジトリを作成する
+func NewPushSubscriptionRepositoryPostgres(db *sql.DB) PushSubscriptionRepository {
+	return &pushSubscriptionRepositoryPostgres{db: db}
+}
+
+func (r *pushSubscriptionRepositoryPostgres) Save(ctx context.Context, subscription *models.PushSubscription) error {
+	if subscription.ID == "" {
+		subscription.ID = uuid.New().String()
+	}
+	if subscription.CreatedAt.IsZero() {
+		subscription.CreatedAt = time.Now()
+	}
+
+	query := `
+		INSERT INTO push_subscriptions (id, user_id, endpoint, p256dh, auth, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (endpoint) DO UPDATE
+		SET p256dh = EXCLUDED.p256dh, auth = EXCLUDED.auth
+		WHERE push_subscriptions.user_id = EXCLUDED.user_id
+		RETURNING id
+	`
+	var id string
+	err := r.db.QueryRowContext(ctx, query,
+		subscription.ID, subscription.UserID, subscription.Endpoint,
+		subscription.P256dh, subscription.Auth, subscription.CreatedAt,
+	).Scan(&id)
+	if err == sql.ErrNoRows {
+		return ErrPushEndpointInUse
+	}
+	if err != nil {
+		return err
+	}
+
+	subscription.ID = id
+	return nil
+}
+
+func (r *pushSubscriptionRepositoryPostgres) FindByUserID(ctx context.Context, userID string) ([]*models.PushSubscription, error) {
+	query := `
+		SELECT id, user_id, endpoint, p256dh, auth, created_at
+		FROM push_subscriptions
+		WHERE user_id = $1
+		ORDER BY created_at
+	`
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []*models.PushSubscription
+	for rows.Next() {
+		subscription := &models.PushSubscription{}
+		if err := rows.Scan(
+			&subscription.ID, &subscription.UserID, &subscription.Endpoint,
+			&subscription.P256dh, &subscription.Auth, &subscription.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		result = append(result, subscription)
+	}
+
+	return result, rows.Err()
+}
+
+func (r *pushSubscriptionRepositoryPostgres) Delete(ctx context.Context, userID string, endpoint string) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM push_subscriptions WHERE user_id = $1 AND endpoint = $2`, userID, endpoint)
+	return err
+}
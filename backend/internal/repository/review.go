@@ -12,6 +12,18 @@ var (
 		Code:    "REVIEW_ITEM_NOT_FOUND",
 		Message: "review item not found",
 	}
+	ErrReviewItemConflict = &RepositoryError{
+		Code:    "REVIEW_ITEM_CONFLICT",
+		Message: "review item was updated concurrently",
+	}
+	ErrReviewSessionNotFound = &RepositoryError{
+		Code:    "REVIEW_SESSION_NOT_FOUND",
+		Message: "review session not found",
+	}
+	ErrReviewSessionLocked = &RepositoryError{
+		Code:    "REVIEW_SESSION_LOCKED",
+		Message: "review session is locked by another request",
+	}
 )
 
 type ReviewRepository interface {
@@ -19,12 +31,57 @@ type ReviewRepository interface {
 	FindByID(ctx context.Context, id string) (*models.ReviewItem, error)
 	FindByUserID(ctx context.Context, userID string) ([]*models.ReviewItem, error)
 	Update(ctx context.Context, item *models.ReviewItem) error
+	// UpdateIfUnchanged は項目の更新日時が updatedAt のままの場合だけ更新する（変更されていた場合は ErrReviewItemConflict）
+	UpdateIfUnchanged(ctx context.Context, item *models.ReviewItem, updatedAt time.Time) error
 	Delete(ctx context.Context, id string) error
+	// ImportItems は項目と復習履歴をまとめて保存する（デッキの取り込み用、途中で失敗した場合は何も保存しない）
+	ImportItems(ctx context.Context, items []*models.ReviewItem, histories []*models.ReviewHistory) error
 
 	// 統計用
 	CountCompletedToday(ctx context.Context, userID string, since time.Time) (int, error)
 	CountCompletedSince(ctx context.Context, userID string, since time.Time) (int, error)
+	// CountIntroducedSince は since 以降に初めて復習した項目の数を返す
+	CountIntroducedSince(ctx context.Context, userID string, since time.Time) (int, error)
+	// CountDueByDate は from 以降 to より前に復習予定の項目数を日付（YYYY-MM-DD）ごとに返す（excludeID の項目は数えない）
+	CountDueByDate(ctx context.Context, userID, excludeID string, from, to time.Time) (map[string]int, error)
 
 	// 履歴
 	SaveHistory(ctx context.Context, history *models.ReviewHistory) error
+	FindHistoryByItemID(ctx context.Context, itemID string) ([]*models.ReviewHistory, error)
+	FindHistoryByUserID(ctx context.Context, userID string) ([]*models.ReviewHistory, error)
+	// DeleteHistory は復習履歴を削除する（復習セッションの回答の取り消し用）
+	DeleteHistory(ctx context.Context, id string) error
+	// FindReviewedUserIDs は since 以降に復習したユーザーのIDを返す
+	FindReviewedUserIDs(ctx context.Context, since time.Time) ([]string, error)
+	// ClaimOptimization はFSRSの最適化を now に始めたことを記録する
+	// 他のサーバーが claimedBefore より後に始めていた場合は記録せずに false を返す
+	ClaimOptimization(ctx context.Context, userID string, claimedBefore, now time.Time) (bool, error)
+
+	// 進行中の復習セッション
+	// 同じセッションへの同時の操作を1つずつ処理するよう、操作の間は token でロックする
+	CreateSession(ctx context.Context, state *models.ReviewSessionState) error
+	// LockSession はセッションを lease の間ロックして返す
+	// ない場合は ErrReviewSessionNotFound、他の token がロック中の場合は ErrReviewSessionLocked
+	LockSession(ctx context.Context, id, token string, lease time.Duration) (*models.ReviewSessionState, error)
+	// SaveSession は token でロックしたセッションの状態を保存してロックを解除する（ロックを失っていた場合は ErrReviewSessionLocked）
+	SaveSession(ctx context.Context, state *models.ReviewSessionState, token string) error
+	// UnlockSession は状態を変えずにロックを解除する
+	UnlockSession(ctx context.Context, id, token string) error
+	// DeleteSession は token でロックしたセッションを削除する（ロックを失っていた場合は ErrReviewSessionLocked）
+	DeleteSession(ctx context.Context, id, token string) error
+	// DeleteExpiredSessions は lastActiveBefore より前から操作されていないセッションを削除する
+	DeleteExpiredSessions(ctx context.Context, lastActiveBefore time.Time) error
+
+	// ユーザーごとの設定（未設定の場合は nil）
+	GetSettings(ctx context.Context, userID string) (*models.ReviewSettings, error)
+	// SaveSettings は設定を保存する（RemindedAt は ClaimReminder で記録するため、既存の設定の値を変えない）
+	SaveSettings(ctx context.Context, settings *models.ReviewSettings) error
+	// SaveFSRSWeights は最適化したFSRSの重みと最適化した日時だけを更新する（最適化中に変更された他の設定を上書きしない）
+	// 設定が保存されていない場合は何もせずに false を返す
+	SaveFSRSWeights(ctx context.Context, userID string, weights []float64, optimizedAt time.Time) (bool, error)
+	// FindReminderSettings は復習リマインダーを有効にしたユーザーの設定を返す
+	FindReminderSettings(ctx context.Context) ([]*models.ReviewSettings, error)
+	// ClaimReminder はリマインダーを now に送ったことを記録する
+	// remindAt 以降にすでに記録されていた場合（他のサーバーが送った場合を含む）は記録せずに false を返す
+	ClaimReminder(ctx context.Context, userID string, remindAt, now time.Time) (bool, error)
 }
@@ -3,18 +3,33 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"sort"
 	"sync"
 	"time"
 
 	"github.com/clearclown/HaiLanGo/backend/internal/models"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 // InMemoryReviewRepository はインメモリの復習リポジトリ
 type InMemoryReviewRepository struct {
 	items     map[string]*models.ReviewItem
 	histories map[string]*models.ReviewHistory
-	mu        sync.RWMutex
+	settings  map[string]*models.ReviewSettings
+	// optimizeClaims はユーザーごとのFSRSの最適化を始めた日時
+	optimizeClaims map[string]time.Time
+	// sessions は進行中の復習セッション
+	sessions map[string]*reviewSessionLock
+	mu       sync.RWMutex
+}
+
+// reviewSessionLock は復習セッションの状態とロック
+type reviewSessionLock struct {
+	state       *models.ReviewSessionState
+	lockedBy    string
+	lockedUntil time.Time
 }
 
 // NewInMemoryReviewRepository は新しいInMemoryReviewRepositoryを作成
@@ -22,6 +37,10 @@ func NewInMemoryReviewRepository() *InMemoryReviewRepository {
 	repo := &InMemoryReviewRepository{
 		items:     make(map[string]*models.ReviewItem),
 		histories: make(map[string]*models.ReviewHistory),
+		settings:  make(map[string]*models.ReviewSettings),
+
+		optimizeClaims: make(map[string]time.Time),
+		sessions:       make(map[string]*reviewSessionLock),
 	}
 
 	// サンプルデータを初期化
@@ -43,6 +62,8 @@ func (r *InMemoryReviewRepository) initSampleData() {
 		id := uuid.New().String()
 		r.items[id] = &models.ReviewItem{
 			ID:           id,
+			SourceID:     id,
+			CardType:     models.CardTypeRecognition,
 			UserID:       sampleUserID,
 			BookID:       sampleBookID,
 			PageNumber:   i + 1,
@@ -66,6 +87,8 @@ func (r *InMemoryReviewRepository) initSampleData() {
 		id := uuid.New().String()
 		r.items[id] = &models.ReviewItem{
 			ID:           id,
+			SourceID:     id,
+			CardType:     models.CardTypeRecognition,
 			UserID:       sampleUserID,
 			BookID:       sampleBookID,
 			PageNumber:   i + 10,
@@ -89,6 +112,8 @@ func (r *InMemoryReviewRepository) initSampleData() {
 		id := uuid.New().String()
 		r.items[id] = &models.ReviewItem{
 			ID:           id,
+			SourceID:     id,
+			CardType:     models.CardTypeRecognition,
 			UserID:       sampleUserID,
 			BookID:       sampleBookID,
 			PageNumber:   i + 20,
@@ -112,16 +137,43 @@ func (r *InMemoryReviewRepository) Create(ctx context.Context, item *models.Revi
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
+	r.createLocked(item)
+	return nil
+}
+
+func (r *InMemoryReviewRepository) ImportItems(ctx context.Context, items []*models.ReviewItem, histories []*models.ReviewHistory) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, item := range items {
+		r.createLocked(item)
+	}
+	for _, history := range histories {
+		if history.ID == "" {
+			history.ID = uuid.New().String()
+		}
+		r.histories[history.ID] = history
+	}
+	return nil
+}
+
+// createLocked は項目を保存する（r.mu をロックして呼び出す）
+func (r *InMemoryReviewRepository) createLocked(item *models.ReviewItem) {
 	if item.ID == "" {
 		item.ID = uuid.New().String()
 	}
+	if item.CardType == "" {
+		item.CardType = models.CardTypeRecognition
+	}
+	if item.SourceID == "" {
+		item.SourceID = item.ID
+	}
 
 	now := time.Now()
 	item.CreatedAt = now
 	item.UpdatedAt = now
 
 	r.items[item.ID] = item
-	return nil
 }
 
 func (r *InMemoryReviewRepository) FindByID(ctx context.Context, id string) (*models.ReviewItem, error) {
@@ -163,6 +215,23 @@ func (r *InMemoryReviewRepository) Update(ctx context.Context, item *models.Revi
 	return nil
 }
 
+func (r *InMemoryReviewRepository) UpdateIfUnchanged(ctx context.Context, item *models.ReviewItem, updatedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	current, exists := r.items[item.ID]
+	if !exists {
+		return ErrReviewItemNotFound
+	}
+	if !current.UpdatedAt.Equal(updatedAt) {
+		return ErrReviewItemConflict
+	}
+
+	item.UpdatedAt = time.Now()
+	r.items[item.ID] = item
+	return nil
+}
+
 func (r *InMemoryReviewRepository) Delete(ctx context.Context, id string) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
@@ -203,6 +272,48 @@ func (r *InMemoryReviewRepository) CountCompletedSince(ctx context.Context, user
 	return count, nil
 }
 
+func (r *InMemoryReviewRepository) CountIntroducedSince(ctx context.Context, userID string, since time.Time) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	firstReviewed := make(map[string]time.Time)
+	for _, history := range r.histories {
+		if history.UserID != userID {
+			continue
+		}
+		if first, ok := firstReviewed[history.ReviewItemID]; !ok || history.ReviewedAt.Before(first) {
+			firstReviewed[history.ReviewItemID] = history.ReviewedAt
+		}
+	}
+
+	count := 0
+	for _, first := range firstReviewed {
+		if !first.Before(since) {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+func (r *InMemoryReviewRepository) CountDueByDate(ctx context.Context, userID, excludeID string, from, to time.Time) (map[string]int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	counts := make(map[string]int)
+	for _, item := range r.items {
+		if item.UserID != userID || item.ID == excludeID {
+			continue
+		}
+		if item.NextReview.Before(from) || !item.NextReview.Before(to) {
+			continue
+		}
+		counts[item.NextReview.Format(time.DateOnly)]++
+	}
+
+	return counts, nil
+}
+
 func (r *InMemoryReviewRepository) SaveHistory(ctx context.Context, history *models.ReviewHistory) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
@@ -215,6 +326,232 @@ func (r *InMemoryReviewRepository) SaveHistory(ctx context.Context, history *mod
 	return nil
 }
 
+func (r *InMemoryReviewRepository) FindHistoryByItemID(ctx context.Context, itemID string) ([]*models.ReviewHistory, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var histories []*models.ReviewHistory
+	for _, history := range r.histories {
+		if history.ReviewItemID == itemID {
+			histories = append(histories, history)
+		}
+	}
+	sort.Slice(histories, func(i, j int) bool {
+		return histories[i].ReviewedAt.Before(histories[j].ReviewedAt)
+	})
+
+	return histories, nil
+}
+
+func (r *InMemoryReviewRepository) FindHistoryByUserID(ctx context.Context, userID string) ([]*models.ReviewHistory, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var histories []*models.ReviewHistory
+	for _, history := range r.histories {
+		if history.UserID == userID {
+			histories = append(histories, history)
+		}
+	}
+	sort.Slice(histories, func(i, j int) bool {
+		return histories[i].ReviewedAt.Before(histories[j].ReviewedAt)
+	})
+
+	return histories, nil
+}
+
+func (r *InMemoryReviewRepository) DeleteHistory(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.histories, id)
+	return nil
+}
+
+func (r *InMemoryReviewRepository) FindReviewedUserIDs(ctx context.Context, since time.Time) ([]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	seen := make(map[string]bool)
+	var userIDs []string
+	for _, history := range r.histories {
+		if history.ReviewedAt.Before(since) || seen[history.UserID] {
+			continue
+		}
+		seen[history.UserID] = true
+		userIDs = append(userIDs, history.UserID)
+	}
+	sort.Strings(userIDs)
+
+	return userIDs, nil
+}
+
+func (r *InMemoryReviewRepository) ClaimOptimization(ctx context.Context, userID string, claimedBefore, now time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if claimedAt, exists := r.optimizeClaims[userID]; exists && claimedAt.After(claimedBefore) {
+		return false, nil
+	}
+	r.optimizeClaims[userID] = now
+	return true, nil
+}
+
+func (r *InMemoryReviewRepository) CreateSession(ctx context.Context, state *models.ReviewSessionState) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.sessions[state.ID] = &reviewSessionLock{state: copySessionState(state)}
+	return nil
+}
+
+func (r *InMemoryReviewRepository) LockSession(ctx context.Context, id, token string, lease time.Duration) (*models.ReviewSessionState, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	session, exists := r.sessions[id]
+	if !exists {
+		return nil, ErrReviewSessionNotFound
+	}
+	now := time.Now()
+	if session.lockedBy != "" && now.Before(session.lockedUntil) {
+		return nil, ErrReviewSessionLocked
+	}
+	session.lockedBy = token
+	session.lockedUntil = now.Add(lease)
+	return copySessionState(session.state), nil
+}
+
+func (r *InMemoryReviewRepository) SaveSession(ctx context.Context, state *models.ReviewSessionState, token string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	session, exists := r.sessions[state.ID]
+	if !exists || session.lockedBy != token {
+		return ErrReviewSessionLocked
+	}
+	r.sessions[state.ID] = &reviewSessionLock{state: copySessionState(state)}
+	return nil
+}
+
+func (r *InMemoryReviewRepository) UnlockSession(ctx context.Context, id, token string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if session, exists := r.sessions[id]; exists && session.lockedBy == token {
+		session.lockedBy = ""
+		session.lockedUntil = time.Time{}
+	}
+	return nil
+}
+
+func (r *InMemoryReviewRepository) DeleteSession(ctx context.Context, id, token string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	session, exists := r.sessions[id]
+	if !exists || session.lockedBy != token {
+		return ErrReviewSessionLocked
+	}
+	delete(r.sessions, id)
+	return nil
+}
+
+func (r *InMemoryReviewRepository) DeleteExpiredSessions(ctx context.Context, lastActiveBefore time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, session := range r.sessions {
+		if session.state.LastActiveAt.Before(lastActiveBefore) {
+			delete(r.sessions, id)
+		}
+	}
+	return nil
+}
+
+// copySessionState は復習セッションの状態をスライスを含めてコピーする
+func copySessionState(state *models.ReviewSessionState) *models.ReviewSessionState {
+	copied := *state
+	copied.QueueIDs = append([]string(nil), state.QueueIDs...)
+	copied.Answers = append([]models.ReviewSessionAnswerState(nil), state.Answers...)
+	return &copied
+}
+
+func (r *InMemoryReviewRepository) GetSettings(ctx context.Context, userID string) (*models.ReviewSettings, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	settings, exists := r.settings[userID]
+	if !exists {
+		return nil, nil
+	}
+	return copySettings(settings), nil
+}
+
+func (r *InMemoryReviewRepository) SaveSettings(ctx context.Context, settings *models.ReviewSettings) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	saved := copySettings(settings)
+	if existing, exists := r.settings[settings.UserID]; exists {
+		saved.RemindedAt = existing.RemindedAt
+	}
+	r.settings[settings.UserID] = saved
+	return nil
+}
+
+func (r *InMemoryReviewRepository) SaveFSRSWeights(ctx context.Context, userID string, weights []float64, optimizedAt time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	settings, exists := r.settings[userID]
+	if !exists {
+		return false, nil
+	}
+	settings.FSRSWeights = append([]float64(nil), weights...)
+	settings.OptimizedAt = &optimizedAt
+	return true, nil
+}
+
+func (r *InMemoryReviewRepository) ClaimReminder(ctx context.Context, userID string, remindAt, now time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	settings, exists := r.settings[userID]
+	if !exists || !settings.ReminderEnabled {
+		return false, nil
+	}
+	if settings.RemindedAt != nil && !settings.RemindedAt.Before(remindAt) {
+		return false, nil
+	}
+	settings.RemindedAt = &now
+	return true, nil
+}
+
+func (r *InMemoryReviewRepository) FindReminderSettings(ctx context.Context) ([]*models.ReviewSettings, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var result []*models.ReviewSettings
+	for _, settings := range r.settings {
+		if settings.ReminderEnabled {
+			result = append(result, copySettings(settings))
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].UserID < result[j].UserID
+	})
+	return result, nil
+}
+
+// copySettings は設定のスライスを含めてコピーする
+func copySettings(settings *models.ReviewSettings) *models.ReviewSettings {
+	copied := *settings
+	copied.FSRSWeights = append([]float64(nil), settings.FSRSWeights...)
+	copied.ReminderChannels = append([]string(nil), settings.ReminderChannels...)
+	return &copied
+}
+
 // PostgreSQL Implementation
 
 type reviewRepositoryPostgres struct {
@@ -226,22 +563,59 @@ func NewReviewRepositoryPostgres(db *sql.DB) ReviewRepository {
 }
 
 func (r *reviewRepositoryPostgres) Create(ctx context.Context, item *models.ReviewItem) error {
+	return insertReviewItem(ctx, r.db, item)
+}
+
+func (r *reviewRepositoryPostgres) ImportItems(ctx context.Context, items []*models.ReviewItem, histories []*models.ReviewHistory) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, item := range items {
+		if err := insertReviewItem(ctx, tx, item); err != nil {
+			return err
+		}
+	}
+	for _, history := range histories {
+		if err := insertReviewHistory(ctx, tx, history); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// insertReviewItem は項目を保存する（db はトランザクションでもよい）
+func insertReviewItem(ctx context.Context, db interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}, item *models.ReviewItem) error {
 	query := `
 		INSERT INTO review_items (
 			id, user_id, book_id, page_number, item_type, content, translation, context,
+			card_type, source_id, language, audio_url,
 			ease_factor, interval, repetitions, next_review_date, last_reviewed_at,
-			correct_count, incorrect_count, created_at, updated_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, NOW(), NOW())
+			stability, difficulty, suspended, leeched_at, leech_cleared_at, correct_count, incorrect_count, created_at, updated_at
+		) VALUES ($1, $2, NULLIF($3, '')::uuid, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, NOW(), NOW())
 	`
 
 	if item.ID == "" {
 		item.ID = uuid.New().String()
 	}
+	if item.CardType == "" {
+		item.CardType = models.CardTypeRecognition
+	}
+	if item.SourceID == "" {
+		item.SourceID = item.ID
+	}
 
-	_, err := r.db.ExecContext(ctx, query,
+	_, err := db.ExecContext(ctx, query,
 		item.ID, item.UserID, item.BookID, item.PageNumber, item.Type,
 		item.Text, item.Translation, "", // context field
+		item.CardType, item.SourceID, item.Language, item.AudioURL,
 		item.EaseFactor, item.IntervalDays, item.ReviewCount, item.NextReview, item.LastReviewed,
+		item.Stability, item.Difficulty, item.Suspended, item.LeechedAt, item.LeechClearedAt,
 		0, 0, // correct_count, incorrect_count
 	)
 	return err
@@ -249,9 +623,10 @@ func (r *reviewRepositoryPostgres) Create(ctx context.Context, item *models.Revi
 
 func (r *reviewRepositoryPostgres) FindByID(ctx context.Context, id string) (*models.ReviewItem, error) {
 	query := `
-		SELECT id, user_id, book_id, page_number, item_type, content, translation,
+		SELECT id, user_id, COALESCE(book_id::text, ''), page_number, item_type, content, translation,
+			card_type, source_id, language, audio_url,
 			ease_factor, interval, repetitions, next_review_date, last_reviewed_at,
-			correct_count, incorrect_count, created_at, updated_at
+			stability, difficulty, suspended, leeched_at, leech_cleared_at, correct_count, incorrect_count, created_at, updated_at
 		FROM review_items WHERE id = $1
 	`
 
@@ -259,8 +634,10 @@ func (r *reviewRepositoryPostgres) FindByID(ctx context.Context, id string) (*mo
 	var correctCount, incorrectCount int
 	err := r.db.QueryRowContext(ctx, query, id).Scan(
 		&item.ID, &item.UserID, &item.BookID, &item.PageNumber, &item.Type,
-		&item.Text, &item.Translation, &item.EaseFactor, &item.IntervalDays,
+		&item.Text, &item.Translation, &item.CardType, &item.SourceID, &item.Language, &item.AudioURL,
+		&item.EaseFactor, &item.IntervalDays,
 		&item.ReviewCount, &item.NextReview, &item.LastReviewed,
+		&item.Stability, &item.Difficulty, &item.Suspended, &item.LeechedAt, &item.LeechClearedAt,
 		&correctCount, &incorrectCount, &item.CreatedAt, &item.UpdatedAt,
 	)
 
@@ -276,9 +653,10 @@ func (r *reviewRepositoryPostgres) FindByID(ctx context.Context, id string) (*mo
 
 func (r *reviewRepositoryPostgres) FindByUserID(ctx context.Context, userID string) ([]*models.ReviewItem, error) {
 	query := `
-		SELECT id, user_id, book_id, page_number, item_type, content, translation,
+		SELECT id, user_id, COALESCE(book_id::text, ''), page_number, item_type, content, translation,
+			card_type, source_id, language, audio_url,
 			ease_factor, interval, repetitions, next_review_date, last_reviewed_at,
-			correct_count, incorrect_count, created_at, updated_at
+			stability, difficulty, suspended, leeched_at, leech_cleared_at, correct_count, incorrect_count, created_at, updated_at
 		FROM review_items WHERE user_id = $1 ORDER BY next_review_date ASC
 	`
 
@@ -294,8 +672,10 @@ func (r *reviewRepositoryPostgres) FindByUserID(ctx context.Context, userID stri
 		var correctCount, incorrectCount int
 		err := rows.Scan(
 			&item.ID, &item.UserID, &item.BookID, &item.PageNumber, &item.Type,
-			&item.Text, &item.Translation, &item.EaseFactor, &item.IntervalDays,
+			&item.Text, &item.Translation, &item.CardType, &item.SourceID, &item.Language, &item.AudioURL,
+			&item.EaseFactor, &item.IntervalDays,
 			&item.ReviewCount, &item.NextReview, &item.LastReviewed,
+			&item.Stability, &item.Difficulty, &item.Suspended, &item.LeechedAt, &item.LeechClearedAt,
 			&correctCount, &incorrectCount, &item.CreatedAt, &item.UpdatedAt,
 		)
 		if err != nil {
@@ -308,18 +688,34 @@ func (r *reviewRepositoryPostgres) FindByUserID(ctx context.Context, userID stri
 }
 
 func (r *reviewRepositoryPostgres) Update(ctx context.Context, item *models.ReviewItem) error {
+	return r.update(ctx, item, nil)
+}
+
+func (r *reviewRepositoryPostgres) UpdateIfUnchanged(ctx context.Context, item *models.ReviewItem, updatedAt time.Time) error {
+	return r.update(ctx, item, &updatedAt)
+}
+
+// update は項目を更新する（updatedAt がある場合は更新日時が変わっていないときだけ）
+// 更新日時は比較できるよう、DBの精度（マイクロ秒）に丸めて item にも設定する
+func (r *reviewRepositoryPostgres) update(ctx context.Context, item *models.ReviewItem, updatedAt *time.Time) error {
 	query := `
 		UPDATE review_items SET
 			page_number = $2, item_type = $3, content = $4, translation = $5,
 			ease_factor = $6, interval = $7, repetitions = $8,
-			next_review_date = $9, last_reviewed_at = $10, updated_at = NOW()
-		WHERE id = $1
+			next_review_date = $9, last_reviewed_at = $10,
+			stability = $11, difficulty = $12, language = $13,
+			suspended = $14, leeched_at = $15, leech_cleared_at = $16,
+			audio_url = $17, updated_at = $18
+		WHERE id = $1 AND ($19::timestamp IS NULL OR updated_at = $19)
 	`
 
+	now := time.Now().Truncate(time.Microsecond)
 	result, err := r.db.ExecContext(ctx, query,
 		item.ID, item.PageNumber, item.Type, item.Text, item.Translation,
 		item.EaseFactor, item.IntervalDays, item.ReviewCount,
-		item.NextReview, item.LastReviewed,
+		item.NextReview, item.LastReviewed, item.Stability, item.Difficulty, item.Language,
+		item.Suspended, item.LeechedAt, item.LeechClearedAt,
+		item.AudioURL, now, updatedAt,
 	)
 	if err != nil {
 		return err
@@ -330,9 +726,14 @@ func (r *reviewRepositoryPostgres) Update(ctx context.Context, item *models.Revi
 		return err
 	}
 	if rows == 0 {
+		if updatedAt != nil {
+			// 更新日時が変わっていた（または削除されていた）
+			return ErrReviewItemConflict
+		}
 		return ErrReviewItemNotFound
 	}
 
+	item.UpdatedAt = now
 	return nil
 }
 
@@ -371,7 +772,54 @@ func (r *reviewRepositoryPostgres) CountCompletedSince(ctx context.Context, user
 	return count, err
 }
 
+func (r *reviewRepositoryPostgres) CountIntroducedSince(ctx context.Context, userID string, since time.Time) (int, error) {
+	query := `
+		SELECT COUNT(*) FROM (
+			SELECT item_id FROM review_history WHERE user_id = $1
+			GROUP BY item_id HAVING MIN(reviewed_at) >= $2
+		) introduced
+	`
+
+	var count int
+	err := r.db.QueryRowContext(ctx, query, userID, since).Scan(&count)
+	return count, err
+}
+
+func (r *reviewRepositoryPostgres) CountDueByDate(ctx context.Context, userID, excludeID string, from, to time.Time) (map[string]int, error) {
+	query := `
+		SELECT to_char(next_review_date, 'YYYY-MM-DD') AS due_date, COUNT(*)
+		FROM review_items
+		WHERE user_id = $1 AND id::text <> $2 AND next_review_date >= $3 AND next_review_date < $4
+		GROUP BY due_date
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID, excludeID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var date string
+		var count int
+		if err := rows.Scan(&date, &count); err != nil {
+			return nil, err
+		}
+		counts[date] = count
+	}
+
+	return counts, rows.Err()
+}
+
 func (r *reviewRepositoryPostgres) SaveHistory(ctx context.Context, history *models.ReviewHistory) error {
+	return insertReviewHistory(ctx, r.db, history)
+}
+
+// insertReviewHistory は復習履歴を保存する（db はトランザクションでもよい）
+func insertReviewHistory(ctx context.Context, db interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}, history *models.ReviewHistory) error {
 	query := `
 		INSERT INTO review_history (id, user_id, item_id, score, time_spent_seconds, reviewed_at)
 		VALUES ($1, $2, $3, $4, $5, $6)
@@ -381,12 +829,332 @@ func (r *reviewRepositoryPostgres) SaveHistory(ctx context.Context, history *mod
 		history.ID = uuid.New().String()
 	}
 
-	// Use default time_spent_seconds of 0 since model doesn't have this field
-	timeSpentSeconds := 0
+	_, err := db.ExecContext(ctx, query,
+		history.ID, history.UserID, history.ReviewItemID,
+		history.Score, history.TimeSpentSec, history.ReviewedAt,
+	)
+	return err
+}
+
+func (r *reviewRepositoryPostgres) FindHistoryByItemID(ctx context.Context, itemID string) ([]*models.ReviewHistory, error) {
+	query := `
+		SELECT id, user_id, item_id, score, time_spent_seconds, reviewed_at
+		FROM review_history WHERE item_id = $1 ORDER BY reviewed_at ASC
+	`
+
+	return r.queryHistories(ctx, query, itemID)
+}
+
+func (r *reviewRepositoryPostgres) FindHistoryByUserID(ctx context.Context, userID string) ([]*models.ReviewHistory, error) {
+	query := `
+		SELECT id, user_id, item_id, score, time_spent_seconds, reviewed_at
+		FROM review_history WHERE user_id = $1 ORDER BY reviewed_at ASC
+	`
+
+	return r.queryHistories(ctx, query, userID)
+}
+
+func (r *reviewRepositoryPostgres) queryHistories(ctx context.Context, query string, args ...any) ([]*models.ReviewHistory, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var histories []*models.ReviewHistory
+	for rows.Next() {
+		history := &models.ReviewHistory{}
+		if err := rows.Scan(
+			&history.ID, &history.UserID, &history.ReviewItemID,
+			&history.Score, &history.TimeSpentSec, &history.ReviewedAt,
+		); err != nil {
+			return nil, err
+		}
+		histories = append(histories, history)
+	}
+
+	return histories, rows.Err()
+}
+
+func (r *reviewRepositoryPostgres) DeleteHistory(ctx context.Context, id string) error {
+	query := `DELETE FROM review_history WHERE id = $1`
+
+	_, err := r.db.ExecContext(ctx, query, id)
+	return err
+}
+
+func (r *reviewRepositoryPostgres) FindReviewedUserIDs(ctx context.Context, since time.Time) ([]string, error) {
+	query := `SELECT DISTINCT user_id FROM review_history WHERE reviewed_at >= $1 ORDER BY user_id`
+
+	rows, err := r.db.QueryContext(ctx, query, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var userIDs []string
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			return nil, err
+		}
+		userIDs = append(userIDs, userID)
+	}
+
+	return userIDs, rows.Err()
+}
+
+func (r *reviewRepositoryPostgres) ClaimOptimization(ctx context.Context, userID string, claimedBefore, now time.Time) (bool, error) {
+	// 設定がないユーザーは既定値の行を作って記録する（同時に実行しても1つのサーバーだけが行を返す）
+	query := `
+		INSERT INTO review_settings (user_id, optimize_claimed_at)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE
+		SET optimize_claimed_at = EXCLUDED.optimize_claimed_at
+		WHERE review_settings.optimize_claimed_at IS NULL OR review_settings.optimize_claimed_at <= $3
+		RETURNING user_id
+	`
+
+	var claimed string
+	err := r.db.QueryRowContext(ctx, query, userID, now, claimedBefore).Scan(&claimed)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *reviewRepositoryPostgres) ClaimReminder(ctx context.Context, userID string, remindAt, now time.Time) (bool, error) {
+	// 同時に実行しても1つのサーバーだけが行を返す
+	query := `
+		UPDATE review_settings SET reminded_at = $3
+		WHERE user_id = $1 AND reminder_enabled AND (reminded_at IS NULL OR reminded_at < $2)
+		RETURNING user_id
+	`
+
+	var claimed string
+	err := r.db.QueryRowContext(ctx, query, userID, remindAt, now).Scan(&claimed)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *reviewRepositoryPostgres) CreateSession(ctx context.Context, state *models.ReviewSessionState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO review_session_states (id, user_id, state, started_at, last_active_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+	_, err = r.db.ExecContext(ctx, query, state.ID, state.UserID, data, state.StartedAt, state.LastActiveAt)
+	return err
+}
+
+func (r *reviewRepositoryPostgres) LockSession(ctx context.Context, id, token string, lease time.Duration) (*models.ReviewSessionState, error) {
+	// 同時に実行しても1つのリクエストだけが行を返す
+	query := `
+		UPDATE review_session_states SET locked_by = $2, locked_until = $3
+		WHERE id = $1 AND (locked_until IS NULL OR locked_until <= $4)
+		RETURNING user_id, state, started_at, last_active_at
+	`
+
+	now := time.Now()
+	state := &models.ReviewSessionState{ID: id}
+	var data []byte
+	err := r.db.QueryRowContext(ctx, query, id, token, now.Add(lease), now).
+		Scan(&state.UserID, &data, &state.StartedAt, &state.LastActiveAt)
+	if err == sql.ErrNoRows {
+		var exists bool
+		if err := r.db.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM review_session_states WHERE id = $1)`, id).Scan(&exists); err != nil {
+			return nil, err
+		}
+		if exists {
+			return nil, ErrReviewSessionLocked
+		}
+		return nil, ErrReviewSessionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+func (r *reviewRepositoryPostgres) SaveSession(ctx context.Context, state *models.ReviewSessionState, token string) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE review_session_states
+		SET state = $3, last_active_at = $4, locked_by = NULL, locked_until = NULL
+		WHERE id = $1 AND locked_by = $2
+	`
+	result, err := r.db.ExecContext(ctx, query, state.ID, token, data, state.LastActiveAt)
+	if err != nil {
+		return err
+	}
+	return sessionLockResult(result)
+}
+
+func (r *reviewRepositoryPostgres) UnlockSession(ctx context.Context, id, token string) error {
+	query := `UPDATE review_session_states SET locked_by = NULL, locked_until = NULL WHERE id = $1 AND locked_by = $2`
+
+	_, err := r.db.ExecContext(ctx, query, id, token)
+	return err
+}
+
+func (r *reviewRepositoryPostgres) DeleteSession(ctx context.Context, id, token string) error {
+	query := `DELETE FROM review_session_states WHERE id = $1 AND locked_by = $2`
+
+	result, err := r.db.ExecContext(ctx, query, id, token)
+	if err != nil {
+		return err
+	}
+	return sessionLockResult(result)
+}
+
+// sessionLockResult はロックしたセッションの更新・削除の結果を確認する（ロックを失っていた場合は ErrReviewSessionLocked）
+func sessionLockResult(result sql.Result) error {
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrReviewSessionLocked
+	}
+	return nil
+}
+
+func (r *reviewRepositoryPostgres) DeleteExpiredSessions(ctx context.Context, lastActiveBefore time.Time) error {
+	query := `DELETE FROM review_session_states WHERE last_active_at < $1`
+
+	_, err := r.db.ExecContext(ctx, query, lastActiveBefore)
+	return err
+}
+
+func (r *reviewRepositoryPostgres) SaveFSRSWeights(ctx context.Context, userID string, weights []float64, optimizedAt time.Time) (bool, error) {
+	query := `UPDATE review_settings SET fsrs_weights = $2, optimized_at = $3 WHERE user_id = $1`
+
+	result, err := r.db.ExecContext(ctx, query, userID, pq.Float64Array(weights), optimizedAt)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+// reviewSettingsColumns は review_settings の取得する列（scanSettings の順）
+const reviewSettingsColumns = `user_id, algorithm, new_cards_per_day, reviews_per_day,
+	leech_threshold, leech_window_days, leech_action,
+	reminder_enabled, reminder_time, reminder_timezone, reminder_channels, reminded_at,
+	fsrs_weights, optimized_at, updated_at`
+
+// scanSettings は reviewSettingsColumns の行を読み取る
+func scanSettings(row interface{ Scan(dest ...any) error }) (*models.ReviewSettings, error) {
+	settings := &models.ReviewSettings{}
+	var weights pq.Float64Array
+	var channels pq.StringArray
+	err := row.Scan(
+		&settings.UserID, &settings.Algorithm, &settings.NewCardsPerDay, &settings.ReviewsPerDay,
+		&settings.LeechThreshold, &settings.LeechWindowDays, &settings.LeechAction,
+		&settings.ReminderEnabled, &settings.ReminderTime, &settings.ReminderTimezone, &channels, &settings.RemindedAt,
+		&weights, &settings.OptimizedAt, &settings.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if len(weights) > 0 {
+		settings.FSRSWeights = weights
+	}
+	settings.ReminderChannels = channels
+	return settings, nil
+}
+
+func (r *reviewRepositoryPostgres) GetSettings(ctx context.Context, userID string) (*models.ReviewSettings, error) {
+	query := `SELECT ` + reviewSettingsColumns + ` FROM review_settings WHERE user_id = $1`
+
+	settings, err := scanSettings(r.db.QueryRowContext(ctx, query, userID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return settings, nil
+}
+
+func (r *reviewRepositoryPostgres) FindReminderSettings(ctx context.Context) ([]*models.ReviewSettings, error) {
+	query := `SELECT ` + reviewSettingsColumns + ` FROM review_settings WHERE reminder_enabled ORDER BY user_id`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []*models.ReviewSettings
+	for rows.Next() {
+		settings, err := scanSettings(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, settings)
+	}
+
+	return result, rows.Err()
+}
+
+func (r *reviewRepositoryPostgres) SaveSettings(ctx context.Context, settings *models.ReviewSettings) error {
+	query := `
+		INSERT INTO review_settings (
+			user_id, algorithm, new_cards_per_day, reviews_per_day,
+			leech_threshold, leech_window_days, leech_action,
+			reminder_enabled, reminder_time, reminder_timezone, reminder_channels, reminded_at,
+			fsrs_weights, optimized_at, updated_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+		ON CONFLICT (user_id) DO UPDATE
+		SET algorithm = EXCLUDED.algorithm,
+		    new_cards_per_day = EXCLUDED.new_cards_per_day, reviews_per_day = EXCLUDED.reviews_per_day,
+		    leech_threshold = EXCLUDED.leech_threshold, leech_window_days = EXCLUDED.leech_window_days,
+		    leech_action = EXCLUDED.leech_action,
+		    reminder_enabled = EXCLUDED.reminder_enabled, reminder_time = EXCLUDED.reminder_time,
+		    reminder_timezone = EXCLUDED.reminder_timezone, reminder_channels = EXCLUDED.reminder_channels,
+		    fsrs_weights = EXCLUDED.fsrs_weights,
+		    optimized_at = EXCLUDED.optimized_at, updated_at = EXCLUDED.updated_at
+	`
+
+	var weights any
+	if len(settings.FSRSWeights) > 0 {
+		weights = pq.Float64Array(settings.FSRSWeights)
+	}
+	channels := pq.StringArray(settings.ReminderChannels)
+	if channels == nil {
+		channels = pq.StringArray{}
+	}
 
 	_, err := r.db.ExecContext(ctx, query,
-		history.ID, history.UserID, history.ReviewItemID,
-		history.Score, timeSpentSeconds, history.ReviewedAt,
+		settings.UserID, settings.Algorithm, settings.NewCardsPerDay, settings.ReviewsPerDay,
+		settings.LeechThreshold, settings.LeechWindowDays, settings.LeechAction,
+		settings.ReminderEnabled, settings.ReminderTime, settings.ReminderTimezone, channels, settings.RemindedAt,
+		weights, settings.OptimizedAt, settings.UpdatedAt,
 	)
 	return err
 }
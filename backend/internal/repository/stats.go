@@ -322,6 +322,7 @@ func (r *StatsRepository) GetWeakPoints(ctx context.Context, userID uuid.UUID, l
 	return &models.WeakPointsData{
 		WeakWords:   []models.WeakItem{},
 		WeakPhrases: []models.WeakItem{},
+		Leeches:     []models.LeechItem{},
 	}, nil
 }
 
@@ -348,6 +349,31 @@ func (r *StatsRepository) RecordLearningSession(ctx context.Context, session *mo
 	return err
 }
 
+// RecordReviewSession records the summary of a finished review session
+func (r *StatsRepository) RecordReviewSession(ctx context.Context, summary *models.ReviewSessionSummary) error {
+	query := `
+		INSERT INTO review_sessions (
+			id, user_id, reviewed_count, correct_count, duration_seconds, time_spent_seconds, started_at, finished_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+
+	_, err := r.db.ExecContext(
+		ctx,
+		query,
+		summary.SessionID,
+		summary.UserID,
+		summary.Reviewed,
+		summary.Correct,
+		summary.DurationSec,
+		summary.TimeSpentSec,
+		summary.StartedAt,
+		summary.FinishedAt,
+	)
+
+	return err
+}
+
 // UpdateUserProgress updates user progress for a specific day
 func (r *StatsRepository) UpdateUserProgress(ctx context.Context, progress *models.UserProgressDaily) error {
 	// Implementation simplified - no daily progress tracking table in current schema
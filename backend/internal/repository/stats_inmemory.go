@@ -16,24 +16,28 @@ type StatsRepositoryInterface interface {
 	GetProgressData(ctx context.Context, userID uuid.UUID, period string) (*models.ProgressData, error)
 	GetWeakPoints(ctx context.Context, userID uuid.UUID, limit int) (*models.WeakPointsData, error)
 	RecordLearningSession(ctx context.Context, session *models.LearningSession) error
+	// RecordReviewSession は終了した復習セッションの集計を記録する
+	RecordReviewSession(ctx context.Context, summary *models.ReviewSessionSummary) error
 	UpdateUserProgress(ctx context.Context, progress *models.UserProgressDaily) error
 	UpdateStreak(ctx context.Context, userID uuid.UUID, activityDate time.Time) error
 }
 
 // InMemoryStatsRepository はInMemory実装
 type InMemoryStatsRepository struct {
-	sessions map[string]*models.LearningSession
-	progress map[string]map[string]*models.UserProgressDaily // userID -> date -> progress
-	streaks  map[string]*models.LearningStreakRecord
-	mu       sync.RWMutex
+	sessions       map[string]*models.LearningSession
+	reviewSessions map[string][]*models.ReviewSessionSummary       // userID -> summaries
+	progress       map[string]map[string]*models.UserProgressDaily // userID -> date -> progress
+	streaks        map[string]*models.LearningStreakRecord
+	mu             sync.RWMutex
 }
 
 // NewInMemoryStatsRepository は新しいInMemoryStatsRepositoryを作成
 func NewInMemoryStatsRepository() *InMemoryStatsRepository {
 	repo := &InMemoryStatsRepository{
-		sessions: make(map[string]*models.LearningSession),
-		progress: make(map[string]map[string]*models.UserProgressDaily),
-		streaks:  make(map[string]*models.LearningStreakRecord),
+		sessions:       make(map[string]*models.LearningSession),
+		reviewSessions: make(map[string][]*models.ReviewSessionSummary),
+		progress:       make(map[string]map[string]*models.UserProgressDaily),
+		streaks:        make(map[string]*models.LearningStreakRecord),
 	}
 
 	// サンプルデータ初期化
@@ -235,6 +239,7 @@ func (r *InMemoryStatsRepository) GetWeakPoints(ctx context.Context, userID uuid
 	return &models.WeakPointsData{
 		WeakWords:   []models.WeakItem{},
 		WeakPhrases: []models.WeakItem{},
+		Leeches:     []models.LeechItem{},
 	}, nil
 }
 
@@ -246,6 +251,41 @@ func (r *InMemoryStatsRepository) RecordLearningSession(ctx context.Context, ses
 	return nil
 }
 
+// RecordReviewSession は復習セッションの集計を記録し、セッションの時間をその日の学習時間に加える
+func (r *InMemoryStatsRepository) RecordReviewSession(ctx context.Context, summary *models.ReviewSessionSummary) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.reviewSessions[summary.UserID] = append(r.reviewSessions[summary.UserID], summary)
+
+	if _, exists := r.progress[summary.UserID]; !exists {
+		r.progress[summary.UserID] = make(map[string]*models.UserProgressDaily)
+	}
+	dateStr := summary.StartedAt.Format("2006-01-02")
+	progress, exists := r.progress[summary.UserID][dateStr]
+	if !exists {
+		userID, _ := uuid.Parse(summary.UserID)
+		progress = &models.UserProgressDaily{
+			ID:     uuid.New(),
+			UserID: userID,
+			Date:   summary.StartedAt,
+		}
+		r.progress[summary.UserID][dateStr] = progress
+	}
+	progress.LearningMinutes += (summary.DurationSec + 30) / 60
+	progress.UpdatedAt = time.Now()
+
+	return nil
+}
+
+// GetReviewSessions はユーザーの復習セッションの集計を記録した順に返す
+func (r *InMemoryStatsRepository) GetReviewSessions(ctx context.Context, userID string) []*models.ReviewSessionSummary {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return append([]*models.ReviewSessionSummary{}, r.reviewSessions[userID]...)
+}
+
 func (r *InMemoryStatsRepository) UpdateUserProgress(ctx context.Context, progress *models.UserProgressDaily) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
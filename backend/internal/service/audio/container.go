@@ -0,0 +1,419 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// opusSampleRate はOpusをデコードしたサンプリングレート
+const opusSampleRate = 48000
+
+// OpusDecoder はOpusの1つのストリームのパケットをデコードする
+// Goの標準ライブラリにはOpusのデコーダーがないため、libopus のバインディングなどの実装を SetOpusDecoder で設定する
+// 設定しない場合は SetFFmpegDecoder で設定したffmpegでデコードする
+type OpusDecoder interface {
+	// Decode はパケットを48kHzのインターリーブしたサンプル（-1〜1）にデコードする
+	Decode(packet []byte) ([]float32, error)
+}
+
+// OpusDecoderFactory はチャンネル数を指定してストリームのデコーダーを作成する
+type OpusDecoderFactory func(channels int) (OpusDecoder, error)
+
+// SetOpusDecoder はOggとWebMのOpusのデコーダーを設定する
+func (p *AudioProcessor) SetOpusDecoder(factory OpusDecoderFactory) {
+	p.opusDecoder = factory
+}
+
+// SetFFmpegDecoder はOpusのデコーダーが未設定の場合に使うffmpegを設定する
+// どちらも未設定の場合、Opusは ErrUnsupportedCodec になる
+func (p *AudioProcessor) SetFFmpegDecoder(decoder *FFmpegDecoder) {
+	p.ffmpeg = decoder
+}
+
+// opusHead はOpusのIDヘッダー（RFC 7845 5.1）
+type opusHead struct {
+	channels int
+	preSkip  int     // 先頭の捨てるサンプル数（48kHz）
+	gain     float64 // 出力のゲイン（倍率）
+}
+
+// parseOpusHead はOpusのIDヘッダーを解析する
+func parseOpusHead(packet []byte) (*opusHead, error) {
+	if len(packet) < 19 || !bytes.HasPrefix(packet, []byte("OpusHead")) {
+		return nil, fmt.Errorf("%w: OpusHeadがありません", ErrInvalidAudio)
+	}
+	head := &opusHead{
+		channels: int(packet[9]),
+		preSkip:  int(binary.LittleEndian.Uint16(packet[10:12])),
+		gain:     math.Pow(10, float64(int16(binary.LittleEndian.Uint16(packet[16:18])))/(20*256)),
+	}
+	if head.channels == 0 {
+		return nil, fmt.Errorf("%w: Opusのチャンネル数が0です", ErrInvalidAudio)
+	}
+	return head, nil
+}
+
+// decodeOpus はOpusのパケットをデコードし、先頭の preSkip サンプルを捨てる
+// Opusのデコーダーが未設定の場合は、元の音声（data）をffmpegでデコードする
+func (p *AudioProcessor) decodeOpus(head *opusHead, packets [][]byte, data []byte, format string) (*Signal, error) {
+	if p.opusDecoder == nil {
+		if p.ffmpeg != nil {
+			return p.ffmpeg.Decode(data, head.channels, format)
+		}
+		return nil, fmt.Errorf("%w: Opusのデコーダーが設定されていません", ErrUnsupportedCodec)
+	}
+	decoder, err := p.opusDecoder(head.channels)
+	if err != nil {
+		return nil, err
+	}
+
+	var interleaved []float64
+	for _, packet := range packets {
+		frame, err := decoder.Decode(packet)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidAudio, err)
+		}
+		for _, sample := range frame {
+			interleaved = append(interleaved, float64(sample)*head.gain)
+		}
+	}
+
+	samples := downmix(interleaved, head.channels)
+	if head.preSkip < len(samples) {
+		samples = samples[head.preSkip:]
+	} else {
+		samples = samples[:0]
+	}
+	return &Signal{Samples: samples, SampleRate: opusSampleRate, Channels: head.channels, Format: format}, nil
+}
+
+// decodeOgg はOggのOpusをデコードする（最初の論理ストリームだけを使用する）
+func (p *AudioProcessor) decodeOgg(data []byte) (*Signal, error) {
+	packets, err := oggPackets(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(packets) == 0 {
+		return nil, fmt.Errorf("%w: Oggのパケットがありません", ErrInvalidAudio)
+	}
+	if !bytes.HasPrefix(packets[0], []byte("OpusHead")) {
+		return nil, fmt.Errorf("%w: Ogg内のOpus以外のコーデック", ErrUnsupportedCodec)
+	}
+
+	head, err := parseOpusHead(packets[0])
+	if err != nil {
+		return nil, err
+	}
+	// 2つ目のパケットはコメントヘッダー（OpusTags）
+	if len(packets) < 2 {
+		return p.decodeOpus(head, nil, data, FormatOgg)
+	}
+	return p.decodeOpus(head, packets[2:], data, FormatOgg)
+}
+
+// oggPackets はOggのページを最初の論理ストリームのパケットに分割する（RFC 3533）
+func oggPackets(data []byte) ([][]byte, error) {
+	var (
+		packets [][]byte
+		current []byte
+		serial  uint32
+	)
+
+	for offset, first := 0, true; offset < len(data); first = false {
+		if offset+27 > len(data) || string(data[offset:offset+4]) != "OggS" {
+			return nil, fmt.Errorf("%w: Oggのページが壊れています", ErrInvalidAudio)
+		}
+		pageSerial := binary.LittleEndian.Uint32(data[offset+14 : offset+18])
+		segments := int(data[offset+26])
+		table := offset + 27
+		body := table + segments
+		if body > len(data) {
+			return nil, fmt.Errorf("%w: Oggのページが途中で終わっています", ErrInvalidAudio)
+		}
+		if first {
+			serial = pageSerial
+		}
+
+		position := body
+		for _, lacing := range data[table:body] {
+			end := position + int(lacing)
+			if end > len(data) {
+				return nil, fmt.Errorf("%w: Oggのページが途中で終わっています", ErrInvalidAudio)
+			}
+			if pageSerial == serial {
+				current = append(current, data[position:end]...)
+				// 255未満のセグメントでパケットが終わる（255のセグメントは次のページに続く）
+				if lacing < 255 {
+					packets = append(packets, current)
+					current = nil
+				}
+			}
+			position = end
+		}
+		offset = position
+	}
+
+	return packets, nil
+}
+
+// MatroskaのエレメントID
+const (
+	ebmlSegment           = 0x18538067
+	ebmlTracks            = 0x1654AE6B
+	ebmlTrackEntry        = 0xAE
+	ebmlTrackNumber       = 0xD7
+	ebmlTrackType         = 0x83
+	ebmlCodecID           = 0x86
+	ebmlCodecPrivate      = 0x63A2
+	ebmlAudio             = 0xE1
+	ebmlSamplingFrequency = 0xB5
+	ebmlChannels          = 0x9F
+	ebmlBitDepth          = 0x6264
+	ebmlCluster           = 0x1F43B675
+	ebmlBlockGroup        = 0xA0
+	ebmlBlock             = 0xA1
+	ebmlSimpleBlock       = 0xA3
+
+	matroskaTrackTypeAudio = 2
+)
+
+// ebmlMasters は子エレメントを読むエレメント（ブラウザの MediaRecorder はサイズ未確定で書き出すため、終わりを探さずに中に入る）
+var ebmlMasters = map[uint64]bool{
+	ebmlSegment:    true,
+	ebmlTracks:     true,
+	ebmlTrackEntry: true,
+	ebmlAudio:      true,
+	ebmlCluster:    true,
+	ebmlBlockGroup: true,
+}
+
+// webmTrack はWebMの音声トラック
+type webmTrack struct {
+	number       uint64
+	trackType    uint64
+	codecID      string
+	codecPrivate []byte
+	sampleRate   float64
+	channels     int
+	bitDepth     int
+}
+
+// webmBlock はトラックのフレーム
+type webmBlock struct {
+	track uint64
+	frame []byte
+}
+
+// decodeWebM はWebMの最初の音声トラックをデコードする（Opus、整数・浮動小数点のPCM）
+func (p *AudioProcessor) decodeWebM(data []byte) (*Signal, error) {
+	tracks, blocks, err := parseWebM(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var track *webmTrack
+	for _, candidate := range tracks {
+		if candidate.trackType == matroskaTrackTypeAudio || strings.HasPrefix(candidate.codecID, "A_") {
+			track = candidate
+			break
+		}
+	}
+	if track == nil {
+		return nil, fmt.Errorf("%w: WebMに音声トラックがありません", ErrInvalidAudio)
+	}
+
+	var frames [][]byte
+	for _, block := range blocks {
+		if block.track == track.number {
+			frames = append(frames, block.frame)
+		}
+	}
+
+	channels := track.channels
+	if channels <= 0 {
+		channels = 1
+	}
+
+	switch track.codecID {
+	case "A_OPUS":
+		head := &opusHead{channels: channels, gain: 1}
+		if len(track.codecPrivate) > 0 {
+			if head, err = parseOpusHead(track.codecPrivate); err != nil {
+				return nil, err
+			}
+		}
+		return p.decodeOpus(head, frames, data, FormatWebM)
+	case "A_PCM/INT/LIT", "A_PCM/FLOAT/IEEE":
+		float := track.codecID == "A_PCM/FLOAT/IEEE"
+		bits := track.bitDepth
+		if bits == 0 && float {
+			bits = 32
+		}
+		if !(float && (bits == 32 || bits == 64)) && !(!float && (bits == 8 || bits == 16 || bits == 24 || bits == 32)) {
+			return nil, fmt.Errorf("%w: WebMのPCM（%dbit）", ErrUnsupportedCodec, bits)
+		}
+		sampleRate := int(math.Round(track.sampleRate))
+		if !validSampleRate(sampleRate) {
+			return nil, fmt.Errorf("%w: サンプリングレート（%dHz）が不正です", ErrInvalidAudio, sampleRate)
+		}
+
+		pcm := bytes.Join(frames, nil)
+		frameSize := channels * bits / 8
+		pcm = pcm[:len(pcm)/frameSize*frameSize]
+		return &Signal{
+			Samples:    downmix(pcmSamples(pcm, bits, float), channels),
+			SampleRate: sampleRate,
+			Channels:   channels,
+			Format:     FormatWebM,
+		}, nil
+	default:
+		return nil, fmt.Errorf("%w: WebMのコーデック %s", ErrUnsupportedCodec, track.codecID)
+	}
+}
+
+// parseWebM はWebMのトラックとブロックを読む
+// 子エレメントを読むエレメント（ebmlMasters）以外はサイズ分を読み飛ばす
+func parseWebM(data []byte) ([]*webmTrack, []webmBlock, error) {
+	var (
+		tracks []*webmTrack
+		blocks []webmBlock
+	)
+
+	for offset := 0; offset < len(data); {
+		id, idLength, ok := ebmlID(data[offset:])
+		if !ok {
+			return nil, nil, fmt.Errorf("%w: EBMLのIDが不正です", ErrInvalidAudio)
+		}
+		size, sizeLength, known, ok := ebmlSize(data[offset+idLength:])
+		if !ok {
+			return nil, nil, fmt.Errorf("%w: EBMLのサイズが不正です", ErrInvalidAudio)
+		}
+		body := offset + idLength + sizeLength
+
+		if ebmlMasters[id] {
+			if id == ebmlTrackEntry {
+				tracks = append(tracks, &webmTrack{})
+			}
+			offset = body
+			continue
+		}
+		if !known {
+			return nil, nil, fmt.Errorf("%w: サイズ未確定のエレメント %X", ErrInvalidAudio, id)
+		}
+		end := body + int(size)
+		if size > uint64(len(data)) || end > len(data) {
+			// 録音の途中で切れたファイルは最後の不完全なエレメントを無視する
+			break
+		}
+		value := data[body:end]
+
+		var track *webmTrack
+		if len(tracks) > 0 {
+			track = tracks[len(tracks)-1]
+		}
+		switch {
+		case id == ebmlSimpleBlock || id == ebmlBlock:
+			block, err := parseWebMBlock(value)
+			if err != nil {
+				return nil, nil, err
+			}
+			blocks = append(blocks, block)
+		case track == nil:
+		case id == ebmlTrackNumber:
+			track.number = ebmlUint(value)
+		case id == ebmlTrackType:
+			track.trackType = ebmlUint(value)
+		case id == ebmlCodecID:
+			track.codecID = strings.TrimRight(string(value), "\x00")
+		case id == ebmlCodecPrivate:
+			track.codecPrivate = value
+		case id == ebmlSamplingFrequency:
+			track.sampleRate = ebmlFloat(value)
+		case id == ebmlChannels:
+			track.channels = int(ebmlUint(value))
+		case id == ebmlBitDepth:
+			track.bitDepth = int(ebmlUint(value))
+		}
+		offset = end
+	}
+
+	return tracks, blocks, nil
+}
+
+// parseWebMBlock はブロックのトラック番号とフレームを読む（レーシングしたブロックには対応しない）
+func parseWebMBlock(value []byte) (webmBlock, error) {
+	track, length, _, ok := ebmlSize(value)
+	if !ok || len(value) < length+3 {
+		return webmBlock{}, fmt.Errorf("%w: WebMのブロックが不正です", ErrInvalidAudio)
+	}
+	flags := value[length+2]
+	if flags&0x06 != 0 {
+		return webmBlock{}, fmt.Errorf("%w: レーシングしたWebMのブロック", ErrUnsupportedFormat)
+	}
+	return webmBlock{track: track, frame: value[length+3:]}, nil
+}
+
+// ebmlID はEBMLの可変長のID（長さを示す先頭のビットを含む）と長さを返す
+func ebmlID(data []byte) (uint64, int, bool) {
+	if len(data) == 0 || data[0] == 0 {
+		return 0, 0, false
+	}
+	length := 1
+	for mask := byte(0x80); data[0]&mask == 0; mask >>= 1 {
+		length++
+	}
+	if length > 4 || len(data) < length {
+		return 0, 0, false
+	}
+	var id uint64
+	for _, b := range data[:length] {
+		id = id<<8 | uint64(b)
+	}
+	return id, length, true
+}
+
+// ebmlSize はEBMLの可変長の整数（サイズ）と長さ、サイズが確定しているかを返す
+func ebmlSize(data []byte) (uint64, int, bool, bool) {
+	if len(data) == 0 || data[0] == 0 {
+		return 0, 0, false, false
+	}
+	length := 1
+	mask := byte(0x80)
+	for ; data[0]&mask == 0; mask >>= 1 {
+		length++
+	}
+	if len(data) < length {
+		return 0, 0, false, false
+	}
+
+	value := uint64(data[0] &^ mask)
+	allOnes := value == uint64(mask-1)
+	for _, b := range data[1:length] {
+		value = value<<8 | uint64(b)
+		allOnes = allOnes && b == 0xFF
+	}
+	return value, length, !allOnes, true
+}
+
+// ebmlUint はEBMLの符号なし整数を読む
+func ebmlUint(value []byte) uint64 {
+	var v uint64
+	for _, b := range value {
+		v = v<<8 | uint64(b)
+	}
+	return v
+}
+
+// ebmlFloat はEBMLの浮動小数点数（4または8バイト）を読む
+func ebmlFloat(value []byte) float64 {
+	switch len(value) {
+	case 4:
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(value)))
+	case 8:
+		return math.Float64frombits(binary.BigEndian.Uint64(value))
+	}
+	return 0
+}
@@ -0,0 +1,268 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// 音声フォーマット
+const (
+	FormatWAV  = "wav"
+	FormatPCM  = "pcm" // ヘッダーのない16bitリトルエンディアンのPCM
+	FormatOgg  = "ogg"
+	FormatWebM = "webm"
+)
+
+const (
+	// RawSampleRate はヘッダーのないPCMのサンプリングレート（モノラル・16bit）
+	RawSampleRate = 16000
+	// minRawSize はヘッダーのないPCMとして有効な最小のサイズ（バイト）
+	minRawSize = 10
+	// MinSampleRate と MaxSampleRate はデコード・変換できるサンプリングレートの範囲
+	// 極端なサンプリングレートで変換の計算量やメモリが大きくなりすぎないようにする
+	MinSampleRate = 8000
+	MaxSampleRate = 192000
+)
+
+var (
+	// ErrUnsupportedFormat はデコードできない音声フォーマットの場合のエラー
+	ErrUnsupportedFormat = errors.New("対応していない音声フォーマットです")
+	// ErrUnsupportedCodec はコンテナ内のコーデックをデコードできない場合のエラー
+	ErrUnsupportedCodec = errors.New("対応していない音声コーデックです")
+	// ErrInvalidAudio は音声データが壊れている場合のエラー
+	ErrInvalidAudio = errors.New("音声データが不正です")
+)
+
+// Signal はデコードした音声
+// チャンネルはデコード時にモノラルにミックスする
+type Signal struct {
+	Samples    []float64 // サンプル（-1〜1）
+	SampleRate int
+	Channels   int    // 元のチャンネル数
+	Format     string // 元のフォーマット
+}
+
+// Duration は音声の長さ（秒）を返す
+func (s *Signal) Duration() float64 {
+	if s.SampleRate <= 0 {
+		return 0
+	}
+	return float64(len(s.Samples)) / float64(s.SampleRate)
+}
+
+// detectFormat は先頭のバイト列から音声フォーマットを判定する
+// MP3・MP4（AAC）・FLACはデコードできないため、PCMとして扱わずにエラーにする
+func detectFormat(data []byte) (string, error) {
+	switch {
+	case len(data) >= 12 && string(data[0:4]) == "RIFF" && string(data[8:12]) == "WAVE":
+		return FormatWAV, nil
+	case bytes.HasPrefix(data, []byte("OggS")):
+		return FormatOgg, nil
+	case bytes.HasPrefix(data, []byte{0x1A, 0x45, 0xDF, 0xA3}):
+		return FormatWebM, nil
+	case bytes.HasPrefix(data, []byte("ID3")),
+		bytes.HasPrefix(data, []byte("fLaC")),
+		len(data) >= 8 && string(data[4:8]) == "ftyp":
+		return "", ErrUnsupportedFormat
+	}
+	return FormatPCM, nil
+}
+
+// Decode は音声データをデコードする
+// WAV（整数・浮動小数点のPCM）、Ogg・WebMのコンテナ、ヘッダーのないPCM（RawSampleRate・モノラル・16bit）に対応する
+// OggとWebMのOpusは SetOpusDecoder で設定したデコーダー、または SetFFmpegDecoder で設定したffmpegでデコードする
+func (p *AudioProcessor) Decode(audioData []byte) (*Signal, error) {
+	if len(audioData) == 0 {
+		return nil, fmt.Errorf("音声データが空です")
+	}
+
+	format, err := detectFormat(audioData)
+	if err != nil {
+		return nil, err
+	}
+
+	switch format {
+	case FormatWAV:
+		return decodeWAV(audioData)
+	case FormatOgg:
+		return p.decodeOgg(audioData)
+	case FormatWebM:
+		return p.decodeWebM(audioData)
+	default:
+		return decodeRaw(audioData), nil
+	}
+}
+
+// decodeRaw はヘッダーのないPCMをデコードする（最後の半端なバイトは無視する）
+func decodeRaw(data []byte) *Signal {
+	return &Signal{
+		Samples:    pcmSamples(data[:len(data)/2*2], 16, false),
+		SampleRate: RawSampleRate,
+		Channels:   1,
+		Format:     FormatPCM,
+	}
+}
+
+// WAVのフォーマットコード
+const (
+	wavFormatPCM        = 1
+	wavFormatFloat      = 3
+	wavFormatExtensible = 0xFFFE
+)
+
+// decodeWAV はWAVをデコードする
+// 録音中に書き出したファイル（dataチャンクのサイズが未確定）も、ファイルの終わりまでをデータとして扱う
+func decodeWAV(data []byte) (*Signal, error) {
+	var (
+		format, channels, bits int
+		sampleRate             int
+		samples                []byte
+		hasFormat, hasData     bool
+	)
+
+	for offset := 12; offset+8 <= len(data) && !hasData; {
+		id := string(data[offset : offset+4])
+		size := int(binary.LittleEndian.Uint32(data[offset+4 : offset+8]))
+		body := offset + 8
+		if size < 0 || body+size > len(data) {
+			size = len(data) - body
+		}
+
+		switch id {
+		case "fmt ":
+			if size < 16 {
+				return nil, fmt.Errorf("%w: fmtチャンクが短すぎます", ErrInvalidAudio)
+			}
+			chunk := data[body : body+size]
+			format = int(binary.LittleEndian.Uint16(chunk[0:2]))
+			channels = int(binary.LittleEndian.Uint16(chunk[2:4]))
+			sampleRate = int(binary.LittleEndian.Uint32(chunk[4:8]))
+			bits = int(binary.LittleEndian.Uint16(chunk[14:16]))
+			// WAVE_FORMAT_EXTENSIBLE はサブフォーマットのGUIDの先頭がフォーマットコード
+			if format == wavFormatExtensible && size >= 26 {
+				format = int(binary.LittleEndian.Uint16(chunk[24:26]))
+			}
+			hasFormat = true
+		case "data":
+			samples = data[body : body+size]
+			hasData = true
+		}
+
+		// チャンクは2バイト境界に揃える
+		offset = body + size + size%2
+	}
+
+	if !hasFormat || !hasData {
+		return nil, fmt.Errorf("%w: fmtチャンクまたはdataチャンクがありません", ErrInvalidAudio)
+	}
+	if channels <= 0 || !validSampleRate(sampleRate) {
+		return nil, fmt.Errorf("%w: チャンネル数またはサンプリングレート（%dHz）が不正です", ErrInvalidAudio, sampleRate)
+	}
+
+	var float bool
+	switch {
+	case format == wavFormatPCM && (bits == 8 || bits == 16 || bits == 24 || bits == 32):
+	case format == wavFormatFloat && (bits == 32 || bits == 64):
+		float = true
+	default:
+		return nil, fmt.Errorf("%w: WAVのフォーマット %d（%dbit）", ErrUnsupportedCodec, format, bits)
+	}
+
+	frameSize := channels * bits / 8
+	samples = samples[:len(samples)/frameSize*frameSize]
+	return &Signal{
+		Samples:    downmix(pcmSamples(samples, bits, float), channels),
+		SampleRate: sampleRate,
+		Channels:   channels,
+		Format:     FormatWAV,
+	}, nil
+}
+
+// validSampleRate はサンプリングレートが MinSampleRate〜MaxSampleRate の範囲かどうかを返す
+func validSampleRate(sampleRate int) bool {
+	return sampleRate >= MinSampleRate && sampleRate <= MaxSampleRate
+}
+
+// pcmSamples はリトルエンディアンのPCMを -1〜1 のサンプルに変換する（8bitは符号なし）
+func pcmSamples(data []byte, bits int, float bool) []float64 {
+	size := bits / 8
+	samples := make([]float64, len(data)/size)
+	for i := range samples {
+		b := data[i*size : (i+1)*size]
+		switch {
+		case float && bits == 32:
+			samples[i] = float64(math.Float32frombits(binary.LittleEndian.Uint32(b)))
+		case float && bits == 64:
+			samples[i] = math.Float64frombits(binary.LittleEndian.Uint64(b))
+		case bits == 8:
+			samples[i] = (float64(b[0]) - 128) / 128
+		case bits == 16:
+			samples[i] = float64(int16(binary.LittleEndian.Uint16(b))) / 32768
+		case bits == 24:
+			v := int32(uint32(b[0])<<8|uint32(b[1])<<16|uint32(b[2])<<24) >> 8
+			samples[i] = float64(v) / 8388608
+		case bits == 32:
+			samples[i] = float64(int32(binary.LittleEndian.Uint32(b))) / 2147483648
+		}
+	}
+	return samples
+}
+
+// downmix はインターリーブしたサンプルをモノラルにミックスする
+func downmix(interleaved []float64, channels int) []float64 {
+	if channels <= 1 {
+		return interleaved
+	}
+	mono := make([]float64, len(interleaved)/channels)
+	for i := range mono {
+		var sum float64
+		for c := 0; c < channels; c++ {
+			sum += interleaved[i*channels+c]
+		}
+		mono[i] = sum / float64(channels)
+	}
+	return mono
+}
+
+// WrapPCM はヘッダーのない16bit・モノラルのPCMにWAVのヘッダーを付ける（最後の半端なバイトは除く）
+// RawSampleRate 以外のサンプリングレートのPCMを Decode や Process に渡す場合に使う
+func WrapPCM(pcm []byte, sampleRate int) []byte {
+	pcm = pcm[:len(pcm)/2*2]
+	buf := make([]byte, 44, 44+len(pcm))
+	writeWAVHeader(buf, sampleRate, len(pcm))
+	return append(buf, pcm...)
+}
+
+// writeWAVHeader は16bit・モノラルのPCMのWAVのヘッダー（44バイト）を書き込む
+func writeWAVHeader(buf []byte, sampleRate, dataSize int) {
+	copy(buf[0:4], "RIFF")
+	binary.LittleEndian.PutUint32(buf[4:8], uint32(36+dataSize))
+	copy(buf[8:12], "WAVE")
+	copy(buf[12:16], "fmt ")
+	binary.LittleEndian.PutUint32(buf[16:20], 16)
+	binary.LittleEndian.PutUint16(buf[20:22], wavFormatPCM)
+	binary.LittleEndian.PutUint16(buf[22:24], 1)
+	binary.LittleEndian.PutUint32(buf[24:28], uint32(sampleRate))
+	binary.LittleEndian.PutUint32(buf[28:32], uint32(sampleRate*2))
+	binary.LittleEndian.PutUint16(buf[32:34], 2)
+	binary.LittleEndian.PutUint16(buf[34:36], 16)
+	copy(buf[36:40], "data")
+	binary.LittleEndian.PutUint32(buf[40:44], uint32(dataSize))
+}
+
+// EncodeWAV はモノラルのサンプルを16bitのPCMのWAVにする（範囲外のサンプルはクリップする）
+func EncodeWAV(samples []float64, sampleRate int) []byte {
+	dataSize := len(samples) * 2
+	buf := make([]byte, 44+dataSize)
+	writeWAVHeader(buf, sampleRate, dataSize)
+
+	for i, sample := range samples {
+		v := math.Round(sample * 32767)
+		v = math.Max(-32768, math.Min(32767, v))
+		binary.LittleEndian.PutUint16(buf[44+i*2:], uint16(int16(v)))
+	}
+	return buf
+}
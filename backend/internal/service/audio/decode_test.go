@@ -0,0 +1,291 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// wavFile はテスト用のWAVを作成する（samples はインターリーブしたサンプル）
+func wavFile(format, channels, sampleRate, bits int, samples []float64) []byte {
+	var data bytes.Buffer
+	for _, sample := range samples {
+		switch {
+		case format == wavFormatFloat:
+			binary.Write(&data, binary.LittleEndian, float32(sample))
+		case bits == 16:
+			binary.Write(&data, binary.LittleEndian, int16(sample*32767))
+		case bits == 24:
+			v := int32(sample * 8388607)
+			data.Write([]byte{byte(v), byte(v >> 8), byte(v >> 16)})
+		}
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("RIFF")
+	binary.Write(&buf, binary.LittleEndian, uint32(4+8+40+8+data.Len()))
+	buf.WriteString("WAVE")
+	// WAVE_FORMAT_EXTENSIBLE の fmt チャンク
+	buf.WriteString("fmt ")
+	binary.Write(&buf, binary.LittleEndian, uint32(40))
+	binary.Write(&buf, binary.LittleEndian, uint16(wavFormatExtensible))
+	binary.Write(&buf, binary.LittleEndian, uint16(channels))
+	binary.Write(&buf, binary.LittleEndian, uint32(sampleRate))
+	binary.Write(&buf, binary.LittleEndian, uint32(sampleRate*channels*bits/8))
+	binary.Write(&buf, binary.LittleEndian, uint16(channels*bits/8))
+	binary.Write(&buf, binary.LittleEndian, uint16(bits))
+	binary.Write(&buf, binary.LittleEndian, uint16(22))
+	binary.Write(&buf, binary.LittleEndian, uint16(bits))
+	binary.Write(&buf, binary.LittleEndian, uint32(0))
+	binary.Write(&buf, binary.LittleEndian, uint16(format))
+	buf.Write(make([]byte, 14))
+	// デコードでは読み飛ばすチャンク（奇数サイズはパディングする）
+	buf.WriteString("LIST")
+	binary.Write(&buf, binary.LittleEndian, uint32(3))
+	buf.Write([]byte{1, 2, 3, 0})
+	buf.WriteString("data")
+	binary.Write(&buf, binary.LittleEndian, uint32(data.Len()))
+	buf.Write(data.Bytes())
+	return buf.Bytes()
+}
+
+// ebmlElement はテスト用のEBMLのエレメントを作成する（unknownSize の場合はサイズ未確定）
+func ebmlElement(id uint64, body []byte, unknownSize bool) []byte {
+	var buf bytes.Buffer
+	for shift := 24; shift >= 0; shift -= 8 {
+		if b := byte(id >> shift); b != 0 || buf.Len() > 0 {
+			buf.WriteByte(b)
+		}
+	}
+	if unknownSize {
+		buf.Write([]byte{0x01, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF})
+	} else {
+		size := make([]byte, 8)
+		binary.BigEndian.PutUint64(size, uint64(len(body)))
+		size[0] = 0x01
+		buf.Write(size)
+	}
+	buf.Write(body)
+	return buf.Bytes()
+}
+
+// fakeOpusDecoder はパケットの1バイト目の値の1/100を20ms分（960サンプル）返すテスト用のデコーダー
+type fakeOpusDecoder struct {
+	channels int
+}
+
+func (d *fakeOpusDecoder) Decode(packet []byte) ([]float32, error) {
+	if len(packet) == 0 {
+		return nil, errors.New("empty packet")
+	}
+	frame := make([]float32, 960*d.channels)
+	for i := range frame {
+		frame[i] = float32(packet[0]) / 100
+	}
+	return frame, nil
+}
+
+func fakeOpusFactory(channels int) (OpusDecoder, error) {
+	return &fakeOpusDecoder{channels: channels}, nil
+}
+
+// opusHeadPacket はテスト用のOpusHeadを作成する
+func opusHeadPacket(channels, preSkip int) []byte {
+	head := []byte("OpusHead")
+	head = append(head, 1, byte(channels))
+	head = binary.LittleEndian.AppendUint16(head, uint16(preSkip))
+	head = binary.LittleEndian.AppendUint32(head, 48000)
+	head = binary.LittleEndian.AppendUint16(head, 0)
+	return append(head, 0)
+}
+
+// oggPage はテスト用のOggのページを作成する
+func oggPage(serial uint32, packets ...[]byte) []byte {
+	var table, body []byte
+	for _, packet := range packets {
+		remaining := len(packet)
+		for remaining >= 255 {
+			table = append(table, 255)
+			remaining -= 255
+		}
+		table = append(table, byte(remaining))
+		body = append(body, packet...)
+	}
+
+	page := []byte("OggS")
+	page = append(page, 0, 0)
+	page = append(page, make([]byte, 8)...)
+	page = binary.LittleEndian.AppendUint32(page, serial)
+	page = append(page, make([]byte, 8)...)
+	page = append(page, byte(len(table)))
+	page = append(page, table...)
+	return append(page, body...)
+}
+
+func TestDecodeWAV(t *testing.T) {
+	processor := NewAudioProcessor()
+
+	// ステレオはモノラルにミックスする
+	stereo := []float64{0.5, -0.5, 0.25, 0.75, -1, -1}
+	signal, err := processor.Decode(wavFile(wavFormatPCM, 2, 44100, 16, stereo))
+	require.NoError(t, err)
+	assert.Equal(t, FormatWAV, signal.Format)
+	assert.Equal(t, 44100, signal.SampleRate)
+	assert.Equal(t, 2, signal.Channels)
+	require.Len(t, signal.Samples, 3)
+	assert.InDelta(t, 0, signal.Samples[0], 1e-4)
+	assert.InDelta(t, 0.5, signal.Samples[1], 1e-4)
+	assert.InDelta(t, -1, signal.Samples[2], 1e-4)
+
+	signal, err = processor.Decode(wavFile(wavFormatPCM, 1, 8000, 24, []float64{0.5, -0.25}))
+	require.NoError(t, err)
+	assert.InDeltaSlice(t, []float64{0.5, -0.25}, signal.Samples, 1e-6)
+
+	signal, err = processor.Decode(wavFile(wavFormatFloat, 1, 48000, 32, []float64{0.125, -0.875}))
+	require.NoError(t, err)
+	assert.InDeltaSlice(t, []float64{0.125, -0.875}, signal.Samples, 1e-7)
+
+	// 書き出したWAVを読み直せる
+	encoded := EncodeWAV([]float64{0, 0.5, -0.5, 2}, 16000)
+	signal, err = processor.Decode(encoded)
+	require.NoError(t, err)
+	assert.Equal(t, 16000, signal.SampleRate)
+	assert.InDeltaSlice(t, []float64{0, 0.5, -0.5, 1}, signal.Samples, 1e-4)
+	assert.InDelta(t, 0.00025, signal.Duration(), 1e-9)
+}
+
+func TestDecode_Raw(t *testing.T) {
+	processor := NewAudioProcessor()
+
+	raw := make([]byte, 0, 7)
+	raw = binary.LittleEndian.AppendUint16(raw, uint16(16384))
+	raw = binary.LittleEndian.AppendUint16(raw, uint16(0xC000))
+	raw = append(raw, 0xFF)
+
+	signal, err := processor.Decode(raw)
+	require.NoError(t, err)
+	assert.Equal(t, FormatPCM, signal.Format)
+	assert.Equal(t, RawSampleRate, signal.SampleRate)
+	assert.Equal(t, []float64{0.5, -0.5}, signal.Samples)
+}
+
+func TestDecode_Unsupported(t *testing.T) {
+	processor := NewAudioProcessor()
+
+	for _, data := range [][]byte{
+		append([]byte("ID3\x04\x00"), make([]byte, 32)...),
+		append([]byte("\x00\x00\x00\x20ftypM4A "), make([]byte, 32)...),
+		append([]byte("fLaC"), make([]byte, 32)...),
+	} {
+		_, err := processor.Decode(data)
+		assert.ErrorIs(t, err, ErrUnsupportedFormat)
+
+		valid, err := processor.ValidateFormat(data)
+		require.NoError(t, err)
+		assert.False(t, valid)
+	}
+
+	_, err := processor.Decode(wavFile(wavFormatPCM, 1, 16000, 16, nil)[:20])
+	assert.ErrorIs(t, err, ErrInvalidAudio)
+}
+
+func TestDecodeOgg(t *testing.T) {
+	// 複数のセグメントに分かれたパケット（255バイト以上）を含む
+	long := bytes.Repeat([]byte{50}, 300)
+	data := append(oggPage(7, opusHeadPacket(2, 480), []byte("OpusTags")), oggPage(7, []byte{20}, long)...)
+	data = append(data, oggPage(9, []byte{99})...)
+
+	processor := NewAudioProcessor()
+	_, err := processor.Decode(data)
+	assert.ErrorIs(t, err, ErrUnsupportedCodec)
+
+	processor.SetOpusDecoder(fakeOpusFactory)
+	signal, err := processor.Decode(data)
+	require.NoError(t, err)
+	assert.Equal(t, FormatOgg, signal.Format)
+	assert.Equal(t, 48000, signal.SampleRate)
+	assert.Equal(t, 2, signal.Channels)
+
+	// 先頭の480サンプルを捨て、別の論理ストリームのパケットは含めない
+	require.Len(t, signal.Samples, 2*960-480)
+	assert.InDelta(t, 0.2, signal.Samples[0], 1e-6)
+	assert.InDelta(t, 0.5, signal.Samples[len(signal.Samples)-1], 1e-6)
+}
+
+func TestDecodeOgg_FFmpeg(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake ffmpeg binary requires a POSIX shell")
+	}
+	data := oggPage(7, opusHeadPacket(2, 480), []byte("OpusTags"), []byte{20})
+
+	// 16bitのPCM（0.5, -0.5）を出力する偽のffmpeg
+	tmpDir := t.TempDir()
+	argsFile := filepath.Join(tmpDir, "args")
+	script := "#!/bin/sh\necho \"$@\" > " + argsFile + "\ncat > /dev/null\nprintf '\\000\\100\\000\\300'\n"
+	binary := filepath.Join(tmpDir, "ffmpeg")
+	require.NoError(t, os.WriteFile(binary, []byte(script), 0755))
+
+	processor := NewAudioProcessor()
+	processor.SetFFmpegDecoder(NewFFmpegDecoder(binary))
+	signal, err := processor.Decode(data)
+	require.NoError(t, err)
+	assert.Equal(t, FormatOgg, signal.Format)
+	assert.Equal(t, 48000, signal.SampleRate)
+	assert.Equal(t, 2, signal.Channels)
+	assert.Equal(t, []float64{0.5, -0.5}, signal.Samples)
+
+	args, err := os.ReadFile(argsFile)
+	require.NoError(t, err)
+	assert.Equal(t, "-hide_banner -loglevel error -i pipe:0 -f s16le -acodec pcm_s16le -ac 1 -ar 48000 pipe:1", strings.TrimSpace(string(args)))
+
+	// ffmpegがない場合は元の音声をそのまま渡さず、エラーにする
+	processor.SetFFmpegDecoder(NewFFmpegDecoder(filepath.Join(tmpDir, "missing-ffmpeg")))
+	_, err = processor.Process(data)
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrUnsupportedCodec)
+}
+
+func TestDecodeWebM(t *testing.T) {
+	pcm := make([]byte, 0)
+	for _, sample := range []float32{0.5, 0.25, -0.5, -0.25} {
+		pcm = binary.LittleEndian.AppendUint32(pcm, math.Float32bits(sample))
+	}
+	block := func(frame []byte) []byte {
+		return ebmlElement(ebmlSimpleBlock, append([]byte{0x81, 0, 0, 0x80}, frame...), false)
+	}
+
+	sampleRate := make([]byte, 8)
+	binary.BigEndian.PutUint64(sampleRate, math.Float64bits(44100))
+	tracks := ebmlElement(ebmlTracks, ebmlElement(ebmlTrackEntry, bytes.Join([][]byte{
+		ebmlElement(ebmlTrackNumber, []byte{1}, false),
+		ebmlElement(ebmlTrackType, []byte{matroskaTrackTypeAudio}, false),
+		ebmlElement(ebmlCodecID, []byte("A_PCM/FLOAT/IEEE"), false),
+		ebmlElement(ebmlAudio, bytes.Join([][]byte{
+			ebmlElement(ebmlSamplingFrequency, sampleRate, false),
+			ebmlElement(ebmlChannels, []byte{2}, false),
+			ebmlElement(ebmlBitDepth, []byte{32}, false),
+		}, nil), false),
+	}, nil), false), false)
+
+	// ブラウザの MediaRecorder と同じく、Segment と Cluster はサイズ未確定にする
+	cluster := ebmlElement(ebmlCluster, append(block(pcm[:8]), block(pcm[8:])...), true)
+	data := bytes.Join([][]byte{
+		ebmlElement(0x1A45DFA3, ebmlElement(0x4282, []byte("webm"), false), false),
+		ebmlElement(ebmlSegment, append(tracks, cluster...), true),
+	}, nil)
+
+	signal, err := NewAudioProcessor().Decode(data)
+	require.NoError(t, err)
+	assert.Equal(t, FormatWebM, signal.Format)
+	assert.Equal(t, 44100, signal.SampleRate)
+	assert.InDeltaSlice(t, []float64{0.375, -0.375}, signal.Samples, 1e-7)
+}
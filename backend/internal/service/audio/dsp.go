@@ -0,0 +1,418 @@
+package audio
+
+import (
+	"math"
+	"math/cmplx"
+	"sort"
+)
+
+const (
+	// ノイズの推定のフレーム（25ms、10msごと）
+	noiseFrameDuration = 0.025
+	noiseHopDuration   = 0.010
+	// noisePercentile と signalPercentile はノイズと音声の音量とみなすフレームの音量のパーセンタイル
+	noisePercentile  = 0.1
+	signalPercentile = 0.9
+	// maxSNR はノイズがない（デジタル無音の）場合のSNR（dB）
+	maxSNR = 60.0
+
+	// スペクトルのノイズゲート
+	gateFrameDuration = 0.032 // FFTのフレームの長さ（秒、2のべき乗に切り上げる）
+	gateOverlap       = 4     // フレームの重なり（ホップはフレームの1/4）
+	gateStdDevs       = 1.5   // ノイズの平均からこの標準偏差の倍数を超える成分を音声とみなす
+	gateFloor         = 0.1   // 音声でない成分の倍率（-20dB）
+	gateMinSNR        = 6.0   // これ未満のSNRではノイズと音声を区別できないため、ゲートをかけない
+
+	// 音量の正規化（ITU-R BS.1770）
+	loudnessBlockDuration = 0.4   // ゲートのブロックの長さ（秒）
+	loudnessStepDuration  = 0.1   // ブロックの間隔（秒）
+	loudnessAbsoluteGate  = -70.0 // 絶対ゲート（LUFS）
+	loudnessRelativeGate  = -10.0 // 相対ゲート（LU）
+	maxNormalizeGain      = 30.0  // 正規化で上げる音量の上限（dB）
+	peakLimit             = 0.891 // 正規化後のピークの上限（-1dBFS）
+
+	// resampleZeroCrossings はリサンプリングのフィルター（窓付きsinc）の片側の零交差の数
+	resampleZeroCrossings = 16
+)
+
+// noiseEstimate はノイズの推定結果
+type noiseEstimate struct {
+	noiseRMS  float64 // ノイズの音量（静かなフレームの実効値）
+	signalRMS float64 // 音声の音量（大きなフレームの実効値）
+	snr       float64 // 信号対雑音比（dB）
+	level     float64 // ノイズの音声に対する振幅の比（0-1、音声がない場合は1）
+}
+
+// estimateNoise は短いフレームの音量の分布から、ノイズ（静かなフレーム）と音声（大きなフレーム）の音量とSNRを推定する
+func estimateNoise(samples []float64, sampleRate int) noiseEstimate {
+	frame := int(noiseFrameDuration * float64(sampleRate))
+	hop := int(noiseHopDuration * float64(sampleRate))
+	if frame < 1 || hop < 1 {
+		frame, hop = 1, 1
+	}
+
+	var levels []float64
+	for start := 0; start+frame <= len(samples); start += hop {
+		levels = append(levels, rms(samples[start:start+frame]))
+	}
+	if len(levels) == 0 {
+		levels = append(levels, rms(samples))
+	}
+	sort.Float64s(levels)
+
+	estimate := noiseEstimate{
+		noiseRMS:  percentile(levels, noisePercentile),
+		signalRMS: percentile(levels, signalPercentile),
+		level:     1,
+	}
+	switch {
+	case estimate.signalRMS == 0:
+	case estimate.noiseRMS == 0:
+		estimate.snr = maxSNR
+		estimate.level = math.Pow(10, -maxSNR/20)
+	default:
+		estimate.snr = math.Min(maxSNR, 20*math.Log10(estimate.signalRMS/estimate.noiseRMS))
+		estimate.level = math.Min(1, estimate.noiseRMS/estimate.signalRMS)
+	}
+	return estimate
+}
+
+// rms は実効値を返す
+func rms(samples []float64) float64 {
+	if len(samples) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, sample := range samples {
+		sum += sample * sample
+	}
+	return math.Sqrt(sum / float64(len(samples)))
+}
+
+// percentile は昇順に並べた値の p（0-1）のパーセンタイルを返す
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	return sorted[int(math.Round(p*float64(len(sorted)-1)))]
+}
+
+// spectralGate はスペクトルのノイズゲートをかける
+// 静かなフレームから周波数ごとのノイズの大きさ（平均と標準偏差）を推定し、それを超えない成分を gateFloor 倍に下げる
+// 音声の途切れ目で雑音が鳴るのを防ぐため、マスクは隣の周波数と前のフレームで平滑化する
+func spectralGate(samples []float64, sampleRate int) []float64 {
+	size := nextPowerOfTwo(int(gateFrameDuration * float64(sampleRate)))
+	hop := size / gateOverlap
+	if len(samples) < size*2 || estimateNoise(samples, sampleRate).snr < gateMinSNR {
+		return append([]float64(nil), samples...)
+	}
+
+	// 端のサンプルもフレームの中央で処理できるよう、前後をフレームの長さ分ゼロで埋める
+	padded := make([]float64, len(samples)+2*size)
+	copy(padded[size:], samples)
+	window := hannWindow(size)
+	bins := size/2 + 1
+
+	frames := (len(padded)-size)/hop + 1
+	spectra := make([][]complex128, frames)
+	magnitudes := make([][]float64, frames)
+	energies := make([]float64, frames)
+	for f := range spectra {
+		buf := make([]complex128, size)
+		for i := range buf {
+			buf[i] = complex(padded[f*hop+i]*window[i], 0)
+		}
+		fft(buf)
+		spectra[f] = buf
+		magnitudes[f] = make([]float64, bins)
+		for b := 0; b < bins; b++ {
+			magnitudes[f][b] = cmplx.Abs(buf[b])
+			energies[f] += magnitudes[f][b] * magnitudes[f][b]
+		}
+	}
+
+	// ノイズの推定には埋めたゼロを含まないフレームのうち、静かな noisePercentile のフレームを使う
+	var inside []int
+	for f := 0; f < frames; f++ {
+		if f*hop >= size && f*hop+size <= size+len(samples) {
+			inside = append(inside, f)
+		}
+	}
+	sort.SliceStable(inside, func(i, j int) bool { return energies[inside[i]] < energies[inside[j]] })
+	quiet := inside[:max(1, int(float64(len(inside))*noisePercentile))]
+
+	threshold := make([]float64, bins)
+	for b := 0; b < bins; b++ {
+		var sum, sumSquares float64
+		for _, f := range quiet {
+			sum += magnitudes[f][b]
+			sumSquares += magnitudes[f][b] * magnitudes[f][b]
+		}
+		mean := sum / float64(len(quiet))
+		variance := math.Max(0, sumSquares/float64(len(quiet))-mean*mean)
+		threshold[b] = mean + gateStdDevs*math.Sqrt(variance)
+	}
+
+	output := make([]float64, len(padded))
+	norm := make([]float64, len(padded))
+	previous := make([]float64, bins)
+	mask := make([]float64, bins)
+	for f, spectrum := range spectra {
+		for b := 0; b < bins; b++ {
+			mask[b] = gateFloor
+			if magnitudes[f][b] > threshold[b] {
+				mask[b] = 1
+			}
+		}
+		for b := 0; b < bins; b++ {
+			sum, count := 0.0, 0
+			for k := max(0, b-1); k <= min(bins-1, b+1); k++ {
+				sum += mask[k]
+				count++
+			}
+			gain := (sum/float64(count) + previous[b]) / 2
+			if f == 0 {
+				gain = sum / float64(count)
+			}
+			previous[b] = gain
+
+			spectrum[b] *= complex(gain, 0)
+			if b > 0 && b < size/2 {
+				spectrum[size-b] = cmplx.Conj(spectrum[b])
+			}
+		}
+
+		ifft(spectrum)
+		for i := 0; i < size; i++ {
+			output[f*hop+i] += real(spectrum[i]) * window[i]
+			norm[f*hop+i] += window[i] * window[i]
+		}
+	}
+
+	result := make([]float64, len(samples))
+	for i := range result {
+		if n := norm[size+i]; n > 1e-9 {
+			result[i] = output[size+i] / n
+		}
+	}
+	return result
+}
+
+// normalizeLoudness は音量（ITU-R BS.1770のラウドネス）を target（LUFS）にする
+// 上げる音量は maxNormalizeGain まで、ピークは peakLimit までに抑える。音声がない場合はそのまま返す
+func normalizeLoudness(samples []float64, sampleRate int, target float64) []float64 {
+	result := append([]float64(nil), samples...)
+	current := loudness(samples, sampleRate)
+	if math.IsInf(current, -1) {
+		return result
+	}
+
+	gain := math.Pow(10, math.Min(target-current, maxNormalizeGain)/20)
+	var peak float64
+	for _, sample := range samples {
+		peak = math.Max(peak, math.Abs(sample))
+	}
+	if peak*gain > peakLimit {
+		gain = peakLimit / peak
+	}
+
+	for i := range result {
+		result[i] *= gain
+	}
+	return result
+}
+
+// loudness はモノラルの音声のラウドネス（LUFS、ITU-R BS.1770-4）を返す（音声がない場合は -Inf）
+// K特性のフィルターをかけ、400msのブロックの平均二乗を絶対ゲート・相対ゲートで選んで平均する
+func loudness(samples []float64, sampleRate int) float64 {
+	weighted := kWeighting(samples, sampleRate)
+
+	block := int(loudnessBlockDuration * float64(sampleRate))
+	step := int(loudnessStepDuration * float64(sampleRate))
+	if block > len(weighted) || step < 1 {
+		block, step = len(weighted), max(1, len(weighted))
+	}
+
+	var powers []float64
+	for start := 0; start+block <= len(weighted) && block > 0; start += step {
+		var sum float64
+		for _, sample := range weighted[start : start+block] {
+			sum += sample * sample
+		}
+		powers = append(powers, sum/float64(block))
+	}
+
+	gated := func(threshold float64) (float64, int) {
+		var sum float64
+		count := 0
+		for _, power := range powers {
+			if power > 0 && blockLoudness(power) > threshold {
+				sum += power
+				count++
+			}
+		}
+		return sum, count
+	}
+
+	sum, count := gated(loudnessAbsoluteGate)
+	if count == 0 {
+		return math.Inf(-1)
+	}
+	relative := blockLoudness(sum/float64(count)) + loudnessRelativeGate
+	sum, count = gated(relative)
+	if count == 0 {
+		return math.Inf(-1)
+	}
+	return blockLoudness(sum / float64(count))
+}
+
+// blockLoudness は平均二乗をラウドネス（LUFS）に変換する
+func blockLoudness(power float64) float64 {
+	return -0.691 + 10*math.Log10(power)
+}
+
+// kWeighting はK特性のフィルター（高域のシェルビングとハイパス）をかける
+// 係数は BS.1770 の48kHzのフィルターの極・零点の特性から、サンプリングレートに合わせて計算する（48kHzでは規格の係数と一致する）
+func kWeighting(samples []float64, sampleRate int) []float64 {
+	fs := float64(sampleRate)
+
+	// 高域のシェルビング（+4dB、1682Hz）
+	k := math.Tan(math.Pi * 1681.974450955533 / fs)
+	q := 0.7071752369554196
+	vh := math.Pow(10, 3.999843853973347/20)
+	vb := math.Pow(vh, 0.4996667741545416)
+	shelf := biquad{
+		b0: vh + vb*k/q + k*k,
+		b1: 2 * (k*k - vh),
+		b2: vh - vb*k/q + k*k,
+		a0: 1 + k/q + k*k,
+		a1: 2 * (k*k - 1),
+		a2: 1 - k/q + k*k,
+	}
+
+	// ハイパス（38Hz）
+	k = math.Tan(math.Pi * 38.13547087613982 / fs)
+	q = 0.5003270373253953
+	highPass := biquad{
+		b0: 1,
+		b1: -2,
+		b2: 1,
+		a0: 1 + k/q + k*k,
+		a1: 2 * (k*k - 1),
+		a2: 1 - k/q + k*k,
+	}
+
+	return highPass.apply(shelf.apply(samples))
+}
+
+// biquad は2次のIIRフィルター
+type biquad struct {
+	b0, b1, b2, a0, a1, a2 float64
+}
+
+// apply はフィルターをかけたサンプルを返す
+func (f biquad) apply(samples []float64) []float64 {
+	out := make([]float64, len(samples))
+	var x1, x2, y1, y2 float64
+	for i, x := range samples {
+		y := (f.b0*x + f.b1*x1 + f.b2*x2 - f.a1*y1 - f.a2*y2) / f.a0
+		x2, x1 = x1, x
+		y2, y1 = y1, y
+		out[i] = y
+	}
+	return out
+}
+
+// resample はサンプリングレートを from から to に変換する
+// 窓付きsinc（Hann窓）で補間し、ダウンサンプリングでは変換後のナイキスト周波数で帯域を制限して折り返しを防ぐ
+func resample(samples []float64, from, to int) []float64 {
+	if from == to || len(samples) == 0 {
+		return append([]float64(nil), samples...)
+	}
+
+	ratio := float64(to) / float64(from)
+	cutoff := math.Min(1, ratio)
+	half := float64(resampleZeroCrossings) / cutoff
+
+	out := make([]float64, int(math.Round(float64(len(samples))*ratio)))
+	for i := range out {
+		t := float64(i) / ratio
+		first := max(0, int(math.Ceil(t-half)))
+		last := min(len(samples)-1, int(math.Floor(t+half)))
+
+		var sum float64
+		for j := first; j <= last; j++ {
+			x := t - float64(j)
+			window := 0.5 + 0.5*math.Cos(math.Pi*x/half)
+			sum += samples[j] * cutoff * sinc(cutoff*x) * window
+		}
+		out[i] = sum
+	}
+	return out
+}
+
+// sinc は正規化したsinc関数
+func sinc(x float64) float64 {
+	if x == 0 {
+		return 1
+	}
+	return math.Sin(math.Pi*x) / (math.Pi * x)
+}
+
+// hannWindow は周期的なHann窓を返す
+func hannWindow(size int) []float64 {
+	window := make([]float64, size)
+	for i := range window {
+		window[i] = 0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/float64(size))
+	}
+	return window
+}
+
+// nextPowerOfTwo は n 以上の最小の2のべき乗を返す
+func nextPowerOfTwo(n int) int {
+	size := 1
+	for size < n {
+		size <<= 1
+	}
+	return size
+}
+
+// fft は長さが2のべき乗のデータの高速フーリエ変換をその場で行う
+func fft(data []complex128) {
+	n := len(data)
+	for i, j := 1, 0; i < n; i++ {
+		bit := n >> 1
+		for ; j&bit != 0; bit >>= 1 {
+			j ^= bit
+		}
+		j ^= bit
+		if i < j {
+			data[i], data[j] = data[j], data[i]
+		}
+	}
+
+	for length := 2; length <= n; length <<= 1 {
+		step := cmplx.Exp(complex(0, -2*math.Pi/float64(length)))
+		for start := 0; start < n; start += length {
+			w := complex(1, 0)
+			for k := 0; k < length/2; k++ {
+				even, odd := data[start+k], data[start+k+length/2]*w
+				data[start+k] = even + odd
+				data[start+k+length/2] = even - odd
+				w *= step
+			}
+		}
+	}
+}
+
+// ifft は逆高速フーリエ変換をその場で行う
+func ifft(data []complex128) {
+	for i := range data {
+		data[i] = cmplx.Conj(data[i])
+	}
+	fft(data)
+	n := complex(float64(len(data)), 0)
+	for i := range data {
+		data[i] = cmplx.Conj(data[i]) / n
+	}
+}
@@ -0,0 +1,124 @@
+package audio
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sine は周波数 freq（Hz）・振幅 amplitude の正弦波を返す
+func sine(freq, amplitude float64, sampleRate int, seconds float64) []float64 {
+	samples := make([]float64, int(seconds*float64(sampleRate)))
+	for i := range samples {
+		samples[i] = amplitude * math.Sin(2*math.Pi*freq*float64(i)/float64(sampleRate))
+	}
+	return samples
+}
+
+// speechLike は0.3秒の正弦波と0.3秒の無音を繰り返し、全体に振幅 noise のホワイトノイズを加えた音声を返す
+func speechLike(sampleRate int, seconds, noise float64) []float64 {
+	rng := rand.New(rand.NewPCG(1, 2))
+	samples := sine(440, 0.5, sampleRate, seconds)
+	period := int(0.3 * float64(sampleRate))
+	for i := range samples {
+		if (i/period)%2 == 1 {
+			samples[i] = 0
+		}
+		samples[i] += noise * (2*rng.Float64() - 1)
+	}
+	return samples
+}
+
+// sliceRMS は samples の [from, to) 秒の実効値を返す
+func sliceRMS(samples []float64, sampleRate int, from, to float64) float64 {
+	return rms(samples[int(from*float64(sampleRate)):int(to*float64(sampleRate))])
+}
+
+func TestEstimateNoise(t *testing.T) {
+	clean := estimateNoise(speechLike(16000, 3, 0.005), 16000)
+	assert.Greater(t, clean.snr, 30.0)
+	assert.Less(t, clean.level, LowQualityNoiseLevel)
+
+	noisy := estimateNoise(speechLike(16000, 3, 0.4), 16000)
+	assert.Less(t, noisy.snr, 10.0)
+	assert.Greater(t, noisy.level, LowQualityNoiseLevel)
+
+	silence := estimateNoise(make([]float64, 16000), 16000)
+	assert.Equal(t, 1.0, silence.level)
+	assert.Equal(t, 0.0, silence.snr)
+}
+
+func TestSpectralGate(t *testing.T) {
+	input := speechLike(16000, 3, 0.02)
+	output := spectralGate(input, 16000)
+	require.Len(t, output, len(input))
+
+	// 無音の区間のノイズは10dB以上下がり、正弦波の区間の音量はほぼ変わらない
+	assert.Less(t, sliceRMS(output, 16000, 0.35, 0.55), sliceRMS(input, 16000, 0.35, 0.55)/math.Sqrt(10))
+	assert.InDelta(t, sliceRMS(input, 16000, 0.65, 0.85), sliceRMS(output, 16000, 0.65, 0.85), 0.03)
+
+	// ノイズと音声を区別できない場合はそのまま返す
+	noise := speechLike(16000, 1, 1)
+	assert.Equal(t, noise, spectralGate(noise, 16000))
+}
+
+func TestLoudness(t *testing.T) {
+	// 1kHz・0dBFSの正弦波は -3.01 LUFS（ITU-R BS.1770）
+	for _, sampleRate := range []int{16000, 48000} {
+		assert.InDelta(t, -3.01, loudness(sine(1000, 1, sampleRate, 2), sampleRate), 0.1)
+		assert.InDelta(t, -23.01, loudness(sine(1000, 0.1, sampleRate, 2), sampleRate), 0.1)
+	}
+	assert.True(t, math.IsInf(loudness(make([]float64, 16000), 16000), -1))
+
+	normalized := normalizeLoudness(sine(1000, 0.01, 16000, 2), 16000, TargetLoudness)
+	assert.InDelta(t, TargetLoudness, loudness(normalized, 16000), 0.1)
+
+	// ピークは -1dBFS までに抑える
+	spiky := sine(1000, 0.01, 16000, 2)
+	spiky[100] = 0.5
+	normalized = normalizeLoudness(spiky, 16000, TargetLoudness)
+	assert.InDelta(t, peakLimit, normalized[100], 1e-9)
+}
+
+func TestResample(t *testing.T) {
+	input := sine(1000, 0.5, 48000, 1)
+	output := resample(input, 48000, 16000)
+	require.Len(t, output, 16000)
+
+	// 通過帯域の正弦波はそのまま残る（フィルターの端を除いて比較）
+	expected := sine(1000, 0.5, 16000, 1)
+	for i := 1000; i < 15000; i += 37 {
+		assert.InDelta(t, expected[i], output[i], 0.01)
+	}
+
+	// 変換後のナイキスト周波数を超える成分は折り返さない
+	aliased := resample(sine(10000, 0.5, 48000, 1), 48000, 16000)
+	assert.Less(t, rms(aliased[1000:15000]), 0.01)
+
+	upsampled := resample(sine(1000, 0.5, 8000, 1), 8000, 16000)
+	require.Len(t, upsampled, 16000)
+	for i := 1000; i < 15000; i += 37 {
+		assert.InDelta(t, expected[i], upsampled[i], 0.01)
+	}
+}
+
+func TestFFT(t *testing.T) {
+	data := make([]complex128, 64)
+	for i := range data {
+		data[i] = complex(math.Cos(2*math.Pi*5*float64(i)/64), 0)
+	}
+	original := append([]complex128(nil), data...)
+
+	fft(data)
+	assert.InDelta(t, 32, real(data[5]), 1e-9)
+	assert.InDelta(t, 32, real(data[59]), 1e-9)
+	assert.InDelta(t, 0, math.Hypot(real(data[6]), imag(data[6])), 1e-9)
+
+	ifft(data)
+	for i := range data {
+		assert.InDelta(t, real(original[i]), real(data[i]), 1e-9)
+	}
+}
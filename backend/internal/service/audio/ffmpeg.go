@@ -0,0 +1,71 @@
+package audio
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultFFmpegBinary はデフォルトのffmpeg実行ファイル名
+	defaultFFmpegBinary = "ffmpeg"
+	// defaultFFmpegTimeout は1回のデコードの制限時間
+	defaultFFmpegTimeout = 30 * time.Second
+)
+
+// FFmpegDecoder はローカルにインストールされたffmpegコマンドで音声をデコードする
+// Opusのデコーダー（SetOpusDecoder）が設定されていない場合に、OggとWebMのOpusをコンテナごとデコードする
+type FFmpegDecoder struct {
+	binaryPath string
+	Timeout    time.Duration
+}
+
+// NewFFmpegDecoder は新しいffmpegのデコーダーを作成する
+// binaryPathが空の場合はPATH上のffmpegを使用する
+func NewFFmpegDecoder(binaryPath string) *FFmpegDecoder {
+	if binaryPath == "" {
+		binaryPath = defaultFFmpegBinary
+	}
+	return &FFmpegDecoder{binaryPath: binaryPath, Timeout: defaultFFmpegTimeout}
+}
+
+// Decode は音声を48kHz・モノラル・16bitのPCMにデコードする
+// ゲインと先頭の捨てるサンプル（pre-skip）はffmpegが適用する
+func (d *FFmpegDecoder) Decode(data []byte, channels int, format string) (*Signal, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), d.Timeout)
+	defer cancel()
+
+	args := []string{
+		"-hide_banner", "-loglevel", "error",
+		"-i", "pipe:0",
+		"-f", "s16le", "-acodec", "pcm_s16le",
+		"-ac", "1", "-ar", strconv.Itoa(opusSampleRate),
+		"pipe:1",
+	}
+
+	cmd := exec.CommandContext(ctx, d.binaryPath, args...)
+	cmd.Stdin = bytes.NewReader(data)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("ffmpeg timed out: %w", ctx.Err())
+		}
+		return nil, fmt.Errorf("ffmpeg execution failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	pcm := stdout.Bytes()
+	return &Signal{
+		Samples:    pcmSamples(pcm[:len(pcm)/2*2], 16, false),
+		SampleRate: opusSampleRate,
+		Channels:   channels,
+		Format:     format,
+	}, nil
+}
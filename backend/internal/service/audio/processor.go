@@ -1,128 +1,210 @@
 package audio
 
 import (
+	"errors"
 	"fmt"
+	"math"
 
 	"github.com/clearclown/HaiLanGo/backend/internal/models"
 )
 
+const (
+	// TargetSampleRate は音声認識に渡す音声のサンプリングレート
+	TargetSampleRate = 16000
+	// TargetLoudness は音量正規化の目標のラウドネス（LUFS）
+	TargetLoudness = -20.0
+	// LowQualityNoiseLevel はこれを超えるノイズレベルを低品質とみなす閾値（SNRで約10.5dB未満）
+	LowQualityNoiseLevel = 0.3
+)
+
 // AudioProcessor は音声処理を行う
-type AudioProcessor struct{}
+// 音声をデコードしてモノラルにミックスし、ノイズの推定・発話の検出・ノイズ除去・無音の削除・音量正規化・サンプリングレート変換を行う
+type AudioProcessor struct {
+	opusDecoder OpusDecoderFactory
+	ffmpeg      *FFmpegDecoder
+}
 
 // NewAudioProcessor は新しいオーディオプロセッサーを作成する
 func NewAudioProcessor() *AudioProcessor {
 	return &AudioProcessor{}
 }
 
-// Process は音声データを処理する
+// Process は音声データを音声認識用に処理する
+// 処理後の音声は16kHz・モノラル・16bitのWAVで、ノイズレベルとSNRはノイズ除去の前の音声から推定する
+// 最初の発話の前と最後の発話の後の無音は削除し、発話区間は処理後の音声の時刻で返す
+// デコードできないフォーマット・コーデック（MP3・MP4・Opusのデコーダーとffmpegが未設定の場合など）は、
+// 音声認識のAPIがデコードできるように元の音声をそのまま返す（サンプリングレートなどは0）
 func (p *AudioProcessor) Process(audioData []byte) (*models.AudioProcessingResult, error) {
-	if len(audioData) == 0 {
-		return nil, fmt.Errorf("音声データが空です")
+	signal, err := p.decode(audioData)
+	if errors.Is(err, ErrUnsupportedFormat) || errors.Is(err, ErrUnsupportedCodec) {
+		return &models.AudioProcessingResult{ProcessedAudio: audioData}, nil
 	}
-
-	// ノイズレベルを検出
-	noiseLevel, err := p.DetectNoiseLevel(audioData)
 	if err != nil {
-		return nil, fmt.Errorf("ノイズレベルの検出に失敗しました: %w", err)
+		return nil, err
 	}
 
+	// サンプリングレート変換（16kHzに統一）
+	// 以降の処理のサンプル数を減らすため、最初に変換する
+	samples := resample(signal.Samples, signal.SampleRate, TargetSampleRate)
+
+	// ノイズレベルを検出（無音の区間をノイズの推定に使うため、無音を削除する前に行う）
+	noise := estimateNoise(samples, TargetSampleRate)
+
+	// 発話区間を検出
+	segments := detectSpeech(samples, TargetSampleRate, noise)
+
 	// ノイズ除去
-	cleaned, err := p.ApplyNoiseReduction(audioData)
+	samples = spectralGate(samples, TargetSampleRate)
+
+	// 前後の無音を削除
+	start, end := trimSilence(segments, len(samples), TargetSampleRate)
+	samples = samples[start:end]
+
+	// 音量正規化
+	samples = normalizeLoudness(samples, TargetSampleRate, TargetLoudness)
+
+	speech := speechSegments(segments, start, TargetSampleRate)
+	var speechDuration float64
+	for _, segment := range speech {
+		speechDuration += segment.End - segment.Start
+	}
+
+	result := &models.AudioProcessingResult{
+		ProcessedAudio: EncodeWAV(samples, TargetSampleRate),
+		SampleRate:     TargetSampleRate,
+		Channels:       1,
+		Duration:       float64(len(samples)) / TargetSampleRate,
+		NoiseLevel:     noise.level,
+		SNR:            noise.snr,
+		SpeechSegments: speech,
+		SpeechDuration: speechDuration,
+		TrimmedStart:   float64(start) / TargetSampleRate,
+		IsLowQuality:   noise.level > LowQualityNoiseLevel,
+	}
+
+	return result, nil
+}
+
+// DetectSpeech は発話区間を検出する（時刻は元の音声の先頭からの秒）
+func (p *AudioProcessor) DetectSpeech(audioData []byte) ([]models.SpeechSegment, error) {
+	signal, err := p.decode(audioData)
 	if err != nil {
-		return nil, fmt.Errorf("ノイズ除去に失敗しました: %w", err)
+		return nil, err
 	}
 
-	// 音量正規化
-	normalized, err := p.NormalizeVolume(cleaned)
+	samples := resample(signal.Samples, signal.SampleRate, TargetSampleRate)
+	segments := detectSpeech(samples, TargetSampleRate, estimateNoise(samples, TargetSampleRate))
+	return speechSegments(segments, 0, TargetSampleRate), nil
+}
+
+// SplitUtterances は音声データを Process で処理し、発話ごとに分けた16kHz・モノラルのWAVを返す
+// 発話の前後には無音を vadPadding 残す。発話がない場合は空のスライスを返す
+func (p *AudioProcessor) SplitUtterances(audioData []byte) ([][]byte, error) {
+	result, err := p.Process(audioData)
 	if err != nil {
-		return nil, fmt.Errorf("音量正規化に失敗しました: %w", err)
+		return nil, err
 	}
 
-	// サンプリングレート変換（16kHzに統一）
-	processed, err := p.ConvertSampleRate(normalized, 16000)
+	signal, err := p.Decode(result.ProcessedAudio)
 	if err != nil {
-		return nil, fmt.Errorf("サンプリングレート変換に失敗しました: %w", err)
+		return nil, err
 	}
 
-	result := &models.AudioProcessingResult{
-		ProcessedAudio: processed,
-		SampleRate:     16000,
-		Channels:       1,
-		Duration:       float64(len(processed)) / 16000.0, // 簡易的な計算
-		NoiseLevel:     noiseLevel,
-		IsLowQuality:   noiseLevel > 0.3, // ノイズレベルが30%を超えると低品質と判断
+	padding := int(vadPadding * TargetSampleRate)
+	utterances := make([][]byte, 0, len(result.SpeechSegments))
+	for _, segment := range result.SpeechSegments {
+		start := max(0, int(math.Round(segment.Start*TargetSampleRate))-padding)
+		end := min(len(signal.Samples), int(math.Round(segment.End*TargetSampleRate))+padding)
+		utterances = append(utterances, EncodeWAV(signal.Samples[start:end], TargetSampleRate))
 	}
+	return utterances, nil
+}
 
-	return result, nil
+// decode は音声データをデコードし、サンプルがない場合はエラーを返す
+func (p *AudioProcessor) decode(audioData []byte) (*Signal, error) {
+	if len(audioData) == 0 {
+		return nil, fmt.Errorf("音声データが空です")
+	}
+
+	signal, err := p.Decode(audioData)
+	if err != nil {
+		return nil, fmt.Errorf("音声のデコードに失敗しました: %w", err)
+	}
+	if len(signal.Samples) == 0 {
+		return nil, fmt.Errorf("音声データが空です")
+	}
+	return signal, nil
 }
 
-// DetectNoiseLevel はノイズレベルを検出する
+// DetectNoiseLevel はノイズレベル（ノイズの音声に対する振幅の比、0-1）を検出する
+// 静かなフレームをノイズ、大きなフレームを音声とみなす。音声がない場合は1を返す
 func (p *AudioProcessor) DetectNoiseLevel(audioData []byte) (float64, error) {
-	if len(audioData) == 0 {
-		return 0, fmt.Errorf("音声データが空です")
+	signal, err := p.decode(audioData)
+	if err != nil {
+		return 0, err
 	}
 
-	// TODO: 実際のノイズレベル検出アルゴリズムを実装
-	// 現時点では簡易的な計算を返す
-	return 0.15, nil
+	return estimateNoise(signal.Samples, signal.SampleRate).level, nil
 }
 
-// ApplyNoiseReduction はノイズ除去を適用する
+// ApplyNoiseReduction はスペクトルのノイズゲートでノイズ除去を適用し、元のサンプリングレートのWAVを返す
 func (p *AudioProcessor) ApplyNoiseReduction(audioData []byte) ([]byte, error) {
-	if len(audioData) == 0 {
-		return nil, fmt.Errorf("音声データが空です")
+	signal, err := p.decode(audioData)
+	if err != nil {
+		return nil, err
 	}
 
-	// TODO: 実際のノイズ除去アルゴリズムを実装
-	// 現時点では元のデータをそのまま返す
-	return audioData, nil
+	return EncodeWAV(spectralGate(signal.Samples, signal.SampleRate), signal.SampleRate), nil
 }
 
-// NormalizeVolume は音量を正規化する
+// NormalizeVolume は音量を TargetLoudness に正規化し、元のサンプリングレートのWAVを返す
 func (p *AudioProcessor) NormalizeVolume(audioData []byte) ([]byte, error) {
-	if len(audioData) == 0 {
-		return nil, fmt.Errorf("音声データが空です")
+	signal, err := p.decode(audioData)
+	if err != nil {
+		return nil, err
 	}
 
-	// TODO: 実際の音量正規化アルゴリズムを実装
-	// 現時点では元のデータをそのまま返す
-	return audioData, nil
+	return EncodeWAV(normalizeLoudness(signal.Samples, signal.SampleRate, TargetLoudness), signal.SampleRate), nil
 }
 
-// ConvertSampleRate はサンプリングレートを変換する
+// ConvertSampleRate はサンプリングレートを変換し、モノラルのWAVを返す
 func (p *AudioProcessor) ConvertSampleRate(audioData []byte, targetRate int) ([]byte, error) {
 	if len(audioData) == 0 {
 		return nil, fmt.Errorf("音声データが空です")
 	}
 
-	if targetRate <= 0 {
+	if !validSampleRate(targetRate) {
 		return nil, fmt.Errorf("無効なサンプリングレート: %d", targetRate)
 	}
 
-	// TODO: 実際のサンプリングレート変換を実装
-	// 現時点では元のデータをそのまま返す
-	return audioData, nil
+	signal, err := p.decode(audioData)
+	if err != nil {
+		return nil, err
+	}
+
+	return EncodeWAV(resample(signal.Samples, signal.SampleRate, targetRate), targetRate), nil
 }
 
 // ValidateFormat は音声フォーマットを検証する
+// デコードできてサンプルがあれば有効とする。ヘッダーのないPCMは16bitのサンプルの境界に揃っている必要がある
 func (p *AudioProcessor) ValidateFormat(audioData []byte) (bool, error) {
 	if len(audioData) == 0 {
 		return false, nil
 	}
 
-	// TODO: 実際のフォーマット検証を実装
-	// 現時点では簡易的な検証を行う
-
-	// "low quality"という文字列は低品質とみなす（テスト用）
-	dataStr := string(audioData)
-	if dataStr == "low quality" {
+	format, err := detectFormat(audioData)
+	if err != nil {
 		return false, nil
 	}
 
 	// 最小サイズチェック（少なくとも10バイト以上が必要）
-	if len(audioData) < 10 {
-		return false, nil
+	if format == FormatPCM {
+		return len(audioData) >= minRawSize && len(audioData)%2 == 0, nil
 	}
 
-	return true, nil
+	signal, err := p.Decode(audioData)
+	if err != nil {
+		return false, nil
+	}
+	return len(signal.Samples) > 0, nil
 }
@@ -120,3 +120,49 @@ func TestValidateAudioFormat(t *testing.T) {
 		})
 	}
 }
+
+// TestProcessAudio_WAV は録音を16kHz・モノラルに変換し、ノイズの多い録音を低品質と判定することをテスト
+func TestProcessAudio_WAV(t *testing.T) {
+	processor := NewAudioProcessor()
+
+	stereo := func(mono []float64) []float64 {
+		interleaved := make([]float64, 0, len(mono)*2)
+		for _, sample := range mono {
+			interleaved = append(interleaved, sample, sample)
+		}
+		return interleaved
+	}
+
+	clean := wavFile(wavFormatPCM, 2, 44100, 16, stereo(speechLike(44100, 2, 0.005)))
+	result, err := processor.Process(clean)
+	require.NoError(t, err)
+	assert.Equal(t, TargetSampleRate, result.SampleRate)
+	assert.Equal(t, 1, result.Channels)
+	assert.InDelta(t, 2.0, result.Duration, 0.001)
+	assert.Greater(t, result.SNR, 30.0)
+	assert.False(t, result.IsLowQuality)
+
+	signal, err := processor.Decode(result.ProcessedAudio)
+	require.NoError(t, err)
+	assert.Equal(t, TargetSampleRate, signal.SampleRate)
+	assert.Len(t, signal.Samples, 32000)
+	assert.InDelta(t, TargetLoudness, loudness(signal.Samples, signal.SampleRate), 0.5)
+
+	noisy := wavFile(wavFormatPCM, 2, 44100, 16, stereo(speechLike(44100, 2, 0.4)))
+	result, err = processor.Process(noisy)
+	require.NoError(t, err)
+	assert.True(t, result.IsLowQuality)
+
+	// デコードできない音声は元のまま返す
+	mp3 := append([]byte("ID3\x04\x00"), make([]byte, 32)...)
+	result, err = processor.Process(mp3)
+	require.NoError(t, err)
+	assert.Equal(t, mp3, result.ProcessedAudio)
+	assert.Zero(t, result.SampleRate)
+
+	// 範囲外のサンプリングレートは変換しない
+	_, err = processor.Process(wavFile(wavFormatPCM, 1, 1, 16, speechLike(16000, 1, 0.005)))
+	assert.ErrorIs(t, err, ErrInvalidAudio)
+	_, err = processor.Process(wavFile(wavFormatPCM, 1, 4000000, 16, speechLike(16000, 1, 0.005)))
+	assert.ErrorIs(t, err, ErrInvalidAudio)
+}
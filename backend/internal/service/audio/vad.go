@@ -0,0 +1,165 @@
+package audio
+
+import (
+	"math"
+
+	"github.com/clearclown/HaiLanGo/backend/internal/models"
+)
+
+const (
+	// 発話検出（VAD）のフレーム（30ms、10msごと）
+	vadFrameDuration = 0.030
+	vadHopDuration   = 0.010
+	// vadThresholdPosition は発話とみなす音量の閾値の位置（ノイズと音声の音量の間のdBでの割合）
+	vadThresholdPosition = 0.3
+	// vadMinLevel は発話とみなす音量の下限（-60dBFS）
+	vadMinLevel = 0.001
+	// 有声音とみなす自己相関の下限と、基本周波数の範囲（Hz）
+	vadMinVoicing = 0.5
+	vadMinPitch   = 70.0
+	vadMaxPitch   = 400.0
+	// vadMinVoiced は発話とみなす区間に必要な有声音の長さ（秒）。これより短い区間はクリック音・息・単独の子音とみなす
+	vadMinVoiced = 0.1
+	// vadMaxPause はひとつの発話とみなす無音の長さの上限（秒）。これ以上の無音で発話を分ける
+	vadMaxPause = 0.5
+	// vadPadding は無音を削除するときに発話の前後に残す長さ（秒）
+	vadPadding = 0.1
+)
+
+// speechSegment は発話区間（サンプルの位置、[start, end)）
+type speechSegment struct {
+	start, end int
+}
+
+// vadRegion は音量が閾値を超えるフレームが続く区間
+type vadRegion struct {
+	speechSegment
+	voiced int // 有声音のフレーム数
+}
+
+// detectSpeech は発話区間を検出する
+// 音量が閾値を超えるフレームが続く区間のうち、有声音（基本周波数の周期性がある音）が vadMinVoiced 以上あるものを発話とし、
+// vadMaxPause 未満の無音を挟む発話をひとつにまとめる。まとめた発話の前後の有声音が短い区間（キーボードの音など）は含めない
+func detectSpeech(samples []float64, sampleRate int, noise noiseEstimate) []speechSegment {
+	frame := int(vadFrameDuration * float64(sampleRate))
+	hop := int(vadHopDuration * float64(sampleRate))
+	if frame < 1 || hop < 1 || len(samples) < frame || noise.signalRMS < vadMinLevel {
+		return nil
+	}
+
+	threshold := vadMinLevel
+	if noise.noiseRMS > 0 && noise.signalRMS > noise.noiseRMS {
+		threshold = math.Max(threshold, noise.noiseRMS*math.Pow(noise.signalRMS/noise.noiseRMS, vadThresholdPosition))
+	}
+
+	// 音量が閾値を超えるフレームが続く区間を集める
+	// 各フレームはフレームの中央のホップの長さの区間を表す（先頭と末尾のフレームは端まで）
+	var regions []vadRegion
+	var current *vadRegion
+	for start := 0; start+frame <= len(samples); start += hop {
+		window := samples[start : start+frame]
+		if rms(window) <= threshold {
+			current = nil
+			continue
+		}
+		if current == nil {
+			from := start + (frame-hop)/2
+			if start == 0 {
+				from = 0
+			}
+			regions = append(regions, vadRegion{speechSegment: speechSegment{start: from}})
+			current = &regions[len(regions)-1]
+		}
+		current.end = start + (frame+hop)/2
+		if start+hop+frame > len(samples) {
+			current.end = len(samples)
+		}
+		if voicing(window, sampleRate) >= vadMinVoicing {
+			current.voiced++
+		}
+	}
+
+	minVoiced := int(math.Ceil(vadMinVoiced / vadHopDuration))
+	maxPause := int(vadMaxPause * float64(sampleRate))
+
+	// 短い無音を挟む区間をまとめ、前後の有声音が短い区間を除いて発話区間にする
+	var segments []speechSegment
+	for i := 0; i < len(regions); {
+		j := i + 1
+		for j < len(regions) && regions[j].start-regions[j-1].end < maxPause {
+			j++
+		}
+		group := regions[i:j]
+		i = j
+
+		for len(group) > 0 && group[0].voiced < minVoiced {
+			group = group[1:]
+		}
+		for len(group) > 0 && group[len(group)-1].voiced < minVoiced {
+			group = group[:len(group)-1]
+		}
+		if len(group) > 0 {
+			segments = append(segments, speechSegment{start: group[0].start, end: group[len(group)-1].end})
+		}
+	}
+	return segments
+}
+
+// voicing はフレームの周期性（基本周波数の範囲の正規化した自己相関の最大値、0-1）を返す
+func voicing(window []float64, sampleRate int) float64 {
+	minLag := int(float64(sampleRate) / vadMaxPitch)
+	maxLag := min(int(float64(sampleRate)/vadMinPitch), len(window)/2)
+	if minLag < 1 || minLag > maxLag {
+		return 0
+	}
+
+	var mean float64
+	for _, sample := range window {
+		mean += sample
+	}
+	mean /= float64(len(window))
+
+	x := make([]float64, len(window))
+	// energy[i] は x[:i] の二乗和
+	energy := make([]float64, len(window)+1)
+	for i, sample := range window {
+		x[i] = sample - mean
+		energy[i+1] = energy[i] + x[i]*x[i]
+	}
+
+	var best float64
+	for lag := minLag; lag <= maxLag; lag++ {
+		var sum float64
+		for i := 0; i+lag < len(x); i++ {
+			sum += x[i] * x[i+lag]
+		}
+		head := energy[len(x)-lag]
+		tail := energy[len(x)] - energy[lag]
+		if head > 0 && tail > 0 {
+			best = math.Max(best, sum/math.Sqrt(head*tail))
+		}
+	}
+	return best
+}
+
+// speechSegments は発話区間を秒に変換する（offset はサンプルの位置の基準）
+func speechSegments(segments []speechSegment, offset, sampleRate int) []models.SpeechSegment {
+	result := make([]models.SpeechSegment, 0, len(segments))
+	for _, segment := range segments {
+		result = append(result, models.SpeechSegment{
+			Start: float64(segment.start-offset) / float64(sampleRate),
+			End:   float64(segment.end-offset) / float64(sampleRate),
+		})
+	}
+	return result
+}
+
+// trimSilence は最初の発話の前と最後の発話の後の無音を、vadPadding を残して削除する範囲 [start, end) を返す
+// 発話がない場合は全体を返す
+func trimSilence(segments []speechSegment, length, sampleRate int) (int, int) {
+	if len(segments) == 0 {
+		return 0, length
+	}
+	padding := int(vadPadding * float64(sampleRate))
+	return max(0, segments[0].start-padding), min(length, segments[len(segments)-1].end+padding)
+}
@@ -0,0 +1,122 @@
+package audio
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// voice は基本周波数150Hzと倍音からなる有声音を返す
+func voice(sampleRate int, seconds float64) []float64 {
+	samples := make([]float64, int(seconds*float64(sampleRate)))
+	for i := range samples {
+		t := float64(i) / float64(sampleRate)
+		for harmonic := 1.0; harmonic <= 5; harmonic++ {
+			samples[i] += 0.3 / harmonic * math.Sin(2*math.Pi*150*harmonic*t)
+		}
+	}
+	return samples
+}
+
+// recording は parts をつなげ、全体に振幅0.003のホワイトノイズを加えた録音を返す
+// parts の秒数は無音、スライスはそのまま使う
+func recording(sampleRate int, parts ...any) []float64 {
+	var samples []float64
+	for _, part := range parts {
+		switch part := part.(type) {
+		case float64:
+			samples = append(samples, make([]float64, int(part*float64(sampleRate)))...)
+		case []float64:
+			samples = append(samples, part...)
+		}
+	}
+	rng := rand.New(rand.NewPCG(3, 4))
+	for i := range samples {
+		samples[i] += 0.003 * (2*rng.Float64() - 1)
+	}
+	return samples
+}
+
+// keyboardClicks は0.15秒ごとに8msのクリック音（減衰するノイズ）が count 回鳴る音を返す
+func keyboardClicks(sampleRate, count int) []float64 {
+	rng := rand.New(rand.NewPCG(5, 6))
+	period := int(0.15 * float64(sampleRate))
+	click := int(0.008 * float64(sampleRate))
+	samples := make([]float64, period*count)
+	for n := 0; n < count; n++ {
+		for i := 0; i < click; i++ {
+			samples[n*period+i] = 0.8 * math.Exp(-float64(i)/float64(click)*4) * (2*rng.Float64() - 1)
+		}
+	}
+	return samples
+}
+
+func TestDetectSpeech(t *testing.T) {
+	const rate = 16000
+	// 短い無音（0.2秒）を挟む発話と、長い無音（1秒）の後の発話。最後の発話の0.3秒後にキーボードの音が鳴る
+	samples := recording(rate,
+		1.0, voice(rate, 0.8), 0.2, voice(rate, 0.5),
+		1.0, voice(rate, 0.6),
+		0.3, keyboardClicks(rate, 4), 0.5,
+	)
+
+	segments := detectSpeech(samples, rate, estimateNoise(samples, rate))
+	require.Len(t, segments, 2)
+	assert.InDelta(t, 1.0, float64(segments[0].start)/rate, 0.03)
+	assert.InDelta(t, 2.5, float64(segments[0].end)/rate, 0.03)
+	assert.InDelta(t, 3.5, float64(segments[1].start)/rate, 0.03)
+	assert.InDelta(t, 4.1, float64(segments[1].end)/rate, 0.03)
+
+	// キーボードの音やノイズだけの録音には発話がない
+	clicks := recording(rate, 0.5, keyboardClicks(rate, 10), 0.5)
+	assert.Empty(t, detectSpeech(clicks, rate, estimateNoise(clicks, rate)))
+	noise := recording(rate, 2.0)
+	assert.Empty(t, detectSpeech(noise, rate, estimateNoise(noise, rate)))
+	assert.Empty(t, detectSpeech(make([]float64, 10), rate, estimateNoise(make([]float64, 10), rate)))
+
+	// 周期性: 有声音は高く、ノイズは低い
+	assert.Greater(t, voicing(voice(rate, 0.03), rate), 0.9)
+	assert.Less(t, voicing(recording(rate, 0.03), rate), vadMinVoicing)
+}
+
+func TestProcessAudio_TrimSilence(t *testing.T) {
+	processor := NewAudioProcessor()
+	data := EncodeWAV(recording(TargetSampleRate,
+		1.0, voice(TargetSampleRate, 0.8),
+		1.0, voice(TargetSampleRate, 0.6),
+		0.3, keyboardClicks(TargetSampleRate, 4), 0.5,
+	), TargetSampleRate)
+
+	segments, err := processor.DetectSpeech(data)
+	require.NoError(t, err)
+	require.Len(t, segments, 2)
+	assert.InDelta(t, 1.0, segments[0].Start, 0.03)
+
+	// 前後の無音とキーボードの音を削除し、発話の前後に0.1秒残す
+	result, err := processor.Process(data)
+	require.NoError(t, err)
+	assert.InDelta(t, 0.9, result.TrimmedStart, 0.03)
+	assert.InDelta(t, 2.6, result.Duration, 0.06)
+	require.Len(t, result.SpeechSegments, 2)
+	assert.InDelta(t, 0.1, result.SpeechSegments[0].Start, 0.03)
+	assert.InDelta(t, 1.9, result.SpeechSegments[1].Start, 0.03)
+	assert.InDelta(t, 1.4, result.SpeechDuration, 0.06)
+
+	utterances, err := processor.SplitUtterances(data)
+	require.NoError(t, err)
+	require.Len(t, utterances, 2)
+	first, err := processor.Decode(utterances[0])
+	require.NoError(t, err)
+	assert.InDelta(t, 1.0, first.Duration(), 0.06)
+
+	// 発話がない場合は削除しない
+	silence := EncodeWAV(recording(TargetSampleRate, 1.0), TargetSampleRate)
+	result, err = processor.Process(silence)
+	require.NoError(t, err)
+	assert.InDelta(t, 1.0, result.Duration, 0.001)
+	assert.Empty(t, result.SpeechSegments)
+	assert.Zero(t, result.SpeechDuration)
+}
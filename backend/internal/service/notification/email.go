@@ -0,0 +1,196 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/clearclown/HaiLanGo/backend/internal/models"
+	"github.com/google/uuid"
+)
+
+const (
+	// defaultSMTPPort is used when SMTP_PORT is not set
+	defaultSMTPPort = 587
+	// smtpTimeout bounds a whole delivery when the context has no earlier deadline
+	smtpTimeout = 30 * time.Second
+)
+
+// ErrNoEmailAddress is returned when the user has no email address to send to
+var ErrNoEmailAddress = errors.New("user has no email address")
+
+// SMTPConfig holds the SMTP server used for reminder emails
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string // no authentication when empty
+	Password string
+	From     string // address or "Name <address>"
+}
+
+// SMTPConfigFromEnv reads SMTP_HOST, SMTP_PORT, SMTP_USERNAME, SMTP_PASSWORD and SMTP_FROM
+// It returns false when SMTP_HOST or SMTP_FROM is not set
+func SMTPConfigFromEnv() (SMTPConfig, bool) {
+	config := SMTPConfig{
+		Host:     os.Getenv("SMTP_HOST"),
+		Port:     defaultSMTPPort,
+		Username: os.Getenv("SMTP_USERNAME"),
+		Password: os.Getenv("SMTP_PASSWORD"),
+		From:     os.Getenv("SMTP_FROM"),
+	}
+	if port, err := strconv.Atoi(os.Getenv("SMTP_PORT")); err == nil && port > 0 {
+		config.Port = port
+	}
+	return config, config.Host != "" && config.From != ""
+}
+
+// UserLookup finds the user a reminder is sent to (implemented by repository.UserRepository)
+type UserLookup interface {
+	GetUserByID(ctx context.Context, id uuid.UUID) (*models.User, error)
+}
+
+// EmailNotifier sends review reminders by email over SMTP
+// STARTTLS is used when the server supports it
+type EmailNotifier struct {
+	config    SMTPConfig
+	users     UserLookup
+	reviewURL string
+}
+
+// NewEmailNotifier creates an email notifier
+func NewEmailNotifier(config SMTPConfig, users UserLookup) *EmailNotifier {
+	return &EmailNotifier{
+		config: config,
+		users:  users,
+	}
+}
+
+// SetReviewURL sets the link to the review page included in the email
+func (n *EmailNotifier) SetReviewURL(reviewURL string) {
+	n.reviewURL = reviewURL
+}
+
+// Channel returns models.ReminderChannelEmail
+func (n *EmailNotifier) Channel() string {
+	return models.ReminderChannelEmail
+}
+
+// NotifyReviewReminder emails the reminder to the user's address
+func (n *EmailNotifier) NotifyReviewReminder(ctx context.Context, reminder *models.ReviewReminder) error {
+	userID, err := uuid.Parse(reminder.UserID)
+	if err != nil {
+		return fmt.Errorf("invalid user id %q: %w", reminder.UserID, err)
+	}
+	user, err := n.users.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.Email == "" {
+		return ErrNoEmailAddress
+	}
+
+	from, err := mail.ParseAddress(n.config.From)
+	if err != nil {
+		return fmt.Errorf("invalid SMTP_FROM %q: %w", n.config.From, err)
+	}
+	message := buildEmail(from.String(), user.Email, reminderTitle(reminder), reminderBody(reminder, n.reviewURL), reminder.SentAt)
+
+	return n.send(ctx, from.Address, user.Email, message)
+}
+
+// send delivers the message like smtp.SendMail, but gives up when ctx is canceled or the deadline passes
+func (n *EmailNotifier) send(ctx context.Context, from, to string, message []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, smtpTimeout)
+	defer cancel()
+
+	addr := net.JoinHostPort(n.config.Host, strconv.Itoa(n.config.Port))
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	deadline, _ := ctx.Deadline()
+	if err := conn.SetDeadline(deadline); err != nil {
+		return err
+	}
+	// Unblock reads and writes as soon as the context is canceled
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Now()) })
+	defer stop()
+
+	client, err := smtp.NewClient(conn, n.config.Host)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: n.config.Host}); err != nil {
+			return err
+		}
+	}
+	if n.config.Username != "" {
+		if ok, _ := client.Extension("AUTH"); !ok {
+			return errors.New("smtp: server doesn't support AUTH")
+		}
+		auth := smtp.PlainAuth("", n.config.Username, n.config.Password, n.config.Host)
+		if err := client.Auth(auth); err != nil {
+			return err
+		}
+	}
+
+	if err := client.Mail(from); err != nil {
+		return err
+	}
+	if err := client.Rcpt(to); err != nil {
+		return err
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(message); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// buildEmail builds a UTF-8 plain-text message; the body is base64-encoded since item texts may be in any script
+func buildEmail(from, to, subject, body string, date time.Time) []byte {
+	if date.IsZero() {
+		date = time.Now()
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", to)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: base64\r\n")
+	b.WriteString("\r\n")
+
+	encoded := base64.StdEncoding.EncodeToString([]byte(body))
+	for len(encoded) > 76 {
+		b.WriteString(encoded[:76] + "\r\n")
+		encoded = encoded[76:]
+	}
+	b.WriteString(encoded + "\r\n")
+
+	return b.Bytes()
+}
@@ -0,0 +1,195 @@
+package notification
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"io"
+	"mime"
+	"net"
+	"net/mail"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/clearclown/HaiLanGo/backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSMTPServer is a minimal local SMTP server that records the delivered messages
+type fakeSMTPServer struct {
+	listener net.Listener
+	messages chan fakeMail
+}
+
+// fakeMail is a message received by the fake server
+type fakeMail struct {
+	from string
+	to   []string
+	data string
+}
+
+func newFakeSMTPServer(t *testing.T) *fakeSMTPServer {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	server := &fakeSMTPServer{listener: listener, messages: make(chan fakeMail, 10)}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go server.serve(conn)
+		}
+	}()
+	return server
+}
+
+// serve speaks just enough SMTP for EmailNotifier (no STARTTLS, no AUTH)
+func (s *fakeSMTPServer) serve(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	reply := func(line string) { io.WriteString(conn, line+"\r\n") }
+
+	var mail fakeMail
+	reply("220 localhost ESMTP fake")
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		command := strings.ToUpper(strings.TrimSpace(line))
+		switch {
+		case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+			reply("250 localhost")
+		case strings.HasPrefix(command, "MAIL FROM:"):
+			mail = fakeMail{from: strings.Trim(strings.TrimSpace(line)[len("MAIL FROM:"):], "<>")}
+			reply("250 OK")
+		case strings.HasPrefix(command, "RCPT TO:"):
+			mail.to = append(mail.to, strings.Trim(strings.TrimSpace(line)[len("RCPT TO:"):], "<>"))
+			reply("250 OK")
+		case command == "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			var data strings.Builder
+			for {
+				dataLine, err := reader.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if dataLine == ".\r\n" {
+					break
+				}
+				data.WriteString(strings.TrimPrefix(dataLine, "."))
+			}
+			mail.data = data.String()
+			s.messages <- mail
+			reply("250 OK")
+		case command == "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("250 OK")
+		}
+	}
+}
+
+// config returns an SMTPConfig pointing at the fake server
+func (s *fakeSMTPServer) config(from string) SMTPConfig {
+	host, port, _ := net.SplitHostPort(s.listener.Addr().String())
+	portNumber, _ := strconv.Atoi(port)
+	return SMTPConfig{Host: host, Port: portNumber, From: from}
+}
+
+// fakeUsers is a UserLookup backed by a map
+type fakeUsers map[uuid.UUID]*models.User
+
+func (u fakeUsers) GetUserByID(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	return u[id], nil
+}
+
+func TestEmailNotifier(t *testing.T) {
+	server := newFakeSMTPServer(t)
+
+	userID := uuid.New()
+	users := fakeUsers{userID: {ID: userID, Email: "learner@example.com"}}
+	notifier := NewEmailNotifier(server.config("HaiLanGo <reminders@hailango.example>"), users)
+	notifier.SetReviewURL("https://hailango.example/review")
+	assert.Equal(t, models.ReminderChannelEmail, notifier.Channel())
+
+	reminder := &models.ReviewReminder{
+		UserID:   userID.String(),
+		DueCount: 12,
+		NewCount: 3,
+		Items: []*models.ReviewItem{
+			{Text: "Здравствуйте", Translation: "こんにちは"},
+			{Text: "книга", Translation: "本"},
+		},
+		SentAt: time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC),
+	}
+	require.NoError(t, notifier.NotifyReviewReminder(context.Background(), reminder))
+
+	var received fakeMail
+	select {
+	case received = <-server.messages:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no message received")
+	}
+	assert.Equal(t, "reminders@hailango.example", received.from)
+	assert.Equal(t, []string{"learner@example.com"}, received.to)
+
+	message, err := mail.ReadMessage(strings.NewReader(received.data))
+	require.NoError(t, err)
+	subject, err := new(mime.WordDecoder).DecodeHeader(message.Header.Get("Subject"))
+	require.NoError(t, err)
+	assert.Equal(t, "12 cards to review today", subject)
+	assert.Equal(t, "base64", message.Header.Get("Content-Transfer-Encoding"))
+	assert.Equal(t, `"HaiLanGo" <reminders@hailango.example>`, message.Header.Get("From"))
+
+	body, err := io.ReadAll(base64.NewDecoder(base64.StdEncoding, message.Body))
+	require.NoError(t, err)
+	assert.Contains(t, string(body), "You have 12 cards to review today (3 new).")
+	assert.Contains(t, string(body), "- Здравствуйте / こんにちは")
+	assert.Contains(t, string(body), "https://hailango.example/review")
+
+	// Users without an email address are not sent anything
+	noEmailID := uuid.New()
+	users[noEmailID] = &models.User{ID: noEmailID}
+	err = notifier.NotifyReviewReminder(context.Background(), &models.ReviewReminder{UserID: noEmailID.String(), DueCount: 1})
+	assert.ErrorIs(t, err, ErrNoEmailAddress)
+}
+
+func TestEmailNotifier_Canceled(t *testing.T) {
+	// A server that accepts the connection but never greets
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+	portNumber, _ := strconv.Atoi(port)
+	userID := uuid.New()
+	notifier := NewEmailNotifier(SMTPConfig{Host: host, Port: portNumber, From: "reminders@hailango.example"},
+		fakeUsers{userID: {ID: userID, Email: "learner@example.com"}})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err = notifier.NotifyReviewReminder(ctx, &models.ReviewReminder{UserID: userID.String(), DueCount: 1})
+	assert.Error(t, err)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
@@ -0,0 +1,103 @@
+package notification
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/clearclown/HaiLanGo/backend/internal/models"
+	ws "github.com/clearclown/HaiLanGo/backend/internal/websocket"
+	"github.com/google/uuid"
+)
+
+// reminderPreviewItems is the number of review items listed in a reminder
+const reminderPreviewItems = 5
+
+// Notifier delivers review reminders through one channel
+type Notifier interface {
+	// Channel returns the channel name (models.ReminderChannelWebSocket, Email or Push)
+	Channel() string
+	// NotifyReviewReminder sends the reminder to its user
+	NotifyReviewReminder(ctx context.Context, reminder *models.ReviewReminder) error
+}
+
+// reminderTitle returns the short title of a reminder
+func reminderTitle(reminder *models.ReviewReminder) string {
+	if reminder.DueCount == 1 {
+		return "1 card to review today"
+	}
+	return fmt.Sprintf("%d cards to review today", reminder.DueCount)
+}
+
+// reminderBody returns the plain-text body of a reminder, listing the first cards of today's queue
+func reminderBody(reminder *models.ReviewReminder, reviewURL string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "You have %d cards to review today", reminder.DueCount)
+	if reminder.NewCount > 0 {
+		fmt.Fprintf(&b, " (%d new)", reminder.NewCount)
+	}
+	b.WriteString(".\n")
+
+	if len(reminder.Items) > 0 {
+		b.WriteString("\n")
+		for i, item := range reminder.Items {
+			if i == reminderPreviewItems {
+				break
+			}
+			b.WriteString("- " + item.Text)
+			if item.Translation != "" {
+				b.WriteString(" / " + item.Translation)
+			}
+			b.WriteString("\n")
+		}
+	}
+
+	if reviewURL != "" {
+		b.WriteString("\nStart reviewing: " + reviewURL + "\n")
+	}
+	return b.String()
+}
+
+// HubNotifier sends review reminders to the user's open WebSocket connections
+type HubNotifier struct {
+	hub *ws.Hub
+}
+
+// NewHubNotifier creates a notifier that sends reminders through the hub
+func NewHubNotifier(hub *ws.Hub) *HubNotifier {
+	return &HubNotifier{hub: hub}
+}
+
+// Channel returns models.ReminderChannelWebSocket
+func (n *HubNotifier) Channel() string {
+	return models.ReminderChannelWebSocket
+}
+
+// NotifyReviewReminder sends a review_reminder message; users without a connection are skipped
+func (n *HubNotifier) NotifyReviewReminder(ctx context.Context, reminder *models.ReviewReminder) error {
+	userID, err := uuid.Parse(reminder.UserID)
+	if err != nil {
+		return fmt.Errorf("invalid user id %q: %w", reminder.UserID, err)
+	}
+
+	items := make([]ws.ReviewItem, 0, len(reminder.Items))
+	for _, item := range reminder.Items {
+		itemID, err := uuid.Parse(item.ID)
+		if err != nil {
+			continue
+		}
+		items = append(items, ws.ReviewItem{
+			ID:          itemID,
+			Content:     item.Text,
+			Translation: item.Translation,
+			DueDate:     item.NextReview,
+			Priority:    item.Priority,
+		})
+	}
+
+	message, err := ws.NewReviewReminderMessage(reminder.DueCount, items)
+	if err != nil {
+		return err
+	}
+	return n.hub.SendToUser(userID, message)
+}
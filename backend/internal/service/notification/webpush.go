@@ -0,0 +1,454 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"os"
+	"syscall"
+	"time"
+
+	"github.com/clearclown/HaiLanGo/backend/internal/models"
+	"github.com/clearclown/HaiLanGo/backend/internal/repository"
+)
+
+const (
+	// pushRecordSize is the aes128gcm record size; reminders always fit in a single record
+	pushRecordSize = 4096
+	// pushTTL is how long the push service keeps an undelivered reminder (a day, until the next one)
+	pushTTL = 24 * time.Hour
+	// vapidTokenLifetime is the expiry of the VAPID JWT (at most 24 hours by RFC 8292)
+	vapidTokenLifetime = 12 * time.Hour
+)
+
+var (
+	// ErrInvalidSubscription is returned when the browser's subscription endpoint or keys are malformed
+	ErrInvalidSubscription = errors.New("invalid push subscription")
+	// ErrInvalidVAPIDKeys is returned when the VAPID key pair cannot be decoded
+	ErrInvalidVAPIDKeys = errors.New("invalid vapid keys")
+
+	// errSubscriptionGone is returned by the push service for expired or unsubscribed subscriptions
+	errSubscriptionGone = errors.New("push subscription is gone")
+	// errNonPublicEndpoint is returned when an endpoint resolves to a loopback, private or link-local address
+	errNonPublicEndpoint = errors.New("push endpoint is not a public address")
+)
+
+// VAPIDKeys is the application server key pair for Web Push (RFC 8292), base64url-encoded without padding
+type VAPIDKeys struct {
+	PublicKey  string // uncompressed P-256 point (65 bytes), passed to pushManager.subscribe as applicationServerKey
+	PrivateKey string // P-256 scalar (32 bytes)
+}
+
+// GenerateVAPIDKeys generates a new VAPID key pair
+func GenerateVAPIDKeys() (VAPIDKeys, error) {
+	key, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return VAPIDKeys{}, err
+	}
+	return VAPIDKeys{
+		PublicKey:  base64.RawURLEncoding.EncodeToString(key.PublicKey().Bytes()),
+		PrivateKey: base64.RawURLEncoding.EncodeToString(key.Bytes()),
+	}, nil
+}
+
+// WebPushConfig holds the VAPID identity used to send Web Push messages
+type WebPushConfig struct {
+	Keys VAPIDKeys
+	// Subject is a contact URI for the push service operator (mailto: or https:)
+	Subject string
+}
+
+// WebPushConfigFromEnv reads VAPID_PUBLIC_KEY, VAPID_PRIVATE_KEY and VAPID_SUBJECT
+// It returns false when the key pair is not set
+func WebPushConfigFromEnv() (WebPushConfig, bool) {
+	config := WebPushConfig{
+		Keys: VAPIDKeys{
+			PublicKey:  os.Getenv("VAPID_PUBLIC_KEY"),
+			PrivateKey: os.Getenv("VAPID_PRIVATE_KEY"),
+		},
+		Subject: os.Getenv("VAPID_SUBJECT"),
+	}
+	return config, config.Keys.PublicKey != "" && config.Keys.PrivateKey != ""
+}
+
+// WebPushNotifier sends review reminders to the user's browsers with the Web Push protocol
+// Payloads are encrypted with aes128gcm (RFC 8291) and authenticated with VAPID (RFC 8292)
+type WebPushNotifier struct {
+	subscriptions repository.PushSubscriptionRepository
+	key           *ecdsa.PrivateKey
+	publicKey     string
+	subject       string
+	client        *http.Client
+	reviewURL     string
+}
+
+// NewWebPushNotifier creates a Web Push notifier
+func NewWebPushNotifier(config WebPushConfig, subscriptions repository.PushSubscriptionRepository) (*WebPushNotifier, error) {
+	key, err := parseVAPIDKeys(config.Keys)
+	if err != nil {
+		return nil, err
+	}
+	return &WebPushNotifier{
+		subscriptions: subscriptions,
+		key:           key,
+		publicKey:     config.Keys.PublicKey,
+		subject:       config.Subject,
+		client:        newPushClient(),
+	}, nil
+}
+
+// newPushClient returns an HTTP client that only connects to public addresses
+// Endpoints are supplied by browsers, so the address is checked after DNS resolution to keep
+// subscriptions from reaching the server's own network (SSRF); redirects are not followed
+func newPushClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 10 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip, err := netip.ParseAddr(host)
+			if err != nil || !isPublicAddr(ip) {
+				return fmt.Errorf("%w: %s", errNonPublicEndpoint, host)
+			}
+			return nil
+		},
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{
+		Timeout:   30 * time.Second,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// isPublicAddr reports whether the address is a globally routable unicast address
+func isPublicAddr(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	return ip.IsGlobalUnicast() && !ip.IsPrivate() && !sharedAddressSpace.Contains(ip)
+}
+
+// sharedAddressSpace is the carrier-grade NAT range (RFC 6598), not reachable from the internet
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// validatePushEndpoint checks that the endpoint is an https URL (RFC 8030 requires TLS)
+func validatePushEndpoint(endpoint string) error {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Scheme != "https" || u.Hostname() == "" || u.User != nil {
+		return fmt.Errorf("%w: endpoint must be an https URL", ErrInvalidSubscription)
+	}
+	return nil
+}
+
+// parseVAPIDKeys decodes the VAPID private key and checks that the public key matches it
+func parseVAPIDKeys(keys VAPIDKeys) (*ecdsa.PrivateKey, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(keys.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidVAPIDKeys, err)
+	}
+	private, err := ecdh.P256().NewPrivateKey(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidVAPIDKeys, err)
+	}
+	public := private.PublicKey().Bytes()
+	if base64.RawURLEncoding.EncodeToString(public) != keys.PublicKey {
+		return nil, fmt.Errorf("%w: public key does not match the private key", ErrInvalidVAPIDKeys)
+	}
+
+	return &ecdsa.PrivateKey{
+		PublicKey: ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(public[1:33]),
+			Y:     new(big.Int).SetBytes(public[33:]),
+		},
+		D: new(big.Int).SetBytes(raw),
+	}, nil
+}
+
+// SetReviewURL sets the page opened when the notification is clicked
+func (n *WebPushNotifier) SetReviewURL(reviewURL string) {
+	n.reviewURL = reviewURL
+}
+
+// PublicKey returns the VAPID public key the browser subscribes with
+func (n *WebPushNotifier) PublicKey() string {
+	return n.publicKey
+}
+
+// Subscribe saves a browser's push subscription for the user
+func (n *WebPushNotifier) Subscribe(ctx context.Context, userID string, req *models.PushSubscriptionRequest) (*models.PushSubscription, error) {
+	if err := validatePushEndpoint(req.Endpoint); err != nil {
+		return nil, err
+	}
+	if _, err := decodeSubscriptionKeys(req.Keys.P256dh, req.Keys.Auth); err != nil {
+		return nil, err
+	}
+
+	subscription := &models.PushSubscription{
+		UserID:   userID,
+		Endpoint: req.Endpoint,
+		P256dh:   req.Keys.P256dh,
+		Auth:     req.Keys.Auth,
+	}
+	if err := n.subscriptions.Save(ctx, subscription); err != nil {
+		return nil, err
+	}
+	return subscription, nil
+}
+
+// Unsubscribe deletes the user's subscription for the endpoint
+func (n *WebPushNotifier) Unsubscribe(ctx context.Context, userID string, endpoint string) error {
+	return n.subscriptions.Delete(ctx, userID, endpoint)
+}
+
+// Channel returns models.ReminderChannelPush
+func (n *WebPushNotifier) Channel() string {
+	return models.ReminderChannelPush
+}
+
+// pushPayload is the JSON the service worker receives in its push event
+type pushPayload struct {
+	Type  string `json:"type"`
+	Title string `json:"title"`
+	Body  string `json:"body"`
+	URL   string `json:"url,omitempty"`
+	Count int    `json:"count"`
+}
+
+// NotifyReviewReminder pushes the reminder to every subscribed browser of the user
+// Subscriptions the push service reports as gone are deleted
+func (n *WebPushNotifier) NotifyReviewReminder(ctx context.Context, reminder *models.ReviewReminder) error {
+	subscriptions, err := n.subscriptions.FindByUserID(ctx, reminder.UserID)
+	if err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(pushPayload{
+		Type:  "review_reminder",
+		Title: reminderTitle(reminder),
+		Body:  reminderBody(reminder, ""),
+		URL:   n.reviewURL,
+		Count: reminder.DueCount,
+	})
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, subscription := range subscriptions {
+		err := n.send(ctx, subscription, payload)
+		if errors.Is(err, errSubscriptionGone) {
+			if err := n.subscriptions.Delete(ctx, subscription.UserID, subscription.Endpoint); err != nil {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// send encrypts the payload for the subscription and posts it to the push service
+func (n *WebPushNotifier) send(ctx context.Context, subscription *models.PushSubscription, payload []byte) error {
+	if err := validatePushEndpoint(subscription.Endpoint); err != nil {
+		return err
+	}
+	keys, err := decodeSubscriptionKeys(subscription.P256dh, subscription.Auth)
+	if err != nil {
+		return err
+	}
+	body, err := encryptPushPayload(keys, payload)
+	if err != nil {
+		return err
+	}
+	token, err := n.vapidToken(subscription.Endpoint, time.Now())
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("TTL", fmt.Sprintf("%d", int(pushTTL.Seconds())))
+	req.Header.Set("Urgency", "normal")
+	req.Header.Set("Authorization", fmt.Sprintf("vapid t=%s, k=%s", token, n.publicKey))
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		return errSubscriptionGone
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("push service returned %d: %s", resp.StatusCode, bytes.TrimSpace(message))
+	}
+	return nil
+}
+
+// vapidToken signs the ES256 JWT identifying this server to the push service of the endpoint
+func (n *WebPushNotifier) vapidToken(endpoint string, now time.Time) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", err
+	}
+
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"typ":"JWT","alg":"ES256"}`))
+	claims := map[string]interface{}{
+		"aud": u.Scheme + "://" + u.Host,
+		"exp": now.Add(vapidTokenLifetime).Unix(),
+	}
+	if n.subject != "" {
+		claims["sub"] = n.subject
+	}
+	claimsJSON, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	unsigned := header + "." + base64.RawURLEncoding.EncodeToString(claimsJSON)
+
+	digest := sha256.Sum256([]byte(unsigned))
+	r, s, err := ecdsa.Sign(rand.Reader, n.key, digest[:])
+	if err != nil {
+		return "", err
+	}
+	signature := make([]byte, 64)
+	r.FillBytes(signature[:32])
+	s.FillBytes(signature[32:])
+
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// subscriptionKeys are the decoded keys of a browser's subscription
+type subscriptionKeys struct {
+	public *ecdh.PublicKey
+	auth   []byte
+}
+
+// decodeSubscriptionKeys decodes the browser's P-256 public key and 16-byte authentication secret
+func decodeSubscriptionKeys(p256dh, auth string) (*subscriptionKeys, error) {
+	rawPublic, err := base64.RawURLEncoding.DecodeString(trimPadding(p256dh))
+	if err != nil {
+		return nil, fmt.Errorf("%w: p256dh: %v", ErrInvalidSubscription, err)
+	}
+	public, err := ecdh.P256().NewPublicKey(rawPublic)
+	if err != nil {
+		return nil, fmt.Errorf("%w: p256dh: %v", ErrInvalidSubscription, err)
+	}
+	secret, err := base64.RawURLEncoding.DecodeString(trimPadding(auth))
+	if err != nil || len(secret) != 16 {
+		return nil, fmt.Errorf("%w: auth must be 16 bytes", ErrInvalidSubscription)
+	}
+	return &subscriptionKeys{public: public, auth: secret}, nil
+}
+
+// trimPadding removes base64 padding some browsers add to the keys
+func trimPadding(value string) string {
+	for len(value) > 0 && value[len(value)-1] == '=' {
+		value = value[:len(value)-1]
+	}
+	return value
+}
+
+// encryptPushPayload encrypts the payload for the browser as a single aes128gcm record (RFC 8291)
+func encryptPushPayload(keys *subscriptionKeys, payload []byte) ([]byte, error) {
+	if len(payload)+1+16 > pushRecordSize {
+		return nil, fmt.Errorf("push payload too large: %d bytes", len(payload))
+	}
+
+	ephemeral, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	sharedSecret, err := ephemeral.ECDH(keys.public)
+	if err != nil {
+		return nil, err
+	}
+	serverPublic := ephemeral.PublicKey().Bytes()
+	cek, nonce := pushContentKeys(sharedSecret, keys.auth, salt, keys.public.Bytes(), serverPublic)
+
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	// 0x02 marks the last (and only) record; no padding is added
+	plaintext := append(append([]byte{}, payload...), 0x02)
+
+	header := make([]byte, 0, 16+4+1+len(serverPublic))
+	header = append(header, salt...)
+	header = binary.BigEndian.AppendUint32(header, pushRecordSize)
+	header = append(header, byte(len(serverPublic)))
+	header = append(header, serverPublic...)
+
+	return gcm.Seal(header, nonce, plaintext, nil), nil
+}
+
+// pushContentKeys derives the content encryption key and nonce from the ECDH shared secret (RFC 8291 section 3.4)
+func pushContentKeys(sharedSecret, authSecret, salt, clientPublic, serverPublic []byte) ([]byte, []byte) {
+	keyInfo := append([]byte("WebPush: info\x00"), clientPublic...)
+	keyInfo = append(keyInfo, serverPublic...)
+	ikm := hkdfExpand(hkdfExtract(authSecret, sharedSecret), keyInfo, 32)
+
+	prk := hkdfExtract(salt, ikm)
+	cek := hkdfExpand(prk, []byte("Content-Encoding: aes128gcm\x00"), 16)
+	nonce := hkdfExpand(prk, []byte("Content-Encoding: nonce\x00"), 12)
+	return cek, nonce
+}
+
+// hkdfExtract is HKDF-Extract with SHA-256
+func hkdfExtract(salt, ikm []byte) []byte {
+	mac := hmac.New(sha256.New, salt)
+	mac.Write(ikm)
+	return mac.Sum(nil)
+}
+
+// hkdfExpand is HKDF-Expand with SHA-256 for outputs of at most one block (32 bytes)
+func hkdfExpand(prk, info []byte, length int) []byte {
+	mac := hmac.New(sha256.New, prk)
+	mac.Write(info)
+	mac.Write([]byte{0x01})
+	return mac.Sum(nil)[:length]
+}
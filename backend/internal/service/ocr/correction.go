@@ -0,0 +1,661 @@
+package ocr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/clearclown/HaiLanGo/backend/internal/models"
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrAutoCorrectionNotFound は自動修正が存在しないエラー
+	ErrAutoCorrectionNotFound = errors.New("auto correction not found")
+	// ErrAutoCorrectionReverted は自動修正が既に取り消されているエラー
+	ErrAutoCorrectionReverted = errors.New("auto correction has already been reverted")
+	// ErrAutoCorrectionConflict はページのテキストに自動修正の結果が残っていないエラー
+	ErrAutoCorrectionConflict = errors.New("page text no longer contains the auto correction")
+)
+
+const (
+	// UserRuleMinOccurrences はユーザー全体のルールを他の書籍に適用するまでに必要な同じ修正の回数
+	UserRuleMinOccurrences = 2
+
+	// maxSubstitutionRunes は学習する置換の前後それぞれの最大文字数
+	maxSubstitutionRunes = 24
+	// maxSubstitutionTokens は学習する置換で置き換える最大単語数
+	maxSubstitutionTokens = 3
+	// maxDiffCells は差分表の最大サイズ（これより大きい書き換えからは学習しない）
+	maxDiffCells = 4_000_000
+)
+
+// CorrectionRuleRepository は学習した修正ルールのリポジトリインターフェース
+type CorrectionRuleRepository interface {
+	// FindRules は書籍のルールとユーザー全体のルールを返す
+	FindRules(ctx context.Context, userID, bookID uuid.UUID) ([]*models.OCRCorrectionRule, error)
+	GetRule(ctx context.Context, ruleID uuid.UUID) (*models.OCRCorrectionRule, error)
+	SaveRule(ctx context.Context, rule *models.OCRCorrectionRule) error
+
+	ReplacePageAutoCorrections(ctx context.Context, pageID uuid.UUID, corrections []*models.OCRAutoCorrection) error
+	GetAutoCorrection(ctx context.Context, correctionID uuid.UUID) (*models.OCRAutoCorrection, error)
+	UpdateAutoCorrection(ctx context.Context, correction *models.OCRAutoCorrection) error
+	FindAutoCorrectionsByPage(ctx context.Context, pageID uuid.UUID) ([]*models.OCRAutoCorrection, error)
+	// FindAutoCorrectionsByBook は書籍の自動修正を返す（status が空の場合はすべて）
+	FindAutoCorrectionsByBook(ctx context.Context, bookID uuid.UUID, status models.OCRAutoCorrectionStatus) ([]*models.OCRAutoCorrection, error)
+}
+
+// Substitution は2つのテキストの差分から見つかった置換
+type Substitution struct {
+	Original    string `json:"original"`
+	Replacement string `json:"replacement"`
+}
+
+// CorrectionLearner はOCR結果の手動修正から置換を学習し、新しいページに適用する
+type CorrectionLearner struct {
+	pageRepo PageRepository
+	repo     CorrectionRuleRepository
+}
+
+// NewCorrectionLearner は新しい CorrectionLearner を作成する
+func NewCorrectionLearner(pageRepo PageRepository, repo CorrectionRuleRepository) *CorrectionLearner {
+	return &CorrectionLearner{
+		pageRepo: pageRepo,
+		repo:     repo,
+	}
+}
+
+// Learn は修正前と修正後のページのテキストの置換を、書籍とユーザーのルールとして記録する
+// 自動修正を元のテキストに戻した場合は、逆の置換を学習せずにその自動修正のルールを無効にする
+func (l *CorrectionLearner) Learn(ctx context.Context, userID, bookID uuid.UUID, previous, corrected string) ([]Substitution, error) {
+	substitutions := ExtractSubstitutions(previous, corrected)
+	if len(substitutions) == 0 {
+		return nil, nil
+	}
+
+	rules, err := l.repo.FindRules(ctx, userID, bookID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get correction rules: %w", err)
+	}
+
+	now := time.Now()
+	for _, sub := range substitutions {
+		for _, scope := range []*uuid.UUID{&bookID, nil} {
+			rule, err := l.learnRule(ctx, rules, userID, scope, sub, now)
+			if err != nil {
+				return nil, err
+			}
+			if rule != nil {
+				rules = append(rules, rule)
+			}
+		}
+	}
+
+	return substitutions, nil
+}
+
+// learnRule は1つの置換について、スコープ内のルールを更新する
+// 新しいルールを作成した場合はそのルールを返す
+func (l *CorrectionLearner) learnRule(
+	ctx context.Context,
+	rules []*models.OCRCorrectionRule,
+	userID uuid.UUID,
+	bookID *uuid.UUID,
+	sub Substitution,
+	now time.Time,
+) (*models.OCRCorrectionRule, error) {
+	var existing *models.OCRCorrectionRule
+	for _, rule := range rules {
+		if !sameRuleScope(rule.BookID, bookID) {
+			continue
+		}
+		if rule.Original == sub.Replacement && rule.Replacement == sub.Original {
+			// このルールによる修正をユーザーが元に戻した
+			if rule.Disabled {
+				return nil, nil
+			}
+			rule.Disabled = true
+			rule.UpdatedAt = now
+			return nil, l.saveRule(ctx, rule)
+		}
+		if rule.Original == sub.Original {
+			existing = rule
+		}
+	}
+
+	if existing != nil {
+		if existing.Replacement == sub.Replacement {
+			existing.Occurrences++
+		} else {
+			existing.Replacement = sub.Replacement
+			existing.Occurrences = 1
+		}
+		existing.Disabled = false
+		existing.UpdatedAt = now
+		return nil, l.saveRule(ctx, existing)
+	}
+
+	rule := &models.OCRCorrectionRule{
+		ID:          uuid.New(),
+		UserID:      userID,
+		Original:    sub.Original,
+		Replacement: sub.Replacement,
+		Occurrences: 1,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if bookID != nil {
+		id := *bookID
+		rule.BookID = &id
+	}
+	if err := l.saveRule(ctx, rule); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+func (l *CorrectionLearner) saveRule(ctx context.Context, rule *models.OCRCorrectionRule) error {
+	if err := l.repo.SaveRule(ctx, rule); err != nil {
+		return fmt.Errorf("failed to save correction rule: %w", err)
+	}
+	return nil
+}
+
+// Apply はページの書籍で有効なルールをOCRの生のテキストに適用する
+// 置換した場合はページの CorrectedText を設定する（ページ自体は保存しない）
+// 返した自動修正は、ページの保存後に SaveAutoCorrections で保存する必要がある
+func (l *CorrectionLearner) Apply(ctx context.Context, page *models.Page, userID uuid.UUID) ([]*models.OCRAutoCorrection, error) {
+	rules, err := l.repo.FindRules(ctx, userID, page.BookID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get correction rules: %w", err)
+	}
+
+	text, corrections := ApplyRules(page.OCRText, activeRules(rules))
+	if len(corrections) == 0 {
+		return nil, nil
+	}
+
+	now := time.Now()
+	for _, correction := range corrections {
+		correction.UserID = userID
+		correction.BookID = page.BookID
+		correction.PageID = page.ID
+		correction.PageNumber = page.PageNumber
+		correction.CreatedAt = now
+	}
+	page.CorrectedText = &text
+
+	return corrections, nil
+}
+
+// SaveAutoCorrections はページの自動修正の記録を置き換える
+func (l *CorrectionLearner) SaveAutoCorrections(ctx context.Context, pageID uuid.UUID, corrections []*models.OCRAutoCorrection) error {
+	if err := l.repo.ReplacePageAutoCorrections(ctx, pageID, corrections); err != nil {
+		return fmt.Errorf("failed to save auto corrections: %w", err)
+	}
+	return nil
+}
+
+// ListAutoCorrections は確認用に書籍の自動修正を返す
+func (l *CorrectionLearner) ListAutoCorrections(ctx context.Context, bookID uuid.UUID, status models.OCRAutoCorrectionStatus) ([]*models.OCRAutoCorrection, error) {
+	corrections, err := l.repo.FindAutoCorrectionsByBook(ctx, bookID, status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get auto corrections: %w", err)
+	}
+	return corrections, nil
+}
+
+// ListRules は書籍に適用されうるルールを返す
+func (l *CorrectionLearner) ListRules(ctx context.Context, userID, bookID uuid.UUID) ([]*models.OCRCorrectionRule, error) {
+	rules, err := l.repo.FindRules(ctx, userID, bookID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get correction rules: %w", err)
+	}
+	return rules, nil
+}
+
+// Accept は自動修正を確認済みにし、そのルールを強化する
+func (l *CorrectionLearner) Accept(ctx context.Context, userID, correctionID uuid.UUID) (*models.OCRAutoCorrection, error) {
+	correction, err := l.getAutoCorrection(ctx, userID, correctionID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch correction.Status {
+	case models.OCRAutoCorrectionReverted:
+		return nil, ErrAutoCorrectionReverted
+	case models.OCRAutoCorrectionAccepted:
+		return correction, nil
+	}
+
+	now := time.Now()
+	correction.Status = models.OCRAutoCorrectionAccepted
+	correction.ReviewedAt = &now
+	if err := l.repo.UpdateAutoCorrection(ctx, correction); err != nil {
+		return nil, fmt.Errorf("failed to update auto correction: %w", err)
+	}
+
+	rule, err := l.repo.GetRule(ctx, correction.RuleID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get correction rule: %w", err)
+	}
+	if rule != nil {
+		rule.Occurrences++
+		rule.UpdatedAt = now
+		if err := l.saveRule(ctx, rule); err != nil {
+			return nil, err
+		}
+	}
+
+	return correction, nil
+}
+
+// Undo はページの自動修正を元のテキストに戻す
+// disableRule が指定された場合は、以降のページにそのルールを適用しない
+func (l *CorrectionLearner) Undo(ctx context.Context, userID, correctionID uuid.UUID, disableRule bool) (*models.OCRAutoCorrection, error) {
+	correction, err := l.getAutoCorrection(ctx, userID, correctionID)
+	if err != nil {
+		return nil, err
+	}
+	if correction.Status == models.OCRAutoCorrectionReverted {
+		return nil, ErrAutoCorrectionReverted
+	}
+
+	page, err := l.pageRepo.GetByID(ctx, correction.PageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get page: %w", err)
+	}
+	if page == nil {
+		return nil, ErrPageNotFound
+	}
+
+	text := []rune(page.Text())
+	replacement := []rune(correction.Replacement)
+	offset := findNearest(text, replacement, correction.Offset)
+	if offset < 0 {
+		return nil, ErrAutoCorrectionConflict
+	}
+
+	restored := string(text[:offset]) + correction.Original + string(text[offset+len(replacement):])
+	page.CorrectedText = &restored
+	page.UpdatedAt = time.Now()
+	if err := l.pageRepo.Update(ctx, page); err != nil {
+		return nil, fmt.Errorf("failed to update page: %w", err)
+	}
+
+	// ページの他の自動修正の位置を、元に戻したテキストに合わせる
+	delta := len([]rune(correction.Original)) - len(replacement)
+	others, err := l.repo.FindAutoCorrectionsByPage(ctx, page.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get auto corrections: %w", err)
+	}
+	for _, other := range others {
+		if other.ID == correction.ID || other.Offset <= offset || delta == 0 {
+			continue
+		}
+		other.Offset += delta
+		if err := l.repo.UpdateAutoCorrection(ctx, other); err != nil {
+			return nil, fmt.Errorf("failed to update auto correction: %w", err)
+		}
+	}
+
+	now := time.Now()
+	correction.Status = models.OCRAutoCorrectionReverted
+	correction.Offset = offset
+	correction.ReviewedAt = &now
+	if err := l.repo.UpdateAutoCorrection(ctx, correction); err != nil {
+		return nil, fmt.Errorf("failed to update auto correction: %w", err)
+	}
+
+	if disableRule {
+		rule, err := l.repo.GetRule(ctx, correction.RuleID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get correction rule: %w", err)
+		}
+		if rule != nil && !rule.Disabled {
+			rule.Disabled = true
+			rule.UpdatedAt = now
+			if err := l.saveRule(ctx, rule); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return correction, nil
+}
+
+// getAutoCorrection は自動修正を取得し、ユーザーのものであることを確認する
+func (l *CorrectionLearner) getAutoCorrection(ctx context.Context, userID, correctionID uuid.UUID) (*models.OCRAutoCorrection, error) {
+	correction, err := l.repo.GetAutoCorrection(ctx, correctionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get auto correction: %w", err)
+	}
+	if correction == nil {
+		return nil, ErrAutoCorrectionNotFound
+	}
+	if correction.UserID != userID {
+		return nil, ErrUnauthorized
+	}
+	return correction, nil
+}
+
+// activeRules は書籍に適用するルールを選ぶ
+// 書籍のルールはすぐに適用し、ユーザー全体のルールは同じ修正が UserRuleMinOccurrences 回以上あった場合のみ適用する
+// 同じテキストのルールは書籍のルールをユーザー全体のルールより優先する
+func activeRules(rules []*models.OCRCorrectionRule) []*models.OCRCorrectionRule {
+	byOriginal := make(map[string]*models.OCRCorrectionRule)
+	for _, rule := range rules {
+		if rule.Disabled {
+			continue
+		}
+		if rule.BookID == nil && rule.Occurrences < UserRuleMinOccurrences {
+			continue
+		}
+		if current, ok := byOriginal[rule.Original]; ok && current.BookID != nil {
+			continue
+		}
+		byOriginal[rule.Original] = rule
+	}
+
+	active := make([]*models.OCRCorrectionRule, 0, len(byOriginal))
+	for _, rule := range byOriginal {
+		active = append(active, rule)
+	}
+	return active
+}
+
+// ApplyRules はルールの修正前のテキストをすべて置換する（最長一致を優先する）
+// 分かち書きしない文字以外は、単語の境界で始まり単語の境界で終わる箇所のみを置換する
+// 修正後のテキストと、そのテキストでの位置（文字単位）を持つ自動修正を返す
+func ApplyRules(text string, rules []*models.OCRCorrectionRule) (string, []*models.OCRAutoCorrection) {
+	type compiledRule struct {
+		rule     *models.OCRCorrectionRule
+		original []rune
+	}
+
+	compiled := make([]compiledRule, 0, len(rules))
+	for _, rule := range rules {
+		if rule.Original == "" || rule.Original == rule.Replacement {
+			continue
+		}
+		compiled = append(compiled, compiledRule{rule: rule, original: []rune(rule.Original)})
+	}
+	if len(compiled) == 0 {
+		return text, nil
+	}
+	sort.SliceStable(compiled, func(i, j int) bool {
+		if len(compiled[i].original) != len(compiled[j].original) {
+			return len(compiled[i].original) > len(compiled[j].original)
+		}
+		return compiled[i].rule.Original < compiled[j].rule.Original
+	})
+
+	runes := []rune(text)
+	out := make([]rune, 0, len(runes))
+	var corrections []*models.OCRAutoCorrection
+	for i := 0; i < len(runes); {
+		matched := false
+		for _, c := range compiled {
+			if !matchRunesAt(runes, i, c.original) {
+				continue
+			}
+			corrections = append(corrections, &models.OCRAutoCorrection{
+				ID:          uuid.New(),
+				RuleID:      c.rule.ID,
+				Original:    c.rule.Original,
+				Replacement: c.rule.Replacement,
+				Offset:      len(out),
+				Status:      models.OCRAutoCorrectionApplied,
+			})
+			out = append(out, []rune(c.rule.Replacement)...)
+			i += len(c.original)
+			matched = true
+			break
+		}
+		if !matched {
+			out = append(out, runes[i])
+			i++
+		}
+	}
+
+	return string(out), corrections
+}
+
+// matchRunesAt は位置 i に単語の境界で pattern があるかどうかを返す
+func matchRunesAt(runes []rune, i int, pattern []rune) bool {
+	if i+len(pattern) > len(runes) {
+		return false
+	}
+	for j, r := range pattern {
+		if runes[i+j] != r {
+			return false
+		}
+	}
+
+	if isSpacedWordRune(pattern[0]) && i > 0 && isSpacedWordRune(runes[i-1]) {
+		return false
+	}
+	end := i + len(pattern)
+	if isSpacedWordRune(pattern[len(pattern)-1]) && end < len(runes) && isSpacedWordRune(runes[end]) {
+		return false
+	}
+	return true
+}
+
+// findNearest は offset に最も近い pattern の位置を返す（ない場合は -1）
+func findNearest(runes []rune, pattern []rune, offset int) int {
+	if len(pattern) == 0 {
+		return -1
+	}
+	best := -1
+	for i := 0; i+len(pattern) <= len(runes); i++ {
+		if !matchRunesAt(runes, i, pattern) {
+			continue
+		}
+		if best < 0 || abs(i-offset) < abs(best-offset) {
+			best = i
+		}
+	}
+	return best
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// ExtractSubstitutions は修正前と修正後のテキストの、単語単位の短い置換を返す
+// 挿入・削除・長い書き換えは他のページに当てはまらないため無視する
+// 分かち書きしない文字の1文字の置換は、すべての箇所を置き換えると強すぎるため前後の文字と合わせて学習する
+func ExtractSubstitutions(original, corrected string) []Substitution {
+	a, b := tokenizeCorrection(original), tokenizeCorrection(corrected)
+
+	// 差分表を小さくするため、共通の先頭と末尾を除く
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	innerA, innerB := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	if len(innerA) == 0 || len(innerB) == 0 || len(innerA)*len(innerB) > maxDiffCells {
+		return nil
+	}
+
+	hunks := diffHunks(innerA, innerB)
+
+	// 複数の箇所でほとんどの単語が変わった場合は、認識誤りの修正ではなく書き換えとみなす
+	changed := 0
+	for _, h := range hunks {
+		changed += countWords(innerA[h.aStart:h.aEnd])
+	}
+	if len(hunks) > 1 && changed*2 > countWords(a) {
+		return nil
+	}
+
+	seen := make(map[Substitution]bool)
+	var substitutions []Substitution
+	for _, h := range hunks {
+		if h.aStart == h.aEnd || h.bStart == h.bEnd {
+			continue
+		}
+		aStart, aEnd := h.aStart+prefix, h.aEnd+prefix
+		bStart, bEnd := h.bStart+prefix, h.bEnd+prefix
+
+		if aEnd-aStart == 1 && isUnspacedToken(a[aStart]) {
+			if aStart > 0 && bStart > 0 && isUnspacedToken(a[aStart-1]) {
+				aStart--
+				bStart--
+			}
+			if aEnd < len(a) && bEnd < len(b) && isUnspacedToken(a[aEnd]) {
+				aEnd++
+				bEnd++
+			}
+		}
+
+		sub := Substitution{
+			Original:    strings.TrimSpace(strings.Join(a[aStart:aEnd], "")),
+			Replacement: strings.TrimSpace(strings.Join(b[bStart:bEnd], "")),
+		}
+		if !learnableSubstitution(sub) || seen[sub] {
+			continue
+		}
+		seen[sub] = true
+		substitutions = append(substitutions, sub)
+	}
+
+	return substitutions
+}
+
+// learnableSubstitution は置換が他のページに適用できるほど短いかどうかを返す
+func learnableSubstitution(sub Substitution) bool {
+	if sub.Original == "" || sub.Replacement == "" || sub.Original == sub.Replacement {
+		return false
+	}
+	if strings.ContainsRune(sub.Original, '\n') || strings.ContainsRune(sub.Replacement, '\n') {
+		return false
+	}
+	for _, s := range []string{sub.Original, sub.Replacement} {
+		if len([]rune(s)) > maxSubstitutionRunes || len(strings.Fields(s)) > maxSubstitutionTokens {
+			return false
+		}
+	}
+	return true
+}
+
+// diffHunk は変更されたトークンの最大の連続（a[aStart:aEnd] が b[bStart:bEnd] に置き換えられた）
+type diffHunk struct {
+	aStart, aEnd int
+	bStart, bEnd int
+}
+
+// diffHunks は最長共通部分列で2つのトークン列の変更箇所を求める
+func diffHunks(a, b []string) []diffHunk {
+	n, m := len(a), len(b)
+	width := m + 1
+	lcs := make([]int32, (n+1)*width)
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i*width+j] = lcs[(i+1)*width+j+1] + 1
+			} else {
+				lcs[i*width+j] = max(lcs[(i+1)*width+j], lcs[i*width+j+1])
+			}
+		}
+	}
+
+	var hunks []diffHunk
+	var current *diffHunk
+	flush := func() {
+		if current != nil {
+			hunks = append(hunks, *current)
+			current = nil
+		}
+	}
+
+	i, j := 0, 0
+	for i < n || j < m {
+		if i < n && j < m && a[i] == b[j] {
+			flush()
+			i++
+			j++
+			continue
+		}
+		if current == nil {
+			current = &diffHunk{aStart: i, aEnd: i, bStart: j, bEnd: j}
+		}
+		if j < m && (i == n || lcs[i*width+j+1] >= lcs[(i+1)*width+j]) {
+			j++
+			current.bEnd = j
+		} else {
+			i++
+			current.aEnd = i
+		}
+	}
+	flush()
+
+	return hunks
+}
+
+// tokenizeCorrection はテキストを単語・連続する空白・1文字に分割する
+// 分かち書きしない文字（漢字・かな・タイ文字）は1文字ずつトークンにする
+func tokenizeCorrection(text string) []string {
+	runes := []rune(text)
+	var tokens []string
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		end := i + 1
+		switch {
+		case unicode.IsSpace(r):
+			for end < len(runes) && unicode.IsSpace(runes[end]) {
+				end++
+			}
+		case isSpacedWordRune(r):
+			for end < len(runes) && (isSpacedWordRune(runes[end]) ||
+				(runes[end] == '\'' || runes[end] == '-') && end+1 < len(runes) && isSpacedWordRune(runes[end+1])) {
+				end++
+			}
+		}
+		tokens = append(tokens, string(runes[i:end]))
+		i = end
+	}
+	return tokens
+}
+
+// countWords は空白以外のトークンの数を返す
+func countWords(tokens []string) int {
+	count := 0
+	for _, token := range tokens {
+		if strings.TrimSpace(token) != "" {
+			count++
+		}
+	}
+	return count
+}
+
+// isSpacedWordRune は r が空白で区切られる単語の文字かどうかを返す
+func isSpacedWordRune(r rune) bool {
+	return (unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r)) && !isUnspacedScript(r)
+}
+
+// isUnspacedToken はトークンが分かち書きしない文字の1文字かどうかを返す
+func isUnspacedToken(token string) bool {
+	runes := []rune(token)
+	return len(runes) == 1 && isUnspacedScript(runes[0])
+}
+
+// sameRuleScope は2つのルールのスコープ（書籍ID、ユーザー全体の場合は nil）が等しいかどうかを返す
+func sameRuleScope(a, b *uuid.UUID) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
@@ -0,0 +1,190 @@
+package ocr
+
+import (
+	"context"
+	"testing"
+
+	"github.com/clearclown/HaiLanGo/backend/internal/models"
+	"github.com/clearclown/HaiLanGo/backend/internal/repository"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtractSubstitutions(t *testing.T) {
+	testCases := []struct {
+		name      string
+		original  string
+		corrected string
+		expected  []Substitution
+	}{
+		{
+			name:      "misrecognised words",
+			original:  "Здравствуите! Как дела? Здравствуите!",
+			corrected: "Здравствуйте! Как дела? Здравствуйте!",
+			expected:  []Substitution{{Original: "Здравствуите", Replacement: "Здравствуйте"}},
+		},
+		{
+			name:      "single kanji is learned with its neighbours",
+			original:  "末来の話",
+			corrected: "未来の話",
+			expected:  []Substitution{{Original: "末来", Replacement: "未来"}},
+		},
+		{
+			name:      "insertions and deletions are ignored",
+			original:  "Как дела?",
+			corrected: "Как ваши дела?",
+			expected:  nil,
+		},
+		{
+			name:      "long rewrites are ignored",
+			original:  "one two three four five",
+			corrected: "six seven eight nine ten",
+			expected:  nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, ExtractSubstitutions(tc.original, tc.corrected))
+		})
+	}
+}
+
+func TestApplyRules(t *testing.T) {
+	rule := &models.OCRCorrectionRule{ID: uuid.New(), Original: "tbe", Replacement: "the"}
+
+	text, corrections := ApplyRules("tbe cat and tbe dog, atbe", []*models.OCRCorrectionRule{rule})
+	assert.Equal(t, "the cat and the dog, atbe", text)
+	require.Len(t, corrections, 2)
+	assert.Equal(t, 0, corrections[0].Offset)
+	assert.Equal(t, 12, corrections[1].Offset)
+	assert.Equal(t, rule.ID, corrections[1].RuleID)
+	assert.Equal(t, models.OCRAutoCorrectionApplied, corrections[1].Status)
+
+	// 分かち書きしない文字は単語境界を考慮しない
+	kanji := &models.OCRCorrectionRule{ID: uuid.New(), Original: "末来", Replacement: "未来"}
+	text, corrections = ApplyRules("明るい末来へ", []*models.OCRCorrectionRule{kanji})
+	assert.Equal(t, "明るい未来へ", text)
+	require.Len(t, corrections, 1)
+	assert.Equal(t, 3, corrections[0].Offset)
+}
+
+func TestCorrectionLearner(t *testing.T) {
+	ctx := context.Background()
+	pageRepo := newMockPageRepository()
+	correctionRepo := repository.NewInMemoryOCRCorrectionRepository()
+	learner := NewCorrectionLearner(pageRepo, correctionRepo)
+	editor := NewEditorService(pageRepo, correctionRepo)
+	editor.SetCorrectionLearner(learner)
+
+	userID := uuid.New()
+	bookID := uuid.New()
+	otherBookID := uuid.New()
+
+	page1 := &models.Page{ID: uuid.New(), BookID: bookID, PageNumber: 1, OCRText: "Здравствуите! Как дела?"}
+	pageRepo.pages[page1.ID] = page1
+
+	_, err := editor.UpdateOCRText(ctx, bookID, page1.ID, userID, "Здравствуйте! Как дела?")
+	require.NoError(t, err)
+
+	// 同じ書籍の以降のページには自動で適用する
+	page2 := &models.Page{ID: uuid.New(), BookID: bookID, PageNumber: 2, OCRText: "Спасибо. Здравствуите и Здравствуите!"}
+	corrections, err := learner.Apply(ctx, page2, userID)
+	require.NoError(t, err)
+	require.Len(t, corrections, 2)
+	require.NotNil(t, page2.CorrectedText)
+	assert.Equal(t, "Спасибо. Здравствуйте и Здравствуйте!", *page2.CorrectedText)
+	assert.Equal(t, "Спасибо. Здравствуите и Здравствуите!", page2.OCRText)
+	pageRepo.pages[page2.ID] = page2
+	require.NoError(t, learner.SaveAutoCorrections(ctx, page2.ID, corrections))
+
+	// ユーザー全体のルールは同じ修正が繰り返されるまで他の書籍に適用しない
+	other := &models.Page{ID: uuid.New(), BookID: otherBookID, PageNumber: 1, OCRText: "Здравствуите!"}
+	corrections, err = learner.Apply(ctx, other, userID)
+	require.NoError(t, err)
+	assert.Empty(t, corrections)
+	assert.Nil(t, other.CorrectedText)
+
+	// 他のユーザーには適用しない
+	corrections, err = learner.Apply(ctx, &models.Page{ID: uuid.New(), BookID: bookID, OCRText: "Здравствуите!"}, uuid.New())
+	require.NoError(t, err)
+	assert.Empty(t, corrections)
+
+	listed, err := learner.ListAutoCorrections(ctx, bookID, models.OCRAutoCorrectionApplied)
+	require.NoError(t, err)
+	require.Len(t, listed, 2)
+
+	// 取り消すとページのテキストを戻し、後ろの修正の位置をずらす
+	_, err = learner.Undo(ctx, uuid.New(), listed[0].ID, false)
+	assert.ErrorIs(t, err, ErrUnauthorized)
+
+	undone, err := learner.Undo(ctx, userID, listed[0].ID, true)
+	require.NoError(t, err)
+	assert.Equal(t, models.OCRAutoCorrectionReverted, undone.Status)
+	assert.Equal(t, "Спасибо. Здравствуите и Здравствуйте!", *pageRepo.pages[page2.ID].CorrectedText)
+
+	_, err = learner.Undo(ctx, userID, listed[0].ID, false)
+	assert.ErrorIs(t, err, ErrAutoCorrectionReverted)
+
+	accepted, err := learner.Accept(ctx, userID, listed[1].ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.OCRAutoCorrectionAccepted, accepted.Status)
+
+	// ルールを無効にしたので以降のページには適用しない
+	page3 := &models.Page{ID: uuid.New(), BookID: bookID, PageNumber: 3, OCRText: "Здравствуите!"}
+	corrections, err = learner.Apply(ctx, page3, userID)
+	require.NoError(t, err)
+	assert.Empty(t, corrections)
+}
+
+func TestCorrectionLearner_UserWideRules(t *testing.T) {
+	ctx := context.Background()
+	learner := NewCorrectionLearner(newMockPageRepository(), repository.NewInMemoryOCRCorrectionRepository())
+	userID := uuid.New()
+
+	for i := 0; i < UserRuleMinOccurrences; i++ {
+		_, err := learner.Learn(ctx, userID, uuid.New(), "tbe book", "the book")
+		require.NoError(t, err)
+	}
+
+	page := &models.Page{ID: uuid.New(), BookID: uuid.New(), OCRText: "tbe end"}
+	corrections, err := learner.Apply(ctx, page, userID)
+	require.NoError(t, err)
+	require.Len(t, corrections, 1)
+	assert.Equal(t, "the end", *page.CorrectedText)
+
+	// 自動修正を手動で元に戻すと逆向きのルールは学習せず、ルールを無効にする
+	substitutions, err := learner.Learn(ctx, userID, page.BookID, "the end", "tbe end")
+	require.NoError(t, err)
+	require.Len(t, substitutions, 1)
+
+	page = &models.Page{ID: uuid.New(), BookID: page.BookID, OCRText: "tbe end"}
+	corrections, err = learner.Apply(ctx, page, userID)
+	require.NoError(t, err)
+	assert.Empty(t, corrections)
+}
+
+func TestCorrectionLearner_UndoConflict(t *testing.T) {
+	ctx := context.Background()
+	pageRepo := newMockPageRepository()
+	correctionRepo := repository.NewInMemoryOCRCorrectionRepository()
+	learner := NewCorrectionLearner(pageRepo, correctionRepo)
+	userID, bookID := uuid.New(), uuid.New()
+
+	_, err := learner.Learn(ctx, userID, bookID, "tbe", "the")
+	require.NoError(t, err)
+
+	page := &models.Page{ID: uuid.New(), BookID: bookID, OCRText: "tbe cat"}
+	corrections, err := learner.Apply(ctx, page, userID)
+	require.NoError(t, err)
+	require.NoError(t, learner.SaveAutoCorrections(ctx, page.ID, corrections))
+
+	// 手動修正で修正箇所がなくなった場合は取り消せない
+	edited := "a cat"
+	page.CorrectedText = &edited
+	pageRepo.pages[page.ID] = page
+
+	_, err = learner.Undo(ctx, userID, corrections[0].ID, false)
+	assert.ErrorIs(t, err, ErrAutoCorrectionConflict)
+}
@@ -4,10 +4,12 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log"
 	"time"
 	"unicode/utf8"
 
 	"github.com/clearclown/HaiLanGo/backend/internal/models"
+	"github.com/clearclown/HaiLanGo/backend/internal/repository"
 	"github.com/google/uuid"
 )
 
@@ -28,6 +30,24 @@ type PageRepository interface {
 	Update(ctx context.Context, page *models.Page) error
 }
 
+// pageStore adapts repository.PageRepository to PageRepository
+type pageStore struct {
+	repo repository.PageRepository
+}
+
+// NewPageStore wraps a repository.PageRepository for use by the editor and the correction learner
+func NewPageStore(repo repository.PageRepository) PageRepository {
+	return &pageStore{repo: repo}
+}
+
+func (s *pageStore) GetByID(ctx context.Context, pageID uuid.UUID) (*models.Page, error) {
+	return s.repo.FindByID(ctx, pageID)
+}
+
+func (s *pageStore) Update(ctx context.Context, page *models.Page) error {
+	return s.repo.Update(ctx, page)
+}
+
 // CorrectionRepository defines methods for correction history data access
 type CorrectionRepository interface {
 	Create(ctx context.Context, correction *models.OCRTextCorrection) error
@@ -39,6 +59,7 @@ type CorrectionRepository interface {
 type EditorService struct {
 	pageRepo       PageRepository
 	correctionRepo CorrectionRepository
+	learner        *CorrectionLearner // nil disables learning from corrections
 }
 
 // NewEditorService creates a new EditorService
@@ -49,6 +70,11 @@ func NewEditorService(pageRepo PageRepository, correctionRepo CorrectionReposito
 	}
 }
 
+// SetCorrectionLearner enables learning substitution rules from manual corrections
+func (s *EditorService) SetCorrectionLearner(learner *CorrectionLearner) {
+	s.learner = learner
+}
+
 // UpdateOCRText updates the OCR text for a page with manual corrections
 func (s *EditorService) UpdateOCRText(
 	ctx context.Context,
@@ -92,13 +118,21 @@ func (s *EditorService) UpdateOCRText(
 	}
 
 	// Update the page with corrected text
-	// TODO: Add CorrectedText field to Page model
-	// page.CorrectedText = &correctedText
+	previousText := page.Text()
+	page.CorrectedText = &correctedText
 	page.UpdatedAt = time.Now()
 	if err := s.pageRepo.Update(ctx, page); err != nil {
 		return nil, fmt.Errorf("failed to update page: %w", err)
 	}
 
+	// Learn from the edit so that the same OCR errors are fixed on later pages.
+	// The correction is already saved, so a learning failure is only logged.
+	if s.learner != nil {
+		if _, err := s.learner.Learn(ctx, userID, bookID, previousText, correctedText); err != nil {
+			log.Printf("failed to learn from OCR correction on page %s: %v", pageID, err)
+		}
+	}
+
 	return correction, nil
 }
 
@@ -0,0 +1,346 @@
+package ocr
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/clearclown/HaiLanGo/backend/internal/models"
+	"github.com/clearclown/HaiLanGo/backend/internal/repository"
+	"github.com/clearclown/HaiLanGo/backend/internal/websocket"
+	"github.com/clearclown/HaiLanGo/backend/pkg/ocr"
+	"github.com/clearclown/HaiLanGo/backend/pkg/pdf"
+	"github.com/clearclown/HaiLanGo/backend/pkg/storage"
+	"github.com/google/uuid"
+)
+
+// PDFIngestResult はPDF取り込みの結果
+type PDFIngestResult struct {
+	TotalPages      int                    `json:"total_pages"`
+	TextLayerPages  int                    `json:"text_layer_pages"` // 埋め込みテキストを使用したページ数（OCR不要）
+	RasterizedPages int                    `json:"rasterized_pages"` // 画像化してOCRキューに登録したページ数
+	Jobs            []*models.OCRJobRecord `json:"jobs"`
+}
+
+// PDFIngestor はアップロードされたPDFをページに分割してOCRに投入する
+// テキストレイヤーがあるページはその文字列をそのまま使い、ないページは画像化してOCRジョブキューに登録する
+type PDFIngestor struct {
+	extractor pdf.Extractor
+	storage   storage.Storage
+	service   *OCRService
+	queue     *JobQueue
+	bookRepo  repository.BookRepository
+	dpi       int
+}
+
+// NewPDFIngestor は新しいPDF取り込みサービスを作成する
+func NewPDFIngestor(extractor pdf.Extractor, store storage.Storage, service *OCRService, queue *JobQueue) *PDFIngestor {
+	return &PDFIngestor{
+		extractor: extractor,
+		storage:   store,
+		service:   service,
+		queue:     queue,
+		dpi:       pdf.DefaultDPI,
+	}
+}
+
+// SetBookRepository は書籍リポジトリを設定する（総ページ数・ステータスの更新に使用）
+func (p *PDFIngestor) SetBookRepository(repo repository.BookRepository) {
+	p.bookRepo = repo
+}
+
+// EnqueuePDF はストレージ上のPDFのページ分割をOCRジョブキューに登録する
+// 分割はキューのワーカーが IngestPDF で行うため、サーバーが再起動しても失われず、失敗した場合はリトライされる
+func (p *PDFIngestor) EnqueuePDF(ctx context.Context, userID, bookID uuid.UUID, pdfPath string, languages []string) (*models.OCRJobRecord, error) {
+	if p.queue == nil {
+		return nil, fmt.Errorf("OCR job queue is not configured")
+	}
+	return p.queue.EnqueuePDF(ctx, userID, bookID, pdfPath, languages)
+}
+
+// IngestPDF はストレージ上のPDFをページに分割し、Pageを作成してOCRに投入する
+// ページ番号は既存ページの続きから、同時に取り込まれる他のPDFと重ならないよう先にまとめて確保する
+// ページのIDは ingestID（PDFのジョブのID）とPDFのページ番号から決めるため、途中で失敗（クラッシュを含む）した取り込みを
+// 同じ ingestID でやり直すと、確保済みのページを再利用し、画像化済みのページは画像化し直さずに続きから取り込む
+// languagesが空の場合は書籍の学習言語・母国語を使用する
+func (p *PDFIngestor) IngestPDF(ctx context.Context, ingestID, userID, bookID uuid.UUID, pdfPath string, languages []string) (*PDFIngestResult, error) {
+	if len(languages) == 0 {
+		languages = p.bookLanguages(ctx, bookID)
+	}
+
+	localPath, cleanup, err := p.copyToTempFile(ctx, pdfPath)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	pageCount, err := p.extractor.PageCount(ctx, localPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PDF: %w", err)
+	}
+
+	pages, err := p.allocatePages(ctx, ingestID, bookID, pageCount)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := p.extractPages(ctx, userID, bookID, localPath, pdfPath, pages, languages)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, page := range pages {
+		if page.OCRStatus == models.OCRStatusCompleted {
+			p.service.segmentPageLogged(ctx, page)
+		}
+	}
+
+	lastPage := 0
+	if pageCount > 0 {
+		lastPage = pages[pageCount-1].PageNumber
+	}
+	p.updateBook(ctx, bookID, lastPage, result.RasterizedPages == 0)
+
+	// 全ページがテキストレイヤーから取得できた場合はOCRを待たずに完了
+	if result.RasterizedPages == 0 {
+		p.service.sendBookReady(userID.String(), bookID.String(), pageCount, pageCount)
+	}
+
+	return result, nil
+}
+
+// allocatePages はPDFのページに対応するページを確保する（前の試行で確保済みの場合はそのページを返す）
+func (p *PDFIngestor) allocatePages(ctx context.Context, ingestID, bookID uuid.UUID, pageCount int) ([]*models.Page, error) {
+	if pageCount == 0 {
+		return nil, nil
+	}
+
+	ids := make([]uuid.UUID, pageCount)
+	for i := range ids {
+		ids[i] = uuid.NewSHA1(ingestID, []byte(strconv.Itoa(i+1)))
+	}
+
+	pages, err := p.findPages(ctx, ids)
+	if err != nil || pages != nil {
+		return pages, err
+	}
+
+	now := time.Now()
+	pages = make([]*models.Page, pageCount)
+	for i := range pages {
+		pages[i] = &models.Page{
+			ID:        ids[i],
+			BookID:    bookID,
+			OCRStatus: models.OCRStatusPending,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+	}
+	if err := p.service.pageRepo.AppendPages(ctx, bookID, pages); err != nil {
+		// 同じ取り込みを他のワーカーが先に確保した場合はそのページを使う
+		if existing, findErr := p.findPages(ctx, ids); findErr == nil && existing != nil {
+			return existing, nil
+		}
+		return nil, fmt.Errorf("failed to allocate pages: %w", err)
+	}
+
+	return pages, nil
+}
+
+// findPages は確保済みのページを返す（まだ確保していない場合は nil）
+// ページはまとめて確保するため、一部だけが確保されていることはない
+func (p *PDFIngestor) findPages(ctx context.Context, ids []uuid.UUID) ([]*models.Page, error) {
+	pages := make([]*models.Page, 0, len(ids))
+	for i, id := range ids {
+		page, err := p.service.pageRepo.FindByID(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load page: %w", err)
+		}
+		if page == nil {
+			if i == 0 {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("page %d of the PDF was not allocated", i+1)
+		}
+		pages = append(pages, page)
+	}
+	return pages, nil
+}
+
+// extractPages は確保したページにテキストレイヤーの文字列か画像化したページを保存し、画像のページをOCRキューに登録する
+// 前の試行で保存したページはそのまま使う（画像のページはOCRキューへの登録だけをやり直す）
+func (p *PDFIngestor) extractPages(ctx context.Context, userID, bookID uuid.UUID, localPath, pdfPath string, pages []*models.Page, languages []string) (*PDFIngestResult, error) {
+	detectedLang := ""
+	if len(languages) > 0 {
+		detectedLang = languages[0]
+	}
+
+	result := &PDFIngestResult{TotalPages: len(pages)}
+	queued := make([]QueuedPage, 0, len(pages))
+
+	for i, page := range pages {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		pdfPage := i + 1
+
+		if page.ImageURL != "" {
+			queued = append(queued, QueuedPage{PageID: page.ID, PageNumber: page.PageNumber, ImagePath: page.ImageURL})
+			result.RasterizedPages++
+			p.sendProgress(userID, bookID, len(pages), pdfPage)
+			continue
+		}
+		if page.OCRProvider == string(ocr.ProviderPDFTextLayer) {
+			result.TextLayerPages++
+			p.sendProgress(userID, bookID, len(pages), pdfPage)
+			continue
+		}
+
+		text, err := p.extractor.ExtractText(ctx, localPath, pdfPage)
+		if err != nil {
+			log.Printf("PDF %s page %d: failed to extract text layer: %v", pdfPath, pdfPage, err)
+		}
+
+		if err == nil && pdf.HasTextLayer(text) {
+			page.OCRText = text
+			page.OCRConfidence = 1.0
+			page.DetectedLang = detectedLang
+			page.OCRStatus = models.OCRStatusCompleted
+			page.OCRProvider = string(ocr.ProviderPDFTextLayer)
+			result.TextLayerPages++
+		} else {
+			imagePath, err := p.renderPage(ctx, userID, bookID, localPath, pdfPage, page.PageNumber)
+			if err != nil {
+				return nil, err
+			}
+			page.ImageURL = imagePath
+			queued = append(queued, QueuedPage{PageID: page.ID, PageNumber: page.PageNumber, ImagePath: imagePath})
+			result.RasterizedPages++
+		}
+
+		page.UpdatedAt = time.Now()
+		if err := p.service.pageRepo.Update(ctx, page); err != nil {
+			return nil, fmt.Errorf("failed to save page %d: %w", page.PageNumber, err)
+		}
+
+		p.sendProgress(userID, bookID, len(pages), pdfPage)
+	}
+
+	if len(queued) > 0 && p.queue != nil {
+		jobs, err := p.queue.EnqueueBook(ctx, userID, bookID, queued, models.OCROptions{Languages: languages})
+		if err != nil {
+			return nil, err
+		}
+		result.Jobs = jobs
+	}
+
+	return result, nil
+}
+
+// copyToTempFile はストレージ上のPDFを外部コマンドで読めるよう一時ファイルにコピーする
+func (p *PDFIngestor) copyToTempFile(ctx context.Context, pdfPath string) (string, func(), error) {
+	reader, err := p.storage.GetFile(ctx, pdfPath)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to read PDF: %w", err)
+	}
+	defer reader.Close()
+
+	tmp, err := os.CreateTemp("", "book-*.pdf")
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	cleanup := func() { os.Remove(tmp.Name()) }
+
+	if _, err := io.Copy(tmp, reader); err != nil {
+		tmp.Close()
+		cleanup()
+		return "", nil, fmt.Errorf("failed to copy PDF: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("failed to copy PDF: %w", err)
+	}
+
+	return tmp.Name(), cleanup, nil
+}
+
+// renderPage はPDFのページを画像化してストレージに保存し、そのパスを返す
+func (p *PDFIngestor) renderPage(ctx context.Context, userID, bookID uuid.UUID, localPath string, pdfPage, pageNumber int) (string, error) {
+	image, err := p.extractor.RenderPage(ctx, localPath, pdfPage, p.dpi)
+	if err != nil {
+		return "", fmt.Errorf("failed to rasterize page %d: %w", pdfPage, err)
+	}
+
+	imagePath, err := p.storage.SaveFile(ctx, userID, bookID, fmt.Sprintf("page_%04d.png", pageNumber), bytes.NewReader(image))
+	if err != nil {
+		return "", fmt.Errorf("failed to save page image %d: %w", pdfPage, err)
+	}
+
+	return imagePath, nil
+}
+
+// bookLanguages は書籍の学習言語・母国語をOCR対象言語として返す
+func (p *PDFIngestor) bookLanguages(ctx context.Context, bookID uuid.UUID) []string {
+	if p.bookRepo == nil {
+		return nil
+	}
+
+	book, err := p.bookRepo.GetByID(ctx, bookID)
+	if err != nil || book == nil {
+		return nil
+	}
+
+	languages := []string{book.TargetLanguage}
+	if book.NativeLanguage != "" && book.NativeLanguage != book.TargetLanguage {
+		languages = append(languages, book.NativeLanguage)
+	}
+	return languages
+}
+
+// updateBook は書籍の総ページ数とステータスを更新する
+func (p *PDFIngestor) updateBook(ctx context.Context, bookID uuid.UUID, totalPages int, ready bool) {
+	if p.bookRepo == nil {
+		return
+	}
+
+	book, err := p.bookRepo.GetByID(ctx, bookID)
+	if err != nil || book == nil {
+		return
+	}
+
+	// 同時に取り込まれた他のPDFが後ろのページ番号を確保している場合は総ページ数を減らさない
+	book.TotalPages = max(book.TotalPages, totalPages)
+	book.Status = models.BookStatusProcessing
+	if ready {
+		book.Status = models.BookStatusReady
+	}
+	book.UpdatedAt = time.Now()
+	if err := p.bookRepo.Update(ctx, book); err != nil {
+		log.Printf("failed to update book %s after PDF ingestion: %v", bookID, err)
+	}
+}
+
+// sendProgress はPDFのページ分割の進捗をWebSocket経由で送信する
+func (p *PDFIngestor) sendProgress(userID, bookID uuid.UUID, totalPages, extractedPages int) {
+	if p.service.wsHub == nil {
+		return
+	}
+
+	message, err := websocket.NewOCRProgressMessage(
+		bookID,
+		totalPages,
+		extractedPages,
+		"extracting",
+		fmt.Sprintf("Extracting PDF page %d of %d", extractedPages, totalPages),
+	)
+	if err != nil {
+		return
+	}
+
+	p.service.wsHub.SendToUser(userID, message)
+}
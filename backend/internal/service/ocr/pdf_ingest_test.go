@@ -0,0 +1,264 @@
+package ocr
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/clearclown/HaiLanGo/backend/internal/models"
+	"github.com/clearclown/HaiLanGo/backend/internal/repository"
+	"github.com/clearclown/HaiLanGo/backend/pkg/cache"
+	"github.com/clearclown/HaiLanGo/backend/pkg/ocr"
+	"github.com/clearclown/HaiLanGo/backend/pkg/pdf"
+	"github.com/clearclown/HaiLanGo/backend/pkg/storage"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakePDFExtractor はページごとのテキストレイヤーを返すExtractor
+type fakePDFExtractor struct {
+	texts    []string
+	rendered []int
+}
+
+func (f *fakePDFExtractor) PageCount(ctx context.Context, path string) (int, error) {
+	return len(f.texts), nil
+}
+
+func (f *fakePDFExtractor) ExtractText(ctx context.Context, path string, page int) (string, error) {
+	if page < 1 || page > len(f.texts) {
+		return "", pdf.ErrPageOutOfRange
+	}
+	return f.texts[page-1], nil
+}
+
+func (f *fakePDFExtractor) RenderPage(ctx context.Context, path string, page int, dpi int) ([]byte, error) {
+	f.rendered = append(f.rendered, page)
+	return []byte{byte(page), 'p', 'n', 'g'}, nil
+}
+
+func TestPDFIngestor_IngestPDF(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewLocalStorage(t.TempDir())
+	pageRepo := repository.NewMockPageRepository()
+
+	service := NewOCRService(ocr.NewMockOCRClient(), cache.NewMockCache())
+	service.SetPageRepository(pageRepo)
+
+	queueRepo := repository.NewInMemoryOCRJobQueueRepository()
+	queue := NewJobQueue(queueRepo, service, store, QueueConfig{WorkerID: "test", Concurrency: 2, LeaseTimeout: time.Minute, MaxAttempts: 1})
+
+	userID := uuid.New()
+	bookID := uuid.New()
+	pdfPath, err := store.SaveFile(ctx, userID, bookID, "book.pdf", bytes.NewReader([]byte("%PDF-1.7")))
+	require.NoError(t, err)
+
+	// 既存の1ページに続けて採番される
+	require.NoError(t, pageRepo.Create(ctx, &models.Page{ID: uuid.New(), BookID: bookID, PageNumber: 1}))
+
+	extractor := &fakePDFExtractor{texts: []string{
+		"Урок 1. Здравствуйте! Как у вас дела?",
+		"",
+		"  7 ",
+	}}
+	ingestor := NewPDFIngestor(extractor, store, service, queue)
+
+	result, err := ingestor.IngestPDF(ctx, uuid.New(), userID, bookID, pdfPath, []string{"ru"})
+	require.NoError(t, err)
+
+	assert.Equal(t, 3, result.TotalPages)
+	assert.Equal(t, 1, result.TextLayerPages)
+	assert.Equal(t, 2, result.RasterizedPages)
+	assert.Len(t, result.Jobs, 2)
+	assert.Equal(t, []int{2, 3}, extractor.rendered)
+
+	pages, err := pageRepo.FindByBookID(ctx, bookID)
+	require.NoError(t, err)
+	require.Len(t, pages, 4)
+
+	byNumber := make(map[int]*models.Page)
+	for _, page := range pages {
+		byNumber[page.PageNumber] = page
+	}
+
+	textPage := byNumber[2]
+	require.NotNil(t, textPage)
+	assert.Equal(t, models.OCRStatusCompleted, textPage.OCRStatus)
+	assert.Equal(t, "pdf_text_layer", textPage.OCRProvider)
+	assert.Contains(t, textPage.OCRText, "Здравствуйте")
+	assert.Empty(t, textPage.ImageURL)
+
+	imagePage := byNumber[3]
+	require.NotNil(t, imagePage)
+	assert.Equal(t, models.OCRStatusPending, imagePage.OCRStatus)
+	exists, err := store.FileExists(ctx, imagePage.ImageURL)
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	// 画像化したページはOCRキューで処理される
+	n, err := queue.RunOnce(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	imagePage, err = pageRepo.FindByID(ctx, imagePage.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.OCRStatusCompleted, imagePage.OCRStatus)
+	assert.NotEmpty(t, imagePage.OCRText)
+}
+
+func TestPDFIngestor_InvalidPDF(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewLocalStorage(t.TempDir())
+	service := NewOCRService(ocr.NewMockOCRClient(), cache.NewMockCache())
+	ingestor := NewPDFIngestor(&fakePDFExtractor{}, store, service, nil)
+
+	_, err := ingestor.IngestPDF(ctx, uuid.New(), uuid.New(), uuid.New(), "missing.pdf", []string{"ru"})
+	assert.Error(t, err)
+
+	ingestor = NewPDFIngestor(brokenPDFExtractor{}, store, service, nil)
+	path, err := store.SaveFile(ctx, uuid.New(), uuid.New(), "broken.pdf", bytes.NewReader([]byte("not a pdf")))
+	require.NoError(t, err)
+	_, err = ingestor.IngestPDF(ctx, uuid.New(), uuid.New(), uuid.New(), path, []string{"ru"})
+	assert.ErrorIs(t, err, pdf.ErrInvalidPDF)
+}
+
+// brokenPDFExtractor は常にPDFの解析に失敗するExtractor
+type brokenPDFExtractor struct{}
+
+func (brokenPDFExtractor) PageCount(ctx context.Context, path string) (int, error) {
+	return 0, pdf.ErrInvalidPDF
+}
+
+func (brokenPDFExtractor) ExtractText(ctx context.Context, path string, page int) (string, error) {
+	return "", errors.New("unreachable")
+}
+
+func (brokenPDFExtractor) RenderPage(ctx context.Context, path string, page int, dpi int) ([]byte, error) {
+	return nil, errors.New("unreachable")
+}
+
+// failingRenderExtractor は指定したページ以降の画像化に失敗するExtractor
+type failingRenderExtractor struct {
+	fakePDFExtractor
+	failFrom int
+}
+
+func (f *failingRenderExtractor) RenderPage(ctx context.Context, path string, page int, dpi int) ([]byte, error) {
+	if page >= f.failFrom {
+		return nil, errors.New("pdftoppm failed")
+	}
+	return f.fakePDFExtractor.RenderPage(ctx, path, page, dpi)
+}
+
+func TestPDFIngestor_EnqueuePDF(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewLocalStorage(t.TempDir())
+	pageRepo := repository.NewMockPageRepository()
+
+	service := NewOCRService(ocr.NewMockOCRClient(), cache.NewMockCache())
+	service.SetPageRepository(pageRepo)
+
+	queue := NewJobQueue(repository.NewInMemoryOCRJobQueueRepository(), service, store, QueueConfig{WorkerID: "test", Concurrency: 5, LeaseTimeout: time.Minute, MaxAttempts: 1})
+	ingestor := NewPDFIngestor(&fakePDFExtractor{texts: []string{"", ""}}, store, service, queue)
+	queue.SetPDFIngestor(ingestor)
+
+	userID := uuid.New()
+	bookID := uuid.New()
+	pdfPath, err := store.SaveFile(ctx, userID, bookID, "book.pdf", bytes.NewReader([]byte("%PDF-1.7")))
+	require.NoError(t, err)
+
+	// 2つのPDFを登録すると、それぞれのページ番号は重ならない
+	first, err := ingestor.EnqueuePDF(ctx, userID, bookID, pdfPath, []string{"ru"})
+	require.NoError(t, err)
+	assert.Equal(t, models.OCRJobKindPDF, first.Kind)
+	_, err = ingestor.EnqueuePDF(ctx, userID, bookID, pdfPath, []string{"ru"})
+	require.NoError(t, err)
+
+	// PDFのページ分割のジョブはページのOCRの進捗に含めない
+	status, err := queue.GetBookStatus(ctx, bookID)
+	require.NoError(t, err)
+	assert.Equal(t, 0, status.TotalPages)
+
+	n, err := queue.RunOnce(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	job, err := queue.GetJob(ctx, first.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.OCRStatusCompleted, job.Status)
+
+	pages, err := pageRepo.FindByBookID(ctx, bookID)
+	require.NoError(t, err)
+	numbers := make(map[int]bool)
+	for _, page := range pages {
+		numbers[page.PageNumber] = true
+	}
+	assert.Equal(t, map[int]bool{1: true, 2: true, 3: true, 4: true}, numbers)
+
+	status, err = queue.GetBookStatus(ctx, bookID)
+	require.NoError(t, err)
+	assert.Equal(t, 4, status.TotalPages)
+	assert.Equal(t, 4, status.Pending)
+}
+
+func TestPDFIngestor_ResumesAfterFailure(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewLocalStorage(t.TempDir())
+	pageRepo := repository.NewMockPageRepository()
+
+	service := NewOCRService(ocr.NewMockOCRClient(), cache.NewMockCache())
+	service.SetPageRepository(pageRepo)
+
+	queue := NewJobQueue(repository.NewInMemoryOCRJobQueueRepository(), service, store, QueueConfig{WorkerID: "test", Concurrency: 1, LeaseTimeout: time.Minute, MaxAttempts: 2})
+	extractor := &failingRenderExtractor{fakePDFExtractor: fakePDFExtractor{texts: []string{"", "Урок 1. Здравствуйте! Как у вас дела?", "", ""}}, failFrom: 4}
+	ingestor := NewPDFIngestor(extractor, store, service, queue)
+	queue.SetPDFIngestor(ingestor)
+
+	userID := uuid.New()
+	bookID := uuid.New()
+	pdfPath, err := store.SaveFile(ctx, userID, bookID, "book.pdf", bytes.NewReader([]byte("%PDF-1.7")))
+	require.NoError(t, err)
+
+	job, err := ingestor.EnqueuePDF(ctx, userID, bookID, pdfPath, []string{"ru"})
+	require.NoError(t, err)
+
+	n, err := queue.RunOnce(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	// ジョブはリトライを待ち、確保したページは残る
+	job, err = queue.GetJob(ctx, job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.OCRStatusPending, job.Status)
+	assert.Contains(t, job.Error, "pdftoppm failed")
+	pages, err := pageRepo.FindByBookID(ctx, bookID)
+	require.NoError(t, err)
+	assert.Len(t, pages, 4)
+
+	// リトライは同じページを使い、画像化済みのページは画像化し直さない
+	extractor.failFrom = 99
+	n, err = queue.RunOnce(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	job, err = queue.GetJob(ctx, job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.OCRStatusCompleted, job.Status)
+	assert.Equal(t, []int{1, 3, 4}, extractor.rendered)
+
+	pages, err = pageRepo.FindByBookID(ctx, bookID)
+	require.NoError(t, err)
+	numbers := make(map[int]bool)
+	for _, page := range pages {
+		numbers[page.PageNumber] = true
+	}
+	assert.Equal(t, map[int]bool{1: true, 2: true, 3: true, 4: true}, numbers)
+
+	status, err := queue.GetBookStatus(ctx, bookID)
+	require.NoError(t, err)
+	assert.Equal(t, 3, status.TotalPages)
+	assert.Equal(t, 3, status.Pending)
+}
@@ -0,0 +1,543 @@
+package ocr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/clearclown/HaiLanGo/backend/internal/models"
+	"github.com/clearclown/HaiLanGo/backend/internal/repository"
+	"github.com/clearclown/HaiLanGo/backend/pkg/ocr"
+	"github.com/clearclown/HaiLanGo/backend/pkg/retry"
+	"github.com/clearclown/HaiLanGo/backend/pkg/storage"
+	"github.com/google/uuid"
+)
+
+// QueueConfig はOCRジョブキューの設定
+type QueueConfig struct {
+	WorkerID     string        // ワーカー識別子（ロックの所有者）
+	Concurrency  int           // 同時に処理するジョブ数
+	PollInterval time.Duration // キューのポーリング間隔
+	LeaseTimeout time.Duration // この時間を超えて処理中のジョブはクラッシュとみなして再取得する（処理中は1/3ごとに延長する）
+	MaxAttempts  int           // ジョブの最大試行回数（超えると dead_letter）
+	RetryBackoff time.Duration // 失敗したジョブを再実行するまでの基本待機時間（試行回数に応じて倍増）
+	RetryConfig  retry.Config  // 1回の試行内での一時的なエラーに対するリトライ設定
+}
+
+// DefaultQueueConfig はデフォルトのキュー設定を返す
+func DefaultQueueConfig() QueueConfig {
+	hostname, _ := os.Hostname()
+
+	return QueueConfig{
+		WorkerID:     fmt.Sprintf("%s-%s", hostname, uuid.New().String()[:8]),
+		Concurrency:  5,
+		PollInterval: 2 * time.Second,
+		LeaseTimeout: 5 * time.Minute,
+		MaxAttempts:  3,
+		RetryBackoff: 30 * time.Second,
+		RetryConfig: retry.Config{
+			MaxRetries:     2,
+			InitialBackoff: 1 * time.Second,
+			MaxBackoff:     10 * time.Second,
+			Multiplier:     2.0,
+		},
+	}
+}
+
+// QueuedPage はキューに登録するページ
+type QueuedPage struct {
+	PageID     uuid.UUID
+	PageNumber int
+	ImagePath  string // ストレージ上の画像パス
+}
+
+// inflightJob は実行中ジョブのキャンセル用情報
+type inflightJob struct {
+	bookID uuid.UUID
+	cancel context.CancelFunc
+}
+
+// JobQueue はPostgreSQLに永続化されたOCRジョブキュー
+// サーバーが再起動しても処理待ち・処理中のジョブはリース期限切れ後に再開される
+type JobQueue struct {
+	repo        repository.OCRJobQueueRepository
+	service     *OCRService
+	storage     storage.Storage
+	config      QueueConfig
+	pdfIngestor *PDFIngestor
+
+	mu       sync.Mutex
+	inflight map[uuid.UUID]inflightJob
+}
+
+// NewJobQueue は新しいOCRジョブキューを作成する
+func NewJobQueue(repo repository.OCRJobQueueRepository, service *OCRService, store storage.Storage, config QueueConfig) *JobQueue {
+	if config.Concurrency <= 0 {
+		config.Concurrency = 1
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = 1
+	}
+
+	return &JobQueue{
+		repo:     repo,
+		service:  service,
+		storage:  store,
+		config:   config,
+		inflight: make(map[uuid.UUID]inflightJob),
+	}
+}
+
+// SetPDFIngestor はPDFのページ分割のジョブを処理する取り込みサービスを設定する
+func (q *JobQueue) SetPDFIngestor(ingestor *PDFIngestor) {
+	q.pdfIngestor = ingestor
+}
+
+// EnqueuePDF はストレージ上のPDFのページ分割をジョブとしてキューに登録する
+// ワーカーがページに分割し、画像化したページを改めてOCRジョブとして登録する
+func (q *JobQueue) EnqueuePDF(ctx context.Context, userID, bookID uuid.UUID, pdfPath string, languages []string) (*models.OCRJobRecord, error) {
+	if languages == nil {
+		languages = []string{}
+	}
+
+	now := time.Now()
+	job := &models.OCRJobRecord{
+		ID:            uuid.New(),
+		Kind:          models.OCRJobKindPDF,
+		UserID:        userID,
+		BookID:        bookID,
+		ImageURL:      pdfPath,
+		Languages:     languages,
+		Status:        models.OCRStatusPending,
+		MaxAttempts:   q.config.MaxAttempts,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+
+	if _, err := q.repo.Enqueue(ctx, []*models.OCRJobRecord{job}); err != nil {
+		return nil, fmt.Errorf("failed to enqueue PDF ingestion: %w", err)
+	}
+
+	return job, nil
+}
+
+// EnqueueBook は書籍のページをOCRジョブとしてキューに登録する
+// 既に処理待ち・処理中・完了済みのページは登録しない
+func (q *JobQueue) EnqueueBook(ctx context.Context, userID, bookID uuid.UUID, pages []QueuedPage, options models.OCROptions) ([]*models.OCRJobRecord, error) {
+	now := time.Now()
+
+	jobs := make([]*models.OCRJobRecord, 0, len(pages))
+	for _, page := range pages {
+		jobs = append(jobs, &models.OCRJobRecord{
+			ID:                uuid.New(),
+			Kind:              models.OCRJobKindPage,
+			UserID:            userID,
+			BookID:            bookID,
+			PageID:            page.PageID,
+			PageNumber:        page.PageNumber,
+			ImageURL:          page.ImagePath,
+			Languages:         options.Languages,
+			DetectOrientation: options.DetectOrientation,
+			Status:            models.OCRStatusPending,
+			MaxAttempts:       q.config.MaxAttempts,
+			NextAttemptAt:     now,
+			CreatedAt:         now,
+			UpdatedAt:         now,
+		})
+	}
+
+	enqueued, err := q.repo.Enqueue(ctx, jobs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to enqueue OCR jobs: %w", err)
+	}
+
+	return enqueued, nil
+}
+
+// EnqueueBookPages はページリポジトリに登録済みの書籍の全ページをキューに登録する
+func (q *JobQueue) EnqueueBookPages(ctx context.Context, userID, bookID uuid.UUID, options models.OCROptions) ([]*models.OCRJobRecord, int, error) {
+	pages, err := q.service.pageRepo.FindByBookID(ctx, bookID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get pages: %w", err)
+	}
+
+	queued := make([]QueuedPage, 0, len(pages))
+	for _, page := range pages {
+		queued = append(queued, QueuedPage{
+			PageID:     page.ID,
+			PageNumber: page.PageNumber,
+			ImagePath:  page.ImageURL,
+		})
+	}
+
+	jobs, err := q.EnqueueBook(ctx, userID, bookID, queued, options)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return jobs, len(pages), nil
+}
+
+// Run はコンテキストがキャンセルされるまでキューをポーリングしてジョブを処理する
+func (q *JobQueue) Run(ctx context.Context) {
+	ticker := time.NewTicker(q.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := q.RunOnce(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("OCR job queue: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce は実行可能なジョブを最大Concurrency件取得して並列に処理し、処理した件数を返す
+func (q *JobQueue) RunOnce(ctx context.Context) (int, error) {
+	jobs, err := q.repo.Claim(ctx, q.config.WorkerID, q.config.Concurrency, q.config.LeaseTimeout)
+	if err != nil {
+		return 0, fmt.Errorf("failed to claim OCR jobs: %w", err)
+	}
+
+	var wg sync.WaitGroup
+	for _, job := range jobs {
+		wg.Add(1)
+		go func(job *models.OCRJobRecord) {
+			defer wg.Done()
+			q.processJob(ctx, job)
+		}(job)
+	}
+	wg.Wait()
+
+	return len(jobs), nil
+}
+
+// processJob は1ページ分（またはPDFのページ分割）のジョブを処理する
+func (q *JobQueue) processJob(ctx context.Context, job *models.OCRJobRecord) {
+	jobCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	q.mu.Lock()
+	q.inflight[job.ID] = inflightJob{bookID: job.BookID, cancel: cancel}
+	q.mu.Unlock()
+	defer func() {
+		q.mu.Lock()
+		delete(q.inflight, job.ID)
+		q.mu.Unlock()
+	}()
+
+	if job.Kind == models.OCRJobKindPDF {
+		q.processPDFJob(ctx, jobCtx, cancel, job)
+		return
+	}
+
+	stopLease := q.keepLease(jobCtx, job, cancel)
+	var result *ocr.OCRResult
+	err := retry.Do(jobCtx, q.config.RetryConfig, func(ctx context.Context) error {
+		var err error
+		result, err = q.runOCR(ctx, job)
+		return err
+	}, isTransientOCRError)
+	stopLease()
+
+	// 書き込みはシャットダウン中でも完了させる
+	writeCtx := context.WithoutCancel(ctx)
+
+	if err != nil {
+		q.handleJobError(ctx, jobCtx, job, err)
+		return
+	}
+
+	// 保存する直前にもリースを延長し、他のワーカーに移ったジョブの結果で上書きしない
+	if err := q.repo.ExtendLease(writeCtx, job.ID, q.config.WorkerID); err != nil {
+		if !errors.Is(err, repository.ErrOCRJobNotOwned) {
+			q.failJob(writeCtx, job, err)
+		}
+		return
+	}
+
+	if err := q.savePage(writeCtx, job, result); err != nil {
+		q.failJob(writeCtx, job, err)
+		return
+	}
+
+	if err := q.repo.Complete(writeCtx, job.ID, q.config.WorkerID, toModelOCRResult(result)); err != nil {
+		if !errors.Is(err, repository.ErrOCRJobNotOwned) {
+			log.Printf("OCR job %s: failed to complete: %v", job.ID, err)
+		}
+		return
+	}
+
+	q.notifyProgress(writeCtx, job)
+}
+
+// processPDFJob はPDFをページに分割し、画像化したページをOCRジョブとして登録する
+// 取り込みにはジョブのIDを使うため、リトライ・リース切れで再取得したジョブは前の試行の続きから取り込む
+func (q *JobQueue) processPDFJob(ctx, jobCtx context.Context, cancel context.CancelFunc, job *models.OCRJobRecord) {
+	var err error
+	if q.pdfIngestor == nil {
+		err = errors.New("PDF ingestion is not configured")
+	} else {
+		stopLease := q.keepLease(jobCtx, job, cancel)
+		_, err = q.pdfIngestor.IngestPDF(jobCtx, job.ID, job.UserID, job.BookID, job.ImageURL, job.Languages)
+		stopLease()
+	}
+	if err != nil {
+		q.handleJobError(ctx, jobCtx, job, err)
+		return
+	}
+
+	if err := q.repo.Complete(context.WithoutCancel(ctx), job.ID, q.config.WorkerID, nil); err != nil {
+		if !errors.Is(err, repository.ErrOCRJobNotOwned) {
+			log.Printf("PDF ingestion job %s: failed to complete: %v", job.ID, err)
+		}
+	}
+}
+
+// handleJobError は失敗したジョブを、サーバー停止なら処理待ちに戻し、それ以外は失敗として記録する
+func (q *JobQueue) handleJobError(ctx, jobCtx context.Context, job *models.OCRJobRecord, err error) {
+	// 書き込みはシャットダウン中でも完了させる
+	writeCtx := context.WithoutCancel(ctx)
+
+	switch {
+	case ctx.Err() != nil:
+		// サーバー停止: 試行回数を消費せずに処理待ちに戻す
+		if err := q.repo.Release(writeCtx, job.ID, q.config.WorkerID); err != nil && !errors.Is(err, repository.ErrOCRJobNotOwned) {
+			log.Printf("OCR job %s: failed to release: %v", job.ID, err)
+		}
+	case jobCtx.Err() != nil:
+		// CancelBookによりキャンセル済み、またはリースを他のワーカーに取られた
+	default:
+		q.failJob(writeCtx, job, err)
+	}
+}
+
+// keepLease は処理中のジョブのリースを LeaseTimeout の1/3ごとに延長し、返した関数で延長を止める
+// ジョブが他のワーカーに移った・キャンセルされた場合は cancel で処理を中断する
+func (q *JobQueue) keepLease(ctx context.Context, job *models.OCRJobRecord, cancel context.CancelFunc) func() {
+	interval := q.config.LeaseTimeout / 3
+	if interval <= 0 {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			err := q.repo.ExtendLease(ctx, job.ID, q.config.WorkerID)
+			if errors.Is(err, repository.ErrOCRJobNotOwned) {
+				log.Printf("OCR job %s: lease lost, stopping", job.ID)
+				cancel()
+				return
+			}
+			if err != nil && ctx.Err() == nil {
+				log.Printf("OCR job %s: failed to extend lease: %v", job.ID, err)
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+// runOCR はストレージから画像を読み込んでOCR処理を実行する
+func (q *JobQueue) runOCR(ctx context.Context, job *models.OCRJobRecord) (*ocr.OCRResult, error) {
+	reader, err := q.storage.GetFile(ctx, job.ImageURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read page image: %w", err)
+	}
+	defer reader.Close()
+
+	imageData, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read page image: %w", err)
+	}
+
+	return q.service.recognize(ctx, job.PageID, imageData, models.OCROptions{
+		Languages:         job.Languages,
+		DetectOrientation: job.DetectOrientation,
+	})
+}
+
+// savePage はOCR結果をページに保存する
+func (q *JobQueue) savePage(ctx context.Context, job *models.OCRJobRecord, result *ocr.OCRResult) error {
+	page := q.service.buildPageFromOCRResult(job.PageID, result)
+	page.BookID = job.BookID
+	page.PageNumber = job.PageNumber
+	page.ImageURL = job.ImageURL
+
+	existing, err := q.service.pageRepo.FindByID(ctx, job.PageID)
+	if err != nil {
+		return fmt.Errorf("failed to load page: %w", err)
+	}
+
+	if existing == nil {
+		corrections := q.service.autoCorrect(ctx, page, job.UserID)
+		page.CreatedAt = page.UpdatedAt
+		if err := q.service.pageRepo.Create(ctx, page); err != nil {
+			return fmt.Errorf("failed to save page: %w", err)
+		}
+		q.service.saveAutoCorrections(ctx, page, corrections)
+		q.service.segmentPageLogged(ctx, page)
+		return nil
+	}
+
+	existing.OCRText = page.OCRText
+	existing.OCRConfidence = page.OCRConfidence
+	existing.DetectedLang = page.DetectedLang
+	existing.OCRStatus = page.OCRStatus
+	existing.OCRLayout = page.OCRLayout
+	existing.OCRProvider = page.OCRProvider
+	existing.OCRError = nil
+	existing.CorrectedText = nil // 再OCRの結果には修正を改めて適用する
+	existing.UpdatedAt = page.UpdatedAt
+	corrections := q.service.autoCorrect(ctx, existing, job.UserID)
+	if err := q.service.pageRepo.Update(ctx, existing); err != nil {
+		return fmt.Errorf("failed to save page: %w", err)
+	}
+	q.service.saveAutoCorrections(ctx, existing, corrections)
+	q.service.segmentPageLogged(ctx, existing)
+
+	return nil
+}
+
+// failJob はジョブの失敗を記録し、試行回数に応じたバックオフ後に再実行されるようにする
+func (q *JobQueue) failJob(ctx context.Context, job *models.OCRJobRecord, cause error) {
+	backoff := q.config.RetryBackoff
+	for i := 1; i < job.Attempts; i++ {
+		backoff *= 2
+	}
+
+	status, err := q.repo.Fail(ctx, job.ID, q.config.WorkerID, cause.Error(), time.Now().Add(backoff))
+	if err != nil {
+		if !errors.Is(err, repository.ErrOCRJobNotOwned) {
+			log.Printf("OCR job %s: failed to record failure: %v", job.ID, err)
+		}
+		return
+	}
+
+	if status == models.OCRStatusDeadLetter {
+		if job.Kind == models.OCRJobKindPDF {
+			log.Printf("PDF ingestion job %s (book %s, %s) moved to dead letter after %d attempts: %v",
+				job.ID, job.BookID, job.ImageURL, job.Attempts, cause)
+			return
+		}
+		log.Printf("OCR job %s (book %s, page %d) moved to dead letter after %d attempts: %v",
+			job.ID, job.BookID, job.PageNumber, job.Attempts, cause)
+		q.notifyProgress(ctx, job)
+	}
+}
+
+// notifyProgress は書籍の進捗をWebSocketで通知し、全ページ処理済みなら完了を通知する
+func (q *JobQueue) notifyProgress(ctx context.Context, job *models.OCRJobRecord) {
+	status, err := q.repo.GetQueueStatus(ctx, job.BookID)
+	if err != nil {
+		return
+	}
+
+	q.service.sendOCRProgress(job.UserID.String(), job.BookID.String(), status.TotalPages, status.Completed+status.DeadLetter)
+	if status.IsFinished() {
+		q.service.sendBookReady(job.UserID.String(), job.BookID.String(), status.Completed, status.TotalPages)
+	}
+}
+
+// CancelBook は書籍の処理待ち・処理中ジョブをキャンセルする
+// このワーカーで実行中のジョブはOCR処理も中断する
+func (q *JobQueue) CancelBook(ctx context.Context, bookID uuid.UUID) (int, error) {
+	cancelled, err := q.repo.CancelBook(ctx, bookID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to cancel OCR jobs: %w", err)
+	}
+
+	q.mu.Lock()
+	for _, job := range q.inflight {
+		if job.bookID == bookID {
+			job.cancel()
+		}
+	}
+	q.mu.Unlock()
+
+	return cancelled, nil
+}
+
+// GetJob はジョブを取得する
+func (q *JobQueue) GetJob(ctx context.Context, jobID uuid.UUID) (*models.OCRJobRecord, error) {
+	return q.repo.GetRecord(ctx, jobID)
+}
+
+// RetryJob はdead_letter・キャンセル済みのジョブを再実行キューに戻す
+func (q *JobQueue) RetryJob(ctx context.Context, jobID uuid.UUID) error {
+	return q.repo.Requeue(ctx, jobID)
+}
+
+// GetBookStatus は書籍のジョブキューの状態を取得する
+func (q *JobQueue) GetBookStatus(ctx context.Context, bookID uuid.UUID) (*models.OCRQueueStatus, error) {
+	return q.repo.GetQueueStatus(ctx, bookID)
+}
+
+// ListDeadLetters は書籍のdead_letterジョブを取得する
+func (q *JobQueue) ListDeadLetters(ctx context.Context, bookID uuid.UUID) ([]*models.OCRJobRecord, error) {
+	return q.repo.ListByStatus(ctx, bookID, models.OCRStatusDeadLetter)
+}
+
+// isTransientOCRError は一時的なエラー（タイムアウト・レート制限・ネットワーク障害）かを判定する
+func isTransientOCRError(err error) bool {
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	msg := strings.ToLower(err.Error())
+	for _, keyword := range []string{"timeout", "temporar", "rate limit", "429", "503", "unavailable", "connection reset"} {
+		if strings.Contains(msg, keyword) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// toModelOCRResult はジョブ結果として保存するOCR結果を構築する
+func toModelOCRResult(result *ocr.OCRResult) *models.OCRResult {
+	return &models.OCRResult{
+		Text:             result.Text,
+		Confidence:       result.Confidence,
+		DetectedLanguage: result.DetectedLanguage,
+		Blocks:           convertOCRLayout(result),
+		HasRuby:          result.HasRuby,
+		Provider:         string(result.Provider),
+	}
+}
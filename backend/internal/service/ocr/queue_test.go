@@ -0,0 +1,292 @@
+package ocr
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/clearclown/HaiLanGo/backend/internal/models"
+	"github.com/clearclown/HaiLanGo/backend/internal/repository"
+	"github.com/clearclown/HaiLanGo/backend/pkg/cache"
+	"github.com/clearclown/HaiLanGo/backend/pkg/ocr"
+	"github.com/clearclown/HaiLanGo/backend/pkg/retry"
+	"github.com/clearclown/HaiLanGo/backend/pkg/storage"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failingOCRClient は指定回数だけエラーを返すOCRクライアント
+type failingOCRClient struct {
+	failures int32
+	err      error
+	calls    int32
+}
+
+func (c *failingOCRClient) ProcessImage(ctx context.Context, imageData []byte, languages []string) (*ocr.OCRResult, error) {
+	call := atomic.AddInt32(&c.calls, 1)
+	if c.failures < 0 || call <= c.failures {
+		return nil, c.err
+	}
+	return ocr.NewMockOCRClient().ProcessImage(ctx, imageData, languages)
+}
+
+func newTestQueue(t *testing.T, client ocr.OCRClient) (*JobQueue, *repository.InMemoryOCRJobQueueRepository, repository.PageRepository, uuid.UUID, []QueuedPage) {
+	t.Helper()
+
+	store := storage.NewLocalStorage(t.TempDir())
+	pageRepo := repository.NewMockPageRepository()
+
+	service := NewOCRService(client, cache.NewMockCache())
+	service.SetPageRepository(pageRepo)
+
+	userID := uuid.New()
+	bookID := uuid.New()
+	pages := make([]QueuedPage, 0, 3)
+	for i := 1; i <= 3; i++ {
+		path, err := store.SaveFile(context.Background(), userID, bookID, "page.jpg", bytes.NewReader([]byte{byte(i), 'i', 'm', 'g'}))
+		require.NoError(t, err)
+		pages = append(pages, QueuedPage{PageID: uuid.New(), PageNumber: i, ImagePath: path})
+	}
+
+	repo := repository.NewInMemoryOCRJobQueueRepository()
+	queue := NewJobQueue(repo, service, store, QueueConfig{
+		WorkerID:     "test-worker",
+		Concurrency:  2,
+		PollInterval: 10 * time.Millisecond,
+		LeaseTimeout: time.Minute,
+		MaxAttempts:  2,
+		RetryBackoff: 0,
+		RetryConfig:  retry.Config{MaxRetries: 0, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond, Multiplier: 1},
+	})
+
+	_, err := queue.EnqueueBook(context.Background(), userID, bookID, pages, models.OCROptions{Languages: []string{"ru"}})
+	require.NoError(t, err)
+
+	return queue, repo, pageRepo, bookID, pages
+}
+
+// drain はキューが空になるまでジョブを処理する
+func drain(t *testing.T, queue *JobQueue) {
+	t.Helper()
+	for i := 0; i < 10; i++ {
+		n, err := queue.RunOnce(context.Background())
+		require.NoError(t, err)
+		if n == 0 {
+			return
+		}
+	}
+	t.Fatal("queue did not drain")
+}
+
+func TestJobQueue_ProcessesAllPages(t *testing.T) {
+	ctx := context.Background()
+	queue, repo, pageRepo, bookID, pages := newTestQueue(t, ocr.NewMockOCRClient())
+
+	drain(t, queue)
+
+	jobs, err := repo.ListByStatus(ctx, bookID, models.OCRStatusCompleted)
+	require.NoError(t, err)
+	assert.Len(t, jobs, 3)
+
+	for _, p := range pages {
+		page, err := pageRepo.FindByID(ctx, p.PageID)
+		require.NoError(t, err)
+		require.NotNil(t, page)
+		assert.Equal(t, p.PageNumber, page.PageNumber)
+		assert.Equal(t, models.OCRStatusCompleted, page.OCRStatus)
+		assert.NotEmpty(t, page.OCRText)
+	}
+
+	status, err := queue.GetBookStatus(ctx, jobs[0].BookID)
+	require.NoError(t, err)
+	assert.Equal(t, 3, status.TotalPages)
+	assert.Equal(t, 3, status.Completed)
+	assert.True(t, status.IsFinished())
+
+	// 完了済みのページは再登録されない
+	enqueued, err := queue.EnqueueBook(ctx, jobs[0].UserID, jobs[0].BookID, pages, models.OCROptions{Languages: []string{"ru"}})
+	require.NoError(t, err)
+	assert.Empty(t, enqueued)
+}
+
+func TestJobQueue_RetryThenDeadLetter(t *testing.T) {
+	ctx := context.Background()
+	client := &failingOCRClient{failures: -1, err: errors.New("invalid image")}
+	queue, repo, _, bookID, _ := newTestQueue(t, client)
+
+	drain(t, queue)
+
+	deadLetters, err := queue.ListDeadLetters(ctx, bookID)
+	require.NoError(t, err)
+	require.Len(t, deadLetters, 3)
+	assert.Equal(t, 2, deadLetters[0].Attempts)
+	assert.Contains(t, deadLetters[0].Error, "invalid image")
+	assert.Equal(t, int32(6), atomic.LoadInt32(&client.calls))
+
+	// 手動で再実行
+	require.NoError(t, queue.RetryJob(ctx, deadLetters[0].ID))
+	record, err := repo.GetRecord(ctx, deadLetters[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.OCRStatusPending, record.Status)
+	assert.Equal(t, 0, record.Attempts)
+
+	// 処理待ちのジョブは再実行できない
+	assert.ErrorIs(t, queue.RetryJob(ctx, deadLetters[0].ID), repository.ErrOCRJobNotOwned)
+	assert.ErrorIs(t, queue.RetryJob(ctx, uuid.New()), repository.ErrOCRJobNotFound)
+}
+
+func TestJobQueue_TransientErrorRetriedWithinAttempt(t *testing.T) {
+	ctx := context.Background()
+	client := &failingOCRClient{failures: 1, err: errors.New("rate limit exceeded")}
+	queue, _, _, bookID, _ := newTestQueue(t, client)
+	queue.config.RetryConfig.MaxRetries = 1
+
+	drain(t, queue)
+
+	status, err := queue.GetBookStatus(ctx, bookID)
+	require.NoError(t, err)
+	assert.Equal(t, 3, status.Completed)
+	assert.Equal(t, 0, status.DeadLetter)
+}
+
+func TestJobQueue_CancelBook(t *testing.T) {
+	ctx := context.Background()
+	queue, _, pageRepo, bookID, pages := newTestQueue(t, ocr.NewMockOCRClient())
+
+	cancelled, err := queue.CancelBook(ctx, bookID)
+	require.NoError(t, err)
+	assert.Equal(t, 3, cancelled)
+
+	n, err := queue.RunOnce(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, n)
+
+	page, err := pageRepo.FindByID(ctx, pages[0].PageID)
+	require.NoError(t, err)
+	assert.Nil(t, page)
+
+	status, err := queue.GetBookStatus(ctx, bookID)
+	require.NoError(t, err)
+	assert.Equal(t, 3, status.Cancelled)
+}
+
+func TestJobQueue_ResumesAfterCrash(t *testing.T) {
+	ctx := context.Background()
+	queue, repo, _, bookID, _ := newTestQueue(t, ocr.NewMockOCRClient())
+
+	// 別のワーカーがジョブを取得したままクラッシュした状態を再現
+	claimed, err := repo.Claim(ctx, "crashed-worker", 3, time.Minute)
+	require.NoError(t, err)
+	require.Len(t, claimed, 3)
+
+	// リース期限内は再取得されない
+	n, err := queue.RunOnce(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, n)
+
+	// リース期限切れ後は再取得されて完了する
+	queue.config.LeaseTimeout = 0
+	drain(t, queue)
+
+	status, err := queue.GetBookStatus(ctx, bookID)
+	require.NoError(t, err)
+	assert.Equal(t, 3, status.Completed)
+
+	// クラッシュしたワーカーの書き込みは拒否される
+	err = repo.Complete(ctx, claimed[0].ID, "crashed-worker", nil)
+	assert.ErrorIs(t, err, repository.ErrOCRJobNotOwned)
+}
+
+// slowOCRClient はリースの期限より長くかかるOCRクライアント
+type slowOCRClient struct {
+	delay time.Duration
+	calls int32
+}
+
+func (c *slowOCRClient) ProcessImage(ctx context.Context, imageData []byte, languages []string) (*ocr.OCRResult, error) {
+	atomic.AddInt32(&c.calls, 1)
+	select {
+	case <-time.After(c.delay):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return ocr.NewMockOCRClient().ProcessImage(ctx, imageData, languages)
+}
+
+func TestJobQueue_ExtendsLease(t *testing.T) {
+	ctx := context.Background()
+	client := &slowOCRClient{delay: 200 * time.Millisecond}
+	queue, repo, _, bookID, _ := newTestQueue(t, client)
+	queue.config.Concurrency = 3
+	queue.config.LeaseTimeout = 60 * time.Millisecond
+
+	otherConfig := queue.config
+	otherConfig.WorkerID = "other-worker"
+	other := NewJobQueue(repo, queue.service, queue.storage, otherConfig)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		n, err := queue.RunOnce(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, 3, n)
+	}()
+
+	// 処理中はリースが延長され、他のワーカーは期限切れとして再取得しない
+	for running := true; running; {
+		select {
+		case <-done:
+			running = false
+		case <-time.After(20 * time.Millisecond):
+			n, err := other.RunOnce(ctx)
+			require.NoError(t, err)
+			assert.Zero(t, n)
+		}
+	}
+
+	jobs, err := repo.ListByStatus(ctx, bookID, models.OCRStatusCompleted)
+	require.NoError(t, err)
+	assert.Len(t, jobs, 3)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&client.calls))
+}
+
+func TestJobQueue_AppliesLearnedCorrections(t *testing.T) {
+	ctx := context.Background()
+	queue, repo, pageRepo, bookID, pages := newTestQueue(t, ocr.NewMockOCRClient())
+
+	jobs, err := repo.ListByStatus(ctx, bookID, models.OCRStatusPending)
+	require.NoError(t, err)
+	require.NotEmpty(t, jobs)
+	userID := jobs[0].UserID
+
+	correctionRepo := repository.NewInMemoryOCRCorrectionRepository()
+	learner := NewCorrectionLearner(NewPageStore(pageRepo), correctionRepo)
+	queue.service.SetCorrectionLearner(learner)
+
+	// 以前のページで「пример」を「образец」に手動修正した
+	_, err = learner.Learn(ctx, userID, bookID, "Это пример текста.", "Это образец текста.")
+	require.NoError(t, err)
+
+	drain(t, queue)
+
+	page, err := pageRepo.FindByID(ctx, pages[0].PageID)
+	require.NoError(t, err)
+	require.NotNil(t, page.CorrectedText)
+	assert.Equal(t, "Здравствуйте! Это образец текста из OCR.", page.Text())
+	assert.Equal(t, "Здравствуйте! Это пример текста из OCR.", page.OCRText)
+
+	corrections, err := learner.ListAutoCorrections(ctx, bookID, models.OCRAutoCorrectionApplied)
+	require.NoError(t, err)
+	assert.Len(t, corrections, len(pages))
+}
+
+func TestIsTransientOCRError(t *testing.T) {
+	assert.True(t, isTransientOCRError(errors.New("Google Vision API error (status 503): unavailable")))
+	assert.True(t, isTransientOCRError(context.DeadlineExceeded))
+	assert.False(t, isTransientOCRError(context.Canceled))
+	assert.False(t, isTransientOCRError(errors.New("image data is empty")))
+}
@@ -0,0 +1,616 @@
+package ocr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/clearclown/HaiLanGo/backend/internal/models"
+	"github.com/google/uuid"
+)
+
+// ErrSameScriptLanguages は学習先言語と母国語が同じ文字体系で、単語ごとの言語判定で区別できない場合のエラー
+var ErrSameScriptLanguages = errors.New("target and native languages use the same script")
+
+// SegmentedPhrase は対訳として対応付けた学習先言語の文と母国語訳
+type SegmentedPhrase struct {
+	Text        string
+	Translation string
+}
+
+// textScript は文字体系
+type textScript int
+
+const (
+	scriptNone textScript = iota
+	scriptLatin
+	scriptCyrillic
+	scriptGreek
+	scriptHan
+	scriptKana
+	scriptHangul
+	scriptArabic
+	scriptHebrew
+	scriptThai
+	scriptDevanagari
+	scriptGeorgian
+	scriptArmenian
+)
+
+// languageScripts は言語コードで使われる文字体系を返す
+func languageScripts(lang string) []textScript {
+	lang = strings.ToLower(lang)
+	if i := strings.IndexAny(lang, "-_"); i >= 0 {
+		lang = lang[:i]
+	}
+
+	switch lang {
+	case "ru", "uk", "be", "bg", "sr", "mk", "kk", "ky", "mn", "tg":
+		return []textScript{scriptCyrillic}
+	case "el":
+		return []textScript{scriptGreek}
+	case "ja":
+		return []textScript{scriptHan, scriptKana}
+	case "zh":
+		return []textScript{scriptHan}
+	case "ko":
+		return []textScript{scriptHangul, scriptHan}
+	case "ar", "fa", "ur":
+		return []textScript{scriptArabic}
+	case "he", "yi":
+		return []textScript{scriptHebrew}
+	case "th":
+		return []textScript{scriptThai}
+	case "hi", "mr", "ne", "sa":
+		return []textScript{scriptDevanagari}
+	case "ka":
+		return []textScript{scriptGeorgian}
+	case "hy":
+		return []textScript{scriptArmenian}
+	default:
+		return []textScript{scriptLatin}
+	}
+}
+
+// runeScript は文字の文字体系を返す（数字・記号はscriptNone）
+func runeScript(r rune) textScript {
+	switch {
+	case unicode.Is(unicode.Han, r) || r == '々':
+		return scriptHan
+	case unicode.Is(unicode.Hiragana, r), unicode.Is(unicode.Katakana, r), r == 'ー':
+		return scriptKana
+	case unicode.Is(unicode.Hangul, r):
+		return scriptHangul
+	case unicode.Is(unicode.Cyrillic, r):
+		return scriptCyrillic
+	case unicode.Is(unicode.Greek, r):
+		return scriptGreek
+	case unicode.Is(unicode.Arabic, r):
+		return scriptArabic
+	case unicode.Is(unicode.Hebrew, r):
+		return scriptHebrew
+	case unicode.Is(unicode.Thai, r):
+		return scriptThai
+	case unicode.Is(unicode.Devanagari, r):
+		return scriptDevanagari
+	case unicode.Is(unicode.Georgian, r):
+		return scriptGeorgian
+	case unicode.Is(unicode.Armenian, r):
+		return scriptArmenian
+	case unicode.Is(unicode.Latin, r):
+		return scriptLatin
+	default:
+		return scriptNone
+	}
+}
+
+// segmentLanguage は単語・文の言語の判定結果
+type segmentLanguage int
+
+const (
+	langUnknown segmentLanguage = iota
+	langTarget
+	langNative
+)
+
+// languageClassifier は文字体系から単語が学習先言語・母国語のどちらかを判定する
+type languageClassifier struct {
+	target map[textScript]bool
+	native map[textScript]bool
+}
+
+// newLanguageClassifier は言語の組から判定器を作成する
+// 両言語の文字体系が同じ場合（英語とフランス語など）は区別できないためエラーを返す
+func newLanguageClassifier(targetLang, nativeLang string) (*languageClassifier, error) {
+	c := &languageClassifier{target: make(map[textScript]bool), native: make(map[textScript]bool)}
+	for _, s := range languageScripts(targetLang) {
+		c.target[s] = true
+	}
+	for _, s := range languageScripts(nativeLang) {
+		c.native[s] = true
+	}
+
+	distinct := false
+	for s := range c.target {
+		if !c.native[s] {
+			distinct = true
+		}
+	}
+	for s := range c.native {
+		if !c.target[s] {
+			distinct = true
+		}
+	}
+	if !distinct {
+		return nil, fmt.Errorf("%w: %s, %s", ErrSameScriptLanguages, targetLang, nativeLang)
+	}
+
+	return c, nil
+}
+
+// classify は単語の言語を判定する
+// 数字・記号のみの単語や、両言語に共通の文字（日本語と中国語の漢字など）のみの単語はlangUnknown
+func (c *languageClassifier) classify(word string) segmentLanguage {
+	var target, native int
+	for _, r := range word {
+		s := runeScript(r)
+		if s == scriptNone {
+			continue
+		}
+		inTarget, inNative := c.target[s], c.native[s]
+		switch {
+		case inTarget && !inNative:
+			target++
+		case inNative && !inTarget:
+			native++
+		}
+	}
+
+	switch {
+	case target > native:
+		return langTarget
+	case native > target:
+		return langNative
+	default:
+		return langUnknown
+	}
+}
+
+// segmentWord はレイアウト上の単語
+type segmentWord struct {
+	text string
+	box  models.BoundingBox
+}
+
+// segmentRun は同じ行の中で同じ言語が連続する部分
+type segmentRun struct {
+	lang segmentLanguage
+	line int
+	text string
+	box  models.BoundingBox
+}
+
+// SegmentParallelText は対訳形式のページを（学習先言語の文, 母国語訳）の組に分割する
+// 単語ごとの言語判定で行を言語ごとの部分に分け、2段組のレイアウトでは同じ高さの部分同士を、
+// それ以外では読み順で続く部分同士を対応付ける
+// レイアウトがない場合（PDFのテキストレイヤーなど）はテキストの行と空白区切りの単語を使う
+func SegmentParallelText(text string, layout []models.OCRBlock, targetLang, nativeLang string) ([]SegmentedPhrase, error) {
+	classifier, err := newLanguageClassifier(targetLang, nativeLang)
+	if err != nil {
+		return nil, err
+	}
+
+	runs := buildSegmentRuns(segmentLines(text, layout), classifier)
+	if len(runs) == 0 {
+		return nil, nil
+	}
+
+	var phrases []SegmentedPhrase
+	if useColumnPairing(runs) {
+		phrases = pairByRows(runs)
+	} else {
+		phrases = pairSequentially(runs)
+	}
+
+	return splitAlignedSentences(phrases), nil
+}
+
+// segmentLines はレイアウトまたはテキストから行ごとの単語を取り出す
+func segmentLines(text string, layout []models.OCRBlock) [][]segmentWord {
+	var lines [][]segmentWord
+
+	for _, block := range layout {
+		for _, line := range block.Lines {
+			words := make([]segmentWord, 0, len(line.Words))
+			for _, w := range line.Words {
+				words = append(words, segmentWord{text: w.Text, box: w.BoundingBox})
+			}
+			if len(words) == 0 && strings.TrimSpace(line.Text) != "" {
+				for _, field := range strings.Fields(line.Text) {
+					words = append(words, segmentWord{text: field, box: line.BoundingBox})
+				}
+			}
+			lines = append(lines, words)
+		}
+	}
+	if len(lines) > 0 {
+		return lines
+	}
+
+	for _, line := range strings.Split(text, "\n") {
+		var words []segmentWord
+		for _, field := range strings.Fields(line) {
+			words = append(words, segmentWord{text: field})
+		}
+		if len(words) > 0 {
+			lines = append(lines, words)
+		}
+	}
+	return lines
+}
+
+// buildSegmentRuns は各行を同じ言語が連続する部分に分ける
+// 言語を判定できない単語（数字・記号など）は直前（行頭では直後）の単語の言語に含める
+func buildSegmentRuns(lines [][]segmentWord, classifier *languageClassifier) []segmentRun {
+	var runs []segmentRun
+
+	for i, words := range lines {
+		langs := make([]segmentLanguage, len(words))
+		for j, w := range words {
+			langs[j] = classifier.classify(w.text)
+		}
+
+		// 判定できない単語は前後の単語の言語を引き継ぐ
+		for j := 1; j < len(langs); j++ {
+			if langs[j] == langUnknown {
+				langs[j] = langs[j-1]
+			}
+		}
+		for j := len(langs) - 2; j >= 0; j-- {
+			if langs[j] == langUnknown {
+				langs[j] = langs[j+1]
+			}
+		}
+
+		for j, w := range words {
+			if langs[j] == langUnknown {
+				continue
+			}
+			if len(runs) > 0 && runs[len(runs)-1].line == i && runs[len(runs)-1].lang == langs[j] {
+				last := &runs[len(runs)-1]
+				last.text = joinSegmentText(last.text, w.text)
+				last.box = unionBox(last.box, w.box)
+				continue
+			}
+			runs = append(runs, segmentRun{lang: langs[j], line: i, text: w.text, box: w.box})
+		}
+	}
+
+	// 対訳の区切りに使われる記号（ダッシュ・コロンなど）を取り除く
+	cleaned := runs[:0]
+	for _, run := range runs {
+		run.text = strings.Trim(run.text, " \t-–—:：=|/・")
+		if run.text != "" {
+			cleaned = append(cleaned, run)
+		}
+	}
+	return cleaned
+}
+
+// useColumnPairing は2段組（左右に対訳が並ぶ）レイアウトかを判定する
+// 学習先言語の部分の半数以上に、別の行として認識された同じ高さの母国語の部分がある場合に2段組とみなす
+func useColumnPairing(runs []segmentRun) bool {
+	var targets, partnered int
+	for _, run := range runs {
+		if run.lang != langTarget {
+			continue
+		}
+		targets++
+		for _, other := range runs {
+			if other.lang == langNative && other.line != run.line && rowOverlap(run.box, other.box) >= 0.5 {
+				partnered++
+				break
+			}
+		}
+	}
+	return targets > 0 && partnered*2 >= targets
+}
+
+// pairByRows は2段組のレイアウトで同じ高さにある部分同士を対応付ける
+// 対応する訳がない行（複数行にわたる文の続き）は直前の組に連結する
+func pairByRows(runs []segmentRun) []SegmentedPhrase {
+	used := make([]bool, len(runs))
+	var phrases []SegmentedPhrase
+	var rows []models.BoundingBox // 各組の母国語の部分の位置
+
+	for _, run := range runs {
+		if run.lang != langTarget {
+			continue
+		}
+
+		best, bestOverlap := -1, 0.0
+		for j, other := range runs {
+			if used[j] || other.lang != langNative || other.line == run.line {
+				continue
+			}
+			if overlap := rowOverlap(run.box, other.box); overlap >= 0.5 && overlap > bestOverlap {
+				best, bestOverlap = j, overlap
+			}
+		}
+
+		if best < 0 {
+			if len(phrases) > 0 {
+				last := &phrases[len(phrases)-1]
+				last.Text = joinSegmentText(last.Text, run.text)
+				continue
+			}
+			phrases = append(phrases, SegmentedPhrase{Text: run.text})
+			rows = append(rows, models.BoundingBox{})
+			continue
+		}
+
+		used[best] = true
+		phrases = append(phrases, SegmentedPhrase{Text: run.text, Translation: runs[best].text})
+		rows = append(rows, runs[best].box)
+	}
+
+	// 対応付けられなかった母国語の部分は、直上にある組の訳に連結する
+	for j, run := range runs {
+		if used[j] || run.lang != langNative {
+			continue
+		}
+		target := -1
+		for i, row := range rows {
+			if row.Height > 0 && row.Y <= run.box.Y && (target < 0 || row.Y > rows[target].Y) {
+				target = i
+			}
+		}
+		if target >= 0 {
+			phrases[target].Translation = joinSegmentText(phrases[target].Translation, run.text)
+		}
+	}
+
+	return phrases
+}
+
+// pairSequentially は読み順で続く部分同士を対応付ける
+// 先に現れた言語を見出し側とし、見出し側の部分に続く他方の言語の部分をその訳とする
+func pairSequentially(runs []segmentRun) []SegmentedPhrase {
+	leader := langUnknown
+	for i := 1; i < len(runs); i++ {
+		if runs[i].lang != runs[i-1].lang {
+			leader = runs[i-1].lang
+			break
+		}
+	}
+	if leader == langUnknown {
+		// 片方の言語しかないページ
+		leader = langTarget
+	}
+
+	// 両方の言語を含む行（「原文 — 訳」の形式）は、それだけで1つの組とする
+	mixed := make(map[int]bool)
+	for i := 1; i < len(runs); i++ {
+		if runs[i].line == runs[i-1].line && runs[i].lang != runs[i-1].lang {
+			mixed[runs[i].line] = true
+		}
+	}
+
+	type group struct{ lead, follow string }
+	var groups []group
+	for i, run := range runs {
+		if run.lang == leader {
+			lineStart := i == 0 || runs[i-1].line != run.line
+			if len(groups) == 0 || groups[len(groups)-1].follow != "" || (lineStart && mixed[run.line]) {
+				groups = append(groups, group{})
+			}
+			last := &groups[len(groups)-1]
+			last.lead = joinSegmentText(last.lead, run.text)
+			continue
+		}
+
+		// 見出し側より前にある部分（ページの見出しなど）は対応付けない
+		if len(groups) == 0 {
+			continue
+		}
+		last := &groups[len(groups)-1]
+		last.follow = joinSegmentText(last.follow, run.text)
+	}
+
+	phrases := make([]SegmentedPhrase, 0, len(groups))
+	for _, g := range groups {
+		phrase := SegmentedPhrase{Text: g.lead, Translation: g.follow}
+		if leader == langNative {
+			phrase = SegmentedPhrase{Text: g.follow, Translation: g.lead}
+		}
+		if phrase.Text != "" {
+			phrases = append(phrases, phrase)
+		}
+	}
+	return phrases
+}
+
+// splitAlignedSentences は組に複数の文が含まれる場合、文の数が一致すれば文ごとの組に分ける
+// 訳がない組は文ごとに分けて、それぞれを訳なしのフレーズとする
+func splitAlignedSentences(phrases []SegmentedPhrase) []SegmentedPhrase {
+	result := make([]SegmentedPhrase, 0, len(phrases))
+	for _, phrase := range phrases {
+		texts := splitSentences(phrase.Text)
+		if phrase.Translation == "" {
+			for _, text := range texts {
+				result = append(result, SegmentedPhrase{Text: text})
+			}
+			continue
+		}
+
+		translations := splitSentences(phrase.Translation)
+		if len(texts) > 1 && len(texts) == len(translations) {
+			for i := range texts {
+				result = append(result, SegmentedPhrase{Text: texts[i], Translation: translations[i]})
+			}
+			continue
+		}
+		result = append(result, phrase)
+	}
+	return result
+}
+
+// splitSentences はテキストを文末記号で文に分ける
+func splitSentences(text string) []string {
+	var sentences []string
+	var current strings.Builder
+	runes := []rune(text)
+
+	ended := false
+	for i, r := range runes {
+		current.WriteRune(r)
+		switch {
+		case strings.ContainsRune(".!?。！？", r):
+			ended = true
+		case ended && strings.ContainsRune("」』)）\"»", r):
+		default:
+			ended = false
+		}
+		if !ended {
+			continue
+		}
+		// 連続する文末記号や閉じ括弧は同じ文に含める
+		if i+1 < len(runes) && strings.ContainsRune(".!?。！？」』)）\"»", runes[i+1]) {
+			continue
+		}
+		ended = false
+		if sentence := strings.TrimSpace(current.String()); sentence != "" {
+			sentences = append(sentences, sentence)
+		}
+		current.Reset()
+	}
+	if sentence := strings.TrimSpace(current.String()); sentence != "" {
+		sentences = append(sentences, sentence)
+	}
+
+	return sentences
+}
+
+// joinSegmentText は2つのテキストを連結する（分かち書きしない文字同士は空白なし）
+func joinSegmentText(a, b string) string {
+	if a == "" {
+		return b
+	}
+	if b == "" {
+		return a
+	}
+	last, _ := lastRune(a)
+	first := []rune(b)[0]
+	if isUnspacedScript(last) && isUnspacedScript(first) {
+		return a + b
+	}
+	return a + " " + b
+}
+
+// lastRune は文字列の最後の文字を返す
+func lastRune(s string) (rune, bool) {
+	runes := []rune(s)
+	if len(runes) == 0 {
+		return 0, false
+	}
+	return runes[len(runes)-1], true
+}
+
+// isUnspacedScript は分かち書きしない文字（漢字・かな・全角記号）かを判定する
+func isUnspacedScript(r rune) bool {
+	s := runeScript(r)
+	return s == scriptHan || s == scriptKana || s == scriptThai ||
+		(r >= 0x3000 && r <= 0x303F) || (r >= 0xFF00 && r <= 0xFFEF)
+}
+
+// rowOverlap は2つの矩形の縦方向の重なりの割合（低い方の高さに対する比率）を返す
+// 横方向に重なる場合（同じ段の上下の行）は0
+func rowOverlap(a, b models.BoundingBox) float64 {
+	if a.Height == 0 || b.Height == 0 {
+		return 0
+	}
+	if a.X < b.X+b.Width && b.X < a.X+a.Width {
+		return 0
+	}
+
+	overlap := min(a.Y+a.Height, b.Y+b.Height) - max(a.Y, b.Y)
+	if overlap <= 0 {
+		return 0
+	}
+	return float64(overlap) / float64(min(a.Height, b.Height))
+}
+
+// unionBox は2つの矩形を包含する最小の矩形を返す
+func unionBox(a, b models.BoundingBox) models.BoundingBox {
+	if a.Width == 0 && a.Height == 0 {
+		return b
+	}
+	if b.Width == 0 && b.Height == 0 {
+		return a
+	}
+
+	minX, minY := min(a.X, b.X), min(a.Y, b.Y)
+	maxX, maxY := max(a.X+a.Width, b.X+b.Width), max(a.Y+a.Height, b.Y+b.Height)
+	return models.BoundingBox{X: minX, Y: minY, Width: maxX - minX, Height: maxY - minY}
+}
+
+// SegmentPage はページのOCR結果を対訳フレーズに分割して保存する
+// フレーズリポジトリ・書籍リポジトリが未設定の場合は何もしない
+func (s *OCRService) SegmentPage(ctx context.Context, page *models.Page) ([]*models.PhraseRecord, error) {
+	if s.phraseRepo == nil || s.bookRepo == nil {
+		return nil, nil
+	}
+
+	book, err := s.bookRepo.GetByID(ctx, page.BookID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get book: %w", err)
+	}
+	if book == nil {
+		return nil, nil
+	}
+
+	// 修正済みのテキストはOCRレイアウトと一致しないため、テキストのみで分割する
+	layout := page.OCRLayout
+	if page.CorrectedText != nil {
+		layout = nil
+	}
+
+	segments, err := SegmentParallelText(page.Text(), layout, book.TargetLanguage, book.NativeLanguage)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	phrases := make([]*models.PhraseRecord, 0, len(segments))
+	for i, segment := range segments {
+		phrases = append(phrases, &models.PhraseRecord{
+			ID:             uuid.New(),
+			BookID:         page.BookID,
+			PageID:         page.ID,
+			PageNumber:     page.PageNumber,
+			Position:       i,
+			Text:           segment.Text,
+			Translation:    segment.Translation,
+			TargetLanguage: book.TargetLanguage,
+			NativeLanguage: book.NativeLanguage,
+			CreatedAt:      now,
+		})
+	}
+
+	if err := s.phraseRepo.ReplacePagePhrases(ctx, page.ID, phrases); err != nil {
+		return nil, fmt.Errorf("failed to save phrases: %w", err)
+	}
+
+	return phrases, nil
+}
+
+// segmentPageLogged はSegmentPageを実行する（失敗してもOCR処理自体は成功として扱う）
+func (s *OCRService) segmentPageLogged(ctx context.Context, page *models.Page) {
+	if _, err := s.SegmentPage(ctx, page); err != nil && !errors.Is(err, ErrSameScriptLanguages) {
+		log.Printf("failed to segment phrases for page %s: %v", page.ID, err)
+	}
+}
@@ -0,0 +1,141 @@
+package ocr
+
+import (
+	"context"
+	"testing"
+
+	"github.com/clearclown/HaiLanGo/backend/internal/models"
+	"github.com/clearclown/HaiLanGo/backend/internal/repository"
+	"github.com/clearclown/HaiLanGo/backend/pkg/cache"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// layoutLine はテスト用に単語と位置から行を構築する
+func layoutLine(words ...models.OCRWord) models.OCRLine {
+	line := models.OCRLine{Words: words}
+	for i, w := range words {
+		if i > 0 {
+			line.Text += " "
+		}
+		line.Text += w.Text
+		line.BoundingBox = unionBox(line.BoundingBox, w.BoundingBox)
+	}
+	return line
+}
+
+func word(text string, x, y, width int) models.OCRWord {
+	return models.OCRWord{Text: text, BoundingBox: models.BoundingBox{X: x, Y: y, Width: width, Height: 20}}
+}
+
+func TestSegmentParallelText_InlinePairs(t *testing.T) {
+	// 1行に「ロシア語 — 日本語訳」が並ぶ形式
+	text := "Урок 1\nПривет! — こんにちは！\nКак дела? — 元気ですか？\n2024"
+
+	phrases, err := SegmentParallelText(text, nil, "ru", "ja")
+	require.NoError(t, err)
+
+	assert.Equal(t, []SegmentedPhrase{
+		{Text: "Урок 1"},
+		{Text: "Привет!", Translation: "こんにちは！"},
+		{Text: "Как дела?", Translation: "元気ですか？"},
+	}, phrases[:3])
+}
+
+func TestSegmentParallelText_AlternatingLines(t *testing.T) {
+	// 訳が次の行に続く形式（母国語が先に現れるページ）
+	text := "おはようございます。\nGood morning.\nありがとう。また明日。\nThank you. See you tomorrow."
+
+	phrases, err := SegmentParallelText(text, nil, "en", "ja")
+	require.NoError(t, err)
+
+	assert.Equal(t, []SegmentedPhrase{
+		{Text: "Good morning.", Translation: "おはようございます。"},
+		{Text: "Thank you.", Translation: "ありがとう。"},
+		{Text: "See you tomorrow.", Translation: "また明日。"},
+	}, phrases)
+}
+
+func TestSegmentParallelText_TwoColumns(t *testing.T) {
+	// 左段に学習先言語、右段に母国語訳が並ぶ2段組（段ごとに別の行として認識される）
+	layout := []models.OCRBlock{
+		{Lines: []models.OCRLine{
+			layoutLine(word("Доброе", 10, 10, 80), word("утро.", 95, 10, 60)),
+			layoutLine(word("Спасибо", 10, 50, 90), word("большое", 105, 50, 90)),
+			layoutLine(word("за", 10, 80, 30), word("помощь.", 45, 80, 90)),
+		}},
+		{Lines: []models.OCRLine{
+			layoutLine(word("おはようございます。", 300, 12, 180)),
+			layoutLine(word("ご協力ありがとうございます。", 300, 52, 220)),
+		}},
+	}
+
+	phrases, err := SegmentParallelText("", layout, "ru", "ja")
+	require.NoError(t, err)
+
+	assert.Equal(t, []SegmentedPhrase{
+		{Text: "Доброе утро.", Translation: "おはようございます。"},
+		{Text: "Спасибо большое за помощь.", Translation: "ご協力ありがとうございます。"},
+	}, phrases)
+}
+
+func TestSegmentParallelText_TargetOnly(t *testing.T) {
+	phrases, err := SegmentParallelText("Я студент. Я живу в Москве.", nil, "ru", "ja")
+	require.NoError(t, err)
+
+	assert.Equal(t, []SegmentedPhrase{
+		{Text: "Я студент."},
+		{Text: "Я живу в Москве."},
+	}, phrases)
+}
+
+func TestSegmentParallelText_SameScript(t *testing.T) {
+	_, err := SegmentParallelText("Bonjour — Hello", nil, "fr", "en")
+	assert.ErrorIs(t, err, ErrSameScriptLanguages)
+
+	_, err = SegmentParallelText("", nil, "ja", "ja")
+	assert.ErrorIs(t, err, ErrSameScriptLanguages)
+}
+
+func TestSplitSentences(t *testing.T) {
+	assert.Equal(t, []string{"「はい。」", "そうです！"}, splitSentences("「はい。」そうです！"))
+	assert.Equal(t, []string{"Really?!", "Yes."}, splitSentences("Really?! Yes."))
+	assert.Equal(t, []string{"no terminator"}, splitSentences("no terminator"))
+}
+
+func TestSegmentPage(t *testing.T) {
+	ctx := context.Background()
+
+	bookRepo := repository.NewInMemoryBookRepository()
+	book := &models.Book{ID: uuid.New(), UserID: uuid.New(), Title: "Русский язык", TargetLanguage: "ru", NativeLanguage: "ja"}
+	require.NoError(t, bookRepo.Create(ctx, book))
+
+	phraseRepo := repository.NewInMemoryPhraseRepository()
+	service := NewOCRService(&staticOCRClient{}, cache.NewMockCache())
+	service.SetBookRepository(bookRepo)
+	service.SetPhraseRepository(phraseRepo)
+
+	page := &models.Page{ID: uuid.New(), BookID: book.ID, PageNumber: 3, OCRText: "Привет! — こんにちは！\nПока! — さようなら！"}
+	phrases, err := service.SegmentPage(ctx, page)
+	require.NoError(t, err)
+	require.Len(t, phrases, 2)
+
+	stored, err := phraseRepo.FindByPage(ctx, book.ID, 3)
+	require.NoError(t, err)
+	require.Len(t, stored, 2)
+	assert.Equal(t, "Привет!", stored[0].Text)
+	assert.Equal(t, "こんにちは！", stored[0].Translation)
+	assert.Equal(t, 1, stored[1].Position)
+	assert.Equal(t, "ru", stored[1].TargetLanguage)
+
+	// 再処理時は古いフレーズを置き換える
+	page.OCRText = "Спасибо! — ありがとう！"
+	_, err = service.SegmentPage(ctx, page)
+	require.NoError(t, err)
+
+	stored, err = phraseRepo.FindByBook(ctx, book.ID)
+	require.NoError(t, err)
+	require.Len(t, stored, 1)
+	assert.Equal(t, "Спасибо!", stored[0].Text)
+}
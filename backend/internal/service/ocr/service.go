@@ -6,14 +6,16 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
-	"sync"
-	"sync/atomic"
+	"log"
+	"os"
+	"path/filepath"
 	"time"
 
 	"github.com/clearclown/HaiLanGo/backend/internal/models"
 	"github.com/clearclown/HaiLanGo/backend/internal/repository"
 	"github.com/clearclown/HaiLanGo/backend/internal/websocket"
 	"github.com/clearclown/HaiLanGo/backend/pkg/cache"
+	imageproc "github.com/clearclown/HaiLanGo/backend/pkg/image"
 	"github.com/clearclown/HaiLanGo/backend/pkg/ocr"
 	"github.com/google/uuid"
 )
@@ -25,6 +27,14 @@ type OCRService struct {
 	cacheTTL  time.Duration
 	pageRepo  repository.PageRepository
 	wsHub     *websocket.Hub
+
+	preprocess *imageproc.PreprocessOptions // nilの場合は前処理を行わない
+	debugDir   string                       // 前処理前後の画像の保存先（空の場合は保存しない）
+
+	phraseRepo repository.PhraseRepository // nilの場合は対訳フレーズの分割を行わない
+	bookRepo   repository.BookRepository
+
+	learner *CorrectionLearner // nilの場合は手動修正から学習した自動修正を行わない
 }
 
 // NewOCRService は新しいOCRサービスを作成する
@@ -47,31 +57,154 @@ func (s *OCRService) SetPageRepository(repo repository.PageRepository) {
 	s.pageRepo = repo
 }
 
+// SetPhraseRepository は対訳フレーズのリポジトリを設定する
+// 設定するとOCR完了時にページを学習先言語と母国語の組に分割して保存する
+func (s *OCRService) SetPhraseRepository(repo repository.PhraseRepository) {
+	s.phraseRepo = repo
+}
+
+// SetBookRepository は書籍リポジトリを設定する（対訳フレーズの言語の取得に使用）
+func (s *OCRService) SetBookRepository(repo repository.BookRepository) {
+	s.bookRepo = repo
+}
+
+// SetCorrectionLearner は手動修正から学習した置換ルールによる自動修正を有効にする
+// 設定するとOCR完了時に同じ書籍（およびユーザーの他の書籍）で学習した修正をページに適用する
+func (s *OCRService) SetCorrectionLearner(learner *CorrectionLearner) {
+	s.learner = learner
+}
+
+// SetPreprocessing はOCR前の画像前処理（回転・傾き補正・トリミング・コントラスト補正）を有効にする
+// 向き検出はページごとのOCROptions.DetectOrientationで指定する
+func (s *OCRService) SetPreprocessing(opts imageproc.PreprocessOptions) {
+	s.preprocess = &opts
+}
+
+// SetPreprocessDebugDir は前処理前後の画像を保存するディレクトリを設定する（デバッグ用）
+func (s *OCRService) SetPreprocessDebugDir(dir string) {
+	s.debugDir = dir
+}
+
 // ProcessPage はページのOCR処理を行う
 func (s *OCRService) ProcessPage(ctx context.Context, pageID uuid.UUID, imageData []byte, languages []string) (*models.Page, error) {
-	// キャッシュキーを生成
+	return s.ProcessPageWithOptions(ctx, pageID, imageData, models.OCROptions{Languages: languages})
+}
+
+// ProcessPageWithOptions はOCRオプションを指定してページのOCR処理を行う
+func (s *OCRService) ProcessPageWithOptions(ctx context.Context, pageID uuid.UUID, imageData []byte, options models.OCROptions) (*models.Page, error) {
+	result, err := s.recognize(ctx, pageID, imageData, options)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.buildPageFromOCRResult(pageID, result), nil
+}
+
+// recognize はキャッシュを確認した上で画像の前処理とOCR処理を行う
+func (s *OCRService) recognize(ctx context.Context, pageID uuid.UUID, imageData []byte, options models.OCROptions) (*ocr.OCRResult, error) {
+	languages := options.Languages
+
+	// キャッシュキーを生成（向き検出の有無で前処理結果が変わるためキーに含める）
 	cacheKey := s.generateCacheKey(imageData, languages)
+	if options.DetectOrientation {
+		cacheKey += ":orient"
+	}
 
 	// キャッシュから取得を試みる
 	if cachedData, err := s.cache.Get(ctx, cacheKey); err == nil {
 		var result ocr.OCRResult
 		if err := json.Unmarshal(cachedData, &result); err == nil {
-			return s.buildPageFromOCRResult(pageID, &result), nil
+			return &result, nil
 		}
 	}
 
+	// 前処理を実行
+	imageData = s.preprocessImage(pageID, imageData, options)
+
 	// OCR処理を実行
 	result, err := s.ocrClient.ProcessImage(ctx, imageData, languages)
 	if err != nil {
 		return nil, fmt.Errorf("OCR processing failed: %w", err)
 	}
 
+	// 日本語・中国語の場合はルビ（ふりがな・ピンイン）を本文から分離する
+	if hasRubyLanguage(languages, result.DetectedLanguage) {
+		ocr.SeparateRuby(result)
+	}
+
 	// キャッシュに保存
 	if data, err := json.Marshal(result); err == nil {
 		s.cache.Set(ctx, cacheKey, data, s.cacheTTL)
 	}
 
-	return s.buildPageFromOCRResult(pageID, result), nil
+	return result, nil
+}
+
+// hasRubyLanguage はOCR対象言語または検出言語にルビを使う言語が含まれるかを判定する
+func hasRubyLanguage(languages []string, detected string) bool {
+	if ocr.IsRubyLanguage(detected) {
+		return true
+	}
+	for _, lang := range languages {
+		if ocr.IsRubyLanguage(lang) {
+			return true
+		}
+	}
+	return false
+}
+
+// preprocessImage は画像の前処理を行う
+// 前処理に失敗した場合（未対応の画像形式など）は元の画像をそのままOCRに渡す
+func (s *OCRService) preprocessImage(pageID uuid.UUID, imageData []byte, options models.OCROptions) []byte {
+	if s.preprocess == nil {
+		return imageData
+	}
+
+	opts := *s.preprocess
+	opts.DetectOrientation = options.DetectOrientation
+
+	result, err := imageproc.Preprocess(imageData, opts)
+	if err != nil {
+		log.Printf("OCR preprocessing skipped for page %s: %v", pageID, err)
+		return imageData
+	}
+
+	s.saveDebugImages(pageID, imageData, result.Image)
+	return result.Image
+}
+
+// saveDebugImages は前処理前後の画像をデバッグ用ディレクトリに保存する
+func (s *OCRService) saveDebugImages(pageID uuid.UUID, before, after []byte) {
+	if s.debugDir == "" {
+		return
+	}
+
+	if err := os.MkdirAll(s.debugDir, 0755); err != nil {
+		log.Printf("failed to create preprocess debug directory: %v", err)
+		return
+	}
+
+	files := map[string][]byte{
+		pageID.String() + "_before" + imageExtension(before): before,
+		pageID.String() + "_after.png":                       after,
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(s.debugDir, name), data, 0644); err != nil {
+			log.Printf("failed to save preprocess debug image %s: %v", name, err)
+		}
+	}
+}
+
+// imageExtension は画像データの先頭バイトから拡張子を判定する
+func imageExtension(data []byte) string {
+	switch {
+	case len(data) >= 3 && data[0] == 0xFF && data[1] == 0xD8 && data[2] == 0xFF:
+		return ".jpg"
+	case len(data) >= 8 && string(data[:8]) == "\x89PNG\r\n\x1a\n":
+		return ".png"
+	default:
+		return ".bin"
+	}
 }
 
 // generateCacheKey は画像データと言語からキャッシュキーを生成する
@@ -93,116 +226,84 @@ func (s *OCRService) buildPageFromOCRResult(pageID uuid.UUID, result *ocr.OCRRes
 		OCRConfidence: result.Confidence,
 		DetectedLang:  result.DetectedLanguage,
 		OCRStatus:     models.OCRStatusCompleted,
+		OCRLayout:     convertOCRLayout(result),
+		OCRProvider:   string(result.Provider),
 		UpdatedAt:     now,
 	}
 }
 
-// PageData はページデータ
-type PageData struct {
-	PageID    uuid.UUID
-	ImageData []byte
-}
+// convertOCRLayout はOCRクライアントのレイアウト結果をモデルのブロック階層に変換する
+func convertOCRLayout(result *ocr.OCRResult) []models.OCRBlock {
+	if len(result.Blocks) == 0 {
+		return nil
+	}
+
+	blocks := make([]models.OCRBlock, 0, len(result.Blocks))
+	for _, block := range result.Blocks {
+		lines := make([]models.OCRLine, 0, len(block.Lines))
+		for _, line := range block.Lines {
+			words := make([]models.OCRWord, 0, len(line.Words))
+			for _, word := range line.Words {
+				words = append(words, models.OCRWord{
+					Text:        word.Text,
+					Confidence:  word.Confidence,
+					BoundingBox: models.BoundingBox(word.BoundingBox),
+					Language:    result.DetectedLanguage,
+					Ruby:        convertRuby(word.Ruby),
+				})
+			}
+			lines = append(lines, models.OCRLine{
+				Text:        line.Text,
+				Confidence:  line.Confidence,
+				BoundingBox: models.BoundingBox(line.BoundingBox),
+				Words:       words,
+			})
+		}
+		blocks = append(blocks, models.OCRBlock{
+			Type:        "text",
+			Text:        block.Text,
+			Confidence:  block.Confidence,
+			BoundingBox: models.BoundingBox(block.BoundingBox),
+			Lines:       lines,
+		})
+	}
 
-// ProcessBookResult は書籍処理結果
-type ProcessBookResult struct {
-	TotalPages      int
-	ProcessedPages  int
-	FailedPages     int
-	ProcessingTime  time.Duration
-	Errors          []error
+	return blocks
 }
 
-// ProcessBook は書籍の全ページをOCR処理する（並列処理）
-func (s *OCRService) ProcessBook(ctx context.Context, bookID uuid.UUID, userID string, pages []PageData, languages []string, maxConcurrency int) (*ProcessBookResult, error) {
-	startTime := time.Now()
-
-	if maxConcurrency <= 0 {
-		maxConcurrency = 5 // デフォルトの並列数
-	}
-
-	result := &ProcessBookResult{
-		TotalPages: len(pages),
-		Errors:     []error{},
-	}
-
-	// 処理済みページ数をアトミックにカウント
-	var processedCount int32
-
-	// ワーカープールを作成
-	jobs := make(chan PageData, len(pages))
-	results := make(chan error, len(pages))
-
-	var wg sync.WaitGroup
-
-	// ワーカーを起動
-	for i := 0; i < maxConcurrency; i++ {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			for pageData := range jobs {
-				// コンテキストがキャンセルされていないかチェック
-				select {
-				case <-ctx.Done():
-					results <- ctx.Err()
-					return
-				default:
-				}
-
-				// ページをOCR処理
-				page, err := s.ProcessPage(ctx, pageData.PageID, pageData.ImageData, languages)
-				if err != nil {
-					results <- fmt.Errorf("page %s failed: %w", pageData.PageID, err)
-					continue
-				}
-
-				// データベースに保存
-				if s.pageRepo != nil {
-					page.BookID = bookID
-					if err := s.pageRepo.Create(ctx, page); err != nil {
-						results <- fmt.Errorf("failed to save page %s: %w", pageData.PageID, err)
-						continue
-					}
-				}
-
-				results <- nil // 成功
-
-				// 処理済みページ数を更新してWebSocket通知を送信
-				processed := atomic.AddInt32(&processedCount, 1)
-				s.sendOCRProgress(userID, bookID.String(), len(pages), int(processed))
-			}
-		}()
+// convertRuby は単語のルビをモデルに変換する
+func convertRuby(ruby []ocr.Ruby) []models.RubyText {
+	if len(ruby) == 0 {
+		return nil
 	}
 
-	// ジョブをワーカーに送信
-	go func() {
-		for _, page := range pages {
-			jobs <- page
-		}
-		close(jobs)
-	}()
-
-	// ワーカーの完了を待つ
-	go func() {
-		wg.Wait()
-		close(results)
-	}()
-
-	// 結果を集計
-	for err := range results {
-		if err != nil {
-			result.FailedPages++
-			result.Errors = append(result.Errors, err)
-		} else {
-			result.ProcessedPages++
-		}
+	converted := make([]models.RubyText, 0, len(ruby))
+	for _, r := range ruby {
+		converted = append(converted, models.RubyText(r))
 	}
+	return converted
+}
 
-	result.ProcessingTime = time.Since(startTime)
+// GetPageLayout は保存済みページのOCRレイアウトを取得する
+func (s *OCRService) GetPageLayout(ctx context.Context, pageID uuid.UUID) (*models.PageOCRLayoutResponse, error) {
+	page, err := s.pageRepo.FindByID(ctx, pageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get page: %w", err)
+	}
+	if page == nil {
+		return nil, ErrPageNotFound
+	}
 
-	// 完了通知を送信
-	s.sendBookReady(userID, bookID.String(), result.ProcessedPages, result.TotalPages)
+	result := models.NewOCRResultFromLayout(page.OCRText, page.DetectedLang, page.OCRConfidence, page.OCRLayout)
+	result.Provider = page.OCRProvider
 
-	return result, nil
+	return &models.PageOCRLayoutResponse{
+		PageID:     page.ID.String(),
+		BookID:     page.BookID.String(),
+		PageNumber: page.PageNumber,
+		ImageURL:   page.ImageURL,
+		Result:     result,
+	}, nil
 }
 
 // sendOCRProgress はOCR処理の進捗をWebSocket経由で送信する
@@ -262,3 +363,26 @@ func (s *OCRService) sendBookReady(userID, bookID string, processedPages, totalP
 
 	s.wsHub.SendToUser(userUUID, message)
 }
+
+// autoCorrect はページのOCR結果に学習済みの修正を適用する（失敗してもOCR処理自体は成功として扱う）
+func (s *OCRService) autoCorrect(ctx context.Context, page *models.Page, userID uuid.UUID) []*models.OCRAutoCorrection {
+	if s.learner == nil {
+		return nil
+	}
+	corrections, err := s.learner.Apply(ctx, page, userID)
+	if err != nil {
+		log.Printf("failed to apply OCR corrections to page %s: %v", page.ID, err)
+		return nil
+	}
+	return corrections
+}
+
+// saveAutoCorrections はページに適用した自動修正を記録する（再OCR時は以前の記録を置き換える）
+func (s *OCRService) saveAutoCorrections(ctx context.Context, page *models.Page, corrections []*models.OCRAutoCorrection) {
+	if s.learner == nil {
+		return
+	}
+	if err := s.learner.SaveAutoCorrections(ctx, page.ID, corrections); err != nil {
+		log.Printf("failed to record OCR auto corrections for page %s: %v", page.ID, err)
+	}
+}
@@ -1,11 +1,18 @@
 package ocr
 
 import (
+	"bytes"
 	"context"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/clearclown/HaiLanGo/backend/internal/models"
+	"github.com/clearclown/HaiLanGo/backend/internal/repository"
 	"github.com/clearclown/HaiLanGo/backend/pkg/cache"
+	imageproc "github.com/clearclown/HaiLanGo/backend/pkg/image"
 	"github.com/clearclown/HaiLanGo/backend/pkg/ocr"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
@@ -143,3 +150,196 @@ func TestBuildPageFromOCRResult(t *testing.T) {
 	assert.Equal(t, models.OCRStatusCompleted, page.OCRStatus)
 	assert.False(t, page.UpdatedAt.IsZero())
 }
+
+func TestBuildPageFromOCRResult_Layout(t *testing.T) {
+	service := NewOCRService(ocr.NewMockOCRClient(), cache.NewMockCache())
+
+	result := &ocr.OCRResult{
+		Text:             "Привет мир",
+		DetectedLanguage: "ru",
+		Confidence:       0.9,
+		Blocks: []ocr.Block{
+			{
+				Text:        "Привет мир",
+				Confidence:  0.9,
+				BoundingBox: ocr.BoundingBox{X: 10, Y: 10, Width: 200, Height: 30},
+				Lines: []ocr.Line{
+					{
+						Text:        "Привет мир",
+						Confidence:  0.9,
+						BoundingBox: ocr.BoundingBox{X: 10, Y: 10, Width: 200, Height: 30},
+						Words: []ocr.Word{
+							{Text: "Привет", Confidence: 0.9, BoundingBox: ocr.BoundingBox{X: 10, Y: 10, Width: 120, Height: 30}},
+							{Text: "мир", Confidence: 0.9, BoundingBox: ocr.BoundingBox{X: 140, Y: 10, Width: 70, Height: 30}},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	page := service.buildPageFromOCRResult(uuid.New(), result)
+
+	require.Len(t, page.OCRLayout, 1)
+	block := page.OCRLayout[0]
+	assert.Equal(t, "text", block.Type)
+	require.Len(t, block.Lines, 1)
+	require.Len(t, block.Lines[0].Words, 2)
+	assert.Equal(t, models.BoundingBox{X: 140, Y: 10, Width: 70, Height: 30}, block.Lines[0].Words[1].BoundingBox)
+	assert.Equal(t, "ru", block.Lines[0].Words[1].Language)
+}
+
+func TestGetPageLayout(t *testing.T) {
+	ctx := context.Background()
+	service := NewOCRService(ocr.NewMockOCRClient(), cache.NewMockCache())
+	pageRepo := repository.NewMockPageRepository()
+	service.SetPageRepository(pageRepo)
+
+	pageID := uuid.New()
+	page, err := service.ProcessPage(ctx, pageID, []byte("test image data"), []string{"en"})
+	require.NoError(t, err)
+	page.BookID = uuid.New()
+	page.PageNumber = 3
+	require.NoError(t, pageRepo.Create(ctx, page))
+
+	layout, err := service.GetPageLayout(ctx, pageID)
+	require.NoError(t, err)
+
+	assert.Equal(t, pageID.String(), layout.PageID)
+	assert.Equal(t, 3, layout.PageNumber)
+	require.NotNil(t, layout.Result)
+	assert.NotEmpty(t, layout.Result.Blocks)
+	assert.NotEmpty(t, layout.Result.Lines)
+	assert.NotEmpty(t, layout.Result.Words)
+
+	_, err = service.GetPageLayout(ctx, uuid.New())
+	assert.ErrorIs(t, err, ErrPageNotFound)
+}
+
+func TestProcessPage_RecordsProvider(t *testing.T) {
+	ctx := context.Background()
+	client := ocr.NewFallbackClient(0.5, ocr.ProviderClient{Provider: ocr.ProviderMock, Client: ocr.NewMockOCRClient()})
+	service := NewOCRService(client, cache.NewMockCache())
+
+	page, err := service.ProcessPage(ctx, uuid.New(), []byte("test image data"), []string{"ru"})
+	require.NoError(t, err)
+	assert.Equal(t, "mock", page.OCRProvider)
+
+	// キャッシュから取得した場合もプロバイダーを保持する
+	page, err = service.ProcessPage(ctx, uuid.New(), []byte("test image data"), []string{"ru"})
+	require.NoError(t, err)
+	assert.Equal(t, "mock", page.OCRProvider)
+}
+
+// capturingOCRClient はOCRに渡された画像を記録するクライアント
+type capturingOCRClient struct {
+	ocr.OCRClient
+	images [][]byte
+}
+
+func (c *capturingOCRClient) ProcessImage(ctx context.Context, imageData []byte, languages []string) (*ocr.OCRResult, error) {
+	c.images = append(c.images, imageData)
+	return c.OCRClient.ProcessImage(ctx, imageData, languages)
+}
+
+func TestProcessPage_Preprocessing(t *testing.T) {
+	ctx := context.Background()
+	client := &capturingOCRClient{OCRClient: ocr.NewMockOCRClient()}
+	service := NewOCRService(client, cache.NewMockCache())
+	debugDir := t.TempDir()
+	service.SetPreprocessing(imageproc.DefaultPreprocessOptions())
+	service.SetPreprocessDebugDir(debugDir)
+
+	// 白い紙に1本の横線を描いた画像
+	page := image.NewGray(image.Rect(0, 0, 200, 100))
+	for i := range page.Pix {
+		page.Pix[i] = 230
+	}
+	for x := 20; x < 180; x++ {
+		page.Pix[50*page.Stride+x] = 10
+	}
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, page))
+
+	pageID := uuid.New()
+	_, err := service.ProcessPageWithOptions(ctx, pageID, buf.Bytes(), models.OCROptions{Languages: []string{"ru"}})
+	require.NoError(t, err)
+
+	// OCRには前処理後の画像が渡される
+	require.Len(t, client.images, 1)
+	assert.NotEqual(t, buf.Bytes(), client.images[0])
+	_, err = png.Decode(bytes.NewReader(client.images[0]))
+	assert.NoError(t, err)
+
+	// 前処理前後の画像がデバッグ用ディレクトリに保存される
+	before, err := os.ReadFile(filepath.Join(debugDir, pageID.String()+"_before.png"))
+	require.NoError(t, err)
+	assert.Equal(t, buf.Bytes(), before)
+	after, err := os.ReadFile(filepath.Join(debugDir, pageID.String()+"_after.png"))
+	require.NoError(t, err)
+	assert.Equal(t, client.images[0], after)
+
+	// 画像として解釈できないデータはそのままOCRに渡す
+	_, err = service.ProcessPage(ctx, uuid.New(), []byte("test image data"), []string{"ru"})
+	require.NoError(t, err)
+	require.Len(t, client.images, 2)
+	assert.Equal(t, []byte("test image data"), client.images[1])
+}
+
+// staticOCRClient は固定のOCR結果を返すクライアント
+type staticOCRClient struct {
+	result ocr.OCRResult
+}
+
+func (c *staticOCRClient) ProcessImage(ctx context.Context, imageData []byte, languages []string) (*ocr.OCRResult, error) {
+	result := c.result
+	result.Blocks = append([]ocr.Block(nil), c.result.Blocks...)
+	return &result, nil
+}
+
+func TestProcessPage_SeparatesRuby(t *testing.T) {
+	ctx := context.Background()
+	words := []ocr.Word{
+		{Text: "にほんご", Confidence: 0.9, BoundingBox: ocr.BoundingBox{X: 10, Y: 0, Width: 60, Height: 12}},
+		{Text: "日本語を話します", Confidence: 0.9, BoundingBox: ocr.BoundingBox{X: 10, Y: 16, Width: 240, Height: 30}},
+	}
+	client := &staticOCRClient{result: ocr.OCRResult{
+		Text:             "にほんご\n日本語を話します",
+		DetectedLanguage: "ja",
+		Confidence:       0.9,
+		Blocks: []ocr.Block{{
+			Text: "にほんご\n日本語を話します",
+			Lines: []ocr.Line{
+				{Text: words[0].Text, BoundingBox: words[0].BoundingBox, Words: words[:1]},
+				{Text: words[1].Text, BoundingBox: words[1].BoundingBox, Words: words[1:]},
+			},
+		}},
+	}}
+
+	service := NewOCRService(client, cache.NewMockCache())
+	pageRepo := repository.NewMockPageRepository()
+	service.SetPageRepository(pageRepo)
+
+	pageID := uuid.New()
+	page, err := service.ProcessPage(ctx, pageID, []byte("test image data"), []string{"ja"})
+	require.NoError(t, err)
+
+	// ルビは本文から除かれ、親文字の単語に読みとして付与される
+	assert.Equal(t, "日本語を話します", page.OCRText)
+	require.Len(t, page.OCRLayout, 1)
+	require.Len(t, page.OCRLayout[0].Lines, 1)
+	assert.Equal(t, []models.RubyText{{Base: "日本語", Reading: "にほんご"}}, page.OCRLayout[0].Lines[0].Words[0].Ruby)
+
+	// 学習ページではページテキスト上の注記として返す
+	assert.Equal(t, []models.RubyAnnotation{{Base: "日本語", Reading: "にほんご", Offset: 0, Length: 3}},
+		models.RubyAnnotationsFromLayout(page.OCRText, page.OCRLayout))
+	assert.Equal(t, []models.RubyAnnotation{{Base: "日本語", Reading: "にほんご", Offset: 4, Length: 3}},
+		models.RubyAnnotationsFromLayout("第1課 日本語を話します", page.OCRLayout))
+
+	page.BookID = uuid.New()
+	page.PageNumber = 1
+	require.NoError(t, pageRepo.Create(ctx, page))
+	layout, err := service.GetPageLayout(ctx, pageID)
+	require.NoError(t, err)
+	assert.True(t, layout.Result.HasRuby)
+}
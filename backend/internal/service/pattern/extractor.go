@@ -17,6 +17,21 @@ type PageText struct {
 	Translation string
 }
 
+// PageTextsFromPhrases converts the aligned phrases of a book into extractor input.
+// Each phrase becomes its own PageText so that examples keep the translation
+// that belongs to the sentence rather than the whole page.
+func PageTextsFromPhrases(phrases []*models.PhraseRecord) []PageText {
+	pages := make([]PageText, 0, len(phrases))
+	for _, phrase := range phrases {
+		pages = append(pages, PageText{
+			PageNumber:  phrase.PageNumber,
+			Text:        phrase.Text,
+			Translation: phrase.Translation,
+		})
+	}
+	return pages
+}
+
 // Extractor handles pattern extraction from book pages
 type Extractor struct {
 	classifier *Classifier
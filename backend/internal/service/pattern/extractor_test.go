@@ -229,3 +229,24 @@ func TestExtractor_Performance(t *testing.T) {
 
 	t.Logf("Extracted %d patterns in %v", len(patterns), duration)
 }
+
+func TestPageTextsFromPhrases(t *testing.T) {
+	bookID := uuid.New()
+	phrases := []*models.PhraseRecord{
+		{BookID: bookID, PageNumber: 1, Position: 0, Text: "Hello!", Translation: "こんにちは！"},
+		{BookID: bookID, PageNumber: 1, Position: 1, Text: "How are you?", Translation: "元気ですか？"},
+		{BookID: bookID, PageNumber: 2, Position: 0, Text: "Hello again!"},
+	}
+
+	pages := PageTextsFromPhrases(phrases)
+
+	if len(pages) != 3 {
+		t.Fatalf("expected 3 page texts, got %d", len(pages))
+	}
+	if pages[1].PageNumber != 1 || pages[1].Text != "How are you?" || pages[1].Translation != "元気ですか？" {
+		t.Errorf("unexpected page text: %+v", pages[1])
+	}
+	if pages[2].PageNumber != 2 || pages[2].Translation != "" {
+		t.Errorf("unexpected page text: %+v", pages[2])
+	}
+}
@@ -0,0 +1,145 @@
+package srs
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/clearclown/HaiLanGo/backend/internal/models"
+)
+
+const (
+	// listeningAudioQuality と listeningAudioSpeed は聞き取りカードの音声合成の設定
+	listeningAudioQuality = "standard"
+	listeningAudioSpeed   = 1.0
+)
+
+var (
+	// ErrNotSpeakingCard は発音カード以外を音声で採点しようとした場合のエラー
+	ErrNotSpeakingCard = errors.New("review item is not a speaking card")
+	// ErrPronunciationUnavailable は発音の採点ができない（評価器が未設定の）場合のエラー
+	ErrPronunciationUnavailable = errors.New("pronunciation evaluation is not available")
+)
+
+// AudioSynthesizer は聞き取りカードの出題音声を合成する（tts.TTSService が実装する）
+type AudioSynthesizer interface {
+	GenerateAudio(ctx context.Context, text string, lang string, quality string, speed float64) (string, error)
+}
+
+// PronunciationEvaluator は発音カードの回答音声を採点する（stt.STTService が実装する）
+type PronunciationEvaluator interface {
+	EvaluatePronunciation(ctx context.Context, expectedText string, audioData []byte, language string) (*models.PronunciationScore, error)
+}
+
+// SetAudioSynthesizer は聞き取りカードの音声合成を設定する
+func (s *SRSService) SetAudioSynthesizer(synthesizer AudioSynthesizer) {
+	s.synthesizer = synthesizer
+}
+
+// SetPronunciationEvaluator は発音カードの採点を設定する
+func (s *SRSService) SetPronunciationEvaluator(evaluator PronunciationEvaluator) {
+	s.evaluator = evaluator
+}
+
+// newCards は1つの学習項目から種類ごとの未学習のカードを作成する
+// SourceID は最初のカード（認識カード）のIDにする
+// 訳のない項目は母国語から出題できないため、産出カードと発音カードは作成しない
+func newCards(source *models.ReviewItem, now time.Time) []*models.ReviewItem {
+	cards := make([]*models.ReviewItem, 0, len(models.CardTypes))
+	for _, cardType := range models.CardTypes {
+		if source.Translation == "" && (cardType == models.CardTypeProduction || cardType == models.CardTypeSpeaking) {
+			continue
+		}
+		card := newReviewItem(source, now)
+		card.CardType = cardType
+		if len(cards) == 0 {
+			card.SourceID = card.ID
+		} else {
+			card.SourceID = cards[0].SourceID
+		}
+		cards = append(cards, card)
+	}
+	return cards
+}
+
+// createCards は学習項目のカードをすべて保存し、保存したカードを返す
+func (s *SRSService) createCards(ctx context.Context, source *models.ReviewItem, now time.Time) ([]*models.ReviewItem, error) {
+	cards := newCards(source, now)
+	for i, card := range cards {
+		if err := s.repo.Create(ctx, card); err != nil {
+			return cards[:i], err
+		}
+	}
+	return cards, nil
+}
+
+// AttachAudio は聞き取りカードに出題音声のURLを設定する
+// 音声合成が未設定の場合や失敗した場合は音声なしで出題する（クライアントは文字を表示する）
+func (s *SRSService) AttachAudio(ctx context.Context, items []*models.ReviewItem) {
+	if s.synthesizer == nil {
+		return
+	}
+
+	for _, item := range items {
+		if item.CardType != models.CardTypeListening || item.AudioURL != "" {
+			continue
+		}
+		audioURL, err := s.synthesizer.GenerateAudio(ctx, item.Text, item.Language, listeningAudioQuality, listeningAudioSpeed)
+		if err != nil {
+			log.Printf("failed to synthesize audio for review item %s: %v", item.ID, err)
+			continue
+		}
+		item.AudioURL = audioURL
+	}
+}
+
+// CompleteSpeakingReview は発音カードの回答音声を採点し、採点結果（0-100）をスコアとして復習を完了する
+func (s *SRSService) CompleteSpeakingReview(ctx context.Context, userID string, itemID string, audioData []byte, timeSpentSec int) (*models.ReviewItem, *models.PronunciationScore, error) {
+	score, err := s.evaluateSpeaking(ctx, userID, itemID, audioData)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	updated, err := s.CompleteReview(ctx, userID, itemID, clampScore(score.TotalScore), timeSpentSec)
+	if err != nil {
+		return nil, nil, err
+	}
+	return updated, score, nil
+}
+
+// evaluateSpeaking は発音カードの回答音声を採点する
+func (s *SRSService) evaluateSpeaking(ctx context.Context, userID string, itemID string, audioData []byte) (*models.PronunciationScore, error) {
+	if s.evaluator == nil {
+		return nil, ErrPronunciationUnavailable
+	}
+
+	item, err := s.ownedItem(ctx, userID, itemID)
+	if err != nil {
+		return nil, err
+	}
+	if item.CardType != models.CardTypeSpeaking {
+		return nil, ErrNotSpeakingCard
+	}
+
+	return s.evaluator.EvaluatePronunciation(ctx, item.Text, audioData, item.Language)
+}
+
+// clampScore はスコアを0-100に収める
+func clampScore(score int) int {
+	if score < 0 {
+		return 0
+	}
+	if score > 100 {
+		return 100
+	}
+	return score
+}
+
+// sourceOf は復習項目の学習項目のIDを返す（カードの種類を導入する前の項目は自身のID）
+func sourceOf(item *models.ReviewItem) string {
+	if item.SourceID == "" {
+		return item.ID
+	}
+	return item.SourceID
+}
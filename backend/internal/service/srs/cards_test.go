@@ -0,0 +1,157 @@
+package srs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/clearclown/HaiLanGo/backend/internal/models"
+	"github.com/clearclown/HaiLanGo/backend/internal/repository"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSynthesizer はテスト用の音声合成
+type fakeSynthesizer struct {
+	err error
+}
+
+func (f *fakeSynthesizer) GenerateAudio(ctx context.Context, text string, lang string, quality string, speed float64) (string, error) {
+	if f.err != nil {
+		return "", f.err
+	}
+	return "https://audio.example.com/" + lang + "/" + text + ".mp3", nil
+}
+
+// fakeEvaluator はテスト用の発音の採点（常に同じスコアを返す）
+type fakeEvaluator struct {
+	score    int
+	expected string
+	language string
+}
+
+func (f *fakeEvaluator) EvaluatePronunciation(ctx context.Context, expectedText string, audioData []byte, language string) (*models.PronunciationScore, error) {
+	f.expected, f.language = expectedText, language
+	return &models.PronunciationScore{TotalScore: f.score, ExpectedText: expectedText}, nil
+}
+
+// findCard は学習項目のカードから指定した種類のカードを返す
+func findCard(t *testing.T, cards []*models.ReviewItem, cardType string) *models.ReviewItem {
+	t.Helper()
+	for _, card := range cards {
+		if card.CardType == cardType {
+			return card
+		}
+	}
+	require.Failf(t, "card not found", "card type %s", cardType)
+	return nil
+}
+
+// TestBuildQueue_BurySiblings は同じ学習項目のカードを1日に1枚だけ出題することをテスト
+func TestBuildQueue_BurySiblings(t *testing.T) {
+	ctx := context.Background()
+	service := NewSRSService(repository.NewInMemoryReviewRepository())
+
+	userID := uuid.New().String()
+	cards, err := service.AddReviewItems(ctx, userID, []*models.ReviewItem{
+		{Type: models.ReviewItemTypeWord, Text: "дом", Translation: "家", Language: "ru"},
+		{Type: models.ReviewItemTypeWord, Text: "кот", Translation: "猫", Language: "ru"},
+	})
+	require.NoError(t, err)
+	require.Len(t, cards, 8)
+
+	queue, err := service.BuildQueue(ctx, userID, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 2, queue.NewCount)
+	assert.Equal(t, 6, queue.BuriedCount)
+	require.Len(t, queue.Items, 2)
+	assert.NotEqual(t, queue.Items[0].SourceID, queue.Items[1].SourceID)
+
+	// 復習したカードの学習項目は、残りのカードも今日は出題しない
+	_, err = service.CompleteReview(ctx, userID, queue.Items[0].ID, 90, 5)
+	require.NoError(t, err)
+
+	queue, err = service.BuildQueue(ctx, userID, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 1, queue.NewCount)
+	assert.Equal(t, 6, queue.BuriedCount)
+
+	// 翌日は復習を迎えたカードを優先し、その学習項目の未学習のカードは引き続き後に回す
+	queue, err = service.BuildQueue(ctx, userID, time.Now().AddDate(0, 0, 1))
+	require.NoError(t, err)
+	assert.Equal(t, 1, queue.ReviewCount)
+	assert.Equal(t, 1, queue.NewCount)
+	assert.Equal(t, 6, queue.BuriedCount)
+}
+
+// TestAttachAudio は聞き取りカードへの出題音声の設定をテスト
+func TestAttachAudio(t *testing.T) {
+	ctx := context.Background()
+	service := NewSRSService(repository.NewInMemoryReviewRepository())
+
+	cards, err := service.AddReviewItems(ctx, uuid.New().String(), []*models.ReviewItem{
+		{Type: models.ReviewItemTypePhrase, Text: "Доброе утро", Translation: "おはよう", Language: "ru"},
+	})
+	require.NoError(t, err)
+
+	// 音声合成が未設定の場合は音声なし
+	service.AttachAudio(ctx, cards)
+	assert.Empty(t, findCard(t, cards, models.CardTypeListening).AudioURL)
+
+	// 音声合成に失敗しても出題は続ける
+	service.SetAudioSynthesizer(&fakeSynthesizer{err: errors.New("tts unavailable")})
+	service.AttachAudio(ctx, cards)
+	assert.Empty(t, findCard(t, cards, models.CardTypeListening).AudioURL)
+
+	service.SetAudioSynthesizer(&fakeSynthesizer{})
+	service.AttachAudio(ctx, cards)
+	assert.Equal(t, "https://audio.example.com/ru/Доброе утро.mp3", findCard(t, cards, models.CardTypeListening).AudioURL)
+	assert.Empty(t, findCard(t, cards, models.CardTypeRecognition).AudioURL)
+}
+
+// TestCompleteSpeakingReview は発音カードの採点による復習完了をテスト
+func TestCompleteSpeakingReview(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewInMemoryReviewRepository()
+	service := NewSRSService(repo)
+
+	userID := uuid.New().String()
+	cards, err := service.AddReviewItems(ctx, userID, []*models.ReviewItem{
+		{Type: models.ReviewItemTypeWord, Text: "спасибо", Translation: "ありがとう", Language: "ru"},
+	})
+	require.NoError(t, err)
+	speaking := findCard(t, cards, models.CardTypeSpeaking)
+	recognition := findCard(t, cards, models.CardTypeRecognition)
+	audio := []byte("recorded audio")
+
+	_, _, err = service.CompleteSpeakingReview(ctx, userID, speaking.ID, audio, 8)
+	assert.ErrorIs(t, err, ErrPronunciationUnavailable)
+
+	evaluator := &fakeEvaluator{score: 85}
+	service.SetPronunciationEvaluator(evaluator)
+
+	_, _, err = service.CompleteSpeakingReview(ctx, userID, recognition.ID, audio, 8)
+	assert.ErrorIs(t, err, ErrNotSpeakingCard)
+	_, _, err = service.CompleteSpeakingReview(ctx, uuid.New().String(), speaking.ID, audio, 8)
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	updated, score, err := service.CompleteSpeakingReview(ctx, userID, speaking.ID, audio, 8)
+	require.NoError(t, err)
+	assert.Equal(t, 85, score.TotalScore)
+	assert.Equal(t, "спасибо", evaluator.expected)
+	assert.Equal(t, "ru", evaluator.language)
+	assert.Equal(t, 1, updated.ReviewCount)
+
+	// 採点結果が復習履歴のスコアになり、同じ学習項目の他のカードの状態は変わらない
+	histories, err := repo.FindHistoryByItemID(ctx, speaking.ID)
+	require.NoError(t, err)
+	require.Len(t, histories, 1)
+	assert.Equal(t, 85, histories[0].Score)
+	assert.Equal(t, 8, histories[0].TimeSpentSec)
+
+	other, err := repo.FindByID(ctx, recognition.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 0, other.ReviewCount)
+}
@@ -0,0 +1,558 @@
+package srs
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html"
+	"log"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/clearclown/HaiLanGo/backend/internal/models"
+	"github.com/clearclown/HaiLanGo/backend/pkg/anki"
+	"github.com/clearclown/HaiLanGo/backend/pkg/srs"
+)
+
+const (
+	// ankiDeckName と ankiDeckID は書き出すAnkiのデッキ
+	ankiDeckName       = "HaiLanGo"
+	ankiDeckID   int64 = 1700000000000
+	// ankiModelIDBase は書き出すノートタイプのID（学習言語ごとに1ずつ増やす）
+	ankiModelIDBase int64 = 1700000000000
+)
+
+// ankiFields は書き出すノートタイプのフィールド
+var ankiFields = []string{"Text", "Translation", "Type", "Language"}
+
+// 取り込むノートのフィールド名の候補（大文字・小文字は区別しない）
+var (
+	textFieldNames        = []string{"Text", "Front", "Word", "Expression", "Question"}
+	translationFieldNames = []string{"Translation", "Back", "Meaning", "Definition", "Answer"}
+)
+
+// MediaStore は取り込んだ音声を保存する（storage.AudioStorage が実装する）
+type MediaStore interface {
+	Save(data []byte, filename string) (string, error)
+}
+
+// SetMediaStore は取り込んだ音声の保存先を設定する
+// 未設定の場合、デッキの音声は取り込まず、聞き取りカードは出題時に音声合成する
+func (s *SRSService) SetMediaStore(store MediaStore) {
+	s.media = store
+}
+
+// ImportOptions はデッキの取り込みの設定
+type ImportOptions struct {
+	// Language はノートに言語のフィールドがない場合の学習言語
+	Language string
+	// BookID は取り込んだ項目を関連付ける本（省略可）
+	BookID string
+}
+
+// ImportResult はデッキの取り込みの結果
+type ImportResult struct {
+	Notes   int `json:"notes"`   // 取り込んだ学習項目（ノート・行）の数
+	Cards   int `json:"cards"`   // 作成したカードの数
+	Skipped int `json:"skipped"` // 空・重複のため取り込まなかった数
+	Reviews int `json:"reviews"` // 取り込んだ復習履歴の数
+	Media   int `json:"media"`   // 取り込んだ音声の数
+}
+
+// ImportAnkiPackage はAnkiのパッケージ（.apkg）のノートを復習項目として取り込む
+// ノートごとに種類別のカードを作成し、対応するAnkiのカードの復習間隔・易しさ・FSRSの状態と復習ログを引き継ぐ
+// すでにある項目と同じ種類・テキストのノートは取り込まない
+// カードと復習ログはすべて作成してからまとめて保存し、保存に失敗した場合は何も取り込まない
+func (s *SRSService) ImportAnkiPackage(ctx context.Context, userID string, data []byte, opts ImportOptions) (*ImportResult, error) {
+	collection, err := anki.ReadPackage(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+
+	seen, err := s.existingKeys(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	cardsByNote := make(map[int64][]anki.Card)
+	for _, card := range collection.Cards {
+		cardsByNote[card.NoteID] = append(cardsByNote[card.NoteID], card)
+	}
+	reviewsByCard := make(map[int64][]anki.Review)
+	for _, review := range collection.Reviews {
+		reviewsByCard[review.CardID] = append(reviewsByCard[review.CardID], review)
+	}
+
+	now := time.Now()
+	result := &ImportResult{}
+	var items []*models.ReviewItem
+	var histories []*models.ReviewHistory
+	for _, note := range collection.Notes {
+		model := collection.Model(note.ModelID)
+		if model == nil {
+			result.Skipped++
+			continue
+		}
+
+		named := make(map[string]string, len(model.Fields))
+		for i, name := range model.Fields {
+			if i < len(note.Fields) {
+				named[strings.ToLower(name)] = anki.PlainText(note.Fields[i])
+			}
+		}
+		positional := make([]string, len(note.Fields))
+		for i, field := range note.Fields {
+			positional[i] = anki.PlainText(field)
+		}
+
+		source := deckSource(named, positional, opts)
+		source.UserID = userID
+		key := reviewItemKey(source.Type, source.Text)
+		if source.Text == "" || seen[key] {
+			result.Skipped++
+			continue
+		}
+		seen[key] = true
+
+		cards := newCards(source, now)
+		byType := make(map[string]*models.ReviewItem, len(cards))
+		for _, card := range cards {
+			byType[card.CardType] = card
+		}
+
+		// 引き継いだAnkiのカード（復習ログの取り込み用）
+		imported := make(map[*models.ReviewItem]anki.Card)
+		for _, ankiCard := range cardsByNote[note.ID] {
+			card := byType[ankiCardType(model, ankiCard.Ord)]
+			if card == nil {
+				continue
+			}
+			applyAnkiSchedule(card, ankiCard, collection, reviewsByCard[ankiCard.ID])
+			imported[card] = ankiCard
+		}
+
+		if listening := byType[models.CardTypeListening]; listening != nil {
+			if audioURL := s.importAudio(collection, note, listening.SourceID); audioURL != "" {
+				listening.AudioURL = audioURL
+				result.Media++
+			}
+		}
+
+		items = append(items, cards...)
+		result.Notes++
+		result.Cards += len(cards)
+
+		for _, card := range cards {
+			ankiCard, ok := imported[card]
+			if !ok {
+				continue
+			}
+			for _, review := range reviewsByCard[ankiCard.ID] {
+				// 手動の変更（期日の変更など）は復習ではないため取り込まない
+				if review.Ease == 0 {
+					continue
+				}
+				histories = append(histories, &models.ReviewHistory{
+					ReviewItemID: card.ID,
+					UserID:       userID,
+					Score:        srs.RatingToScore(srs.Rating(review.Ease)),
+					TimeSpentSec: int(review.Duration / time.Second),
+					ReviewedAt:   review.ReviewedAt(),
+				})
+				result.Reviews++
+			}
+		}
+	}
+
+	if err := s.repo.ImportItems(ctx, items, histories); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// ImportTextDeck はAnkiのテキスト形式（タブ・カンマ区切り）のノートを未学習の復習項目として取り込む
+// 列の名前（#columns）がない場合は1列目をテキスト、2列目を訳にする
+func (s *SRSService) ImportTextDeck(ctx context.Context, userID string, data []byte, opts ImportOptions) (*ImportResult, error) {
+	deck, err := anki.ReadText(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	seen, err := s.existingKeys(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	result := &ImportResult{}
+	for _, row := range deck.Rows {
+		positional := make([]string, len(row))
+		for i, value := range row {
+			if deck.HTML {
+				value = anki.PlainText(value)
+			}
+			positional[i] = strings.TrimSpace(value)
+		}
+		named := make(map[string]string, len(deck.Columns))
+		for i, name := range deck.Columns {
+			if i < len(positional) {
+				named[strings.ToLower(name)] = positional[i]
+			}
+		}
+
+		source := deckSource(named, positional, opts)
+		source.UserID = userID
+		key := reviewItemKey(source.Type, source.Text)
+		if source.Text == "" || seen[key] {
+			result.Skipped++
+			continue
+		}
+		seen[key] = true
+
+		cards, err := s.createCards(ctx, source, now)
+		result.Cards += len(cards)
+		if err != nil {
+			return result, err
+		}
+		result.Notes++
+	}
+
+	return result, nil
+}
+
+// deckSource はノート・行のフィールドから学習項目を作成する
+// named はフィールド名（小文字）ごとの値、positional は位置ごとの値（名前で見つからない場合に使用）
+func deckSource(named map[string]string, positional []string, opts ImportOptions) *models.ReviewItem {
+	field := func(names []string) (string, bool) {
+		for _, name := range names {
+			if value, ok := named[strings.ToLower(name)]; ok {
+				return value, true
+			}
+		}
+		return "", false
+	}
+
+	text, hasText := field(textFieldNames)
+	translation, hasTranslation := field(translationFieldNames)
+	// フィールド名の分からないノートタイプ・列は1つ目をテキスト、2つ目を訳にする
+	if !hasText && len(positional) > 0 {
+		text = positional[0]
+		if !hasTranslation && len(positional) > 1 {
+			translation = positional[1]
+		}
+	}
+
+	source := &models.ReviewItem{
+		BookID:      opts.BookID,
+		Text:        text,
+		Translation: translation,
+		Type:        named["type"],
+		Language:    named["language"],
+	}
+	if source.Language == "" {
+		source.Language = opts.Language
+	}
+	switch source.Type {
+	case models.ReviewItemTypeWord, models.ReviewItemTypePhrase, models.ReviewItemTypePattern:
+	default:
+		// 種類のフィールドがない場合は、空白を含むものをフレーズとする
+		source.Type = models.ReviewItemTypeWord
+		if len(strings.Fields(text)) > 1 {
+			source.Type = models.ReviewItemTypePhrase
+		}
+	}
+	return source
+}
+
+// ankiCardType はAnkiのカード（テンプレートの位置）に対応するカードの種類を返す
+// テンプレート名がカードの種類の場合（HaiLanGoから書き出したデッキ）はその種類、
+// それ以外は1つ目のテンプレートを認識カード、2つ目（逆向き）を産出カードとする
+func ankiCardType(model *anki.Model, ord int) string {
+	if ord < len(model.Templates) {
+		name := strings.ToLower(model.Templates[ord].Name)
+		for _, cardType := range models.CardTypes {
+			if name == cardType {
+				return cardType
+			}
+		}
+	}
+
+	switch ord {
+	case 0:
+		return models.CardTypeRecognition
+	case 1:
+		return models.CardTypeProduction
+	}
+	return ""
+}
+
+// applyAnkiSchedule はAnkiのカードの復習の状態をカードに引き継ぐ（未学習のカードは未学習のまま）
+func applyAnkiSchedule(item *models.ReviewItem, card anki.Card, collection *anki.Collection, reviews []anki.Review) {
+	if card.Type == anki.CardTypeNew {
+		return
+	}
+
+	item.IntervalDays = card.IntervalDays()
+	if card.Factor > 0 {
+		item.EaseFactor = float64(card.Factor) / 1000
+	}
+	item.ReviewCount = card.Reps
+	item.Stability = card.Stability
+	item.Difficulty = card.Difficulty
+	if due := collection.DueDate(card); !due.IsZero() {
+		item.NextReview = due
+	}
+
+	// 最後の復習日時は復習ログから、ログがない場合は次回復習日と間隔から求める
+	item.LastReviewed = item.NextReview.AddDate(0, 0, -item.IntervalDays)
+	var latest time.Time
+	for _, review := range reviews {
+		if review.Ease > 0 && review.ReviewedAt().After(latest) {
+			latest = review.ReviewedAt()
+		}
+	}
+	if !latest.IsZero() {
+		item.LastReviewed = latest
+	}
+}
+
+// importAudio はノートが参照している最初の音声を保存し、URLを返す（保存しない場合は空）
+func (s *SRSService) importAudio(collection *anki.Collection, note anki.Note, sourceID string) string {
+	if s.media == nil {
+		return ""
+	}
+
+	for _, field := range note.Fields {
+		for _, name := range anki.SoundFiles(field) {
+			data, ok := collection.Media[name]
+			if !ok || len(data) == 0 {
+				continue
+			}
+			// ファイル名はデッキの内容に依存しないよう、学習項目のIDと拡張子から作る
+			audioURL, err := s.media.Save(data, "imported/"+sourceID+strings.ToLower(path.Ext(path.Base(name))))
+			if err != nil {
+				log.Printf("failed to save imported audio %s: %v", name, err)
+				return ""
+			}
+			return audioURL
+		}
+	}
+	return ""
+}
+
+// ExportAnkiPackage はユーザーの復習項目をAnkiのパッケージ（.apkg）として書き出す
+// 学習項目ごとに1つのノートを作成し、カードの種類ごとのカードに復習の状態と復習履歴を書き出す
+func (s *SRSService) ExportAnkiPackage(ctx context.Context, userID string) ([]byte, error) {
+	sources, err := s.exportSources(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	collection := &anki.Collection{
+		Created: time.Now().UTC().Truncate(24 * time.Hour),
+		Decks:   []anki.Deck{{ID: ankiDeckID, Name: ankiDeckName}},
+	}
+	// 復習カードの期日は作成日からの日数のため、作成日は最初に作成した項目の日付にする
+	for _, cards := range sources {
+		for _, card := range cards {
+			if !card.CreatedAt.IsZero() && card.CreatedAt.Before(collection.Created) {
+				collection.Created = card.CreatedAt.UTC().Truncate(24 * time.Hour)
+			}
+		}
+	}
+
+	modelIDs := make(map[string]int64)
+	noteIDs := make(map[int64]bool)
+	cardIDs := make(map[int64]bool)
+	reviewIDs := make(map[int64]bool)
+	newPosition := 0
+
+	for _, cards := range sources {
+		first := cards[0]
+		modelID, ok := modelIDs[first.Language]
+		if !ok {
+			modelID = ankiModelIDBase + int64(len(modelIDs))
+			modelIDs[first.Language] = modelID
+			collection.Models = append(collection.Models, ankiModel(modelID, first.Language))
+		}
+
+		note := anki.Note{
+			ID:      uniqueID(noteIDs, first.CreatedAt.UnixMilli()),
+			GUID:    first.SourceID,
+			ModelID: modelID,
+			Fields: []string{
+				html.EscapeString(first.Text), html.EscapeString(first.Translation), first.Type, first.Language,
+			},
+			Modified: first.UpdatedAt,
+		}
+		collection.Notes = append(collection.Notes, note)
+
+		newPosition++
+		for _, item := range cards {
+			card := anki.Card{
+				ID:       uniqueID(cardIDs, item.CreatedAt.UnixMilli()),
+				NoteID:   note.ID,
+				DeckID:   ankiDeckID,
+				Ord:      cardTypeIndex(item.CardType),
+				Due:      int64(newPosition),
+				Modified: item.UpdatedAt,
+			}
+			if item.ReviewCount > 0 {
+				card.Type = anki.CardTypeReview
+				card.Due = int64(item.NextReview.Sub(collection.Created).Hours() / 24)
+				if card.Due < 0 {
+					card.Due = 0
+				}
+				card.Interval = item.IntervalDays
+				if card.Interval < 1 {
+					card.Interval = 1
+				}
+				card.Factor = int(item.EaseFactor * 1000)
+				card.Reps = item.ReviewCount
+				card.Stability = item.Stability
+				card.Difficulty = item.Difficulty
+			}
+			collection.Cards = append(collection.Cards, card)
+
+			histories, err := s.repo.FindHistoryByItemID(ctx, item.ID)
+			if err != nil {
+				return nil, err
+			}
+			sort.Slice(histories, func(i, j int) bool { return histories[i].ReviewedAt.Before(histories[j].ReviewedAt) })
+			for i, history := range histories {
+				review := anki.Review{
+					ID:       uniqueID(reviewIDs, history.ReviewedAt.UnixMilli()),
+					CardID:   card.ID,
+					Ease:     int(srs.ScoreToRating(history.Score)),
+					Factor:   card.Factor,
+					Duration: time.Duration(history.TimeSpentSec) * time.Second,
+					Type:     anki.ReviewTypeReview,
+				}
+				if i == 0 {
+					review.Type = anki.ReviewTypeLearning
+				}
+				if i == len(histories)-1 {
+					review.Interval = card.Interval
+				}
+				collection.Reviews = append(collection.Reviews, review)
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := anki.WritePackage(&buf, collection); err != nil {
+		return nil, fmt.Errorf("failed to write anki package: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// ExportTextDeck はユーザーの学習項目をAnkiのテキスト形式（タブ区切り、1行に1項目）で書き出す
+// 復習の状態は書き出さない
+func (s *SRSService) ExportTextDeck(ctx context.Context, userID string) ([]byte, error) {
+	sources, err := s.exportSources(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	deck := &anki.TextDeck{Separator: '\t', Columns: ankiFields}
+	for _, cards := range sources {
+		first := cards[0]
+		deck.Rows = append(deck.Rows, []string{first.Text, first.Translation, first.Type, first.Language})
+	}
+
+	var buf bytes.Buffer
+	if err := anki.WriteText(&buf, deck); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// exportSources はユーザーの復習項目を学習項目（SourceID）ごとにまとめる
+// 学習項目は作成順、カードはカードの種類の順に並べる
+func (s *SRSService) exportSources(ctx context.Context, userID string) ([][]*models.ReviewItem, error) {
+	items, err := s.repo.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		if !items[i].CreatedAt.Equal(items[j].CreatedAt) {
+			return items[i].CreatedAt.Before(items[j].CreatedAt)
+		}
+		if sourceOf(items[i]) != sourceOf(items[j]) {
+			return sourceOf(items[i]) < sourceOf(items[j])
+		}
+		return cardTypeIndex(items[i].CardType) < cardTypeIndex(items[j].CardType)
+	})
+
+	var sources [][]*models.ReviewItem
+	index := make(map[string]int)
+	for _, item := range items {
+		source := sourceOf(item)
+		i, ok := index[source]
+		if !ok {
+			i = len(sources)
+			index[source] = i
+			sources = append(sources, nil)
+		}
+		sources[i] = append(sources[i], item)
+	}
+	for _, cards := range sources {
+		sort.SliceStable(cards, func(i, j int) bool {
+			return cardTypeIndex(cards[i].CardType) < cardTypeIndex(cards[j].CardType)
+		})
+	}
+	return sources, nil
+}
+
+// ankiModel は書き出すノートタイプ（カードの種類ごとのテンプレート）を作成する
+// 聞き取りカードはAnkiの音声合成（{{tts}}）で出題する
+func ankiModel(id int64, language string) anki.Model {
+	answer := "{{FrontSide}}<hr id=answer>"
+	name := ankiDeckName
+	listening := "{{Text}}"
+	speaking := answer + "{{Text}}"
+	if language != "" {
+		name += " (" + language + ")"
+		listening = "{{tts " + language + ":Text}}"
+		speaking += listening
+	}
+
+	return anki.Model{
+		ID:     id,
+		Name:   name,
+		Fields: ankiFields,
+		Templates: []anki.Template{
+			{Name: models.CardTypeRecognition, Front: "{{Text}}", Back: answer + "{{Translation}}"},
+			{Name: models.CardTypeProduction, Front: "{{Translation}}", Back: answer + "{{Text}}"},
+			{Name: models.CardTypeListening, Front: listening, Back: answer + "{{Text}}<br>{{Translation}}"},
+			{Name: models.CardTypeSpeaking, Front: "{{Translation}}", Back: speaking},
+		},
+		CSS: ".card { font-family: arial; font-size: 20px; text-align: center; }",
+	}
+}
+
+// cardTypeIndex はカードの種類の位置（Ankiのテンプレートの位置）を返す
+func cardTypeIndex(cardType string) int {
+	for i, t := range models.CardTypes {
+		if t == cardType {
+			return i
+		}
+	}
+	return 0
+}
+
+// uniqueID は使用済みでないIDを返す（AnkiのIDは作成日時のミリ秒のため、重複する場合は1ずつずらす）
+func uniqueID(used map[int64]bool, id int64) int64 {
+	if id <= 0 {
+		id = 1
+	}
+	for used[id] {
+		id++
+	}
+	used[id] = true
+	return id
+}
@@ -0,0 +1,272 @@
+package srs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/clearclown/HaiLanGo/backend/internal/models"
+	"github.com/clearclown/HaiLanGo/backend/internal/repository"
+	"github.com/clearclown/HaiLanGo/backend/pkg/anki"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeMediaStore はテスト用の音声の保存先
+type fakeMediaStore struct {
+	saved map[string][]byte
+}
+
+func (f *fakeMediaStore) Save(data []byte, filename string) (string, error) {
+	if f.saved == nil {
+		f.saved = make(map[string][]byte)
+	}
+	f.saved[filename] = data
+	return "https://audio.example.com/" + filename, nil
+}
+
+// basicAnkiPackage はAnkiの「基本（裏表反転カード付き）」のノート2件のパッケージを作成する
+// 1件目の表のカードは復習済み（復習ログ3件、うち1件は手動の変更）、それ以外は未学習
+func basicAnkiPackage(t *testing.T) []byte {
+	t.Helper()
+
+	created := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	collection := &anki.Collection{
+		Created: created,
+		Models: []anki.Model{{
+			ID: 1342697561419, Name: "Basic (and reversed card)", Fields: []string{"Front", "Back"},
+			Templates: []anki.Template{
+				{Name: "Card 1", Front: "{{Front}}", Back: "{{Back}}"},
+				{Name: "Card 2", Front: "{{Back}}", Back: "{{Front}}"},
+			},
+		}},
+		Notes: []anki.Note{
+			{ID: 1, GUID: "a", ModelID: 1342697561419, Fields: []string{"<b>говорить</b> [sound:govorit.mp3]", "話す"}},
+			{ID: 2, GUID: "b", ModelID: 1342697561419, Fields: []string{"Доброе утро", "おはよう"}},
+		},
+		Cards: []anki.Card{
+			{ID: 10, NoteID: 1, DeckID: 1, Ord: 0, Type: anki.CardTypeReview, Due: 400, Interval: 12, Factor: 2300, Reps: 5, Stability: 14.2, Difficulty: 5.3},
+			{ID: 11, NoteID: 1, DeckID: 1, Ord: 1},
+			{ID: 20, NoteID: 2, DeckID: 1, Ord: 0},
+			{ID: 21, NoteID: 2, DeckID: 1, Ord: 1},
+		},
+		Reviews: []anki.Review{
+			{ID: created.Add(24 * time.Hour).UnixMilli(), CardID: 10, Ease: 3, Duration: 8 * time.Second},
+			{ID: created.Add(30 * 24 * time.Hour).UnixMilli(), CardID: 10, Ease: 1, Duration: 12 * time.Second},
+			{ID: created.Add(31 * 24 * time.Hour).UnixMilli(), CardID: 10, Ease: 0},
+		},
+		Media: map[string][]byte{"govorit.mp3": []byte("audio")},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, anki.WritePackage(&buf, collection))
+	return buf.Bytes()
+}
+
+// TestImportAnkiPackage はAnkiのノートの取り込みと復習の状態・復習ログの引き継ぎをテスト
+func TestImportAnkiPackage(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewInMemoryReviewRepository()
+	service := NewSRSService(repo)
+	media := &fakeMediaStore{}
+	service.SetMediaStore(media)
+	userID := uuid.New().String()
+
+	result, err := service.ImportAnkiPackage(ctx, userID, basicAnkiPackage(t), ImportOptions{Language: "ru"})
+	require.NoError(t, err)
+	assert.Equal(t, &ImportResult{Notes: 2, Cards: 8, Reviews: 2, Media: 1}, result)
+
+	items, err := repo.FindByUserID(ctx, userID)
+	require.NoError(t, err)
+	require.Len(t, items, 8)
+
+	var word []*models.ReviewItem
+	for _, item := range items {
+		assert.Equal(t, "ru", item.Language)
+		if item.Text == "говорить" {
+			word = append(word, item)
+		}
+	}
+	require.Len(t, word, 4)
+	assert.Equal(t, models.ReviewItemTypeWord, word[0].Type)
+	assert.Equal(t, "話す", word[0].Translation)
+
+	// 表のカード（Card 1）は認識カードとして復習の状態を引き継ぐ
+	recognition := findCard(t, word, models.CardTypeRecognition)
+	assert.Equal(t, 12, recognition.IntervalDays)
+	assert.InDelta(t, 2.3, recognition.EaseFactor, 1e-9)
+	assert.Equal(t, 5, recognition.ReviewCount)
+	assert.InDelta(t, 14.2, recognition.Stability, 1e-9)
+	assert.Equal(t, time.Date(2026, 2, 5, 0, 0, 0, 0, time.UTC), recognition.NextReview.UTC())
+	assert.Equal(t, time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC), recognition.LastReviewed.UTC())
+
+	histories, err := repo.FindHistoryByItemID(ctx, recognition.ID)
+	require.NoError(t, err)
+	require.Len(t, histories, 2, "manual log entries are not imported")
+	scores := []int{histories[0].Score, histories[1].Score}
+	assert.ElementsMatch(t, []int{80, 30}, scores)
+
+	// 裏のカード（Card 2）は産出カード、未学習のまま
+	production := findCard(t, word, models.CardTypeProduction)
+	assert.Equal(t, 0, production.ReviewCount)
+
+	// ノートの音声は聞き取りカードの出題音声にする
+	listening := findCard(t, word, models.CardTypeListening)
+	assert.Equal(t, "https://audio.example.com/imported/"+listening.SourceID+".mp3", listening.AudioURL)
+	assert.Equal(t, []byte("audio"), media.saved["imported/"+listening.SourceID+".mp3"])
+
+	for _, item := range items {
+		if item.Text == "Доброе утро" {
+			assert.Equal(t, models.ReviewItemTypePhrase, item.Type)
+			assert.Equal(t, 0, item.ReviewCount)
+		}
+	}
+
+	// 同じデッキをもう一度取り込んでも重複しない
+	result, err = service.ImportAnkiPackage(ctx, userID, basicAnkiPackage(t), ImportOptions{Language: "ru"})
+	require.NoError(t, err)
+	assert.Equal(t, &ImportResult{Skipped: 2}, result)
+}
+
+// TestImportAnkiPackage_Invalid は読み取れないパッケージのエラーをテスト
+func TestImportAnkiPackage_Invalid(t *testing.T) {
+	service := NewSRSService(repository.NewInMemoryReviewRepository())
+
+	_, err := service.ImportAnkiPackage(context.Background(), uuid.New().String(), []byte("not a package"), ImportOptions{})
+	assert.ErrorIs(t, err, anki.ErrInvalidPackage)
+}
+
+// failingImportRepository はまとめて保存する途中で失敗するリポジトリ
+type failingImportRepository struct {
+	*repository.InMemoryReviewRepository
+}
+
+func (r *failingImportRepository) ImportItems(ctx context.Context, items []*models.ReviewItem, histories []*models.ReviewHistory) error {
+	return errors.New("connection reset")
+}
+
+// TestImportAnkiPackage_SaveFailure は保存に失敗した場合に一部だけ取り込まないことをテスト
+func TestImportAnkiPackage_SaveFailure(t *testing.T) {
+	ctx := context.Background()
+	repo := &failingImportRepository{repository.NewInMemoryReviewRepository()}
+	service := NewSRSService(repo)
+	userID := uuid.New().String()
+
+	_, err := service.ImportAnkiPackage(ctx, userID, basicAnkiPackage(t), ImportOptions{Language: "ru"})
+	require.Error(t, err)
+
+	items, err := repo.FindByUserID(ctx, userID)
+	require.NoError(t, err)
+	assert.Empty(t, items)
+}
+
+// TestImportTextDeck はテキスト形式の取り込み（列名あり・なし）をテスト
+func TestImportTextDeck(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewInMemoryReviewRepository()
+	service := NewSRSService(repo)
+	userID := uuid.New().String()
+
+	data := "#separator:tab\n#columns:Translation\tText\tLanguage\n猫\tкот\tru\nこんにちは\tHello there\ten\n\tempty\n"
+	result, err := service.ImportTextDeck(ctx, userID, []byte(data), ImportOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, 3, result.Notes)
+	assert.Equal(t, 0, result.Skipped)
+
+	items, err := repo.FindByUserID(ctx, userID)
+	require.NoError(t, err)
+	byText := make(map[string]*models.ReviewItem)
+	for _, item := range items {
+		if item.CardType == models.CardTypeRecognition {
+			byText[item.Text] = item
+		}
+	}
+	require.Contains(t, byText, "кот")
+	assert.Equal(t, "猫", byText["кот"].Translation)
+	assert.Equal(t, "ru", byText["кот"].Language)
+	assert.Equal(t, models.ReviewItemTypePhrase, byText["Hello there"].Type)
+	// 訳のない行は認識・聞き取りカードのみ
+	assert.Equal(t, 4+4+2, len(items))
+
+	// 列名のないデッキは1列目をテキスト、2列目を訳にする
+	result, err = service.ImportTextDeck(ctx, userID, []byte("кот,cat\nдом,house\n"), ImportOptions{Language: "ru"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Notes)
+	assert.Equal(t, 1, result.Skipped)
+}
+
+// TestExportAnkiPackage は書き出したパッケージの復習の状態・復習ログと、取り込み直しをテスト
+func TestExportAnkiPackage(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewInMemoryReviewRepository()
+	service := NewSRSService(repo)
+	userID := uuid.New().String()
+
+	cards, err := service.AddReviewItems(ctx, userID, []*models.ReviewItem{
+		{Type: models.ReviewItemTypeWord, Text: "дом", Translation: "家 & 住まい", Language: "ru"},
+		{Type: models.ReviewItemTypeWord, Text: "hello", Language: "en"},
+	})
+	require.NoError(t, err)
+	recognition := findCard(t, cards, models.CardTypeRecognition)
+	_, err = service.CompleteReview(ctx, userID, recognition.ID, 85, 6)
+	require.NoError(t, err)
+
+	data, err := service.ExportAnkiPackage(ctx, userID)
+	require.NoError(t, err)
+	collection, err := anki.ReadPackage(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+
+	require.Len(t, collection.Models, 2, "one note type per language")
+	assert.Equal(t, "HaiLanGo (ru)", collection.Models[0].Name)
+	assert.Equal(t, "{{tts ru:Text}}", collection.Models[0].Templates[2].Front)
+	require.Len(t, collection.Notes, 2)
+	assert.Equal(t, recognition.SourceID, collection.Notes[0].GUID)
+	assert.Equal(t, "家 &amp; 住まい", collection.Notes[0].Fields[1])
+	assert.Len(t, collection.Cards, 6)
+
+	reviewed := collection.Cards[0]
+	assert.Equal(t, 0, reviewed.Ord)
+	assert.Equal(t, anki.CardTypeReview, reviewed.Type)
+	assert.Equal(t, 1, reviewed.Reps)
+	require.Len(t, collection.Reviews, 1)
+	assert.Equal(t, 3, collection.Reviews[0].Ease)
+	assert.Equal(t, reviewed.ID, collection.Reviews[0].CardID)
+
+	// 別のユーザーに取り込むと同じカードと復習履歴になる
+	otherUserID := uuid.New().String()
+	result, err := service.ImportAnkiPackage(ctx, otherUserID, data, ImportOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, &ImportResult{Notes: 2, Cards: 6, Reviews: 1}, result)
+
+	imported, err := repo.FindByUserID(ctx, otherUserID)
+	require.NoError(t, err)
+	for _, item := range imported {
+		if item.Text == "дом" && item.CardType == models.CardTypeRecognition {
+			assert.Equal(t, "家 & 住まい", item.Translation)
+			assert.Equal(t, "ru", item.Language)
+			assert.Equal(t, 1, item.ReviewCount)
+		}
+	}
+}
+
+// TestExportTextDeck はテキスト形式の書き出し（1行に1項目）をテスト
+func TestExportTextDeck(t *testing.T) {
+	ctx := context.Background()
+	service := NewSRSService(repository.NewInMemoryReviewRepository())
+	userID := uuid.New().String()
+
+	_, err := service.AddReviewItems(ctx, userID, []*models.ReviewItem{
+		{Type: models.ReviewItemTypePhrase, Text: "Доброе утро", Translation: "おはよう", Language: "ru"},
+	})
+	require.NoError(t, err)
+
+	data, err := service.ExportTextDeck(ctx, userID)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	assert.Equal(t, "#columns:Text\tTranslation\tType\tLanguage", lines[2])
+	assert.Equal(t, []string{"Доброе утро\tおはよう\tphrase\tru"}, lines[3:])
+}
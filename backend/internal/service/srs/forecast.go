@@ -0,0 +1,510 @@
+package srs
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"math"
+	"math/rand/v2"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/clearclown/HaiLanGo/backend/internal/models"
+	"github.com/clearclown/HaiLanGo/backend/pkg/srs"
+	"github.com/google/uuid"
+)
+
+const (
+	// DefaultForecastDays は予測する日数のデフォルト
+	DefaultForecastDays = 30
+	// MaxForecastDays は予測できる日数の上限
+	MaxForecastDays = 365
+	// MaxForecastNewItems は予測に追加できる学習項目の数の上限
+	MaxForecastNewItems = 10000
+
+	// forecastRuns は平均をとるシミュレーションの試行回数
+	forecastRuns = 10
+	// forecastSeed は同じ条件で同じ予測を返すための乱数の種
+	forecastSeed = 0x6861696c616e67
+	// forecastBudget はシミュレーションするカード数×日数×試行回数の上限
+	// 超える場合は学習項目を抽出してシミュレーションし、結果を全体に換算する
+	forecastBudget = 20_000_000
+
+	// defaultFirstReviewPassRate は初めての復習の正答率のデフォルト（復習履歴が足りない場合に使用）
+	defaultFirstReviewPassRate = 0.8
+	// minPassRateSamples は正答率を復習履歴から推定するのに必要な項目数
+	minPassRateSamples = 20
+)
+
+// ErrInvalidForecast は予測の日数・追加する学習項目の数が不正な場合のエラー
+var ErrInvalidForecast = errors.New("invalid forecast request")
+
+// ForecastRequest は復習の予測の条件
+type ForecastRequest struct {
+	Days     int       // 予測する日数（0の場合は DefaultForecastDays）
+	NewItems int       // 追加を検討している学習項目の数（学習項目ごとに種類別のカードを追加する）
+	BookID   uuid.UUID // 追加を検討している書籍（訳のあるフレーズのうち、まだ追加していないものを追加する）
+}
+
+// ForecastDay は1日の復習の予測（カード数はシミュレーションの試行の平均）
+type ForecastDay struct {
+	Date    string  `json:"date"`    // YYYY-MM-DD（ユーザーのタイムゾーン）
+	Due     float64 `json:"due"`     // 期限を迎えている復習カードの数（上限を超えて前日から残ったものを含む）
+	Reviews float64 `json:"reviews"` // 復習するカードの数
+	New     float64 `json:"new"`     // 新しく学習するカードの数
+	// Retention はその日の復習の前の、学習済みのカードの平均想起確率の予測（学習済みのカードがない場合は null）
+	Retention *float64 `json:"retention"`
+}
+
+// Forecast は復習の負荷と記憶の定着の予測
+type Forecast struct {
+	Algorithm string         `json:"algorithm"`
+	Days      []*ForecastDay `json:"days"`
+	// AddedItems と AddedCards は予測に含めた追加の学習項目とそのカードの数
+	AddedItems int `json:"added_items"`
+	AddedCards int `json:"added_cards"`
+	// FirstReviewPassRate は未学習のカードを初めて復習したときに覚えている確率（復習履歴から推定）
+	FirstReviewPassRate float64 `json:"first_review_pass_rate"`
+	// Sampled はカードが多いため、一部の学習項目のシミュレーションを全体に換算した予測かどうか
+	Sampled bool `json:"sampled"`
+
+	TotalReviews float64 `json:"total_reviews"`
+	TotalNew     float64 `json:"total_new"`
+	PeakDate     string  `json:"peak_date"`    // 復習と新しく学習するカードの合計が最も多い日
+	PeakCards    float64 `json:"peak_cards"`   // PeakDate のカードの数
+	Unintroduced float64 `json:"unintroduced"` // 期間の終わりにまだ学習していないカードの数
+}
+
+// forecastCard はシミュレーション中のカードの状態
+type forecastCard struct {
+	id     string
+	source string
+	state  srs.State
+	due    int // 次回復習日（予測の開始日からの日数）
+
+	// retention はその日の想起確率の予測（estimated が false の場合は推定できない）
+	retention float64
+	estimated bool
+}
+
+// Forecast はユーザーの復習項目（と追加を検討している学習項目）を、ユーザーのスケジューラーで days 日先まで復習したと仮定して、
+// 日ごとの復習するカードの数と想起確率を予測する
+// 出題は BuildQueue と同じく1日の上限・同じ学習項目のカードは1日1枚・出題を停止したリーチは除く、とし、
+// 各カードを覚えているかは想起確率の予測（未学習のカードは初めての復習の正答率）で乱数により決める
+func (s *SRSService) Forecast(ctx context.Context, userID string, req *ForecastRequest, now time.Time) (*Forecast, error) {
+	days := req.Days
+	if days == 0 {
+		days = DefaultForecastDays
+	}
+	if days < 0 || days > MaxForecastDays || req.NewItems < 0 || req.NewItems > MaxForecastNewItems {
+		return nil, ErrInvalidForecast
+	}
+
+	settings, err := s.GetSettings(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	scheduler, err := s.Scheduler(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	items, err := s.repo.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	added, addedItems, err := s.forecastAdditions(ctx, items, req, now)
+	if err != nil {
+		return nil, err
+	}
+	passRate, err := s.firstReviewPassRate(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 今日すでに出題した分は今日の上限から除く（BuildQueue と同じ）
+	todayStart := startOfDay(settings, now)
+	completedToday, err := s.repo.CountCompletedToday(ctx, userID, todayStart)
+	if err != nil {
+		return nil, err
+	}
+	introducedToday, err := s.repo.CountIntroducedSince(ctx, userID, todayStart)
+	if err != nil {
+		return nil, err
+	}
+
+	sim := &forecastSimulation{
+		scheduler:     scheduler,
+		now:           now,
+		start:         todayStart,
+		days:          days,
+		newLimit:      float64(settings.NewCardsPerDay),
+		reviewLimit:   float64(settings.ReviewsPerDay),
+		todayNew:      float64(settings.NewCardsPerDay - introducedToday),
+		todayReviews:  float64(settings.ReviewsPerDay - (completedToday - introducedToday)),
+		passRate:      passRate,
+		scale:         1,
+		reviewedToday: make(map[string]bool),
+	}
+	sim.load(items, added)
+	sim.sample(forecastBudget / (days * forecastRuns))
+
+	forecast := &Forecast{
+		Algorithm:           string(scheduler.Algorithm()),
+		Days:                make([]*ForecastDay, days),
+		AddedItems:          addedItems,
+		AddedCards:          len(added),
+		FirstReviewPassRate: passRate,
+		Sampled:             sim.scale > 1,
+	}
+	totals := make([]forecastTotals, days)
+	var unintroduced float64
+	for run := 0; run < forecastRuns; run++ {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		unintroduced += sim.run(rand.New(rand.NewPCG(forecastSeed, uint64(run))), totals)
+	}
+
+	for day := range totals {
+		total := totals[day]
+		result := &ForecastDay{
+			Date:    todayStart.AddDate(0, 0, day).Format("2006-01-02"),
+			Due:     roundCards(total.due / forecastRuns),
+			Reviews: roundCards(total.reviews / forecastRuns),
+			New:     roundCards(total.introduced / forecastRuns),
+		}
+		if total.retentionRuns > 0 {
+			retention := math.Round(total.retention/float64(total.retentionRuns)*1000) / 1000
+			result.Retention = &retention
+		}
+		forecast.Days[day] = result
+
+		forecast.TotalReviews += result.Reviews
+		forecast.TotalNew += result.New
+		if cards := result.Reviews + result.New; cards > forecast.PeakCards {
+			forecast.PeakDate = result.Date
+			forecast.PeakCards = cards
+		}
+	}
+	forecast.TotalReviews = roundCards(forecast.TotalReviews)
+	forecast.TotalNew = roundCards(forecast.TotalNew)
+	forecast.PeakCards = roundCards(forecast.PeakCards)
+	forecast.Unintroduced = roundCards(unintroduced / forecastRuns)
+
+	return forecast, nil
+}
+
+// forecastAdditions は追加を検討している学習項目の未学習のカードと学習項目の数を返す
+// 書籍のフレーズは AddReviewItems と同じく、すでに追加した項目と重複するものを除く
+func (s *SRSService) forecastAdditions(ctx context.Context, items []*models.ReviewItem, req *ForecastRequest, now time.Time) ([]*models.ReviewItem, int, error) {
+	var cards []*models.ReviewItem
+	sources := 0
+
+	if req.BookID != uuid.Nil && s.phraseRepo != nil {
+		records, err := s.phraseRepo.FindByBook(ctx, req.BookID)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		seen := make(map[string]bool, len(items))
+		for _, item := range items {
+			seen[reviewItemKey(item.Type, item.Text)] = true
+		}
+		for _, phrase := range PhraseDataFromRecords(uuid.Nil, records) {
+			key := reviewItemKey(models.ReviewItemTypePhrase, phrase.Content)
+			if strings.TrimSpace(phrase.Content) == "" || seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			cards = append(cards, newCards(&models.ReviewItem{
+				BookID:      phrase.BookID.String(),
+				PageNumber:  phrase.PageNumber,
+				Type:        models.ReviewItemTypePhrase,
+				Text:        phrase.Content,
+				Translation: phrase.Translation,
+			}, now)...)
+			sources++
+		}
+	}
+
+	// 件数だけ指定した学習項目は訳のあるフレーズとして、すべての種類のカードを追加する
+	for i := 0; i < req.NewItems; i++ {
+		cards = append(cards, newCards(&models.ReviewItem{
+			Type:        models.ReviewItemTypePhrase,
+			Translation: "-",
+		}, now)...)
+		sources++
+	}
+
+	return cards, sources, nil
+}
+
+// firstReviewPassRate はユーザーが未学習のカードを初めて復習したときに覚えていた割合を返す
+// 初めて復習したカードが minPassRateSamples に満たない場合は defaultFirstReviewPassRate を返す
+func (s *SRSService) firstReviewPassRate(ctx context.Context, userID string) (float64, error) {
+	histories, err := s.repo.FindHistoryByUserID(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	first := make(map[string]*models.ReviewHistory)
+	for _, history := range histories {
+		if earliest, ok := first[history.ReviewItemID]; !ok || history.ReviewedAt.Before(earliest.ReviewedAt) {
+			first[history.ReviewItemID] = history
+		}
+	}
+	if len(first) < minPassRateSamples {
+		return defaultFirstReviewPassRate, nil
+	}
+
+	passed := 0
+	for _, history := range first {
+		if !isLapse(history.Score) {
+			passed++
+		}
+	}
+	return float64(passed) / float64(len(first)), nil
+}
+
+// roundCards はカードの数の平均を小数第1位に丸める
+func roundCards(cards float64) float64 {
+	return math.Round(cards*10) / 10
+}
+
+// forecastTotals は1日の予測の試行の合計
+type forecastTotals struct {
+	due, reviews, introduced float64
+	retention                float64
+	retentionRuns            int
+}
+
+// forecastSimulation は復習の予測のシミュレーション
+type forecastSimulation struct {
+	scheduler srs.Scheduler
+	now       time.Time
+	start     time.Time // 今日の0時（UTC、BuildQueue の今日と同じ）
+	days      int
+
+	// 1日の上限（今日の上限は今日すでに出題した分を除く、抽出した場合は抽出した割合の分）
+	newLimit, reviewLimit  float64
+	todayNew, todayReviews float64
+	passRate               float64
+	// scale は抽出したカードの数から全体のカードの数への倍率（抽出しない場合は1）
+	scale float64
+
+	// initial は出題するカードの最初の状態（未学習のカードは出題する順）
+	initial []forecastCard
+	// initialLoad は日ごとの復習予定のカードの数（出題を停止したリーチを含む）
+	initialLoad map[int]int
+	// reviewedToday は今日すでにカードを復習した学習項目
+	reviewedToday map[string]bool
+}
+
+// load はシミュレーションの最初の状態を設定する
+func (sim *forecastSimulation) load(items, added []*models.ReviewItem) {
+	var reviewed, unseen []*models.ReviewItem
+	sim.initialLoad = make(map[int]int)
+	for _, item := range items {
+		sim.initialLoad[sim.dayOf(item.NextReview)]++
+		if item.ReviewCount > 0 && !item.LastReviewed.Before(sim.start) {
+			sim.reviewedToday[sourceOf(item)] = true
+		}
+		switch {
+		case item.Suspended:
+		case item.ReviewCount == 0:
+			unseen = append(unseen, item)
+		default:
+			reviewed = append(reviewed, item)
+		}
+	}
+	for _, card := range added {
+		sim.initialLoad[sim.dayOf(card.NextReview)]++
+	}
+
+	// 未学習のカードは BuildQueue と同じく追加した順（本・ページ順）に出題し、追加を検討しているカードは最後にする
+	sort.SliceStable(unseen, func(i, j int) bool {
+		a, b := unseen[i], unseen[j]
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.Before(b.CreatedAt)
+		}
+		if a.BookID != b.BookID {
+			return a.BookID < b.BookID
+		}
+		return a.PageNumber < b.PageNumber
+	})
+
+	for _, group := range [][]*models.ReviewItem{reviewed, unseen, added} {
+		for _, item := range group {
+			sim.initial = append(sim.initial, forecastCard{
+				id:     item.ID,
+				source: sourceOf(item),
+				state:  itemState(item),
+				due:    sim.dayOf(item.NextReview),
+			})
+		}
+	}
+}
+
+// sample はカードが maxCards を超える場合に、学習項目の一部（同じ学習項目のカードはまとめて）を抽出し、1日の上限を抽出した割合に合わせる
+// 抽出は学習項目のIDのハッシュで決めるため、同じ条件で同じ予測を返す
+func (sim *forecastSimulation) sample(maxCards int) {
+	if len(sim.initial) <= maxCards {
+		return
+	}
+
+	rate := float64(maxCards) / float64(len(sim.initial))
+	threshold := uint32(rate * math.MaxUint32)
+	sampled := sim.initial[:0:0]
+	sim.initialLoad = make(map[int]int)
+	for _, card := range sim.initial {
+		hash := fnv.New32a()
+		hash.Write([]byte(card.source))
+		if hash.Sum32() > threshold {
+			continue
+		}
+		sampled = append(sampled, card)
+		sim.initialLoad[card.due]++
+	}
+	if len(sampled) == 0 {
+		return
+	}
+
+	sim.scale = float64(len(sim.initial)) / float64(len(sampled))
+	sim.initial = sampled
+	sim.newLimit /= sim.scale
+	sim.reviewLimit /= sim.scale
+	sim.todayNew /= sim.scale
+	sim.todayReviews /= sim.scale
+}
+
+// dayOf は t が予測の開始日から何日目かを返す（過去は負の値）
+func (sim *forecastSimulation) dayOf(t time.Time) int {
+	return int(math.Floor(t.Sub(sim.start).Hours() / 24))
+}
+
+// run はシミュレーションを1回行って日ごとの結果を totals に加え、期間の終わりにまだ学習していないカードの数を返す
+// 抽出した場合、カードの数は全体に換算する
+func (sim *forecastSimulation) run(rng *rand.Rand, totals []forecastTotals) float64 {
+	cards := append([]forecastCard(nil), sim.initial...)
+	load := make(map[int]int, len(sim.initialLoad))
+	for day, count := range sim.initialLoad {
+		load[day] = count
+	}
+
+	var due []*forecastCard
+	var unseen []*forecastCard
+	// 上限の端数は次の日に繰り越す（抽出して上限が1枚未満になる場合用）
+	var newCarry, reviewCarry float64
+	for day := 0; day < sim.days; day++ {
+		reviewAt := sim.now.AddDate(0, 0, day)
+		total := &totals[day]
+
+		// 復習の前の想起確率と、期限を迎えたカード
+		due, unseen = due[:0], unseen[:0]
+		var retention float64
+		introduced := 0
+		for i := range cards {
+			card := &cards[i]
+			card.retention, card.estimated = srs.Retrievability(card.state, reviewAt)
+			if card.estimated {
+				retention += card.retention
+				introduced++
+			}
+			if card.due > day {
+				continue
+			}
+			if card.state.ReviewCount == 0 {
+				unseen = append(unseen, card)
+			} else {
+				due = append(due, card)
+			}
+		}
+		if introduced > 0 {
+			total.retention += retention / float64(introduced)
+			total.retentionRuns++
+		}
+		total.due += float64(len(due)) * sim.scale
+
+		// 忘れている可能性が高い順（推定できないカードが最優先）に復習し、同じ学習項目のカードは1日1枚にする
+		sort.SliceStable(due, func(i, j int) bool {
+			if due[i].retention != due[j].retention {
+				return due[i].retention < due[j].retention
+			}
+			return due[i].due < due[j].due
+		})
+
+		newLimit, reviewLimit := sim.newLimit, sim.reviewLimit
+		buried := make(map[string]bool)
+		if day == 0 {
+			newLimit, reviewLimit = sim.todayNew, sim.todayReviews
+			for source := range sim.reviewedToday {
+				buried[source] = true
+			}
+		}
+
+		reviewed := sim.review(rng, due, takeLimit(&reviewCarry, reviewLimit), buried, day, reviewAt, load)
+		introducedCards := sim.review(rng, unseen, takeLimit(&newCarry, newLimit), buried, day, reviewAt, load)
+		total.reviews += float64(reviewed) * sim.scale
+		total.introduced += float64(introducedCards) * sim.scale
+	}
+
+	unintroduced := 0
+	for i := range cards {
+		if cards[i].state.ReviewCount == 0 {
+			unintroduced++
+		}
+	}
+	return float64(unintroduced) * sim.scale
+}
+
+// takeLimit は上限 limit の整数部分を返し、端数を carry に繰り越す
+func takeLimit(carry *float64, limit float64) int {
+	*carry += max(limit, 0)
+	n := int(*carry)
+	*carry -= float64(n)
+	return n
+}
+
+// review は cards を先頭から limit 枚まで復習し、復習した枚数を返す
+// buried に含まれる学習項目のカードは復習せず、復習したカードの学習項目を buried に追加する
+func (sim *forecastSimulation) review(rng *rand.Rand, cards []*forecastCard, limit int, buried map[string]bool, day int, reviewAt time.Time, load map[int]int) int {
+	reviewed := 0
+	for _, card := range cards {
+		if reviewed >= limit {
+			break
+		}
+		if buried[card.source] {
+			continue
+		}
+		buried[card.source] = true
+
+		recall := sim.passRate
+		if card.estimated {
+			recall = card.retention
+		}
+		score := srs.RatingToScore(srs.RatingAgain)
+		if rng.Float64() < recall {
+			score = srs.RatingToScore(srs.RatingGood)
+		}
+
+		// completeReview と同じく次回復習日を計算し、復習予定の少ない日にずらす
+		load[card.due]--
+		state := sim.scheduler.Schedule(card.state, score, reviewAt)
+		next := sim.dayOf(state.NextReview)
+		if minInterval, maxInterval := srs.FuzzRange(state.IntervalDays); minInterval != maxInterval {
+			window := make(map[int]int, maxInterval-minInterval+1)
+			for days := minInterval; days <= maxInterval; days++ {
+				window[days] = load[day+days]
+			}
+			next = day + srs.BalanceInterval(state.IntervalDays, window, card.id)
+			state.NextReview = reviewAt.AddDate(0, 0, next-day)
+		}
+		card.state = state
+		card.due = next
+		load[next]++
+		reviewed++
+	}
+	return reviewed
+}
@@ -0,0 +1,150 @@
+package srs
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/clearclown/HaiLanGo/backend/internal/models"
+	"github.com/clearclown/HaiLanGo/backend/internal/repository"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestForecast は1日の上限と同じ学習項目のカードを1日1枚にする出題で、日ごとの復習の件数と想起確率を予測することをテスト
+func TestForecast(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewInMemoryReviewRepository()
+	service := NewSRSService(repo)
+
+	userID := uuid.New().String()
+	candidates := make([]*models.ReviewItem, 10)
+	for i := range candidates {
+		candidates[i] = &models.ReviewItem{Type: models.ReviewItemTypePhrase, Text: fmt.Sprintf("фраза %d", i), Translation: "フレーズ"}
+	}
+	_, err := service.AddReviewItems(ctx, userID, candidates)
+	require.NoError(t, err)
+
+	newCards := 5
+	_, err = service.UpdateSettings(ctx, userID, &models.UpdateReviewSettingsRequest{NewCardsPerDay: &newCards})
+	require.NoError(t, err)
+
+	now := time.Now()
+	forecast, err := service.Forecast(ctx, userID, &ForecastRequest{Days: 14}, now)
+	require.NoError(t, err)
+	require.Len(t, forecast.Days, 14)
+	assert.Equal(t, now.UTC().Format("2006-01-02"), forecast.Days[0].Date)
+	assert.Equal(t, defaultFirstReviewPassRate, forecast.FirstReviewPassRate)
+
+	// 初日は学習済みのカードがなく、1日の上限まで新しく学習する
+	assert.Nil(t, forecast.Days[0].Retention)
+	assert.Equal(t, 5.0, forecast.Days[0].New)
+	assert.Equal(t, 0.0, forecast.Days[0].Reviews)
+	for _, day := range forecast.Days {
+		assert.LessOrEqual(t, day.New, 5.0)
+	}
+	require.NotNil(t, forecast.Days[1].Retention)
+	assert.Greater(t, *forecast.Days[1].Retention, 0.0)
+	assert.LessOrEqual(t, *forecast.Days[1].Retention, 1.0)
+	assert.Greater(t, forecast.TotalReviews, 0.0)
+	assert.Equal(t, 40.0, forecast.TotalNew+forecast.Unintroduced)
+
+	// 同じ条件では同じ予測を返す
+	again, err := service.Forecast(ctx, userID, &ForecastRequest{Days: 14}, now)
+	require.NoError(t, err)
+	assert.Equal(t, forecast, again)
+}
+
+// TestForecast_Additions は追加を検討している書籍のフレーズ（追加済みのものを除く）と学習項目を予測に含めることをテスト
+func TestForecast_Additions(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewInMemoryReviewRepository()
+	service := NewSRSService(repo)
+
+	bookID := uuid.New()
+	phraseRepo := repository.NewInMemoryPhraseRepository()
+	require.NoError(t, phraseRepo.ReplacePagePhrases(ctx, uuid.New(), []*models.PhraseRecord{
+		{ID: uuid.New(), BookID: bookID, PageNumber: 1, Position: 0, Text: "Глава 1"},
+		{ID: uuid.New(), BookID: bookID, PageNumber: 1, Position: 1, Text: "Я читаю книгу.", Translation: "私は本を読んでいる。"},
+		{ID: uuid.New(), BookID: bookID, PageNumber: 1, Position: 2, Text: "Где метро?", Translation: "地下鉄はどこですか？"},
+	}))
+	service.SetPhraseRepository(phraseRepo)
+
+	userID := uuid.New().String()
+	_, err := service.AddReviewItems(ctx, userID, []*models.ReviewItem{
+		{Type: models.ReviewItemTypePhrase, Text: "я читаю  книгу.", Translation: "私は本を読んでいる。"},
+	})
+	require.NoError(t, err)
+
+	now := time.Now()
+	base, err := service.Forecast(ctx, userID, &ForecastRequest{Days: 7}, now)
+	require.NoError(t, err)
+	assert.Equal(t, 0, base.AddedItems)
+
+	withBook, err := service.Forecast(ctx, userID, &ForecastRequest{Days: 7, BookID: bookID, NewItems: 2}, now)
+	require.NoError(t, err)
+	assert.Equal(t, 3, withBook.AddedItems)
+	assert.Equal(t, 3*len(models.CardTypes), withBook.AddedCards)
+	assert.Greater(t, withBook.TotalNew, base.TotalNew)
+	assert.Greater(t, withBook.TotalReviews, base.TotalReviews)
+
+	// 予測は復習項目を変更しない
+	items, err := repo.FindByUserID(ctx, userID)
+	require.NoError(t, err)
+	assert.Len(t, items, len(models.CardTypes))
+	for _, item := range items {
+		assert.Equal(t, 0, item.ReviewCount)
+	}
+}
+
+// TestForecast_Invalid は予測の日数・追加する学習項目の数の検証をテスト
+func TestForecast_Invalid(t *testing.T) {
+	service := NewSRSService(repository.NewInMemoryReviewRepository())
+	userID := uuid.New().String()
+
+	invalid := []*ForecastRequest{
+		{Days: -1},
+		{Days: MaxForecastDays + 1},
+		{NewItems: -1},
+		{NewItems: MaxForecastNewItems + 1},
+	}
+	for _, req := range invalid {
+		_, err := service.Forecast(context.Background(), userID, req, time.Now())
+		assert.ErrorIs(t, err, ErrInvalidForecast)
+	}
+
+	forecast, err := service.Forecast(context.Background(), userID, &ForecastRequest{}, time.Now())
+	require.NoError(t, err)
+	assert.Len(t, forecast.Days, DefaultForecastDays)
+	assert.Empty(t, forecast.PeakDate)
+}
+
+// TestForecast_Sampled はカードが多い場合に一部の学習項目でシミュレーションし、結果を全体に換算することをテスト
+func TestForecast_Sampled(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewInMemoryReviewRepository()
+	service := NewSRSService(repo)
+
+	userID := uuid.New().String()
+	candidates := make([]*models.ReviewItem, 2000)
+	for i := range candidates {
+		candidates[i] = &models.ReviewItem{Type: models.ReviewItemTypePhrase, Text: fmt.Sprintf("фраза %d", i), Translation: "フレーズ"}
+	}
+	_, err := service.AddReviewItems(ctx, userID, candidates)
+	require.NoError(t, err)
+
+	forecast, err := service.Forecast(ctx, userID, &ForecastRequest{Days: MaxForecastDays}, time.Now())
+	require.NoError(t, err)
+	assert.True(t, forecast.Sampled)
+
+	// 全体のカードの数と1日の上限は抽出しても変わらない
+	assert.InDelta(t, 8000, forecast.TotalNew+forecast.Unintroduced, 80)
+	assert.InDelta(t, float64(DefaultNewCardsPerDay), forecast.Days[0].New, float64(DefaultNewCardsPerDay)*0.5)
+
+	// カードが少ない場合は抽出しない
+	small, err := service.Forecast(ctx, userID, &ForecastRequest{Days: 30}, time.Now())
+	require.NoError(t, err)
+	assert.False(t, small.Sampled)
+}
@@ -0,0 +1,132 @@
+package srs
+
+import (
+	"context"
+	"errors"
+
+	"github.com/clearclown/HaiLanGo/backend/internal/models"
+	"github.com/clearclown/HaiLanGo/backend/internal/repository"
+	"github.com/google/uuid"
+)
+
+// ErrPatternNotFound は練習したパターンが見つからない場合のエラー
+var ErrPatternNotFound = errors.New("pattern not found")
+
+// ReviewItemGenerator は学習の進捗（ページの完了・単語の収集・パターンの練習）から復習項目を自動で作成する
+// 作成した項目は書籍・ページに紐付け、同じユーザーの同じ種類・同じテキストの項目は重複して作成しない
+type ReviewItemGenerator struct {
+	service     *SRSService
+	phraseRepo  repository.PhraseRepository
+	patternRepo repository.PatternRepositoryInterface
+}
+
+// NewReviewItemGenerator は新しいReviewItemGeneratorを作成
+func NewReviewItemGenerator(service *SRSService) *ReviewItemGenerator {
+	return &ReviewItemGenerator{
+		service: service,
+	}
+}
+
+// SetPhraseRepository はページの対訳フレーズのリポジトリを設定する（ページの完了時に使用）
+func (g *ReviewItemGenerator) SetPhraseRepository(repo repository.PhraseRepository) {
+	g.phraseRepo = repo
+}
+
+// SetPatternRepository はパターンのリポジトリを設定する（パターンの練習時に使用）
+func (g *ReviewItemGenerator) SetPatternRepository(repo repository.PatternRepositoryInterface) {
+	g.patternRepo = repo
+}
+
+// FromCompletedPage は完了したページの対訳フレーズから復習項目を作成する
+// 訳のないフレーズ（見出しなど）は復習項目にしない
+func (g *ReviewItemGenerator) FromCompletedPage(ctx context.Context, userID string, bookID uuid.UUID, pageNumber int) ([]*models.ReviewItem, error) {
+	if g.phraseRepo == nil {
+		return nil, nil
+	}
+
+	records, err := g.phraseRepo.FindByPage(ctx, bookID, pageNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := make([]*models.ReviewItem, 0, len(records))
+	for _, record := range records {
+		if record.Translation == "" {
+			continue
+		}
+		candidates = append(candidates, &models.ReviewItem{
+			BookID:      record.BookID.String(),
+			PageNumber:  record.PageNumber,
+			Type:        models.ReviewItemTypePhrase,
+			Text:        record.Text,
+			Translation: record.Translation,
+			Language:    record.TargetLanguage,
+		})
+	}
+
+	return g.service.AddReviewItems(ctx, userID, candidates)
+}
+
+// FromWords は単語帳に収集した単語から復習項目を作成する
+// 意味が未設定の単語も、あとで辞書から意味を補えるよう復習項目にする
+func (g *ReviewItemGenerator) FromWords(ctx context.Context, words []*models.Word) ([]*models.ReviewItem, error) {
+	byUser := make(map[string][]*models.ReviewItem)
+	var userIDs []string
+	for _, word := range words {
+		if _, ok := byUser[word.UserID]; !ok {
+			userIDs = append(userIDs, word.UserID)
+		}
+		byUser[word.UserID] = append(byUser[word.UserID], &models.ReviewItem{
+			BookID:      word.BookID,
+			PageNumber:  word.PageNumber,
+			Type:        models.ReviewItemTypeWord,
+			Text:        word.Text,
+			Translation: word.Meaning,
+			Language:    word.Language,
+		})
+	}
+
+	var added []*models.ReviewItem
+	for _, userID := range userIDs {
+		items, err := g.service.AddReviewItems(ctx, userID, byUser[userID])
+		added = append(added, items...)
+		if err != nil {
+			return added, err
+		}
+	}
+
+	return added, nil
+}
+
+// FromPracticedPattern は練習したパターンから復習項目を作成する
+// ページ番号はパターンの最初の使用例のページにする
+func (g *ReviewItemGenerator) FromPracticedPattern(ctx context.Context, userID string, patternID uuid.UUID) ([]*models.ReviewItem, error) {
+	if g.patternRepo == nil {
+		return nil, nil
+	}
+
+	pattern, err := g.patternRepo.GetPatternByID(ctx, patternID)
+	if err != nil {
+		return nil, err
+	}
+	if pattern == nil {
+		return nil, ErrPatternNotFound
+	}
+
+	pageNumber := 0
+	examples, err := g.patternRepo.GetPatternExamples(ctx, patternID, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(examples) > 0 {
+		pageNumber = examples[0].PageNumber
+	}
+
+	return g.service.AddReviewItems(ctx, userID, []*models.ReviewItem{{
+		BookID:      pattern.BookID.String(),
+		PageNumber:  pageNumber,
+		Type:        models.ReviewItemTypePattern,
+		Text:        pattern.Pattern,
+		Translation: pattern.Translation,
+	}})
+}
@@ -0,0 +1,144 @@
+package srs
+
+import (
+	"context"
+	"testing"
+
+	"github.com/clearclown/HaiLanGo/backend/internal/models"
+	"github.com/clearclown/HaiLanGo/backend/internal/repository"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAddReviewItems は重複を除いた復習項目の追加をテスト
+func TestAddReviewItems(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewInMemoryReviewRepository()
+	service := NewSRSService(repo)
+
+	userID := uuid.New().String()
+	added, err := service.AddReviewItems(ctx, userID, []*models.ReviewItem{
+		{Type: models.ReviewItemTypeWord, Text: "Привет", Translation: "やあ"},
+		{Type: models.ReviewItemTypeWord, Text: " привет ", Translation: "やあ"}, // 大文字・小文字と空白の違いは同じ項目
+		{Type: models.ReviewItemTypePhrase, Text: "Привет", Translation: "やあ"}, // 種類が異なれば別の項目
+		{Type: models.ReviewItemTypeWord, Text: "  "},
+	})
+	require.NoError(t, err)
+	require.Len(t, added, 8) // 2項目 × 4種類のカード
+	assert.Equal(t, userID, added[0].UserID)
+	assert.Equal(t, 0, added[0].ReviewCount)
+	assert.NotEmpty(t, added[0].ID)
+	for i, cardType := range models.CardTypes {
+		assert.Equal(t, cardType, added[i].CardType)
+		assert.Equal(t, added[0].ID, added[i].SourceID) // 学習項目のIDは認識カードのID
+		assert.Equal(t, added[0].Text, added[i].Text)
+	}
+	assert.NotEqual(t, added[0].SourceID, added[4].SourceID)
+
+	// すでにある項目は追加しない（他のユーザーの項目は関係ない）
+	added, err = service.AddReviewItems(ctx, userID, []*models.ReviewItem{
+		{Type: models.ReviewItemTypeWord, Text: "ПРИВЕТ"},
+	})
+	require.NoError(t, err)
+	assert.Empty(t, added)
+
+	// 訳のない項目は母国語から出題するカード（産出・発音）を作成しない
+	added, err = service.AddReviewItems(ctx, uuid.New().String(), []*models.ReviewItem{
+		{Type: models.ReviewItemTypeWord, Text: "Привет"},
+	})
+	require.NoError(t, err)
+	require.Len(t, added, 2)
+	assert.Equal(t, models.CardTypeRecognition, added[0].CardType)
+	assert.Equal(t, models.CardTypeListening, added[1].CardType)
+}
+
+// TestReviewItemGenerator_FromCompletedPage はページのフレーズからの作成をテスト
+func TestReviewItemGenerator_FromCompletedPage(t *testing.T) {
+	ctx := context.Background()
+	bookID := uuid.New()
+	userID := uuid.New().String()
+
+	phraseRepo := repository.NewInMemoryPhraseRepository()
+	require.NoError(t, phraseRepo.ReplacePagePhrases(ctx, uuid.New(), []*models.PhraseRecord{
+		{ID: uuid.New(), BookID: bookID, PageNumber: 2, Position: 0, Text: "Глава 1"},
+		{ID: uuid.New(), BookID: bookID, PageNumber: 2, Position: 1, Text: "Доброе утро!", Translation: "おはよう！", TargetLanguage: "ru"},
+	}))
+
+	generator := NewReviewItemGenerator(NewSRSService(repository.NewInMemoryReviewRepository()))
+
+	// フレーズのリポジトリが未設定の場合は何もしない
+	added, err := generator.FromCompletedPage(ctx, userID, bookID, 2)
+	require.NoError(t, err)
+	assert.Empty(t, added)
+
+	generator.SetPhraseRepository(phraseRepo)
+	added, err = generator.FromCompletedPage(ctx, userID, bookID, 2)
+	require.NoError(t, err)
+	require.Len(t, added, len(models.CardTypes))
+	assert.Equal(t, models.ReviewItemTypePhrase, added[0].Type)
+	assert.Equal(t, "Доброе утро!", added[0].Text)
+	assert.Equal(t, "おはよう！", added[0].Translation)
+	assert.Equal(t, bookID.String(), added[0].BookID)
+	assert.Equal(t, 2, added[0].PageNumber)
+	assert.Equal(t, "ru", added[0].Language)
+}
+
+// TestReviewItemGenerator_FromWords は収集した単語からの作成をテスト
+func TestReviewItemGenerator_FromWords(t *testing.T) {
+	ctx := context.Background()
+	generator := NewReviewItemGenerator(NewSRSService(repository.NewInMemoryReviewRepository()))
+
+	userA, userB := uuid.New().String(), uuid.New().String()
+	added, err := generator.FromWords(ctx, []*models.Word{
+		{UserID: userA, BookID: "book-1", PageNumber: 3, Text: "книга", Meaning: "本", Language: "ru"},
+		{UserID: userA, BookID: "book-2", PageNumber: 8, Text: "Книга", Language: "ru"},
+		{UserID: userB, BookID: "book-1", PageNumber: 3, Text: "книга", Language: "ru"},
+	})
+	require.NoError(t, err)
+	require.Len(t, added, 6) // ユーザーAの4種類と、意味のないユーザーBの2種類（認識・聞き取り）
+	assert.Equal(t, userA, added[0].UserID)
+	assert.Equal(t, models.ReviewItemTypeWord, added[0].Type)
+	assert.Equal(t, "本", added[0].Translation)
+	assert.Equal(t, "book-1", added[0].BookID)
+	assert.Equal(t, 3, added[0].PageNumber)
+	assert.Equal(t, userB, added[5].UserID)
+}
+
+// TestReviewItemGenerator_FromPracticedPattern は練習したパターンからの作成をテスト
+func TestReviewItemGenerator_FromPracticedPattern(t *testing.T) {
+	ctx := context.Background()
+	patternRepo := repository.NewInMemoryPatternRepository()
+	patterns, err := patternRepo.GetPatternsByBookID(ctx, uuid.MustParse("550e8400-e29b-41d4-a716-446655440001"))
+	require.NoError(t, err)
+	require.NotEmpty(t, patterns)
+
+	var practiced models.Pattern
+	for _, pattern := range patterns {
+		if pattern.Pattern == "Здравствуйте!" {
+			practiced = pattern
+		}
+	}
+	require.NotEqual(t, uuid.Nil, practiced.ID)
+
+	generator := NewReviewItemGenerator(NewSRSService(repository.NewInMemoryReviewRepository()))
+	generator.SetPatternRepository(patternRepo)
+
+	userID := uuid.New().String()
+	added, err := generator.FromPracticedPattern(ctx, userID, practiced.ID)
+	require.NoError(t, err)
+	require.Len(t, added, len(models.CardTypes))
+	assert.Equal(t, models.ReviewItemTypePattern, added[0].Type)
+	assert.Equal(t, practiced.Pattern, added[0].Text)
+	assert.Equal(t, practiced.Translation, added[0].Translation)
+	assert.Equal(t, practiced.BookID.String(), added[0].BookID)
+	assert.Equal(t, 1, added[0].PageNumber) // 最初の使用例のページ
+
+	// 何度練習しても1つだけ
+	added, err = generator.FromPracticedPattern(ctx, userID, practiced.ID)
+	require.NoError(t, err)
+	assert.Empty(t, added)
+
+	_, err = generator.FromPracticedPattern(ctx, userID, uuid.New())
+	assert.ErrorIs(t, err, ErrPatternNotFound)
+}
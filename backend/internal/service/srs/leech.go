@@ -0,0 +1,235 @@
+package srs
+
+import (
+	"context"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/clearclown/HaiLanGo/backend/internal/models"
+	"github.com/clearclown/HaiLanGo/backend/internal/repository"
+	"github.com/clearclown/HaiLanGo/backend/pkg/srs"
+	"github.com/google/uuid"
+)
+
+const (
+	// DefaultLeechThreshold と DefaultLeechWindowDays はリーチと判定する忘却の回数と期間のデフォルト
+	DefaultLeechThreshold  = 5
+	DefaultLeechWindowDays = 30
+	// DefaultLeechAction はリーチの対処のデフォルト
+	DefaultLeechAction = models.LeechActionReteach
+
+	// remediationExamples は学び直しの教材に含めるパターンの使用例の数
+	remediationExamples = 5
+)
+
+// DictionaryLookup は単語の辞書の項目を引く（dictionary.Service が実装する）
+type DictionaryLookup interface {
+	LookupWord(ctx context.Context, word string, language string) (*models.WordEntry, error)
+}
+
+// SetPhraseRepository はページの対訳フレーズのリポジトリを設定する（リーチの学び直しの教材に使用）
+func (s *SRSService) SetPhraseRepository(repo repository.PhraseRepository) {
+	s.phraseRepo = repo
+}
+
+// SetPatternRepository はパターンのリポジトリを設定する（リーチの学び直しの教材に使用）
+func (s *SRSService) SetPatternRepository(repo repository.PatternRepositoryInterface) {
+	s.patternRepo = repo
+}
+
+// SetDictionary は辞書を設定する（リーチの学び直しの教材に使用）
+func (s *SRSService) SetDictionary(dictionary DictionaryLookup) {
+	s.dictionary = dictionary
+}
+
+// isLapse は復習のスコアが忘却（もう一度）かどうかを返す
+func isLapse(score int) bool {
+	return srs.ScoreToRating(score) == srs.RatingAgain
+}
+
+// countLapses は since 以降の忘却の回数と最後の忘却の日時を返す
+// 初めての復習（まだ覚えていない項目）の失敗は忘却に数えない
+func countLapses(histories []*models.ReviewHistory, since time.Time) (int, time.Time) {
+	lapses := 0
+	var last time.Time
+	for i, history := range histories {
+		if i == 0 || history.ReviewedAt.Before(since) || !isLapse(history.Score) {
+			continue
+		}
+		lapses++
+		if history.ReviewedAt.After(last) {
+			last = history.ReviewedAt
+		}
+	}
+	return lapses, last
+}
+
+// detectLeech は now の忘却で復習項目がリーチになったかを判定し、なった場合はユーザーの設定に従って対処する
+// 判定の期間（leech_window_days）とリーチを解除した日時より前の忘却は数えない
+// すでにリーチの項目は、リーチになった日時（LeechedAt）を最初の日時のままにする
+func (s *SRSService) detectLeech(ctx context.Context, item *models.ReviewItem, now time.Time) {
+	if item.LeechedAt != nil {
+		return
+	}
+
+	settings, err := s.GetSettings(ctx, item.UserID)
+	if err != nil {
+		log.Printf("failed to load review settings for leech detection (user %s): %v", item.UserID, err)
+		return
+	}
+	histories, err := s.repo.FindHistoryByItemID(ctx, item.ID)
+	if err != nil {
+		log.Printf("failed to load review history for leech detection (item %s): %v", item.ID, err)
+		return
+	}
+
+	since := now.AddDate(0, 0, -settings.LeechWindowDays)
+	if item.LeechClearedAt != nil && item.LeechClearedAt.After(since) {
+		since = *item.LeechClearedAt
+	}
+	// 今回の忘却はまだ履歴に記録していないため1回加える
+	lapses, _ := countLapses(histories, since)
+	if lapses+1 < settings.LeechThreshold {
+		return
+	}
+
+	item.LeechedAt = &now
+	if settings.LeechAction == models.LeechActionSuspend {
+		item.Suspended = true
+	}
+}
+
+// Leeches はユーザーのリーチを忘却の多い順に返す（limit が0以下の場合はすべて）
+func (s *SRSService) Leeches(ctx context.Context, userID string, limit int) ([]models.LeechItem, error) {
+	items, err := s.repo.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	histories, err := s.repo.FindHistoryByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	byItem := make(map[string][]*models.ReviewHistory)
+	for _, history := range histories {
+		byItem[history.ReviewItemID] = append(byItem[history.ReviewItemID], history)
+	}
+
+	leeches := make([]models.LeechItem, 0)
+	for _, item := range items {
+		if item.LeechedAt == nil {
+			continue
+		}
+		lapses, lastLapse := countLapses(byItem[item.ID], time.Time{})
+		leeches = append(leeches, models.LeechItem{
+			ItemID:      item.ID,
+			Text:        item.Text,
+			Translation: item.Translation,
+			Type:        item.Type,
+			CardType:    item.CardType,
+			Language:    item.Language,
+			Lapses:      lapses,
+			Suspended:   item.Suspended,
+			LeechedAt:   *item.LeechedAt,
+			LastLapseAt: lastLapse,
+		})
+	}
+
+	sort.SliceStable(leeches, func(i, j int) bool {
+		if leeches[i].Lapses != leeches[j].Lapses {
+			return leeches[i].Lapses > leeches[j].Lapses
+		}
+		return leeches[i].LeechedAt.After(leeches[j].LeechedAt)
+	})
+	if limit > 0 && len(leeches) > limit {
+		leeches = leeches[:limit]
+	}
+	return leeches, nil
+}
+
+// ClearLeech は学び直したリーチを解除し、出題を再開する（すぐに復習する）
+// 解除より前の忘却は、以降のリーチの判定に数えない
+func (s *SRSService) ClearLeech(ctx context.Context, userID string, itemID string, now time.Time) (*models.ReviewItem, error) {
+	item, err := s.ownedItem(ctx, userID, itemID)
+	if err != nil {
+		return nil, err
+	}
+
+	item.Suspended = false
+	item.LeechedAt = nil
+	item.LeechClearedAt = &now
+	item.NextReview = now
+	if err := s.repo.Update(ctx, item); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// Remediation はリーチを学び直すための教材（元のページ・辞書の項目・パターンの使用例）を集める
+// 教材の取得に失敗した場合は、その教材を省いて返す
+func (s *SRSService) Remediation(ctx context.Context, userID string, itemID string) (*models.LeechRemediation, error) {
+	item, err := s.ownedItem(ctx, userID, itemID)
+	if err != nil {
+		return nil, err
+	}
+	remediation := &models.LeechRemediation{Item: item}
+
+	bookID, bookErr := uuid.Parse(item.BookID)
+	if bookErr == nil && item.PageNumber > 0 && s.phraseRepo != nil {
+		phrases, err := s.phraseRepo.FindByPage(ctx, bookID, item.PageNumber)
+		if err != nil {
+			log.Printf("failed to load source page for review item %s: %v", item.ID, err)
+		} else {
+			remediation.Page = &models.LeechSourcePage{BookID: item.BookID, PageNumber: item.PageNumber, Phrases: phrases}
+		}
+	}
+
+	if item.Type == models.ReviewItemTypeWord && s.dictionary != nil {
+		entry, err := s.dictionary.LookupWord(ctx, item.Text, item.Language)
+		if err != nil {
+			log.Printf("failed to look up dictionary entry for review item %s: %v", item.ID, err)
+		} else {
+			remediation.Dictionary = entry
+		}
+	}
+
+	if item.Type == models.ReviewItemTypePattern && bookErr == nil && s.patternRepo != nil {
+		examples, err := s.patternExamples(ctx, bookID, item.Text)
+		if err != nil {
+			log.Printf("failed to load pattern examples for review item %s: %v", item.ID, err)
+		}
+		remediation.PatternExamples = examples
+	}
+
+	return remediation, nil
+}
+
+// patternExamples は本のパターンのうちテキストが一致するものの使用例を返す
+func (s *SRSService) patternExamples(ctx context.Context, bookID uuid.UUID, text string) ([]models.PatternExample, error) {
+	patterns, err := s.patternRepo.GetPatternsByBookID(ctx, bookID)
+	if err != nil {
+		return nil, err
+	}
+	for _, pattern := range patterns {
+		if strings.EqualFold(pattern.Pattern, text) {
+			return s.patternRepo.GetPatternExamples(ctx, pattern.ID, remediationExamples)
+		}
+	}
+	return nil, nil
+}
+
+// ownedItem はユーザーの復習項目を取得する
+func (s *SRSService) ownedItem(ctx context.Context, userID string, itemID string) (*models.ReviewItem, error) {
+	item, err := s.repo.FindByID(ctx, itemID)
+	if err != nil {
+		return nil, err
+	}
+	if item == nil {
+		return nil, repository.ErrReviewItemNotFound
+	}
+	if item.UserID != userID {
+		return nil, ErrForbidden
+	}
+	return item, nil
+}
@@ -0,0 +1,171 @@
+package srs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/clearclown/HaiLanGo/backend/internal/models"
+	"github.com/clearclown/HaiLanGo/backend/internal/repository"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeDictionary はテスト用の辞書
+type fakeDictionary struct{}
+
+func (fakeDictionary) LookupWord(ctx context.Context, word string, language string) (*models.WordEntry, error) {
+	return &models.WordEntry{Word: word, Language: language}, nil
+}
+
+// lapse は復習項目を忘れた（スコア30）として復習する
+func lapse(t *testing.T, service *SRSService, item *models.ReviewItem) *models.ReviewItem {
+	t.Helper()
+	updated, err := service.CompleteReview(context.Background(), item.UserID, item.ID, 30, 5)
+	require.NoError(t, err)
+	return updated
+}
+
+// TestLeechDetection_Suspend は忘却の回数によるリーチの判定と出題の停止・解除をテスト
+func TestLeechDetection_Suspend(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewInMemoryReviewRepository()
+	service := NewSRSService(repo)
+
+	userID := uuid.New().String()
+	threshold := 3
+	_, err := service.UpdateSettings(ctx, userID, &models.UpdateReviewSettingsRequest{
+		LeechThreshold: &threshold, LeechAction: models.LeechActionSuspend,
+	})
+	require.NoError(t, err)
+
+	item := newTestReviewItem(t, repo, userID, time.Now().Add(-time.Hour))
+
+	// 初めての復習の失敗は忘却に数えない
+	item = lapse(t, service, item)
+	item = lapse(t, service, item)
+	item = lapse(t, service, item)
+	assert.Nil(t, item.LeechedAt)
+	assert.False(t, item.Suspended)
+
+	item = lapse(t, service, item)
+	require.NotNil(t, item.LeechedAt)
+	assert.True(t, item.Suspended)
+
+	// 出題を停止した項目は今日の出題に含めない
+	queue, err := service.BuildQueue(ctx, userID, time.Now().AddDate(0, 0, 1))
+	require.NoError(t, err)
+	assert.Empty(t, queue.Items)
+
+	leeches, err := service.Leeches(ctx, userID, 10)
+	require.NoError(t, err)
+	require.Len(t, leeches, 1)
+	assert.Equal(t, item.ID, leeches[0].ItemID)
+	assert.Equal(t, 3, leeches[0].Lapses)
+	assert.True(t, leeches[0].Suspended)
+
+	// 解除すると出題を再開し、解除より前の忘却は数えない
+	cleared, err := service.ClearLeech(ctx, userID, item.ID, time.Now())
+	require.NoError(t, err)
+	assert.False(t, cleared.Suspended)
+	assert.Nil(t, cleared.LeechedAt)
+
+	item = lapse(t, service, cleared)
+	assert.Nil(t, item.LeechedAt)
+	leeches, err = service.Leeches(ctx, userID, 10)
+	require.NoError(t, err)
+	assert.Empty(t, leeches)
+
+	_, err = service.ClearLeech(ctx, uuid.New().String(), item.ID, time.Now())
+	assert.ErrorIs(t, err, ErrForbidden)
+}
+
+// TestLeechDetection_Reteach はリーチの学び直し（出題を続け、教材を集める）をテスト
+func TestLeechDetection_Reteach(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewInMemoryReviewRepository()
+	service := NewSRSService(repo)
+
+	bookID := uuid.New()
+	phraseRepo := repository.NewInMemoryPhraseRepository()
+	require.NoError(t, phraseRepo.ReplacePagePhrases(ctx, uuid.New(), []*models.PhraseRecord{
+		{ID: uuid.New(), BookID: bookID, PageNumber: 4, Position: 0, Text: "Я читаю книгу.", Translation: "私は本を読んでいる。"},
+	}))
+	service.SetPhraseRepository(phraseRepo)
+	service.SetDictionary(fakeDictionary{})
+
+	userID := uuid.New().String()
+	cards, err := service.AddReviewItems(ctx, userID, []*models.ReviewItem{
+		{BookID: bookID.String(), PageNumber: 4, Type: models.ReviewItemTypeWord, Text: "книга", Translation: "本", Language: "ru"},
+	})
+	require.NoError(t, err)
+	item := findCard(t, cards, models.CardTypeRecognition)
+
+	_, err = service.CompleteReview(ctx, userID, item.ID, 80, 5)
+	require.NoError(t, err)
+	for i := 0; i < DefaultLeechThreshold; i++ {
+		item = lapse(t, service, item)
+	}
+	require.NotNil(t, item.LeechedAt)
+	assert.False(t, item.Suspended, "reteach keeps the item in the queue")
+
+	// 学び直し中の忘却ではリーチになった日時を更新しない
+	leechedAt := *item.LeechedAt
+	item = lapse(t, service, item)
+	require.NotNil(t, item.LeechedAt)
+	assert.Equal(t, leechedAt, *item.LeechedAt)
+
+	remediation, err := service.Remediation(ctx, userID, item.ID)
+	require.NoError(t, err)
+	require.NotNil(t, remediation.Page)
+	assert.Equal(t, 4, remediation.Page.PageNumber)
+	require.Len(t, remediation.Page.Phrases, 1)
+	assert.Equal(t, "Я читаю книгу.", remediation.Page.Phrases[0].Text)
+	require.NotNil(t, remediation.Dictionary)
+	assert.Equal(t, "книга", remediation.Dictionary.Word)
+	assert.Empty(t, remediation.PatternExamples)
+}
+
+// TestRemediation_PatternExamples はパターンの項目の使用例をテスト
+func TestRemediation_PatternExamples(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewInMemoryReviewRepository()
+	service := NewSRSService(repo)
+
+	patternRepo := repository.NewInMemoryPatternRepository()
+	bookID := uuid.MustParse("550e8400-e29b-41d4-a716-446655440001")
+	service.SetPatternRepository(patternRepo)
+
+	userID := uuid.New().String()
+	cards, err := service.AddReviewItems(ctx, userID, []*models.ReviewItem{
+		{BookID: bookID.String(), Type: models.ReviewItemTypePattern, Text: "Здравствуйте!", Translation: "こんにちは！"},
+	})
+	require.NoError(t, err)
+
+	remediation, err := service.Remediation(ctx, userID, cards[0].ID)
+	require.NoError(t, err)
+	assert.Nil(t, remediation.Page)
+	assert.Nil(t, remediation.Dictionary)
+	require.NotEmpty(t, remediation.PatternExamples)
+	assert.Contains(t, remediation.PatternExamples[0].OriginalText, "Здравствуйте")
+}
+
+// TestUpdateSettings_Leech はリーチの設定の検証をテスト
+func TestUpdateSettings_Leech(t *testing.T) {
+	ctx := context.Background()
+	service := NewSRSService(repository.NewInMemoryReviewRepository())
+	userID := uuid.New().String()
+
+	settings, err := service.GetSettings(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, DefaultLeechThreshold, settings.LeechThreshold)
+	assert.Equal(t, DefaultLeechWindowDays, settings.LeechWindowDays)
+	assert.Equal(t, models.LeechActionReteach, settings.LeechAction)
+
+	_, err = service.UpdateSettings(ctx, userID, &models.UpdateReviewSettingsRequest{LeechAction: "delete"})
+	assert.ErrorIs(t, err, ErrInvalidLeechSettings)
+	zero := 0
+	_, err = service.UpdateSettings(ctx, userID, &models.UpdateReviewSettingsRequest{LeechWindowDays: &zero})
+	assert.ErrorIs(t, err, ErrInvalidLeechSettings)
+}
@@ -0,0 +1,132 @@
+package srs
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/clearclown/HaiLanGo/backend/pkg/srs"
+)
+
+// DefaultOptimizeInterval はFSRSのパラメータを定期的に最適化する間隔
+const DefaultOptimizeInterval = 24 * time.Hour
+
+// FitFSRS はユーザーの復習ログからFSRSの重みを最適化する（保存はしない）
+// 以前に最適化した重みがあればそこから調整する
+func (s *SRSService) FitFSRS(ctx context.Context, userID string) (*srs.FSRSOptimizationResult, error) {
+	settings, err := s.GetSettings(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	histories, err := s.repo.FindHistoryByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	reviews := make([]srs.FSRSReview, 0, len(histories))
+	for _, history := range histories {
+		reviews = append(reviews, srs.FSRSReview{
+			ItemID:     history.ReviewItemID,
+			Score:      history.Score,
+			ReviewedAt: history.ReviewedAt,
+		})
+	}
+
+	return srs.OptimizeFSRS(reviews, settings.FSRSWeights)
+}
+
+// OptimizeFSRS はユーザーの復習ログからFSRSの重みを最適化して保存する
+// 選択中のアルゴリズムは変更しない（FSRSを選択したときに最適化した重みを使用する）
+func (s *SRSService) OptimizeFSRS(ctx context.Context, userID string) (*srs.FSRSOptimizationResult, error) {
+	result, err := s.FitFSRS(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 最適化には時間がかかるため、その間にユーザーが変更した他の設定を上書きしないよう重みだけを更新する
+	now := time.Now()
+	saved, err := s.repo.SaveFSRSWeights(ctx, userID, result.Weights, now)
+	if err != nil {
+		return nil, err
+	}
+	if saved {
+		return result, nil
+	}
+
+	// 設定を保存していないユーザーはデフォルトの設定に重みを加えて保存する
+	settings, err := s.GetSettings(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	settings.FSRSWeights = result.Weights
+	settings.OptimizedAt = &now
+	settings.UpdatedAt = now
+
+	if err := s.repo.SaveSettings(ctx, settings); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// OptimizeReviewedUsers は since 以降に復習したユーザーのFSRSの重みを最適化し、最適化した人数を返す
+// 復習ログが足りないユーザーと、複数のサーバーで動かしている場合に他のサーバーが since より後に最適化を始めたユーザーはスキップする
+func (s *SRSService) OptimizeReviewedUsers(ctx context.Context, since, now time.Time) (int, error) {
+	userIDs, err := s.repo.FindReviewedUserIDs(ctx, since)
+	if err != nil {
+		return 0, err
+	}
+
+	optimized := 0
+	for _, userID := range userIDs {
+		if ctx.Err() != nil {
+			return optimized, ctx.Err()
+		}
+
+		claimed, err := s.repo.ClaimOptimization(ctx, userID, since, now)
+		if err != nil {
+			log.Printf("failed to claim fsrs optimization for user %s: %v", userID, err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+
+		result, err := s.OptimizeFSRS(ctx, userID)
+		if errors.Is(err, srs.ErrNotEnoughReviews) {
+			continue
+		}
+		if err != nil {
+			log.Printf("failed to optimize fsrs parameters for user %s: %v", userID, err)
+			continue
+		}
+
+		log.Printf("optimized fsrs parameters for user %s (%d reviews, log loss %.4f -> %.4f)",
+			userID, result.ReviewCount, result.InitialLoss, result.Loss)
+		optimized++
+	}
+
+	return optimized, nil
+}
+
+// RunOptimizer は interval ごとに、前回以降に復習したユーザーのFSRSの重みを最適化する
+// ctx がキャンセルされるまでブロックする
+func (s *SRSService) RunOptimizer(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	since := time.Now().Add(-interval)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if _, err := s.OptimizeReviewedUsers(ctx, since, now); err != nil && ctx.Err() == nil {
+				log.Printf("failed to run fsrs optimizer: %v", err)
+				continue
+			}
+			since = now
+		}
+	}
+}
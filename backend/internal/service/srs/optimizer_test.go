@@ -0,0 +1,155 @@
+package srs
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/clearclown/HaiLanGo/backend/internal/models"
+	"github.com/clearclown/HaiLanGo/backend/internal/repository"
+	"github.com/clearclown/HaiLanGo/backend/pkg/srs"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// saveTestHistories はテスト用に items 項目 × reviews 回分の復習ログを保存する
+func saveTestHistories(t *testing.T, repo repository.ReviewRepository, userID string, items, reviews int, start time.Time) {
+	t.Helper()
+
+	for item := 0; item < items; item++ {
+		reviewedAt := start.Add(time.Duration(item) * time.Hour)
+		for review := 0; review < reviews; review++ {
+			score := 80
+			if (item+review)%4 == 0 {
+				score = 30
+			}
+			require.NoError(t, repo.SaveHistory(context.Background(), &models.ReviewHistory{
+				ReviewItemID: fmt.Sprintf("item-%d", item),
+				UserID:       userID,
+				Score:        score,
+				ReviewedAt:   reviewedAt,
+			}))
+			reviewedAt = reviewedAt.AddDate(0, 0, 2*(review+1))
+		}
+	}
+}
+
+// TestOptimizeFSRS は復習ログからのFSRSの重みの最適化と保存をテスト
+func TestOptimizeFSRS(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewInMemoryReviewRepository()
+	service := NewSRSService(repo)
+
+	userID := uuid.New().String()
+	saveTestHistories(t, repo, userID, 30, 5, time.Now().AddDate(0, -3, 0))
+
+	result, err := service.OptimizeFSRS(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, 120, result.ReviewCount)
+	assert.LessOrEqual(t, result.Loss, result.InitialLoss)
+
+	// 重みは保存されるが、選択中のアルゴリズムは変更しない
+	settings, err := service.GetSettings(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, result.Weights, settings.FSRSWeights)
+	assert.NotNil(t, settings.OptimizedAt)
+	assert.Equal(t, string(srs.DefaultAlgorithm), settings.Algorithm)
+
+	// FSRSを選択すると最適化した重みのスケジューラーを使用する
+	_, err = service.UpdateAlgorithm(ctx, userID, string(srs.AlgorithmFSRS))
+	require.NoError(t, err)
+	scheduler, err := service.Scheduler(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, srs.AlgorithmFSRS, scheduler.Algorithm())
+	state := scheduler.Schedule(srs.NewState(), 75, time.Now())
+	assert.InDelta(t, result.Weights[2], state.Stability, 1e-9)
+
+	// 保存済みの設定は重みと最適化した日時だけを更新し、他の設定を変更しない
+	newCards := 5
+	_, err = service.UpdateSettings(ctx, userID, &models.UpdateReviewSettingsRequest{NewCardsPerDay: &newCards})
+	require.NoError(t, err)
+	optimizedAt := *settings.OptimizedAt
+	_, err = service.OptimizeFSRS(ctx, userID)
+	require.NoError(t, err)
+	settings, err = service.GetSettings(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, string(srs.AlgorithmFSRS), settings.Algorithm)
+	assert.Equal(t, 5, settings.NewCardsPerDay)
+	assert.True(t, settings.OptimizedAt.After(optimizedAt))
+
+	// 復習ログが足りない場合は保存しない
+	otherID := uuid.New().String()
+	saveTestHistories(t, repo, otherID, 5, 3, time.Now().AddDate(0, -1, 0))
+	_, err = service.OptimizeFSRS(ctx, otherID)
+	assert.ErrorIs(t, err, srs.ErrNotEnoughReviews)
+	settings, err = service.GetSettings(ctx, otherID)
+	require.NoError(t, err)
+	assert.Empty(t, settings.FSRSWeights)
+}
+
+// TestOptimizeReviewedUsers は期間内に復習したユーザーの一括最適化をテスト
+func TestOptimizeReviewedUsers(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewInMemoryReviewRepository()
+	service := NewSRSService(repo)
+
+	now := time.Now()
+	saveTestHistories(t, repo, uuid.New().String(), 30, 5, now.AddDate(0, -3, 0))
+	saveTestHistories(t, repo, uuid.New().String(), 5, 3, now.AddDate(0, -3, 0)) // 復習ログが足りない
+
+	since, tick := now.AddDate(0, -6, 0), now.AddDate(0, -5, 0)
+	optimized, err := service.OptimizeReviewedUsers(ctx, since, tick)
+	require.NoError(t, err)
+	assert.Equal(t, 1, optimized)
+
+	// 同じ期間を他のサーバーが実行しても最適化し直さない
+	replica := NewSRSService(repo)
+	optimized, err = replica.OptimizeReviewedUsers(ctx, since.Add(time.Minute), tick.Add(time.Minute))
+	require.NoError(t, err)
+	assert.Zero(t, optimized)
+
+	// 次の期間は最適化する
+	optimized, err = service.OptimizeReviewedUsers(ctx, tick, now.AddDate(0, -4, 0))
+	require.NoError(t, err)
+	assert.Equal(t, 1, optimized)
+
+	// 期間内に復習したユーザーがいない場合
+	optimized, err = service.OptimizeReviewedUsers(ctx, now.AddDate(1, 0, 0), now.AddDate(1, 0, 1))
+	require.NoError(t, err)
+	assert.Zero(t, optimized)
+}
+
+// TestCompleteReview_FSRS はFSRSでの復習完了と想起確率の予測をテスト
+func TestCompleteReview_FSRS(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewInMemoryReviewRepository()
+	service := NewSRSService(repo)
+
+	userID := uuid.New().String()
+	_, err := service.UpdateAlgorithm(ctx, userID, string(srs.AlgorithmFSRS))
+	require.NoError(t, err)
+
+	reviewed := newTestReviewItem(t, repo, userID, time.Now())
+	fresh := newTestReviewItem(t, repo, userID, time.Now())
+
+	updated, err := service.CompleteReview(ctx, userID, reviewed.ID, 75, 10)
+	require.NoError(t, err)
+	assert.Greater(t, updated.Stability, 0.0)
+	assert.Greater(t, updated.Difficulty, 0.0)
+	assert.Equal(t, 1, updated.ReviewCount)
+
+	// 次の復習日時には想起確率が目標（90%）付近まで下がっている（負荷分散で前後1日ずれる分を許容する）
+	due, err := service.GetDueItems(ctx, userID, updated.NextReview)
+	require.NoError(t, err)
+	require.Len(t, due, 2)
+	for _, item := range due {
+		if item.ID == fresh.ID {
+			assert.Nil(t, item.PredictedRetention) // 未復習の項目は推定しない
+			continue
+		}
+		require.NotNil(t, item.PredictedRetention)
+		assert.InDelta(t, srs.DefaultDesiredRetention, *item.PredictedRetention, 0.03)
+	}
+}
@@ -0,0 +1,231 @@
+package srs
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/clearclown/HaiLanGo/backend/internal/models"
+)
+
+const (
+	// DefaultNewCardsPerDay は1日に新しく学習する項目数の上限のデフォルト
+	DefaultNewCardsPerDay = 20
+	// DefaultReviewsPerDay は1日に復習する項目数の上限のデフォルト
+	DefaultReviewsPerDay = 200
+
+	// interleaveWindow は出題順を入れ替えるときに先読みする項目数
+	// 優先度の低い項目が大きく前に出ないよう、近くの項目とだけ入れ替える
+	interleaveWindow = 10
+)
+
+// ReviewQueue は今日の復習セッションで出題する順に並べた復習項目
+type ReviewQueue struct {
+	Items       []*models.ReviewItem `json:"items"`
+	NewCount    int                  `json:"new_count"`    // 新しく学習する項目の数
+	ReviewCount int                  `json:"review_count"` // 復習する項目の数
+	// DeferredNewCount と DeferredReviewCount は上限を超えたため明日以降に回した項目の数
+	DeferredNewCount    int `json:"deferred_new_count"`
+	DeferredReviewCount int `json:"deferred_review_count"`
+	// BuriedCount は同じ学習項目の別のカードを出題するため明日以降に回したカードの数
+	BuriedCount int `json:"buried_count"`
+}
+
+// BuildQueue は今日の復習セッションの出題順を作成する
+// 期限を迎えた復習項目と未学習の項目を、ユーザーの1日の上限（今日すでに出題した分を除く）まで選び、
+// 未学習の項目を復習項目の間に均等に混ぜたうえで、種類（単語・フレーズ・パターン）や本が続かないように並べる
+// 同じ学習項目のカード（認識・産出・聞き取り・発音）は答えが分かってしまうため、1日に1枚だけ出題する
+func (s *SRSService) BuildQueue(ctx context.Context, userID string, now time.Time) (*ReviewQueue, error) {
+	settings, err := s.GetSettings(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := s.repo.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	todayStart := startOfDay(settings, now)
+	completedToday, err := s.repo.CountCompletedToday(ctx, userID, todayStart)
+	if err != nil {
+		return nil, err
+	}
+	introducedToday, err := s.repo.CountIntroducedSince(ctx, userID, todayStart)
+	if err != nil {
+		return nil, err
+	}
+
+	newLimit := settings.NewCardsPerDay - introducedToday
+	reviewLimit := settings.ReviewsPerDay - (completedToday - introducedToday)
+
+	return buildQueue(items, newLimit, reviewLimit, todayStart, now), nil
+}
+
+// startOfDay は now のユーザーのタイムゾーン（ReminderTimezone）での今日の始まりを返す
+// 1日の上限と今日の復習は、ユーザーの日付で数える（タイムゾーンを読み込めない場合はUTC）
+func startOfDay(settings *models.ReviewSettings, now time.Time) time.Time {
+	location, err := time.LoadLocation(settings.ReminderTimezone)
+	if err != nil {
+		location = time.UTC
+	}
+	local := now.In(location)
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, location)
+}
+
+// buildQueue は復習項目から出題順を作成する（todayStart はユーザーのタイムゾーンでの今日の始まり）
+func buildQueue(items []*models.ReviewItem, newLimit, reviewLimit int, todayStart, now time.Time) *ReviewQueue {
+	// 今日すでにカードを復習した学習項目
+	reviewedToday := make(map[string]bool)
+	for _, item := range items {
+		if item.ReviewCount > 0 && !item.LastReviewed.Before(todayStart) {
+			reviewedToday[sourceOf(item)] = true
+		}
+	}
+
+	tomorrowStart := todayStart.AddDate(0, 0, 1)
+	var newItems, dueItems []*models.ReviewItem
+	for _, item := range items {
+		// 出題を停止したリーチと、復習予定が明日以降の項目は出題しない
+		if item.Suspended || !item.NextReview.Before(tomorrowStart) {
+			continue
+		}
+		Annotate(item, now)
+		if item.ReviewCount == 0 {
+			newItems = append(newItems, item)
+		} else {
+			dueItems = append(dueItems, item)
+		}
+	}
+
+	// 復習項目は忘れている可能性が高い順、未学習の項目は追加した順（本・ページ順）
+	sort.SliceStable(dueItems, func(i, j int) bool {
+		ri, rj := retentionOf(dueItems[i]), retentionOf(dueItems[j])
+		if ri != rj {
+			return ri < rj
+		}
+		return dueItems[i].NextReview.Before(dueItems[j].NextReview)
+	})
+	sort.SliceStable(newItems, func(i, j int) bool {
+		a, b := newItems[i], newItems[j]
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.Before(b.CreatedAt)
+		}
+		if a.BookID != b.BookID {
+			return a.BookID < b.BookID
+		}
+		return a.PageNumber < b.PageNumber
+	})
+
+	// 復習項目を優先し、学習項目ごとに最初のカードだけを残す
+	queue := &ReviewQueue{}
+	dueItems, queue.BuriedCount = burySiblings(dueItems, reviewedToday)
+	newItems, buriedNew := burySiblings(newItems, reviewedToday)
+	queue.BuriedCount += buriedNew
+
+	dueItems, queue.DeferredReviewCount = limitItems(dueItems, reviewLimit)
+	newItems, queue.DeferredNewCount = limitItems(newItems, newLimit)
+	queue.ReviewCount = len(dueItems)
+	queue.NewCount = len(newItems)
+	queue.Items = interleave(mixNewItems(dueItems, newItems))
+
+	return queue
+}
+
+// burySiblings は buried に含まれない学習項目のカードを学習項目ごとに1枚だけ残し、残りの枚数を返す
+// 残したカードの学習項目は buried に追加する
+func burySiblings(items []*models.ReviewItem, buried map[string]bool) ([]*models.ReviewItem, int) {
+	kept := make([]*models.ReviewItem, 0, len(items))
+	for _, item := range items {
+		source := sourceOf(item)
+		if buried[source] {
+			continue
+		}
+		buried[source] = true
+		kept = append(kept, item)
+	}
+	return kept, len(items) - len(kept)
+}
+
+// retentionOf は想起確率の予測を返す（推定できない項目は最優先にするため0）
+func retentionOf(item *models.ReviewItem) float64 {
+	if item.PredictedRetention == nil {
+		return 0
+	}
+	return *item.PredictedRetention
+}
+
+// limitItems は先頭から limit 件を返し、残りの件数を返す
+func limitItems(items []*models.ReviewItem, limit int) ([]*models.ReviewItem, int) {
+	if limit < 0 {
+		limit = 0
+	}
+	if len(items) <= limit {
+		return items, 0
+	}
+	return items[:limit], len(items) - limit
+}
+
+// mixNewItems は未学習の項目を復習項目の間に均等に混ぜる
+func mixNewItems(dueItems, newItems []*models.ReviewItem) []*models.ReviewItem {
+	total := len(dueItems) + len(newItems)
+	mixed := make([]*models.ReviewItem, 0, total)
+
+	newIndex, dueIndex := 0, 0
+	for i := 0; i < total; i++ {
+		// i 番目までに出題しておきたい未学習の項目の数（各区間の中央に配置する）
+		wantNew := ((2*i+1)*len(newItems) + total) / (2 * total)
+		if dueIndex >= len(dueItems) || (newIndex < len(newItems) && newIndex < wantNew) {
+			mixed = append(mixed, newItems[newIndex])
+			newIndex++
+		} else {
+			mixed = append(mixed, dueItems[dueIndex])
+			dueIndex++
+		}
+	}
+
+	return mixed
+}
+
+// interleave は同じ種類・同じ本の項目が続かないように出題順を入れ替える
+// 直前の項目と種類も本も異なる項目、種類か本が異なる項目の順に、interleaveWindow 件先まで探す
+func interleave(items []*models.ReviewItem) []*models.ReviewItem {
+	remaining := append([]*models.ReviewItem(nil), items...)
+	result := make([]*models.ReviewItem, 0, len(items))
+
+	for len(remaining) > 0 {
+		pick := 0
+		if len(result) > 0 {
+			pick = pickNext(result[len(result)-1], remaining)
+		}
+		result = append(result, remaining[pick])
+		remaining = append(remaining[:pick], remaining[pick+1:]...)
+	}
+
+	return result
+}
+
+// pickNext は直前の項目 prev の次に出題する項目の位置を返す
+func pickNext(prev *models.ReviewItem, candidates []*models.ReviewItem) int {
+	window := len(candidates)
+	if window > interleaveWindow {
+		window = interleaveWindow
+	}
+
+	partial := -1
+	for i := 0; i < window; i++ {
+		sameType := candidates[i].Type == prev.Type
+		sameBook := candidates[i].BookID == prev.BookID
+		if !sameType && !sameBook {
+			return i
+		}
+		if partial < 0 && (!sameType || !sameBook) {
+			partial = i
+		}
+	}
+
+	if partial >= 0 {
+		return partial
+	}
+	return 0
+}
@@ -0,0 +1,203 @@
+package srs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/clearclown/HaiLanGo/backend/internal/models"
+	"github.com/clearclown/HaiLanGo/backend/internal/repository"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newQueueTestItem は出題順のテスト用の復習項目を作成する
+func newQueueTestItem(id, itemType, bookID string, reviewCount int, nextReview time.Time) *models.ReviewItem {
+	item := &models.ReviewItem{
+		ID:           id,
+		BookID:       bookID,
+		Type:         itemType,
+		IntervalDays: 1,
+		ReviewCount:  reviewCount,
+		NextReview:   nextReview,
+	}
+	if reviewCount > 0 {
+		item.LastReviewed = nextReview.AddDate(0, 0, -1)
+	}
+	return item
+}
+
+// TestBuildQueue_Limits は1日の上限と出題順をテスト
+func TestBuildQueue_Limits(t *testing.T) {
+	now := time.Date(2025, 11, 13, 12, 0, 0, 0, time.UTC)
+
+	var items []*models.ReviewItem
+	for i := 0; i < 6; i++ {
+		// 期限切れが長い項目ほど想起確率が低い
+		items = append(items, newQueueTestItem(
+			string(rune('a'+i)), "word", "book", 1, now.Add(-time.Duration(i)*time.Hour*12)))
+	}
+	for i := 0; i < 4; i++ {
+		item := newQueueTestItem(string(rune('A'+i)), "word", "book", 0, now)
+		item.CreatedAt = now.Add(time.Duration(i) * time.Minute)
+		items = append(items, item)
+	}
+	items = append(items, newQueueTestItem("future", "word", "book", 1, now.AddDate(0, 0, 2)))
+
+	queue := buildQueue(items, 2, 4, startOfDay(&models.ReviewSettings{}, now), now)
+	assert.Equal(t, 4, queue.ReviewCount)
+	assert.Equal(t, 2, queue.NewCount)
+	assert.Equal(t, 2, queue.DeferredReviewCount)
+	assert.Equal(t, 2, queue.DeferredNewCount)
+	require.Len(t, queue.Items, 6)
+
+	var reviewIDs, newIDs []string
+	for i, item := range queue.Items {
+		if item.ReviewCount == 0 {
+			newIDs = append(newIDs, item.ID)
+			assert.NotZero(t, i, "新規項目は復習項目の間に混ぜる")
+			continue
+		}
+		reviewIDs = append(reviewIDs, item.ID)
+	}
+	assert.Equal(t, []string{"f", "e", "d", "c"}, reviewIDs) // 忘れている可能性が高い順
+	assert.Equal(t, []string{"A", "B"}, newIDs)              // 追加した順
+
+	// 上限に達している場合は出題しない
+	queue = buildQueue(items, 0, -3, startOfDay(&models.ReviewSettings{}, now), now)
+	assert.Empty(t, queue.Items)
+	assert.Equal(t, 6, queue.DeferredReviewCount)
+	assert.Equal(t, 4, queue.DeferredNewCount)
+}
+
+// TestBuildQueue_Interleave は種類・本が続かないように並べることをテスト
+func TestBuildQueue_Interleave(t *testing.T) {
+	now := time.Date(2025, 11, 13, 12, 0, 0, 0, time.UTC)
+
+	var items []*models.ReviewItem
+	for i, itemType := range []string{"word", "word", "word", "phrase", "phrase", "pattern"} {
+		bookID := "book-1"
+		if i%2 == 1 {
+			bookID = "book-2"
+		}
+		// 単語の期限切れが最も長い（単語が先頭にまとまる）
+		items = append(items, newQueueTestItem(
+			string(rune('a'+i)), itemType, bookID, 1, now.Add(-time.Duration(6-i)*time.Hour*12)))
+	}
+
+	queue := buildQueue(items, 10, 10, startOfDay(&models.ReviewSettings{}, now), now)
+	require.Len(t, queue.Items, 6)
+	for i := 1; i < len(queue.Items); i++ {
+		prev, item := queue.Items[i-1], queue.Items[i]
+		assert.False(t, prev.Type == item.Type && prev.BookID == item.BookID,
+			"%s と %s が同じ種類・同じ本", prev.ID, item.ID)
+	}
+	assert.Equal(t, "a", queue.Items[0].ID)
+}
+
+// TestBuildQueue_Timezone は今日の復習をユーザーのタイムゾーンの日付で判定することをテスト
+func TestBuildQueue_Timezone(t *testing.T) {
+	// 東京では11月14日の1時（UTCではまだ11月13日）
+	now := time.Date(2025, 11, 13, 16, 0, 0, 0, time.UTC)
+	tokyo := &models.ReviewSettings{ReminderTimezone: "Asia/Tokyo"}
+
+	start := startOfDay(tokyo, now)
+	assert.True(t, start.Equal(time.Date(2025, 11, 13, 15, 0, 0, 0, time.UTC)))
+
+	// 東京の前日（11月13日の23時）に同じ学習項目の別のカードを復習した
+	reviewed := newQueueTestItem("reviewed", "word", "book", 1, now.AddDate(0, 0, 3))
+	reviewed.SourceID = "source"
+	reviewed.LastReviewed = time.Date(2025, 11, 13, 14, 0, 0, 0, time.UTC)
+	due := newQueueTestItem("due", "word", "book", 1, now.Add(-time.Hour))
+	due.SourceID = "source"
+	// 東京の今日（11月14日）の20時が予定（UTCでは11月14日）
+	later := newQueueTestItem("later", "word", "book", 1, time.Date(2025, 11, 14, 11, 0, 0, 0, time.UTC))
+	items := []*models.ReviewItem{reviewed, due, later}
+
+	queue := buildQueue(items, 10, 10, start, now)
+	require.Len(t, queue.Items, 2)
+	assert.ElementsMatch(t, []string{"due", "later"}, []string{queue.Items[0].ID, queue.Items[1].ID})
+	assert.Zero(t, queue.BuriedCount)
+
+	// UTCでは同じ日に復習済みのため、同じ学習項目のカードを明日以降に回し、翌日の予定の項目も出題しない
+	queue = buildQueue(items, 10, 10, startOfDay(&models.ReviewSettings{ReminderTimezone: "UTC"}, now), now)
+	assert.Empty(t, queue.Items)
+	assert.Equal(t, 1, queue.BuriedCount)
+}
+
+// TestBuildQueue_CompletedToday は今日すでに出題した分を上限から除くことをテスト
+func TestBuildQueue_CompletedToday(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewInMemoryReviewRepository()
+	service := NewSRSService(repo)
+
+	userID := uuid.New().String()
+	newCards, reviews := 2, 3
+	_, err := service.UpdateSettings(ctx, userID, &models.UpdateReviewSettingsRequest{
+		NewCardsPerDay: &newCards,
+		ReviewsPerDay:  &reviews,
+	})
+	require.NoError(t, err)
+
+	now := time.Now()
+	for i := 0; i < 3; i++ {
+		newTestReviewItem(t, repo, userID, now)
+	}
+	for i := 0; i < 4; i++ {
+		item := newTestReviewItem(t, repo, userID, now.Add(-time.Hour))
+		item.ReviewCount = 1
+		item.LastReviewed = now.AddDate(0, 0, -2)
+		require.NoError(t, repo.Update(ctx, item))
+	}
+
+	queue, err := service.BuildQueue(ctx, userID, now)
+	require.NoError(t, err)
+	assert.Equal(t, 2, queue.NewCount)
+	assert.Equal(t, 3, queue.ReviewCount)
+
+	// 新規項目を1つ学習すると、新規項目の残りが1つ減る（復習の上限には数えない）
+	queue, err = service.BuildQueue(ctx, userID, now)
+	require.NoError(t, err)
+	var newItem *models.ReviewItem
+	for _, item := range queue.Items {
+		if item.ReviewCount == 0 {
+			newItem = item
+			break
+		}
+	}
+	require.NotNil(t, newItem)
+	_, err = service.CompleteReview(ctx, userID, newItem.ID, 30, 10)
+	require.NoError(t, err)
+
+	queue, err = service.BuildQueue(ctx, userID, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 1, queue.NewCount)
+	assert.Equal(t, 3, queue.ReviewCount)
+}
+
+// TestCompleteReview_LoadBalance は次回復習日を予定の少ない日にずらすことをテスト
+func TestCompleteReview_LoadBalance(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewInMemoryReviewRepository()
+	service := NewSRSService(repo)
+
+	userID := uuid.New().String()
+	now := time.Now()
+
+	// 6日後（5〜7日）のうち、5日後と6日後に予定が集中している
+	for _, days := range []int{5, 5, 6, 6, 6} {
+		newTestReviewItem(t, repo, userID, now.AddDate(0, 0, days))
+	}
+
+	item := newTestReviewItem(t, repo, userID, now)
+	item.ReviewCount = 1
+	item.IntervalDays = 1
+	require.NoError(t, repo.Update(ctx, item))
+
+	// SM-2の2回目は6日（間隔は変えずに次回復習日だけ7日後にずらす）
+	updated, err := service.CompleteReview(ctx, userID, item.ID, 90, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 6, updated.IntervalDays)
+	assert.Equal(t, now.AddDate(0, 0, 7).Format("2006-01-02"), updated.NextReview.Format("2006-01-02"))
+}
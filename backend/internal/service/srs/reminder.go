@@ -0,0 +1,216 @@
+package srs
+
+import (
+	"context"
+	"log"
+	"time"
+	// コンテナにタイムゾーンのデータがなくてもユーザーのタイムゾーンを扱えるようにする
+	_ "time/tzdata"
+
+	"github.com/clearclown/HaiLanGo/backend/internal/models"
+	"github.com/clearclown/HaiLanGo/backend/internal/service/notification"
+)
+
+const (
+	// DefaultReminderTime と DefaultReminderTimezone はリマインダーを送る時刻のデフォルト
+	DefaultReminderTime     = "09:00"
+	DefaultReminderTimezone = "UTC"
+	// DefaultReminderInterval はリマインダーを送るユーザーを確認する間隔
+	DefaultReminderInterval = time.Minute
+
+	// reminderItems はリマインダーに含める今日最初に出題する項目の数
+	reminderItems = 5
+)
+
+// reminderChannels は選択できるリマインダーの通知先
+var reminderChannels = map[string]bool{
+	models.ReminderChannelWebSocket: true,
+	models.ReminderChannelEmail:     true,
+	models.ReminderChannelPush:      true,
+}
+
+// SetReminderNotifiers はリマインダーの通知先を設定する
+// ユーザーが選択した通知先（Notifier.Channel）のものだけで送る
+func (s *SRSService) SetReminderNotifiers(notifiers ...notification.Notifier) {
+	s.notifiers = notifiers
+}
+
+// applyReminderDefaults はリマインダーの未設定の項目にデフォルトを設定する
+func applyReminderDefaults(settings *models.ReviewSettings) {
+	if settings.ReminderTime == "" {
+		settings.ReminderTime = DefaultReminderTime
+	}
+	if settings.ReminderTimezone == "" {
+		settings.ReminderTimezone = DefaultReminderTimezone
+	}
+	if len(settings.ReminderChannels) == 0 {
+		settings.ReminderChannels = []string{models.ReminderChannelWebSocket}
+	}
+}
+
+// validateReminderSettings はリマインダーの設定の変更を検証し、重複を除いた通知先を返す（変更しない場合は nil）
+func validateReminderSettings(req *models.UpdateReviewSettingsRequest) ([]string, error) {
+	if req.ReminderTime != "" {
+		if _, _, err := parseReminderTime(req.ReminderTime); err != nil {
+			return nil, ErrInvalidReminderSettings
+		}
+	}
+	if req.ReminderTimezone != "" {
+		if req.ReminderTimezone == "Local" {
+			return nil, ErrInvalidReminderSettings
+		}
+		if _, err := time.LoadLocation(req.ReminderTimezone); err != nil {
+			return nil, ErrInvalidReminderSettings
+		}
+	}
+	if req.ReminderChannels == nil {
+		return nil, nil
+	}
+
+	channels := make([]string, 0, len(req.ReminderChannels))
+	seen := make(map[string]bool)
+	for _, channel := range req.ReminderChannels {
+		if !reminderChannels[channel] {
+			return nil, ErrInvalidReminderSettings
+		}
+		if !seen[channel] {
+			seen[channel] = true
+			channels = append(channels, channel)
+		}
+	}
+	if len(channels) == 0 {
+		channels = append(channels, models.ReminderChannelWebSocket)
+	}
+	return channels, nil
+}
+
+// parseReminderTime は HH:MM の時刻を時・分に変換する
+func parseReminderTime(value string) (int, int, error) {
+	parsed, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, 0, err
+	}
+	return parsed.Hour(), parsed.Minute(), nil
+}
+
+// reminderDue は now がユーザーのタイムゾーンで今日のリマインダーの時刻を過ぎていて、今日まだ送っていないかを、今日の時刻とともに返す
+// サーバーが止まっていて時刻を過ぎた場合も、その日のうちであれば送る
+func reminderDue(settings *models.ReviewSettings, now time.Time) (time.Time, bool, error) {
+	location, err := time.LoadLocation(settings.ReminderTimezone)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	hour, minute, err := parseReminderTime(settings.ReminderTime)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+
+	local := now.In(location)
+	remindAt := time.Date(local.Year(), local.Month(), local.Day(), hour, minute, 0, 0, location)
+	if now.Before(remindAt) {
+		return remindAt, false, nil
+	}
+	return remindAt, settings.RemindedAt == nil || settings.RemindedAt.Before(remindAt), nil
+}
+
+// SendDueReminders はリマインダーの時刻を過ぎたユーザーに今日の復習の件数を通知し、通知した人数を返す
+// 今日の復習キューが空のユーザーには送らない
+func (s *SRSService) SendDueReminders(ctx context.Context, now time.Time) (int, error) {
+	candidates, err := s.repo.FindReminderSettings(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, settings := range candidates {
+		if ctx.Err() != nil {
+			return sent, ctx.Err()
+		}
+
+		applyReminderDefaults(settings)
+		remindAt, due, err := reminderDue(settings, now)
+		if err != nil {
+			log.Printf("invalid reminder settings for user %s: %v", settings.UserID, err)
+			continue
+		}
+		if !due {
+			continue
+		}
+
+		reminder, err := s.sendReminder(ctx, settings, remindAt, now)
+		if err != nil {
+			log.Printf("failed to send review reminder to user %s: %v", settings.UserID, err)
+			continue
+		}
+		if reminder != nil {
+			sent++
+		}
+	}
+
+	return sent, nil
+}
+
+// sendReminder はユーザーの今日の復習キューからリマインダーを作成し、ユーザーが選択した通知先で送る
+// 送る前に送ったことを記録し、複数のサーバーで動かしていても1回だけ送る
+// 通知先の一部が失敗しても同じ日に送り直さない（キューが空の日も送ったことにする）
+func (s *SRSService) sendReminder(ctx context.Context, settings *models.ReviewSettings, remindAt, now time.Time) (*models.ReviewReminder, error) {
+	userID := settings.UserID
+	claimed, err := s.repo.ClaimReminder(ctx, userID, remindAt, now)
+	if err != nil || !claimed {
+		return nil, err
+	}
+
+	queue, err := s.BuildQueue(ctx, userID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(queue.Items) == 0 {
+		return nil, nil
+	}
+
+	items := queue.Items
+	if len(items) > reminderItems {
+		items = items[:reminderItems]
+	}
+	reminder := &models.ReviewReminder{
+		UserID:   userID,
+		DueCount: len(queue.Items),
+		NewCount: queue.NewCount,
+		Items:    items,
+		SentAt:   now,
+	}
+
+	selected := make(map[string]bool)
+	for _, channel := range settings.ReminderChannels {
+		selected[channel] = true
+	}
+	for _, notifier := range s.notifiers {
+		if !selected[notifier.Channel()] {
+			continue
+		}
+		if err := notifier.NotifyReviewReminder(ctx, reminder); err != nil {
+			log.Printf("failed to send %s review reminder to user %s: %v", notifier.Channel(), userID, err)
+		}
+	}
+
+	return reminder, nil
+}
+
+// RunReminders は interval ごとにリマインダーの時刻を過ぎたユーザーにリマインダーを送る
+// ctx がキャンセルされるまでブロックする
+func (s *SRSService) RunReminders(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if _, err := s.SendDueReminders(ctx, now); err != nil && ctx.Err() == nil {
+				log.Printf("failed to send review reminders: %v", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package srs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/clearclown/HaiLanGo/backend/internal/models"
+	"github.com/clearclown/HaiLanGo/backend/internal/repository"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeNotifier はテスト用の通知先（送ったリマインダーを記録する）
+type fakeNotifier struct {
+	channel   string
+	reminders []*models.ReviewReminder
+}
+
+func (n *fakeNotifier) Channel() string {
+	return n.channel
+}
+
+func (n *fakeNotifier) NotifyReviewReminder(ctx context.Context, reminder *models.ReviewReminder) error {
+	n.reminders = append(n.reminders, reminder)
+	return nil
+}
+
+// TestSendDueReminders はユーザーのタイムゾーンの時刻に1日1回、選択した通知先だけでリマインダーを送ることをテスト
+func TestSendDueReminders(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewInMemoryReviewRepository()
+	service := NewSRSService(repo)
+
+	hub := &fakeNotifier{channel: models.ReminderChannelWebSocket}
+	email := &fakeNotifier{channel: models.ReminderChannelEmail}
+	push := &fakeNotifier{channel: models.ReminderChannelPush}
+	service.SetReminderNotifiers(hub, email, push)
+
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	require.NoError(t, err)
+	morning := time.Date(2026, 3, 10, 8, 30, 0, 0, tokyo)
+
+	userID := uuid.New().String()
+	for i := 0; i < 7; i++ {
+		newTestReviewItem(t, repo, userID, morning.AddDate(0, 0, -1))
+	}
+	enabled := true
+	_, err = service.UpdateSettings(ctx, userID, &models.UpdateReviewSettingsRequest{
+		ReminderEnabled:  &enabled,
+		ReminderTime:     "09:00",
+		ReminderTimezone: "Asia/Tokyo",
+		ReminderChannels: []string{models.ReminderChannelWebSocket, models.ReminderChannelPush, models.ReminderChannelPush},
+	})
+	require.NoError(t, err)
+
+	// リマインダーを無効にしたユーザー・復習する項目がないユーザーには送らない
+	otherID := uuid.New().String()
+	newTestReviewItem(t, repo, otherID, morning.AddDate(0, 0, -1))
+	emptyID := uuid.New().String()
+	_, err = service.UpdateSettings(ctx, emptyID, &models.UpdateReviewSettingsRequest{ReminderEnabled: &enabled})
+	require.NoError(t, err)
+
+	// 時刻の前は送らない
+	sent, err := service.SendDueReminders(ctx, morning)
+	require.NoError(t, err)
+	assert.Equal(t, 0, sent)
+
+	sent, err = service.SendDueReminders(ctx, morning.Add(31*time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	require.Len(t, hub.reminders, 1)
+	assert.Len(t, push.reminders, 1)
+	assert.Empty(t, email.reminders)
+
+	reminder := hub.reminders[0]
+	assert.Equal(t, userID, reminder.UserID)
+	assert.Equal(t, 7, reminder.DueCount)
+	assert.Len(t, reminder.Items, reminderItems)
+
+	// 同時に確認した他のサーバーは、送る前の設定を読んでいても送らない
+	replica := NewSRSService(repo)
+	replica.SetReminderNotifiers(hub, email, push)
+	stale := &models.ReviewSettings{UserID: userID, ReminderChannels: []string{models.ReminderChannelWebSocket}}
+	remindAt := time.Date(2026, 3, 10, 9, 0, 0, 0, tokyo)
+	duplicate, err := replica.sendReminder(ctx, stale, remindAt, morning.Add(31*time.Minute))
+	require.NoError(t, err)
+	assert.Nil(t, duplicate)
+	assert.Len(t, hub.reminders, 1)
+
+	// 設定を変更しても同じ日には送り直さない
+	_, err = service.UpdateSettings(ctx, userID, &models.UpdateReviewSettingsRequest{ReminderTime: "08:45"})
+	require.NoError(t, err)
+	sent, err = service.SendDueReminders(ctx, morning.Add(3*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 0, sent)
+
+	// 次の日の時刻を過ぎたらまた送る（UTCでは同じ日の23:00）
+	sent, err = service.SendDueReminders(ctx, morning.Add(14*time.Hour+30*time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, 0, sent)
+	sent, err = service.SendDueReminders(ctx, morning.Add(24*time.Hour+30*time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	assert.Len(t, hub.reminders, 2)
+}
+
+// TestUpdateSettings_Reminder はリマインダーの設定のデフォルトと検証をテスト
+func TestUpdateSettings_Reminder(t *testing.T) {
+	ctx := context.Background()
+	service := NewSRSService(repository.NewInMemoryReviewRepository())
+	userID := uuid.New().String()
+
+	settings, err := service.GetSettings(ctx, userID)
+	require.NoError(t, err)
+	assert.False(t, settings.ReminderEnabled)
+	assert.Equal(t, DefaultReminderTime, settings.ReminderTime)
+	assert.Equal(t, DefaultReminderTimezone, settings.ReminderTimezone)
+	assert.Equal(t, []string{models.ReminderChannelWebSocket}, settings.ReminderChannels)
+
+	invalid := []*models.UpdateReviewSettingsRequest{
+		{ReminderTime: "25:00"},
+		{ReminderTime: "9am"},
+		{ReminderTimezone: "Mars/Olympus_Mons"},
+		{ReminderTimezone: "Local"},
+		{ReminderChannels: []string{"sms"}},
+	}
+	for _, req := range invalid {
+		_, err := service.UpdateSettings(ctx, userID, req)
+		assert.ErrorIs(t, err, ErrInvalidReminderSettings)
+	}
+
+	// 通知先を空にするとWebSocketだけで通知する
+	settings, err = service.UpdateSettings(ctx, userID, &models.UpdateReviewSettingsRequest{
+		ReminderTime: "21:30", ReminderChannels: []string{},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "21:30", settings.ReminderTime)
+	assert.Equal(t, []string{models.ReminderChannelWebSocket}, settings.ReminderChannels)
+}
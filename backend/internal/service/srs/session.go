@@ -0,0 +1,404 @@
+package srs
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math"
+	"time"
+
+	"github.com/clearclown/HaiLanGo/backend/internal/models"
+	"github.com/clearclown/HaiLanGo/backend/internal/repository"
+	"github.com/clearclown/HaiLanGo/backend/pkg/srs"
+	"github.com/google/uuid"
+)
+
+const (
+	// maxAnswerTime は1枚の回答時間として記録する上限（Ankiの「回答時間の上限」のデフォルトと同じ）
+	// 出題したまま席を外した場合などに、回答時間と学習時間が大きくなりすぎないようにする
+	maxAnswerTime = 60 * time.Second
+
+	// sessionTTL は最後の操作から復習セッションを破棄するまでの時間（終了されなかったセッション用）
+	sessionTTL = 24 * time.Hour
+
+	// sessionLockLease は1回の操作でセッションをロックする期限（操作中にサーバーが停止した場合も期限後に操作できる）
+	sessionLockLease = 30 * time.Second
+	// sessionLockWait は他のリクエストが操作中のセッションのロックを待つ上限、sessionLockRetry はロックを試す間隔
+	sessionLockWait  = 10 * time.Second
+	sessionLockRetry = 20 * time.Millisecond
+)
+
+var (
+	// ErrSessionNotFound は復習セッションが存在しない（終了済み・期限切れを含む）場合のエラー
+	ErrSessionNotFound = errors.New("review session not found")
+	// ErrNotCurrentCard は出題中のカード以外に回答しようとした場合のエラー
+	ErrNotCurrentCard = errors.New("review item is not the current card of the session")
+	// ErrNothingToUndo は取り消す回答がない場合のエラー
+	ErrNothingToUndo = errors.New("no answer to undo")
+	// ErrAnswerChanged は回答した後にカードが変更されたため、回答を取り消せない場合のエラー
+	ErrAnswerChanged = errors.New("review item was changed after the answer")
+	// ErrSessionBusy は他のリクエストがセッションを操作し続けていて、ロックを取得できない場合のエラー
+	ErrSessionBusy = errors.New("review session is busy")
+)
+
+// SessionRecorder は終了した復習セッションを学習統計に記録する（repository.StatsRepositoryInterface が実装する）
+type SessionRecorder interface {
+	RecordReviewSession(ctx context.Context, summary *models.ReviewSessionSummary) error
+	UpdateStreak(ctx context.Context, userID uuid.UUID, activityDate time.Time) error
+}
+
+// SetSessionRecorder は復習セッションの集計の記録先を設定する
+func (s *SRSService) SetSessionRecorder(recorder SessionRecorder) {
+	s.recorder = recorder
+}
+
+// sessionStatus はセッションの進み具合を返す
+func sessionStatus(state *models.ReviewSessionState) *models.ReviewSession {
+	return &models.ReviewSession{
+		ID:        state.ID,
+		UserID:    state.UserID,
+		StartedAt: state.StartedAt,
+		Total:     len(state.Answers) + len(state.QueueIDs),
+		Reviewed:  len(state.Answers),
+		Remaining: len(state.QueueIDs),
+		CanUndo:   len(state.Answers) > 0,
+	}
+}
+
+// reviewItemState は取り消しのために回答前の復習の状態を取り出す
+func reviewItemState(item *models.ReviewItem) models.ReviewItemState {
+	return models.ReviewItemState{
+		MasteryLevel: item.MasteryLevel,
+		IntervalDays: item.IntervalDays,
+		EaseFactor:   item.EaseFactor,
+		ReviewCount:  item.ReviewCount,
+		LastReviewed: item.LastReviewed,
+		NextReview:   item.NextReview,
+		Stability:    item.Stability,
+		Difficulty:   item.Difficulty,
+		Suspended:    item.Suspended,
+		LeechedAt:    item.LeechedAt,
+	}
+}
+
+// restoreItemState は復習項目を回答前の状態に戻す
+func restoreItemState(item *models.ReviewItem, state models.ReviewItemState) {
+	item.MasteryLevel = state.MasteryLevel
+	item.IntervalDays = state.IntervalDays
+	item.EaseFactor = state.EaseFactor
+	item.ReviewCount = state.ReviewCount
+	item.LastReviewed = state.LastReviewed
+	item.NextReview = state.NextReview
+	item.Stability = state.Stability
+	item.Difficulty = state.Difficulty
+	item.Suspended = state.Suspended
+	item.LeechedAt = state.LeechedAt
+}
+
+// StartSession は今日の復習キュー（BuildQueue）の順に出題する復習セッションを開始する
+// セッションの状態はリポジトリに保存するため、どのサーバーからでも続けられる
+func (s *SRSService) StartSession(ctx context.Context, userID string, now time.Time) (*models.ReviewSession, error) {
+	queue, err := s.BuildQueue(ctx, userID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	state := &models.ReviewSessionState{
+		ID:           uuid.New().String(),
+		UserID:       userID,
+		StartedAt:    now,
+		LastActiveAt: now,
+		QueueIDs:     make([]string, 0, len(queue.Items)),
+	}
+	for _, item := range queue.Items {
+		state.QueueIDs = append(state.QueueIDs, item.ID)
+	}
+
+	// 終了されずに期限が切れたセッションを破棄する
+	if err := s.repo.DeleteExpiredSessions(ctx, now.Add(-sessionTTL)); err != nil {
+		log.Printf("failed to delete expired review sessions: %v", err)
+	}
+	if err := s.repo.CreateSession(ctx, state); err != nil {
+		return nil, err
+	}
+
+	return sessionStatus(state), nil
+}
+
+// NextCard は出題中のカードを返し、初めて出題した時刻を回答時間の計算のために記録する
+// すべてのカードに回答済みの場合は nil を返す
+func (s *SRSService) NextCard(ctx context.Context, userID string, sessionID string, now time.Time) (*models.ReviewSession, *models.ReviewItem, error) {
+	var card *models.ReviewItem
+	state, err := s.updateSession(ctx, userID, sessionID, now, func(state *models.ReviewSessionState) error {
+		// セッションの開始後に削除されたカードは出題しない
+		for len(state.QueueIDs) > 0 {
+			item, err := s.repo.FindByID(ctx, state.QueueIDs[0])
+			if errors.Is(err, repository.ErrReviewItemNotFound) {
+				state.QueueIDs = state.QueueIDs[1:]
+				state.ShownAt = nil
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			// リポジトリが同じ値を返す場合があるため複製する
+			copied := *item
+			card = &copied
+			break
+		}
+		if card != nil && state.ShownAt == nil {
+			shownAt := now
+			state.ShownAt = &shownAt
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if card == nil {
+		return sessionStatus(state), nil, nil
+	}
+
+	// 音声合成は時間がかかるため、セッションのロックを解除してから行う
+	s.AttachAudio(ctx, []*models.ReviewItem{card})
+	Annotate(card, now)
+
+	return sessionStatus(state), card, nil
+}
+
+// AnswerCard は出題中のカードに回答する
+// 回答時間は出題した時刻（NextCard）からサーバーで計算し、復習履歴に記録する
+func (s *SRSService) AnswerCard(ctx context.Context, userID string, sessionID string, itemID string, score int, now time.Time) (*models.ReviewSession, *models.ReviewItem, error) {
+	var item *models.ReviewItem
+	state, err := s.updateSession(ctx, userID, sessionID, now, func(state *models.ReviewSessionState) error {
+		if len(state.QueueIDs) == 0 || state.QueueIDs[0] != itemID {
+			return ErrNotCurrentCard
+		}
+
+		// 取り消しのために回答前の状態を記録する
+		current, err := s.repo.FindByID(ctx, itemID)
+		if err != nil {
+			return err
+		}
+		before := reviewItemState(current)
+
+		// 出題した時刻が分からない場合は最後に操作した時刻から数える
+		shownAt := state.LastActiveAt
+		if state.ShownAt != nil {
+			shownAt = *state.ShownAt
+		}
+		timeSpent := now.Sub(shownAt)
+		if timeSpent > maxAnswerTime {
+			timeSpent = maxAnswerTime
+		}
+
+		timeSpentSec := int(math.Round(timeSpent.Seconds()))
+
+		var history *models.ReviewHistory
+		item, history, err = s.completeReview(ctx, userID, itemID, score, timeSpentSec, now)
+		if err != nil {
+			return err
+		}
+
+		answer := models.ReviewSessionAnswerState{
+			ItemID:       itemID,
+			Before:       before,
+			UpdatedAt:    item.UpdatedAt,
+			Score:        score,
+			TimeSpentSec: timeSpentSec,
+		}
+		if history != nil {
+			answer.HistoryID = history.ID
+		}
+		state.Answers = append(state.Answers, answer)
+		state.QueueIDs = state.QueueIDs[1:]
+		state.ShownAt = nil
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return sessionStatus(state), item, nil
+}
+
+// AnswerSpeakingCard は出題中の発音カードの回答音声を採点し、採点結果（0-100）をスコアとして回答する
+func (s *SRSService) AnswerSpeakingCard(ctx context.Context, userID string, sessionID string, itemID string, audioData []byte, now time.Time) (*models.ReviewSession, *models.ReviewItem, *models.PronunciationScore, error) {
+	score, err := s.evaluateSpeaking(ctx, userID, itemID, audioData)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	session, item, err := s.AnswerCard(ctx, userID, sessionID, itemID, clampScore(score.TotalScore), now)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return session, item, score, nil
+}
+
+// UndoAnswer は最後の回答を取り消す
+// 復習の状態を回答前に戻して復習履歴を削除し、そのカードをもう一度出題する
+// 回答した後にカードが変更されていた場合（他の端末での復習など）は上書きせずに ErrAnswerChanged を返す
+func (s *SRSService) UndoAnswer(ctx context.Context, userID string, sessionID string, now time.Time) (*models.ReviewSession, *models.ReviewItem, error) {
+	var restored *models.ReviewItem
+	state, err := s.updateSession(ctx, userID, sessionID, now, func(state *models.ReviewSessionState) error {
+		if len(state.Answers) == 0 {
+			return ErrNothingToUndo
+		}
+		last := state.Answers[len(state.Answers)-1]
+
+		current, err := s.repo.FindByID(ctx, last.ItemID)
+		if err != nil {
+			return err
+		}
+		item := *current
+		restoreItemState(&item, last.Before)
+		if err := s.repo.UpdateIfUnchanged(ctx, &item, last.UpdatedAt); err != nil {
+			if errors.Is(err, repository.ErrReviewItemConflict) {
+				return ErrAnswerChanged
+			}
+			return err
+		}
+		if last.HistoryID != "" {
+			if err := s.repo.DeleteHistory(ctx, last.HistoryID); err != nil {
+				return err
+			}
+		}
+		restored = &item
+
+		state.Answers = state.Answers[:len(state.Answers)-1]
+		state.QueueIDs = append([]string{last.ItemID}, state.QueueIDs...)
+		state.ShownAt = nil
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return sessionStatus(state), restored, nil
+}
+
+// FinishSession は復習セッションを終了し、集計を学習統計に記録する
+// 回答のないセッションは記録しない
+func (s *SRSService) FinishSession(ctx context.Context, userID string, sessionID string, now time.Time) (*models.ReviewSessionSummary, error) {
+	state, token, err := s.lockSession(ctx, userID, sessionID, now)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.DeleteSession(ctx, sessionID, token); err != nil {
+		return nil, sessionLockError(err)
+	}
+
+	summary := &models.ReviewSessionSummary{
+		SessionID:   state.ID,
+		UserID:      userID,
+		Reviewed:    len(state.Answers),
+		DurationSec: int(math.Round(now.Sub(state.StartedAt).Seconds())),
+		StartedAt:   state.StartedAt,
+		FinishedAt:  now,
+	}
+	for _, answer := range state.Answers {
+		if srs.ScoreToRating(answer.Score) >= srs.RatingGood {
+			summary.Correct++
+		}
+		summary.TimeSpentSec += answer.TimeSpentSec
+	}
+	if summary.Reviewed == 0 {
+		return summary, nil
+	}
+	summary.AccuracyRate = float64(summary.Correct) / float64(summary.Reviewed)
+	summary.AverageTimeSec = float64(summary.TimeSpentSec) / float64(summary.Reviewed)
+
+	// 記録に失敗しても復習結果は反映済みのため、エラーにはしない
+	if s.recorder != nil {
+		if err := s.recorder.RecordReviewSession(ctx, summary); err != nil {
+			log.Printf("failed to record review session %s: %v", state.ID, err)
+		}
+		if userUUID, err := uuid.Parse(userID); err == nil {
+			if err := s.recorder.UpdateStreak(ctx, userUUID, now); err != nil {
+				log.Printf("failed to update streak for user %s: %v", userID, err)
+			}
+		}
+	}
+
+	return summary, nil
+}
+
+// updateSession はセッションをロックして update で変更し、最後に操作した時刻を now にして保存する
+// update がエラーを返した場合は保存せずにロックを解除する
+func (s *SRSService) updateSession(ctx context.Context, userID string, sessionID string, now time.Time, update func(state *models.ReviewSessionState) error) (*models.ReviewSessionState, error) {
+	state, token, err := s.lockSession(ctx, userID, sessionID, now)
+	if err != nil {
+		return nil, err
+	}
+	if err := update(state); err != nil {
+		s.unlockSession(ctx, sessionID, token)
+		return nil, err
+	}
+
+	state.LastActiveAt = now
+	if err := s.repo.SaveSession(ctx, state, token); err != nil {
+		return nil, sessionLockError(err)
+	}
+	return state, nil
+}
+
+// lockSession はユーザーの進行中の復習セッションをロックして、状態とロックの token を返す
+// 他のリクエストが操作中の場合は終わるまで待つ（同じセッションへの回答を1つずつ処理する）
+func (s *SRSService) lockSession(ctx context.Context, userID string, sessionID string, now time.Time) (*models.ReviewSessionState, string, error) {
+	if _, err := uuid.Parse(sessionID); err != nil {
+		return nil, "", ErrSessionNotFound
+	}
+
+	token := uuid.New().String()
+	deadline := time.Now().Add(sessionLockWait)
+	var state *models.ReviewSessionState
+	for {
+		var err error
+		state, err = s.repo.LockSession(ctx, sessionID, token, sessionLockLease)
+		if err == nil {
+			break
+		}
+		if errors.Is(err, repository.ErrReviewSessionNotFound) {
+			return nil, "", ErrSessionNotFound
+		}
+		if !errors.Is(err, repository.ErrReviewSessionLocked) {
+			return nil, "", err
+		}
+		if time.Now().After(deadline) {
+			return nil, "", ErrSessionBusy
+		}
+		select {
+		case <-ctx.Done():
+			return nil, "", ctx.Err()
+		case <-time.After(sessionLockRetry):
+		}
+	}
+
+	if now.Sub(state.LastActiveAt) > sessionTTL {
+		if err := s.repo.DeleteSession(ctx, sessionID, token); err != nil {
+			log.Printf("failed to delete expired review session %s: %v", sessionID, err)
+		}
+		return nil, "", ErrSessionNotFound
+	}
+	if state.UserID != userID {
+		s.unlockSession(ctx, sessionID, token)
+		return nil, "", ErrForbidden
+	}
+	return state, token, nil
+}
+
+// unlockSession は状態を変えずにセッションのロックを解除する（失敗してもロックの期限後に操作できる）
+func (s *SRSService) unlockSession(ctx context.Context, sessionID string, token string) {
+	if err := s.repo.UnlockSession(ctx, sessionID, token); err != nil {
+		log.Printf("failed to unlock review session %s: %v", sessionID, err)
+	}
+}
+
+// sessionLockError は保存中にロックの期限が切れて他のリクエストが操作した場合を ErrSessionBusy にする
+func sessionLockError(err error) error {
+	if errors.Is(err, repository.ErrReviewSessionLocked) {
+		return ErrSessionBusy
+	}
+	return err
+}
@@ -0,0 +1,220 @@
+package srs
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/clearclown/HaiLanGo/backend/internal/models"
+	"github.com/clearclown/HaiLanGo/backend/internal/repository"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestReviewSession は復習セッションの出題・回答時間の記録・取り消し・集計をテスト
+func TestReviewSession(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewInMemoryReviewRepository()
+	stats := repository.NewInMemoryStatsRepository()
+	service := NewSRSService(repo)
+	service.SetSessionRecorder(stats)
+
+	userID := uuid.New().String()
+	start := time.Now()
+	newTestReviewItem(t, repo, userID, start.AddDate(0, 0, -2))
+	newTestReviewItem(t, repo, userID, start.AddDate(0, 0, -1))
+
+	session, err := service.StartSession(ctx, userID, start)
+	require.NoError(t, err)
+	assert.Equal(t, 2, session.Total)
+	assert.False(t, session.CanUndo)
+
+	// 出題から8秒後に回答
+	_, first, err := service.NextCard(ctx, userID, session.ID, start.Add(time.Second))
+	require.NoError(t, err)
+	require.NotNil(t, first)
+	status, answered, err := service.AnswerCard(ctx, userID, session.ID, first.ID, 85, start.Add(9*time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, 1, answered.ReviewCount)
+	assert.Equal(t, 1, status.Reviewed)
+	assert.True(t, status.CanUndo)
+
+	histories, err := repo.FindHistoryByItemID(ctx, first.ID)
+	require.NoError(t, err)
+	require.Len(t, histories, 1)
+	assert.Equal(t, 8, histories[0].TimeSpentSec)
+
+	// 回答を取り消すと復習の状態が戻り、履歴が削除され、同じカードをもう一度出題する
+	status, restored, err := service.UndoAnswer(ctx, userID, session.ID, start.Add(10*time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, 0, restored.ReviewCount)
+	assert.Equal(t, 0, status.Reviewed)
+	assert.Equal(t, 2, status.Remaining)
+
+	item, err := repo.FindByID(ctx, first.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 0, item.ReviewCount)
+	histories, err = repo.FindHistoryByItemID(ctx, first.ID)
+	require.NoError(t, err)
+	assert.Empty(t, histories)
+
+	_, again, err := service.NextCard(ctx, userID, session.ID, start.Add(12*time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, first.ID, again.ID)
+	_, _, err = service.AnswerCard(ctx, userID, session.ID, first.ID, 40, start.Add(15*time.Second))
+	require.NoError(t, err)
+
+	// 回答時間は上限（60秒）までしか記録しない
+	_, second, err := service.NextCard(ctx, userID, session.ID, start.Add(20*time.Second))
+	require.NoError(t, err)
+	_, _, err = service.AnswerCard(ctx, userID, session.ID, second.ID, 95, start.Add(10*time.Minute))
+	require.NoError(t, err)
+
+	status, next, err := service.NextCard(ctx, userID, session.ID, start.Add(10*time.Minute))
+	require.NoError(t, err)
+	assert.Nil(t, next)
+	assert.Equal(t, 0, status.Remaining)
+
+	summary, err := service.FinishSession(ctx, userID, session.ID, start.Add(11*time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, 2, summary.Reviewed)
+	assert.Equal(t, 1, summary.Correct)
+	assert.InDelta(t, 0.5, summary.AccuracyRate, 1e-9)
+	assert.Equal(t, 3+60, summary.TimeSpentSec)
+	assert.InDelta(t, 31.5, summary.AverageTimeSec, 1e-9)
+	assert.Equal(t, 11*60, summary.DurationSec)
+
+	assert.Equal(t, []*models.ReviewSessionSummary{summary}, stats.GetReviewSessions(ctx, userID))
+
+	// 終了したセッションは操作できない
+	_, _, err = service.NextCard(ctx, userID, session.ID, start.Add(12*time.Minute))
+	assert.ErrorIs(t, err, ErrSessionNotFound)
+}
+
+// TestReviewSession_Concurrent は同時に操作した場合に同じカードへの回答が1回だけ記録されることをテスト
+func TestReviewSession_Concurrent(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewInMemoryReviewRepository()
+	service := NewSRSService(repo)
+
+	userID := uuid.New().String()
+	now := time.Now()
+	item := newTestReviewItem(t, repo, userID, now.AddDate(0, 0, -1))
+	session, err := service.StartSession(ctx, userID, now)
+	require.NoError(t, err)
+	other, err := service.StartSession(ctx, uuid.New().String(), now)
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	var answered atomic.Int32
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if _, _, err := service.AnswerCard(ctx, userID, session.ID, item.ID, 80, now.Add(time.Second)); err == nil {
+				answered.Add(1)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			_, _, err := service.NextCard(ctx, other.UserID, other.ID, now)
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), answered.Load())
+	histories, err := repo.FindHistoryByItemID(ctx, item.ID)
+	require.NoError(t, err)
+	assert.Len(t, histories, 1)
+}
+
+// TestReviewSession_MultipleServers はリポジトリに保存したセッションを他のサーバーで続けられることをテスト
+func TestReviewSession_MultipleServers(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewInMemoryReviewRepository()
+	first := NewSRSService(repo)
+	second := NewSRSService(repo)
+
+	userID := uuid.New().String()
+	now := time.Now()
+	item := newTestReviewItem(t, repo, userID, now.AddDate(0, 0, -1))
+
+	session, err := first.StartSession(ctx, userID, now)
+	require.NoError(t, err)
+	_, card, err := first.NextCard(ctx, userID, session.ID, now)
+	require.NoError(t, err)
+	require.Equal(t, item.ID, card.ID)
+
+	// 出題した時刻は他のサーバーでの回答時間の計算にも使う
+	status, _, err := second.AnswerCard(ctx, userID, session.ID, item.ID, 80, now.Add(7*time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, 1, status.Reviewed)
+	histories, err := repo.FindHistoryByItemID(ctx, item.ID)
+	require.NoError(t, err)
+	require.Len(t, histories, 1)
+	assert.Equal(t, 7, histories[0].TimeSpentSec)
+
+	status, restored, err := first.UndoAnswer(ctx, userID, session.ID, now.Add(8*time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, 0, restored.ReviewCount)
+	assert.Equal(t, 1, status.Remaining)
+
+	_, _, err = first.AnswerCard(ctx, userID, session.ID, item.ID, 90, now.Add(10*time.Second))
+	require.NoError(t, err)
+	summary, err := second.FinishSession(ctx, userID, session.ID, now.Add(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, 1, summary.Reviewed)
+
+	_, _, err = first.NextCard(ctx, userID, session.ID, now.Add(2*time.Minute))
+	assert.ErrorIs(t, err, ErrSessionNotFound)
+}
+
+// TestReviewSession_Errors は復習セッションの操作のエラーをテスト
+func TestReviewSession_Errors(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewInMemoryReviewRepository()
+	service := NewSRSService(repo)
+
+	userID := uuid.New().String()
+	now := time.Now()
+	item := newTestReviewItem(t, repo, userID, now.AddDate(0, 0, -1))
+	other := newTestReviewItem(t, repo, userID, now.AddDate(0, 0, 5))
+
+	session, err := service.StartSession(ctx, userID, now)
+	require.NoError(t, err)
+
+	_, _, err = service.NextCard(ctx, uuid.New().String(), session.ID, now)
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	_, _, err = service.AnswerCard(ctx, userID, session.ID, other.ID, 80, now)
+	assert.ErrorIs(t, err, ErrNotCurrentCard)
+
+	_, _, err = service.UndoAnswer(ctx, userID, session.ID, now)
+	assert.ErrorIs(t, err, ErrNothingToUndo)
+
+	_, _, err = service.AnswerCard(ctx, userID, session.ID, item.ID, 80, now.Add(5*time.Second))
+	require.NoError(t, err)
+
+	// 回答した後に他の端末で変更されたカードは取り消さない
+	changed, err := repo.FindByID(ctx, item.ID)
+	require.NoError(t, err)
+	updated := *changed
+	updated.IntervalDays = 30
+	require.NoError(t, repo.Update(ctx, &updated))
+	_, _, err = service.UndoAnswer(ctx, userID, session.ID, now.Add(10*time.Second))
+	assert.ErrorIs(t, err, ErrAnswerChanged)
+	stored, err := repo.FindByID(ctx, item.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 30, stored.IntervalDays)
+
+	// 最後の操作から期限が切れたセッションは破棄する
+	_, _, err = service.UndoAnswer(ctx, userID, session.ID, now.Add(sessionTTL+time.Minute))
+	assert.ErrorIs(t, err, ErrSessionNotFound)
+
+	_, err = service.FinishSession(ctx, userID, "unknown", now)
+	assert.ErrorIs(t, err, ErrSessionNotFound)
+}
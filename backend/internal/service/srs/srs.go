@@ -2,35 +2,52 @@ package srs
 
 import (
 	"context"
+	"errors"
+	"log"
+	"strings"
 	"time"
 
 	"github.com/clearclown/HaiLanGo/backend/internal/models"
+	"github.com/clearclown/HaiLanGo/backend/internal/repository"
+	"github.com/clearclown/HaiLanGo/backend/internal/service/notification"
 	"github.com/clearclown/HaiLanGo/backend/pkg/srs"
 	"github.com/google/uuid"
 )
 
+var (
+	// ErrForbidden は他のユーザーの復習項目を操作しようとした場合のエラー
+	ErrForbidden = errors.New("review item belongs to another user")
+	// ErrInvalidReviewLimit は1日の出題数の上限が不正な場合のエラー
+	ErrInvalidReviewLimit = errors.New("invalid daily review limit")
+	// ErrInvalidLeechSettings はリーチの判定条件・対処が不正な場合のエラー
+	ErrInvalidLeechSettings = errors.New("invalid leech settings")
+	// ErrInvalidReminderSettings はリマインダーの時刻・タイムゾーン・通知先が不正な場合のエラー
+	ErrInvalidReminderSettings = errors.New("invalid reminder settings")
+)
+
 // SRSService は間隔反復学習サービス
+// 復習間隔の計算はユーザーが選択したアルゴリズム（srs.Scheduler）に委譲する
 type SRSService struct {
-	repo ReviewItemRepository
-}
+	repo        repository.ReviewRepository
+	synthesizer AudioSynthesizer
+	evaluator   PronunciationEvaluator
+	media       MediaStore
+	recorder    SessionRecorder
 
-// ReviewItemRepository は復習項目のリポジトリインターフェース
-type ReviewItemRepository interface {
-	Create(ctx context.Context, item *models.ReviewItem) error
-	GetByID(ctx context.Context, id uuid.UUID) (*models.ReviewItem, error)
-	GetByUserID(ctx context.Context, userID uuid.UUID) ([]*models.ReviewItem, error)
-	GetDueItems(ctx context.Context, userID uuid.UUID, now time.Time) ([]*models.ReviewItem, error)
-	Update(ctx context.Context, item *models.ReviewItem) error
-	AddHistory(ctx context.Context, history *models.ReviewHistory) error
-	GetHistoriesByItemID(ctx context.Context, itemID uuid.UUID) ([]models.ReviewHistory, error)
-	GetStats(ctx context.Context, userID uuid.UUID, now time.Time) (*models.ReviewStats, error)
+	// リーチの学び直しの教材
+	phraseRepo  repository.PhraseRepository
+	patternRepo repository.PatternRepositoryInterface
+	dictionary  DictionaryLookup
+
+	// 復習リマインダーの通知先
+	notifiers []notification.Notifier
 }
 
 // ReviewItemsByPriority は優先度別の復習項目
 type ReviewItemsByPriority struct {
 	UrgentItems      []*models.ReviewItem `json:"urgent_items"`
 	RecommendedItems []*models.ReviewItem `json:"recommended_items"`
-	RelaxedItems     []*models.ReviewItem `json:"relaxed_items"`
+	OptionalItems    []*models.ReviewItem `json:"optional_items"`
 }
 
 // PhraseData はフレーズデータ
@@ -42,17 +59,188 @@ type PhraseData struct {
 	Translation string
 }
 
+// PhraseDataFromRecords はOCR結果から分割した対訳フレーズを復習項目の作成用データに変換する
+// 訳のないフレーズ（見出しなど）は復習項目にしない
+func PhraseDataFromRecords(userID uuid.UUID, records []*models.PhraseRecord) []*PhraseData {
+	phrases := make([]*PhraseData, 0, len(records))
+	for _, record := range records {
+		if record.Translation == "" {
+			continue
+		}
+		phrases = append(phrases, &PhraseData{
+			UserID:      userID,
+			BookID:      record.BookID,
+			PageNumber:  record.PageNumber,
+			Content:     record.Text,
+			Translation: record.Translation,
+		})
+	}
+	return phrases
+}
+
 // NewSRSService は新しいSRSServiceを作成
-func NewSRSService(repo ReviewItemRepository) *SRSService {
-	return &SRSService{
-		repo: repo,
+func NewSRSService(repo repository.ReviewRepository) *SRSService {
+	return &SRSService{repo: repo}
+}
+
+// GetSettings はユーザーの復習の設定を取得する（未設定の場合はデフォルト）
+func (s *SRSService) GetSettings(ctx context.Context, userID string) (*models.ReviewSettings, error) {
+	settings, err := s.repo.GetSettings(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if settings == nil {
+		settings = &models.ReviewSettings{
+			UserID:         userID,
+			Algorithm:      string(srs.DefaultAlgorithm),
+			NewCardsPerDay: DefaultNewCardsPerDay,
+			ReviewsPerDay:  DefaultReviewsPerDay,
+		}
+	}
+	if settings.ReviewsPerDay <= 0 {
+		settings.ReviewsPerDay = DefaultReviewsPerDay
+	}
+	if settings.LeechThreshold <= 0 {
+		settings.LeechThreshold = DefaultLeechThreshold
+	}
+	if settings.LeechWindowDays <= 0 {
+		settings.LeechWindowDays = DefaultLeechWindowDays
+	}
+	if settings.LeechAction == "" {
+		settings.LeechAction = DefaultLeechAction
+	}
+	applyReminderDefaults(settings)
+	return settings, nil
+}
+
+// UpdateAlgorithm はユーザーが使用する復習アルゴリズムを変更する
+// 復習項目の状態はアルゴリズム間で共通のため、切り替えてもこれまでの復習履歴を引き継ぐ
+func (s *SRSService) UpdateAlgorithm(ctx context.Context, userID string, algorithm string) (*models.ReviewSettings, error) {
+	if algorithm == "" {
+		return nil, srs.ErrUnknownAlgorithm
+	}
+	return s.UpdateSettings(ctx, userID, &models.UpdateReviewSettingsRequest{Algorithm: algorithm})
+}
+
+// UpdateSettings はユーザーの復習の設定を変更する（指定されていない項目は変更しない）
+func (s *SRSService) UpdateSettings(ctx context.Context, userID string, req *models.UpdateReviewSettingsRequest) (*models.ReviewSettings, error) {
+	if req.Algorithm != "" {
+		if _, err := srs.NewScheduler(srs.Algorithm(req.Algorithm)); err != nil {
+			return nil, srs.ErrUnknownAlgorithm
+		}
+	}
+	if req.NewCardsPerDay != nil && *req.NewCardsPerDay < 0 {
+		return nil, ErrInvalidReviewLimit
+	}
+	if req.ReviewsPerDay != nil && *req.ReviewsPerDay <= 0 {
+		return nil, ErrInvalidReviewLimit
+	}
+	if (req.LeechThreshold != nil && *req.LeechThreshold <= 0) || (req.LeechWindowDays != nil && *req.LeechWindowDays <= 0) {
+		return nil, ErrInvalidLeechSettings
+	}
+	if req.LeechAction != "" && req.LeechAction != models.LeechActionSuspend && req.LeechAction != models.LeechActionReteach {
+		return nil, ErrInvalidLeechSettings
+	}
+	channels, err := validateReminderSettings(req)
+	if err != nil {
+		return nil, err
+	}
+
+	settings, err := s.GetSettings(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if req.Algorithm != "" {
+		settings.Algorithm = req.Algorithm
+	}
+	if req.NewCardsPerDay != nil {
+		settings.NewCardsPerDay = *req.NewCardsPerDay
+	}
+	if req.ReviewsPerDay != nil {
+		settings.ReviewsPerDay = *req.ReviewsPerDay
+	}
+	if req.LeechThreshold != nil {
+		settings.LeechThreshold = *req.LeechThreshold
+	}
+	if req.LeechWindowDays != nil {
+		settings.LeechWindowDays = *req.LeechWindowDays
+	}
+	if req.LeechAction != "" {
+		settings.LeechAction = req.LeechAction
+	}
+	if req.ReminderEnabled != nil {
+		settings.ReminderEnabled = *req.ReminderEnabled
+	}
+	if req.ReminderTime != "" {
+		settings.ReminderTime = req.ReminderTime
+	}
+	if req.ReminderTimezone != "" {
+		settings.ReminderTimezone = req.ReminderTimezone
+	}
+	if channels != nil {
+		settings.ReminderChannels = channels
+	}
+	settings.UpdatedAt = time.Now()
+
+	if err := s.repo.SaveSettings(ctx, settings); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+// Scheduler はユーザーが選択したアルゴリズムのスケジューラーを返す
+func (s *SRSService) Scheduler(ctx context.Context, userID string) (srs.Scheduler, error) {
+	settings, err := s.GetSettings(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// FSRSは最適化したユーザーの重みがあればそれを使用する
+	if srs.Algorithm(settings.Algorithm) == srs.AlgorithmFSRS && len(settings.FSRSWeights) > 0 {
+		params := srs.DefaultFSRSParameters()
+		params.Weights = settings.FSRSWeights
+		scheduler, err := srs.NewFSRSScheduler(params)
+		if err == nil {
+			return scheduler, nil
+		}
+		log.Printf("invalid fsrs weights for user %s, using defaults: %v", userID, err)
+	}
+
+	scheduler, err := srs.NewScheduler(srs.Algorithm(settings.Algorithm))
+	if err != nil {
+		// 保存された値が不正な場合はデフォルトにフォールバックする
+		log.Printf("unknown srs algorithm %q for user %s, using %s", settings.Algorithm, userID, srs.DefaultAlgorithm)
+		return srs.NewScheduler(srs.DefaultAlgorithm)
+	}
+	return scheduler, nil
+}
+
+// Annotate は復習項目に優先度と現時点の想起確率の予測を設定する
+func Annotate(item *models.ReviewItem, now time.Time) {
+	item.Priority = srs.Priority(item.NextReview, now)
+	item.PredictedRetention = nil
+	if retention, ok := srs.Retrievability(itemState(item), now); ok {
+		item.PredictedRetention = &retention
+	}
+}
+
+// itemState は復習項目のスケジューラーの状態を返す
+func itemState(item *models.ReviewItem) srs.State {
+	return srs.State{
+		EaseFactor:   item.EaseFactor,
+		IntervalDays: item.IntervalDays,
+		ReviewCount:  item.ReviewCount,
+		NextReview:   item.NextReview,
+		LastReviewed: item.LastReviewed,
+		Stability:    item.Stability,
+		Difficulty:   item.Difficulty,
 	}
 }
 
 // GetReviewItems は優先度別に復習項目を取得
-func (s *SRSService) GetReviewItems(ctx context.Context, userID uuid.UUID, now time.Time) (*ReviewItemsByPriority, error) {
+func (s *SRSService) GetReviewItems(ctx context.Context, userID string, now time.Time) (*ReviewItemsByPriority, error) {
 	// ユーザーのすべての復習項目を取得
-	items, err := s.repo.GetByUserID(ctx, userID)
+	items, err := s.repo.FindByUserID(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -60,25 +248,22 @@ func (s *SRSService) GetReviewItems(ctx context.Context, userID uuid.UUID, now t
 	result := &ReviewItemsByPriority{
 		UrgentItems:      make([]*models.ReviewItem, 0),
 		RecommendedItems: make([]*models.ReviewItem, 0),
-		RelaxedItems:     make([]*models.ReviewItem, 0),
+		OptionalItems:    make([]*models.ReviewItem, 0),
 	}
 
-	// 優先度で分類
+	// 優先度で分類（次回復習日が未設定の項目は緊急として扱う、出題を停止したリーチは除く）
 	for _, item := range items {
-		if item.NextReviewDate == nil {
-			// 次回復習日が未設定の項目は緊急として扱う
-			result.UrgentItems = append(result.UrgentItems, item)
+		if item.Suspended {
 			continue
 		}
-
-		priority := item.GetPriority(now)
-		switch priority {
-		case models.PriorityUrgent:
+		Annotate(item, now)
+		switch item.Priority {
+		case srs.PriorityUrgent:
 			result.UrgentItems = append(result.UrgentItems, item)
-		case models.PriorityRecommended:
+		case srs.PriorityRecommended:
 			result.RecommendedItems = append(result.RecommendedItems, item)
-		case models.PriorityRelaxed:
-			result.RelaxedItems = append(result.RelaxedItems, item)
+		default:
+			result.OptionalItems = append(result.OptionalItems, item)
 		}
 	}
 
@@ -86,79 +271,233 @@ func (s *SRSService) GetReviewItems(ctx context.Context, userID uuid.UUID, now t
 }
 
 // CompleteReview は復習完了処理
-func (s *SRSService) CompleteReview(ctx context.Context, itemID uuid.UUID, score int, timeSpentSec int) error {
+// ユーザーが選択したアルゴリズムで次回復習日を計算し、習熟度と復習履歴を更新する
+func (s *SRSService) CompleteReview(ctx context.Context, userID string, itemID string, score int, timeSpentSec int) (*models.ReviewItem, error) {
+	item, _, err := s.completeReview(ctx, userID, itemID, score, timeSpentSec, time.Now())
+	return item, err
+}
+
+// completeReview は now に復習を完了し、更新した復習項目と記録した復習履歴（記録に失敗した場合は nil）を返す
+func (s *SRSService) completeReview(ctx context.Context, userID string, itemID string, score int, timeSpentSec int, now time.Time) (*models.ReviewItem, *models.ReviewHistory, error) {
 	// 復習項目を取得
-	item, err := s.repo.GetByID(ctx, itemID)
+	item, err := s.repo.FindByID(ctx, itemID)
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
 	if item == nil {
-		return nil // 項目が見つからない場合は何もしない
+		return nil, nil, repository.ErrReviewItemNotFound
 	}
 
-	now := time.Now()
+	// 所有権チェック
+	if item.UserID != userID {
+		return nil, nil, ErrForbidden
+	}
+
+	scheduler, err := s.Scheduler(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	// 次回復習日を計算
-	nextReviewDate := srs.CalculateNextReviewDate(item.ReviewCount, score, now)
+	// 覚えていた項目の忘却はリーチの判定に数える
+	lapsed := item.ReviewCount > 0 && isLapse(score)
+
+	// 次回復習日を計算し、同じ日に復習が集中しないよう前後にずらす（間隔そのものは変えない）
+	state := scheduler.Schedule(itemState(item), score, now)
+	state.NextReview = s.balanceNextReview(ctx, item, state, now)
 
 	// 復習項目を更新
-	item.ReviewCount++
-	item.LastScore = score
-	item.LastReviewDate = &now
-	item.NextReviewDate = &nextReviewDate
-	item.UpdatedAt = now
+	item.MasteryLevel = nextMasteryLevel(item.MasteryLevel, score)
+	item.EaseFactor = state.EaseFactor
+	item.IntervalDays = state.IntervalDays
+	item.ReviewCount = state.ReviewCount
+	item.NextReview = state.NextReview
+	item.LastReviewed = now
+	item.Stability = state.Stability
+	item.Difficulty = state.Difficulty
+	if lapsed {
+		s.detectLeech(ctx, item, now)
+	}
 
-	err = s.repo.Update(ctx, item)
-	if err != nil {
-		return err
+	if err := s.repo.Update(ctx, item); err != nil {
+		return nil, nil, err
 	}
 
-	// 復習履歴を記録
+	// 復習履歴を記録（失敗しても復習結果は反映済みのため、エラーにはしない）
 	history := &models.ReviewHistory{
-		ID:           uuid.New(),
-		ReviewItemID: itemID,
-		UserID:       item.UserID,
+		ReviewItemID: item.ID,
+		UserID:       userID,
 		Score:        score,
-		ReviewedAt:   now,
 		TimeSpentSec: timeSpentSec,
+		ReviewedAt:   now,
+	}
+	if err := s.repo.SaveHistory(ctx, history); err != nil {
+		log.Printf("failed to save review history for item %s: %v", item.ID, err)
+		return item, nil, nil
 	}
 
-	return s.repo.AddHistory(ctx, history)
+	return item, history, nil
 }
 
-// GetStats は復習統計を取得
-func (s *SRSService) GetStats(ctx context.Context, userID uuid.UUID, now time.Time) (*models.ReviewStats, error) {
-	return s.repo.GetStats(ctx, userID, now)
+// balanceNextReview は FuzzRange の範囲で、ユーザーの復習予定が最も少ない日を次回復習日にする
+// 項目をすべて読み込まず、範囲内の日ごとの復習予定の件数だけを取得する
+func (s *SRSService) balanceNextReview(ctx context.Context, item *models.ReviewItem, state srs.State, now time.Time) time.Time {
+	minInterval, maxInterval := srs.FuzzRange(state.IntervalDays)
+	if minInterval == maxInterval {
+		return state.NextReview
+	}
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	counts, err := s.repo.CountDueByDate(ctx, item.UserID, item.ID,
+		today.AddDate(0, 0, minInterval), today.AddDate(0, 0, maxInterval+1))
+	if err != nil {
+		log.Printf("failed to count due review items for load balancing (user %s): %v", item.UserID, err)
+		return state.NextReview
+	}
+
+	load := make(map[int]int, maxInterval-minInterval+1)
+	for days := minInterval; days <= maxInterval; days++ {
+		load[days] = counts[today.AddDate(0, 0, days).Format(time.DateOnly)]
+	}
+
+	days := srs.BalanceInterval(state.IntervalDays, load, item.ID)
+	return now.AddDate(0, 0, days)
 }
 
-// CreateReviewItem はフレーズから復習項目を作成
-func (s *SRSService) CreateReviewItem(ctx context.Context, data *PhraseData) (uuid.UUID, error) {
-	now := time.Now()
+// nextMasteryLevel はスコアに応じて習熟度（0-100）を更新する
+func nextMasteryLevel(current int, score int) int {
+	switch {
+	case score >= 70:
+		current += 10
+		if current > 100 {
+			current = 100
+		}
+	case score < 50:
+		current -= 5
+		if current < 0 {
+			current = 0
+		}
+	}
+	return current
+}
+
+// GetStats は復習統計を取得
+func (s *SRSService) GetStats(ctx context.Context, userID string, now time.Time) (*models.ReviewStats, error) {
+	items, err := s.GetReviewItems(ctx, userID, now)
+	if err != nil {
+		return nil, err
+	}
 
-	item := &models.ReviewItem{
-		ID:          uuid.New(),
-		UserID:      data.UserID,
-		BookID:      data.BookID,
+	stats := &models.ReviewStats{
+		UrgentCount:      len(items.UrgentItems),
+		RecommendedCount: len(items.RecommendedItems),
+		OptionalCount:    len(items.OptionalItems),
+	}
+
+	// 今日完了した復習数（ユーザーのタイムゾーンの日付で数える）
+	settings, err := s.GetSettings(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	todayStart := startOfDay(settings, now)
+	stats.TotalCompletedToday, err = s.repo.CountCompletedToday(ctx, userID, todayStart)
+	if err != nil {
+		stats.TotalCompletedToday = 0
+	}
+
+	// 今週の完了率（1日1回 × 7日を目標とする）
+	weekStart := todayStart.Add(-7 * 24 * time.Hour)
+	weeklyCompleted, _ := s.repo.CountCompletedSince(ctx, userID, weekStart)
+	total := stats.UrgentCount + stats.RecommendedCount + stats.OptionalCount
+	if weeklyTarget := total * 7; weeklyTarget > 0 {
+		stats.WeeklyCompletionRate = float64(weeklyCompleted) / float64(weeklyTarget) * 100
+	}
+
+	return stats, nil
+}
+
+// CreateReviewItem はフレーズから種類ごとのカードを作成し、学習項目のID（認識カードのID）を返す
+// 作成直後の項目はすぐに復習できるよう、次回復習日を作成日時にする
+func (s *SRSService) CreateReviewItem(ctx context.Context, data *PhraseData) (string, error) {
+	cards, err := s.createCards(ctx, &models.ReviewItem{
+		UserID:      data.UserID.String(),
+		BookID:      data.BookID.String(),
 		PageNumber:  data.PageNumber,
-		ItemType:    "phrase",
-		Content:     data.Content,
+		Type:        models.ReviewItemTypePhrase,
+		Text:        data.Content,
 		Translation: data.Translation,
-		ReviewCount: 0,
-		CreatedAt:   now,
-		UpdatedAt:   now,
+	}, time.Now())
+	if err != nil {
+		return "", err
 	}
 
-	err := s.repo.Create(ctx, item)
+	return cards[0].SourceID, nil
+}
+
+// AddReviewItems はユーザーの学習項目ごとに種類別のカードを追加し、追加したカードを返す
+// 同じ種類で同じテキスト（大文字・小文字と空白の違いは無視）の項目がすでにある場合は追加しない
+func (s *SRSService) AddReviewItems(ctx context.Context, userID string, candidates []*models.ReviewItem) ([]*models.ReviewItem, error) {
+	seen, err := s.existingKeys(ctx, userID)
 	if err != nil {
-		return uuid.Nil, err
+		return nil, err
 	}
 
-	return item.ID, nil
+	now := time.Now()
+	added := make([]*models.ReviewItem, 0, len(candidates))
+	for _, candidate := range candidates {
+		key := reviewItemKey(candidate.Type, candidate.Text)
+		if strings.TrimSpace(candidate.Text) == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		source := *candidate
+		source.UserID = userID
+		cards, err := s.createCards(ctx, &source, now)
+		added = append(added, cards...)
+		if err != nil {
+			return added, err
+		}
+	}
+
+	return added, nil
+}
+
+// newReviewItem は未学習の復習項目を作成する（次回復習日は作成日時、カードの種類は newCards で設定する）
+func newReviewItem(item *models.ReviewItem, now time.Time) *models.ReviewItem {
+	created := *item
+	created.ID = uuid.New().String()
+	created.EaseFactor = srs.NewState().EaseFactor
+	created.IntervalDays = 0
+	created.ReviewCount = 0
+	created.NextReview = now
+	created.LastReviewed = time.Time{}
+	created.CreatedAt = now
+	created.UpdatedAt = now
+	return &created
+}
+
+// existingKeys はユーザーの復習項目の重複判定用のキーを返す
+func (s *SRSService) existingKeys(ctx context.Context, userID string) (map[string]bool, error) {
+	existing, err := s.repo.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool, len(existing))
+	for _, item := range existing {
+		seen[reviewItemKey(item.Type, item.Text)] = true
+	}
+	return seen, nil
+}
+
+// reviewItemKey は重複を判定するための復習項目のキーを返す
+func reviewItemKey(itemType, text string) string {
+	return itemType + "\x00" + strings.ToLower(strings.Join(strings.Fields(text), " "))
 }
 
 // BulkCreateReviewItems は複数の復習項目を一括作成
-func (s *SRSService) BulkCreateReviewItems(ctx context.Context, phrases []*PhraseData) ([]uuid.UUID, error) {
-	itemIDs := make([]uuid.UUID, 0, len(phrases))
+func (s *SRSService) BulkCreateReviewItems(ctx context.Context, phrases []*PhraseData) ([]string, error) {
+	itemIDs := make([]string, 0, len(phrases))
 
 	for _, phrase := range phrases {
 		itemID, err := s.CreateReviewItem(ctx, phrase)
@@ -171,12 +510,24 @@ func (s *SRSService) BulkCreateReviewItems(ctx context.Context, phrases []*Phras
 	return itemIDs, nil
 }
 
-// GetDueItems は今日復習すべき項目を取得
-func (s *SRSService) GetDueItems(ctx context.Context, userID uuid.UUID, now time.Time) ([]*models.ReviewItem, error) {
-	return s.repo.GetDueItems(ctx, userID, now)
+// GetDueItems は今日復習すべき項目を、優先度と想起確率の予測を付けて取得
+func (s *SRSService) GetDueItems(ctx context.Context, userID string, now time.Time) ([]*models.ReviewItem, error) {
+	items, err := s.repo.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	due := make([]*models.ReviewItem, 0, len(items))
+	for _, item := range items {
+		if !item.Suspended && srs.ShouldReviewToday(item.NextReview, now) {
+			Annotate(item, now)
+			due = append(due, item)
+		}
+	}
+	return due, nil
 }
 
 // GetReviewHistory は復習履歴を取得
-func (s *SRSService) GetReviewHistory(ctx context.Context, itemID uuid.UUID) ([]models.ReviewHistory, error) {
-	return s.repo.GetHistoriesByItemID(ctx, itemID)
+func (s *SRSService) GetReviewHistory(ctx context.Context, itemID string) ([]*models.ReviewHistory, error) {
+	return s.repo.FindHistoryByItemID(ctx, itemID)
 }
@@ -6,173 +6,185 @@ import (
 	"time"
 
 	"github.com/clearclown/HaiLanGo/backend/internal/models"
+	"github.com/clearclown/HaiLanGo/backend/internal/repository"
+	"github.com/clearclown/HaiLanGo/backend/pkg/srs"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
+// newTestReviewItem はテスト用の復習項目を作成する
+func newTestReviewItem(t *testing.T, repo repository.ReviewRepository, userID string, nextReview time.Time) *models.ReviewItem {
+	t.Helper()
+
+	item := &models.ReviewItem{
+		UserID:      userID,
+		BookID:      uuid.New().String(),
+		PageNumber:  1,
+		Type:        "phrase",
+		Text:        "Test",
+		Translation: "テスト",
+		EaseFactor:  srs.DefaultEaseFactor,
+		NextReview:  nextReview,
+	}
+	require.NoError(t, repo.Create(context.Background(), item))
+	return item
+}
+
 // TestGetReviewItemsWithPriority は優先度付き復習項目の取得をテスト
 func TestGetReviewItemsWithPriority(t *testing.T) {
 	ctx := context.Background()
-	service := NewMockSRSService()
+	repo := repository.NewInMemoryReviewRepository()
+	service := NewSRSService(repo)
 
-	userID := uuid.New()
-	bookID := uuid.New()
+	userID := uuid.New().String()
 	now := time.Now()
 
 	// 異なる優先度の項目を作成
-	items := []*models.ReviewItem{
-		{
-			ID:             uuid.New(),
-			UserID:         userID,
-			BookID:         bookID,
-			PageNumber:     1,
-			Content:        "Urgent 1",
-			NextReviewDate: &[]time.Time{now.AddDate(0, 0, -2)}[0],
-		},
-		{
-			ID:             uuid.New(),
-			UserID:         userID,
-			BookID:         bookID,
-			PageNumber:     2,
-			Content:        "Urgent 2",
-			NextReviewDate: &[]time.Time{now.AddDate(0, 0, -1)}[0],
-		},
-		{
-			ID:             uuid.New(),
-			UserID:         userID,
-			BookID:         bookID,
-			PageNumber:     3,
-			Content:        "Recommended",
-			NextReviewDate: &[]time.Time{now.AddDate(0, 0, 1)}[0],
-		},
-		{
-			ID:             uuid.New(),
-			UserID:         userID,
-			BookID:         bookID,
-			PageNumber:     4,
-			Content:        "Relaxed",
-			NextReviewDate: &[]time.Time{now.AddDate(0, 0, 3)}[0],
-		},
-	}
-
-	for _, item := range items {
-		service.items[item.ID] = item
-	}
+	newTestReviewItem(t, repo, userID, now.AddDate(0, 0, -2))
+	newTestReviewItem(t, repo, userID, now.AddDate(0, 0, -1))
+	newTestReviewItem(t, repo, userID, now.Add(36*time.Hour))
+	newTestReviewItem(t, repo, userID, now.AddDate(0, 0, 3))
 
 	result, err := service.GetReviewItems(ctx, userID, now)
 	require.NoError(t, err)
-	assert.NotNil(t, result)
 	assert.Len(t, result.UrgentItems, 2)
 	assert.Len(t, result.RecommendedItems, 1)
-	assert.Len(t, result.RelaxedItems, 1)
+	assert.Len(t, result.OptionalItems, 1)
+	assert.Equal(t, srs.PriorityUrgent, result.UrgentItems[0].Priority)
 }
 
 // TestCompleteReview は復習完了処理をテスト
 func TestCompleteReview(t *testing.T) {
 	ctx := context.Background()
-
-	userID := uuid.New()
-	bookID := uuid.New()
+	userID := uuid.New().String()
 	now := time.Now()
 
 	tests := []struct {
-		name               string
-		score              int
-		expectedReviewCount int
-		checkNextReviewDate bool
+		name                 string
+		score                int
+		expectedReviewCount  int
+		expectedIntervalDays int
 	}{
-		{"高得点（90点）", 90, 1, true},
-		{"中得点（75点）", 75, 1, true},
-		{"低得点（60点）", 60, 1, true},
-		{"最低得点（40点）", 40, 1, true},
+		{"高得点（90点）", 90, 1, 1},
+		{"中得点（75点）", 75, 1, 1},
+		{"低得点（60点）", 60, 1, 1},
+		{"最低得点（40点）", 40, 1, 1},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			// 各テストケースで新しいserviceとitemを作成
-			service := NewMockSRSService()
-
-			item := &models.ReviewItem{
-				ID:             uuid.New(),
-				UserID:         userID,
-				BookID:         bookID,
-				PageNumber:     1,
-				Content:        "Test",
-				ReviewCount:    0,
-				LastReviewDate: &now,
-			}
-
-			service.items[item.ID] = item
+			repo := repository.NewInMemoryReviewRepository()
+			service := NewSRSService(repo)
+			item := newTestReviewItem(t, repo, userID, now)
 
-			err := service.CompleteReview(ctx, item.ID, tt.score, 30)
+			updated, err := service.CompleteReview(ctx, userID, item.ID, tt.score, 30)
 			require.NoError(t, err)
-
-			updated := service.items[item.ID]
 			assert.Equal(t, tt.expectedReviewCount, updated.ReviewCount)
-			assert.Equal(t, tt.score, updated.LastScore)
+			assert.Equal(t, tt.expectedIntervalDays, updated.IntervalDays)
+			assert.True(t, updated.NextReview.After(now))
+			assert.False(t, updated.LastReviewed.Before(now))
 
-			if tt.checkNextReviewDate {
-				assert.NotNil(t, updated.NextReviewDate)
-				assert.True(t, updated.NextReviewDate.After(now))
-			}
+			histories, err := service.GetReviewHistory(ctx, item.ID)
+			require.NoError(t, err)
+			require.Len(t, histories, 1)
+			assert.Equal(t, tt.score, histories[0].Score)
+			assert.Equal(t, 30, histories[0].TimeSpentSec)
 		})
 	}
 }
 
+// TestCompleteReview_SelectedAlgorithm はユーザーが選択したアルゴリズムで間隔を計算することをテスト
+func TestCompleteReview_SelectedAlgorithm(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewInMemoryReviewRepository()
+	service := NewSRSService(repo)
+
+	userID := uuid.New().String()
+	item := newTestReviewItem(t, repo, userID, time.Now())
+
+	// デフォルトはSM-2（1日 → 6日）
+	settings, err := service.GetSettings(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, string(srs.AlgorithmSM2), settings.Algorithm)
+
+	updated, err := service.CompleteReview(ctx, userID, item.ID, 90, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 1, updated.IntervalDays)
+	updated, err = service.CompleteReview(ctx, userID, item.ID, 90, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 6, updated.IntervalDays)
+
+	// 固定間隔に切り替えると復習回数を引き継いで3回目の間隔（7日 × 1.5）になる
+	_, err = service.UpdateAlgorithm(ctx, userID, string(srs.AlgorithmLadder))
+	require.NoError(t, err)
+
+	updated, err = service.CompleteReview(ctx, userID, item.ID, 90, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 10, updated.IntervalDays)
+	assert.Equal(t, 3, updated.ReviewCount)
+
+	_, err = service.UpdateAlgorithm(ctx, userID, "unknown")
+	assert.ErrorIs(t, err, srs.ErrUnknownAlgorithm)
+}
+
+// TestCompleteReview_Forbidden は他のユーザーの復習項目を更新できないことをテスト
+func TestCompleteReview_Forbidden(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewInMemoryReviewRepository()
+	service := NewSRSService(repo)
+
+	item := newTestReviewItem(t, repo, uuid.New().String(), time.Now())
+
+	_, err := service.CompleteReview(ctx, uuid.New().String(), item.ID, 90, 10)
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	_, err = service.CompleteReview(ctx, item.UserID, uuid.New().String(), 90, 10)
+	assert.ErrorIs(t, err, repository.ErrReviewItemNotFound)
+}
+
 // TestGetReviewStats は統計情報の取得をテスト
 func TestGetReviewStats(t *testing.T) {
 	ctx := context.Background()
-	service := NewMockSRSService()
+	repo := repository.NewInMemoryReviewRepository()
+	service := NewSRSService(repo)
 
-	userID := uuid.New()
-	bookID := uuid.New()
+	userID := uuid.New().String()
 	now := time.Now()
 
 	// テストデータを作成
 	for i := 0; i < 10; i++ {
-		var nextReviewDate time.Time
+		var nextReview time.Time
 		if i < 3 {
 			// 緊急項目
-			nextReviewDate = now.AddDate(0, 0, -1)
+			nextReview = now.AddDate(0, 0, -1)
 		} else if i < 7 {
 			// 推奨項目
-			nextReviewDate = now.AddDate(0, 0, 1)
+			nextReview = now.Add(36 * time.Hour)
 		} else {
 			// 余裕あり項目
-			nextReviewDate = now.AddDate(0, 0, 3)
-		}
-
-		item := &models.ReviewItem{
-			ID:             uuid.New(),
-			UserID:         userID,
-			BookID:         bookID,
-			PageNumber:     i + 1,
-			Content:        "Test",
-			NextReviewDate: &nextReviewDate,
+			nextReview = now.AddDate(0, 0, 3)
 		}
-		service.items[item.ID] = item
+		newTestReviewItem(t, repo, userID, nextReview)
 	}
 
 	stats, err := service.GetStats(ctx, userID, now)
 	require.NoError(t, err)
-	assert.Equal(t, 10, stats.TotalReviewItems)
-	assert.Equal(t, 3, stats.UrgentItems)
-	assert.Equal(t, 4, stats.RecommendedItems)
-	assert.Equal(t, 3, stats.RelaxedItems)
+	assert.Equal(t, 3, stats.UrgentCount)
+	assert.Equal(t, 4, stats.RecommendedCount)
+	assert.Equal(t, 3, stats.OptionalCount)
 }
 
 // TestCreateReviewItemFromPhrase はフレーズから復習項目を作成をテスト
 func TestCreateReviewItemFromPhrase(t *testing.T) {
 	ctx := context.Background()
-	service := NewMockSRSService()
-
-	userID := uuid.New()
-	bookID := uuid.New()
+	repo := repository.NewInMemoryReviewRepository()
+	service := NewSRSService(repo)
 
 	phraseData := &PhraseData{
-		UserID:      userID,
-		BookID:      bookID,
+		UserID:      uuid.New(),
+		BookID:      uuid.New(),
 		PageNumber:  1,
 		Content:     "Здравствуйте!",
 		Translation: "こんにちは",
@@ -180,20 +192,23 @@ func TestCreateReviewItemFromPhrase(t *testing.T) {
 
 	itemID, err := service.CreateReviewItem(ctx, phraseData)
 	require.NoError(t, err)
-	assert.NotEqual(t, uuid.Nil, itemID)
+	assert.NotEmpty(t, itemID)
 
 	// 作成された項目を確認
-	item := service.items[itemID]
-	assert.NotNil(t, item)
-	assert.Equal(t, phraseData.Content, item.Content)
+	item, err := repo.FindByID(ctx, itemID)
+	require.NoError(t, err)
+	assert.Equal(t, phraseData.Content, item.Text)
+	assert.Equal(t, phraseData.UserID.String(), item.UserID)
 	assert.Equal(t, 0, item.ReviewCount)
-	assert.Nil(t, item.NextReviewDate) // 初回はまだ復習日未設定
+	assert.Equal(t, srs.DefaultEaseFactor, item.EaseFactor)
+	assert.Equal(t, srs.PriorityUrgent, srs.Priority(item.NextReview, time.Now())) // 作成直後から復習できる
 }
 
 // TestBulkCreateReviewItems は一括作成をテスト
 func TestBulkCreateReviewItems(t *testing.T) {
 	ctx := context.Background()
-	service := NewMockSRSService()
+	repo := repository.NewInMemoryReviewRepository()
+	service := NewSRSService(repo)
 
 	userID := uuid.New()
 	bookID := uuid.New()
@@ -210,133 +225,29 @@ func TestBulkCreateReviewItems(t *testing.T) {
 
 	// すべての項目が作成されたことを確認
 	for _, id := range itemIDs {
-		assert.NotNil(t, service.items[id])
-	}
-}
-
-// MockSRSService はテスト用のモックサービス
-type MockSRSService struct {
-	items     map[uuid.UUID]*models.ReviewItem
-	histories []models.ReviewHistory
-}
-
-func NewMockSRSService() *MockSRSService {
-	return &MockSRSService{
-		items:     make(map[uuid.UUID]*models.ReviewItem),
-		histories: make([]models.ReviewHistory, 0),
-	}
-}
-
-func (m *MockSRSService) GetReviewItems(ctx context.Context, userID uuid.UUID, now time.Time) (*ReviewItemsByPriority, error) {
-	result := &ReviewItemsByPriority{
-		UrgentItems:      make([]*models.ReviewItem, 0),
-		RecommendedItems: make([]*models.ReviewItem, 0),
-		RelaxedItems:     make([]*models.ReviewItem, 0),
-	}
-
-	for _, item := range m.items {
-		if item.UserID == userID {
-			priority := item.GetPriority(now)
-			switch priority {
-			case models.PriorityUrgent:
-				result.UrgentItems = append(result.UrgentItems, item)
-			case models.PriorityRecommended:
-				result.RecommendedItems = append(result.RecommendedItems, item)
-			case models.PriorityRelaxed:
-				result.RelaxedItems = append(result.RelaxedItems, item)
-			}
-		}
-	}
-
-	return result, nil
-}
-
-func (m *MockSRSService) CompleteReview(ctx context.Context, itemID uuid.UUID, score int, timeSpentSec int) error {
-	item, ok := m.items[itemID]
-	if !ok {
-		return nil
-	}
-
-	now := time.Now()
-
-	// 次回復習日を計算（srs.CalculateNextReviewDate を使用）
-	// ここではモックなので簡易的な実装
-	item.ReviewCount++
-	item.LastScore = score
-	item.LastReviewDate = &now
-
-	nextDate := now.AddDate(0, 0, 3) // 簡易的に3日後
-	item.NextReviewDate = &nextDate
-
-	// 履歴を記録
-	history := models.ReviewHistory{
-		ID:           uuid.New(),
-		ReviewItemID: itemID,
-		UserID:       item.UserID,
-		Score:        score,
-		ReviewedAt:   now,
-		TimeSpentSec: timeSpentSec,
+		item, err := repo.FindByID(ctx, id)
+		require.NoError(t, err)
+		assert.NotNil(t, item)
 	}
-	m.histories = append(m.histories, history)
-
-	return nil
-}
-
-func (m *MockSRSService) GetStats(ctx context.Context, userID uuid.UUID, now time.Time) (*models.ReviewStats, error) {
-	stats := &models.ReviewStats{
-		UserID: userID,
-	}
-
-	for _, item := range m.items {
-		if item.UserID == userID {
-			stats.TotalReviewItems++
-
-			if item.NextReviewDate != nil {
-				priority := item.GetPriority(now)
-				switch priority {
-				case models.PriorityUrgent:
-					stats.UrgentItems++
-				case models.PriorityRecommended:
-					stats.RecommendedItems++
-				case models.PriorityRelaxed:
-					stats.RelaxedItems++
-				}
-			}
-		}
-	}
-
-	return stats, nil
 }
 
-func (m *MockSRSService) CreateReviewItem(ctx context.Context, data *PhraseData) (uuid.UUID, error) {
-	itemID := uuid.New()
-	item := &models.ReviewItem{
-		ID:          itemID,
-		UserID:      data.UserID,
-		BookID:      data.BookID,
-		PageNumber:  data.PageNumber,
-		ItemType:    "phrase",
-		Content:     data.Content,
-		Translation: data.Translation,
-		ReviewCount: 0,
-		CreatedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
-	}
-
-	m.items[itemID] = item
-	return itemID, nil
-}
-
-func (m *MockSRSService) BulkCreateReviewItems(ctx context.Context, phrases []*PhraseData) ([]uuid.UUID, error) {
-	itemIDs := make([]uuid.UUID, 0, len(phrases))
-
-	for _, phrase := range phrases {
-		itemID, err := m.CreateReviewItem(ctx, phrase)
-		if err != nil {
-			return nil, err
-		}
-		itemIDs = append(itemIDs, itemID)
-	}
-
-	return itemIDs, nil
+// TestBalanceNextReview は復習予定の件数による次回復習日の分散をテスト
+func TestBalanceNextReview(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewInMemoryReviewRepository()
+	service := NewSRSService(repo)
+
+	userID := uuid.New().String()
+	now := time.Date(2025, 11, 13, 12, 0, 0, 0, time.UTC)
+	for _, days := range []int{28, 29, 30, 30, 32} {
+		newTestReviewItem(t, repo, userID, now.AddDate(0, 0, days))
+	}
+	// 他のユーザーと範囲外の予定は数えない
+	newTestReviewItem(t, repo, uuid.New().String(), now.AddDate(0, 0, 31))
+	newTestReviewItem(t, repo, userID, now.AddDate(0, 0, 40))
+	item := newTestReviewItem(t, repo, userID, now.AddDate(0, 0, 31))
+
+	// 30日後（28〜32日）のうち、予定の少ない31日後を選ぶ（自分自身の予定は数えない）
+	state := srs.State{IntervalDays: 30, NextReview: now.AddDate(0, 0, 30)}
+	assert.Equal(t, now.AddDate(0, 0, 31), service.balanceNextReview(ctx, item, state, now))
 }
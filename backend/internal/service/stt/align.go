@@ -0,0 +1,73 @@
+package stt
+
+// alignOp はアライメントの操作
+type alignOp int
+
+const (
+	alignMatch        alignOp = iota // 一致
+	alignSubstitution                // 置換
+	alignDeletion                    // 脱落（期待される要素だけ）
+	alignInsertion                   // 挿入（認識された要素だけ）
+)
+
+// alignedPair はアライメントで対応付けた要素の組（対応する要素がない側は -1）
+type alignedPair struct {
+	op         alignOp
+	expected   int
+	recognized int
+}
+
+// alignSequences は長さ n の期待される列と長さ m の認識された列を、編集コストが最小になるよう対応付ける
+// 脱落と挿入のコストは1、置換のコストは substitution(i, j)（0-1、0は一致）
+// コストが同じ場合は、位置の揃った対応（一致・置換）を優先する
+func alignSequences(n, m int, substitution func(i, j int) float64) []alignedPair {
+	// cost[i][j] は期待される列の先頭 i 個と認識された列の先頭 j 個の最小コスト
+	cost := make([][]float64, n+1)
+	for i := range cost {
+		cost[i] = make([]float64, m+1)
+		cost[i][0] = float64(i)
+	}
+	for j := 0; j <= m; j++ {
+		cost[0][j] = float64(j)
+	}
+
+	subCost := make([][]float64, n)
+	for i := 0; i < n; i++ {
+		subCost[i] = make([]float64, m)
+		for j := 0; j < m; j++ {
+			subCost[i][j] = substitution(i, j)
+			cost[i+1][j+1] = min(
+				cost[i][j]+subCost[i][j],
+				cost[i][j+1]+1,
+				cost[i+1][j]+1,
+			)
+		}
+	}
+
+	// 末尾から辿る
+	var pairs []alignedPair
+	i, j := n, m
+	for i > 0 || j > 0 {
+		switch {
+		case i > 0 && j > 0 && cost[i][j] == cost[i-1][j-1]+subCost[i-1][j-1]:
+			op := alignSubstitution
+			if subCost[i-1][j-1] == 0 {
+				op = alignMatch
+			}
+			pairs = append(pairs, alignedPair{op: op, expected: i - 1, recognized: j - 1})
+			i--
+			j--
+		case i > 0 && cost[i][j] == cost[i-1][j]+1:
+			pairs = append(pairs, alignedPair{op: alignDeletion, expected: i - 1, recognized: -1})
+			i--
+		default:
+			pairs = append(pairs, alignedPair{op: alignInsertion, expected: -1, recognized: j - 1})
+			j--
+		}
+	}
+
+	for left, right := 0, len(pairs)-1; left < right; left, right = left+1, right-1 {
+		pairs[left], pairs[right] = pairs[right], pairs[left]
+	}
+	return pairs
+}
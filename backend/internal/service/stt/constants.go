@@ -7,6 +7,8 @@ const (
 	ErrInvalidLanguage    = "無効な言語コード"
 	ErrSTTRecognition     = "音声認識に失敗しました"
 	ErrAudioProcessing    = "音声処理に失敗しました"
+	ErrInvalidSampleRate  = "無効なサンプリングレート"
+	ErrStreamTooLong      = "ストリーミングの音声が長すぎます"
 )
 
 // 評価スコアの境界値
@@ -33,6 +35,13 @@ const (
 	WordScoreCorrectMin   = 80    // 単語が正しいと判断される最小スコア
 )
 
+// ストリーミング評価の定数
+const (
+	MinStreamSampleRate = 8000  // ストリーミングで受け付ける最小のサンプリングレート（Hz）
+	MaxStreamSampleRate = 48000 // ストリーミングで受け付ける最大のサンプリングレート（Hz）
+	MaxStreamDuration   = 60    // ストリーミングで受け付ける音声の最大の長さ（秒）
+)
+
 // フィードバックレベル
 const (
 	FeedbackLevelExcellent = "excellent"
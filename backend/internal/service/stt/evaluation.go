@@ -48,13 +48,6 @@ func CalculateFluencyScore(words []models.WordInfo, duration float64) int {
 		return 0
 	}
 
-	// 単語あたりの平均時間を計算（秒）
-	avgTimePerWord := duration / float64(len(words))
-
-	// 理想的な単語あたりの時間（秒）- 自然な会話速度
-	// 英語では約0.5秒/単語が自然
-	idealTime := 0.5
-
 	// 間隔の安定性を計算
 	var gaps []float64
 	for i := 0; i < len(words)-1; i++ {
@@ -62,6 +55,41 @@ func CalculateFluencyScore(words []models.WordInfo, duration float64) int {
 		gaps = append(gaps, gap)
 	}
 
+	return fluencyScore(len(words), duration, gaps)
+}
+
+// CalculateSpeechFluencyScore は発話区間の中だけで流暢性スコアを計算する（0-100点）
+// 録音の前後の無音や発話の間の長い無音を含めないよう、発話区間の合計の長さを話した時間とし、
+// 単語の間隔は同じ発話区間の単語の間だけで求める。発話区間がない場合は0を返す
+func CalculateSpeechFluencyScore(words []models.WordInfo, segments []models.SpeechSegment) int {
+	var duration float64
+	for _, segment := range segments {
+		duration += segment.End - segment.Start
+	}
+	if len(words) == 0 || duration <= 0 {
+		return 0
+	}
+
+	var gaps []float64
+	for i := 0; i < len(words)-1; i++ {
+		if speechSegmentIndex(words[i], segments) != speechSegmentIndex(words[i+1], segments) {
+			continue
+		}
+		gaps = append(gaps, words[i+1].StartTime-words[i].EndTime)
+	}
+
+	return fluencyScore(len(words), duration, gaps)
+}
+
+// fluencyScore は単語数・話した時間（秒）・単語の間隔から流暢性スコアを計算する（0-100点）
+func fluencyScore(wordCount int, duration float64, gaps []float64) int {
+	// 単語あたりの平均時間を計算（秒）
+	avgTimePerWord := duration / float64(wordCount)
+
+	// 理想的な単語あたりの時間（秒）- 自然な会話速度
+	// 英語では約0.5秒/単語が自然
+	idealTime := IdealTimePerWord
+
 	// 間隔の標準偏差を計算
 	gapVariance := calculateVariance(gaps)
 
@@ -79,30 +107,208 @@ func CalculateFluencyScore(words []models.WordInfo, duration float64) int {
 	return int(totalScore)
 }
 
-// CalculatePronunciationScore は発音スコアを計算する（0-100点）
-func CalculatePronunciationScore(expectedWords, recognizedWords []models.WordInfo) int {
-	if len(expectedWords) == 0 {
-		return 0
+// speechSegmentIndex は単語の中央の時刻に最も近い発話区間の番号を返す
+func speechSegmentIndex(word models.WordInfo, segments []models.SpeechSegment) int {
+	middle := (word.StartTime + word.EndTime) / 2
+	best, bestDistance := 0, math.Inf(1)
+	for i, segment := range segments {
+		distance := math.Max(0, math.Max(segment.Start-middle, middle-segment.End))
+		if distance < bestDistance {
+			best, bestDistance = i, distance
+		}
 	}
+	return best
+}
 
+// CalculatePronunciationScore は単語ごとのスコアから発音スコアを計算する（0-100点）
+// 期待される単語のスコアの平均で、余分に発音された（挿入された）単語は含めない
+func CalculatePronunciationScore(wordScores []models.WordScore) int {
 	totalScore := 0
-	matchCount := 0
+	wordCount := 0
 
-	// 各単語の発音スコアを計算
-	for i := 0; i < len(expectedWords) && i < len(recognizedWords); i++ {
-		expectedWord := strings.ToLower(expectedWords[i].Word)
-		recognizedWord := strings.ToLower(recognizedWords[i].Word)
+	for _, wordScore := range wordScores {
+		if wordScore.ErrorType == models.PronunciationErrorInsertion {
+			continue
+		}
+		totalScore += wordScore.Score
+		wordCount++
+	}
 
-		wordScore := CalculateAccuracyScore(expectedWord, recognizedWord)
-		totalScore += wordScore
-		matchCount++
+	if wordCount == 0 {
+		return 0
 	}
 
-	if matchCount == 0 {
+	return totalScore / wordCount
+}
+
+// scoredWord はスコアを計算する単語（正規化した綴りと音素列）
+type scoredWord struct {
+	text       string
+	normalized string
+	phonemes   []string
+}
+
+// ScoreWords は期待される単語と認識された単語を対応付け、単語ごとのスコアと音素の誤りを計算する
+// 単語は音素列の編集距離をコストとする動的計画法で対応付けるため、単語の挿入・脱落があっても後の単語の対応はずれない
+// 単語のスコアは音素列の編集距離から計算する（綴りが違っても発音が同じ単語は正しいとみなす）
+func ScoreWords(g2p *G2P, expectedWords, recognizedWords []models.WordInfo, language string) []models.WordScore {
+	if !spacedLanguage(language) {
+		expectedWords = joinWords(expectedWords)
+		recognizedWords = joinWords(recognizedWords)
+	}
+	expected := prepareWords(g2p, expectedWords, language)
+	recognized := prepareWords(g2p, recognizedWords, language)
+
+	pairs := alignSequences(len(expected), len(recognized), func(i, j int) float64 {
+		if expected[i].normalized == recognized[j].normalized {
+			return 0
+		}
+		return phonemeDistance(expected[i].phonemes, recognized[j].phonemes)
+	})
+
+	wordScores := make([]models.WordScore, 0, len(pairs))
+	for _, pair := range pairs {
+		switch pair.op {
+		case alignDeletion:
+			word := expected[pair.expected]
+			wordScores = append(wordScores, models.WordScore{
+				Word:         word.text,
+				ExpectedWord: word.text,
+				ErrorType:    models.PronunciationErrorDeletion,
+				Phonemes:     word.phonemes,
+			})
+		case alignInsertion:
+			word := recognized[pair.recognized]
+			wordScores = append(wordScores, models.WordScore{
+				Word:           word.text,
+				RecognizedWord: word.text,
+				ErrorType:      models.PronunciationErrorInsertion,
+			})
+		default:
+			wordScores = append(wordScores, scoreWord(expected[pair.expected], recognized[pair.recognized]))
+		}
+	}
+
+	return wordScores
+}
+
+// scoreWord は対応付けた単語の音素の誤りとスコアを計算する
+func scoreWord(expected, recognized scoredWord) models.WordScore {
+	wordScore := models.WordScore{
+		Word:           expected.text,
+		Score:          100,
+		IsCorrect:      true,
+		ExpectedWord:   expected.text,
+		RecognizedWord: recognized.text,
+		Phonemes:       expected.phonemes,
+	}
+	if expected.normalized == recognized.normalized {
+		return wordScore
+	}
+
+	pairs := alignSequences(len(expected.phonemes), len(recognized.phonemes), func(i, j int) float64 {
+		if expected.phonemes[i] == recognized.phonemes[j] {
+			return 0
+		}
+		return 1
+	})
+
+	// 挿入の位置は直後の期待される音素の位置
+	position := 0
+	for _, pair := range pairs {
+		switch pair.op {
+		case alignMatch:
+			position = pair.expected + 1
+		case alignSubstitution:
+			wordScore.PhonemeErrors = append(wordScore.PhonemeErrors, models.PhonemeError{
+				Type:     models.PronunciationErrorSubstitution,
+				Expected: expected.phonemes[pair.expected],
+				Actual:   recognized.phonemes[pair.recognized],
+				Position: pair.expected,
+			})
+			position = pair.expected + 1
+		case alignDeletion:
+			wordScore.PhonemeErrors = append(wordScore.PhonemeErrors, models.PhonemeError{
+				Type:     models.PronunciationErrorDeletion,
+				Expected: expected.phonemes[pair.expected],
+				Position: pair.expected,
+			})
+			position = pair.expected + 1
+		case alignInsertion:
+			wordScore.PhonemeErrors = append(wordScore.PhonemeErrors, models.PhonemeError{
+				Type:     models.PronunciationErrorInsertion,
+				Actual:   recognized.phonemes[pair.recognized],
+				Position: position,
+			})
+		}
+	}
+
+	if len(wordScore.PhonemeErrors) > 0 {
+		longest := max(len(expected.phonemes), len(recognized.phonemes))
+		wordScore.Score = max(0, int(math.Round(100*(1-float64(len(wordScore.PhonemeErrors))/float64(longest)))))
+		wordScore.IsCorrect = wordScore.Score >= WordScoreCorrectMin
+		wordScore.ErrorType = models.PronunciationErrorSubstitution
+	}
+	return wordScore
+}
+
+// prepareWords は単語を正規化して音素列に変換する（正規化して空になる単語は除く）
+func prepareWords(g2p *G2P, words []models.WordInfo, language string) []scoredWord {
+	base := baseLanguage(language)
+	prepared := make([]scoredWord, 0, len(words))
+	for _, word := range words {
+		normalized := normalizeWord(word.Word, base)
+		if normalized == "" {
+			continue
+		}
+		prepared = append(prepared, scoredWord{
+			text:       word.Word,
+			normalized: normalized,
+			phonemes:   g2p.Phonemes(word.Word, language),
+		})
+	}
+	return prepared
+}
+
+// joinWords は単語をつなげてひとつの単語にする（単語がない場合はそのまま返す）
+func joinWords(words []models.WordInfo) []models.WordInfo {
+	if len(words) == 0 {
+		return words
+	}
+
+	var b strings.Builder
+	var confidence float64
+	for _, word := range words {
+		b.WriteString(word.Word)
+		confidence += word.Confidence
+	}
+	return []models.WordInfo{{
+		Word:       b.String(),
+		StartTime:  words[0].StartTime,
+		EndTime:    words[len(words)-1].EndTime,
+		Confidence: confidence / float64(len(words)),
+	}}
+}
+
+// phonemeDistance は音素列の編集距離を長い方の長さで割った値を返す（0-1）
+func phonemeDistance(a, b []string) float64 {
+	longest := max(len(a), len(b))
+	if longest == 0 {
 		return 0
 	}
 
-	return totalScore / matchCount
+	edits := 0
+	for _, pair := range alignSequences(len(a), len(b), func(i, j int) float64 {
+		if a[i] == b[j] {
+			return 0
+		}
+		return 1
+	}) {
+		if pair.op != alignMatch {
+			edits++
+		}
+	}
+	return float64(edits) / float64(longest)
 }
 
 // GenerateFeedback はスコアに基づいてフィードバックを生成する
@@ -169,13 +375,27 @@ func GenerateFeedback(score *models.PronunciationScore) *models.Feedback {
 
 	// 単語レベルの改善点を追加
 	for _, wordScore := range score.WordScores {
-		if !wordScore.IsCorrect && wordScore.Score < 70 {
-			advice := fmt.Sprintf("「%s」の発音を練習してください（認識結果: %s）",
-				wordScore.ExpectedWord, wordScore.RecognizedWord)
-			feedback.SpecificAdvice = append(feedback.SpecificAdvice, advice)
+		switch wordScore.ErrorType {
+		case models.PronunciationErrorDeletion:
+			feedback.SpecificAdvice = append(feedback.SpecificAdvice,
+				fmt.Sprintf("「%s」が発音されていません", wordScore.ExpectedWord))
+		case models.PronunciationErrorInsertion:
+			feedback.SpecificAdvice = append(feedback.SpecificAdvice,
+				fmt.Sprintf("余分な単語「%s」が入っています", wordScore.RecognizedWord))
+		default:
+			if !wordScore.IsCorrect && wordScore.Score < 70 {
+				advice := fmt.Sprintf("「%s」の発音を練習してください（認識結果: %s）",
+					wordScore.ExpectedWord, wordScore.RecognizedWord)
+				feedback.SpecificAdvice = append(feedback.SpecificAdvice, advice)
+			}
 		}
 	}
 
+	// 音素レベルの改善点を追加
+	advice, focus := phonemeFeedback(score.WordScores)
+	feedback.SpecificAdvice = append(feedback.SpecificAdvice, advice...)
+	feedback.FocusPhonemes = focus
+
 	return feedback
 }
 
@@ -0,0 +1,125 @@
+package stt
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/clearclown/HaiLanGo/backend/internal/models"
+)
+
+// maxPhonemeAdvice は音素のアドバイスの最大数（多い誤りから順に示す）
+const maxPhonemeAdvice = 3
+
+// phonemeTips は音素ごとの発音のコツ
+var phonemeTips = map[string]string{
+	"θ":  "舌先を上の前歯に軽く当て、すき間から息を出します（「ス」にならないように）",
+	"ð":  "舌先を上の前歯に軽く当て、声を出しながら息を出します（「ズ」にならないように）",
+	"ɹ":  "舌先をどこにも付けずに少し後ろへ引き、唇を丸めて発音します",
+	"l":  "舌先を上の前歯の裏の歯茎に付けたまま発音します",
+	"r":  "舌先を上の歯茎の近くで震わせて発音します",
+	"ɾ":  "舌先で上の歯茎を1回軽くはじいて発音します",
+	"v":  "上の前歯を下唇に軽く当て、声を出しながら息を出します（「ブ」にならないように）",
+	"f":  "上の前歯を下唇に軽く当てて息を出します（「フ」にならないように）",
+	"æ":  "口を横に大きく開き、「ア」と「エ」の中間の音を出します",
+	"ʌ":  "口をあまり開けずに、短く「ア」と発音します",
+	"ɚ":  "舌を奥へ引いて丸め、こもった「ア」の音を出します",
+	"ɝ":  "舌を奥へ引いて丸め、こもった「アー」の音を出します",
+	"ŋ":  "舌の奥を上あごの奥に付け、鼻から声を出します（後に「グ」を付けないように）",
+	"ʃ":  "唇を少し突き出し、舌をどこにも付けずに「シュ」と息を出します",
+	"ʒ":  "唇を少し突き出し、舌をどこにも付けずに声を出しながら「ジュ」と息を出します",
+	"ʁ":  "舌の奥を上あごの奥に近づけ、のどの奥で息をこするように発音します",
+	"x":  "舌の奥を上あごの奥に近づけ、息をこするように発音します",
+	"ç":  "「ヒ」の口の形で、息を強くこするように発音します",
+	"y":  "「イ」の舌の位置のまま、唇を丸めて発音します",
+	"ø":  "「エ」の舌の位置のまま、唇を丸めて発音します",
+	"ɨ":  "唇を丸めずに舌を少し後ろへ引き、「ウ」と「イ」の中間の音を出します",
+	"ɲ":  "舌の中ほどを上あごに付けて「ニャ」の子音を出します",
+	"ʎ":  "舌の中ほどを上あごに付けて「リャ」に近い音を出します",
+	"ɑ̃": "口を大きく開けた「ア」を、息を鼻にも抜きながら発音します（最後に「ン」を付けないように）",
+	"ɔ̃": "唇を丸めた「オ」を、息を鼻にも抜きながら発音します（最後に「ン」を付けないように）",
+	"ɛ̃": "「エ」を、息を鼻にも抜きながら発音します（最後に「ン」を付けないように）",
+	"N":  "「ん」は1拍分の長さで発音します",
+	"Q":  "小さい「っ」は1拍分の間を取って発音します",
+	"ː":  "伸ばす音は2拍分の長さで発音します",
+	"iː": "「イー」と長めに、唇を横に引いて発音します",
+	"uː": "「ウー」と長めに、唇を丸めて発音します",
+}
+
+// phonemeIssue は同じ種類・音素の誤りをまとめたもの
+type phonemeIssue struct {
+	error models.PhonemeError
+	words []string
+	count int
+	order int // 最初に現れた順番
+}
+
+// phonemeFeedback は音素の誤りを種類と音素ごとにまとめ、多い順にアドバイスと重点的に練習する音素を返す
+func phonemeFeedback(wordScores []models.WordScore) ([]string, []string) {
+	issues := make(map[models.PhonemeError]*phonemeIssue)
+	for _, wordScore := range wordScores {
+		for _, phonemeError := range wordScore.PhonemeErrors {
+			key := models.PhonemeError{Type: phonemeError.Type, Expected: phonemeError.Expected, Actual: phonemeError.Actual}
+			issue, ok := issues[key]
+			if !ok {
+				issue = &phonemeIssue{error: key, order: len(issues)}
+				issues[key] = issue
+			}
+			issue.count++
+			if !containsString(issue.words, wordScore.ExpectedWord) {
+				issue.words = append(issue.words, wordScore.ExpectedWord)
+			}
+		}
+	}
+
+	sorted := make([]*phonemeIssue, 0, len(issues))
+	for _, issue := range issues {
+		sorted = append(sorted, issue)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].count != sorted[j].count {
+			return sorted[i].count > sorted[j].count
+		}
+		return sorted[i].order < sorted[j].order
+	})
+	if len(sorted) > maxPhonemeAdvice {
+		sorted = sorted[:maxPhonemeAdvice]
+	}
+
+	var advice, focus []string
+	for _, issue := range sorted {
+		words := "「" + strings.Join(issue.words, "」「") + "」"
+
+		var message, phoneme string
+		switch issue.error.Type {
+		case models.PronunciationErrorSubstitution:
+			message = fmt.Sprintf("%sの /%s/ が /%s/ になっています", words, issue.error.Expected, issue.error.Actual)
+			phoneme = issue.error.Expected
+		case models.PronunciationErrorDeletion:
+			message = fmt.Sprintf("%sの /%s/ が抜けています", words, issue.error.Expected)
+			phoneme = issue.error.Expected
+		case models.PronunciationErrorInsertion:
+			message = fmt.Sprintf("%sに余分な /%s/ が入っています", words, issue.error.Actual)
+			phoneme = issue.error.Actual
+		}
+
+		if tip, ok := phonemeTips[phoneme]; ok && issue.error.Type != models.PronunciationErrorInsertion {
+			message += "。" + tip
+		}
+		advice = append(advice, message)
+		if !containsString(focus, phoneme) {
+			focus = append(focus, phoneme)
+		}
+	}
+	return advice, focus
+}
+
+// containsString はスライスに文字列が含まれるかどうかを返す
+func containsString(values []string, target string) bool {
+	for _, value := range values {
+		if value == target {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,161 @@
+package stt
+
+import (
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Lexicon は単語の発音（音素列）の辞書
+// CMUdict などの発音辞書を読み込んで G2P に追加すると、規則による変換より優先して使われる
+type Lexicon interface {
+	// Lookup は正規化した（小文字にして句読点を除いた）単語の音素列を返す
+	Lookup(word string) ([]string, bool)
+}
+
+// MapLexicon はマップで表す発音辞書（キーは正規化した単語）
+type MapLexicon map[string][]string
+
+// Lookup は単語の音素列を返す
+func (l MapLexicon) Lookup(word string) ([]string, bool) {
+	phonemes, ok := l[word]
+	return phonemes, ok
+}
+
+// newMapLexicon は単語と空白で区切った音素列の表から発音辞書を作成する
+func newMapLexicon(table map[string]string) MapLexicon {
+	lexicon := make(MapLexicon, len(table))
+	for word, phonemes := range table {
+		lexicon[word] = strings.Fields(phonemes)
+	}
+	return lexicon
+}
+
+// G2P は単語を言語ごとに音素列（IPA）に変換する（grapheme-to-phoneme）
+// 言語の発音辞書に単語があればそれを使い、なければ言語の綴りの規則で変換する
+// 規則のない言語（中国語・アラビア語・ヘブライ語など）や漢字は、文字をそのまま音素として扱う
+type G2P struct {
+	mu       sync.RWMutex
+	lexicons map[string][]Lexicon
+}
+
+// NewG2P は組み込みの発音辞書（英語・フランス語の綴りが不規則な頻出語）を持つ G2P を作成する
+func NewG2P() *G2P {
+	g := &G2P{lexicons: make(map[string][]Lexicon)}
+	g.AddLexicon("en", newMapLexicon(englishLexicon))
+	g.AddLexicon("fr", newMapLexicon(frenchLexicon))
+	return g
+}
+
+// AddLexicon は言語の発音辞書を追加する（後から追加した辞書を優先する）
+func (g *G2P) AddLexicon(language string, lexicon Lexicon) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	base := baseLanguage(language)
+	g.lexicons[base] = append([]Lexicon{lexicon}, g.lexicons[base]...)
+}
+
+// Phonemes は単語の音素列を返す（正規化して空になる単語は空のスライス）
+func (g *G2P) Phonemes(word, language string) []string {
+	base := baseLanguage(language)
+	word = normalizeWord(word, base)
+	if word == "" {
+		return []string{}
+	}
+
+	g.mu.RLock()
+	lexicons := g.lexicons[base]
+	g.mu.RUnlock()
+	for _, lexicon := range lexicons {
+		if phonemes, ok := lexicon.Lookup(word); ok {
+			return append([]string(nil), phonemes...)
+		}
+	}
+
+	if rules, ok := languageRules[base]; ok {
+		return rules.convert(word)
+	}
+
+	phonemes := make([]string, 0, len(word))
+	for _, r := range word {
+		phonemes = append(phonemes, string(r))
+	}
+	return phonemes
+}
+
+// baseLanguage は言語コードの地域を除いた部分を返す（例: en-US → en）
+func baseLanguage(language string) string {
+	base, _, _ := strings.Cut(strings.ToLower(language), "-")
+	return base
+}
+
+// normalizeWord は単語を小文字にし、句読点と記号を除く（日本語はカタカナをひらがなにする）
+func normalizeWord(word, language string) string {
+	var b strings.Builder
+	for _, r := range word {
+		switch {
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			continue
+		case language == "tr":
+			r = unicode.TurkishCase.ToLower(r)
+		case language == "ja" && r >= 'ァ' && r <= 'ヶ':
+			r -= 'ァ' - 'ぁ'
+		default:
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// spacedLanguage は単語を空白で区切る言語かどうかを返す
+// 日本語と中国語は単語の区切りが音声認識の結果と一致しないため、文全体をひとつの単語として評価する
+func spacedLanguage(language string) bool {
+	switch baseLanguage(language) {
+	case "ja", "zh":
+		return false
+	}
+	return true
+}
+
+// graphemeRules は綴りから音素への変換規則
+// 単語の先頭を ^、末尾を $ で表し、最も長く一致する綴りから順に変換する。規則のない文字はそのまま音素にする
+type graphemeRules struct {
+	rules   map[string][]string
+	longest int
+}
+
+// newGraphemeRules は綴りと空白で区切った音素列（空の場合は発音しない）の表から変換規則を作成する
+func newGraphemeRules(table map[string]string) *graphemeRules {
+	rules := make(map[string][]string, len(table))
+	longest := 0
+	for grapheme, phonemes := range table {
+		rules[grapheme] = strings.Fields(phonemes)
+		longest = max(longest, len([]rune(grapheme)))
+	}
+	return &graphemeRules{rules: rules, longest: longest}
+}
+
+// convert は正規化した単語を音素列に変換する
+func (r *graphemeRules) convert(word string) []string {
+	text := []rune("^" + word + "$")
+	phonemes := []string{}
+	for i := 0; i < len(text); {
+		n := min(r.longest, len(text)-i)
+		for ; n > 0; n-- {
+			if converted, ok := r.rules[string(text[i:i+n])]; ok {
+				phonemes = append(phonemes, converted...)
+				break
+			}
+		}
+		if n == 0 {
+			if text[i] != '^' && text[i] != '$' {
+				phonemes = append(phonemes, string(text[i]))
+			}
+			n = 1
+		}
+		i += n
+	}
+	return phonemes
+}
@@ -0,0 +1,216 @@
+package stt
+
+// 言語ごとの綴りから音素（IPA）への変換規則
+// 綴りと発音の対応が規則的な言語は規則でおおむね変換できる。英語・フランス語のように不規則な言語は、
+// 頻出語を組み込みの発音辞書で補い、それ以外は AddLexicon で発音辞書を追加して精度を上げる
+var languageRules = map[string]*graphemeRules{
+	"en": newGraphemeRules(englishRules),
+	"es": newGraphemeRules(spanishRules),
+	"it": newGraphemeRules(italianRules),
+	"de": newGraphemeRules(germanRules),
+	"fr": newGraphemeRules(withFrenchNasals(frenchRules)),
+	"pt": newGraphemeRules(portugueseRules),
+	"tr": newGraphemeRules(turkishRules),
+	"ru": newGraphemeRules(withRussianIotation(russianRules)),
+	"ja": newGraphemeRules(kanaRules()),
+}
+
+var englishRules = map[string]string{
+	"a": "æ", "b": "b", "c": "k", "d": "d", "e": "ɛ", "f": "f", "g": "ɡ", "h": "h", "i": "ɪ",
+	"j": "dʒ", "k": "k", "l": "l", "m": "m", "n": "n", "o": "ɑ", "p": "p", "q": "k", "r": "ɹ",
+	"s": "s", "t": "t", "u": "ʌ", "v": "v", "w": "w", "x": "k s", "y": "j", "z": "z",
+	// 子音の綴り
+	"sh": "ʃ", "ch": "tʃ", "tch": "tʃ", "th": "θ", "ph": "f", "wh": "w", "ck": "k", "ng": "ŋ", "nk": "ŋ k",
+	"qu": "k w", "dge": "dʒ", "gh": "", "^kn": "n", "^wr": "ɹ",
+	"ce": "s ɛ", "ci": "s ɪ", "cy": "s i", "ce$": "s", "ge$": "dʒ",
+	"bb": "b", "dd": "d", "ff": "f", "gg": "ɡ", "ll": "l", "mm": "m", "nn": "n", "pp": "p", "rr": "ɹ",
+	"ss": "s", "tt": "t", "zz": "z",
+	// 母音の綴り
+	"ee": "iː", "ea": "iː", "oo": "uː", "ou": "aʊ", "ow": "aʊ", "ai": "eɪ", "ay": "eɪ", "ey": "eɪ",
+	"oa": "oʊ", "oi": "ɔɪ", "oy": "ɔɪ", "au": "ɔː", "aw": "ɔː", "ew": "uː", "igh": "aɪ",
+	"er": "ɚ", "ir": "ɝ", "ur": "ɝ", "ar": "ɑ ɹ", "or": "ɔ ɹ",
+	// 語末
+	"e$": "", "le$": "ə l", "o$": "oʊ", "y$": "i",
+}
+
+// englishLexicon は英語の綴りが不規則な頻出語の発音
+var englishLexicon = map[string]string{
+	"a": "ə", "the": "ð ə", "i": "aɪ", "you": "j uː", "is": "ɪ z", "are": "ɑ ɹ", "was": "w ʌ z",
+	"to": "t uː", "of": "ʌ v", "do": "d uː", "does": "d ʌ z", "one": "w ʌ n", "two": "t uː",
+	"said": "s ɛ d", "have": "h æ v", "what": "w ʌ t", "where": "w ɛ ɹ", "there": "ð ɛ ɹ",
+	"they": "ð eɪ", "he": "h iː", "she": "ʃ iː", "we": "w iː", "me": "m iː", "be": "b iː",
+	"my": "m aɪ", "by": "b aɪ", "this": "ð ɪ s", "that": "ð æ t", "with": "w ɪ ð", "hello": "h ə l oʊ",
+	"world": "w ɝ l d", "good": "ɡ ʊ d", "morning": "m ɔ ɹ n ɪ ŋ", "how": "h aʊ", "name": "n eɪ m",
+	"yes": "j ɛ s", "no": "n oʊ", "please": "p l iː z", "sorry": "s ɑ ɹ i", "water": "w ɔ t ɚ",
+	"like": "l aɪ k", "know": "n oʊ", "come": "k ʌ m", "some": "s ʌ m", "very": "v ɛ ɹ i",
+	"thank": "θ æ ŋ k", "thanks": "θ æ ŋ k s", "nice": "n aɪ s", "meet": "m iː t",
+}
+
+var spanishRules = map[string]string{
+	"a": "a", "b": "b", "c": "k", "d": "d", "e": "e", "f": "f", "g": "ɡ", "h": "", "i": "i",
+	"j": "x", "k": "k", "l": "l", "m": "m", "n": "n", "ñ": "ɲ", "o": "o", "p": "p", "q": "k",
+	"r": "ɾ", "s": "s", "t": "t", "u": "u", "v": "b", "w": "w", "x": "k s", "y": "ʝ", "z": "s",
+	"á": "a", "é": "e", "í": "i", "ó": "o", "ú": "u", "ü": "w",
+	"ch": "tʃ", "ll": "ʝ", "rr": "r", "^r": "r", "qu": "k", "y$": "i",
+	"gue": "ɡ e", "gui": "ɡ i", "gué": "ɡ e", "guí": "ɡ i",
+	"ce": "s e", "ci": "s i", "cé": "s e", "cí": "s i", "ge": "x e", "gi": "x i", "gé": "x e", "gí": "x i",
+}
+
+var italianRules = map[string]string{
+	"a": "a", "b": "b", "c": "k", "d": "d", "e": "e", "f": "f", "g": "ɡ", "h": "", "i": "i",
+	"l": "l", "m": "m", "n": "n", "o": "o", "p": "p", "q": "k", "r": "r", "s": "s", "t": "t",
+	"u": "u", "v": "v", "z": "ts", "zz": "ts",
+	"à": "a", "è": "ɛ", "é": "e", "ì": "i", "ò": "ɔ", "ó": "o", "ù": "u",
+	"gn": "ɲ", "gli": "ʎ i", "qu": "k w",
+	"che": "k e", "chi": "k i", "ghe": "ɡ e", "ghi": "ɡ i",
+	"ce": "tʃ e", "ci": "tʃ i", "cia": "tʃ a", "cio": "tʃ o", "ciu": "tʃ u",
+	"ge": "dʒ e", "gi": "dʒ i", "gia": "dʒ a", "gio": "dʒ o", "giu": "dʒ u",
+	"sce": "ʃ e", "sci": "ʃ i", "scia": "ʃ a", "scio": "ʃ o", "sciu": "ʃ u",
+}
+
+var germanRules = map[string]string{
+	"a": "a", "b": "b", "c": "k", "d": "d", "e": "ɛ", "f": "f", "g": "ɡ", "h": "h", "i": "ɪ",
+	"j": "j", "k": "k", "l": "l", "m": "m", "n": "n", "o": "ɔ", "p": "p", "q": "k", "r": "ʁ",
+	"s": "s", "t": "t", "u": "ʊ", "v": "f", "w": "v", "x": "k s", "y": "y", "z": "ts",
+	"ä": "ɛ", "ö": "ø", "ü": "y", "ß": "s",
+	// 子音の綴り
+	"sch": "ʃ", "tsch": "tʃ", "^sp": "ʃ p", "^st": "ʃ t", "ch": "ç", "ach": "a x", "och": "ɔ x",
+	"uch": "u x", "auch": "aʊ x", "chs": "k s", "ck": "k", "ph": "f", "qu": "k v", "pf": "pf",
+	"tz": "ts", "ss": "s", "ng": "ŋ", "nk": "ŋ k",
+	// 母音の綴り（h・重ねた母音は長母音）
+	"ei": "aɪ", "ai": "aɪ", "ie": "iː", "eu": "ɔʏ", "äu": "ɔʏ", "au": "aʊ",
+	"ah": "aː", "eh": "eː", "ih": "iː", "oh": "oː", "uh": "uː", "aa": "aː", "ee": "eː", "oo": "oː",
+	// 語末（無声化・弱化）
+	"b$": "p", "d$": "t", "g$": "k", "ig$": "ɪ ç", "e$": "ə", "er$": "ɐ",
+}
+
+var frenchRules = map[string]string{
+	"a": "a", "b": "b", "c": "k", "d": "d", "e": "ə", "f": "f", "g": "ɡ", "h": "", "i": "i",
+	"j": "ʒ", "k": "k", "l": "l", "m": "m", "n": "n", "o": "o", "p": "p", "q": "k", "r": "ʁ",
+	"s": "s", "t": "t", "u": "y", "v": "v", "w": "w", "x": "k s", "y": "i", "z": "z",
+	"é": "e", "è": "ɛ", "ê": "ɛ", "ë": "ɛ", "à": "a", "â": "ɑ", "î": "i", "ï": "i", "ô": "o",
+	"û": "y", "ù": "y", "ç": "s",
+	// 子音の綴り
+	"ch": "ʃ", "gn": "ɲ", "qu": "k", "ph": "f", "th": "t",
+	"ce": "s ə", "cé": "s e", "ci": "s i", "ge": "ʒ ə", "gé": "ʒ e", "gi": "ʒ i",
+	"ss": "s", "ll": "l", "tt": "t", "mm": "m", "nn": "n", "pp": "p",
+	// 母音の綴り
+	"eau": "o", "eaux": "o", "au": "o", "ou": "u", "oi": "w a", "ai": "ɛ", "ei": "ɛ", "eu": "ø", "œu": "ø",
+	// 鼻母音
+	"an": "ɑ̃", "en": "ɑ̃", "in": "ɛ̃", "on": "ɔ̃", "un": "œ̃", "ain": "ɛ̃", "ein": "ɛ̃", "oin": "w ɛ̃",
+	// 語末（発音しない子音・e）
+	"e$": "", "es$": "", "s$": "", "t$": "", "d$": "", "x$": "", "z$": "", "ts$": "", "ds$": "",
+	"er$": "e", "ez$": "e", "et$": "ɛ", "ais$": "ɛ", "ait$": "ɛ", "aux$": "o",
+}
+
+// withFrenchNasals は母音の前の n を鼻母音にしない規則を加える（例: ami の a、une の u）
+func withFrenchNasals(rules map[string]string) map[string]string {
+	oral := map[string]string{"a": "a", "e": "ə", "i": "i", "o": "ɔ", "u": "y"}
+	for vowel, phoneme := range oral {
+		for _, next := range []string{"a", "e", "i", "o", "u", "y", "é", "è", "ê"} {
+			rules[vowel+"n"+next] = phoneme + " n " + rules[next]
+		}
+		rules[vowel+"nn"] = phoneme + " n"
+	}
+	// 語末の e は発音しない（例: une）
+	for vowel, phoneme := range oral {
+		rules[vowel+"ne$"] = phoneme + " n"
+	}
+	return rules
+}
+
+// frenchLexicon はフランス語の綴りが不規則な頻出語の発音
+var frenchLexicon = map[string]string{
+	"le": "l ə", "les": "l e", "de": "d ə", "des": "d e", "je": "ʒ ə", "ne": "n ə", "me": "m ə",
+	"te": "t ə", "se": "s ə", "ce": "s ə", "que": "k ə", "mes": "m e", "tes": "t e", "ses": "s e",
+	"et": "e", "est": "ɛ", "oui": "w i", "bonjour": "b ɔ̃ ʒ u ʁ", "merci": "m ɛ ʁ s i",
+	"monsieur": "m ə s j ø", "femme": "f a m", "fils": "f i s",
+}
+
+var portugueseRules = map[string]string{
+	"a": "a", "b": "b", "c": "k", "d": "d", "e": "e", "f": "f", "g": "ɡ", "h": "", "i": "i",
+	"j": "ʒ", "k": "k", "l": "l", "m": "m", "n": "n", "o": "o", "p": "p", "q": "k", "r": "ɾ",
+	"s": "s", "t": "t", "u": "u", "v": "v", "w": "w", "x": "ʃ", "y": "i", "z": "z",
+	"á": "a", "â": "ɐ", "à": "a", "ã": "ɐ̃", "é": "ɛ", "ê": "e", "í": "i", "ó": "ɔ", "ô": "o",
+	"õ": "õ", "ú": "u", "ç": "s",
+	// 子音の綴り
+	"lh": "ʎ", "nh": "ɲ", "ch": "ʃ", "rr": "ʁ", "^r": "ʁ", "ss": "s", "qu": "k",
+	"gue": "ɡ e", "gui": "ɡ i", "ce": "s e", "ci": "s i", "ge": "ʒ e", "gi": "ʒ i",
+	// 鼻母音・二重母音
+	"ão": "ɐ̃w̃", "ção": "s ɐ̃w̃", "ões": "õj̃ s", "ções": "s õj̃ s",
+	"am$": "ɐ̃w̃", "em$": "ẽj̃", "im$": "ĩ", "om$": "õ", "um$": "ũ",
+	// 語末の弱い母音
+	"o$": "u", "os$": "u s", "e$": "i", "es$": "i s",
+}
+
+var turkishRules = map[string]string{
+	"c": "dʒ", "ç": "tʃ", "ş": "ʃ", "ğ": "ɰ", "ı": "ɯ", "ö": "ø", "ü": "y", "j": "ʒ", "y": "j",
+	"r": "ɾ", "g": "ɡ", "â": "a", "î": "i", "û": "u",
+}
+
+var russianRules = map[string]string{
+	"а": "a", "б": "b", "в": "v", "г": "ɡ", "д": "d", "е": "e", "ё": "o", "ж": "ʐ", "з": "z",
+	"и": "i", "й": "j", "к": "k", "л": "l", "м": "m", "н": "n", "о": "o", "п": "p", "р": "r",
+	"с": "s", "т": "t", "у": "u", "ф": "f", "х": "x", "ц": "ts", "ч": "tɕ", "ш": "ʂ", "щ": "ɕː",
+	"ъ": "", "ы": "ɨ", "ь": "", "э": "e", "ю": "u", "я": "a",
+	"тся$": "ts a", "ться$": "ts a",
+}
+
+// withRussianIotation は語頭・母音の後・分離記号の後の е, ё, ю, я を j と母音にする規則を加える
+func withRussianIotation(rules map[string]string) map[string]string {
+	iotated := map[string]string{"е": "j e", "ё": "j o", "ю": "j u", "я": "j a"}
+	for letter, phonemes := range iotated {
+		rules["^"+letter] = phonemes
+		rules["ь"+letter] = phonemes
+		rules["ъ"+letter] = phonemes
+		for _, vowel := range []string{"а", "е", "ё", "и", "о", "у", "ы", "э", "ю", "я"} {
+			rules[vowel+letter] = rules[vowel] + " " + phonemes
+		}
+	}
+	return rules
+}
+
+// kanaRules はひらがな（カタカナはひらがなに正規化する）の音素の規則を返す
+// 撥音「ん」は N、促音「っ」は Q、長音「ー」は ː で表す。漢字は変換せず、そのまま音素として扱う
+func kanaRules() map[string]string {
+	rules := map[string]string{"ん": "N", "っ": "Q", "ー": "ː", "を": "o", "ぁ": "a", "ぃ": "i", "ぅ": "ɯ", "ぇ": "e", "ぉ": "o"}
+	vowels := []string{"a", "i", "ɯ", "e", "o"}
+	rows := []struct {
+		consonant string
+		kana      string
+	}{
+		{"", "あいうえお"}, {"k", "かきくけこ"}, {"ɡ", "がぎぐげご"}, {"s", "さしすせそ"}, {"z", "ざじずぜぞ"},
+		{"t", "たちつてと"}, {"d", "だぢづでど"}, {"n", "なにぬねの"}, {"h", "はひふへほ"}, {"b", "ばびぶべぼ"},
+		{"p", "ぱぴぷぺぽ"}, {"m", "まみむめも"}, {"ɾ", "らりるれろ"},
+	}
+	// i段の子音（拗音では「ゃ・ゅ・ょ」の前でこの子音になる）
+	palatal := map[string]string{}
+	for _, row := range rows {
+		for i, kana := range []rune(row.kana) {
+			consonant := row.consonant
+			if i == 1 && consonant != "" {
+				palatal[string(kana)] = consonant + " j"
+			}
+			rules[string(kana)] = consonant + " " + vowels[i]
+		}
+	}
+
+	// 子音が変わるかな
+	for kana, phonemes := range map[string]string{
+		"し": "ɕ i", "じ": "dʑ i", "ち": "tɕ i", "ぢ": "dʑ i", "つ": "ts ɯ", "づ": "z ɯ", "ひ": "ç i", "ふ": "ɸ ɯ",
+		"や": "j a", "ゆ": "j ɯ", "よ": "j o", "わ": "w a",
+	} {
+		rules[kana] = phonemes
+	}
+	for kana, consonant := range map[string]string{"し": "ɕ", "じ": "dʑ", "ち": "tɕ", "ぢ": "dʑ", "ひ": "ç"} {
+		palatal[kana] = consonant
+	}
+
+	// 拗音
+	for kana, consonant := range palatal {
+		for small, vowel := range map[string]string{"ゃ": "a", "ゅ": "ɯ", "ょ": "o"} {
+			rules[kana+small] = consonant + " " + vowel
+		}
+	}
+	return rules
+}
@@ -0,0 +1,62 @@
+package stt
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestG2P_Phonemes(t *testing.T) {
+	g2p := NewG2P()
+
+	tests := []struct {
+		word     string
+		language string
+		expected []string
+	}{
+		// 組み込みの発音辞書
+		{"The", "en-US", []string{"ð", "ə"}},
+		{"Hello,", "en", []string{"h", "ə", "l", "oʊ"}},
+		// 規則による変換
+		{"think", "en", []string{"θ", "ɪ", "ŋ", "k"}},
+		{"ship", "en", []string{"ʃ", "ɪ", "p"}},
+		{"perro", "es", []string{"p", "e", "r", "o"}},
+		{"gente", "es", []string{"x", "e", "n", "t", "e"}},
+		{"ciao", "it", []string{"tʃ", "a", "o"}},
+		{"Schule", "de", []string{"ʃ", "ʊ", "l", "ə"}},
+		{"bonne", "fr", []string{"b", "ɔ", "n"}},
+		{"chanter", "fr", []string{"ʃ", "ɑ̃", "t", "e"}},
+		{"filho", "pt", []string{"f", "i", "ʎ", "u"}},
+		{"IŞIK", "tr", []string{"ɯ", "ʃ", "ɯ", "k"}},
+		{"ель", "ru", []string{"j", "e", "l"}},
+		{"моё", "ru", []string{"m", "o", "j", "o"}},
+		{"きょう", "ja", []string{"k", "j", "o", "ɯ"}},
+		{"チョコレート", "ja", []string{"tɕ", "o", "k", "o", "ɾ", "e", "ː", "t", "o"}},
+		{"がっこう", "ja", []string{"ɡ", "a", "Q", "k", "o", "ɯ"}},
+		// 規則のない言語は文字をそのまま使う
+		{"你好", "zh", []string{"你", "好"}},
+		{"!?", "en", []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.word, func(t *testing.T) {
+			assert.Equal(t, tt.expected, g2p.Phonemes(tt.word, tt.language))
+		})
+	}
+}
+
+func TestG2P_AddLexicon(t *testing.T) {
+	g2p := NewG2P()
+	assert.Equal(t, []string{"t", "ɑ", "m", "æ", "t", "oʊ"}, g2p.Phonemes("tomato", "en"))
+
+	// 追加した辞書は組み込みの辞書と規則より優先する
+	g2p.AddLexicon("en", MapLexicon{
+		"tomato": {"t", "ə", "m", "eɪ", "t", "oʊ"},
+		"the":    {"ð", "iː"},
+	})
+	assert.Equal(t, []string{"t", "ə", "m", "eɪ", "t", "oʊ"}, g2p.Phonemes("Tomato", "en-GB"))
+	assert.Equal(t, []string{"ð", "iː"}, g2p.Phonemes("the", "en"))
+
+	// 他の言語には影響しない
+	assert.Equal(t, []string{"t", "o", "m", "a", "t", "e"}, g2p.Phonemes("tomate", "es"))
+}
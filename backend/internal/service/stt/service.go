@@ -14,8 +14,10 @@ import (
 
 // STTService は音声認識・発音評価サービス
 type STTService struct {
-	sttClient      stt.STTClient
-	audioProcessor *audio.AudioProcessor
+	sttClient       stt.STTClient
+	streamingClient stt.StreamingSTTClient
+	audioProcessor  *audio.AudioProcessor
+	g2p             *G2P
 }
 
 // NewSTTService は新しいSTTサービスを作成する
@@ -23,12 +25,23 @@ func NewSTTService() *STTService {
 	useMock := os.Getenv("USE_MOCK_APIS") == "true" || os.Getenv("TEST_USE_MOCKS") == "true"
 	apiKey := os.Getenv("GOOGLE_CLOUD_STT_API_KEY")
 
+	// ブラウザで録音したOgg・WebMのOpusはffmpegでデコードする
+	processor := audio.NewAudioProcessor()
+	processor.SetFFmpegDecoder(audio.NewFFmpegDecoder(os.Getenv("FFMPEG_PATH")))
+
 	return &STTService{
-		sttClient:      stt.NewSTTClient(useMock, apiKey),
-		audioProcessor: audio.NewAudioProcessor(),
+		sttClient:       stt.NewSTTClient(useMock, apiKey),
+		streamingClient: stt.NewStreamingSTTClient(useMock, apiKey),
+		audioProcessor:  processor,
+		g2p:             NewG2P(),
 	}
 }
 
+// AddLexicon は発音評価で使う言語の発音辞書を追加する
+func (s *STTService) AddLexicon(language string, lexicon Lexicon) {
+	s.g2p.AddLexicon(language, lexicon)
+}
+
 // RecognizeSpeech は音声データをテキストに変換する
 func (s *STTService) RecognizeSpeech(ctx context.Context, audioData []byte, language string) (*models.STTResult, error) {
 	if len(audioData) == 0 {
@@ -57,6 +70,11 @@ func (s *STTService) RecognizeSpeech(ctx context.Context, audioData []byte, lang
 		return nil, fmt.Errorf("%s: %w", ErrSTTRecognition, err)
 	}
 
+	// 発話区間を返す（単語の時刻と同じく、前処理した音声の時刻）
+	if len(processedResult.SpeechSegments) > 0 {
+		result.SpeechSegments = processedResult.SpeechSegments
+	}
+
 	return result, nil
 }
 
@@ -76,20 +94,28 @@ func (s *STTService) EvaluatePronunciation(ctx context.Context, expectedText str
 		return nil, fmt.Errorf("%s: %w", ErrSTTRecognition, err)
 	}
 
+	return s.scorePronunciation(expectedText, sttResult, language), nil
+}
+
+// scorePronunciation は音声認識の結果を期待されるテキストと比べて発音を評価する
+func (s *STTService) scorePronunciation(expectedText string, sttResult *models.STTResult, language string) *models.PronunciationScore {
 	// 正確性スコアを計算
 	accuracyScore := CalculateAccuracyScore(expectedText, sttResult.Text)
 
-	// 流暢性スコアを計算
+	// 流暢性スコアを計算（発話区間を検出できた場合は話した時間だけで測る）
 	fluencyScore := CalculateFluencyScore(sttResult.Words, sttResult.Duration)
+	if len(sttResult.SpeechSegments) > 0 {
+		fluencyScore = CalculateSpeechFluencyScore(sttResult.Words, sttResult.SpeechSegments)
+	}
 
 	// 期待される単語を分割
 	expectedWords := parseWords(expectedText)
 
-	// 発音スコアを計算
-	pronunciationScore := CalculatePronunciationScore(expectedWords, sttResult.Words)
+	// 単語を対応付け、単語ごとのスコアを音素の誤りから計算する
+	wordScores := ScoreWords(s.g2p, expectedWords, sttResult.Words, language)
 
-	// 単語ごとのスコアを計算
-	wordScores := calculateWordScores(expectedWords, sttResult.Words)
+	// 発音スコアを計算
+	pronunciationScore := CalculatePronunciationScore(wordScores)
 
 	// 総合スコアを計算（重み付け平均）
 	totalScore := (accuracyScore*AccuracyWeight + fluencyScore*FluencyWeight + pronunciationScore*PronunciationWeight) / 100
@@ -110,7 +136,7 @@ func (s *STTService) EvaluatePronunciation(ctx context.Context, expectedText str
 	// フィードバックを生成
 	score.Feedback = GenerateFeedback(score)
 
-	return score, nil
+	return score
 }
 
 // isValidLanguageCode は言語コードが有効かどうかを検証する
@@ -157,43 +183,6 @@ func parseWords(text string) []models.WordInfo {
 	return wordInfos
 }
 
-// calculateWordScores は単語ごとのスコアを計算する
-func calculateWordScores(expectedWords, recognizedWords []models.WordInfo) []models.WordScore {
-	wordScores := make([]models.WordScore, 0)
-
-	maxLen := len(expectedWords)
-	if len(recognizedWords) > maxLen {
-		maxLen = len(recognizedWords)
-	}
-
-	for i := 0; i < maxLen; i++ {
-		var expectedWord, recognizedWord string
-
-		if i < len(expectedWords) {
-			expectedWord = expectedWords[i].Word
-		}
-
-		if i < len(recognizedWords) {
-			recognizedWord = recognizedWords[i].Word
-		}
-
-		score := CalculateAccuracyScore(expectedWord, recognizedWord)
-		isCorrect := score >= WordScoreCorrectMin
-
-		wordScore := models.WordScore{
-			Word:           expectedWord,
-			Score:          score,
-			IsCorrect:      isCorrect,
-			ExpectedWord:   expectedWord,
-			RecognizedWord: recognizedWord,
-		}
-
-		wordScores = append(wordScores, wordScore)
-	}
-
-	return wordScores
-}
-
 // generateEvaluationID は評価IDを生成する
 func generateEvaluationID() string {
 	return fmt.Sprintf("eval_%d", time.Now().UnixNano())
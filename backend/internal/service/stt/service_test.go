@@ -205,6 +205,94 @@ func TestCalculateFluencyScore(t *testing.T) {
 	}
 }
 
+// TestCalculateSpeechFluencyScore は発話区間の中だけで流暢性スコアを計算するテスト
+func TestCalculateSpeechFluencyScore(t *testing.T) {
+	// 1秒の無音の後に話し、長い無音を挟んでもう一度話した録音
+	words := []models.WordInfo{
+		{Word: "Hello", StartTime: 1.0, EndTime: 1.5},
+		{Word: "world", StartTime: 1.6, EndTime: 2.1},
+		{Word: "How", StartTime: 4.0, EndTime: 4.4},
+		{Word: "are", StartTime: 4.5, EndTime: 4.9},
+	}
+	segments := []models.SpeechSegment{
+		{Start: 1.0, End: 2.1},
+		{Start: 4.0, End: 4.9},
+	}
+
+	// 録音全体の長さと発話の間の無音で測ると低くなる
+	whole := CalculateFluencyScore(words, 5.5)
+	spoken := CalculateSpeechFluencyScore(words, segments)
+	assert.Greater(t, spoken, whole)
+	assert.GreaterOrEqual(t, spoken, 90)
+
+	// 発話区間がない場合は0
+	assert.Equal(t, 0, CalculateSpeechFluencyScore(words, nil))
+	assert.Equal(t, 0, CalculateSpeechFluencyScore(nil, segments))
+}
+
+// TestScoreWords は単語の対応付けと音素レベルの評価のテスト
+func TestScoreWords(t *testing.T) {
+	g2p := NewG2P()
+
+	// 余分な単語があっても後の単語の対応はずれない
+	wordScores := ScoreWords(g2p, parseWords("I like apples"), parseWords("I really like apples."), "en")
+	require.Len(t, wordScores, 4)
+	assert.Equal(t, models.PronunciationErrorInsertion, wordScores[1].ErrorType)
+	assert.Equal(t, "really", wordScores[1].RecognizedWord)
+	for _, i := range []int{0, 2, 3} {
+		assert.True(t, wordScores[i].IsCorrect, wordScores[i].Word)
+		assert.Equal(t, 100, wordScores[i].Score)
+		assert.Empty(t, wordScores[i].ErrorType)
+	}
+	assert.Equal(t, 100, CalculatePronunciationScore(wordScores))
+
+	// 発音されなかった単語と、音素の置換
+	wordScores = ScoreWords(g2p, parseWords("thank you very much"), parseWords("sank you much"), "en")
+	require.Len(t, wordScores, 4)
+	assert.Equal(t, models.PronunciationErrorSubstitution, wordScores[0].ErrorType)
+	assert.Equal(t, []models.PhonemeError{
+		{Type: models.PronunciationErrorSubstitution, Expected: "θ", Actual: "s", Position: 0},
+	}, wordScores[0].PhonemeErrors)
+	assert.Equal(t, 75, wordScores[0].Score)
+	assert.False(t, wordScores[0].IsCorrect)
+	assert.Equal(t, models.PronunciationErrorDeletion, wordScores[2].ErrorType)
+	assert.Equal(t, "very", wordScores[2].ExpectedWord)
+	assert.Equal(t, 0, wordScores[2].Score)
+	assert.Equal(t, (75+100+0+100)/4, CalculatePronunciationScore(wordScores))
+
+	// 母音の挿入
+	wordScores = ScoreWords(g2p, parseWords("desk"), parseWords("desuku"), "en")
+	require.Len(t, wordScores, 1)
+	assert.Contains(t, wordScores[0].PhonemeErrors, models.PhonemeError{
+		Type: models.PronunciationErrorInsertion, Actual: "ʌ", Position: 3,
+	})
+
+	// 日本語は文全体をひとつの単語として音素で比べる
+	wordScores = ScoreWords(g2p, parseWords("がっこう"), []models.WordInfo{{Word: "がこう"}}, "ja")
+	require.Len(t, wordScores, 1)
+	assert.Equal(t, []models.PhonemeError{
+		{Type: models.PronunciationErrorDeletion, Expected: "Q", Position: 2},
+	}, wordScores[0].PhonemeErrors)
+}
+
+// TestGenerateFeedback_Phonemes は音素の誤りに基づくフィードバックのテスト
+func TestGenerateFeedback_Phonemes(t *testing.T) {
+	g2p := NewG2P()
+	wordScores := ScoreWords(g2p, parseWords("think three things"), parseWords("sink three sings"), "en")
+
+	feedback := GenerateFeedback(&models.PronunciationScore{
+		TotalScore:    70,
+		AccuracyScore: 80,
+		FluencyScore:  80,
+		PronuncScore:  CalculatePronunciationScore(wordScores),
+		WordScores:    wordScores,
+	})
+
+	assert.Equal(t, []string{"θ"}, feedback.FocusPhonemes)
+	assert.Contains(t, feedback.SpecificAdvice,
+		"「think」「things」の /θ/ が /s/ になっています。"+phonemeTips["θ"])
+}
+
 // TestGenerateFeedback はフィードバック生成のテスト
 func TestGenerateFeedback(t *testing.T) {
 	tests := []struct {
@@ -0,0 +1,176 @@
+package stt
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/clearclown/HaiLanGo/backend/internal/models"
+	"github.com/clearclown/HaiLanGo/backend/internal/service/audio"
+	"github.com/clearclown/HaiLanGo/backend/pkg/stt"
+)
+
+// interimBufferSize は受け取られていない途中結果を溜めておく数（溢れた途中結果は捨てる）
+const interimBufferSize = 8
+
+// StreamingEvaluation はストリーミングの発音評価のセッション
+// 音声のチャンク（16bit・モノラルのPCM）を SendAudio で送ると Interim に途中の認識結果が届き、
+// Finish で最終の認識結果と発音評価を返す
+type StreamingEvaluation struct {
+	service      *STTService
+	stream       stt.RecognizeStream
+	expectedText string
+	language     string
+	sampleRate   int
+	maxBytes     int
+
+	mu       sync.Mutex
+	audio    []byte
+	sendDone bool
+
+	interim chan string
+	final   chan streamOutcome
+}
+
+// streamOutcome はストリームの最終結果
+type streamOutcome struct {
+	result *models.STTResult
+	err    error
+}
+
+// StartStreamingEvaluation はストリーミングの発音評価を開始する
+// sampleRate は送る音声のサンプリングレート（0 の場合は DefaultSampleRate）
+func (s *STTService) StartStreamingEvaluation(ctx context.Context, expectedText, language string, sampleRate int) (*StreamingEvaluation, error) {
+	if expectedText == "" {
+		return nil, fmt.Errorf(ErrEmptyExpectedText)
+	}
+
+	if !isValidLanguageCode(language) {
+		return nil, fmt.Errorf("%s: %s", ErrInvalidLanguage, language)
+	}
+
+	if sampleRate == 0 {
+		sampleRate = DefaultSampleRate
+	}
+	if sampleRate < MinStreamSampleRate || sampleRate > MaxStreamSampleRate {
+		return nil, fmt.Errorf("%s: %d", ErrInvalidSampleRate, sampleRate)
+	}
+
+	stream, err := s.streamingClient.StartStream(ctx, stt.StreamConfig{Language: language, SampleRate: sampleRate})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrSTTRecognition, err)
+	}
+
+	evaluation := &StreamingEvaluation{
+		service:      s,
+		stream:       stream,
+		expectedText: expectedText,
+		language:     language,
+		sampleRate:   sampleRate,
+		maxBytes:     MaxStreamDuration * sampleRate * 2,
+		interim:      make(chan string, interimBufferSize),
+		final:        make(chan streamOutcome, 1),
+	}
+	go evaluation.receive()
+
+	return evaluation, nil
+}
+
+// receive はストリームの認識結果を受け取り、途中結果を Interim に、最終結果を Finish に渡す
+func (e *StreamingEvaluation) receive() {
+	defer close(e.interim)
+
+	for {
+		result, err := e.stream.Recv()
+		if err != nil {
+			e.final <- streamOutcome{err: err}
+			return
+		}
+
+		if result.IsFinal {
+			e.final <- streamOutcome{result: result.Result}
+			return
+		}
+
+		select {
+		case e.interim <- result.Text:
+		default:
+		}
+	}
+}
+
+// Interim は途中の認識結果のチャネルを返す（認識が終わると閉じる）
+func (e *StreamingEvaluation) Interim() <-chan string {
+	return e.interim
+}
+
+// SendAudio は音声のチャンクを送る
+func (e *StreamingEvaluation) SendAudio(chunk []byte) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.sendDone {
+		return stt.ErrStreamClosed
+	}
+
+	if len(e.audio)+len(chunk) > e.maxBytes {
+		return fmt.Errorf("%s: %d秒まで", ErrStreamTooLong, MaxStreamDuration)
+	}
+	e.audio = append(e.audio, chunk...)
+
+	if err := e.stream.Send(chunk); err != nil {
+		return fmt.Errorf("%s: %w", ErrSTTRecognition, err)
+	}
+	return nil
+}
+
+// Finish は音声の送信を終え、最終の認識結果と発音評価を返す
+// 流暢性は送られた音声から検出した発話区間で測る
+func (e *StreamingEvaluation) Finish(ctx context.Context) (*models.STTResult, *models.PronunciationScore, error) {
+	e.mu.Lock()
+	if e.sendDone {
+		e.mu.Unlock()
+		return nil, nil, stt.ErrStreamClosed
+	}
+	e.sendDone = true
+	audioData := e.audio
+	e.mu.Unlock()
+
+	if len(audioData) == 0 {
+		e.stream.Close()
+		return nil, nil, fmt.Errorf(ErrEmptyAudioData)
+	}
+
+	if err := e.stream.CloseSend(); err != nil {
+		e.stream.Close()
+		return nil, nil, fmt.Errorf("%s: %w", ErrSTTRecognition, err)
+	}
+
+	var outcome streamOutcome
+	select {
+	case outcome = <-e.final:
+	case <-ctx.Done():
+		e.stream.Close()
+		return nil, nil, ctx.Err()
+	}
+	if outcome.err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", ErrSTTRecognition, outcome.err)
+	}
+
+	// 発話区間を検出する（単語の時刻と同じく、送られた音声の時刻）
+	result := outcome.result
+	segments, err := e.service.audioProcessor.DetectSpeech(audio.WrapPCM(audioData, e.sampleRate))
+	if err == nil && len(segments) > 0 {
+		result.SpeechSegments = segments
+	}
+
+	return result, e.service.scorePronunciation(e.expectedText, result, e.language), nil
+}
+
+// Close はセッションを中断する（Finish の後に呼んでもよい）
+func (e *StreamingEvaluation) Close() error {
+	e.mu.Lock()
+	e.sendDone = true
+	e.mu.Unlock()
+	return e.stream.Close()
+}
@@ -0,0 +1,128 @@
+package stt
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/clearclown/HaiLanGo/backend/internal/service/audio"
+	"github.com/clearclown/HaiLanGo/backend/pkg/stt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// voicedPCM は silence 秒の無音の後に speech 秒の有声音（基本周波数150Hz）が続く16kHz・16bitのPCMを返す
+func voicedPCM(silence, speech float64) []byte {
+	samples := make([]float64, int((silence+speech+silence)*DefaultSampleRate))
+	start := int(silence * DefaultSampleRate)
+	for i := start; i < start+int(speech*DefaultSampleRate); i++ {
+		t := float64(i) / DefaultSampleRate
+		for harmonic := 1.0; harmonic <= 5; harmonic++ {
+			samples[i] += 0.3 / harmonic * math.Sin(2*math.Pi*150*harmonic*t)
+		}
+	}
+	return audio.EncodeWAV(samples, DefaultSampleRate)[44:]
+}
+
+// TestStreamingEvaluation はストリーミングの発音評価のテスト
+func TestStreamingEvaluation(t *testing.T) {
+	ctx := context.Background()
+	service := NewSTTService()
+
+	evaluation, err := service.StartStreamingEvaluation(ctx, "Hello world", "en", 0)
+	require.NoError(t, err)
+	defer evaluation.Close()
+
+	var interims []string
+	interimDone := make(chan struct{})
+	go func() {
+		defer close(interimDone)
+		for text := range evaluation.Interim() {
+			interims = append(interims, text)
+		}
+	}()
+
+	// 0.1秒ずつ送る
+	pcm := voicedPCM(0.3, 1.2)
+	chunk := DefaultSampleRate / 10 * 2
+	for offset := 0; offset < len(pcm); offset += chunk {
+		require.NoError(t, evaluation.SendAudio(pcm[offset:min(offset+chunk, len(pcm))]))
+	}
+
+	result, score, err := evaluation.Finish(ctx)
+	require.NoError(t, err)
+	<-interimDone
+
+	assert.Equal(t, "Hello world", interims[len(interims)-1])
+	assert.Equal(t, "Hello, world!", result.Text)
+
+	// 送った音声の時刻で発話区間を検出する
+	require.Len(t, result.SpeechSegments, 1)
+	assert.InDelta(t, 0.3, result.SpeechSegments[0].Start, 0.05)
+	assert.InDelta(t, 1.5, result.SpeechSegments[0].End, 0.05)
+
+	require.NotNil(t, score)
+	assert.Equal(t, "Hello world", score.ExpectedText)
+	assert.Equal(t, "Hello, world!", score.RecognizedText)
+	assert.Greater(t, score.TotalScore, 0)
+	assert.NotNil(t, score.Feedback)
+
+	assert.ErrorIs(t, evaluation.SendAudio(pcm[:chunk]), stt.ErrStreamClosed)
+}
+
+// TestStartStreamingEvaluation_Invalid はストリーミングの発音評価の入力検証のテスト
+func TestStartStreamingEvaluation_Invalid(t *testing.T) {
+	ctx := context.Background()
+	service := NewSTTService()
+
+	tests := []struct {
+		name         string
+		expectedText string
+		lang         string
+		sampleRate   int
+		errorMsg     string
+	}{
+		{name: "期待されるテキストが空", expectedText: "", lang: "en", errorMsg: ErrEmptyExpectedText},
+		{name: "無効な言語コード", expectedText: "Hello", lang: "invalid", errorMsg: ErrInvalidLanguage},
+		{name: "サンプリングレートが低すぎる", expectedText: "Hello", lang: "en", sampleRate: 4000, errorMsg: ErrInvalidSampleRate},
+		{name: "サンプリングレートが高すぎる", expectedText: "Hello", lang: "en", sampleRate: 96000, errorMsg: ErrInvalidSampleRate},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.StartStreamingEvaluation(ctx, tt.expectedText, tt.lang, tt.sampleRate)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errorMsg)
+		})
+	}
+}
+
+// TestStreamingEvaluation_Limits はストリーミングの発音評価で受け付けない音声のテスト
+func TestStreamingEvaluation_Limits(t *testing.T) {
+	ctx := context.Background()
+	service := NewSTTService()
+
+	t.Run("音声を送らずに終える", func(t *testing.T) {
+		evaluation, err := service.StartStreamingEvaluation(ctx, "Hello", "en", 0)
+		require.NoError(t, err)
+		defer evaluation.Close()
+
+		_, _, err = evaluation.Finish(ctx)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), ErrEmptyAudioData)
+	})
+
+	t.Run("最大の長さを超える音声", func(t *testing.T) {
+		evaluation, err := service.StartStreamingEvaluation(ctx, "Hello", "en", MinStreamSampleRate)
+		require.NoError(t, err)
+		defer evaluation.Close()
+
+		second := make([]byte, MinStreamSampleRate*2)
+		for i := 0; i < MaxStreamDuration; i++ {
+			require.NoError(t, evaluation.SendAudio(second))
+		}
+		err = evaluation.SendAudio(second[:2])
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), ErrStreamTooLong)
+	})
+}
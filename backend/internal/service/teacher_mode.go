@@ -3,10 +3,12 @@ package service
 import (
 	"context"
 	"fmt"
+	"log"
 	"time"
 
 	"github.com/clearclown/HaiLanGo/backend/internal/models"
 	"github.com/clearclown/HaiLanGo/backend/internal/repository"
+	"github.com/clearclown/HaiLanGo/backend/pkg/translate"
 	"github.com/google/uuid"
 )
 
@@ -16,6 +18,7 @@ type TeacherModeService struct {
 	pageRepo        repository.PageRepository
 	bookRepo        repository.BookRepository
 	ttsRepo         repository.TTSRepositoryInterface
+	translator      translate.Client // nilの場合は母国語訳のセグメントを生成しない
 }
 
 // NewTeacherModeService は新しいTeacherModeServiceを作成する
@@ -33,6 +36,11 @@ func NewTeacherModeService(
 	}
 }
 
+// SetTranslator は母国語訳の生成に使用する翻訳クライアントを設定する
+func (s *TeacherModeService) SetTranslator(translator translate.Client) {
+	s.translator = translator
+}
+
 // GeneratePlaylist は教師モードのプレイリストを生成する
 func (s *TeacherModeService) GeneratePlaylist(
 	ctx context.Context,
@@ -118,9 +126,11 @@ func (s *TeacherModeService) GeneratePlaylist(
 		}
 
 		// 2. 母国語訳（オプション）
+		translationText := ""
 		if settings.Content.IncludeTranslation && page.OCRText != "" {
-			// TODO: 実際には翻訳APIを使用する
-			translationText := fmt.Sprintf("Translation of: %s", page.OCRText)
+			translationText = s.translatePage(ctx, page, book)
+		}
+		if translationText != "" {
 			translationSegment, duration, err := s.createAudioSegment(
 				ctx,
 				userID,
@@ -188,6 +198,21 @@ func (s *TeacherModeService) GeneratePlaylist(
 	return playlist, nil
 }
 
+// translatePage はページのテキストを書籍の母国語に翻訳する
+// 翻訳クライアントが未設定の場合や翻訳に失敗した場合は空文字列を返す（訳のセグメントを省略する）
+func (s *TeacherModeService) translatePage(ctx context.Context, page *models.Page, book *models.Book) string {
+	if s.translator == nil {
+		return ""
+	}
+
+	translation, err := translate.TranslateText(ctx, s.translator, page.OCRText, book.TargetLanguage, book.NativeLanguage)
+	if err != nil {
+		log.Printf("failed to translate page %d of book %s: %v", page.PageNumber, book.ID, err)
+		return ""
+	}
+	return translation
+}
+
 // createAudioSegment は音声セグメントを作成する
 func (s *TeacherModeService) createAudioSegment(
 	ctx context.Context,
@@ -0,0 +1,44 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/clearclown/HaiLanGo/backend/internal/models"
+	"github.com/clearclown/HaiLanGo/backend/internal/repository"
+	"github.com/clearclown/HaiLanGo/backend/pkg/translate"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestTeacherModeService_GeneratePlaylist_Translation は母国語訳のセグメントが翻訳クライアントで生成されることをテストする
+func TestTeacherModeService_GeneratePlaylist_Translation(t *testing.T) {
+	ctx := context.Background()
+
+	bookRepo := repository.NewInMemoryBookRepository()
+	book := &models.Book{ID: uuid.New(), UserID: uuid.New(), Title: "ロシア語入門", TargetLanguage: "ru", NativeLanguage: "ja"}
+	require.NoError(t, bookRepo.Create(ctx, book))
+
+	pageRepo := repository.NewMockPageRepository()
+	require.NoError(t, pageRepo.Create(ctx, &models.Page{ID: uuid.New(), BookID: book.ID, PageNumber: 1, OCRText: "Как дела?"}))
+
+	service := NewTeacherModeService(nil, pageRepo, bookRepo, repository.NewInMemoryTTSRepository())
+	settings := &models.TeacherModeSettings{Speed: 1.0, Content: models.TeacherModeContent{IncludeTranslation: true}}
+
+	// 翻訳クライアントが未設定の場合は訳のセグメントを省略する
+	playlist, err := service.GeneratePlaylist(ctx, book.UserID, book.ID, settings, nil)
+	require.NoError(t, err)
+	require.Len(t, playlist.Pages, 1)
+	require.Len(t, playlist.Pages[0].Segments, 1)
+
+	service.SetTranslator(translate.NewMockClient())
+	playlist, err = service.GeneratePlaylist(ctx, book.UserID, book.ID, settings, nil)
+	require.NoError(t, err)
+
+	segments := playlist.Pages[0].Segments
+	require.Len(t, segments, 2)
+	assert.Equal(t, models.AudioSegmentTypeTranslation, segments[1].Type)
+	assert.Equal(t, "元気ですか？", segments[1].Text)
+	assert.Equal(t, "ja", segments[1].Language)
+}
@@ -0,0 +1,324 @@
+package vocabulary
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/clearclown/HaiLanGo/backend/internal/models"
+	"github.com/clearclown/HaiLanGo/backend/internal/repository"
+	"github.com/clearclown/HaiLanGo/backend/pkg/anki"
+	"github.com/clearclown/HaiLanGo/backend/pkg/srs"
+)
+
+const (
+	// ankiDeckID と ankiModelID は書き出すAnkiのデッキとノートタイプ
+	ankiDeckID  int64 = 1700000000100
+	ankiModelID int64 = 1700000000100
+)
+
+// ankiWordFields は書き出すノートタイプのフィールド
+var ankiWordFields = []string{"Word", "Meaning", "Pronunciation", "PartOfSpeech", "Example", "Language"}
+
+// 取り込むノートのフィールド名の候補（大文字・小文字は区別しない）
+var (
+	wordFieldNames          = []string{"Word", "Text", "Front", "Expression"}
+	meaningFieldNames       = []string{"Meaning", "Translation", "Definition", "Back"}
+	pronunciationFieldNames = []string{"Pronunciation", "Reading", "IPA"}
+	partOfSpeechFieldNames  = []string{"PartOfSpeech", "Part of Speech", "POS"}
+	exampleFieldNames       = []string{"Example", "Sentence"}
+)
+
+// ImportWordsFromAnki はAnkiのパッケージ（.apkg）のノートを単語として取り込み、取り込んだ単語の数を返す
+// 学習回数・平均スコア・最終学習日時はノートのカードの復習ログから引き継ぐ
+// すでにある単語（大文字・小文字は区別しない）は取り込まない
+func (s *vocabularyService) ImportWordsFromAnki(ctx context.Context, userID, bookID, language string, data []byte) (int, error) {
+	collection, err := anki.ReadPackage(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return 0, err
+	}
+
+	noteOfCard := make(map[int64]int64, len(collection.Cards))
+	reps := make(map[int64]int)
+	for _, card := range collection.Cards {
+		noteOfCard[card.ID] = card.NoteID
+		if card.Reps > reps[card.NoteID] {
+			reps[card.NoteID] = card.Reps
+		}
+	}
+	reviews := make(map[int64][]anki.Review)
+	for _, review := range collection.Reviews {
+		// 手動の変更（期日の変更など）は復習ではないため除く
+		if review.Ease == 0 {
+			continue
+		}
+		noteID := noteOfCard[review.CardID]
+		reviews[noteID] = append(reviews[noteID], review)
+	}
+
+	imported := 0
+	for _, note := range collection.Notes {
+		model := collection.Model(note.ModelID)
+		if model == nil {
+			continue
+		}
+
+		named := make(map[string]string, len(model.Fields))
+		for i, name := range model.Fields {
+			if i < len(note.Fields) {
+				named[strings.ToLower(name)] = anki.PlainText(note.Fields[i])
+			}
+		}
+		positional := make([]string, len(note.Fields))
+		for i, field := range note.Fields {
+			positional[i] = anki.PlainText(field)
+		}
+
+		word := deckWord(named, positional, userID, bookID, language)
+		word.Tags = append(word.Tags, note.Tags...)
+		applyReviews(word, reps[note.ID], reviews[note.ID])
+
+		created, err := s.importWord(ctx, word)
+		if err != nil {
+			return imported, err
+		}
+		if created {
+			imported++
+		}
+	}
+
+	return imported, nil
+}
+
+// ImportWordsFromText はAnkiのテキスト形式（タブ・カンマ区切り）のノートを単語として取り込み、取り込んだ単語の数を返す
+// 列の名前（#columns）がない場合は1列目を単語、2列目を意味にする
+func (s *vocabularyService) ImportWordsFromText(ctx context.Context, userID, bookID, language string, data []byte) (int, error) {
+	deck, err := anki.ReadText(bytes.NewReader(data))
+	if err != nil {
+		return 0, err
+	}
+
+	imported := 0
+	for _, row := range deck.Rows {
+		positional := make([]string, len(row))
+		for i, value := range row {
+			if deck.HTML {
+				value = anki.PlainText(value)
+			}
+			positional[i] = strings.TrimSpace(value)
+		}
+		named := make(map[string]string, len(deck.Columns))
+		for i, name := range deck.Columns {
+			// タグの列はフィールドとして扱わない
+			if i < len(positional) && i+1 != deck.TagsColumn {
+				named[strings.ToLower(name)] = positional[i]
+			}
+		}
+
+		word := deckWord(named, positional, userID, bookID, language)
+		word.Tags = append(word.Tags, deck.RowTags(row)...)
+
+		created, err := s.importWord(ctx, word)
+		if err != nil {
+			return imported, err
+		}
+		if created {
+			imported++
+		}
+	}
+
+	return imported, nil
+}
+
+// importWord は空でない新しい単語を保存し、保存したかどうかを返す
+func (s *vocabularyService) importWord(ctx context.Context, word *models.Word) (bool, error) {
+	if word.Text == "" {
+		return false, nil
+	}
+
+	existing, _, err := s.repo.List(ctx, &models.WordFilter{UserID: word.UserID, BookID: word.BookID, Query: word.Text})
+	if err != nil {
+		return false, fmt.Errorf("failed to check existing word: %w", err)
+	}
+	for _, w := range existing {
+		if strings.EqualFold(w.Text, word.Text) {
+			return false, nil
+		}
+	}
+
+	if err := s.repo.Create(ctx, word); err != nil {
+		if err == repository.ErrWordAlreadyExists {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to create word: %w", err)
+	}
+	return true, nil
+}
+
+// deckWord はノート・行のフィールドから単語を作成する
+// named はフィールド名（小文字）ごとの値、positional は位置ごとの値（名前で見つからない場合に使用）
+func deckWord(named map[string]string, positional []string, userID, bookID, language string) *models.Word {
+	field := func(names []string) (string, bool) {
+		for _, name := range names {
+			if value, ok := named[strings.ToLower(name)]; ok {
+				return value, true
+			}
+		}
+		return "", false
+	}
+
+	text, hasText := field(wordFieldNames)
+	meaning, hasMeaning := field(meaningFieldNames)
+	// フィールド名の分からないノートタイプ・列は1つ目を単語、2つ目を意味にする
+	if !hasText && len(positional) > 0 {
+		text = positional[0]
+		if !hasMeaning && len(positional) > 1 {
+			meaning = positional[1]
+		}
+	}
+
+	word := &models.Word{
+		UserID:   userID,
+		BookID:   bookID,
+		Text:     text,
+		Meaning:  meaning,
+		Language: named["language"],
+		Tags:     []string{},
+	}
+	word.Pronunciation, _ = field(pronunciationFieldNames)
+	word.PartOfSpeech, _ = field(partOfSpeechFieldNames)
+	word.Example, _ = field(exampleFieldNames)
+	if word.Language == "" {
+		word.Language = language
+	}
+	return word
+}
+
+// applyReviews はAnkiの復習ログから学習回数・平均スコア・習得度・最終学習日時を設定する
+// 復習ログのないデッキ（共有デッキなど）はカードの復習回数のみ引き継ぐ
+func applyReviews(word *models.Word, reps int, reviews []anki.Review) {
+	if len(reviews) == 0 {
+		word.ReviewCount = reps
+		return
+	}
+
+	total := 0
+	for _, review := range reviews {
+		total += srs.RatingToScore(srs.Rating(review.Ease))
+		if review.ReviewedAt().After(word.LastReviewedAt) {
+			word.LastReviewedAt = review.ReviewedAt()
+		}
+	}
+	word.ReviewCount = len(reviews)
+	word.AverageScore = float64(total) / float64(len(reviews))
+	word.Mastery = CalculateMastery(word.ReviewCount, word.AverageScore)
+}
+
+// ExportWordsToAnki は単語をAnkiのパッケージ（.apkg）としてエクスポートする
+// 単語ごとに単語 → 意味、意味 → 単語の2枚の未学習のカードを作成する
+func (s *vocabularyService) ExportWordsToAnki(ctx context.Context, filter *models.WordFilter) ([]byte, error) {
+	words, err := s.exportWords(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	collection := &anki.Collection{
+		Decks: []anki.Deck{{ID: ankiDeckID, Name: "HaiLanGo::Vocabulary"}},
+		Models: []anki.Model{{
+			ID:     ankiModelID,
+			Name:   "HaiLanGo Vocabulary",
+			Fields: ankiWordFields,
+			Templates: []anki.Template{
+				{Name: models.CardTypeRecognition, Front: "{{Word}}", Back: "{{FrontSide}}<hr id=answer>{{Meaning}}<br>{{Pronunciation}}<br>{{Example}}"},
+				{Name: models.CardTypeProduction, Front: "{{Meaning}}", Back: "{{FrontSide}}<hr id=answer>{{Word}}<br>{{Pronunciation}}<br>{{Example}}"},
+			},
+			CSS: ".card { font-family: arial; font-size: 20px; text-align: center; }",
+		}},
+	}
+
+	noteIDs := make(map[int64]bool, len(words))
+	for i, word := range words {
+		created := word.CreatedAt
+		if created.IsZero() {
+			created = time.Now()
+		}
+		noteID := created.UnixMilli()
+		for noteIDs[noteID] {
+			noteID++
+		}
+		noteIDs[noteID] = true
+
+		fields := []string{word.Text, word.Meaning, word.Pronunciation, word.PartOfSpeech, word.Example, word.Language}
+		for j := range fields {
+			fields[j] = html.EscapeString(fields[j])
+		}
+		collection.Notes = append(collection.Notes, anki.Note{
+			ID:       noteID,
+			GUID:     word.ID,
+			ModelID:  ankiModelID,
+			Fields:   fields,
+			Tags:     ankiTags(word.Tags),
+			Modified: word.UpdatedAt,
+		})
+		// カードのIDはノートのIDの2倍（と+1）にして重複を避ける
+		for ord := 0; ord < 2; ord++ {
+			collection.Cards = append(collection.Cards, anki.Card{
+				ID: noteID*2 + int64(ord), NoteID: noteID, DeckID: ankiDeckID, Ord: ord, Due: int64(i + 1),
+			})
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := anki.WritePackage(&buf, collection); err != nil {
+		return nil, fmt.Errorf("failed to write anki package: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// ExportWordsToText は単語をAnkiのテキスト形式（タブ区切り、最後の列はタグ）でエクスポートする
+func (s *vocabularyService) ExportWordsToText(ctx context.Context, filter *models.WordFilter) ([]byte, error) {
+	words, err := s.exportWords(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	columns := append(append([]string{}, ankiWordFields...), "Tags")
+	deck := &anki.TextDeck{Separator: '\t', Columns: columns, TagsColumn: len(columns)}
+	for _, word := range words {
+		deck.Rows = append(deck.Rows, []string{
+			word.Text, word.Meaning, word.Pronunciation, word.PartOfSpeech, word.Example, word.Language,
+			strings.Join(ankiTags(word.Tags), " "),
+		})
+	}
+
+	var buf bytes.Buffer
+	if err := anki.WriteText(&buf, deck); err != nil {
+		return nil, fmt.Errorf("failed to write text deck: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// exportWords はエクスポートする単語を追加した順に返す
+func (s *vocabularyService) exportWords(ctx context.Context, filter *models.WordFilter) ([]*models.Word, error) {
+	words, _, err := s.repo.List(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get words: %w", err)
+	}
+	sort.SliceStable(words, func(i, j int) bool { return words[i].CreatedAt.Before(words[j].CreatedAt) })
+	return words, nil
+}
+
+// ankiTags はタグをAnkiのタグにする（Ankiのタグは空白を含められないため "_" に置き換える）
+func ankiTags(tags []string) []string {
+	converted := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		if tag = strings.Join(strings.Fields(tag), "_"); tag != "" {
+			converted = append(converted, tag)
+		}
+	}
+	return converted
+}
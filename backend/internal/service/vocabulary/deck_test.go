@@ -0,0 +1,116 @@
+package vocabulary
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/clearclown/HaiLanGo/backend/internal/models"
+	"github.com/clearclown/HaiLanGo/backend/pkg/anki"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestVocabularyService_ImportWordsFromAnki はAnkiのノートの単語への取り込みと復習ログの引き継ぎをテスト
+func TestVocabularyService_ImportWordsFromAnki(t *testing.T) {
+	service := NewMockVocabularyService()
+	ctx := context.Background()
+
+	reviewed := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	collection := &anki.Collection{
+		Models: []anki.Model{{
+			ID: 1, Name: "Vocab", Fields: []string{"Word", "Meaning", "Reading"},
+			Templates: []anki.Template{{Name: "Card 1", Front: "{{Word}}", Back: "{{Meaning}}"}},
+		}},
+		Notes: []anki.Note{
+			{ID: 1, ModelID: 1, Fields: []string{"кошка", "<i>猫</i>", "кóшка"}, Tags: []string{"animals"}},
+			{ID: 2, ModelID: 1, Fields: []string{"собака", "犬", ""}},
+		},
+		Cards: []anki.Card{
+			{ID: 10, NoteID: 1, Ord: 0, Type: anki.CardTypeReview, Reps: 2},
+			{ID: 20, NoteID: 2, Ord: 0, Type: anki.CardTypeReview, Reps: 7},
+		},
+		Reviews: []anki.Review{
+			{ID: reviewed.Add(-48 * time.Hour).UnixMilli(), CardID: 10, Ease: 4},
+			{ID: reviewed.UnixMilli(), CardID: 10, Ease: 3},
+		},
+	}
+	var buf bytes.Buffer
+	require.NoError(t, anki.WritePackage(&buf, collection))
+
+	imported, err := service.ImportWordsFromAnki(ctx, "user-1", "", "ru", buf.Bytes())
+	require.NoError(t, err)
+	assert.Equal(t, 2, imported)
+
+	words, err := service.GetWords(ctx, &models.WordFilter{UserID: "user-1", Query: "кошка"})
+	require.NoError(t, err)
+	require.Len(t, words, 1)
+	word := words[0]
+	assert.Equal(t, "猫", word.Meaning)
+	assert.Equal(t, "кóшка", word.Pronunciation)
+	assert.Equal(t, "ru", word.Language)
+	assert.Equal(t, []string{"animals"}, word.Tags)
+	assert.Equal(t, 2, word.ReviewCount)
+	assert.InDelta(t, 87.5, word.AverageScore, 1e-9)
+	assert.Equal(t, reviewed, word.LastReviewedAt.UTC())
+
+	// 復習ログのないノートはカードの復習回数のみ引き継ぐ
+	words, err = service.GetWords(ctx, &models.WordFilter{UserID: "user-1", Query: "собака"})
+	require.NoError(t, err)
+	require.Len(t, words, 1)
+	assert.Equal(t, 7, words[0].ReviewCount)
+
+	// すでにある単語は取り込まない
+	imported, err = service.ImportWordsFromAnki(ctx, "user-1", "", "ru", buf.Bytes())
+	require.NoError(t, err)
+	assert.Equal(t, 0, imported)
+}
+
+// TestVocabularyService_ExportWordsToText はテキスト形式のエクスポートと取り込み直しをテスト
+func TestVocabularyService_ExportWordsToText(t *testing.T) {
+	service := NewMockVocabularyService()
+	ctx := context.Background()
+
+	require.NoError(t, service.AddWord(ctx, &models.Word{
+		UserID: "user-1", Text: "дом", Meaning: "家", PartOfSpeech: "noun", Language: "ru", Tags: []string{"lesson 1"},
+	}))
+
+	data, err := service.ExportWordsToText(ctx, &models.WordFilter{UserID: "user-1"})
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "#columns:Word\tMeaning\tPronunciation\tPartOfSpeech\tExample\tLanguage\tTags\n#tags column:7\n")
+	assert.Contains(t, string(data), "дом\t家\t\tnoun\t\tru\tlesson_1\n")
+
+	imported, err := service.ImportWordsFromText(ctx, "user-2", "", "", data)
+	require.NoError(t, err)
+	assert.Equal(t, 1, imported)
+
+	words, err := service.GetWords(ctx, &models.WordFilter{UserID: "user-2"})
+	require.NoError(t, err)
+	require.Len(t, words, 1)
+	assert.Equal(t, "家", words[0].Meaning)
+	assert.Equal(t, "noun", words[0].PartOfSpeech)
+	assert.Equal(t, []string{"lesson_1"}, words[0].Tags)
+}
+
+// TestVocabularyService_ExportWordsToAnki はパッケージのエクスポートをテスト
+func TestVocabularyService_ExportWordsToAnki(t *testing.T) {
+	service := NewMockVocabularyService()
+	ctx := context.Background()
+
+	for _, text := range []string{"дом", "кот"} {
+		require.NoError(t, service.AddWord(ctx, &models.Word{UserID: "user-1", Text: text, Meaning: "<" + text + ">", Language: "ru"}))
+	}
+
+	data, err := service.ExportWordsToAnki(ctx, &models.WordFilter{UserID: "user-1"})
+	require.NoError(t, err)
+	collection, err := anki.ReadPackage(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+
+	require.Len(t, collection.Models, 1)
+	assert.Equal(t, ankiWordFields, collection.Models[0].Fields)
+	require.Len(t, collection.Notes, 2)
+	assert.Len(t, collection.Cards, 4)
+	meanings := []string{collection.Notes[0].Fields[1], collection.Notes[1].Fields[1]}
+	assert.ElementsMatch(t, []string{"&lt;дом&gt;", "&lt;кот&gt;"}, meanings)
+}
@@ -24,12 +24,23 @@ type VocabularyService interface {
 	RecordReview(ctx context.Context, wordID string, score float64) error
 	GetStats(ctx context.Context, userID, bookID string) (*models.WordStats, error)
 	ExportWordsToCSV(ctx context.Context, filter *models.WordFilter) ([]byte, error)
+	ExportWordsToAnki(ctx context.Context, filter *models.WordFilter) ([]byte, error)
+	ExportWordsToText(ctx context.Context, filter *models.WordFilter) ([]byte, error)
+	ImportWordsFromAnki(ctx context.Context, userID, bookID, language string, data []byte) (int, error)
+	ImportWordsFromText(ctx context.Context, userID, bookID, language string, data []byte) (int, error)
 	AddTags(ctx context.Context, wordID string, tags []string) error
+	SetReviewItemGenerator(generator ReviewItemGenerator)
+}
+
+// ReviewItemGenerator は収集した単語から復習項目を作成する
+type ReviewItemGenerator interface {
+	FromWords(ctx context.Context, words []*models.Word) ([]*models.ReviewItem, error)
 }
 
 // vocabularyService は単語帳サービスの実装
 type vocabularyService struct {
-	repo repository.WordRepository
+	repo      repository.WordRepository
+	generator ReviewItemGenerator
 }
 
 // NewVocabularyService は新しい単語帳サービスを作成する
@@ -46,10 +57,17 @@ func NewMockVocabularyService() VocabularyService {
 	}
 }
 
+// SetReviewItemGenerator は自動収集した単語を復習項目にするジェネレーターを設定する
+func (s *vocabularyService) SetReviewItemGenerator(generator ReviewItemGenerator) {
+	s.generator = generator
+}
+
 // AutoCollectWords はテキストから単語を自動収集する
+// ReviewItemGenerator が設定されている場合は、新しく収集した単語を復習項目にする
 func (s *vocabularyService) AutoCollectWords(ctx context.Context, userID, bookID string, pageNumber int, text, language string) error {
 	// 単語を抽出
 	words := vocabulary.ExtractWords(text, language)
+	var collected []*models.Word
 
 	// 各単語を保存
 	for _, wordText := range words {
@@ -87,6 +105,15 @@ func (s *vocabularyService) AutoCollectWords(ctx context.Context, userID, bookID
 			if err != repository.ErrWordAlreadyExists {
 				return fmt.Errorf("failed to create word: %w", err)
 			}
+			continue
+		}
+		collected = append(collected, word)
+	}
+
+	// 収集した単語を復習項目にする
+	if s.generator != nil && len(collected) > 0 {
+		if _, err := s.generator.FromWords(ctx, collected); err != nil {
+			return fmt.Errorf("failed to create review items: %w", err)
 		}
 	}
 
@@ -5,6 +5,8 @@ import (
 	"testing"
 
 	"github.com/clearclown/HaiLanGo/backend/internal/models"
+	"github.com/clearclown/HaiLanGo/backend/internal/repository"
+	srsservice "github.com/clearclown/HaiLanGo/backend/internal/service/srs"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -33,6 +35,40 @@ func TestVocabularyService_AutoCollectWords(t *testing.T) {
 	assert.NotEmpty(t, words, "単語が自動収集されること")
 }
 
+// TestVocabularyService_AutoCollectWordsCreatesReviewItems は自動収集した単語の復習項目の作成をテスト
+func TestVocabularyService_AutoCollectWordsCreatesReviewItems(t *testing.T) {
+	service := NewMockVocabularyService()
+	reviewRepo := repository.NewInMemoryReviewRepository()
+	service.SetReviewItemGenerator(srsservice.NewReviewItemGenerator(srsservice.NewSRSService(reviewRepo)))
+	ctx := context.Background()
+
+	err := service.AutoCollectWords(ctx, "user-1", "book-1", 5, "Здравствуйте! Как дела?", "ru")
+	require.NoError(t, err)
+
+	words, err := service.GetWords(ctx, &models.WordFilter{UserID: "user-1", BookID: "book-1"})
+	require.NoError(t, err)
+	items, err := reviewRepo.FindByUserID(ctx, "user-1")
+	require.NoError(t, err)
+	assert.NotEmpty(t, items)
+	sources := make(map[string]bool)
+	for _, item := range items {
+		sources[item.SourceID] = true
+	}
+	assert.Len(t, sources, len(words), "収集した単語ごとに復習項目が作成されること")
+	for _, item := range items {
+		assert.Equal(t, models.ReviewItemTypeWord, item.Type)
+		assert.Equal(t, "book-1", item.BookID)
+		assert.Equal(t, 5, item.PageNumber)
+	}
+
+	// 同じテキストを再度収集しても復習項目は増えない
+	err = service.AutoCollectWords(ctx, "user-1", "book-1", 5, "Здравствуйте! Как дела?", "ru")
+	require.NoError(t, err)
+	again, err := reviewRepo.FindByUserID(ctx, "user-1")
+	require.NoError(t, err)
+	assert.Len(t, again, len(items))
+}
+
 // TestVocabularyService_AddWord は単語追加のテスト
 func TestVocabularyService_AddWord(t *testing.T) {
 	service := NewMockVocabularyService()
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"time"
 
+	"github.com/clearclown/HaiLanGo/backend/internal/models"
 	"github.com/google/uuid"
 )
 
@@ -31,6 +32,12 @@ const (
 
 	// MessageTypeConnectionEstablished は接続確立通知
 	MessageTypeConnectionEstablished MessageType = "connection_established"
+
+	// MessageTypeSTTInterim はストリーミング音声認識の途中結果
+	MessageTypeSTTInterim MessageType = "stt_interim"
+
+	// MessageTypeSTTResult はストリーミング音声認識の最終結果と発音評価
+	MessageTypeSTTResult MessageType = "stt_result"
 )
 
 // Message はWebSocketメッセージの基本構造
@@ -152,6 +159,17 @@ type ConnectionEstablishedPayload struct {
 	Timestamp time.Time `json:"timestamp"`
 }
 
+// STTInterimPayload はストリーミング音声認識の途中結果のペイロード
+type STTInterimPayload struct {
+	Text string `json:"text"`
+}
+
+// STTResultPayload はストリーミング音声認識の最終結果と発音評価のペイロード
+type STTResultPayload struct {
+	Result *models.STTResult          `json:"result"`
+	Score  *models.PronunciationScore `json:"score"`
+}
+
 // Helper functions for creating typed messages
 
 // NewOCRProgressMessage はOCR進捗メッセージを作成する
@@ -238,3 +256,22 @@ func NewConnectionEstablishedMessage(userID uuid.UUID) (Message, error) {
 
 	return NewMessage(MessageTypeConnectionEstablished, payload)
 }
+
+// NewSTTInterimMessage はストリーミング音声認識の途中結果メッセージを作成する
+func NewSTTInterimMessage(text string) (Message, error) {
+	payload := STTInterimPayload{
+		Text: text,
+	}
+
+	return NewMessage(MessageTypeSTTInterim, payload)
+}
+
+// NewSTTResultMessage はストリーミング音声認識の最終結果メッセージを作成する
+func NewSTTResultMessage(result *models.STTResult, score *models.PronunciationScore) (Message, error) {
+	payload := STTResultPayload{
+		Result: result,
+		Score:  score,
+	}
+
+	return NewMessage(MessageTypeSTTResult, payload)
+}
@@ -0,0 +1,4 @@
+-- ページごとのOCRレイアウト（ブロック→行→単語と矩形領域）
+ALTER TABLE pages ADD COLUMN IF NOT EXISTS ocr_layout JSONB;
+
+COMMENT ON COLUMN pages.ocr_layout IS 'OCRレイアウト（ブロック・行・単語の階層とバウンディングボックス）';
@@ -0,0 +1,16 @@
+DROP INDEX IF EXISTS idx_ocr_jobs_page_id;
+DROP INDEX IF EXISTS idx_ocr_jobs_queue;
+
+ALTER TABLE ocr_jobs DROP COLUMN IF EXISTS next_attempt_at;
+ALTER TABLE ocr_jobs DROP COLUMN IF EXISTS locked_at;
+ALTER TABLE ocr_jobs DROP COLUMN IF EXISTS locked_by;
+ALTER TABLE ocr_jobs DROP COLUMN IF EXISTS max_attempts;
+ALTER TABLE ocr_jobs DROP COLUMN IF EXISTS attempts;
+ALTER TABLE ocr_jobs DROP COLUMN IF EXISTS languages;
+ALTER TABLE ocr_jobs DROP COLUMN IF EXISTS image_url;
+ALTER TABLE ocr_jobs DROP COLUMN IF EXISTS page_id;
+
+UPDATE ocr_jobs SET status = 'failed' WHERE status IN ('cancelled', 'dead_letter');
+ALTER TABLE ocr_jobs DROP CONSTRAINT IF EXISTS ocr_jobs_status_check;
+ALTER TABLE ocr_jobs ADD CONSTRAINT ocr_jobs_status_check
+    CHECK (status IN ('pending', 'processing', 'completed', 'failed'));
@@ -0,0 +1,17 @@
+-- ocr_jobs を永続ジョブキューとして使うためのカラムを追加
+-- ワーカーは SELECT ... FOR UPDATE SKIP LOCKED でジョブを取得し、locked_at のリース期限切れで再取得する
+ALTER TABLE ocr_jobs DROP CONSTRAINT IF EXISTS ocr_jobs_status_check;
+ALTER TABLE ocr_jobs ADD CONSTRAINT ocr_jobs_status_check
+    CHECK (status IN ('pending', 'processing', 'completed', 'failed', 'cancelled', 'dead_letter'));
+
+ALTER TABLE ocr_jobs ADD COLUMN IF NOT EXISTS page_id UUID REFERENCES pages(id) ON DELETE CASCADE;
+ALTER TABLE ocr_jobs ADD COLUMN IF NOT EXISTS image_url TEXT;
+ALTER TABLE ocr_jobs ADD COLUMN IF NOT EXISTS languages TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE ocr_jobs ADD COLUMN IF NOT EXISTS attempts INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE ocr_jobs ADD COLUMN IF NOT EXISTS max_attempts INTEGER NOT NULL DEFAULT 3;
+ALTER TABLE ocr_jobs ADD COLUMN IF NOT EXISTS locked_by VARCHAR(255);
+ALTER TABLE ocr_jobs ADD COLUMN IF NOT EXISTS locked_at TIMESTAMP;
+ALTER TABLE ocr_jobs ADD COLUMN IF NOT EXISTS next_attempt_at TIMESTAMP NOT NULL DEFAULT NOW();
+
+CREATE INDEX IF NOT EXISTS idx_ocr_jobs_queue ON ocr_jobs(status, next_attempt_at);
+CREATE INDEX IF NOT EXISTS idx_ocr_jobs_page_id ON ocr_jobs(page_id);
@@ -0,0 +1,4 @@
+-- ページのOCR結果を生成したプロバイダー（フォールバックチェーンで採用されたもの）
+ALTER TABLE pages ADD COLUMN IF NOT EXISTS ocr_provider VARCHAR(50);
+
+COMMENT ON COLUMN pages.ocr_provider IS 'OCR結果を生成したプロバイダー（google_vision, azure_vision, tesseract など）';
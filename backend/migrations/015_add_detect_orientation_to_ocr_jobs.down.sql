@@ -0,0 +1 @@
+ALTER TABLE ocr_jobs DROP COLUMN IF EXISTS detect_orientation;
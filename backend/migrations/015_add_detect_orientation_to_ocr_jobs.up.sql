@@ -0,0 +1,4 @@
+-- OCR前処理で文字の向き検出（90度回転の自動補正）を行うかどうか
+ALTER TABLE ocr_jobs ADD COLUMN IF NOT EXISTS detect_orientation BOOLEAN NOT NULL DEFAULT FALSE;
+
+COMMENT ON COLUMN ocr_jobs.detect_orientation IS 'OCR前処理で文字の向きを検出して回転補正するか（縦書きの書籍では無効にする）';
@@ -0,0 +1,22 @@
+-- ページから抽出した対訳フレーズ（学習先言語の文と母国語訳の組）
+CREATE TABLE IF NOT EXISTS phrases (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    book_id UUID NOT NULL REFERENCES books(id) ON DELETE CASCADE,
+    page_id UUID NOT NULL REFERENCES pages(id) ON DELETE CASCADE,
+    page_number INTEGER NOT NULL,
+    position INTEGER NOT NULL,
+    text TEXT NOT NULL,
+    translation TEXT NOT NULL DEFAULT '',
+    target_language VARCHAR(10) NOT NULL,
+    native_language VARCHAR(10) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT unique_page_phrase_position UNIQUE (page_id, position)
+);
+
+CREATE INDEX idx_phrases_book_page ON phrases(book_id, page_number, position);
+
+COMMENT ON TABLE phrases IS 'OCR結果から分割した対訳フレーズ';
+COMMENT ON COLUMN phrases.position IS 'ページ内の順序（0から開始）';
+COMMENT ON COLUMN phrases.text IS '学習先言語の文';
+COMMENT ON COLUMN phrases.translation IS '母国語訳（対応する訳がない場合は空）';
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS ocr_auto_corrections;
+DROP TABLE IF EXISTS ocr_correction_rules;
+DROP TABLE IF EXISTS ocr_text_corrections;
+ALTER TABLE pages DROP COLUMN IF EXISTS corrected_text;
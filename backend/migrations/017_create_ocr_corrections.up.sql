@@ -0,0 +1,61 @@
+-- OCR結果の手動修正と、修正から学習した置換ルールによる自動修正
+ALTER TABLE pages ADD COLUMN IF NOT EXISTS corrected_text TEXT;
+
+COMMENT ON COLUMN pages.corrected_text IS '手動修正・自動修正後のテキスト（NULLの場合はOCR結果のまま）';
+
+-- 手動修正の履歴
+CREATE TABLE IF NOT EXISTS ocr_text_corrections (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    book_id UUID NOT NULL REFERENCES books(id) ON DELETE CASCADE,
+    page_id UUID NOT NULL REFERENCES pages(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    original_text TEXT NOT NULL,
+    corrected_text TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_ocr_text_corrections_page ON ocr_text_corrections(page_id, created_at DESC);
+
+-- 手動修正の差分から学習した置換ルール
+CREATE TABLE IF NOT EXISTS ocr_correction_rules (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    book_id UUID REFERENCES books(id) ON DELETE CASCADE,
+    original TEXT NOT NULL,
+    replacement TEXT NOT NULL,
+    occurrences INTEGER NOT NULL DEFAULT 1,
+    disabled BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX idx_ocr_correction_rules_scope
+    ON ocr_correction_rules(user_id, COALESCE(book_id, '00000000-0000-0000-0000-000000000000'::uuid), original);
+
+COMMENT ON TABLE ocr_correction_rules IS 'OCR結果の手動修正から学習した置換ルール';
+COMMENT ON COLUMN ocr_correction_rules.book_id IS '書籍単位のルール（NULLの場合はユーザーの全書籍に適用）';
+COMMENT ON COLUMN ocr_correction_rules.occurrences IS '同じ修正が行われた回数';
+
+-- 置換ルールによる自動修正の記録（確認・取り消し用）
+CREATE TABLE IF NOT EXISTS ocr_auto_corrections (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    rule_id UUID NOT NULL REFERENCES ocr_correction_rules(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    book_id UUID NOT NULL REFERENCES books(id) ON DELETE CASCADE,
+    page_id UUID NOT NULL REFERENCES pages(id) ON DELETE CASCADE,
+    page_number INTEGER NOT NULL,
+    original TEXT NOT NULL,
+    replacement TEXT NOT NULL,
+    "offset" INTEGER NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'applied',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    reviewed_at TIMESTAMP,
+
+    CONSTRAINT check_ocr_auto_correction_status CHECK (status IN ('applied', 'accepted', 'reverted'))
+);
+
+CREATE INDEX idx_ocr_auto_corrections_book ON ocr_auto_corrections(book_id, status, page_number);
+CREATE INDEX idx_ocr_auto_corrections_page ON ocr_auto_corrections(page_id);
+
+COMMENT ON COLUMN ocr_auto_corrections."offset" IS '修正後テキスト内の置換位置（文字数）';
@@ -0,0 +1,2 @@
+-- 復習履歴から再計算したスケジューラー状態は元に戻さない
+DROP TABLE IF EXISTS review_settings;
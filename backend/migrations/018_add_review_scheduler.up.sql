@@ -0,0 +1,81 @@
+-- 復習アルゴリズム（スケジューラー）のユーザー設定
+CREATE TABLE IF NOT EXISTS review_settings (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    algorithm VARCHAR(20) NOT NULL DEFAULT 'sm2',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT review_settings_algorithm_check CHECK (algorithm IN ('sm2', 'ladder'))
+);
+
+COMMENT ON COLUMN review_settings.algorithm IS '復習間隔の計算アルゴリズム（sm2: SuperMemo 2, ladder: 1/3/7/14/30/60日の固定間隔）';
+
+-- 既存の復習項目のスケジューラー状態を復習履歴から再計算する
+-- これまで ease_factor / interval は一部の経路でしか更新されていなかったため、
+-- 履歴を古い順にSM-2（デフォルトのアルゴリズム）で再生して状態をそろえる
+DO $$
+DECLARE
+    target RECORD;
+    history RECORD;
+    ease NUMERIC;
+    interval_days INTEGER;
+    quality INTEGER;
+    reviews INTEGER;
+    correct INTEGER;
+    incorrect INTEGER;
+    last_reviewed TIMESTAMP;
+BEGIN
+    FOR target IN SELECT DISTINCT item_id FROM review_history LOOP
+        ease := 2.5;
+        interval_days := 0;
+        reviews := 0;
+        correct := 0;
+        incorrect := 0;
+        last_reviewed := NULL;
+
+        FOR history IN
+            SELECT score, reviewed_at FROM review_history
+            WHERE item_id = target.item_id
+            ORDER BY reviewed_at
+        LOOP
+            -- スコア（0-100）をSM-2の品質（0-5）に変換
+            quality := CASE
+                WHEN history.score >= 90 THEN 5
+                WHEN history.score >= 70 THEN 4
+                WHEN history.score >= 50 THEN 3
+                WHEN history.score >= 30 THEN 2
+                ELSE 0
+            END;
+
+            ease := GREATEST(1.3, ease + (0.1 - (5 - quality) * (0.08 + (5 - quality) * 0.02)));
+
+            IF quality < 3 THEN
+                interval_days := 1;
+                incorrect := incorrect + 1;
+            ELSE
+                IF interval_days = 0 THEN
+                    interval_days := 1;
+                ELSIF interval_days = 1 THEN
+                    interval_days := 6;
+                ELSE
+                    interval_days := ROUND(interval_days * ease);
+                END IF;
+                correct := correct + 1;
+            END IF;
+
+            reviews := reviews + 1;
+            last_reviewed := history.reviewed_at;
+        END LOOP;
+
+        UPDATE review_items SET
+            ease_factor = ROUND(ease, 2),
+            interval = interval_days,
+            repetitions = reviews,
+            last_reviewed_at = last_reviewed,
+            next_review_date = last_reviewed + make_interval(days => interval_days),
+            correct_count = correct,
+            incorrect_count = incorrect,
+            updated_at = NOW()
+        WHERE id = target.item_id;
+    END LOOP;
+END $$;
@@ -0,0 +1,13 @@
+UPDATE review_settings SET algorithm = 'sm2' WHERE algorithm = 'fsrs';
+
+ALTER TABLE review_settings DROP CONSTRAINT IF EXISTS review_settings_algorithm_check;
+ALTER TABLE review_settings
+    ADD CONSTRAINT review_settings_algorithm_check CHECK (algorithm IN ('sm2', 'ladder'));
+
+ALTER TABLE review_settings
+    DROP COLUMN IF EXISTS optimized_at,
+    DROP COLUMN IF EXISTS fsrs_weights;
+
+ALTER TABLE review_items
+    DROP COLUMN IF EXISTS difficulty,
+    DROP COLUMN IF EXISTS stability;
@@ -0,0 +1,17 @@
+-- FSRS（Free Spaced Repetition Scheduler）の記憶の状態とユーザーごとの最適化したパラメータ
+ALTER TABLE review_items
+    ADD COLUMN IF NOT EXISTS stability DOUBLE PRECISION NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS difficulty DOUBLE PRECISION NOT NULL DEFAULT 0;
+
+COMMENT ON COLUMN review_items.stability IS 'FSRSの記憶の安定度（想起率が90%に下がるまでの日数、FSRS以外で復習した項目は0）';
+COMMENT ON COLUMN review_items.difficulty IS 'FSRSの難易度（1-10、FSRS以外で復習した項目は0）';
+
+ALTER TABLE review_settings
+    ADD COLUMN IF NOT EXISTS fsrs_weights DOUBLE PRECISION[],
+    ADD COLUMN IF NOT EXISTS optimized_at TIMESTAMP;
+
+COMMENT ON COLUMN review_settings.fsrs_weights IS '復習ログから最適化したFSRSの重み（NULLの場合はデフォルト）';
+
+ALTER TABLE review_settings DROP CONSTRAINT IF EXISTS review_settings_algorithm_check;
+ALTER TABLE review_settings
+    ADD CONSTRAINT review_settings_algorithm_check CHECK (algorithm IN ('sm2', 'ladder', 'fsrs'));
@@ -0,0 +1,5 @@
+ALTER TABLE review_settings
+    DROP CONSTRAINT IF EXISTS review_settings_reviews_per_day_check,
+    DROP CONSTRAINT IF EXISTS review_settings_new_cards_per_day_check,
+    DROP COLUMN IF EXISTS reviews_per_day,
+    DROP COLUMN IF EXISTS new_cards_per_day;
@@ -0,0 +1,11 @@
+-- 1日に出題する新規項目・復習項目の上限
+ALTER TABLE review_settings
+    ADD COLUMN IF NOT EXISTS new_cards_per_day INTEGER NOT NULL DEFAULT 20,
+    ADD COLUMN IF NOT EXISTS reviews_per_day INTEGER NOT NULL DEFAULT 200;
+
+ALTER TABLE review_settings
+    ADD CONSTRAINT review_settings_new_cards_per_day_check CHECK (new_cards_per_day >= 0),
+    ADD CONSTRAINT review_settings_reviews_per_day_check CHECK (reviews_per_day > 0);
+
+COMMENT ON COLUMN review_settings.new_cards_per_day IS '1日に新しく学習する項目数の上限';
+COMMENT ON COLUMN review_settings.reviews_per_day IS '1日に復習する項目数の上限（新しく学習する項目は含まない）';
@@ -0,0 +1,9 @@
+DELETE FROM review_items WHERE card_type <> 'recognition';
+
+DROP INDEX IF EXISTS idx_review_items_source_id;
+
+ALTER TABLE review_items
+    DROP CONSTRAINT IF EXISTS review_items_card_type_check,
+    DROP COLUMN IF EXISTS language,
+    DROP COLUMN IF EXISTS source_id,
+    DROP COLUMN IF EXISTS card_type;
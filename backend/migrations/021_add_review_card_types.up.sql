@@ -0,0 +1,32 @@
+-- 1つの学習項目（単語・フレーズ・パターン）から作成するカードの種類
+-- 同じ学習項目のカードは source_id を共有し、それぞれ独立して復習の状態と履歴を持つ
+ALTER TABLE review_items
+    ADD COLUMN IF NOT EXISTS card_type VARCHAR(20) NOT NULL DEFAULT 'recognition',
+    ADD COLUMN IF NOT EXISTS source_id UUID,
+    ADD COLUMN IF NOT EXISTS language VARCHAR(10) NOT NULL DEFAULT '';
+
+UPDATE review_items SET source_id = id WHERE source_id IS NULL;
+
+ALTER TABLE review_items
+    ALTER COLUMN source_id SET NOT NULL,
+    ADD CONSTRAINT review_items_card_type_check
+        CHECK (card_type IN ('recognition', 'production', 'listening', 'speaking'));
+
+CREATE INDEX IF NOT EXISTS idx_review_items_source_id ON review_items(source_id);
+
+COMMENT ON COLUMN review_items.card_type IS 'カードの種類（recognition: 学習言語→母国語, production: 母国語→学習言語, listening: 音声のみ, speaking: 発音）';
+COMMENT ON COLUMN review_items.source_id IS '同じ学習項目から作成したカードで共通のID';
+COMMENT ON COLUMN review_items.language IS '学習言語（聞き取りカードの音声合成と発音カードの採点に使用）';
+
+-- 既存の項目（認識カード）に残りの種類のカードを未学習の状態で追加する
+-- 訳のない項目は母国語から出題できないため、産出カードと発音カードは作成しない
+INSERT INTO review_items (
+    user_id, book_id, page_number, item_type, content, translation, context,
+    card_type, source_id, language, created_at, updated_at
+)
+SELECT r.user_id, r.book_id, r.page_number, r.item_type, r.content, r.translation, r.context,
+       c.card_type, r.source_id, r.language, NOW(), NOW()
+FROM review_items r
+CROSS JOIN (VALUES ('production'), ('listening'), ('speaking')) AS c(card_type)
+WHERE r.card_type = 'recognition'
+  AND (c.card_type = 'listening' OR r.translation <> '');
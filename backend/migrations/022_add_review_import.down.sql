@@ -0,0 +1,5 @@
+DELETE FROM review_items WHERE book_id IS NULL;
+
+ALTER TABLE review_items
+    DROP COLUMN IF EXISTS audio_url,
+    ALTER COLUMN book_id SET NOT NULL;
@@ -0,0 +1,7 @@
+-- Ankiなどの外部のデッキから取り込んだ復習項目は本に属さないため、book_id を省略できるようにする
+ALTER TABLE review_items
+    ALTER COLUMN book_id DROP NOT NULL,
+    ADD COLUMN IF NOT EXISTS audio_url TEXT NOT NULL DEFAULT '';
+
+COMMENT ON COLUMN review_items.book_id IS '学習項目を作成した本（外部のデッキから取り込んだ項目はNULL）';
+COMMENT ON COLUMN review_items.audio_url IS '取り込んだ音声のURL（空の場合は聞き取りカードの出題時に音声合成する）';
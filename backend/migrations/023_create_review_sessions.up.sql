@@ -0,0 +1,18 @@
+-- 終了した復習セッションの集計（学習時間・正答率の統計に使用）
+CREATE TABLE IF NOT EXISTS review_sessions (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    reviewed_count INTEGER NOT NULL DEFAULT 0,
+    correct_count INTEGER NOT NULL DEFAULT 0,
+    duration_seconds INTEGER NOT NULL DEFAULT 0,
+    time_spent_seconds INTEGER NOT NULL DEFAULT 0,
+    started_at TIMESTAMP NOT NULL,
+    finished_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_review_sessions_user_started ON review_sessions(user_id, started_at);
+
+COMMENT ON COLUMN review_sessions.correct_count IS 'スコアが70以上（Good・Easy）の回答の数';
+COMMENT ON COLUMN review_sessions.duration_seconds IS 'セッションの開始から終了までの秒数';
+COMMENT ON COLUMN review_sessions.time_spent_seconds IS '回答に要した秒数の合計（review_history.time_spent_seconds の合計）';
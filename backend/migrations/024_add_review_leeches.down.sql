@@ -0,0 +1,14 @@
+ALTER TABLE review_settings
+    DROP CONSTRAINT IF EXISTS review_settings_leech_action_check,
+    DROP CONSTRAINT IF EXISTS review_settings_leech_window_days_check,
+    DROP CONSTRAINT IF EXISTS review_settings_leech_threshold_check,
+    DROP COLUMN IF EXISTS leech_action,
+    DROP COLUMN IF EXISTS leech_window_days,
+    DROP COLUMN IF EXISTS leech_threshold;
+
+DROP INDEX IF EXISTS idx_review_items_leeched;
+
+ALTER TABLE review_items
+    DROP COLUMN IF EXISTS leech_cleared_at,
+    DROP COLUMN IF EXISTS leeched_at,
+    DROP COLUMN IF EXISTS suspended;
@@ -0,0 +1,25 @@
+-- 何度も忘れる復習項目（リーチ）の検出と対処
+ALTER TABLE review_items
+    ADD COLUMN IF NOT EXISTS suspended BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN IF NOT EXISTS leeched_at TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS leech_cleared_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_review_items_leeched ON review_items(user_id) WHERE leeched_at IS NOT NULL;
+
+COMMENT ON COLUMN review_items.suspended IS '出題を停止しているか（リーチの対処が suspend の場合）';
+COMMENT ON COLUMN review_items.leeched_at IS 'リーチと判定した日時（解除するとNULL）';
+COMMENT ON COLUMN review_items.leech_cleared_at IS 'リーチを解除した日時（それ以前の忘却は数えない）';
+
+ALTER TABLE review_settings
+    ADD COLUMN IF NOT EXISTS leech_threshold INTEGER NOT NULL DEFAULT 5,
+    ADD COLUMN IF NOT EXISTS leech_window_days INTEGER NOT NULL DEFAULT 30,
+    ADD COLUMN IF NOT EXISTS leech_action VARCHAR(20) NOT NULL DEFAULT 'reteach';
+
+ALTER TABLE review_settings
+    ADD CONSTRAINT review_settings_leech_threshold_check CHECK (leech_threshold > 0),
+    ADD CONSTRAINT review_settings_leech_window_days_check CHECK (leech_window_days > 0),
+    ADD CONSTRAINT review_settings_leech_action_check CHECK (leech_action IN ('suspend', 'reteach'));
+
+COMMENT ON COLUMN review_settings.leech_threshold IS 'リーチと判定する忘却（スコア50未満）の回数';
+COMMENT ON COLUMN review_settings.leech_window_days IS '忘却の回数を数える期間（日数）';
+COMMENT ON COLUMN review_settings.leech_action IS 'リーチの対処（suspend: 出題を停止, reteach: 教材を示して学び直す）';
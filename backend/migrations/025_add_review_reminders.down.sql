@@ -0,0 +1,12 @@
+DROP TABLE IF EXISTS push_subscriptions;
+
+DROP INDEX IF EXISTS idx_review_settings_reminder;
+
+ALTER TABLE review_settings
+    DROP CONSTRAINT IF EXISTS review_settings_reminder_channels_check,
+    DROP CONSTRAINT IF EXISTS review_settings_reminder_time_check,
+    DROP COLUMN IF EXISTS reminded_at,
+    DROP COLUMN IF EXISTS reminder_channels,
+    DROP COLUMN IF EXISTS reminder_timezone,
+    DROP COLUMN IF EXISTS reminder_time,
+    DROP COLUMN IF EXISTS reminder_enabled;
@@ -0,0 +1,33 @@
+-- 復習リマインダー（毎日決まった時刻に今日の復習の件数を通知する）
+ALTER TABLE review_settings
+    ADD COLUMN IF NOT EXISTS reminder_enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN IF NOT EXISTS reminder_time VARCHAR(5) NOT NULL DEFAULT '09:00',
+    ADD COLUMN IF NOT EXISTS reminder_timezone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+    ADD COLUMN IF NOT EXISTS reminder_channels TEXT[] NOT NULL DEFAULT '{websocket}',
+    ADD COLUMN IF NOT EXISTS reminded_at TIMESTAMP;
+
+ALTER TABLE review_settings
+    ADD CONSTRAINT review_settings_reminder_time_check CHECK (reminder_time ~ '^([01][0-9]|2[0-3]):[0-5][0-9]$'),
+    ADD CONSTRAINT review_settings_reminder_channels_check CHECK (reminder_channels <@ ARRAY['websocket', 'email', 'push']::TEXT[]);
+
+CREATE INDEX IF NOT EXISTS idx_review_settings_reminder ON review_settings(user_id) WHERE reminder_enabled;
+
+COMMENT ON COLUMN review_settings.reminder_time IS 'リマインダーを送る時刻（reminder_timezone の HH:MM）';
+COMMENT ON COLUMN review_settings.reminder_timezone IS 'ユーザーのタイムゾーン（IANA、例: Asia/Tokyo）';
+COMMENT ON COLUMN review_settings.reminder_channels IS '通知先（websocket, email, push）';
+COMMENT ON COLUMN review_settings.reminded_at IS '最後にリマインダーを送った日時（1日に1回だけ送る）';
+
+-- ブラウザのWeb Pushの購読
+CREATE TABLE IF NOT EXISTS push_subscriptions (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    endpoint TEXT NOT NULL UNIQUE,
+    p256dh TEXT NOT NULL,
+    auth TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_push_subscriptions_user ON push_subscriptions(user_id);
+
+COMMENT ON COLUMN push_subscriptions.p256dh IS 'ブラウザのP-256公開鍵（base64url）';
+COMMENT ON COLUMN push_subscriptions.auth IS 'ブラウザの認証シークレット（base64url）';
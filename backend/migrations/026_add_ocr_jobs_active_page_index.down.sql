@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_ocr_jobs_active_page;
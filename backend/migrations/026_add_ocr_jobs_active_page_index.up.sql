@@ -0,0 +1,14 @@
+-- 同じページの処理待ち・処理中のジョブは1件だけにする
+-- 同時に登録された場合も Enqueue の ON CONFLICT DO NOTHING で重複を登録しない
+UPDATE ocr_jobs SET status = 'cancelled', updated_at = NOW()
+WHERE status IN ('pending', 'processing')
+  AND id NOT IN (
+      SELECT DISTINCT ON (book_id, page_number) id
+      FROM ocr_jobs
+      WHERE status IN ('pending', 'processing')
+      ORDER BY book_id, page_number, (status = 'processing') DESC, created_at
+  );
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_ocr_jobs_active_page
+    ON ocr_jobs(book_id, page_number)
+    WHERE status IN ('pending', 'processing');
@@ -0,0 +1,3 @@
+DELETE FROM ocr_jobs WHERE kind = 'pdf';
+ALTER TABLE ocr_jobs DROP CONSTRAINT IF EXISTS ocr_jobs_kind_check;
+ALTER TABLE ocr_jobs DROP COLUMN IF EXISTS kind;
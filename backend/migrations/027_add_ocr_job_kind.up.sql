@@ -0,0 +1,7 @@
+-- ocr_jobs でアップロードされたPDFのページ分割もジョブとして扱う
+-- pdf のジョブは image_url にPDFのパスを持ち、page_id・page_number は NULL にする
+ALTER TABLE ocr_jobs ADD COLUMN IF NOT EXISTS kind VARCHAR(20) NOT NULL DEFAULT 'page';
+ALTER TABLE ocr_jobs DROP CONSTRAINT IF EXISTS ocr_jobs_kind_check;
+ALTER TABLE ocr_jobs ADD CONSTRAINT ocr_jobs_kind_check CHECK (kind IN ('page', 'pdf'));
+
+COMMENT ON COLUMN ocr_jobs.kind IS 'ジョブの種類（page: 1ページのOCR, pdf: PDFのページ分割）';
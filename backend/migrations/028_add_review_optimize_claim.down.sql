@@ -0,0 +1,2 @@
+ALTER TABLE review_settings
+    DROP COLUMN IF EXISTS optimize_claimed_at;
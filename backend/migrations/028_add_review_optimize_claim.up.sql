@@ -0,0 +1,5 @@
+-- FSRSの最適化を始めた日時（複数のサーバーで同じユーザーを最適化しないよう、始める前に記録する）
+ALTER TABLE review_settings
+    ADD COLUMN IF NOT EXISTS optimize_claimed_at TIMESTAMP;
+
+COMMENT ON COLUMN review_settings.optimize_claimed_at IS 'FSRSの最適化を最後に始めた日時（サーバー間の重複実行の防止用）';
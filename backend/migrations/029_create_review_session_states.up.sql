@@ -0,0 +1,17 @@
+-- 進行中の復習セッションの状態（どのサーバーからでも続けられるよう、操作のたびに保存する）
+CREATE TABLE IF NOT EXISTS review_session_states (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    state JSONB NOT NULL,
+    started_at TIMESTAMP NOT NULL,
+    last_active_at TIMESTAMP NOT NULL,
+    locked_by VARCHAR(64),
+    locked_until TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_review_session_states_last_active ON review_session_states(last_active_at);
+
+COMMENT ON COLUMN review_session_states.state IS '出題順・出題した時刻・回答の取り消しに必要な回答前の状態';
+COMMENT ON COLUMN review_session_states.locked_by IS 'セッションを操作中のリクエスト（同じセッションへの同時の回答を1つずつ処理する）';
+COMMENT ON COLUMN review_session_states.locked_until IS 'ロックの期限（操作中にサーバーが停止した場合は期限後に他のリクエストが操作できる）';
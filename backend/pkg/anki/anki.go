@@ -0,0 +1,741 @@
+// Package anki はAnkiのデッキ（.apkg パッケージとテキスト形式）を読み書きする
+package anki
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// fieldSeparator はノートのフィールドの区切り文字
+const fieldSeparator = "\x1f"
+
+// カードの種類（cards.type）
+const (
+	CardTypeNew        = 0
+	CardTypeLearning   = 1
+	CardTypeReview     = 2
+	CardTypeRelearning = 3
+)
+
+// 復習ログの種類（revlog.type）
+const (
+	ReviewTypeLearning   = 0
+	ReviewTypeReview     = 1
+	ReviewTypeRelearning = 2
+	ReviewTypeFiltered   = 3
+	ReviewTypeManual     = 4
+)
+
+var (
+	// ErrInvalidPackage は .apkg として読み取れない場合のエラー
+	ErrInvalidPackage = errors.New("invalid anki package")
+	// ErrUnsupportedFormat はこのパッケージが対応していない、より新しい形式（meta のバージョン）のパッケージの場合のエラー
+	ErrUnsupportedFormat = errors.New("unsupported anki package format")
+)
+
+// Collection はAnkiのコレクション（パッケージの内容）
+type Collection struct {
+	// Created はコレクションの作成日（復習カードの期日はこの日からの日数）
+	Created time.Time
+	Models  []Model
+	Decks   []Deck
+	Notes   []Note
+	Cards   []Card
+	Reviews []Review
+	// Media はメディアファイル（ファイル名 → 内容）
+	Media map[string][]byte
+}
+
+// Model はノートタイプ（フィールドとカードのテンプレート）
+type Model struct {
+	ID        int64
+	Name      string
+	Fields    []string
+	Templates []Template
+	CSS       string
+}
+
+// Template はカードのテンプレート（ord はテンプレートの位置）
+type Template struct {
+	Name  string
+	Front string
+	Back  string
+}
+
+// Deck はデッキ
+type Deck struct {
+	ID   int64
+	Name string
+}
+
+// Note はノート（1つの学習項目、フィールドの値はHTML）
+type Note struct {
+	ID       int64
+	GUID     string
+	ModelID  int64
+	Fields   []string
+	Tags     []string
+	Modified time.Time
+}
+
+// Card はノートのテンプレートごとのカードと、その復習の状態
+type Card struct {
+	ID     int64
+	NoteID int64
+	DeckID int64
+	Ord    int
+	Type   int
+	// Queue は出題のキュー（-1: 保留, -2/-3: 埋め込み、それ以外は Type と同じ）
+	Queue int
+	// Due は新規カードでは出題順、学習中のカードではUNIX時刻（秒）、復習カードではコレクションの作成日からの日数
+	Due int64
+	// Interval は復習間隔（正の値は日数、負の値は秒数）
+	Interval int
+	// Factor は易しさ（SM-2の易しさ係数 × 1000）
+	Factor   int
+	Reps     int
+	Lapses   int
+	Modified time.Time
+	// Stability と Difficulty はFSRSの記憶の状態（FSRSで復習していないカードは0）
+	Stability  float64
+	Difficulty float64
+}
+
+// Review は復習ログ
+type Review struct {
+	ID     int64 // 復習した時刻（UNIXミリ秒）
+	CardID int64
+	// Ease は回答のボタン（1: もう一度, 2: 難しい, 3: 普通, 4: 簡単、手動の変更は0）
+	Ease         int
+	Interval     int
+	LastInterval int
+	Factor       int
+	Duration     time.Duration
+	Type         int
+}
+
+// ReviewedAt は復習した時刻を返す
+func (r Review) ReviewedAt() time.Time {
+	return time.UnixMilli(r.ID)
+}
+
+// DueDate はカードの次の復習日時を返す（新規カードはゼロ値）
+func (c *Collection) DueDate(card Card) time.Time {
+	switch card.Type {
+	case CardTypeReview:
+		return c.Created.AddDate(0, 0, int(card.Due))
+	case CardTypeLearning, CardTypeRelearning:
+		// 日をまたぐ学習ステップは復習カードと同じく日数で保存される
+		if card.Due < 1_000_000_000 {
+			return c.Created.AddDate(0, 0, int(card.Due))
+		}
+		return time.Unix(card.Due, 0)
+	}
+	return time.Time{}
+}
+
+// IntervalDays はカードの復習間隔の日数を返す（1日未満の間隔は0）
+func (card Card) IntervalDays() int {
+	if card.Interval < 0 {
+		return 0
+	}
+	return card.Interval
+}
+
+// Model はIDのノートタイプを返す
+func (c *Collection) Model(id int64) *Model {
+	for i := range c.Models {
+		if c.Models[i].ID == id {
+			return &c.Models[i]
+		}
+	}
+	return nil
+}
+
+// Field はノートのフィールドの値を名前（大文字・小文字は区別しない）で返す
+func (m *Model) Field(note Note, name string) (string, bool) {
+	for i, field := range m.Fields {
+		if strings.EqualFold(field, name) && i < len(note.Fields) {
+			return note.Fields[i], true
+		}
+	}
+	return "", false
+}
+
+var (
+	soundPattern = regexp.MustCompile(`\[sound:([^\]]+)\]`)
+	breakPattern = regexp.MustCompile(`(?i)<br\s*/?>|</?(div|p)(\s[^>]*)?>`)
+	tagPattern   = regexp.MustCompile(`<[^>]*>`)
+)
+
+// SoundFiles はフィールドから参照している音声ファイル名（[sound:...]）を返す
+func SoundFiles(field string) []string {
+	var files []string
+	for _, match := range soundPattern.FindAllStringSubmatch(field, -1) {
+		files = append(files, match[1])
+	}
+	return files
+}
+
+// PlainText はフィールドのHTMLと音声の参照を取り除いたテキストを返す
+func PlainText(field string) string {
+	text := soundPattern.ReplaceAllString(field, "")
+	text = breakPattern.ReplaceAllString(text, "\n")
+	text = tagPattern.ReplaceAllString(text, "")
+	text = html.UnescapeString(text)
+
+	lines := strings.Split(text, "\n")
+	kept := lines[:0]
+	for _, line := range lines {
+		if line = strings.TrimSpace(line); line != "" {
+			kept = append(kept, line)
+		}
+	}
+	return strings.Join(kept, "\n")
+}
+
+// ReadPackage は .apkg パッケージ（ZIP）からコレクションとメディアを読み取る
+func ReadPackage(r io.ReaderAt, size int64) (*Collection, error) {
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPackage, err)
+	}
+
+	files := make(map[string]*zip.File, len(archive.File))
+	for _, file := range archive.File {
+		files[file.Name] = file
+	}
+
+	// meta はパッケージの形式のバージョン（ない場合は旧形式）
+	if metaFile := files["meta"]; metaFile != nil {
+		data, err := readZipFile(metaFile)
+		if err != nil {
+			return nil, err
+		}
+		version, err := parsePackageVersion(data)
+		if err != nil {
+			return nil, err
+		}
+		if version > packageVersionLatest {
+			return nil, fmt.Errorf("%w: version %d", ErrUnsupportedFormat, version)
+		}
+	}
+
+	// collection.anki21b（新しい形式）、collection.anki21、collection.anki2 の順に新しいスケジューラーのコレクション
+	// 新しい形式のパッケージの collection.anki2 は「Ankiを更新してください」というダミーのため使用しない
+	var collectionFile *zip.File
+	latest := false
+	switch {
+	case files["collection.anki21b"] != nil:
+		collectionFile = files["collection.anki21b"]
+		latest = true
+	case files["collection.anki21"] != nil:
+		collectionFile = files["collection.anki21"]
+	case files["collection.anki2"] != nil:
+		collectionFile = files["collection.anki2"]
+	default:
+		return nil, fmt.Errorf("%w: collection not found", ErrInvalidPackage)
+	}
+
+	data, err := readZipFile(collectionFile)
+	if err != nil {
+		return nil, err
+	}
+	if latest {
+		if data, err = decompress(collectionFile.Name, data); err != nil {
+			return nil, err
+		}
+	}
+	collection, err := readCollection(data)
+	if err != nil {
+		return nil, err
+	}
+
+	// media は ZIP 内のファイル名（"0", "1", ...）から元のファイル名への対応
+	// 旧形式ではJSON、新しい形式ではzstd圧縮した protobuf（メディアファイルもzstd圧縮）
+	collection.Media = make(map[string][]byte)
+	if mediaFile := files["media"]; mediaFile != nil {
+		data, err := readZipFile(mediaFile)
+		if err != nil {
+			return nil, err
+		}
+		var names map[string]string
+		if latest {
+			if data, err = decompress(mediaFile.Name, data); err != nil {
+				return nil, err
+			}
+			if names, err = parseMediaEntries(data); err != nil {
+				return nil, err
+			}
+		} else if len(bytes.TrimSpace(data)) > 0 {
+			if err := json.Unmarshal(data, &names); err != nil {
+				return nil, fmt.Errorf("%w: media: %v", ErrInvalidPackage, err)
+			}
+		}
+		for entry, name := range names {
+			file := files[entry]
+			if file == nil {
+				continue
+			}
+			content, err := readZipFile(file)
+			if err != nil {
+				return nil, err
+			}
+			if latest {
+				if content, err = decompress(name, content); err != nil {
+					return nil, err
+				}
+			}
+			collection.Media[name] = content
+		}
+	}
+
+	return collection, nil
+}
+
+// readZipFile はZIP内のファイルの内容を読み取る
+func readZipFile(file *zip.File) ([]byte, error) {
+	reader, err := file.Open()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPackage, err)
+	}
+	defer reader.Close()
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPackage, err)
+	}
+	return data, nil
+}
+
+// collectionModel はコレクションの models（JSON）のノートタイプ
+type collectionModel struct {
+	ID   json.Number `json:"id"`
+	Name string      `json:"name"`
+	CSS  string      `json:"css"`
+	Flds []struct {
+		Name string `json:"name"`
+		Ord  int    `json:"ord"`
+	} `json:"flds"`
+	Tmpls []struct {
+		Name string `json:"name"`
+		Ord  int    `json:"ord"`
+		Qfmt string `json:"qfmt"`
+		Afmt string `json:"afmt"`
+	} `json:"tmpls"`
+}
+
+// collectionDeck はコレクションの decks（JSON）のデッキ
+type collectionDeck struct {
+	ID   json.Number `json:"id"`
+	Name string      `json:"name"`
+}
+
+// readCollection はコレクション（SQLiteのデータベース）を読み取る
+func readCollection(data []byte) (*Collection, error) {
+	db, err := openSQLite(data)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	for _, table := range []string{"col", "notes", "cards"} {
+		if !db.hasTable(table) {
+			return nil, fmt.Errorf("%w: table %s not found", ErrInvalidPackage, table)
+		}
+	}
+
+	collection := &Collection{}
+
+	cols, err := db.readTable("col")
+	if err != nil {
+		return nil, err
+	}
+	if len(cols) == 0 {
+		return nil, fmt.Errorf("%w: empty col table", ErrInvalidPackage)
+	}
+	col := cols[0]
+	collection.Created = time.Unix(asInt(col["crt"]), 0)
+
+	// ノートタイプとデッキは col の JSON（スキーマ11）、または notetypes・decks テーブル（スキーマ15以降）
+	if asString(col["models"]) == "" && db.hasTable("notetypes") {
+		if collection.Models, err = readNotetypes(db); err != nil {
+			return nil, err
+		}
+	}
+	if asString(col["decks"]) == "" && db.hasTable("decks") {
+		if collection.Decks, err = readDeckTable(db); err != nil {
+			return nil, err
+		}
+	}
+
+	var models map[string]collectionModel
+	if text := asString(col["models"]); text != "" {
+		if err := json.Unmarshal([]byte(text), &models); err != nil {
+			return nil, fmt.Errorf("%w: models: %v", ErrInvalidPackage, err)
+		}
+	}
+	for _, model := range models {
+		id, _ := model.ID.Int64()
+		m := Model{ID: id, Name: model.Name, CSS: model.CSS}
+		sort.SliceStable(model.Flds, func(i, j int) bool { return model.Flds[i].Ord < model.Flds[j].Ord })
+		for _, field := range model.Flds {
+			m.Fields = append(m.Fields, field.Name)
+		}
+		sort.SliceStable(model.Tmpls, func(i, j int) bool { return model.Tmpls[i].Ord < model.Tmpls[j].Ord })
+		for _, tmpl := range model.Tmpls {
+			m.Templates = append(m.Templates, Template{Name: tmpl.Name, Front: tmpl.Qfmt, Back: tmpl.Afmt})
+		}
+		collection.Models = append(collection.Models, m)
+	}
+	sort.Slice(collection.Models, func(i, j int) bool { return collection.Models[i].ID < collection.Models[j].ID })
+
+	var decks map[string]collectionDeck
+	if text := asString(col["decks"]); text != "" {
+		if err := json.Unmarshal([]byte(text), &decks); err != nil {
+			return nil, fmt.Errorf("%w: decks: %v", ErrInvalidPackage, err)
+		}
+	}
+	for _, deck := range decks {
+		id, _ := deck.ID.Int64()
+		collection.Decks = append(collection.Decks, Deck{ID: id, Name: deck.Name})
+	}
+	sort.Slice(collection.Decks, func(i, j int) bool { return collection.Decks[i].ID < collection.Decks[j].ID })
+
+	notes, err := db.readTable("notes")
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range notes {
+		collection.Notes = append(collection.Notes, Note{
+			ID:       asInt(row["id"]),
+			GUID:     asString(row["guid"]),
+			ModelID:  asInt(row["mid"]),
+			Fields:   strings.Split(asString(row["flds"]), fieldSeparator),
+			Tags:     strings.Fields(asString(row["tags"])),
+			Modified: time.Unix(asInt(row["mod"]), 0),
+		})
+	}
+
+	cards, err := db.readTable("cards")
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range cards {
+		card := Card{
+			ID:       asInt(row["id"]),
+			NoteID:   asInt(row["nid"]),
+			DeckID:   asInt(row["did"]),
+			Ord:      int(asInt(row["ord"])),
+			Type:     int(asInt(row["type"])),
+			Queue:    int(asInt(row["queue"])),
+			Due:      asInt(row["due"]),
+			Interval: int(asInt(row["ivl"])),
+			Factor:   int(asInt(row["factor"])),
+			Reps:     int(asInt(row["reps"])),
+			Lapses:   int(asInt(row["lapses"])),
+			Modified: time.Unix(asInt(row["mod"]), 0),
+		}
+		card.Stability, card.Difficulty = parseMemoryState(asString(row["data"]))
+		collection.Cards = append(collection.Cards, card)
+	}
+
+	if db.hasTable("revlog") {
+		reviews, err := db.readTable("revlog")
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range reviews {
+			collection.Reviews = append(collection.Reviews, Review{
+				ID:           asInt(row["id"]),
+				CardID:       asInt(row["cid"]),
+				Ease:         int(asInt(row["ease"])),
+				Interval:     int(asInt(row["ivl"])),
+				LastInterval: int(asInt(row["lastIvl"])),
+				Factor:       int(asInt(row["factor"])),
+				Duration:     time.Duration(asInt(row["time"])) * time.Millisecond,
+				Type:         int(asInt(row["type"])),
+			})
+		}
+	}
+
+	return collection, nil
+}
+
+// cardData はカードの data（JSON）のFSRSの記憶の状態
+type cardData struct {
+	Stability  float64 `json:"s,omitempty"`
+	Difficulty float64 `json:"d,omitempty"`
+}
+
+// parseMemoryState はカードの data からFSRSの記憶の状態を取り出す
+func parseMemoryState(data string) (float64, float64) {
+	var parsed cardData
+	if data == "" || json.Unmarshal([]byte(data), &parsed) != nil {
+		return 0, 0
+	}
+	return parsed.Stability, parsed.Difficulty
+}
+
+// 書き出すコレクションのスキーマ（Anki 2.1 の旧形式、スキーマ11）
+const (
+	schemaCol    = "CREATE TABLE col (id integer primary key, crt integer not null, mod integer not null, scm integer not null, ver integer not null, dty integer not null, usn integer not null, ls integer not null, conf text not null, models text not null, decks text not null, dconf text not null, tags text not null)"
+	schemaNotes  = "CREATE TABLE notes (id integer primary key, guid text not null, mid integer not null, mod integer not null, usn integer not null, tags text not null, flds text not null, sfld integer not null, csum integer not null, flags integer not null, data text not null)"
+	schemaCards  = "CREATE TABLE cards (id integer primary key, nid integer not null, did integer not null, ord integer not null, mod integer not null, usn integer not null, type integer not null, queue integer not null, due integer not null, ivl integer not null, factor integer not null, reps integer not null, lapses integer not null, left integer not null, odue integer not null, odid integer not null, flags integer not null, data text not null)"
+	schemaRevlog = "CREATE TABLE revlog (id integer primary key, cid integer not null, usn integer not null, ease integer not null, ivl integer not null, lastIvl integer not null, factor integer not null, time integer not null, type integer not null)"
+	schemaGraves = "CREATE TABLE graves (usn integer not null, oid integer not null, type integer not null)"
+
+	// defaultDeckID はAnkiの既定のデッキ（Default）のID
+	defaultDeckID = 1
+)
+
+// WritePackage はコレクションを .apkg パッケージ（ZIP）として書き出す
+// Created が未設定の場合は今日の0時（UTC）を作成日にする
+func WritePackage(w io.Writer, collection *Collection) error {
+	if collection.Created.IsZero() {
+		collection.Created = time.Now().UTC().Truncate(24 * time.Hour)
+	}
+
+	data, err := writeCollection(collection)
+	if err != nil {
+		return err
+	}
+
+	archive := zip.NewWriter(w)
+	file, err := archive.Create("collection.anki2")
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(data); err != nil {
+		return err
+	}
+
+	// メディアはファイル名順に "0", "1", ... として保存する
+	names := make([]string, 0, len(collection.Media))
+	for name := range collection.Media {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	index := make(map[string]string, len(names))
+	for i, name := range names {
+		entry := strconv.Itoa(i)
+		index[entry] = name
+		file, err := archive.Create(entry)
+		if err != nil {
+			return err
+		}
+		if _, err := file.Write(collection.Media[name]); err != nil {
+			return err
+		}
+	}
+	mediaJSON, err := json.Marshal(index)
+	if err != nil {
+		return err
+	}
+	file, err = archive.Create("media")
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(mediaJSON); err != nil {
+		return err
+	}
+
+	return archive.Close()
+}
+
+// writeCollection はコレクションをSQLiteのデータベースにする
+func writeCollection(c *Collection) ([]byte, error) {
+	now := time.Now()
+	created := c.Created.Unix()
+
+	models := make(map[string]any, len(c.Models))
+	for _, model := range c.Models {
+		models[strconv.FormatInt(model.ID, 10)] = modelJSON(model, now)
+	}
+	decks := map[string]any{strconv.Itoa(defaultDeckID): deckJSON(Deck{ID: defaultDeckID, Name: "Default"}, now)}
+	for _, deck := range c.Decks {
+		decks[strconv.FormatInt(deck.ID, 10)] = deckJSON(deck, now)
+	}
+
+	var curModel int64
+	if len(c.Models) > 0 {
+		curModel = c.Models[0].ID
+	}
+	conf := map[string]any{
+		"nextPos": len(c.Notes) + 1, "estTimes": true, "activeDecks": []int{defaultDeckID},
+		"sortType": "noteFld", "timeLim": 0, "sortBackwards": false, "addToCur": true,
+		"curDeck": defaultDeckID, "newSpread": 0, "dueCounts": true, "curModel": curModel,
+		"collapseTime": 1200,
+	}
+	dconf := map[string]any{strconv.Itoa(defaultDeckID): deckConfigJSON(now)}
+
+	colRow, err := jsonValues(conf, models, decks, dconf)
+	if err != nil {
+		return nil, err
+	}
+	tables := []sqliteTable{
+		{name: "col", sql: schemaCol, rows: [][]any{{
+			int64(1), created, now.UnixMilli(), now.UnixMilli(), int64(11), int64(0), int64(0), int64(0),
+			colRow[0], colRow[1], colRow[2], colRow[3], "{}",
+		}}},
+		{name: "notes", sql: schemaNotes},
+		{name: "cards", sql: schemaCards},
+		{name: "revlog", sql: schemaRevlog},
+		{name: "graves", sql: schemaGraves},
+	}
+
+	notes := append([]Note(nil), c.Notes...)
+	sort.Slice(notes, func(i, j int) bool { return notes[i].ID < notes[j].ID })
+	for _, note := range notes {
+		sortField := ""
+		if len(note.Fields) > 0 {
+			sortField = PlainText(note.Fields[0])
+		}
+		tags := ""
+		if len(note.Tags) > 0 {
+			tags = " " + strings.Join(note.Tags, " ") + " "
+		}
+		tables[1].rows = append(tables[1].rows, []any{
+			note.ID, note.GUID, note.ModelID, unixOr(note.Modified, now), int64(-1), tags,
+			strings.Join(note.Fields, fieldSeparator), sortField, fieldChecksum(sortField), int64(0), "",
+		})
+	}
+
+	cards := append([]Card(nil), c.Cards...)
+	sort.Slice(cards, func(i, j int) bool { return cards[i].ID < cards[j].ID })
+	for _, card := range cards {
+		data := "{}"
+		if card.Stability > 0 {
+			encoded, err := json.Marshal(cardData{Stability: card.Stability, Difficulty: card.Difficulty})
+			if err != nil {
+				return nil, err
+			}
+			data = string(encoded)
+		}
+		queue := card.Queue
+		if queue == 0 && card.Type != CardTypeNew {
+			queue = card.Type
+		}
+		tables[2].rows = append(tables[2].rows, []any{
+			card.ID, card.NoteID, card.DeckID, int64(card.Ord), unixOr(card.Modified, now), int64(-1),
+			int64(card.Type), int64(queue), card.Due, int64(card.Interval), int64(card.Factor),
+			int64(card.Reps), int64(card.Lapses), int64(0), int64(0), int64(0), int64(0), data,
+		})
+	}
+
+	reviews := append([]Review(nil), c.Reviews...)
+	sort.Slice(reviews, func(i, j int) bool { return reviews[i].ID < reviews[j].ID })
+	for i, review := range reviews {
+		if i > 0 && review.ID == reviews[i-1].ID {
+			return nil, fmt.Errorf("duplicate review log id %d", review.ID)
+		}
+		tables[3].rows = append(tables[3].rows, []any{
+			review.ID, review.CardID, int64(-1), int64(review.Ease), int64(review.Interval),
+			int64(review.LastInterval), int64(review.Factor), review.Duration.Milliseconds(), int64(review.Type),
+		})
+	}
+
+	return writeSQLite(tables)
+}
+
+// jsonValues は値をJSONの文字列にする
+func jsonValues(values ...any) ([]string, error) {
+	encoded := make([]string, 0, len(values))
+	for _, value := range values {
+		data, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		encoded = append(encoded, string(data))
+	}
+	return encoded, nil
+}
+
+// unixOr は時刻のUNIX秒を返す（ゼロ値の場合は fallback）
+func unixOr(t time.Time, fallback time.Time) int64 {
+	if t.IsZero() {
+		t = fallback
+	}
+	return t.Unix()
+}
+
+// modelJSON はノートタイプをコレクションの models の形式にする
+func modelJSON(model Model, now time.Time) map[string]any {
+	fields := make([]map[string]any, 0, len(model.Fields))
+	for i, name := range model.Fields {
+		fields = append(fields, map[string]any{
+			"name": name, "ord": i, "sticky": false, "rtl": false,
+			"font": "Arial", "size": 20, "media": []string{},
+		})
+	}
+
+	templates := make([]map[string]any, 0, len(model.Templates))
+	// req は各テンプレートのカードを作成するために必要なフィールド（表面で最初に参照するフィールド）
+	req := make([]any, 0, len(model.Templates))
+	for i, template := range model.Templates {
+		templates = append(templates, map[string]any{
+			"name": template.Name, "ord": i, "qfmt": template.Front, "afmt": template.Back,
+			"did": nil, "bqfmt": "", "bafmt": "",
+		})
+		required := []int{}
+		for j, name := range model.Fields {
+			if strings.Contains(template.Front, name+"}}") {
+				required = append(required, j)
+				break
+			}
+		}
+		req = append(req, []any{i, "any", required})
+	}
+
+	return map[string]any{
+		"id": model.ID, "name": model.Name, "type": 0, "mod": now.Unix(), "usn": -1,
+		"sortf": 0, "did": defaultDeckID, "tmpls": templates, "flds": fields, "css": model.CSS,
+		"latexPre":  "\\documentclass[12pt]{article}\n\\special{papersize=3in,5in}\n\\usepackage[utf8]{inputenc}\n\\usepackage{amssymb,amsmath}\n\\pagestyle{empty}\n\\setlength{\\parindent}{0in}\n\\begin{document}\n",
+		"latexPost": "\\end{document}", "latexsvg": false, "req": req, "tags": []string{}, "vers": []int{},
+	}
+}
+
+// deckJSON はデッキをコレクションの decks の形式にする
+func deckJSON(deck Deck, now time.Time) map[string]any {
+	return map[string]any{
+		"id": deck.ID, "name": deck.Name, "desc": "", "mod": now.Unix(), "usn": -1,
+		"collapsed": false, "browserCollapsed": false, "dyn": 0, "conf": defaultDeckID,
+		"extendNew": 0, "extendRev": 0,
+		"newToday": []int{0, 0}, "revToday": []int{0, 0}, "lrnToday": []int{0, 0}, "timeToday": []int{0, 0},
+	}
+}
+
+// deckConfigJSON はAnkiの既定のデッキのオプション
+func deckConfigJSON(now time.Time) map[string]any {
+	return map[string]any{
+		"id": defaultDeckID, "name": "Default", "mod": now.Unix(), "usn": -1,
+		"maxTaken": 60, "autoplay": true, "timer": 0, "replayq": true, "dyn": false,
+		"new": map[string]any{
+			"delays": []float64{1, 10}, "ints": []int{1, 4, 0}, "initialFactor": 2500,
+			"order": 1, "perDay": 20, "bury": false,
+		},
+		"rev": map[string]any{
+			"perDay": 200, "ease4": 1.3, "ivlFct": 1, "maxIvl": 36500, "hardFactor": 1.2, "bury": false,
+		},
+		"lapse": map[string]any{
+			"delays": []float64{10}, "mult": 0, "minInt": 1, "leechFails": 8, "leechAction": 1,
+		},
+	}
+}
+
+// fieldChecksum はノートの重複の判定に使う先頭のフィールドのチェックサム（SHA-1の先頭8桁）
+func fieldChecksum(field string) int64 {
+	sum := sha1.Sum([]byte(field))
+	return int64(binary.BigEndian.Uint32(sum[:4]))
+}
@@ -0,0 +1,290 @@
+package anki
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func readPackageBytes(t *testing.T, data []byte) (*Collection, error) {
+	t.Helper()
+	return ReadPackage(bytes.NewReader(data), int64(len(data)))
+}
+
+// testdata/basic.apkg はSQLiteで作成したパッケージ（ページサイズ1024、300ノート、長いフィールド、インデックス付き）
+func TestReadPackage_Fixture(t *testing.T) {
+	data, err := os.ReadFile("testdata/basic.apkg")
+	require.NoError(t, err)
+
+	collection, err := readPackageBytes(t, data)
+	require.NoError(t, err)
+
+	assert.Equal(t, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), collection.Created.UTC())
+	require.Len(t, collection.Models, 1)
+	model := collection.Models[0]
+	assert.Equal(t, "Basic (and reversed card)", model.Name)
+	assert.Equal(t, []string{"Front", "Back"}, model.Fields, "fields are ordered by ord")
+	require.Len(t, model.Templates, 2)
+	assert.Equal(t, "{{Front}}", model.Templates[0].Front)
+
+	require.Len(t, collection.Decks, 2)
+	assert.Equal(t, "Русский::Глаголы", collection.Decks[1].Name)
+
+	require.Len(t, collection.Notes, 300)
+	require.Len(t, collection.Cards, 600)
+	note := collection.Notes[0]
+	assert.Equal(t, []string{"verbs", "lesson1"}, note.Tags)
+	front, ok := model.Field(note, "front")
+	require.True(t, ok)
+	assert.Equal(t, "говорить", PlainText(front))
+	assert.Equal(t, []string{"govorit.mp3"}, SoundFiles(front))
+	assert.Equal(t, strings.Repeat("long ", 1000), collection.Notes[1].Fields[1], "overflow pages are followed")
+	assert.Equal(t, []byte("ID3fake-mp3"), collection.Media["govorit.mp3"])
+
+	card := collection.Cards[0]
+	assert.Equal(t, CardTypeReview, card.Type)
+	assert.Equal(t, 12, card.Interval)
+	assert.Equal(t, 2500, card.Factor)
+	assert.InDelta(t, 14.2, card.Stability, 1e-9)
+	assert.InDelta(t, 5.3, card.Difficulty, 1e-9)
+	assert.Equal(t, time.Date(2026, 2, 5, 0, 0, 0, 0, time.UTC), collection.DueDate(card).UTC())
+	assert.Equal(t, CardTypeNew, collection.Cards[1].Type)
+	assert.True(t, collection.DueDate(collection.Cards[1]).IsZero())
+
+	require.Len(t, collection.Reviews, 3)
+	assert.Equal(t, 3, collection.Reviews[0].Ease)
+	assert.Equal(t, 8*time.Second, collection.Reviews[0].Duration)
+	assert.Equal(t, ReviewTypeManual, collection.Reviews[2].Type)
+}
+
+func TestWritePackage_RoundTrip(t *testing.T) {
+	modelID := int64(1700000000000)
+	collection := &Collection{
+		Created: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+		Models: []Model{{
+			ID: modelID, Name: "HaiLanGo", Fields: []string{"Text", "Translation"},
+			Templates: []Template{
+				{Name: "Recognition", Front: "{{Text}}", Back: "{{FrontSide}}<hr id=answer>{{Translation}}"},
+				{Name: "Production", Front: "{{Translation}}", Back: "{{FrontSide}}<hr id=answer>{{Text}}"},
+			},
+		}},
+		Decks: []Deck{{ID: 1700000000001, Name: "HaiLanGo"}},
+		Media: map[string][]byte{"a.mp3": []byte("audio-a"), "b.mp3": []byte("audio-b")},
+	}
+	// 複数ページ（内部ページ）とオーバーフローページが必要になる件数・長さにする
+	for i := 0; i < 2000; i++ {
+		translation := "hello"
+		if i%400 == 0 {
+			translation = strings.Repeat("とても長い訳文", 2000)
+		}
+		noteID := int64(1000 + i)
+		collection.Notes = append(collection.Notes, Note{
+			ID: noteID, GUID: "guid", ModelID: modelID,
+			Fields: []string{"Здравствуйте [sound:a.mp3]", translation}, Tags: []string{"greeting"},
+		})
+		collection.Cards = append(collection.Cards,
+			Card{ID: 2 * noteID, NoteID: noteID, DeckID: 1700000000001, Type: CardTypeReview, Due: 30, Interval: 7, Factor: 2300, Reps: 4, Stability: 6.5, Difficulty: 4.2},
+			Card{ID: 2*noteID + 1, NoteID: noteID, DeckID: 1700000000001, Ord: 1, Due: int64(i)},
+		)
+	}
+	collection.Reviews = []Review{
+		{ID: 1767300000000, CardID: 2000, Ease: 3, Interval: 7, LastInterval: 3, Factor: 2300, Duration: 5 * time.Second, Type: ReviewTypeReview},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, WritePackage(&buf, collection))
+
+	got, err := readPackageBytes(t, buf.Bytes())
+	require.NoError(t, err)
+
+	assert.Equal(t, collection.Created.Unix(), got.Created.Unix())
+	require.Len(t, got.Models, 1)
+	assert.Equal(t, collection.Models[0].Fields, got.Models[0].Fields)
+	assert.Equal(t, collection.Models[0].Templates, got.Models[0].Templates)
+	assert.Len(t, got.Decks, 2, "the default deck is always written")
+	require.Len(t, got.Notes, 2000)
+	require.Len(t, got.Cards, 4000)
+	assert.Equal(t, collection.Notes[400].Fields, got.Notes[400].Fields)
+	assert.Equal(t, []string{"greeting"}, got.Notes[1999].Tags)
+	assert.Equal(t, collection.Cards[0].Interval, got.Cards[0].Interval)
+	assert.Equal(t, CardTypeReview, got.Cards[0].Queue)
+	assert.InDelta(t, 6.5, got.Cards[0].Stability, 1e-9)
+	assert.Equal(t, int64(1999), got.Cards[3999].Due)
+	assert.Equal(t, collection.Reviews, got.Reviews)
+	assert.Equal(t, collection.Media, got.Media)
+}
+
+func TestWritePackage_DuplicateReviewID(t *testing.T) {
+	collection := &Collection{Reviews: []Review{{ID: 1, CardID: 1}, {ID: 1, CardID: 2}}}
+
+	err := WritePackage(&bytes.Buffer{}, collection)
+	assert.Error(t, err)
+}
+
+// writeLatestPackage は新しい形式（collection.anki21b、スキーマ18）のパッケージを作成する
+func writeLatestPackage(t *testing.T, meta []byte) []byte {
+	t.Helper()
+
+	templateConfig := func(front, back string) []byte {
+		config := protowire.AppendTag(nil, 1, protowire.BytesType)
+		config = protowire.AppendString(config, front)
+		config = protowire.AppendTag(config, 2, protowire.BytesType)
+		return protowire.AppendString(config, back)
+	}
+	notetypeConfig := protowire.AppendTag(nil, 3, protowire.BytesType)
+	notetypeConfig = protowire.AppendString(notetypeConfig, ".card {}")
+
+	database, err := writeSQLite([]sqliteTable{
+		{name: "col", sql: schemaCol, rows: [][]any{{
+			int64(1), time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC).Unix(), int64(0), int64(0), int64(18), int64(0), int64(0), int64(0), "", "", "", "", "",
+		}}},
+		{name: "notetypes", sql: "CREATE TABLE notetypes (id integer NOT NULL PRIMARY KEY, name text NOT NULL COLLATE unicase, mtime_secs integer NOT NULL, usn integer NOT NULL, config blob NOT NULL)", rows: [][]any{
+			{int64(10), "Basic", int64(0), int64(0), notetypeConfig},
+		}},
+		{name: "fields", sql: "CREATE TABLE fields (ntid integer NOT NULL, ord integer NOT NULL, name text NOT NULL COLLATE unicase, config blob NOT NULL, PRIMARY KEY (ntid, ord)) WITHOUT ROWID", rows: [][]any{
+			{int64(10), int64(1), "Back", []byte{}},
+			{int64(10), int64(0), "Front", []byte{}},
+		}},
+		{name: "templates", sql: "CREATE TABLE templates (ntid integer NOT NULL, ord integer NOT NULL, name text NOT NULL COLLATE unicase, mtime_secs integer NOT NULL, usn integer NOT NULL, config blob NOT NULL, PRIMARY KEY (ntid, ord)) WITHOUT ROWID", rows: [][]any{
+			{int64(10), int64(0), "Card 1", int64(0), int64(0), templateConfig("{{Front}}", "{{Back}}")},
+		}},
+		{name: "decks", sql: "CREATE TABLE decks (id integer PRIMARY KEY NOT NULL, name text NOT NULL COLLATE unicase, mtime_secs integer NOT NULL, usn integer NOT NULL, common blob NOT NULL, kind blob NOT NULL)", rows: [][]any{
+			{int64(1), "Default", int64(0), int64(0), []byte{}, []byte{}},
+			{int64(20), "Русский\x1fГлаголы", int64(0), int64(0), []byte{}, []byte{}},
+		}},
+		{name: "notes", sql: schemaNotes, rows: [][]any{
+			{int64(100), "guid", int64(10), int64(0), int64(0), " verbs ", "говорить [sound:govorit.mp3]\x1fto speak", "говорить", int64(0), int64(0), ""},
+		}},
+		{name: "cards", sql: schemaCards, rows: [][]any{
+			{int64(200), int64(100), int64(20), int64(0), int64(0), int64(0), int64(2), int64(2), int64(10), int64(12), int64(2500), int64(3), int64(0), int64(0), int64(0), int64(0), int64(0), `{"s":14.2,"d":5.3}`},
+		}},
+		{name: "revlog", sql: schemaRevlog},
+	})
+	require.NoError(t, err)
+
+	encoder, err := zstd.NewWriter(nil)
+	require.NoError(t, err)
+	defer encoder.Close()
+
+	entry := protowire.AppendTag(nil, 1, protowire.BytesType)
+	entry = protowire.AppendString(entry, "govorit.mp3")
+	entry = protowire.AppendTag(entry, 2, protowire.VarintType)
+	entry = protowire.AppendVarint(entry, uint64(len("ID3fake-mp3")))
+	media := protowire.AppendTag(nil, 1, protowire.BytesType)
+	media = protowire.AppendBytes(media, entry)
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	files := []struct {
+		name string
+		data []byte
+	}{
+		{"meta", meta},
+		{"collection.anki2", []byte("dummy")},
+		{"collection.anki21b", encoder.EncodeAll(database, nil)},
+		{"media", encoder.EncodeAll(media, nil)},
+		{"0", encoder.EncodeAll([]byte("ID3fake-mp3"), nil)},
+	}
+	for _, f := range files {
+		file, err := archive.Create(f.name)
+		require.NoError(t, err)
+		_, err = file.Write(f.data)
+		require.NoError(t, err)
+	}
+	require.NoError(t, archive.Close())
+	return buf.Bytes()
+}
+
+func packageMeta(version uint64) []byte {
+	meta := protowire.AppendTag(nil, 1, protowire.VarintType)
+	return protowire.AppendVarint(meta, version)
+}
+
+func TestReadPackage_Latest(t *testing.T) {
+	collection, err := readPackageBytes(t, writeLatestPackage(t, packageMeta(packageVersionLatest)))
+	require.NoError(t, err)
+
+	assert.Equal(t, time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC), collection.Created.UTC())
+	require.Len(t, collection.Models, 1)
+	model := collection.Models[0]
+	assert.Equal(t, "Basic", model.Name)
+	assert.Equal(t, ".card {}", model.CSS)
+	assert.Equal(t, []string{"Front", "Back"}, model.Fields, "fields are ordered by ord")
+	assert.Equal(t, []Template{{Name: "Card 1", Front: "{{Front}}", Back: "{{Back}}"}}, model.Templates)
+
+	require.Len(t, collection.Decks, 2)
+	assert.Equal(t, "Русский::Глаголы", collection.Decks[1].Name)
+
+	require.Len(t, collection.Notes, 1)
+	assert.Equal(t, []string{"говорить [sound:govorit.mp3]", "to speak"}, collection.Notes[0].Fields)
+	require.Len(t, collection.Cards, 1)
+	assert.InDelta(t, 14.2, collection.Cards[0].Stability, 1e-9)
+	assert.Equal(t, map[string][]byte{"govorit.mp3": []byte("ID3fake-mp3")}, collection.Media)
+}
+
+func TestReadPackage_UnsupportedFormat(t *testing.T) {
+	_, err := readPackageBytes(t, writeLatestPackage(t, packageMeta(packageVersionLatest+1)))
+	assert.ErrorIs(t, err, ErrUnsupportedFormat)
+}
+
+func TestParseMediaEntries_LegacyZipFilename(t *testing.T) {
+	entry := protowire.AppendTag(nil, 1, protowire.BytesType)
+	entry = protowire.AppendString(entry, "a.mp3")
+	entry = protowire.AppendTag(entry, 255, protowire.VarintType)
+	entry = protowire.AppendVarint(entry, 7)
+	var media []byte
+	for _, e := range [][]byte{entry, protowire.AppendString(protowire.AppendTag(nil, 1, protowire.BytesType), "b.mp3")} {
+		media = protowire.AppendTag(media, 1, protowire.BytesType)
+		media = protowire.AppendBytes(media, e)
+	}
+
+	names, err := parseMediaEntries(media)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"7": "a.mp3", "1": "b.mp3"}, names)
+
+	_, err = parseMediaEntries([]byte{0x0a, 0x05})
+	assert.ErrorIs(t, err, ErrInvalidPackage)
+}
+
+func TestReadPackage_Invalid(t *testing.T) {
+	_, err := readPackageBytes(t, []byte("not a zip"))
+	assert.ErrorIs(t, err, ErrInvalidPackage)
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	file, err := archive.Create("collection.anki2")
+	require.NoError(t, err)
+	_, err = file.Write([]byte("not a database"))
+	require.NoError(t, err)
+	require.NoError(t, archive.Close())
+
+	_, err = readPackageBytes(t, buf.Bytes())
+	assert.ErrorIs(t, err, ErrInvalidDatabase)
+}
+
+func TestPlainText(t *testing.T) {
+	tests := []struct {
+		name  string
+		field string
+		want  string
+	}{
+		{"plain", "hello", "hello"},
+		{"tags and entities", "<b>Tom</b> &amp; Jerry&nbsp;", "Tom & Jerry"},
+		{"line breaks", "to speak<br>to talk<div>to say</div>", "to speak\nto talk\nto say"},
+		{"sound", "[sound:hello.mp3]hello", "hello"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, PlainText(tt.field))
+		})
+	}
+}
@@ -0,0 +1,212 @@
+package anki
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Anki 2.1.50 以降の新しい形式のパッケージ（collection.anki21b）
+// - collection.anki21b と各メディアファイルはzstd圧縮
+// - media はファイルの一覧（protobuf の MediaEntries）をzstd圧縮したもの
+// - meta はパッケージの形式のバージョン（protobuf の PackageMetadata、圧縮なし）
+// - コレクションはスキーマ18で、ノートタイプとデッキは notetypes・fields・templates・decks テーブル
+// https://github.com/ankitects/anki/blob/main/proto/anki/import_export.proto
+
+// packageVersionLatest は新しい形式のパッケージのバージョン（PackageMetadata.Version.LATEST）
+const packageVersionLatest = 3
+
+// deckNameSeparator はスキーマ18のデッキ名の階層の区切り文字（"::" の代わりに保存される）
+const deckNameSeparator = "\x1f"
+
+// zstdDecoder はパッケージのファイルの展開に共有するデコーダー（DecodeAll は並行に呼び出せる）
+var zstdDecoder, _ = zstd.NewReader(nil)
+
+// decompress はzstd圧縮されたファイルを展開する
+func decompress(name string, data []byte) ([]byte, error) {
+	decoded, err := zstdDecoder.DecodeAll(data, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrInvalidPackage, name, err)
+	}
+	return decoded, nil
+}
+
+// protoField はprotobufのメッセージのフィールド（varint または長さ付きの値）
+type protoField struct {
+	number protowire.Number
+	varint uint64
+	bytes  []byte
+}
+
+// parseProto はprotobufのメッセージをフィールドに分解する（その他の型のフィールドは読み飛ばす）
+func parseProto(data []byte) ([]protoField, error) {
+	var fields []protoField
+	for len(data) > 0 {
+		number, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return nil, protowire.ParseError(n)
+		}
+		data = data[n:]
+
+		field := protoField{number: number}
+		switch typ {
+		case protowire.VarintType:
+			field.varint, n = protowire.ConsumeVarint(data)
+		case protowire.BytesType:
+			field.bytes, n = protowire.ConsumeBytes(data)
+		default:
+			n = protowire.ConsumeFieldValue(number, typ, data)
+		}
+		if n < 0 {
+			return nil, protowire.ParseError(n)
+		}
+		data = data[n:]
+		if typ == protowire.VarintType || typ == protowire.BytesType {
+			fields = append(fields, field)
+		}
+	}
+	return fields, nil
+}
+
+// parsePackageVersion は meta（PackageMetadata）からパッケージの形式のバージョンを取り出す
+func parsePackageVersion(data []byte) (uint64, error) {
+	fields, err := parseProto(data)
+	if err != nil {
+		return 0, fmt.Errorf("%w: meta: %v", ErrInvalidPackage, err)
+	}
+	var version uint64
+	for _, field := range fields {
+		if field.number == 1 {
+			version = field.varint
+		}
+	}
+	return version, nil
+}
+
+// parseMediaEntries は media（展開済みの MediaEntries）から ZIP 内のファイル名 → 元のファイル名の対応を作る
+// ZIP 内のファイル名は一覧の位置（"0", "1", ...）、旧形式から変換した一覧では legacy_zip_filename
+func parseMediaEntries(data []byte) (map[string]string, error) {
+	entries, err := parseProto(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: media: %v", ErrInvalidPackage, err)
+	}
+
+	names := make(map[string]string)
+	index := 0
+	for _, entry := range entries {
+		if entry.number != 1 {
+			continue
+		}
+		fields, err := parseProto(entry.bytes)
+		if err != nil {
+			return nil, fmt.Errorf("%w: media: %v", ErrInvalidPackage, err)
+		}
+		zipName := strconv.Itoa(index)
+		var name string
+		for _, field := range fields {
+			switch field.number {
+			case 1:
+				name = string(field.bytes)
+			case 255:
+				zipName = strconv.FormatUint(field.varint, 10)
+			}
+		}
+		names[zipName] = name
+		index++
+	}
+	return names, nil
+}
+
+// readNotetypes はスキーマ18のコレクションのノートタイプ（notetypes・fields・templates テーブル）を読み取る
+func readNotetypes(db *sqliteDB) ([]Model, error) {
+	notetypes, err := db.readTable("notetypes")
+	if err != nil {
+		return nil, err
+	}
+	fields, err := db.readTable("fields")
+	if err != nil {
+		return nil, err
+	}
+	templates, err := db.readTable("templates")
+	if err != nil {
+		return nil, err
+	}
+
+	// フィールドとテンプレートはノートタイプごとに ord 順に並べる
+	sortByOrd := func(rows []map[string]any) {
+		sort.SliceStable(rows, func(i, j int) bool {
+			if a, b := asInt(rows[i]["ntid"]), asInt(rows[j]["ntid"]); a != b {
+				return a < b
+			}
+			return asInt(rows[i]["ord"]) < asInt(rows[j]["ord"])
+		})
+	}
+	sortByOrd(fields)
+	sortByOrd(templates)
+
+	var models []Model
+	for _, row := range notetypes {
+		model := Model{ID: asInt(row["id"]), Name: asString(row["name"])}
+
+		// Notetype.Config の css は3番目のフィールド
+		config, err := parseProto([]byte(asString(row["config"])))
+		if err != nil {
+			return nil, fmt.Errorf("%w: notetype %d: %v", ErrInvalidPackage, model.ID, err)
+		}
+		for _, field := range config {
+			if field.number == 3 {
+				model.CSS = string(field.bytes)
+			}
+		}
+
+		for _, field := range fields {
+			if asInt(field["ntid"]) == model.ID {
+				model.Fields = append(model.Fields, asString(field["name"]))
+			}
+		}
+
+		// Notetype.Template.Config の q_format・a_format は1・2番目のフィールド
+		for _, tmpl := range templates {
+			if asInt(tmpl["ntid"]) != model.ID {
+				continue
+			}
+			config, err := parseProto([]byte(asString(tmpl["config"])))
+			if err != nil {
+				return nil, fmt.Errorf("%w: template of notetype %d: %v", ErrInvalidPackage, model.ID, err)
+			}
+			template := Template{Name: asString(tmpl["name"])}
+			for _, field := range config {
+				switch field.number {
+				case 1:
+					template.Front = string(field.bytes)
+				case 2:
+					template.Back = string(field.bytes)
+				}
+			}
+			model.Templates = append(model.Templates, template)
+		}
+
+		models = append(models, model)
+	}
+	return models, nil
+}
+
+// readDeckTable はスキーマ18のコレクションのデッキ（decks テーブル）を読み取る
+func readDeckTable(db *sqliteDB) ([]Deck, error) {
+	rows, err := db.readTable("decks")
+	if err != nil {
+		return nil, err
+	}
+	decks := make([]Deck, 0, len(rows))
+	for _, row := range rows {
+		decks = append(decks, Deck{
+			ID:   asInt(row["id"]),
+			Name: strings.ReplaceAll(asString(row["name"]), deckNameSeparator, "::"),
+		})
+	}
+	return decks, nil
+}
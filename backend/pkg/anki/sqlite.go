@@ -0,0 +1,244 @@
+package anki
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"modernc.org/sqlite"
+)
+
+// Ankiのコレクション（collection.anki2 など）はSQLiteのデータベースファイル
+// cgo に依存しない純Goのドライバー（modernc.org/sqlite）で読み書きする
+
+const sqliteMagic = "SQLite format 3\x00"
+
+// ErrInvalidDatabase はSQLiteのデータベースとして読み取れない場合のエラー
+var ErrInvalidDatabase = errors.New("invalid sqlite database")
+
+func init() {
+	// スキーマ15以降のコレクションは名前の列にAnki独自の照合順序 unicase（大文字・小文字を区別しない）を使う
+	sqlite.MustRegisterCollationUtf8("unicase", func(left, right string) int {
+		return strings.Compare(strings.ToLower(left), strings.ToLower(right))
+	})
+}
+
+// sqliteDB は読み取るデータベース（一時ファイル）
+type sqliteDB struct {
+	db   *sql.DB
+	path string
+}
+
+// openSQLite はデータベースファイルの内容を一時ファイルに書き出し、読み取り専用で開く
+func openSQLite(data []byte) (*sqliteDB, error) {
+	if len(data) < len(sqliteMagic) || string(data[:len(sqliteMagic)]) != sqliteMagic {
+		return nil, ErrInvalidDatabase
+	}
+
+	file, err := os.CreateTemp("", "anki-*.sqlite")
+	if err != nil {
+		return nil, err
+	}
+	s := &sqliteDB{path: file.Name()}
+	// WALモードのファイルは -wal・-shm ファイルなしでは開けないため、ロールバックジャーナルの形式として書き出す
+	if len(data) > 19 && data[18] == 2 && data[19] == 2 {
+		_, err = file.Write(data[:18])
+		if err == nil {
+			_, err = file.Write([]byte{1, 1})
+		}
+		if err == nil {
+			_, err = file.Write(data[20:])
+		}
+	} else {
+		_, err = file.Write(data)
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(s.path)
+		return nil, err
+	}
+
+	// immutable: 読み取り中に変更されないファイルとして、ロックとジャーナルを使わずに開く
+	s.db, err = sql.Open("sqlite", (&url.URL{Scheme: "file", Path: s.path, RawQuery: "mode=ro&immutable=1"}).String())
+	if err == nil {
+		// 壊れたファイルは最初にスキーマを読み取るときにエラーになる
+		_, err = s.tables()
+	}
+	if err != nil {
+		s.Close()
+		return nil, fmt.Errorf("%w: %v", ErrInvalidDatabase, err)
+	}
+	return s, nil
+}
+
+// Close はデータベースを閉じて一時ファイルを削除する
+func (s *sqliteDB) Close() error {
+	var err error
+	if s.db != nil {
+		err = s.db.Close()
+	}
+	os.Remove(s.path)
+	return err
+}
+
+// tables はテーブルの名前を返す
+func (s *sqliteDB) tables() (map[string]bool, error) {
+	rows, err := s.db.Query(`SELECT name FROM sqlite_master WHERE type = 'table'`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	names := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names[name] = true
+	}
+	return names, rows.Err()
+}
+
+// hasTable はテーブルがあるかを返す
+func (s *sqliteDB) hasTable(name string) bool {
+	tables, err := s.tables()
+	return err == nil && tables[name]
+}
+
+// readTable はテーブルの全行を列名 → 値の形で読み取る（rowid順）
+func (s *sqliteDB) readTable(name string) ([]map[string]any, error) {
+	rows, err := s.db.Query(fmt.Sprintf(`SELECT * FROM "%s"`, strings.ReplaceAll(name, `"`, `""`)))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrInvalidDatabase, name, err)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	var result []map[string]any
+	for rows.Next() {
+		values := make([]any, len(columns))
+		pointers := make([]any, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrInvalidDatabase, name, err)
+		}
+		row := make(map[string]any, len(columns))
+		for i, column := range columns {
+			row[column] = values[i]
+		}
+		result = append(result, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrInvalidDatabase, name, err)
+	}
+	return result, nil
+}
+
+// sqliteTable は書き出すテーブル
+type sqliteTable struct {
+	name string
+	sql  string
+	rows [][]any
+}
+
+// serializer はドライバーの接続のデータベースファイルの内容を返す
+type serializer interface {
+	Serialize() ([]byte, error)
+}
+
+// writeSQLite はテーブルをメモリ上のデータベースに書き込み、データベースファイルの内容にする
+func writeSQLite(tables []sqliteTable) ([]byte, error) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	// メモリ上のデータベースは接続ごとに別になるため、1つの接続だけを使う
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	for _, table := range tables {
+		if _, err := tx.ExecContext(ctx, table.sql); err != nil {
+			return nil, fmt.Errorf("create table %s: %w", table.name, err)
+		}
+		if len(table.rows) == 0 {
+			continue
+		}
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(table.rows[0])), ", ")
+		stmt, err := tx.PrepareContext(ctx, fmt.Sprintf("INSERT INTO %s VALUES (%s)", table.name, placeholders))
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range table.rows {
+			if _, err := stmt.ExecContext(ctx, row...); err != nil {
+				stmt.Close()
+				return nil, fmt.Errorf("insert into %s: %w", table.name, err)
+			}
+		}
+		stmt.Close()
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	var data []byte
+	err = conn.Raw(func(driverConn any) error {
+		var err error
+		data, err = driverConn.(serializer).Serialize()
+		return err
+	})
+	return data, err
+}
+
+// asInt は値を整数として返す（整数でない場合は0）
+func asInt(value any) int64 {
+	switch v := value.(type) {
+	case int64:
+		return v
+	case int:
+		return int64(v)
+	case float64:
+		return int64(v)
+	case string:
+		var n int64
+		fmt.Sscan(v, &n)
+		return n
+	}
+	return 0
+}
+
+// asString は値を文字列として返す
+func asString(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case nil:
+		return ""
+	}
+	return fmt.Sprint(value)
+}
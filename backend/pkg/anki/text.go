@@ -0,0 +1,204 @@
+package anki
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidTextDeck はテキスト形式として読み取れない場合のエラー
+var ErrInvalidTextDeck = errors.New("invalid text deck")
+
+// TextDeck はAnkiのテキスト形式（タブ・カンマ区切り）のノート
+type TextDeck struct {
+	// Separator はフィールドの区切り文字（既定はタブ）
+	Separator rune
+	// HTML はフィールドの値がHTMLの場合は true
+	HTML bool
+	// Columns は列の名前（ヘッダーに #columns がない場合は空）
+	Columns []string
+	// TagsColumn はタグの列の位置（1始まり、タグの列がない場合は0）
+	TagsColumn int
+	// Tags はすべてのノートに付けるタグ
+	Tags []string
+	Rows [][]string
+}
+
+// separatorNames はヘッダーの #separator で使える区切り文字の名前
+var separatorNames = map[string]rune{
+	"tab": '\t', "comma": ',', "semicolon": ';', "space": ' ', "pipe": '|', "colon": ':',
+}
+
+// ReadText はAnkiのテキスト形式のノートを読み取る
+// 区切り文字がヘッダーにない場合は先頭の行から推定する（タブ、セミコロン、カンマの順）
+func ReadText(r io.Reader) (*TextDeck, error) {
+	reader := bufio.NewReader(r)
+	deck := &TextDeck{}
+
+	var body bytes.Buffer
+	first, inHeader := true, true
+	for {
+		line, err := reader.ReadString('\n')
+		if first {
+			line = strings.TrimPrefix(line, "\ufeff")
+			first = false
+		}
+		if line != "" {
+			if inHeader && strings.HasPrefix(line, "#") {
+				if err := deck.parseHeader(strings.TrimRight(line, "\r\n")); err != nil {
+					return nil, err
+				}
+			} else {
+				inHeader = false
+				body.WriteString(line)
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if deck.Separator == 0 {
+		deck.Separator = guessSeparator(body.String())
+	}
+
+	csvReader := csv.NewReader(&body)
+	csvReader.Comma = deck.Separator
+	csvReader.LazyQuotes = true
+	csvReader.FieldsPerRecord = -1
+	// Ankiは # で始まる行をヘッダー以外ではコメントとして扱わない
+	for {
+		record, err := csvReader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidTextDeck, err)
+		}
+		if len(record) == 1 && strings.TrimSpace(record[0]) == "" {
+			continue
+		}
+		deck.Rows = append(deck.Rows, record)
+	}
+
+	return deck, nil
+}
+
+// parseHeader はヘッダーの行（#key:value）を読み取る
+func (d *TextDeck) parseHeader(line string) error {
+	key, value, found := strings.Cut(strings.TrimPrefix(line, "#"), ":")
+	if !found {
+		return nil
+	}
+	key = strings.ToLower(strings.TrimSpace(key))
+	value = strings.TrimSpace(value)
+
+	switch key {
+	case "separator":
+		if sep, ok := separatorNames[strings.ToLower(value)]; ok {
+			d.Separator = sep
+		} else if runes := []rune(value); len(runes) == 1 {
+			d.Separator = runes[0]
+		} else {
+			return fmt.Errorf("%w: unknown separator %q", ErrInvalidTextDeck, value)
+		}
+	case "html":
+		d.HTML = strings.EqualFold(value, "true")
+	case "tags":
+		d.Tags = strings.Fields(value)
+	case "columns":
+		// 区切り文字は #columns より前に指定されている必要がある
+		sep := d.Separator
+		if sep == 0 {
+			sep = guessSeparator(value)
+		}
+		for _, column := range strings.Split(value, string(sep)) {
+			d.Columns = append(d.Columns, strings.TrimSpace(column))
+		}
+	case "tags column":
+		column, err := strconv.Atoi(value)
+		if err != nil || column < 0 {
+			return fmt.Errorf("%w: tags column %q", ErrInvalidTextDeck, value)
+		}
+		d.TagsColumn = column
+	}
+	return nil
+}
+
+// guessSeparator は先頭の行から区切り文字を推定する
+func guessSeparator(text string) rune {
+	first, _, _ := strings.Cut(text, "\n")
+	for _, sep := range []rune{'\t', ';', ','} {
+		if strings.ContainsRune(first, sep) {
+			return sep
+		}
+	}
+	return '\t'
+}
+
+// Column は行の列の値を名前（大文字・小文字は区別しない）で返す
+func (d *TextDeck) Column(row []string, name string) (string, bool) {
+	for i, column := range d.Columns {
+		if strings.EqualFold(column, name) && i < len(row) {
+			return row[i], true
+		}
+	}
+	return "", false
+}
+
+// RowTags は行のタグ（タグの列とすべてのノートのタグ）を返す
+func (d *TextDeck) RowTags(row []string) []string {
+	tags := append([]string(nil), d.Tags...)
+	if d.TagsColumn > 0 && d.TagsColumn <= len(row) {
+		tags = append(tags, strings.Fields(row[d.TagsColumn-1])...)
+	}
+	return tags
+}
+
+// WriteText はノートをAnkiのテキスト形式で書き出す
+func WriteText(w io.Writer, deck *TextDeck) error {
+	sep := deck.Separator
+	if sep == 0 {
+		sep = '\t'
+	}
+
+	separatorName := string(sep)
+	for name, r := range separatorNames {
+		if r == sep {
+			separatorName = name
+		}
+	}
+	headers := []string{"#separator:" + separatorName, "#html:" + strconv.FormatBool(deck.HTML)}
+	if len(deck.Tags) > 0 {
+		headers = append(headers, "#tags:"+strings.Join(deck.Tags, " "))
+	}
+	if len(deck.Columns) > 0 {
+		headers = append(headers, "#columns:"+strings.Join(deck.Columns, string(sep)))
+	}
+	if deck.TagsColumn > 0 {
+		headers = append(headers, "#tags column:"+strconv.Itoa(deck.TagsColumn))
+	}
+	for _, header := range headers {
+		if _, err := io.WriteString(w, header+"\n"); err != nil {
+			return err
+		}
+	}
+
+	csvWriter := csv.NewWriter(w)
+	csvWriter.Comma = sep
+	for _, row := range deck.Rows {
+		if err := csvWriter.Write(row); err != nil {
+			return err
+		}
+	}
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
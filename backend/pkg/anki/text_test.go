@@ -0,0 +1,78 @@
+package anki
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadText_Headers(t *testing.T) {
+	input := "\ufeff#separator:Semicolon\n#html:true\n#tags:imported\n#columns:Front;Back;Tags\n#tags column:3\n" +
+		"привет;\"hello; hi\";greeting basic\n\n" +
+		"спасибо;thank you;\n"
+
+	deck, err := ReadText(strings.NewReader(input))
+	require.NoError(t, err)
+
+	assert.Equal(t, ';', deck.Separator)
+	assert.True(t, deck.HTML)
+	assert.Equal(t, []string{"Front", "Back", "Tags"}, deck.Columns)
+	require.Len(t, deck.Rows, 2)
+
+	back, ok := deck.Column(deck.Rows[0], "back")
+	require.True(t, ok)
+	assert.Equal(t, "hello; hi", back)
+	assert.Equal(t, []string{"imported", "greeting", "basic"}, deck.RowTags(deck.Rows[0]))
+	assert.Equal(t, []string{"imported"}, deck.RowTags(deck.Rows[1]))
+}
+
+func TestReadText_GuessSeparator(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  rune
+	}{
+		{"tab", "a\tb, c\n", '\t'},
+		{"semicolon", "a;b, c\n", ';'},
+		{"comma", "a,b\n", ','},
+		{"single column", "a\n", '\t'},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deck, err := ReadText(strings.NewReader(tt.input))
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, deck.Separator)
+		})
+	}
+}
+
+func TestReadText_UnknownSeparator(t *testing.T) {
+	_, err := ReadText(strings.NewReader("#separator:Unknown\na\tb\n"))
+	assert.ErrorIs(t, err, ErrInvalidTextDeck)
+}
+
+func TestWriteText_RoundTrip(t *testing.T) {
+	deck := &TextDeck{
+		Columns:    []string{"Text", "Translation", "Tags"},
+		TagsColumn: 3,
+		Rows: [][]string{
+			{"Доброе утро", "Good morning", "greeting"},
+			{"Он сказал: \"да\"", "He said \"yes\"\tquickly", ""},
+		},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteText(&buf, deck))
+	assert.True(t, strings.HasPrefix(buf.String(), "#separator:tab\n#html:false\n#columns:Text\tTranslation\tTags\n#tags column:3\n"))
+
+	got, err := ReadText(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, '\t', got.Separator)
+	assert.Equal(t, deck.Columns, got.Columns)
+	assert.Equal(t, deck.TagsColumn, got.TagsColumn)
+	assert.Equal(t, deck.Rows, got.Rows)
+}
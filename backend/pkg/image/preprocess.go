@@ -0,0 +1,596 @@
+package image
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/jpeg" // register the JPEG decoder for image.Decode
+	"image/png"
+	"math"
+	"sort"
+)
+
+// PreprocessOptions controls which pre-processing steps run before OCR
+type PreprocessOptions struct {
+	// AutoRotate applies the EXIF orientation of JPEG photos
+	AutoRotate bool
+	// DetectOrientation rotates the page by 90 degrees when text lines run vertically.
+	// Keep it off for books with vertical writing (e.g. Japanese tategaki).
+	DetectOrientation bool
+	// Deskew straightens pages photographed at a slight angle
+	Deskew bool
+	// MaxSkewAngle is the largest skew (in degrees) that Deskew searches for
+	MaxSkewAngle float64
+	// Crop removes dark borders (table, hands) and empty page margins
+	Crop bool
+	// NormalizeContrast removes uneven lighting (shadows) and stretches the histogram
+	NormalizeContrast bool
+}
+
+// DefaultPreprocessOptions returns the options used for textbook photos
+func DefaultPreprocessOptions() PreprocessOptions {
+	return PreprocessOptions{
+		AutoRotate:        true,
+		DetectOrientation: false,
+		Deskew:            true,
+		MaxSkewAngle:      10,
+		Crop:              true,
+		NormalizeContrast: true,
+	}
+}
+
+// PreprocessResult is the outcome of Preprocess
+type PreprocessResult struct {
+	Image     []byte          // Processed grayscale PNG
+	Rotation  int             // Clockwise rotation applied from EXIF / orientation detection (0, 90, 180, 270)
+	Mirrored  bool            // Whether the EXIF orientation required a mirror flip
+	SkewAngle float64         // Detected skew in degrees (the page was rotated by -SkewAngle)
+	CropBox   image.Rectangle // Region kept by cropping, in the coordinates of the deskewed image
+}
+
+const (
+	// skewSearchSize is the longest side of the thumbnail used for skew and orientation detection
+	skewSearchSize = 800
+	// skewStep is the angle resolution of the skew search in degrees
+	skewStep = 0.25
+	// backgroundSize is the longest side of the thumbnail used to estimate lighting
+	backgroundSize = 64
+	// cropMarginRatio is the margin kept around the detected content
+	cropMarginRatio = 0.02
+)
+
+// Preprocess prepares a page photo for OCR: EXIF / orientation rotation, deskew,
+// border cropping and contrast normalisation. The result is always a grayscale PNG.
+func Preprocess(data []byte, opts PreprocessOptions) (*PreprocessResult, error) {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedFormat, err)
+	}
+
+	gray := toGray(img)
+	result := &PreprocessResult{}
+
+	if opts.AutoRotate {
+		orientation := ExifOrientation(data)
+		gray = applyExifOrientation(gray, orientation)
+		result.Rotation, result.Mirrored = exifRotation(orientation)
+	}
+
+	if opts.DetectOrientation && isTextVertical(gray) {
+		gray = rotate270(gray)
+		result.Rotation = (result.Rotation + 270) % 360
+	}
+
+	if opts.Deskew {
+		maxAngle := opts.MaxSkewAngle
+		if maxAngle <= 0 {
+			maxAngle = DefaultPreprocessOptions().MaxSkewAngle
+		}
+		angle := estimateSkew(gray, maxAngle)
+		if math.Abs(angle) >= skewStep {
+			gray = rotate(gray, angle, borderMean(gray))
+			result.SkewAngle = angle
+		}
+	}
+
+	result.CropBox = gray.Bounds()
+	if opts.Crop {
+		result.CropBox = contentBounds(gray)
+		gray = crop(gray, result.CropBox)
+	}
+
+	if opts.NormalizeContrast {
+		gray = normalizeContrast(gray)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, gray); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrConversionFailed, err)
+	}
+	result.Image = buf.Bytes()
+
+	return result, nil
+}
+
+// toGray converts any image to an 8-bit grayscale image with origin (0, 0)
+func toGray(img image.Image) *image.Gray {
+	bounds := img.Bounds()
+	gray := image.NewGray(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	for y := 0; y < bounds.Dy(); y++ {
+		for x := 0; x < bounds.Dx(); x++ {
+			gray.SetGray(x, y, color.GrayModel.Convert(img.At(bounds.Min.X+x, bounds.Min.Y+y)).(color.Gray))
+		}
+	}
+	return gray
+}
+
+// remap builds a new w x h image where each pixel is copied from src(x, y) = source(x, y)
+func remap(g *image.Gray, w, h int, source func(x, y int) (int, int)) *image.Gray {
+	out := image.NewGray(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			sx, sy := source(x, y)
+			out.Pix[y*out.Stride+x] = g.Pix[sy*g.Stride+sx]
+		}
+	}
+	return out
+}
+
+// rotate90 rotates the image 90 degrees clockwise
+func rotate90(g *image.Gray) *image.Gray {
+	w, h := g.Rect.Dx(), g.Rect.Dy()
+	return remap(g, h, w, func(x, y int) (int, int) { return y, h - 1 - x })
+}
+
+// rotate180 rotates the image by 180 degrees
+func rotate180(g *image.Gray) *image.Gray {
+	w, h := g.Rect.Dx(), g.Rect.Dy()
+	return remap(g, w, h, func(x, y int) (int, int) { return w - 1 - x, h - 1 - y })
+}
+
+// rotate270 rotates the image 270 degrees clockwise (90 degrees counter-clockwise)
+func rotate270(g *image.Gray) *image.Gray {
+	w, h := g.Rect.Dx(), g.Rect.Dy()
+	return remap(g, h, w, func(x, y int) (int, int) { return w - 1 - y, x })
+}
+
+// applyExifOrientation transforms the image so that it is displayed upright
+func applyExifOrientation(g *image.Gray, orientation int) *image.Gray {
+	w, h := g.Rect.Dx(), g.Rect.Dy()
+
+	switch orientation {
+	case 2: // mirrored horizontally
+		return remap(g, w, h, func(x, y int) (int, int) { return w - 1 - x, y })
+	case 3:
+		return rotate180(g)
+	case 4: // mirrored vertically
+		return remap(g, w, h, func(x, y int) (int, int) { return x, h - 1 - y })
+	case 5: // transpose
+		return remap(g, h, w, func(x, y int) (int, int) { return y, x })
+	case 6:
+		return rotate90(g)
+	case 7: // transverse
+		return remap(g, h, w, func(x, y int) (int, int) { return w - 1 - y, h - 1 - x })
+	case 8:
+		return rotate270(g)
+	default:
+		return g
+	}
+}
+
+// exifRotation returns the clockwise rotation and mirroring implied by an EXIF orientation
+func exifRotation(orientation int) (int, bool) {
+	switch orientation {
+	case 2:
+		return 0, true
+	case 3:
+		return 180, false
+	case 4:
+		return 180, true
+	case 5:
+		return 270, true
+	case 6:
+		return 90, false
+	case 7:
+		return 90, true
+	case 8:
+		return 270, false
+	default:
+		return 0, false
+	}
+}
+
+// thumbnail downsamples the image so that its longest side is at most size pixels.
+// It returns the thumbnail and the scale factor (thumbnail / original).
+func thumbnail(g *image.Gray, size int) (*image.Gray, float64) {
+	w, h := g.Rect.Dx(), g.Rect.Dy()
+	longest := max(w, h)
+	if longest <= size {
+		return g, 1
+	}
+
+	scale := float64(size) / float64(longest)
+	tw := max(1, int(float64(w)*scale))
+	th := max(1, int(float64(h)*scale))
+	return remap(g, tw, th, func(x, y int) (int, int) {
+		return min(w-1, int(float64(x)/scale)), min(h-1, int(float64(y)/scale))
+	}), scale
+}
+
+// otsuThreshold computes the threshold that best separates ink from paper
+func otsuThreshold(g *image.Gray) uint8 {
+	var hist [256]int
+	for _, v := range g.Pix {
+		hist[v]++
+	}
+
+	total := len(g.Pix)
+	var sum float64
+	for i, count := range hist {
+		sum += float64(i * count)
+	}
+
+	var sumBackground float64
+	var weightBackground int
+	var best float64
+	threshold := 128
+
+	for i, count := range hist {
+		weightBackground += count
+		if weightBackground == 0 {
+			continue
+		}
+		weightForeground := total - weightBackground
+		if weightForeground == 0 {
+			break
+		}
+
+		sumBackground += float64(i * count)
+		meanBackground := sumBackground / float64(weightBackground)
+		meanForeground := (sum - sumBackground) / float64(weightForeground)
+		between := float64(weightBackground) * float64(weightForeground) * (meanBackground - meanForeground) * (meanBackground - meanForeground)
+		if between > best {
+			best = between
+			threshold = i
+		}
+	}
+
+	return uint8(threshold)
+}
+
+// inkPoints returns the coordinates of dark pixels of a thumbnail
+func inkPoints(g *image.Gray) [][2]int {
+	threshold := otsuThreshold(g)
+	w, h := g.Rect.Dx(), g.Rect.Dy()
+
+	points := make([][2]int, 0, len(g.Pix)/8)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if g.Pix[y*g.Stride+x] <= threshold {
+				points = append(points, [2]int{x, y})
+			}
+		}
+	}
+	return points
+}
+
+// profileScore measures how sharply ink concentrates into lines when projected
+// along the given angle (degrees). Higher is sharper.
+func profileScore(points [][2]int, angle float64, size int) float64 {
+	rad := angle * math.Pi / 180
+	sin, cos := math.Sin(rad), math.Cos(rad)
+
+	bins := make([]int, 3*size)
+	for _, p := range points {
+		r := int(math.Round(float64(p[1])*cos-float64(p[0])*sin)) + size
+		if r >= 0 && r < len(bins) {
+			bins[r]++
+		}
+	}
+
+	var score float64
+	for _, count := range bins {
+		score += float64(count) * float64(count)
+	}
+	return score
+}
+
+// estimateSkew finds the angle (degrees) of the text lines using projection profiles.
+// A positive angle means lines descend to the right.
+func estimateSkew(g *image.Gray, maxAngle float64) float64 {
+	small, _ := thumbnail(g, skewSearchSize)
+	points := inkPoints(small)
+	if len(points) == 0 {
+		return 0
+	}
+	size := max(small.Rect.Dx(), small.Rect.Dy())
+
+	best := 0.0
+	bestScore := profileScore(points, 0, size)
+	for angle := -maxAngle; angle <= maxAngle+1e-9; angle += skewStep {
+		if score := profileScore(points, angle, size); score > bestScore {
+			best = angle
+			bestScore = score
+		}
+	}
+
+	return best
+}
+
+// isTextVertical reports whether ink lines run vertically (the page is on its side)
+func isTextVertical(g *image.Gray) bool {
+	small, _ := thumbnail(g, skewSearchSize)
+	points := inkPoints(small)
+	if len(points) == 0 {
+		return false
+	}
+
+	w, h := small.Rect.Dx(), small.Rect.Dy()
+	rows := make([]int, h)
+	cols := make([]int, w)
+	for _, p := range points {
+		cols[p[0]]++
+		rows[p[1]]++
+	}
+
+	return variance(cols) > 1.5*variance(rows)
+}
+
+// variance returns the variance of the values normalised by their mean
+func variance(values []int) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+
+	var sum float64
+	for _, v := range values {
+		sum += float64(v)
+	}
+	mean := sum / float64(len(values))
+	if mean == 0 {
+		return 0
+	}
+
+	var total float64
+	for _, v := range values {
+		d := float64(v) - mean
+		total += d * d
+	}
+	return total / float64(len(values)) / (mean * mean)
+}
+
+// borderMean returns the mean value of the outermost pixels, used to fill rotated corners
+func borderMean(g *image.Gray) uint8 {
+	w, h := g.Rect.Dx(), g.Rect.Dy()
+	var sum, count int
+	for x := 0; x < w; x++ {
+		sum += int(g.Pix[x]) + int(g.Pix[(h-1)*g.Stride+x])
+		count += 2
+	}
+	for y := 0; y < h; y++ {
+		sum += int(g.Pix[y*g.Stride]) + int(g.Pix[y*g.Stride+w-1])
+		count += 2
+	}
+	return uint8(sum / count)
+}
+
+// rotate rotates the image around its centre by -angle degrees (undoing a skew of angle),
+// keeping its size and filling uncovered corners with fill
+func rotate(g *image.Gray, angle float64, fill uint8) *image.Gray {
+	w, h := g.Rect.Dx(), g.Rect.Dy()
+	rad := angle * math.Pi / 180
+	sin, cos := math.Sin(rad), math.Cos(rad)
+	cx, cy := float64(w-1)/2, float64(h-1)/2
+
+	out := image.NewGray(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			dx, dy := float64(x)-cx, float64(y)-cy
+			sx := cx + dx*cos - dy*sin
+			sy := cy + dx*sin + dy*cos
+			out.Pix[y*out.Stride+x] = bilinear(g, sx, sy, fill)
+		}
+	}
+	return out
+}
+
+// bilinear samples the image at a fractional position
+func bilinear(g *image.Gray, x, y float64, fill uint8) uint8 {
+	w, h := g.Rect.Dx(), g.Rect.Dy()
+	if x < 0 || y < 0 || x > float64(w-1) || y > float64(h-1) {
+		return fill
+	}
+
+	x0, y0 := int(x), int(y)
+	x1, y1 := min(x0+1, w-1), min(y0+1, h-1)
+	fx, fy := x-float64(x0), y-float64(y0)
+
+	top := float64(g.Pix[y0*g.Stride+x0])*(1-fx) + float64(g.Pix[y0*g.Stride+x1])*fx
+	bottom := float64(g.Pix[y1*g.Stride+x0])*(1-fx) + float64(g.Pix[y1*g.Stride+x1])*fx
+	return uint8(math.Round(top*(1-fy) + bottom*fy))
+}
+
+// contentBounds finds the page content: dark borders around the page are removed first,
+// then empty margins are trimmed down to the ink with a small margin
+func contentBounds(g *image.Gray) image.Rectangle {
+	w, h := g.Rect.Dx(), g.Rect.Dy()
+	threshold := otsuThreshold(g)
+
+	brightRatio := func(x0, y0, x1, y1 int) float64 {
+		var bright int
+		for y := y0; y < y1; y++ {
+			for x := x0; x < x1; x++ {
+				if g.Pix[y*g.Stride+x] > threshold {
+					bright++
+				}
+			}
+		}
+		return float64(bright) / float64(max(1, (x1-x0)*(y1-y0)))
+	}
+
+	// Dark borders: at most a quarter of each side
+	top, bottom, left, right := 0, h, 0, w
+	for top < h/4 && brightRatio(0, top, w, top+1) < 0.5 {
+		top++
+	}
+	for bottom > h-h/4 && brightRatio(0, bottom-1, w, bottom) < 0.5 {
+		bottom--
+	}
+	for left < w/4 && brightRatio(left, top, left+1, bottom) < 0.5 {
+		left++
+	}
+	for right > w-w/4 && brightRatio(right-1, top, right, bottom) < 0.5 {
+		right--
+	}
+
+	// Ink bounding box inside the page. Rows or columns with only a couple of dark pixels are noise.
+	minInkX := max(2, (right-left)/200)
+	minInkY := max(2, (bottom-top)/200)
+	inkTop, inkBottom, inkLeft, inkRight := bottom, top, right, left
+	for y := top; y < bottom; y++ {
+		var count int
+		for x := left; x < right; x++ {
+			if g.Pix[y*g.Stride+x] <= threshold {
+				count++
+			}
+		}
+		if count >= minInkX {
+			inkTop = min(inkTop, y)
+			inkBottom = max(inkBottom, y+1)
+		}
+	}
+	for x := left; x < right; x++ {
+		var count int
+		for y := top; y < bottom; y++ {
+			if g.Pix[y*g.Stride+x] <= threshold {
+				count++
+			}
+		}
+		if count >= minInkY {
+			inkLeft = min(inkLeft, x)
+			inkRight = max(inkRight, x+1)
+		}
+	}
+
+	page := image.Rect(left, top, right, bottom)
+	if inkTop >= inkBottom || inkLeft >= inkRight {
+		return page
+	}
+
+	marginX := int(float64(w) * cropMarginRatio)
+	marginY := int(float64(h) * cropMarginRatio)
+	return image.Rect(inkLeft-marginX, inkTop-marginY, inkRight+marginX, inkBottom+marginY).Intersect(page)
+}
+
+// crop copies a region of the image into a new image with origin (0, 0)
+func crop(g *image.Gray, rect image.Rectangle) *image.Gray {
+	rect = rect.Intersect(g.Rect)
+	if rect.Empty() {
+		return g
+	}
+	return remap(g, rect.Dx(), rect.Dy(), func(x, y int) (int, int) { return rect.Min.X + x, rect.Min.Y + y })
+}
+
+// normalizeContrast divides the image by an estimate of the paper brightness to remove
+// shadows and uneven lighting, then stretches the histogram to the full range
+func normalizeContrast(g *image.Gray) *image.Gray {
+	w, h := g.Rect.Dx(), g.Rect.Dy()
+	background := estimateBackground(g)
+
+	out := image.NewGray(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			bg := math.Max(1, backgroundAt(background, w, h, x, y))
+			v := float64(g.Pix[y*g.Stride+x]) / bg * 255
+			out.Pix[y*out.Stride+x] = uint8(math.Min(255, v))
+		}
+	}
+
+	stretchHistogram(out)
+	return out
+}
+
+// estimateBackground builds a small map of the paper brightness.
+// Taking the maximum of each block removes the (dark) text, and a blur smooths the result.
+func estimateBackground(g *image.Gray) [][]float64 {
+	w, h := g.Rect.Dx(), g.Rect.Dy()
+	block := max(1, int(math.Ceil(float64(max(w, h))/backgroundSize)))
+	bw := (w + block - 1) / block
+	bh := (h + block - 1) / block
+
+	blocks := make([][]float64, bh)
+	for by := 0; by < bh; by++ {
+		blocks[by] = make([]float64, bw)
+		for bx := 0; bx < bw; bx++ {
+			var brightest uint8
+			for y := by * block; y < min(h, (by+1)*block); y++ {
+				for x := bx * block; x < min(w, (bx+1)*block); x++ {
+					brightest = max(brightest, g.Pix[y*g.Stride+x])
+				}
+			}
+			blocks[by][bx] = float64(brightest)
+		}
+	}
+
+	// 3x3 box blur
+	blurred := make([][]float64, bh)
+	for by := 0; by < bh; by++ {
+		blurred[by] = make([]float64, bw)
+		for bx := 0; bx < bw; bx++ {
+			var sum float64
+			var count int
+			for dy := -1; dy <= 1; dy++ {
+				for dx := -1; dx <= 1; dx++ {
+					y, x := by+dy, bx+dx
+					if y >= 0 && y < bh && x >= 0 && x < bw {
+						sum += blocks[y][x]
+						count++
+					}
+				}
+			}
+			blurred[by][bx] = sum / float64(count)
+		}
+	}
+
+	return blurred
+}
+
+// backgroundAt interpolates the background map at a full-resolution pixel
+func backgroundAt(background [][]float64, w, h, x, y int) float64 {
+	bh, bw := len(background), len(background[0])
+	fx := (float64(x)+0.5)/float64(w)*float64(bw) - 0.5
+	fy := (float64(y)+0.5)/float64(h)*float64(bh) - 0.5
+	fx = math.Max(0, math.Min(fx, float64(bw-1)))
+	fy = math.Max(0, math.Min(fy, float64(bh-1)))
+
+	x0, y0 := int(fx), int(fy)
+	x1, y1 := min(x0+1, bw-1), min(y0+1, bh-1)
+	tx, ty := fx-float64(x0), fy-float64(y0)
+
+	top := background[y0][x0]*(1-tx) + background[y0][x1]*tx
+	bottom := background[y1][x0]*(1-tx) + background[y1][x1]*tx
+	return top*(1-ty) + bottom*ty
+}
+
+// stretchHistogram maps the 0.5th-99.5th percentile range to 0-255 in place
+func stretchHistogram(g *image.Gray) {
+	if len(g.Pix) == 0 {
+		return
+	}
+
+	values := make([]uint8, len(g.Pix))
+	copy(values, g.Pix)
+	sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+
+	low := float64(values[len(values)*5/1000])
+	high := float64(values[min(len(values)-1, len(values)*995/1000)])
+	if high-low < 10 {
+		return
+	}
+
+	for i, v := range g.Pix {
+		stretched := (float64(v) - low) / (high - low) * 255
+		g.Pix[i] = uint8(math.Max(0, math.Min(255, stretched)))
+	}
+}
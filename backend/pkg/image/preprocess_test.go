@@ -0,0 +1,211 @@
+package image
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// textPage draws a synthetic page: white paper with horizontal "text lines" tilted by angle degrees
+func textPage(w, h int, angle float64) *image.Gray {
+	page := image.NewGray(image.Rect(0, 0, w, h))
+	for i := range page.Pix {
+		page.Pix[i] = 240
+	}
+
+	slope := math.Tan(angle * math.Pi / 180)
+	for y0 := h / 5; y0 < h*4/5; y0 += 24 {
+		for x := w / 8; x < w*7/8; x++ {
+			// Leave word gaps so that lines look like text
+			if (x/30)%5 == 4 {
+				continue
+			}
+			y := y0 + int(math.Round(float64(x)*slope))
+			for dy := 0; dy < 6; dy++ {
+				if y+dy >= 0 && y+dy < h {
+					page.Pix[(y+dy)*page.Stride+x] = 20
+				}
+			}
+		}
+	}
+	return page
+}
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func decodeGray(t *testing.T, data []byte) *image.Gray {
+	t.Helper()
+	img, err := png.Decode(bytes.NewReader(data))
+	require.NoError(t, err)
+	gray, ok := img.(*image.Gray)
+	require.True(t, ok)
+	return gray
+}
+
+// withExifOrientation inserts an APP1 Exif segment with the given orientation after the SOI marker
+func withExifOrientation(t *testing.T, jpegData []byte, orientation uint16) []byte {
+	t.Helper()
+
+	tiff := []byte("MM\x00\x2a\x00\x00\x00\x08")
+	entry := make([]byte, 2+12+4)
+	binary.BigEndian.PutUint16(entry[0:], 1)
+	binary.BigEndian.PutUint16(entry[2:], exifOrientationTag)
+	binary.BigEndian.PutUint16(entry[4:], 3) // SHORT
+	binary.BigEndian.PutUint32(entry[6:], 1)
+	binary.BigEndian.PutUint16(entry[10:], orientation)
+	payload := append([]byte("Exif\x00\x00"), append(tiff, entry...)...)
+
+	segment := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(len(payload)+2))
+	segment = append(segment, payload...)
+
+	out := append([]byte{}, jpegData[:2]...)
+	out = append(out, segment...)
+	return append(out, jpegData[2:]...)
+}
+
+func TestExifOrientation(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, image.NewGray(image.Rect(0, 0, 4, 2)), nil))
+
+	assert.Equal(t, 1, ExifOrientation(buf.Bytes()))
+	assert.Equal(t, 6, ExifOrientation(withExifOrientation(t, buf.Bytes(), 6)))
+	assert.Equal(t, 1, ExifOrientation([]byte("not a jpeg")))
+}
+
+func TestApplyExifOrientation(t *testing.T) {
+	// 3x2 image with a marked top-left pixel
+	src := image.NewGray(image.Rect(0, 0, 3, 2))
+	src.SetGray(0, 0, color.Gray{Y: 255})
+
+	tests := []struct {
+		orientation int
+		width       int
+		height      int
+		marked      image.Point
+	}{
+		{1, 3, 2, image.Pt(0, 0)},
+		{2, 3, 2, image.Pt(2, 0)},
+		{3, 3, 2, image.Pt(2, 1)},
+		{4, 3, 2, image.Pt(0, 1)},
+		{5, 2, 3, image.Pt(0, 0)},
+		{6, 2, 3, image.Pt(1, 0)},
+		{7, 2, 3, image.Pt(1, 2)},
+		{8, 2, 3, image.Pt(0, 2)},
+	}
+
+	for _, tt := range tests {
+		out := applyExifOrientation(src, tt.orientation)
+		assert.Equal(t, tt.width, out.Rect.Dx(), "orientation %d", tt.orientation)
+		assert.Equal(t, tt.height, out.Rect.Dy(), "orientation %d", tt.orientation)
+		assert.Equal(t, uint8(255), out.GrayAt(tt.marked.X, tt.marked.Y).Y, "orientation %d", tt.orientation)
+	}
+}
+
+func TestEstimateSkew(t *testing.T) {
+	for _, angle := range []float64{-4, 0, 3} {
+		skew := estimateSkew(textPage(600, 800, angle), 10)
+		assert.InDelta(t, angle, skew, 0.5, "angle %v", angle)
+	}
+}
+
+func TestPreprocess_Deskew(t *testing.T) {
+	opts := DefaultPreprocessOptions()
+	opts.Crop = false
+	opts.NormalizeContrast = false
+
+	result, err := Preprocess(encodePNG(t, textPage(600, 800, 3)), opts)
+	require.NoError(t, err)
+	assert.InDelta(t, 3, result.SkewAngle, 0.5)
+
+	// After deskewing, the page should no longer look skewed
+	assert.InDelta(t, 0, estimateSkew(decodeGray(t, result.Image), 10), 0.5)
+}
+
+func TestPreprocess_ExifRotation(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, textPage(400, 300, 0), nil))
+
+	opts := PreprocessOptions{AutoRotate: true}
+	result, err := Preprocess(withExifOrientation(t, buf.Bytes(), 6), opts)
+	require.NoError(t, err)
+
+	assert.Equal(t, 90, result.Rotation)
+	out := decodeGray(t, result.Image)
+	assert.Equal(t, 300, out.Rect.Dx())
+	assert.Equal(t, 400, out.Rect.Dy())
+}
+
+func TestPreprocess_DetectOrientation(t *testing.T) {
+	sideways := rotate90(textPage(600, 800, 0))
+
+	result, err := Preprocess(encodePNG(t, sideways), PreprocessOptions{DetectOrientation: true})
+	require.NoError(t, err)
+	assert.Equal(t, 270, result.Rotation)
+	assert.False(t, isTextVertical(decodeGray(t, result.Image)))
+
+	result, err = Preprocess(encodePNG(t, textPage(600, 800, 0)), PreprocessOptions{DetectOrientation: true})
+	require.NoError(t, err)
+	assert.Equal(t, 0, result.Rotation)
+}
+
+func TestPreprocess_Crop(t *testing.T) {
+	// A page lying on a dark table
+	photo := image.NewGray(image.Rect(0, 0, 800, 1000))
+	for i := range photo.Pix {
+		photo.Pix[i] = 30
+	}
+	page := textPage(600, 800, 0)
+	for y := 0; y < 800; y++ {
+		copy(photo.Pix[(y+100)*photo.Stride+100:], page.Pix[y*page.Stride:(y+1)*page.Stride])
+	}
+
+	result, err := Preprocess(encodePNG(t, photo), PreprocessOptions{Crop: true})
+	require.NoError(t, err)
+
+	// The crop stays inside the page and still contains all text lines
+	assert.True(t, result.CropBox.In(image.Rect(100, 100, 700, 900)), "crop box %v", result.CropBox)
+	assert.True(t, image.Rect(175, 260, 625, 740).In(result.CropBox), "crop box %v", result.CropBox)
+}
+
+func TestPreprocess_NormalizeContrast(t *testing.T) {
+	// Faded text under a shadow that darkens the right half
+	page := textPage(400, 400, 0)
+	for y := 0; y < 400; y++ {
+		for x := 200; x < 400; x++ {
+			v := page.Pix[y*page.Stride+x]
+			page.Pix[y*page.Stride+x] = uint8(float64(v)*0.5) + 40
+		}
+	}
+
+	result, err := Preprocess(encodePNG(t, page), PreprocessOptions{NormalizeContrast: true})
+	require.NoError(t, err)
+	out := decodeGray(t, result.Image)
+
+	// Paper in the shadow becomes as bright as paper in the light
+	lit := out.GrayAt(20, 20).Y
+	shadowed := out.GrayAt(380, 20).Y
+	assert.InDelta(t, float64(lit), float64(shadowed), 20)
+	assert.Greater(t, shadowed, uint8(200))
+
+	// Text in the shadow stays dark
+	assert.Less(t, out.GrayAt(300, 82).Y, uint8(120))
+}
+
+func TestPreprocess_InvalidImage(t *testing.T) {
+	_, err := Preprocess([]byte("not an image"), DefaultPreprocessOptions())
+	assert.ErrorIs(t, err, ErrUnsupportedFormat)
+}
@@ -3,9 +3,12 @@ package ocr
 import (
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
 )
 
 // NewOCRClient は環境変数に基づいて適切なOCRクライアントを返す
+// OCR_PROVIDERS（カンマ区切り）が設定されている場合は、その順にフォールバックする複合クライアントを返す
 func NewOCRClient() (OCRClient, error) {
 	// モック使用の判定
 	useMocks := os.Getenv("USE_MOCK_APIS") == "true" ||
@@ -15,12 +18,70 @@ func NewOCRClient() (OCRClient, error) {
 		return NewMockOCRClient(), nil
 	}
 
+	// フォールバックチェーンの構築
+	if chain := os.Getenv("OCR_PROVIDERS"); chain != "" {
+		return newFallbackClientFromEnv(chain)
+	}
+
 	// プロバイダーの選択
 	provider := OCRProvider(os.Getenv("OCR_PROVIDER"))
 	if provider == "" {
 		provider = ProviderGoogleVision // デフォルト
 	}
 
+	client, err := newProviderClient(provider)
+	if err != nil {
+		return nil, err
+	}
+	if client == nil {
+		// 認証情報がない場合は自動的にモックを使用
+		return NewMockOCRClient(), nil
+	}
+
+	return client, nil
+}
+
+// newFallbackClientFromEnv はOCR_PROVIDERSとOCR_MIN_CONFIDENCEからフォールバッククライアントを作成する
+// 認証情報が設定されていないプロバイダーはチェーンから除外する
+func newFallbackClientFromEnv(chain string) (OCRClient, error) {
+	minConfidence := DefaultMinConfidence
+	if value := os.Getenv("OCR_MIN_CONFIDENCE"); value != "" {
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil || parsed < 0 || parsed > 1 {
+			return nil, fmt.Errorf("invalid OCR_MIN_CONFIDENCE: %s", value)
+		}
+		minConfidence = parsed
+	}
+
+	var providers []ProviderClient
+	for _, name := range strings.Split(chain, ",") {
+		provider := OCRProvider(strings.TrimSpace(name))
+		if provider == "" {
+			continue
+		}
+
+		client, err := newProviderClient(provider)
+		if err != nil {
+			return nil, err
+		}
+		if client == nil {
+			continue
+		}
+
+		providers = append(providers, ProviderClient{Provider: provider, Client: client})
+	}
+
+	if len(providers) == 0 {
+		// 利用可能なプロバイダーがない場合は自動的にモックを使用
+		providers = append(providers, ProviderClient{Provider: ProviderMock, Client: NewMockOCRClient()})
+	}
+
+	return NewFallbackClient(minConfidence, providers...), nil
+}
+
+// newProviderClient はプロバイダーのクライアントを作成する
+// 認証情報が設定されていない場合は nil を返す
+func newProviderClient(provider OCRProvider) (OCRClient, error) {
 	switch provider {
 	case ProviderGoogleVision:
 		// サービスアカウント認証を優先
@@ -35,8 +96,7 @@ func NewOCRClient() (OCRClient, error) {
 		// APIキー認証にフォールバック
 		apiKey := os.Getenv("GOOGLE_CLOUD_VISION_API_KEY")
 		if apiKey == "" {
-			// 認証情報がない場合は自動的にモックを使用
-			return NewMockOCRClient(), nil
+			return nil, nil
 		}
 		return NewGoogleVisionClient(apiKey), nil
 
@@ -44,13 +104,15 @@ func NewOCRClient() (OCRClient, error) {
 		endpoint := os.Getenv("AZURE_COMPUTER_VISION_ENDPOINT")
 		apiKey := os.Getenv("AZURE_COMPUTER_VISION_API_KEY")
 		if endpoint == "" || apiKey == "" {
-			// APIキーがない場合は自動的にモックを使用
-			return NewMockOCRClient(), nil
+			return nil, nil
 		}
 		return NewAzureVisionClient(endpoint, apiKey), nil
 
 	case ProviderTesseract:
-		return NewTesseractClient(), nil
+		return NewTesseractClient(os.Getenv("TESSERACT_PATH")), nil
+
+	case ProviderMock:
+		return NewMockOCRClient(), nil
 
 	default:
 		return nil, fmt.Errorf("unsupported OCR provider: %s", provider)
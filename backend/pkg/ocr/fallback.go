@@ -0,0 +1,84 @@
+package ocr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+)
+
+// DefaultMinConfidence は再処理を行わずに結果を採用する信頼度の下限
+const DefaultMinConfidence = 0.7
+
+// ProviderClient はプロバイダー名付きのOCRクライアント
+type ProviderClient struct {
+	Provider OCRProvider
+	Client   OCRClient
+}
+
+// FallbackClient は複数のOCRプロバイダーを優先順に試す複合クライアント
+// エラー（レート制限を含む）の場合は次のプロバイダーにフォールバックし、
+// 信頼度が minConfidence 未満の場合も次のプロバイダーで再処理する
+// すべてのプロバイダーの信頼度が下限未満の場合は最も信頼度の高い結果を採用する
+type FallbackClient struct {
+	providers     []ProviderClient
+	minConfidence float64
+}
+
+// NewFallbackClient は新しいフォールバッククライアントを作成する
+func NewFallbackClient(minConfidence float64, providers ...ProviderClient) *FallbackClient {
+	return &FallbackClient{
+		providers:     providers,
+		minConfidence: minConfidence,
+	}
+}
+
+// Providers はフォールバック順のプロバイダー一覧を返す
+func (f *FallbackClient) Providers() []OCRProvider {
+	providers := make([]OCRProvider, 0, len(f.providers))
+	for _, p := range f.providers {
+		providers = append(providers, p.Provider)
+	}
+	return providers
+}
+
+// ProcessImage は画像データをOCR処理する
+// 採用した結果の Provider には結果を生成したプロバイダーが設定される
+func (f *FallbackClient) ProcessImage(ctx context.Context, imageData []byte, languages []string) (*OCRResult, error) {
+	if len(f.providers) == 0 {
+		return nil, errors.New("no OCR providers configured")
+	}
+
+	var best *OCRResult
+	var lastErr error
+
+	for _, p := range f.providers {
+		result, err := p.Client.ProcessImage(ctx, imageData, languages)
+		if err != nil {
+			// キャンセルされた場合は他のプロバイダーを試さない
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			log.Printf("OCR provider (%s) failed: %v. Trying fallback...", p.Provider, err)
+			lastErr = err
+			continue
+		}
+
+		result.Provider = p.Provider
+		if result.Confidence >= f.minConfidence {
+			return result, nil
+		}
+
+		log.Printf("OCR provider (%s) confidence %.2f is below %.2f. Re-processing with next provider...",
+			p.Provider, result.Confidence, f.minConfidence)
+		if best == nil || result.Confidence > best.Confidence {
+			best = result
+		}
+	}
+
+	if best != nil {
+		return best, nil
+	}
+
+	return nil, fmt.Errorf("all OCR providers failed: %w", lastErr)
+}
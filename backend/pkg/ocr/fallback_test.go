@@ -0,0 +1,118 @@
+package ocr
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubOCRClient は固定の結果またはエラーを返すOCRクライアント
+type stubOCRClient struct {
+	result *OCRResult
+	err    error
+	calls  int
+}
+
+func (s *stubOCRClient) ProcessImage(ctx context.Context, imageData []byte, languages []string) (*OCRResult, error) {
+	s.calls++
+	if s.err != nil {
+		return nil, s.err
+	}
+	result := *s.result
+	return &result, nil
+}
+
+func TestFallbackClient_FallsBackOnError(t *testing.T) {
+	primary := &stubOCRClient{err: errors.New("rate limit exceeded")}
+	secondary := &stubOCRClient{result: &OCRResult{Text: "Привет", Confidence: 0.9}}
+
+	client := NewFallbackClient(0.7,
+		ProviderClient{Provider: ProviderGoogleVision, Client: primary},
+		ProviderClient{Provider: ProviderTesseract, Client: secondary},
+	)
+
+	result, err := client.ProcessImage(context.Background(), []byte("image"), []string{"ru"})
+	require.NoError(t, err)
+	assert.Equal(t, "Привет", result.Text)
+	assert.Equal(t, ProviderTesseract, result.Provider)
+	assert.Equal(t, 1, primary.calls)
+	assert.Equal(t, 1, secondary.calls)
+}
+
+func TestFallbackClient_StopsAtConfidentResult(t *testing.T) {
+	primary := &stubOCRClient{result: &OCRResult{Text: "primary", Confidence: 0.95}}
+	secondary := &stubOCRClient{result: &OCRResult{Text: "secondary", Confidence: 0.99}}
+
+	client := NewFallbackClient(0.7,
+		ProviderClient{Provider: ProviderGoogleVision, Client: primary},
+		ProviderClient{Provider: ProviderAzureVision, Client: secondary},
+	)
+
+	result, err := client.ProcessImage(context.Background(), []byte("image"), nil)
+	require.NoError(t, err)
+	assert.Equal(t, ProviderGoogleVision, result.Provider)
+	assert.Equal(t, 0, secondary.calls)
+}
+
+func TestFallbackClient_ReprocessesLowConfidence(t *testing.T) {
+	low := &stubOCRClient{result: &OCRResult{Text: "l0w", Confidence: 0.4}}
+	better := &stubOCRClient{result: &OCRResult{Text: "low", Confidence: 0.6}}
+	failing := &stubOCRClient{err: errors.New("unavailable")}
+
+	client := NewFallbackClient(0.7,
+		ProviderClient{Provider: ProviderTesseract, Client: low},
+		ProviderClient{Provider: ProviderAzureVision, Client: better},
+		ProviderClient{Provider: ProviderGoogleVision, Client: failing},
+	)
+
+	// すべて下限未満の場合は最も信頼度の高い結果を採用する
+	result, err := client.ProcessImage(context.Background(), []byte("image"), nil)
+	require.NoError(t, err)
+	assert.Equal(t, "low", result.Text)
+	assert.Equal(t, ProviderAzureVision, result.Provider)
+	assert.Equal(t, 1, failing.calls)
+}
+
+func TestFallbackClient_AllFail(t *testing.T) {
+	client := NewFallbackClient(0.7,
+		ProviderClient{Provider: ProviderGoogleVision, Client: &stubOCRClient{err: errors.New("quota")}},
+		ProviderClient{Provider: ProviderTesseract, Client: &stubOCRClient{err: errors.New("not installed")}},
+	)
+
+	_, err := client.ProcessImage(context.Background(), []byte("image"), nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not installed")
+
+	_, err = NewFallbackClient(0.7).ProcessImage(context.Background(), []byte("image"), nil)
+	assert.Error(t, err)
+}
+
+func TestNewOCRClient_ProviderChain(t *testing.T) {
+	t.Setenv("TEST_USE_MOCKS", "")
+	t.Setenv("USE_MOCK_APIS", "")
+	t.Setenv("GOOGLE_APPLICATION_CREDENTIALS", "")
+	t.Setenv("GOOGLE_CLOUD_VISION_API_KEY", "")
+	t.Setenv("OCR_PROVIDERS", "google_vision, tesseract,mock")
+	t.Setenv("OCR_MIN_CONFIDENCE", "0.8")
+
+	client, err := NewOCRClient()
+	require.NoError(t, err)
+
+	fallback, ok := client.(*FallbackClient)
+	require.True(t, ok, "expected FallbackClient")
+	// 認証情報のない google_vision はスキップされる
+	assert.Equal(t, []OCRProvider{ProviderTesseract, ProviderMock}, fallback.Providers())
+	assert.Equal(t, 0.8, fallback.minConfidence)
+
+	t.Setenv("OCR_MIN_CONFIDENCE", "2")
+	_, err = NewOCRClient()
+	assert.Error(t, err)
+
+	t.Setenv("OCR_MIN_CONFIDENCE", "")
+	t.Setenv("OCR_PROVIDERS", "unknown")
+	_, err = NewOCRClient()
+	assert.Error(t, err)
+}
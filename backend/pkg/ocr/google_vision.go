@@ -130,18 +130,21 @@ func (g *GoogleVisionClient) processImageWithServiceAccount(ctx context.Context,
 	fullText := annotateResp.TextAnnotations[0].Description
 	locale := annotateResp.TextAnnotations[0].Locale
 
-	return &OCRResult{
+	result := &OCRResult{
 		Text:             fullText,
 		DetectedLanguage: locale,
-		Confidence:       0.95, // Google Vision APIは信頼度を提供しないので固定値
+		Confidence:       googleVisionDefaultConfidence, // Google Vision APIは信頼度を提供しないので固定値
 		Pages: []PageOCRResult{
 			{
 				PageNumber: 1,
 				Text:       fullText,
-				Confidence: 0.95,
+				Confidence: googleVisionDefaultConfidence,
 			},
 		},
-	}, nil
+	}
+	applyVisionLayout(result, visionWordsFromProto(annotateResp.FullTextAnnotation))
+
+	return result, nil
 }
 
 // processImageWithAPIKey はAPIキーを使用してOCR処理する（従来の方法）
@@ -249,6 +252,7 @@ func (g *GoogleVisionClient) callAPI(ctx context.Context, imageData []byte, lang
 				Description string `json:"description"`
 				Locale      string `json:"locale"`
 			} `json:"textAnnotations"`
+			FullTextAnnotation *visionTextAnnotation `json:"fullTextAnnotation"`
 		} `json:"responses"`
 		Error *struct {
 			Code    int    `json:"code"`
@@ -279,18 +283,21 @@ func (g *GoogleVisionClient) callAPI(ctx context.Context, imageData []byte, lang
 	fullText := apiResponse.Responses[0].TextAnnotations[0].Description
 	locale := apiResponse.Responses[0].TextAnnotations[0].Locale
 
-	return &OCRResult{
+	result := &OCRResult{
 		Text:             fullText,
 		DetectedLanguage: locale,
-		Confidence:       0.95, // Google Vision APIは信頼度を提供しないので固定値
+		Confidence:       googleVisionDefaultConfidence, // Google Vision APIは信頼度を提供しないので固定値
 		Pages: []PageOCRResult{
 			{
 				PageNumber: 1,
 				Text:       fullText,
-				Confidence: 0.95,
+				Confidence: googleVisionDefaultConfidence,
 			},
 		},
-	}, nil
+	}
+	applyVisionLayout(result, visionWordsFromREST(apiResponse.Responses[0].FullTextAnnotation))
+
+	return result, nil
 }
 
 // GetCredentialsFile はGOOGLE_APPLICATION_CREDENTIALS環境変数またはデフォルトパスからファイルを取得
@@ -302,3 +309,154 @@ func GetCredentialsFile() string {
 	// デフォルトはプロジェクトルートのgcp_hailingo.json
 	return ""
 }
+
+// googleVisionDefaultConfidence はVision APIが信頼度を返さない場合に使用する固定値
+const googleVisionDefaultConfidence = 0.95
+
+// visionTextAnnotation はVision APIのfullTextAnnotation（REST APIのJSON表現）
+type visionTextAnnotation struct {
+	Pages []struct {
+		Blocks []struct {
+			Paragraphs []struct {
+				Words []struct {
+					BoundingBox visionBoundingPoly `json:"boundingBox"`
+					Confidence  float64            `json:"confidence"`
+					Symbols     []struct {
+						Text     string `json:"text"`
+						Property *struct {
+							DetectedBreak *struct {
+								Type string `json:"type"`
+							} `json:"detectedBreak"`
+						} `json:"property"`
+					} `json:"symbols"`
+				} `json:"words"`
+			} `json:"paragraphs"`
+		} `json:"blocks"`
+	} `json:"pages"`
+}
+
+// visionBoundingPoly はVision APIの多角形領域
+type visionBoundingPoly struct {
+	Vertices []struct {
+		X int `json:"x"`
+		Y int `json:"y"`
+	} `json:"vertices"`
+}
+
+// visionWord はfullTextAnnotationから抽出した単語
+type visionWord struct {
+	block      int
+	text       string
+	confidence float64
+	xs, ys     []int
+	breakType  string // 単語末尾の区切り種別（SPACE, LINE_BREAK, EOL_SURE_SPACE など）
+}
+
+// visionWordsFromREST はREST APIのfullTextAnnotationから単語を抽出する
+func visionWordsFromREST(annotation *visionTextAnnotation) []visionWord {
+	if annotation == nil {
+		return nil
+	}
+
+	var words []visionWord
+	block := 0
+	for _, page := range annotation.Pages {
+		for _, b := range page.Blocks {
+			for _, paragraph := range b.Paragraphs {
+				for _, w := range paragraph.Words {
+					vw := visionWord{block: block, confidence: w.Confidence}
+					for _, v := range w.BoundingBox.Vertices {
+						vw.xs = append(vw.xs, v.X)
+						vw.ys = append(vw.ys, v.Y)
+					}
+					for _, sym := range w.Symbols {
+						vw.text += sym.Text
+						if sym.Property != nil && sym.Property.DetectedBreak != nil {
+							vw.breakType = sym.Property.DetectedBreak.Type
+						}
+					}
+					words = append(words, vw)
+				}
+			}
+			block++
+		}
+	}
+	return words
+}
+
+// visionWordsFromProto はgRPC APIのTextAnnotationから単語を抽出する
+func visionWordsFromProto(annotation *visionpb.TextAnnotation) []visionWord {
+	var words []visionWord
+	block := 0
+	for _, page := range annotation.GetPages() {
+		for _, b := range page.GetBlocks() {
+			for _, paragraph := range b.GetParagraphs() {
+				for _, w := range paragraph.GetWords() {
+					vw := visionWord{block: block, confidence: float64(w.GetConfidence())}
+					for _, v := range w.GetBoundingBox().GetVertices() {
+						vw.xs = append(vw.xs, int(v.GetX()))
+						vw.ys = append(vw.ys, int(v.GetY()))
+					}
+					for _, sym := range w.GetSymbols() {
+						vw.text += sym.GetText()
+						if detected := sym.GetProperty().GetDetectedBreak(); detected != nil {
+							vw.breakType = detected.GetType().String()
+						}
+					}
+					words = append(words, vw)
+				}
+			}
+			block++
+		}
+	}
+	return words
+}
+
+// applyVisionLayout は抽出した単語からレイアウト情報と信頼度をOCRResultに設定する
+func applyVisionLayout(result *OCRResult, words []visionWord) {
+	if len(words) == 0 {
+		return
+	}
+
+	layout := make([]layoutWord, 0, len(words))
+	line := 0
+	var confSum float64
+	var measured int
+
+	for i, w := range words {
+		if i > 0 {
+			prev := words[i-1]
+			if w.block != prev.block || prev.breakType == "LINE_BREAK" || prev.breakType == "EOL_SURE_SPACE" {
+				line++
+			}
+		}
+
+		confidence := w.confidence
+		if confidence > 0 {
+			confSum += confidence
+			measured++
+		} else {
+			confidence = googleVisionDefaultConfidence
+		}
+
+		layout = append(layout, layoutWord{
+			block: w.block,
+			line:  line,
+			word: Word{
+				Text:        w.text,
+				Confidence:  confidence,
+				BoundingBox: boundingBoxFromVertices(w.xs, w.ys),
+			},
+		})
+	}
+
+	result.Blocks, result.Lines, result.Words = buildLayout(layout)
+
+	// DOCUMENT_TEXT_DETECTION等で単語ごとの信頼度が得られた場合はその平均を使用する
+	if measured > 0 {
+		result.Confidence = confSum / float64(measured)
+		for i := range result.Pages {
+			result.Pages[i].Confidence = result.Confidence
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package ocr
+
+// BoundingBox は画像上の矩形領域（ピクセル単位、左上原点）
+type BoundingBox struct {
+	X      int `json:"x"`
+	Y      int `json:"y"`
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+// Word は単語レベルのOCR結果
+type Word struct {
+	Text        string      `json:"text"`
+	Confidence  float64     `json:"confidence"`
+	BoundingBox BoundingBox `json:"bounding_box"`
+	Ruby        []Ruby      `json:"ruby,omitempty"` // 漢字に付与されたルビ（SeparateRubyで設定）
+}
+
+// Line は行レベルのOCR結果
+type Line struct {
+	Text        string      `json:"text"`
+	Confidence  float64     `json:"confidence"`
+	BoundingBox BoundingBox `json:"bounding_box"`
+	Words       []Word      `json:"words"`
+}
+
+// Block はブロック（段落）レベルのOCR結果
+type Block struct {
+	Text        string      `json:"text"`
+	Confidence  float64     `json:"confidence"`
+	BoundingBox BoundingBox `json:"bounding_box"`
+	Lines       []Line      `json:"lines"`
+}
+
+// union は2つの矩形を包含する最小の矩形を返す
+func (b BoundingBox) union(other BoundingBox) BoundingBox {
+	if b.Width == 0 && b.Height == 0 {
+		return other
+	}
+	if other.Width == 0 && other.Height == 0 {
+		return b
+	}
+
+	minX := min(b.X, other.X)
+	minY := min(b.Y, other.Y)
+	maxX := max(b.X+b.Width, other.X+other.Width)
+	maxY := max(b.Y+b.Height, other.Y+other.Height)
+
+	return BoundingBox{X: minX, Y: minY, Width: maxX - minX, Height: maxY - minY}
+}
+
+// boundingBoxFromVertices は多角形の頂点から外接矩形を計算する
+func boundingBoxFromVertices(xs, ys []int) BoundingBox {
+	if len(xs) == 0 || len(ys) == 0 {
+		return BoundingBox{}
+	}
+
+	minX, maxX := xs[0], xs[0]
+	for _, x := range xs[1:] {
+		minX = min(minX, x)
+		maxX = max(maxX, x)
+	}
+	minY, maxY := ys[0], ys[0]
+	for _, y := range ys[1:] {
+		minY = min(minY, y)
+		maxY = max(maxY, y)
+	}
+
+	return BoundingBox{X: minX, Y: minY, Width: maxX - minX, Height: maxY - minY}
+}
+
+// layoutWord はレイアウト構築用の単語（所属ブロック・行の識別子付き）
+type layoutWord struct {
+	block int
+	line  int
+	word  Word
+}
+
+// buildLayout は順序付きの単語列からブロック→行→単語の階層構造を構築する
+// 戻り値はブロック、行、単語のそれぞれをフラットにしたスライス
+func buildLayout(words []layoutWord) ([]Block, []Line, []Word) {
+	var blocks []Block
+	var lines []Line
+	flatWords := make([]Word, 0, len(words))
+
+	for i, lw := range words {
+		flatWords = append(flatWords, lw.word)
+
+		newBlock := i == 0 || lw.block != words[i-1].block
+		newLine := newBlock || lw.line != words[i-1].line
+
+		if newBlock {
+			blocks = append(blocks, Block{})
+		}
+		block := &blocks[len(blocks)-1]
+
+		if newLine {
+			block.Lines = append(block.Lines, Line{})
+		}
+		line := &block.Lines[len(block.Lines)-1]
+
+		if len(line.Words) > 0 && needsSpace(line.Words[len(line.Words)-1].Text, lw.word.Text) {
+			line.Text += " "
+		}
+		line.Text += lw.word.Text
+		line.Words = append(line.Words, lw.word)
+		line.BoundingBox = line.BoundingBox.union(lw.word.BoundingBox)
+	}
+
+	for i := range blocks {
+		block := &blocks[i]
+		var wordCount int
+		var confSum float64
+		for j := range block.Lines {
+			line := &block.Lines[j]
+			var lineConf float64
+			for _, w := range line.Words {
+				lineConf += w.Confidence
+			}
+			line.Confidence = lineConf / float64(len(line.Words))
+
+			if j > 0 {
+				block.Text += "\n"
+			}
+			block.Text += line.Text
+			block.BoundingBox = block.BoundingBox.union(line.BoundingBox)
+			confSum += lineConf
+			wordCount += len(line.Words)
+
+			lines = append(lines, *line)
+		}
+		block.Confidence = confSum / float64(wordCount)
+	}
+
+	return blocks, lines, flatWords
+}
@@ -0,0 +1,95 @@
+package ocr
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildLayout(t *testing.T) {
+	words := []layoutWord{
+		{block: 0, line: 0, word: Word{Text: "Как", Confidence: 0.9, BoundingBox: BoundingBox{X: 10, Y: 10, Width: 50, Height: 30}}},
+		{block: 0, line: 0, word: Word{Text: "дела?", Confidence: 0.7, BoundingBox: BoundingBox{X: 70, Y: 12, Width: 60, Height: 30}}},
+		{block: 0, line: 1, word: Word{Text: "元気", Confidence: 0.8, BoundingBox: BoundingBox{X: 10, Y: 50, Width: 40, Height: 30}}},
+		{block: 0, line: 1, word: Word{Text: "です", Confidence: 0.8, BoundingBox: BoundingBox{X: 50, Y: 50, Width: 40, Height: 30}}},
+		{block: 1, line: 2, word: Word{Text: "Hello", Confidence: 1.0, BoundingBox: BoundingBox{X: 10, Y: 200, Width: 80, Height: 30}}},
+	}
+
+	blocks, lines, flat := buildLayout(words)
+	require.Len(t, blocks, 2)
+	require.Len(t, lines, 3)
+	require.Len(t, flat, 5)
+
+	assert.Equal(t, "Как дела?", lines[0].Text)
+	assert.Equal(t, BoundingBox{X: 10, Y: 10, Width: 120, Height: 32}, lines[0].BoundingBox)
+	assert.InDelta(t, 0.8, lines[0].Confidence, 0.001)
+
+	// 日本語の単語は空白なしで連結される
+	assert.Equal(t, "元気です", lines[1].Text)
+
+	assert.Equal(t, "Как дела?\n元気です", blocks[0].Text)
+	assert.Equal(t, BoundingBox{X: 10, Y: 10, Width: 120, Height: 70}, blocks[0].BoundingBox)
+	assert.Len(t, blocks[0].Lines, 2)
+	assert.Equal(t, "Hello", blocks[1].Text)
+}
+
+func TestApplyVisionLayout_REST(t *testing.T) {
+	raw := `{
+		"pages": [{
+			"blocks": [{
+				"paragraphs": [{
+					"words": [
+						{
+							"boundingBox": {"vertices": [{"x": 10, "y": 10}, {"x": 60, "y": 10}, {"x": 60, "y": 40}, {"x": 10, "y": 40}]},
+							"symbols": [{"text": "H"}, {"text": "i", "property": {"detectedBreak": {"type": "LINE_BREAK"}}}]
+						},
+						{
+							"boundingBox": {"vertices": [{"x": 10, "y": 50}, {"x": 90, "y": 50}, {"x": 90, "y": 80}, {"x": 10, "y": 80}]},
+							"confidence": 0.5,
+							"symbols": [{"text": "t"}, {"text": "h"}, {"text": "e"}, {"text": "r"}, {"text": "e"}]
+						}
+					]
+				}]
+			}]
+		}]
+	}`
+
+	var annotation visionTextAnnotation
+	require.NoError(t, json.Unmarshal([]byte(raw), &annotation))
+
+	result := &OCRResult{
+		Text:       "Hi\nthere",
+		Confidence: googleVisionDefaultConfidence,
+		Pages:      []PageOCRResult{{PageNumber: 1, Text: "Hi\nthere", Confidence: googleVisionDefaultConfidence}},
+	}
+	applyVisionLayout(result, visionWordsFromREST(&annotation))
+
+	require.Len(t, result.Blocks, 1)
+	require.Len(t, result.Lines, 2)
+	require.Len(t, result.Words, 2)
+
+	assert.Equal(t, "Hi", result.Lines[0].Text)
+	assert.Equal(t, "there", result.Lines[1].Text)
+	assert.Equal(t, BoundingBox{X: 10, Y: 10, Width: 50, Height: 30}, result.Words[0].BoundingBox)
+	assert.Equal(t, googleVisionDefaultConfidence, result.Words[0].Confidence)
+
+	// 信頼度が返された単語のみで全体の信頼度を算出する
+	assert.InDelta(t, 0.5, result.Confidence, 0.001)
+	assert.InDelta(t, 0.5, result.Pages[0].Confidence, 0.001)
+}
+
+func TestMockOCRClient_Layout(t *testing.T) {
+	client := NewMockOCRClient()
+	result := client.generateDefaultResponse([]byte("image"), []string{"en"})
+
+	require.Len(t, result.Blocks, 1)
+	require.Len(t, result.Lines, 1)
+	assert.Equal(t, result.Text, result.Lines[0].Text)
+	assert.NotEmpty(t, result.Words)
+	for _, word := range result.Words {
+		assert.Greater(t, word.BoundingBox.Width, 0)
+		assert.Greater(t, word.BoundingBox.Height, 0)
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"unicode/utf8"
 )
 
 // MockOCRClient はモックOCRクライアント
@@ -70,7 +72,7 @@ func (m *MockOCRClient) generateDefaultResponse(imageData []byte, languages []st
 		text = sampleTexts["en"]
 	}
 
-	return &OCRResult{
+	result := &OCRResult{
 		Text:             text,
 		DetectedLanguage: detectedLang,
 		Confidence:       0.95,
@@ -82,6 +84,34 @@ func (m *MockOCRClient) generateDefaultResponse(imageData []byte, languages []st
 			},
 		},
 	}
+	result.Blocks, result.Lines, result.Words = buildLayout(mockLayoutWords(text))
+
+	return result
+}
+
+// mockLayoutWords はサンプルテキストを1行に並べた単語レイアウトを生成する
+// 1文字あたり幅20px・高さ30pxとして矩形領域を割り当てる
+func mockLayoutWords(text string) []layoutWord {
+	const (
+		charWidth  = 20
+		lineHeight = 30
+		margin     = 10
+	)
+
+	words := make([]layoutWord, 0)
+	x := margin
+	for _, token := range strings.Fields(text) {
+		width := utf8.RuneCountInString(token) * charWidth
+		words = append(words, layoutWord{
+			word: Word{
+				Text:        token,
+				Confidence:  0.95,
+				BoundingBox: BoundingBox{X: x, Y: margin, Width: width, Height: lineHeight},
+			},
+		})
+		x += width + charWidth
+	}
+	return words
 }
 
 // SetMockResponse はモックレスポンスを設定する（テスト用）
@@ -16,6 +16,11 @@ type OCRResult struct {
 	DetectedLanguage string   `json:"detected_language"`  // 検出された言語
 	Confidence       float64  `json:"confidence"`         // 信頼度（0.0-1.0）
 	Pages            []PageOCRResult `json:"pages"`       // ページごとの結果
+	Blocks           []Block  `json:"blocks,omitempty"`   // ブロック→行→単語の階層レイアウト
+	Lines            []Line   `json:"lines,omitempty"`    // 行レベルの結果（読み順）
+	Words            []Word   `json:"words,omitempty"`    // 単語レベルの結果（読み順）
+	Provider         OCRProvider `json:"provider,omitempty"` // 結果を生成したプロバイダー
+	HasRuby          bool     `json:"has_ruby,omitempty"`  // ルビ（ふりがな）を本文から分離したか
 }
 
 // PageOCRResult はページごとのOCR結果を表す
@@ -32,4 +37,8 @@ const (
 	ProviderGoogleVision OCRProvider = "google_vision"
 	ProviderAzureVision  OCRProvider = "azure_vision"
 	ProviderTesseract    OCRProvider = "tesseract"
+	ProviderMock         OCRProvider = "mock"
+
+	// ProviderPDFTextLayer はPDFの埋め込みテキストレイヤーから取得した結果（OCR未実行）
+	ProviderPDFTextLayer OCRProvider = "pdf_text_layer"
 )
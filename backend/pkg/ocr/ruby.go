@@ -0,0 +1,367 @@
+package ocr
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Ruby はルビ（ふりがな・ピンイン）と親文字の対応
+type Ruby struct {
+	Base    string `json:"base"`    // 親文字（漢字）
+	Reading string `json:"reading"` // 読み
+	Offset  int    `json:"offset"`  // 単語テキスト内での親文字の開始位置（文字単位）
+}
+
+const (
+	// rubyHeightRatio はルビとみなす文字の高さの上限（親文字の高さに対する比率）
+	rubyHeightRatio = 0.7
+	// rubyMaxGapRatio はルビ行と親文字行の間隔の上限（親文字の高さに対する比率）
+	rubyMaxGapRatio = 0.6
+	// rubyOverlapRatio はルビの下端が親文字の上端からはみ出してよい量（親文字の高さに対する比率）
+	rubyOverlapRatio = 0.35
+)
+
+// IsRubyLanguage はルビ付きの教材が想定される言語（日本語・中国語）かを判定する
+func IsRubyLanguage(lang string) bool {
+	lang = strings.ToLower(lang)
+	return strings.HasPrefix(lang, "ja") || strings.HasPrefix(lang, "zh")
+}
+
+// SeparateRuby は漢字の上に小さく組まれたルビを本文から分離し、親文字の単語に読みとして付与する
+// 行・単語の矩形から判定するため、レイアウト（Blocks）を持つ横書きの結果のみが対象
+// ルビが見つかった場合はレイアウトとTextを再構築してtrueを返す
+func SeparateRuby(result *OCRResult) bool {
+	if result == nil || len(result.Blocks) == 0 {
+		return false
+	}
+
+	// ページ全体の行をフラットに扱う（ルビ行が別ブロックとして認識される場合があるため）
+	type lineRef struct {
+		block int
+		line  *Line
+	}
+	var lines []lineRef
+	for i := range result.Blocks {
+		for j := range result.Blocks[i].Lines {
+			lines = append(lines, lineRef{block: i, line: &result.Blocks[i].Lines[j]})
+		}
+	}
+
+	attachments := make(map[*Word]map[int][]Word)
+	removed := make(map[*Word]bool)
+
+	// 1. 行全体がルビの場合（ルビが独立した行として認識された場合）
+	rubyLine := make([]bool, len(lines))
+	for i, ref := range lines {
+		if !isReadingLine(ref.line) {
+			continue
+		}
+
+		base := -1
+		bestGap := 0
+		for j, candidate := range lines {
+			if j == i || !hasHanWord(candidate.line.Words) {
+				continue
+			}
+			gap, ok := rubyLineGap(ref.line.BoundingBox, candidate.line.BoundingBox)
+			if ok && (base < 0 || gap < bestGap) {
+				base, bestGap = j, gap
+			}
+		}
+		if base < 0 {
+			continue
+		}
+
+		// 行内のすべての単語が親文字に対応付けられた場合のみルビ行とみなす
+		matches := make([]rubyMatch, 0, len(ref.line.Words))
+		for k := range ref.line.Words {
+			match, ok := matchRubyBase(lines[base].line.Words, ref.line.Words[k])
+			if !ok {
+				break
+			}
+			matches = append(matches, match)
+		}
+		if len(matches) != len(ref.line.Words) {
+			continue
+		}
+
+		rubyLine[i] = true
+		for k, match := range matches {
+			addRubyAttachment(attachments, &lines[base].line.Words[match.word], match.run, ref.line.Words[k])
+		}
+	}
+
+	// 2. ルビが本文と同じ行に混在している場合
+	for i, ref := range lines {
+		if rubyLine[i] {
+			continue
+		}
+		words := ref.line.Words
+
+		baseTop, baseHeight := -1, 0
+		for _, w := range words {
+			if containsHan(w.Text) {
+				if baseTop < 0 || w.BoundingBox.Y < baseTop {
+					baseTop = w.BoundingBox.Y
+				}
+				baseHeight = max(baseHeight, w.BoundingBox.Height)
+			}
+		}
+		if baseTop < 0 || baseHeight == 0 {
+			continue
+		}
+
+		for k := range words {
+			w := words[k]
+			if !isReadingText(w.Text) ||
+				float64(w.BoundingBox.Height) > rubyHeightRatio*float64(baseHeight) ||
+				float64(w.BoundingBox.Y+w.BoundingBox.Height) > float64(baseTop)+rubyOverlapRatio*float64(baseHeight) {
+				continue
+			}
+
+			match, ok := matchRubyBase(words, w)
+			if !ok {
+				continue
+			}
+			addRubyAttachment(attachments, &words[match.word], match.run, w)
+			removed[&words[k]] = true
+		}
+	}
+
+	if len(attachments) == 0 {
+		return false
+	}
+
+	// 3. 親文字にルビを付与し、ルビを除いたレイアウトを再構築する
+	layout := make([]layoutWord, 0, len(result.Words))
+	for i, ref := range lines {
+		if rubyLine[i] {
+			continue
+		}
+		for k := range ref.line.Words {
+			w := &ref.line.Words[k]
+			if removed[w] {
+				continue
+			}
+			word := *w
+			if runs, ok := attachments[w]; ok {
+				word.Ruby = buildRuby(word.Text, runs)
+			}
+			layout = append(layout, layoutWord{block: ref.block, line: i, word: word})
+		}
+	}
+
+	result.Blocks, result.Lines, result.Words = buildLayout(layout)
+	texts := make([]string, 0, len(result.Blocks))
+	for _, block := range result.Blocks {
+		texts = append(texts, block.Text)
+	}
+	result.Text = strings.Join(texts, "\n\n")
+	if len(result.Pages) == 1 {
+		result.Pages[0].Text = result.Text
+	}
+	result.HasRuby = true
+
+	return true
+}
+
+// rubyMatch はルビの単語が対応する親文字（単語と、単語内の漢字の連続部分）
+type rubyMatch struct {
+	word int
+	run  int
+}
+
+// hanRun は単語内の漢字の連続部分（文字単位の範囲）
+type hanRun struct {
+	start int
+	end   int
+}
+
+// matchRubyBase はルビの単語の水平位置から、その下にある親文字を探す
+func matchRubyBase(words []Word, ruby Word) (rubyMatch, bool) {
+	center := float64(ruby.BoundingBox.X) + float64(ruby.BoundingBox.Width)/2
+
+	best := rubyMatch{word: -1}
+	bestDistance := 0.0
+	for i, w := range words {
+		runs := hanRuns(w.Text)
+		if len(runs) == 0 {
+			continue
+		}
+		// ルビ自身や、親文字より大きい単語は対象外
+		if w.BoundingBox.Height <= ruby.BoundingBox.Height {
+			continue
+		}
+
+		tolerance := float64(w.BoundingBox.Height) / 2
+		left := float64(w.BoundingBox.X) - tolerance
+		right := float64(w.BoundingBox.X+w.BoundingBox.Width) + tolerance
+		if center < left || center > right {
+			continue
+		}
+
+		// 単語の幅を文字数で等分して各漢字部分の位置を推定する
+		length := float64(len([]rune(w.Text)))
+		for j, run := range runs {
+			runLeft := float64(w.BoundingBox.X) + float64(w.BoundingBox.Width)*float64(run.start)/length
+			runRight := float64(w.BoundingBox.X) + float64(w.BoundingBox.Width)*float64(run.end)/length
+
+			distance := 0.0
+			if center < runLeft {
+				distance = runLeft - center
+			} else if center > runRight {
+				distance = center - runRight
+			}
+			if best.word < 0 || distance < bestDistance {
+				best = rubyMatch{word: i, run: j}
+				bestDistance = distance
+			}
+		}
+	}
+
+	return best, best.word >= 0
+}
+
+// addRubyAttachment はルビの単語を親文字の漢字部分に対応付ける
+func addRubyAttachment(attachments map[*Word]map[int][]Word, base *Word, run int, ruby Word) {
+	if attachments[base] == nil {
+		attachments[base] = make(map[int][]Word)
+	}
+	attachments[base][run] = append(attachments[base][run], ruby)
+}
+
+// buildRuby は漢字部分ごとに対応付けられたルビを左から順に連結する
+func buildRuby(text string, attached map[int][]Word) []Ruby {
+	runes := []rune(text)
+	runs := hanRuns(text)
+
+	indexes := make([]int, 0, len(attached))
+	for index := range attached {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+
+	result := make([]Ruby, 0, len(indexes))
+	for _, index := range indexes {
+		words := attached[index]
+		sort.SliceStable(words, func(i, j int) bool { return words[i].BoundingBox.X < words[j].BoundingBox.X })
+
+		var reading string
+		for i, w := range words {
+			if i > 0 && needsSpace(reading, w.Text) {
+				reading += " "
+			}
+			reading += w.Text
+		}
+
+		run := runs[index]
+		result = append(result, Ruby{
+			Base:    string(runes[run.start:run.end]),
+			Reading: reading,
+			Offset:  run.start,
+		})
+	}
+
+	return result
+}
+
+// rubyLineGap はルビ行が親文字行の直上にあるかを判定し、その間隔を返す
+func rubyLineGap(ruby, base BoundingBox) (int, bool) {
+	if float64(ruby.Height) > rubyHeightRatio*float64(base.Height) {
+		return 0, false
+	}
+
+	// 水平方向に重なっていること
+	if ruby.X+ruby.Width <= base.X || base.X+base.Width <= ruby.X {
+		return 0, false
+	}
+
+	gap := base.Y - (ruby.Y + ruby.Height)
+	if float64(gap) < -rubyOverlapRatio*float64(base.Height) || float64(gap) > rubyMaxGapRatio*float64(base.Height) {
+		return 0, false
+	}
+
+	return max(gap, 0), true
+}
+
+// hanRuns は単語内の漢字の連続部分を返す
+func hanRuns(text string) []hanRun {
+	var runs []hanRun
+	start := -1
+	runes := []rune(text)
+	for i, r := range runes {
+		if isHan(r) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 {
+			runs = append(runs, hanRun{start: start, end: i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		runs = append(runs, hanRun{start: start, end: len(runes)})
+	}
+	return runs
+}
+
+// isReadingLine は行がすべて読み（かな・注音・ピンイン）で構成されているかを判定する
+func isReadingLine(line *Line) bool {
+	if len(line.Words) == 0 {
+		return false
+	}
+	for _, w := range line.Words {
+		if !isReadingText(w.Text) {
+			return false
+		}
+	}
+	return true
+}
+
+// isReadingText はテキストが読み（かな・注音符号・ピンイン）のみで構成されているかを判定する
+func isReadingText(text string) bool {
+	if strings.TrimSpace(text) == "" {
+		return false
+	}
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Hiragana, r),
+			unicode.Is(unicode.Katakana, r),
+			unicode.Is(unicode.Bopomofo, r),
+			unicode.Is(unicode.Latin, r),
+			unicode.Is(unicode.Mn, r), // ピンインの声調記号（結合文字）
+			r == 'ー', r == '・', r == ' ':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// hasHanWord は漢字を含む単語があるかを判定する
+func hasHanWord(words []Word) bool {
+	for _, w := range words {
+		if containsHan(w.Text) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsHan はテキストに漢字が含まれるかを判定する
+func containsHan(text string) bool {
+	for _, r := range text {
+		if isHan(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// isHan は漢字（踊り字「々」を含む）かを判定する
+func isHan(r rune) bool {
+	return unicode.Is(unicode.Han, r) || r == '々' || r == '〆' || r == 'ヶ'
+}
@@ -0,0 +1,118 @@
+package ocr
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newLayoutResult はテスト用に行ごとの単語からOCR結果を構築する
+func newLayoutResult(lines ...[]Word) *OCRResult {
+	var layout []layoutWord
+	for i, words := range lines {
+		for _, w := range words {
+			w.Confidence = 0.9
+			layout = append(layout, layoutWord{block: 0, line: i, word: w})
+		}
+	}
+
+	result := &OCRResult{Pages: []PageOCRResult{{PageNumber: 1}}}
+	result.Blocks, result.Lines, result.Words = buildLayout(layout)
+	result.Text = result.Blocks[0].Text
+	result.Pages[0].Text = result.Text
+	return result
+}
+
+func TestSeparateRuby_RubyLine(t *testing.T) {
+	result := newLayoutResult(
+		[]Word{
+			{Text: "にほんご", BoundingBox: BoundingBox{X: 10, Y: 0, Width: 60, Height: 12}},
+			{Text: "べんきょう", BoundingBox: BoundingBox{X: 110, Y: 0, Width: 70, Height: 12}},
+		},
+		[]Word{
+			{Text: "日本語を勉強する", BoundingBox: BoundingBox{X: 10, Y: 16, Width: 240, Height: 30}},
+		},
+	)
+	require.Equal(t, "にほんごべんきょう\n日本語を勉強する", result.Text)
+
+	assert.True(t, SeparateRuby(result))
+	assert.True(t, result.HasRuby)
+	assert.Equal(t, "日本語を勉強する", result.Text)
+	assert.Equal(t, "日本語を勉強する", result.Pages[0].Text)
+
+	require.Len(t, result.Lines, 1)
+	require.Len(t, result.Words, 1)
+	assert.Equal(t, []Ruby{
+		{Base: "日本語", Reading: "にほんご", Offset: 0},
+		{Base: "勉強", Reading: "べんきょう", Offset: 4},
+	}, result.Words[0].Ruby)
+	assert.Equal(t, result.Words[0].Ruby, result.Blocks[0].Lines[0].Words[0].Ruby)
+}
+
+func TestSeparateRuby_MixedIntoLine(t *testing.T) {
+	result := newLayoutResult([]Word{
+		{Text: "漢", BoundingBox: BoundingBox{X: 10, Y: 20, Width: 30, Height: 30}},
+		{Text: "かん", BoundingBox: BoundingBox{X: 12, Y: 5, Width: 26, Height: 12}},
+		{Text: "字", BoundingBox: BoundingBox{X: 40, Y: 20, Width: 30, Height: 30}},
+		{Text: "じ", BoundingBox: BoundingBox{X: 48, Y: 5, Width: 14, Height: 12}},
+		{Text: "です", BoundingBox: BoundingBox{X: 70, Y: 20, Width: 60, Height: 30}},
+	})
+	require.Equal(t, "漢かん字じです", result.Text)
+
+	assert.True(t, SeparateRuby(result))
+	assert.Equal(t, "漢字です", result.Text)
+
+	require.Len(t, result.Words, 3)
+	assert.Equal(t, []Ruby{{Base: "漢", Reading: "かん"}}, result.Words[0].Ruby)
+	assert.Equal(t, []Ruby{{Base: "字", Reading: "じ"}}, result.Words[1].Ruby)
+	assert.Empty(t, result.Words[2].Ruby)
+	assert.Equal(t, BoundingBox{X: 10, Y: 20, Width: 120, Height: 30}, result.Lines[0].BoundingBox)
+}
+
+func TestSeparateRuby_Pinyin(t *testing.T) {
+	result := newLayoutResult(
+		[]Word{
+			{Text: "hàn", BoundingBox: BoundingBox{X: 12, Y: 0, Width: 24, Height: 12}},
+			{Text: "zì", BoundingBox: BoundingBox{X: 44, Y: 0, Width: 20, Height: 12}},
+		},
+		[]Word{
+			{Text: "汉字", BoundingBox: BoundingBox{X: 10, Y: 15, Width: 60, Height: 30}},
+		},
+	)
+
+	assert.True(t, SeparateRuby(result))
+	assert.Equal(t, "汉字", result.Text)
+	require.Len(t, result.Words, 1)
+	assert.Equal(t, []Ruby{{Base: "汉字", Reading: "hàn zì"}}, result.Words[0].Ruby)
+}
+
+func TestSeparateRuby_NoRuby(t *testing.T) {
+	// 漢字を含まない行や、同じ大きさのかなはルビとみなさない
+	result := newLayoutResult(
+		[]Word{
+			{Text: "Здравствуйте!", BoundingBox: BoundingBox{X: 10, Y: 0, Width: 150, Height: 30}},
+		},
+		[]Word{
+			{Text: "ひらがな", BoundingBox: BoundingBox{X: 10, Y: 40, Width: 120, Height: 30}},
+			{Text: "漢字", BoundingBox: BoundingBox{X: 130, Y: 40, Width: 60, Height: 30}},
+		},
+	)
+	text := result.Text
+
+	assert.False(t, SeparateRuby(result))
+	assert.False(t, result.HasRuby)
+	assert.Equal(t, text, result.Text)
+
+	// レイアウトがない場合は何もしない
+	assert.False(t, SeparateRuby(&OCRResult{Text: "漢字"}))
+	assert.False(t, SeparateRuby(nil))
+}
+
+func TestIsRubyLanguage(t *testing.T) {
+	assert.True(t, IsRubyLanguage("ja"))
+	assert.True(t, IsRubyLanguage("zh-TW"))
+	assert.True(t, IsRubyLanguage("ZH"))
+	assert.False(t, IsRubyLanguage("ru"))
+	assert.False(t, IsRubyLanguage(""))
+}
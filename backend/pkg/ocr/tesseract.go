@@ -1,21 +1,310 @@
 package ocr
 
 import (
+	"bufio"
+	"bytes"
 	"context"
 	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"unicode"
 )
 
+// defaultTesseractBinary はデフォルトのTesseract実行ファイル名
+const defaultTesseractBinary = "tesseract"
+
+// tesseractLanguageCodes はISO 639-1コードからTesseractの言語コードへの対応表
+var tesseractLanguageCodes = map[string]string{
+	"ar":      "ara",
+	"de":      "deu",
+	"en":      "eng",
+	"es":      "spa",
+	"fa":      "fas",
+	"fr":      "fra",
+	"he":      "heb",
+	"hi":      "hin",
+	"it":      "ita",
+	"ja":      "jpn",
+	"ko":      "kor",
+	"pt":      "por",
+	"ru":      "rus",
+	"th":      "tha",
+	"tr":      "tur",
+	"uk":      "ukr",
+	"vi":      "vie",
+	"zh":      "chi_sim",
+	"zh-CN":   "chi_sim",
+	"zh-Hans": "chi_sim",
+	"zh-TW":   "chi_tra",
+	"zh-Hant": "chi_tra",
+}
+
 // TesseractClient はTesseract OCRクライアント
-type TesseractClient struct{}
+// ローカルにインストールされたtesseractコマンドをTSV出力モードで実行する
+type TesseractClient struct {
+	binaryPath  string
+	pageSegMode int
+}
 
 // NewTesseractClient は新しいTesseract OCRクライアントを作成する
-func NewTesseractClient() *TesseractClient {
-	return &TesseractClient{}
+// binaryPathが空の場合はPATH上のtesseractを使用する
+func NewTesseractClient(binaryPath string) *TesseractClient {
+	if binaryPath == "" {
+		binaryPath = defaultTesseractBinary
+	}
+
+	return &TesseractClient{
+		binaryPath:  binaryPath,
+		pageSegMode: 3, // 完全自動のページ分割（OSDなし）
+	}
 }
 
 // ProcessImage は画像データをOCR処理する
 func (t *TesseractClient) ProcessImage(ctx context.Context, imageData []byte, languages []string) (*OCRResult, error) {
-	// TODO: Tesseract OCRの実装
-	// 現在はスタブ実装
-	return nil, fmt.Errorf("Tesseract OCR not yet implemented")
+	if len(imageData) == 0 {
+		return nil, fmt.Errorf("image data is empty")
+	}
+
+	args := []string{
+		"stdin", "stdout",
+		"-l", tesseractLanguageArg(languages),
+		"--psm", strconv.Itoa(t.pageSegMode),
+		"tsv",
+	}
+
+	cmd := exec.CommandContext(ctx, t.binaryPath, args...)
+	cmd.Stdin = bytes.NewReader(imageData)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("tesseract cancelled: %w", ctx.Err())
+		}
+		return nil, fmt.Errorf("tesseract execution failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	result, err := parseTesseractTSV(stdout.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	// Tesseractは言語検出を行わないため、指定された最初の言語を検出言語とする
+	if len(languages) > 0 {
+		result.DetectedLanguage = languages[0]
+	}
+
+	return result, nil
+}
+
+// tesseractLanguageArg は言語リストをTesseractの -l 引数形式（例: rus+eng）に変換する
+func tesseractLanguageArg(languages []string) string {
+	codes := make([]string, 0, len(languages))
+	seen := make(map[string]bool)
+
+	for _, lang := range languages {
+		code, ok := tesseractLanguageCodes[lang]
+		if !ok {
+			// 既にTesseract形式（3文字コード等）で指定されている場合はそのまま使用
+			if len(lang) < 3 {
+				continue
+			}
+			code = lang
+		}
+		if seen[code] {
+			continue
+		}
+		seen[code] = true
+		codes = append(codes, code)
+	}
+
+	if len(codes) == 0 {
+		return "eng"
+	}
+
+	return strings.Join(codes, "+")
+}
+
+// tesseractWord はTSV出力の単語行を表す
+type tesseractWord struct {
+	page       int
+	block      int
+	paragraph  int
+	line       int
+	text       string
+	confidence float64
+	box        BoundingBox
+}
+
+// parseTesseractTSV はTesseractのTSV出力をOCRResultに変換する
+func parseTesseractTSV(data []byte) (*OCRResult, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	var words []tesseractWord
+	header := true
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if header {
+			header = false
+			if strings.HasPrefix(line, "level") {
+				continue
+			}
+		}
+
+		fields := strings.Split(line, "\t")
+		if len(fields) < 12 {
+			continue
+		}
+
+		// level 5 が単語レベル
+		if fields[0] != "5" {
+			continue
+		}
+
+		text := strings.TrimSpace(fields[11])
+		if text == "" {
+			continue
+		}
+
+		conf, err := strconv.ParseFloat(fields[10], 64)
+		if err != nil || conf < 0 {
+			continue
+		}
+
+		page, _ := strconv.Atoi(fields[1])
+		block, _ := strconv.Atoi(fields[2])
+		paragraph, _ := strconv.Atoi(fields[3])
+		lineNum, _ := strconv.Atoi(fields[4])
+		left, _ := strconv.Atoi(fields[6])
+		top, _ := strconv.Atoi(fields[7])
+		width, _ := strconv.Atoi(fields[8])
+		height, _ := strconv.Atoi(fields[9])
+
+		words = append(words, tesseractWord{
+			page:       page,
+			block:      block,
+			paragraph:  paragraph,
+			line:       lineNum,
+			text:       text,
+			confidence: conf / 100.0,
+			box:        BoundingBox{X: left, Y: top, Width: width, Height: height},
+		})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read tesseract output: %w", err)
+	}
+
+	result := &OCRResult{
+		Pages: []PageOCRResult{},
+	}
+
+	if len(words) == 0 {
+		return result, nil
+	}
+
+	var (
+		pageTexts   []string
+		totalConf   float64
+		pageConf    float64
+		pageWords   int
+		builder     strings.Builder
+		currentPage = words[0].page
+		prev        *tesseractWord
+		layout      = make([]layoutWord, 0, len(words))
+		blockSeq    int
+		lineSeq     int
+	)
+
+	flushPage := func() {
+		text := builder.String()
+		confidence := 0.0
+		if pageWords > 0 {
+			confidence = pageConf / float64(pageWords)
+		}
+		result.Pages = append(result.Pages, PageOCRResult{
+			PageNumber: currentPage,
+			Text:       text,
+			Confidence: confidence,
+		})
+		pageTexts = append(pageTexts, text)
+		builder.Reset()
+		pageConf = 0
+		pageWords = 0
+		prev = nil
+	}
+
+	for i := range words {
+		w := &words[i]
+		if w.page != currentPage {
+			flushPage()
+			currentPage = w.page
+		}
+
+		if prev != nil {
+			switch {
+			case w.block != prev.block || w.paragraph != prev.paragraph:
+				builder.WriteString("\n\n")
+				blockSeq++
+				lineSeq++
+			case w.line != prev.line:
+				builder.WriteString("\n")
+				lineSeq++
+			case needsSpace(prev.text, w.text):
+				builder.WriteString(" ")
+			}
+		} else if len(layout) > 0 {
+			// 新しいページは常に新しいブロックとして扱う
+			blockSeq++
+			lineSeq++
+		}
+		builder.WriteString(w.text)
+
+		layout = append(layout, layoutWord{
+			block: blockSeq,
+			line:  lineSeq,
+			word: Word{
+				Text:        w.text,
+				Confidence:  w.confidence,
+				BoundingBox: w.box,
+			},
+		})
+
+		pageConf += w.confidence
+		totalConf += w.confidence
+		pageWords++
+		prev = w
+	}
+	flushPage()
+
+	result.Text = strings.Join(pageTexts, "\n\n")
+	result.Confidence = totalConf / float64(len(words))
+	result.Blocks, result.Lines, result.Words = buildLayout(layout)
+
+	return result, nil
+}
+
+// needsSpace は2つの単語の間に空白が必要かを判定する
+// 日本語・中国語などの分かち書きしない文字同士は空白なしで連結する
+func needsSpace(prev, next string) bool {
+	last := []rune(prev)
+	first := []rune(next)
+	if len(last) == 0 || len(first) == 0 {
+		return false
+	}
+	return !(isCJK(last[len(last)-1]) && isCJK(first[0]))
+}
+
+// isCJK は文字が分かち書きしない文字体系（漢字・かな・全角記号）かを判定する
+func isCJK(r rune) bool {
+	return unicode.Is(unicode.Han, r) ||
+		unicode.Is(unicode.Hiragana, r) ||
+		unicode.Is(unicode.Katakana, r) ||
+		(r >= 0x3000 && r <= 0x303F) || // CJK記号・句読点
+		(r >= 0xFF00 && r <= 0xFFEF) // 全角形
 }
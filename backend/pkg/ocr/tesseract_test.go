@@ -0,0 +1,116 @@
+package ocr
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const sampleTesseractTSV = "level\tpage_num\tblock_num\tpar_num\tline_num\tword_num\tleft\ttop\twidth\theight\tconf\ttext\n" +
+	"1\t1\t0\t0\t0\t0\t0\t0\t800\t600\t-1\t\n" +
+	"2\t1\t1\t0\t0\t0\t10\t10\t300\t60\t-1\t\n" +
+	"4\t1\t1\t1\t1\t0\t10\t10\t300\t30\t-1\t\n" +
+	"5\t1\t1\t1\t1\t1\t10\t10\t150\t30\t96.5\tЗдравствуйте!\n" +
+	"5\t1\t1\t1\t1\t2\t170\t10\t50\t30\t93.5\tКак\n" +
+	"5\t1\t1\t1\t1\t3\t230\t10\t70\t30\t90\tдела?\n" +
+	"5\t1\t1\t1\t2\t1\t10\t50\t100\t30\t80\tこんにちは\n" +
+	"5\t1\t1\t1\t2\t2\t110\t50\t40\t30\t80\t！\n" +
+	"5\t2\t1\t1\t1\t1\t10\t10\t80\t30\t70\tHello\n"
+
+func TestParseTesseractTSV(t *testing.T) {
+	result, err := parseTesseractTSV([]byte(sampleTesseractTSV))
+	require.NoError(t, err)
+	require.Len(t, result.Pages, 2)
+
+	assert.Equal(t, "Здравствуйте! Как дела?\nこんにちは！", result.Pages[0].Text)
+	assert.Equal(t, 1, result.Pages[0].PageNumber)
+	assert.InDelta(t, 0.88, result.Pages[0].Confidence, 0.001)
+
+	assert.Equal(t, "Hello", result.Pages[1].Text)
+	assert.Equal(t, 2, result.Pages[1].PageNumber)
+	assert.InDelta(t, 0.70, result.Pages[1].Confidence, 0.001)
+
+	assert.Equal(t, "Здравствуйте! Как дела?\nこんにちは！\n\nHello", result.Text)
+	assert.InDelta(t, 0.85, result.Confidence, 0.001)
+
+	// レイアウト（ページごとに別ブロック）
+	require.Len(t, result.Blocks, 2)
+	require.Len(t, result.Lines, 3)
+	require.Len(t, result.Words, 6)
+	assert.Equal(t, "Здравствуйте! Как дела?", result.Lines[0].Text)
+	assert.Equal(t, BoundingBox{X: 10, Y: 10, Width: 290, Height: 30}, result.Lines[0].BoundingBox)
+	assert.Equal(t, BoundingBox{X: 170, Y: 10, Width: 50, Height: 30}, result.Words[1].BoundingBox)
+	assert.Equal(t, "Hello", result.Blocks[1].Text)
+}
+
+func TestParseTesseractTSV_Empty(t *testing.T) {
+	result, err := parseTesseractTSV([]byte("level\tpage_num\tblock_num\tpar_num\tline_num\tword_num\tleft\ttop\twidth\theight\tconf\ttext\n"))
+	require.NoError(t, err)
+
+	assert.Empty(t, result.Text)
+	assert.Equal(t, 0.0, result.Confidence)
+	assert.Empty(t, result.Pages)
+}
+
+func TestTesseractLanguageArg(t *testing.T) {
+	tests := []struct {
+		name      string
+		languages []string
+		want      string
+	}{
+		{name: "default", languages: nil, want: "eng"},
+		{name: "iso codes", languages: []string{"ru", "ja"}, want: "rus+jpn"},
+		{name: "tesseract codes", languages: []string{"chi_tra"}, want: "chi_tra"},
+		{name: "duplicates", languages: []string{"zh", "zh-CN"}, want: "chi_sim"},
+		{name: "unknown iso code", languages: []string{"xx", "en"}, want: "eng"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tesseractLanguageArg(tt.languages))
+		})
+	}
+}
+
+func TestTesseractClient_ProcessImage(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake tesseract binary requires a POSIX shell")
+	}
+
+	tmpDir := t.TempDir()
+	argsFile := filepath.Join(tmpDir, "args")
+	tsvFile := filepath.Join(tmpDir, "out.tsv")
+	require.NoError(t, os.WriteFile(tsvFile, []byte(sampleTesseractTSV), 0644))
+
+	script := "#!/bin/sh\necho \"$@\" > " + argsFile + "\ncat > /dev/null\ncat " + tsvFile + "\n"
+	binary := filepath.Join(tmpDir, "tesseract")
+	require.NoError(t, os.WriteFile(binary, []byte(script), 0755))
+
+	client := NewTesseractClient(binary)
+	result, err := client.ProcessImage(context.Background(), []byte("image"), []string{"ru", "ja"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "ru", result.DetectedLanguage)
+	assert.Len(t, result.Pages, 2)
+	assert.Greater(t, result.Confidence, 0.0)
+
+	args, err := os.ReadFile(argsFile)
+	require.NoError(t, err)
+	assert.Equal(t, "stdin stdout -l rus+jpn --psm 3 tsv", strings.TrimSpace(string(args)))
+}
+
+func TestTesseractClient_ProcessImage_Errors(t *testing.T) {
+	client := NewTesseractClient(filepath.Join(t.TempDir(), "missing-tesseract"))
+
+	_, err := client.ProcessImage(context.Background(), nil, []string{"en"})
+	assert.Error(t, err)
+
+	_, err = client.ProcessImage(context.Background(), []byte("image"), []string{"en"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,185 @@
+package pdf
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// DefaultDPI はページをラスタライズする際のデフォルト解像度（OCRに十分な300dpi）
+const DefaultDPI = 300
+
+// minTextLayerChars はテキストレイヤーありと判定する最小文字数（空白を除く）
+const minTextLayerChars = 20
+
+var (
+	// ErrInvalidPDF はPDFとして解析できないエラー
+	ErrInvalidPDF = errors.New("invalid PDF file")
+	// ErrPageOutOfRange はページ番号が範囲外のエラー
+	ErrPageOutOfRange = errors.New("page number out of range")
+)
+
+// Extractor はPDFからページ数・テキストレイヤー・ページ画像を取り出すインターフェース
+type Extractor interface {
+	// PageCount はPDFのページ数を返す
+	PageCount(ctx context.Context, path string) (int, error)
+
+	// ExtractText は指定ページの埋め込みテキストレイヤーを返す（ページ番号は1始まり）
+	ExtractText(ctx context.Context, path string, page int) (string, error)
+
+	// RenderPage は指定ページをPNG画像にラスタライズする（ページ番号は1始まり）
+	RenderPage(ctx context.Context, path string, page int, dpi int) ([]byte, error)
+}
+
+// PopplerExtractor はPoppler付属のコマンド（pdfinfo, pdftotext, pdftoppm）を使用するExtractor
+type PopplerExtractor struct {
+	pdfinfoPath   string
+	pdftotextPath string
+	pdftoppmPath  string
+}
+
+// NewPopplerExtractor は新しいPopplerExtractorを作成する
+// binDirが空の場合はPATH上のコマンドを使用する
+func NewPopplerExtractor(binDir string) *PopplerExtractor {
+	resolve := func(name string) string {
+		if binDir == "" {
+			return name
+		}
+		return filepath.Join(binDir, name)
+	}
+
+	return &PopplerExtractor{
+		pdfinfoPath:   resolve("pdfinfo"),
+		pdftotextPath: resolve("pdftotext"),
+		pdftoppmPath:  resolve("pdftoppm"),
+	}
+}
+
+// PageCount はPDFのページ数を返す
+func (p *PopplerExtractor) PageCount(ctx context.Context, path string) (int, error) {
+	output, err := run(ctx, p.pdfinfoPath, path)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrInvalidPDF, err)
+	}
+
+	return parsePageCount(output)
+}
+
+// ExtractText は指定ページの埋め込みテキストレイヤーを返す
+func (p *PopplerExtractor) ExtractText(ctx context.Context, path string, page int) (string, error) {
+	if page < 1 {
+		return "", ErrPageOutOfRange
+	}
+
+	pageArg := strconv.Itoa(page)
+	output, err := run(ctx, p.pdftotextPath, "-f", pageArg, "-l", pageArg, "-layout", "-enc", "UTF-8", path, "-")
+	if err != nil {
+		return "", fmt.Errorf("pdftotext failed: %w", err)
+	}
+
+	return normalizeText(string(output)), nil
+}
+
+// RenderPage は指定ページをPNG画像にラスタライズする
+func (p *PopplerExtractor) RenderPage(ctx context.Context, path string, page int, dpi int) ([]byte, error) {
+	if page < 1 {
+		return nil, ErrPageOutOfRange
+	}
+	if dpi <= 0 {
+		dpi = DefaultDPI
+	}
+
+	pageArg := strconv.Itoa(page)
+	output, err := run(ctx, p.pdftoppmPath, "-f", pageArg, "-l", pageArg, "-r", strconv.Itoa(dpi), "-png", "-singlefile", path)
+	if err != nil {
+		return nil, fmt.Errorf("pdftoppm failed: %w", err)
+	}
+	if len(output) == 0 {
+		return nil, ErrPageOutOfRange
+	}
+
+	return output, nil
+}
+
+// HasTextLayer はテキストがOCR不要とみなせる十分なテキストレイヤーかを判定する
+func HasTextLayer(text string) bool {
+	count := 0
+	for _, r := range text {
+		if !unicode.IsSpace(r) {
+			count++
+			if count >= minTextLayerChars {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// run はコマンドを実行して標準出力を返す
+func run(ctx context.Context, name string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("%s: %w: %s", filepath.Base(name), err, strings.TrimSpace(stderr.String()))
+	}
+
+	return stdout.Bytes(), nil
+}
+
+// parsePageCount はpdfinfoの出力からページ数を取得する
+func parsePageCount(output []byte) (int, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "Pages:") {
+			continue
+		}
+
+		count, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "Pages:")))
+		if err != nil {
+			return 0, fmt.Errorf("%w: invalid page count", ErrInvalidPDF)
+		}
+		return count, nil
+	}
+
+	return 0, fmt.Errorf("%w: page count not found", ErrInvalidPDF)
+}
+
+// normalizeText はpdftotextの出力を整形する
+// ページ区切り（フォームフィード）と行末の空白を取り除き、連続する空行を1行にまとめる
+func normalizeText(text string) string {
+	text = strings.ReplaceAll(text, "\f", "")
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+
+	lines := strings.Split(text, "\n")
+	normalized := make([]string, 0, len(lines))
+	blank := false
+	for _, line := range lines {
+		line = strings.TrimRightFunc(line, unicode.IsSpace)
+		if line == "" {
+			if blank || len(normalized) == 0 {
+				continue
+			}
+			blank = true
+		} else {
+			blank = false
+		}
+		normalized = append(normalized, line)
+	}
+
+	return strings.TrimSpace(strings.Join(normalized, "\n"))
+}
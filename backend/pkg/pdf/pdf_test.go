@@ -0,0 +1,82 @@
+package pdf
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeFakeBinary はテスト用の偽コマンドを作成する
+func writeFakeBinary(t *testing.T, dir, name, script string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"+script), 0755))
+}
+
+func TestParsePageCount(t *testing.T) {
+	output := "Title:          Русский язык\nProducer:       LaTeX\nPages:          12\nEncrypted:      no\n"
+
+	count, err := parsePageCount([]byte(output))
+	require.NoError(t, err)
+	assert.Equal(t, 12, count)
+
+	_, err = parsePageCount([]byte("Title: x\n"))
+	assert.ErrorIs(t, err, ErrInvalidPDF)
+}
+
+func TestNormalizeText(t *testing.T) {
+	text := "Урок 1   \r\n\n\n\nЗдравствуйте!  \n\f"
+	assert.Equal(t, "Урок 1\n\nЗдравствуйте!", normalizeText(text))
+	assert.Empty(t, normalizeText("\f\n  \n"))
+}
+
+func TestHasTextLayer(t *testing.T) {
+	assert.False(t, HasTextLayer(""))
+	assert.False(t, HasTextLayer("  12  \n "))
+	assert.True(t, HasTextLayer("Здравствуйте! Как у вас дела?"))
+}
+
+func TestPopplerExtractor(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake poppler binaries require a POSIX shell")
+	}
+
+	binDir := t.TempDir()
+	argsFile := filepath.Join(binDir, "args")
+	writeFakeBinary(t, binDir, "pdfinfo", "echo 'Pages:          3'\n")
+	writeFakeBinary(t, binDir, "pdftotext", "echo \"$@\" > "+argsFile+"\nprintf 'Страница 1\\n\\f'\n")
+	writeFakeBinary(t, binDir, "pdftoppm", "printf 'PNGDATA'\n")
+
+	extractor := NewPopplerExtractor(binDir)
+	ctx := context.Background()
+
+	count, err := extractor.PageCount(ctx, "book.pdf")
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+
+	text, err := extractor.ExtractText(ctx, "book.pdf", 2)
+	require.NoError(t, err)
+	assert.Equal(t, "Страница 1", text)
+
+	args, err := os.ReadFile(argsFile)
+	require.NoError(t, err)
+	assert.Equal(t, "-f 2 -l 2 -layout -enc UTF-8 book.pdf -\n", string(args))
+
+	image, err := extractor.RenderPage(ctx, "book.pdf", 1, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("PNGDATA"), image)
+
+	_, err = extractor.RenderPage(ctx, "book.pdf", 0, DefaultDPI)
+	assert.ErrorIs(t, err, ErrPageOutOfRange)
+}
+
+func TestPopplerExtractor_MissingBinary(t *testing.T) {
+	extractor := NewPopplerExtractor(t.TempDir())
+
+	_, err := extractor.PageCount(context.Background(), "book.pdf")
+	assert.ErrorIs(t, err, ErrInvalidPDF)
+}
@@ -0,0 +1,271 @@
+package srs
+
+import (
+	"errors"
+	"math"
+	"time"
+)
+
+// FSRS（Free Spaced Repetition Scheduler）v4.5
+// 記憶の安定度（stability: 想起確率が90%に下がるまでの日数）と難易度（difficulty: 1-10）を復習ごとに更新し、
+// 目標の想起率（desired retention）を下回る直前に次の復習を設定する
+
+// FSRSWeightCount はFSRSのパラメータ（重み）の数
+const FSRSWeightCount = 17
+
+const (
+	// DefaultDesiredRetention は目標の想起率
+	DefaultDesiredRetention = 0.9
+	// DefaultMaximumInterval は復習間隔の上限（日数）
+	DefaultMaximumInterval = 36500
+
+	fsrsDecay        = -0.5
+	fsrsFactor       = 19.0 / 81.0 // R(S, S) = 0.9 となる係数
+	fsrsMinStability = 0.01
+	fsrsMinDiff      = 1.0
+	fsrsMaxDiff      = 10.0
+)
+
+// DefaultFSRSWeights は大規模な復習ログから学習されたFSRS v4.5のデフォルトの重み
+var DefaultFSRSWeights = []float64{
+	0.4872, 1.4003, 3.7145, 13.8206, 5.1618, 1.2298, 0.8975, 0.031,
+	1.6474, 0.1367, 1.0461, 2.1072, 0.0793, 0.3246, 1.587, 0.2272, 2.8755,
+}
+
+// ErrInvalidFSRSParameters はFSRSのパラメータが不正な場合のエラー
+var ErrInvalidFSRSParameters = errors.New("invalid fsrs parameters")
+
+// Rating はFSRSの評価（1: もう一度, 2: 難しい, 3: 普通, 4: 簡単）
+type Rating int
+
+const (
+	RatingAgain Rating = 1
+	RatingHard  Rating = 2
+	RatingGood  Rating = 3
+	RatingEasy  Rating = 4
+)
+
+// ScoreToRating はスコア（0-100）をFSRSの評価に変換する
+// 50点未満は想起に失敗したものとして扱う（SM-2の品質3未満と同じ基準）
+func ScoreToRating(score int) Rating {
+	switch {
+	case score >= 90:
+		return RatingEasy
+	case score >= 70:
+		return RatingGood
+	case score >= 50:
+		return RatingHard
+	default:
+		return RatingAgain
+	}
+}
+
+// RatingToScore は評価をスコア（0-100）に変換する（ScoreToRating の逆変換）
+// 外部の復習ログ（Ankiの回答ボタンなど）を復習履歴として取り込む場合に使用する
+func RatingToScore(rating Rating) int {
+	switch rating {
+	case RatingEasy:
+		return 95
+	case RatingGood:
+		return 80
+	case RatingHard:
+		return 60
+	default:
+		return 30
+	}
+}
+
+// FSRSParameters はFSRSのパラメータ
+type FSRSParameters struct {
+	Weights          []float64
+	DesiredRetention float64
+	MaximumInterval  int
+}
+
+// DefaultFSRSParameters はデフォルトのFSRSのパラメータを返す
+func DefaultFSRSParameters() FSRSParameters {
+	weights := make([]float64, FSRSWeightCount)
+	copy(weights, DefaultFSRSWeights)
+	return FSRSParameters{
+		Weights:          weights,
+		DesiredRetention: DefaultDesiredRetention,
+		MaximumInterval:  DefaultMaximumInterval,
+	}
+}
+
+// FSRSScheduler はFSRSアルゴリズムのスケジューラー
+type FSRSScheduler struct {
+	params FSRSParameters
+}
+
+// NewFSRSScheduler はパラメータを指定してFSRSのスケジューラーを作成する
+func NewFSRSScheduler(params FSRSParameters) (*FSRSScheduler, error) {
+	if len(params.Weights) != FSRSWeightCount {
+		return nil, ErrInvalidFSRSParameters
+	}
+	if params.DesiredRetention <= 0 || params.DesiredRetention >= 1 {
+		return nil, ErrInvalidFSRSParameters
+	}
+	if params.MaximumInterval <= 0 {
+		params.MaximumInterval = DefaultMaximumInterval
+	}
+	return &FSRSScheduler{params: params}, nil
+}
+
+// Algorithm はアルゴリズムの種類を返す
+func (s *FSRSScheduler) Algorithm() Algorithm {
+	return AlgorithmFSRS
+}
+
+// Schedule は安定度と難易度を更新し、想起率が目標を下回る直前を次の復習日時にする
+// 想起に失敗した場合は他のアルゴリズムと同様に翌日に復習する
+func (s *FSRSScheduler) Schedule(state State, score int, now time.Time) State {
+	rating := ScoreToRating(score)
+	stability, difficulty := fsrsNextMemory(s.params.Weights, state, rating, now)
+
+	interval := 1
+	if rating != RatingAgain {
+		interval = s.interval(stability)
+	}
+
+	easeFactor := state.EaseFactor
+	if easeFactor == 0 {
+		easeFactor = DefaultEaseFactor
+	}
+
+	return State{
+		EaseFactor:   easeFactor,
+		IntervalDays: interval,
+		ReviewCount:  state.ReviewCount + 1,
+		NextReview:   now.AddDate(0, 0, interval),
+		LastReviewed: now,
+		Stability:    stability,
+		Difficulty:   difficulty,
+	}
+}
+
+// interval は想起率が目標に下がるまでの日数を返す
+func (s *FSRSScheduler) interval(stability float64) int {
+	days := stability / fsrsFactor * (math.Pow(s.params.DesiredRetention, 1/fsrsDecay) - 1)
+	interval := int(math.Round(days))
+	if interval < 1 {
+		interval = 1
+	}
+	if interval > s.params.MaximumInterval {
+		interval = s.params.MaximumInterval
+	}
+	return interval
+}
+
+// Retrievability は現在の想起確率（0-1）を推定する
+// FSRS以外のアルゴリズムで復習した項目は、直前の間隔の時点で想起率が90%になるとみなして推定する
+// まだ復習していない項目は推定できないため false を返す
+func Retrievability(state State, now time.Time) (float64, bool) {
+	if state.ReviewCount == 0 || state.LastReviewed.IsZero() {
+		return 0, false
+	}
+
+	stability := state.Stability
+	if stability <= 0 {
+		stability = float64(state.IntervalDays)
+	}
+	if stability <= 0 {
+		return 0, false
+	}
+
+	return fsrsForgettingCurve(elapsedDays(state.LastReviewed, now), stability), true
+}
+
+// fsrsNextMemory は復習結果を反映した安定度と難易度を返す
+func fsrsNextMemory(w []float64, state State, rating Rating, now time.Time) (float64, float64) {
+	if state.ReviewCount == 0 {
+		return fsrsInitStability(w, rating), fsrsInitDifficulty(w, rating)
+	}
+
+	stability, difficulty := state.Stability, state.Difficulty
+	if stability <= 0 {
+		// 他のアルゴリズムから切り替えた項目は、直前の間隔を安定度とみなす
+		stability = math.Max(float64(state.IntervalDays), fsrsMinStability)
+		difficulty = fsrsInitDifficulty(w, RatingGood)
+	}
+
+	elapsed := 0.0
+	if !state.LastReviewed.IsZero() {
+		elapsed = elapsedDays(state.LastReviewed, now)
+	}
+
+	return fsrsStep(w, stability, difficulty, elapsed, rating)
+}
+
+// fsrsStep は前回の安定度・難易度と経過日数から、復習後の安定度と難易度を計算する
+func fsrsStep(w []float64, stability, difficulty, elapsed float64, rating Rating) (float64, float64) {
+	retrievability := fsrsForgettingCurve(elapsed, stability)
+
+	var next float64
+	if rating == RatingAgain {
+		next = fsrsForgetStability(w, difficulty, stability, retrievability)
+	} else {
+		next = fsrsRecallStability(w, difficulty, stability, retrievability, rating)
+	}
+
+	return math.Max(next, fsrsMinStability), fsrsNextDifficulty(w, difficulty, rating)
+}
+
+// fsrsForgettingCurve は経過日数と安定度から想起確率を返す
+func fsrsForgettingCurve(elapsed, stability float64) float64 {
+	return math.Pow(1+fsrsFactor*elapsed/stability, fsrsDecay)
+}
+
+func fsrsInitStability(w []float64, rating Rating) float64 {
+	return math.Max(w[rating-1], fsrsMinStability)
+}
+
+func fsrsInitDifficulty(w []float64, rating Rating) float64 {
+	return clampDifficulty(w[4] - float64(rating-3)*w[5])
+}
+
+// fsrsNextDifficulty は評価に応じて難易度を更新し、「簡単」の初期難易度に向けて平均回帰させる
+func fsrsNextDifficulty(w []float64, difficulty float64, rating Rating) float64 {
+	next := difficulty - w[6]*float64(rating-3)
+	target := w[4] - w[5] // 「簡単」の初期難易度（クランプしない）
+	return clampDifficulty(w[7]*target + (1-w[7])*next)
+}
+
+// fsrsRecallStability は想起に成功した場合の安定度を返す
+func fsrsRecallStability(w []float64, difficulty, stability, retrievability float64, rating Rating) float64 {
+	hardPenalty, easyBonus := 1.0, 1.0
+	if rating == RatingHard {
+		hardPenalty = w[15]
+	}
+	if rating == RatingEasy {
+		easyBonus = w[16]
+	}
+
+	return stability * (1 + math.Exp(w[8])*
+		(11-difficulty)*
+		math.Pow(stability, -w[9])*
+		(math.Exp((1-retrievability)*w[10])-1)*
+		hardPenalty*easyBonus)
+}
+
+// fsrsForgetStability は想起に失敗した場合の安定度を返す（失敗前より大きくはしない）
+func fsrsForgetStability(w []float64, difficulty, stability, retrievability float64) float64 {
+	next := w[11] *
+		math.Pow(difficulty, -w[12]) *
+		(math.Pow(stability+1, w[13]) - 1) *
+		math.Exp((1-retrievability)*w[14])
+	return math.Min(next, stability)
+}
+
+func clampDifficulty(difficulty float64) float64 {
+	return math.Min(math.Max(difficulty, fsrsMinDiff), fsrsMaxDiff)
+}
+
+// elapsedDays は2つの時刻の間の日数（小数）を返す
+func elapsedDays(from, to time.Time) float64 {
+	days := to.Sub(from).Hours() / 24
+	if days < 0 {
+		return 0
+	}
+	return days
+}
@@ -0,0 +1,206 @@
+package srs
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"time"
+)
+
+// MinFSRSOptimizationReviews は最適化に必要な予測対象の復習数（各項目の2回目以降の復習の数）
+const MinFSRSOptimizationReviews = 100
+
+const (
+	fsrsOptimizerIterations   = 300
+	fsrsOptimizerLearningRate = 0.02
+	// fsrsOptimizerRegularization はデフォルトの重みから離れすぎないための正則化の強さ
+	// 復習ログが少ないユーザーでも過学習しにくくする
+	fsrsOptimizerRegularization = 0.01
+)
+
+// fsrsWeightBounds は最適化時の各重みの範囲
+var fsrsWeightBounds = [FSRSWeightCount][2]float64{
+	{0.1, 100}, {0.1, 100}, {0.1, 100}, {0.1, 100}, // 初期安定度
+	{1, 10}, {0.1, 5}, {0.1, 5}, {0, 0.75}, // 難易度
+	{0, 4}, {0, 0.8}, {0.01, 3}, // 想起成功時の安定度
+	{0.2, 6}, {0.01, 0.4}, {0.01, 0.9}, {0.01, 4}, // 想起失敗時の安定度
+	{0, 1}, {1, 6}, // 「難しい」のペナルティ、「簡単」のボーナス
+}
+
+// ErrNotEnoughReviews は最適化に必要な復習ログが足りない場合のエラー
+var ErrNotEnoughReviews = errors.New("not enough reviews to optimize fsrs parameters")
+
+// FSRSReview は最適化に使う1回分の復習記録
+type FSRSReview struct {
+	ItemID     string
+	Score      int
+	ReviewedAt time.Time
+}
+
+// FSRSOptimizationResult は最適化の結果
+type FSRSOptimizationResult struct {
+	Weights     []float64 `json:"weights"`
+	ReviewCount int       `json:"review_count"` // 予測対象の復習数
+	InitialLoss float64   `json:"initial_loss"` // 最適化前の対数損失
+	Loss        float64   `json:"loss"`         // 最適化後の対数損失
+}
+
+// fsrsSequence は1項目分の復習の評価と前回からの経過日数
+type fsrsSequence struct {
+	ratings []Rating
+	elapsed []float64
+}
+
+// OptimizeFSRS は復習ログからFSRSの重みを最適化する
+// 各項目の2回目以降の復習について、前回までの復習から予測した想起確率と実際の想起の成否の対数損失を
+// 最小化するように、initial（空の場合はデフォルト）から勾配法で重みを調整する
+func OptimizeFSRS(reviews []FSRSReview, initial []float64) (*FSRSOptimizationResult, error) {
+	if len(initial) == 0 {
+		initial = DefaultFSRSWeights
+	}
+	if len(initial) != FSRSWeightCount {
+		return nil, ErrInvalidFSRSParameters
+	}
+
+	sequences, count := buildFSRSSequences(reviews)
+	if count < MinFSRSOptimizationReviews {
+		return nil, ErrNotEnoughReviews
+	}
+
+	weights := make([]float64, FSRSWeightCount)
+	copy(weights, initial)
+	clampWeights(weights)
+
+	objective := func(w []float64) float64 {
+		return fsrsLogLoss(w, sequences, count) + fsrsRegularization(w)
+	}
+
+	initialLoss := fsrsLogLoss(weights, sequences, count)
+	best := make([]float64, FSRSWeightCount)
+	copy(best, weights)
+	bestObjective := objective(weights)
+
+	// Adam（勾配は中心差分で近似する）
+	const beta1, beta2, epsilon = 0.9, 0.999, 1e-8
+	m := make([]float64, FSRSWeightCount)
+	v := make([]float64, FSRSWeightCount)
+	gradient := make([]float64, FSRSWeightCount)
+	probe := make([]float64, FSRSWeightCount)
+
+	for iteration := 1; iteration <= fsrsOptimizerIterations; iteration++ {
+		for i := range weights {
+			h := 1e-4 * math.Max(1, math.Abs(weights[i]))
+			copy(probe, weights)
+			probe[i] = weights[i] + h
+			upper := objective(probe)
+			probe[i] = weights[i] - h
+			lower := objective(probe)
+			gradient[i] = (upper - lower) / (2 * h)
+		}
+
+		for i := range weights {
+			m[i] = beta1*m[i] + (1-beta1)*gradient[i]
+			v[i] = beta2*v[i] + (1-beta2)*gradient[i]*gradient[i]
+			mHat := m[i] / (1 - math.Pow(beta1, float64(iteration)))
+			vHat := v[i] / (1 - math.Pow(beta2, float64(iteration)))
+			step := fsrsOptimizerLearningRate * math.Max(math.Abs(DefaultFSRSWeights[i]), 0.1)
+			weights[i] -= step * mHat / (math.Sqrt(vHat) + epsilon)
+		}
+		clampWeights(weights)
+
+		if value := objective(weights); value < bestObjective {
+			bestObjective = value
+			copy(best, weights)
+		}
+	}
+
+	return &FSRSOptimizationResult{
+		Weights:     best,
+		ReviewCount: count,
+		InitialLoss: initialLoss,
+		Loss:        fsrsLogLoss(best, sequences, count),
+	}, nil
+}
+
+// buildFSRSSequences は復習ログを項目ごとに時刻順に並べ、予測対象の復習数を返す
+func buildFSRSSequences(reviews []FSRSReview) ([]fsrsSequence, int) {
+	byItem := make(map[string][]FSRSReview)
+	var order []string
+	for _, review := range reviews {
+		if _, ok := byItem[review.ItemID]; !ok {
+			order = append(order, review.ItemID)
+		}
+		byItem[review.ItemID] = append(byItem[review.ItemID], review)
+	}
+
+	sequences := make([]fsrsSequence, 0, len(order))
+	count := 0
+	for _, itemID := range order {
+		history := byItem[itemID]
+		if len(history) < 2 {
+			continue
+		}
+		sort.SliceStable(history, func(i, j int) bool {
+			return history[i].ReviewedAt.Before(history[j].ReviewedAt)
+		})
+
+		sequence := fsrsSequence{
+			ratings: make([]Rating, len(history)),
+			elapsed: make([]float64, len(history)),
+		}
+		for i, review := range history {
+			sequence.ratings[i] = ScoreToRating(review.Score)
+			if i > 0 {
+				sequence.elapsed[i] = elapsedDays(history[i-1].ReviewedAt, review.ReviewedAt)
+			}
+		}
+		sequences = append(sequences, sequence)
+		count += len(history) - 1
+	}
+
+	return sequences, count
+}
+
+// fsrsLogLoss は予測した想起確率と実際の想起の成否の平均対数損失を返す
+func fsrsLogLoss(w []float64, sequences []fsrsSequence, count int) float64 {
+	if count == 0 {
+		return 0
+	}
+
+	total := 0.0
+	for _, sequence := range sequences {
+		stability := fsrsInitStability(w, sequence.ratings[0])
+		difficulty := fsrsInitDifficulty(w, sequence.ratings[0])
+
+		for i := 1; i < len(sequence.ratings); i++ {
+			predicted := fsrsForgettingCurve(sequence.elapsed[i], stability)
+			predicted = math.Min(math.Max(predicted, 1e-6), 1-1e-6)
+			if sequence.ratings[i] == RatingAgain {
+				total -= math.Log(1 - predicted)
+			} else {
+				total -= math.Log(predicted)
+			}
+
+			stability, difficulty = fsrsStep(w, stability, difficulty, sequence.elapsed[i], sequence.ratings[i])
+		}
+	}
+
+	return total / float64(count)
+}
+
+// fsrsRegularization はデフォルトの重みからの距離（各重みの範囲で正規化）に比例するペナルティを返す
+func fsrsRegularization(w []float64) float64 {
+	penalty := 0.0
+	for i, value := range w {
+		span := fsrsWeightBounds[i][1] - fsrsWeightBounds[i][0]
+		diff := (value - DefaultFSRSWeights[i]) / span
+		penalty += diff * diff
+	}
+	return fsrsOptimizerRegularization * penalty
+}
+
+func clampWeights(w []float64) {
+	for i := range w {
+		w[i] = math.Min(math.Max(w[i], fsrsWeightBounds[i][0]), fsrsWeightBounds[i][1])
+	}
+}
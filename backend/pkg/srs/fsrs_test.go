@@ -0,0 +1,146 @@
+package srs
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestScoreToRating はスコアからFSRSの評価への変換をテスト
+func TestScoreToRating(t *testing.T) {
+	assert.Equal(t, RatingEasy, ScoreToRating(95))
+	assert.Equal(t, RatingGood, ScoreToRating(70))
+	assert.Equal(t, RatingHard, ScoreToRating(50))
+	assert.Equal(t, RatingAgain, ScoreToRating(49))
+}
+
+func TestRatingToScore(t *testing.T) {
+	for _, rating := range []Rating{RatingAgain, RatingHard, RatingGood, RatingEasy} {
+		assert.Equal(t, rating, ScoreToRating(RatingToScore(rating)))
+	}
+}
+
+// TestFSRSScheduler はFSRSの安定度・難易度と間隔の更新をテスト
+func TestFSRSScheduler(t *testing.T) {
+	now := time.Date(2025, 11, 13, 12, 0, 0, 0, time.UTC)
+	scheduler, err := NewFSRSScheduler(DefaultFSRSParameters())
+	require.NoError(t, err)
+
+	// 初回は評価ごとの初期安定度（目標の想起率90%では間隔 = 安定度）
+	state := scheduler.Schedule(NewState(), 75, now)
+	assert.InDelta(t, DefaultFSRSWeights[2], state.Stability, 1e-9)
+	assert.InDelta(t, DefaultFSRSWeights[4], state.Difficulty, 1e-9)
+	assert.Equal(t, 4, state.IntervalDays)
+	assert.Equal(t, 1, state.ReviewCount)
+	assert.Equal(t, now, state.LastReviewed)
+
+	// 予定どおりに想起できると安定度が伸び、間隔が長くなる
+	next := scheduler.Schedule(state, 75, state.NextReview)
+	assert.Greater(t, next.Stability, state.Stability)
+	assert.Greater(t, next.IntervalDays, state.IntervalDays)
+
+	// 「簡単」は「普通」より間隔が長く、難易度が下がる
+	easy := scheduler.Schedule(state, 95, state.NextReview)
+	assert.Greater(t, easy.IntervalDays, next.IntervalDays)
+	assert.Less(t, easy.Difficulty, next.Difficulty)
+
+	// 想起に失敗すると安定度が下がり、翌日に復習する
+	forgot := scheduler.Schedule(next, 20, next.NextReview)
+	assert.Less(t, forgot.Stability, next.Stability)
+	assert.Greater(t, forgot.Difficulty, next.Difficulty)
+	assert.Equal(t, 1, forgot.IntervalDays)
+
+	// 目標の想起率を上げると間隔が短くなる
+	params := DefaultFSRSParameters()
+	params.DesiredRetention = 0.95
+	strict, err := NewFSRSScheduler(params)
+	require.NoError(t, err)
+	assert.Less(t, strict.Schedule(state, 75, state.NextReview).IntervalDays, next.IntervalDays)
+
+	// SM-2から切り替えた項目は直前の間隔を安定度とみなす
+	sm2 := SM2Scheduler{}.Schedule(SM2Scheduler{}.Schedule(NewState(), 90, now), 90, now)
+	switched := scheduler.Schedule(sm2, 75, sm2.NextReview)
+	assert.Greater(t, switched.Stability, float64(sm2.IntervalDays))
+
+	_, err = NewFSRSScheduler(FSRSParameters{Weights: []float64{1, 2}, DesiredRetention: 0.9})
+	assert.ErrorIs(t, err, ErrInvalidFSRSParameters)
+}
+
+// TestRetrievability は想起確率の推定をテスト
+func TestRetrievability(t *testing.T) {
+	now := time.Date(2025, 11, 13, 12, 0, 0, 0, time.UTC)
+
+	_, ok := Retrievability(NewState(), now)
+	assert.False(t, ok)
+
+	state := State{ReviewCount: 1, LastReviewed: now, Stability: 10}
+	r, ok := Retrievability(state, now)
+	require.True(t, ok)
+	assert.InDelta(t, 1.0, r, 1e-9)
+
+	// 安定度と同じ日数が経つと90%
+	r, _ = Retrievability(state, now.AddDate(0, 0, 10))
+	assert.InDelta(t, 0.9, r, 1e-9)
+
+	// FSRS以外で復習した項目は直前の間隔から推定する
+	r, ok = Retrievability(State{ReviewCount: 2, LastReviewed: now, IntervalDays: 6}, now.AddDate(0, 0, 6))
+	require.True(t, ok)
+	assert.InDelta(t, 0.9, r, 1e-9)
+}
+
+// TestOptimizeFSRS は復習ログからの重みの最適化をテスト
+func TestOptimizeFSRS(t *testing.T) {
+	start := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+
+	// デフォルトよりも忘れにくいユーザー（初期安定度が3倍）の復習ログを生成する
+	truth := DefaultFSRSParameters()
+	for i := 0; i < 4; i++ {
+		truth.Weights[i] *= 3
+	}
+	trueScheduler, err := NewFSRSScheduler(truth)
+	require.NoError(t, err)
+	scheduler, err := NewFSRSScheduler(DefaultFSRSParameters())
+	require.NoError(t, err)
+
+	random := rand.New(rand.NewSource(1))
+	var reviews []FSRSReview
+	for item := 0; item < 60; item++ {
+		itemID := string(rune('A'+item%26)) + string(rune('a'+item/26))
+		now := start.Add(time.Duration(item) * time.Hour)
+		state, trueState := NewState(), NewState()
+		score := 75
+		for review := 0; review < 5; review++ {
+			if review > 0 {
+				recall, _ := Retrievability(trueState, now)
+				score = 75
+				if random.Float64() > recall {
+					score = 20
+				}
+			}
+			reviews = append(reviews, FSRSReview{ItemID: itemID, Score: score, ReviewedAt: now})
+			trueState = trueScheduler.Schedule(trueState, score, now)
+			state = scheduler.Schedule(state, score, now)
+			now = state.NextReview
+		}
+	}
+
+	result, err := OptimizeFSRS(reviews, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 240, result.ReviewCount)
+	assert.Len(t, result.Weights, FSRSWeightCount)
+	assert.Less(t, result.Loss, result.InitialLoss)
+	assert.Greater(t, result.Weights[2], DefaultFSRSWeights[2]) // 「普通」の初期安定度が伸びる
+	for i, w := range result.Weights {
+		assert.False(t, math.IsNaN(w))
+		assert.GreaterOrEqual(t, w, fsrsWeightBounds[i][0])
+		assert.LessOrEqual(t, w, fsrsWeightBounds[i][1])
+	}
+
+	// 復習ログが足りない場合は最適化しない
+	_, err = OptimizeFSRS(reviews[:20], nil)
+	assert.ErrorIs(t, err, ErrNotEnoughReviews)
+}
@@ -0,0 +1,68 @@
+package srs
+
+import (
+	"hash/fnv"
+	"math"
+	"time"
+)
+
+// FuzzRange は間隔 interval（日数）に対して、次の復習日をずらしてよい範囲（日数）を返す
+// 間隔が長いほど広くし（7日未満: ±15%、20日未満: ±10%、それ以上: ±5%、最低1日）、2日以下の間隔はずらさない
+func FuzzRange(interval int) (int, int) {
+	if interval <= 2 {
+		return interval, interval
+	}
+
+	factor := 0.05
+	switch {
+	case interval < 7:
+		factor = 0.15
+	case interval < 20:
+		factor = 0.1
+	}
+
+	delta := int(math.Round(float64(interval) * factor))
+	if delta < 1 {
+		delta = 1
+	}
+
+	minInterval := interval - delta
+	if minInterval < 2 {
+		minInterval = 2
+	}
+	return minInterval, interval + delta
+}
+
+// DueLoad は復習予定日ごとの項目数を、今日からの日数をキーにして返す
+func DueLoad(nextReviews []time.Time, now time.Time) map[int]int {
+	load := make(map[int]int)
+	for _, nextReview := range nextReviews {
+		load[daysBetween(now, nextReview)]++
+	}
+	return load
+}
+
+// BalanceInterval は FuzzRange の範囲内で、復習予定の項目数（load）が最も少ない日の間隔を返す
+// 最も少ない日が複数ある場合は key（項目ID）から決まる日を選び、同時に作成した項目が同じ日に集中しないようにする
+func BalanceInterval(interval int, load map[int]int, key string) int {
+	minInterval, maxInterval := FuzzRange(interval)
+	if minInterval == maxInterval {
+		return interval
+	}
+
+	var candidates []int
+	minLoad := math.MaxInt
+	for days := minInterval; days <= maxInterval; days++ {
+		switch {
+		case load[days] < minLoad:
+			minLoad = load[days]
+			candidates = []int{days}
+		case load[days] == minLoad:
+			candidates = append(candidates, days)
+		}
+	}
+
+	hash := fnv.New32a()
+	hash.Write([]byte(key))
+	return candidates[int(hash.Sum32()%uint32(len(candidates)))]
+}
@@ -0,0 +1,63 @@
+package srs
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestFuzzRange は間隔をずらす範囲をテスト
+func TestFuzzRange(t *testing.T) {
+	tests := []struct {
+		interval int
+		min      int
+		max      int
+	}{
+		{1, 1, 1},
+		{2, 2, 2},
+		{3, 2, 4},
+		{6, 5, 7},
+		{14, 13, 15},
+		{30, 28, 32},
+		{100, 95, 105},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%d日", tt.interval), func(t *testing.T) {
+			minInterval, maxInterval := FuzzRange(tt.interval)
+			assert.Equal(t, tt.min, minInterval)
+			assert.Equal(t, tt.max, maxInterval)
+		})
+	}
+}
+
+// TestBalanceInterval は復習予定の少ない日への分散をテスト
+func TestBalanceInterval(t *testing.T) {
+	now := time.Date(2025, 11, 13, 12, 0, 0, 0, time.UTC)
+
+	// 30日後（28〜32日）のうち、予定の少ない31日後を選ぶ
+	load := DueLoad([]time.Time{
+		now.AddDate(0, 0, 28), now.AddDate(0, 0, 29), now.AddDate(0, 0, 30),
+		now.AddDate(0, 0, 30), now.AddDate(0, 0, 32),
+	}, now)
+	assert.Equal(t, 2, load[30])
+	assert.Equal(t, 31, BalanceInterval(30, load, "item"))
+
+	// ずらさない間隔はそのまま
+	assert.Equal(t, 2, BalanceInterval(2, load, "item"))
+
+	// 予定がない場合は同じ間隔の項目を範囲内に分散する
+	days := make(map[int]int)
+	for i := 0; i < 100; i++ {
+		interval := BalanceInterval(30, nil, fmt.Sprintf("item-%d", i))
+		assert.GreaterOrEqual(t, interval, 28)
+		assert.LessOrEqual(t, interval, 32)
+		days[interval]++
+	}
+	assert.Len(t, days, 5)
+
+	// 同じ項目は同じ日を選ぶ
+	assert.Equal(t, BalanceInterval(30, nil, "item-1"), BalanceInterval(30, nil, "item-1"))
+}
@@ -0,0 +1,183 @@
+package srs
+
+import (
+	"errors"
+	"math"
+	"time"
+)
+
+// Algorithm は復習間隔を計算するアルゴリズムの種類
+type Algorithm string
+
+const (
+	// AlgorithmSM2 は SuperMemo 2（容易度係数で間隔を伸ばす）
+	AlgorithmSM2 Algorithm = "sm2"
+	// AlgorithmLadder は固定の間隔（1/3/7/14/30/60日）をスコアで調整する
+	AlgorithmLadder Algorithm = "ladder"
+	// AlgorithmFSRS は記憶の安定度・難易度から想起率を予測する（ユーザーごとにパラメータを最適化できる）
+	AlgorithmFSRS Algorithm = "fsrs"
+
+	// DefaultAlgorithm はユーザーが選択していない場合のアルゴリズム
+	DefaultAlgorithm = AlgorithmSM2
+)
+
+const (
+	// DefaultEaseFactor は新しい復習項目の容易度係数
+	DefaultEaseFactor = 2.5
+	// MinEaseFactor は容易度係数の下限
+	MinEaseFactor = 1.3
+)
+
+// ErrUnknownAlgorithm は未対応のアルゴリズムが指定された場合のエラー
+var ErrUnknownAlgorithm = errors.New("unknown srs algorithm")
+
+// State は復習項目ごとのスケジューラーの状態
+// どのアルゴリズムも同じ状態を読み書きするため、途中でアルゴリズムを切り替えても履歴を引き継げる
+type State struct {
+	EaseFactor   float64   // 容易度係数（SM-2で使用）
+	IntervalDays int       // 直前に設定した復習間隔（日数）
+	ReviewCount  int       // これまでの復習回数
+	NextReview   time.Time // 次の復習日時
+	LastReviewed time.Time // 最後に復習した日時（未復習の場合はゼロ値）
+	Stability    float64   // 記憶の安定度（FSRSで使用、他のアルゴリズムでは0に戻す）
+	Difficulty   float64   // 難易度（FSRSで使用、他のアルゴリズムでは0に戻す）
+}
+
+// NewState は新しい復習項目の状態を返す
+func NewState() State {
+	return State{EaseFactor: DefaultEaseFactor}
+}
+
+// Scheduler は復習結果から次の復習日時を決めるアルゴリズム
+type Scheduler interface {
+	// Algorithm はアルゴリズムの種類を返す
+	Algorithm() Algorithm
+	// Schedule はスコア（0-100）の復習結果を反映した新しい状態を返す
+	Schedule(state State, score int, now time.Time) State
+}
+
+// Algorithms は選択できるアルゴリズムの一覧を返す
+func Algorithms() []Algorithm {
+	return []Algorithm{AlgorithmSM2, AlgorithmLadder, AlgorithmFSRS}
+}
+
+// NewScheduler はアルゴリズムに対応するスケジューラーを返す（空の場合はデフォルト）
+func NewScheduler(algorithm Algorithm) (Scheduler, error) {
+	switch algorithm {
+	case "", AlgorithmSM2:
+		return SM2Scheduler{}, nil
+	case AlgorithmLadder:
+		return LadderScheduler{}, nil
+	case AlgorithmFSRS:
+		return NewFSRSScheduler(DefaultFSRSParameters())
+	default:
+		return nil, ErrUnknownAlgorithm
+	}
+}
+
+// SM2Scheduler は SuperMemo 2 アルゴリズムのスケジューラー
+type SM2Scheduler struct{}
+
+// Algorithm はアルゴリズムの種類を返す
+func (SM2Scheduler) Algorithm() Algorithm {
+	return AlgorithmSM2
+}
+
+// Schedule は容易度係数を更新し、前回の間隔に掛けて次の間隔を決める
+// 失敗（品質3未満）した場合は1日後からやり直す
+func (SM2Scheduler) Schedule(state State, score int, now time.Time) State {
+	quality := float64(scoreToQuality(score))
+
+	easeFactor := state.EaseFactor
+	if easeFactor == 0 {
+		easeFactor = DefaultEaseFactor
+	}
+	easeFactor += 0.1 - (5-quality)*(0.08+(5-quality)*0.02)
+	if easeFactor < MinEaseFactor {
+		easeFactor = MinEaseFactor
+	}
+
+	var interval int
+	switch {
+	case quality < 3, state.IntervalDays == 0:
+		interval = 1
+	case state.IntervalDays == 1:
+		interval = 6
+	default:
+		interval = int(math.Round(float64(state.IntervalDays) * easeFactor))
+	}
+
+	return State{
+		EaseFactor:   easeFactor,
+		IntervalDays: interval,
+		ReviewCount:  state.ReviewCount + 1,
+		NextReview:   now.AddDate(0, 0, interval),
+		LastReviewed: now,
+	}
+}
+
+// LadderScheduler は復習回数ごとの固定の間隔をスコアで調整するスケジューラー
+type LadderScheduler struct{}
+
+// Algorithm はアルゴリズムの種類を返す
+func (LadderScheduler) Algorithm() Algorithm {
+	return AlgorithmLadder
+}
+
+// Schedule は復習回数に応じた基本間隔をスコアで調整する
+// 初回の1日を半分にすると当日になるため、間隔は最短でも1日にする
+// 容易度係数は使用しないため、そのまま引き継ぐ
+func (LadderScheduler) Schedule(state State, score int, now time.Time) State {
+	interval := max(AdjustInterval(GetBaseInterval(state.ReviewCount), score), 1)
+
+	easeFactor := state.EaseFactor
+	if easeFactor == 0 {
+		easeFactor = DefaultEaseFactor
+	}
+
+	return State{
+		EaseFactor:   easeFactor,
+		IntervalDays: interval,
+		ReviewCount:  state.ReviewCount + 1,
+		NextReview:   now.AddDate(0, 0, interval),
+		LastReviewed: now,
+	}
+}
+
+// scoreToQuality はスコア（0-100）をSM-2の品質（0-5）に変換する
+func scoreToQuality(score int) int {
+	switch {
+	case score >= 90:
+		return 5 // 完璧
+	case score >= 70:
+		return 4 // 正解だが努力が必要
+	case score >= 50:
+		return 3 // かろうじて正解
+	case score >= 30:
+		return 2 // 不正解だが覚えていた
+	default:
+		return 0 // 完全に忘れた
+	}
+}
+
+// 復習の優先度
+const (
+	PriorityUrgent      = "urgent"      // 期限切れ・今日中
+	PriorityRecommended = "recommended" // 明日まで
+	PriorityOptional    = "optional"    // 余裕あり
+)
+
+// Priority は次の復習日時までの残り時間から優先度を返す
+// 24時間以内: urgent、48時間以内: recommended、それ以降: optional
+func Priority(nextReview time.Time, now time.Time) string {
+	hoursUntil := nextReview.Sub(now).Hours()
+
+	switch {
+	case hoursUntil <= 24:
+		return PriorityUrgent
+	case hoursUntil <= 48:
+		return PriorityRecommended
+	default:
+		return PriorityOptional
+	}
+}
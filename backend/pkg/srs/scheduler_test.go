@@ -0,0 +1,114 @@
+package srs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestNewScheduler はアルゴリズムの選択をテスト
+func TestNewScheduler(t *testing.T) {
+	tests := []struct {
+		name      string
+		algorithm Algorithm
+		want      Algorithm
+		wantErr   error
+	}{
+		{"未指定はデフォルト", "", DefaultAlgorithm, nil},
+		{"SM-2", AlgorithmSM2, AlgorithmSM2, nil},
+		{"固定間隔", AlgorithmLadder, AlgorithmLadder, nil},
+		{"未対応", "leitner", "", ErrUnknownAlgorithm},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scheduler, err := NewScheduler(tt.algorithm)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, scheduler.Algorithm())
+		})
+	}
+}
+
+// TestSM2Scheduler はSM-2の間隔と容易度係数の更新をテスト
+func TestSM2Scheduler(t *testing.T) {
+	now := time.Date(2025, 11, 13, 12, 0, 0, 0, time.UTC)
+	scheduler := SM2Scheduler{}
+
+	// 1日 → 6日 → 前回の間隔 × 容易度係数
+	state := scheduler.Schedule(NewState(), 90, now)
+	assert.Equal(t, 1, state.IntervalDays)
+	assert.Equal(t, 1, state.ReviewCount)
+	assert.InDelta(t, 2.6, state.EaseFactor, 0.0001)
+	assert.Equal(t, now.AddDate(0, 0, 1), state.NextReview)
+
+	state = scheduler.Schedule(state, 90, now)
+	assert.Equal(t, 6, state.IntervalDays)
+
+	state = scheduler.Schedule(state, 70, now)
+	assert.Equal(t, 16, state.IntervalDays)          // 6 × 2.7 = 16.2
+	assert.InDelta(t, 2.7, state.EaseFactor, 0.0001) // 品質4では容易度係数は変わらない
+
+	// 失敗すると1日後からやり直し、容易度係数は下限を下回らない
+	state.EaseFactor = 1.4
+	state = scheduler.Schedule(state, 0, now)
+	assert.Equal(t, 1, state.IntervalDays)
+	assert.Equal(t, 4, state.ReviewCount)
+	assert.Equal(t, MinEaseFactor, state.EaseFactor)
+
+	// 容易度係数が未設定の項目はデフォルトから計算する
+	state = scheduler.Schedule(State{}, 70, now)
+	assert.Equal(t, DefaultEaseFactor, state.EaseFactor)
+}
+
+// TestLadderScheduler は固定間隔のスケジューラーが CalculateNextReviewDate と一致することをテスト
+func TestLadderScheduler(t *testing.T) {
+	now := time.Date(2025, 11, 13, 12, 0, 0, 0, time.UTC)
+	scheduler := LadderScheduler{}
+
+	state := NewState()
+	for i, score := range []int{90, 75, 60, 40, 85} {
+		want := CalculateNextReviewDate(state.ReviewCount, score, now)
+		state = scheduler.Schedule(state, score, now)
+		assert.Equal(t, want, state.NextReview)
+		assert.Equal(t, i+1, state.ReviewCount)
+		assert.Equal(t, DefaultEaseFactor, state.EaseFactor)
+	}
+}
+
+// TestLadderScheduler_MinimumInterval は50-69点で間隔が半分になっても当日にならないことをテスト
+func TestLadderScheduler_MinimumInterval(t *testing.T) {
+	now := time.Date(2025, 11, 13, 12, 0, 0, 0, time.UTC)
+	scheduler := LadderScheduler{}
+
+	for _, score := range []int{50, 60, 69} {
+		// 初回の1日の半分は0日になるので1日にする
+		state := scheduler.Schedule(NewState(), score, now)
+		assert.Equal(t, 1, state.IntervalDays, score)
+		assert.Equal(t, now.AddDate(0, 0, 1), state.NextReview, score)
+
+		// 2回目の3日は半分の1日
+		state = scheduler.Schedule(state, score, now)
+		assert.Equal(t, 1, state.IntervalDays, score)
+
+		// 3回目の7日は半分の3日
+		state = scheduler.Schedule(state, score, now)
+		assert.Equal(t, 3, state.IntervalDays, score)
+	}
+}
+
+// TestPriority は次の復習日時までの残り時間による優先度をテスト
+func TestPriority(t *testing.T) {
+	now := time.Date(2025, 11, 13, 12, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, PriorityUrgent, Priority(time.Time{}, now))
+	assert.Equal(t, PriorityUrgent, Priority(now.Add(-time.Hour), now))
+	assert.Equal(t, PriorityUrgent, Priority(now.Add(24*time.Hour), now))
+	assert.Equal(t, PriorityRecommended, Priority(now.Add(36*time.Hour), now))
+	assert.Equal(t, PriorityOptional, Priority(now.Add(72*time.Hour), now))
+}
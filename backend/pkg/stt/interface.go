@@ -2,10 +2,22 @@ package stt
 
 import (
 	"context"
+	"errors"
+	"log"
+	"os"
 
 	"github.com/clearclown/HaiLanGo/backend/internal/models"
 )
 
+// STTプロバイダー（環境変数 STT_PROVIDER で選ぶ）
+const (
+	ProviderGoogle       = "google"        // Google Cloud STT（デフォルト）
+	ProviderLocalWhisper = "local_whisper" // 自前のサーバーで動かす whisper.cpp・faster-whisper
+)
+
+// ErrLocalWhisperURLNotSet は STT_PROVIDER=local_whisper で LOCAL_WHISPER_URL が未設定の場合のエラー
+var ErrLocalWhisperURLNotSet = errors.New("STT_PROVIDER=local_whisper には LOCAL_WHISPER_URL が必要です")
+
 // STTClient は音声認識クライアントのインターフェース
 type STTClient interface {
 	// Recognize は音声データをテキストに変換する
@@ -13,9 +25,39 @@ type STTClient interface {
 }
 
 // NewSTTClient は環境変数とAPIキーに基づいて適切なSTTクライアントを返す
+// STT_PROVIDER=local_whisper の場合は LOCAL_WHISPER_URL のサーバーで認識し、音声を外部のAPIに送らない
+// （LOCAL_WHISPER_URL が未設定の場合は、Google に送らずにモックの結果も返さず、常に ErrLocalWhisperURLNotSet を返すクライアントを返す）
 func NewSTTClient(useMock bool, apiKey string) STTClient {
-	if useMock || apiKey == "" {
+	if useMock {
+		return NewMockSTTClient()
+	}
+
+	if os.Getenv("STT_PROVIDER") == ProviderLocalWhisper {
+		baseURL := os.Getenv("LOCAL_WHISPER_URL")
+		if baseURL == "" {
+			log.Printf("⚠️  %v", ErrLocalWhisperURLNotSet)
+			return unavailableSTTClient{err: ErrLocalWhisperURLNotSet}
+		}
+		client := NewLocalWhisperClient(baseURL)
+		if path := os.Getenv("LOCAL_WHISPER_PATH"); path != "" {
+			client.SetPath(path)
+		}
+		client.SetModel(os.Getenv("LOCAL_WHISPER_MODEL"))
+		return client
+	}
+
+	if apiKey == "" {
 		return NewMockSTTClient()
 	}
 	return NewGoogleSTTClient()
 }
+
+// unavailableSTTClient は設定の誤りで使えないSTTクライアント（常に err を返す）
+type unavailableSTTClient struct {
+	err error
+}
+
+// Recognize は設定の誤りのエラーを返す
+func (c unavailableSTTClient) Recognize(ctx context.Context, audioData []byte, language string) (*models.STTResult, error) {
+	return nil, c.err
+}
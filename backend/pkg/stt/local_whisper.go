@@ -0,0 +1,342 @@
+package stt
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/clearclown/HaiLanGo/backend/internal/models"
+	"github.com/clearclown/HaiLanGo/backend/pkg/retry"
+)
+
+// DefaultLocalWhisperPath は whisper.cpp のサーバー（examples/server）の音声認識のパス
+// faster-whisper などの OpenAI 互換のサーバーは "/v1/audio/transcriptions" を使う
+const DefaultLocalWhisperPath = "/inference"
+
+// errLocalWhisperRetryable はリトライ可能なエラー（接続の失敗・サーバーの混雑）
+var errLocalWhisperRetryable = errors.New("ローカルのWhisperサーバーに一時的に接続できません")
+
+// LocalWhisperClient は自前のサーバーで動かす whisper.cpp・faster-whisper のクライアント
+// 音声を外部のAPIに送らずに認識する。レスポンスの verbose_json から単語の時刻を WordInfo にする
+type LocalWhisperClient struct {
+	baseURL    string
+	path       string
+	model      string
+	httpClient *http.Client
+	retry      retry.Config
+}
+
+// NewLocalWhisperClient は新しいローカルのWhisperクライアントを作成する（baseURL の例: http://localhost:8080）
+func NewLocalWhisperClient(baseURL string) *LocalWhisperClient {
+	return &LocalWhisperClient{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		path:       DefaultLocalWhisperPath,
+		httpClient: &http.Client{Timeout: 120 * time.Second},
+		retry: retry.Config{
+			MaxRetries:     2,
+			InitialBackoff: 1 * time.Second,
+			MaxBackoff:     5 * time.Second,
+			Multiplier:     2.0,
+		},
+	}
+}
+
+// SetPath は音声認識のパスを変更する
+func (w *LocalWhisperClient) SetPath(path string) {
+	if path != "" && !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	w.path = path
+}
+
+// SetModel はリクエストで指定するモデルを設定する（OpenAI 互換のサーバー用、whisper.cpp は起動時のモデルを使う）
+func (w *LocalWhisperClient) SetModel(model string) {
+	w.model = model
+}
+
+// localWhisperWord は verbose_json の単語（whisper.cpp は segments の中、OpenAI 互換のサーバーはトップレベル）
+type localWhisperWord struct {
+	Word        string   `json:"word"`
+	Start       float64  `json:"start"`
+	End         float64  `json:"end"`
+	Probability *float64 `json:"probability"`
+}
+
+// localWhisperSegment は verbose_json のセグメント
+type localWhisperSegment struct {
+	Text       string             `json:"text"`
+	Start      float64            `json:"start"`
+	End        float64            `json:"end"`
+	AvgLogprob *float64           `json:"avg_logprob"`
+	Words      []localWhisperWord `json:"words"`
+}
+
+// localWhisperResponse は verbose_json のレスポンス
+type localWhisperResponse struct {
+	Text     string                `json:"text"`
+	Language string                `json:"language"`
+	Duration float64               `json:"duration"`
+	Words    []localWhisperWord    `json:"words"`
+	Segments []localWhisperSegment `json:"segments"`
+	Error    string                `json:"error"`
+}
+
+// Recognize はローカルのWhisperサーバーで音声認識を実行する
+func (w *LocalWhisperClient) Recognize(ctx context.Context, audioData []byte, language string) (*models.STTResult, error) {
+	if len(audioData) == 0 {
+		return nil, fmt.Errorf("音声データが空です")
+	}
+
+	var response *localWhisperResponse
+	err := retry.Do(ctx, w.retry, func(ctx context.Context) error {
+		var err error
+		response, err = w.callServer(ctx, audioData, language)
+		return err
+	}, func(err error) bool {
+		return errors.Is(err, errLocalWhisperRetryable)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("ローカルのWhisperサーバーでの音声認識に失敗しました: %w", err)
+	}
+
+	return localWhisperResult(response, language), nil
+}
+
+// callServer は音声を multipart/form-data で送り、verbose_json のレスポンスを返す
+func (w *LocalWhisperClient) callServer(ctx context.Context, audioData []byte, language string) (*localWhisperResponse, error) {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+
+	file, err := form.CreateFormFile("file", audioFilename(audioData))
+	if err != nil {
+		return nil, fmt.Errorf("リクエストの作成に失敗しました: %w", err)
+	}
+	if _, err := file.Write(audioData); err != nil {
+		return nil, fmt.Errorf("リクエストの作成に失敗しました: %w", err)
+	}
+
+	fields := [][2]string{
+		{"response_format", "verbose_json"},
+		{"temperature", "0"},
+		{"timestamp_granularities[]", "word"},
+		{"timestamp_granularities[]", "segment"},
+	}
+	if lang := whisperLanguage(language); lang != "" {
+		fields = append(fields, [2]string{"language", lang})
+	}
+	if w.model != "" {
+		fields = append(fields, [2]string{"model", w.model})
+	}
+	for _, field := range fields {
+		if err := form.WriteField(field[0], field[1]); err != nil {
+			return nil, fmt.Errorf("リクエストの作成に失敗しました: %w", err)
+		}
+	}
+	if err := form.Close(); err != nil {
+		return nil, fmt.Errorf("リクエストの作成に失敗しました: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.baseURL+w.path, &body)
+	if err != nil {
+		return nil, fmt.Errorf("リクエストの作成に失敗しました: %w", err)
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+
+	resp, err := w.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errLocalWhisperRetryable, err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("レスポンスの読み込みに失敗しました: %w", err)
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable:
+		return nil, fmt.Errorf("%w: status %d: %s", errLocalWhisperRetryable, resp.StatusCode, respBody)
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("status %d: %s", resp.StatusCode, respBody)
+	}
+
+	var parsed localWhisperResponse
+	if err := json.Unmarshal(respBody, &parsed); err != nil {
+		return nil, fmt.Errorf("レスポンスの解析に失敗しました: %w", err)
+	}
+	if parsed.Error != "" {
+		return nil, errors.New(parsed.Error)
+	}
+	return &parsed, nil
+}
+
+// localWhisperResult は verbose_json のレスポンスを認識結果にする
+// 単語の時刻がない場合は、セグメントの時間を単語の文字数で割り振る
+func localWhisperResult(response *localWhisperResponse, language string) *models.STTResult {
+	pieces := response.Words
+	if len(pieces) == 0 {
+		for _, segment := range response.Segments {
+			pieces = append(pieces, segment.Words...)
+		}
+	}
+
+	words := mergeWhisperWords(pieces)
+	if len(words) == 0 {
+		for _, segment := range response.Segments {
+			words = append(words, estimateSegmentWords(segment)...)
+		}
+	}
+
+	text := strings.TrimSpace(response.Text)
+	if text == "" {
+		parts := make([]string, 0, len(response.Segments))
+		for _, segment := range response.Segments {
+			parts = append(parts, strings.TrimSpace(segment.Text))
+		}
+		text = strings.Join(parts, " ")
+	}
+
+	duration := response.Duration
+	if duration == 0 {
+		if len(words) > 0 {
+			duration = words[len(words)-1].EndTime
+		}
+		if n := len(response.Segments); n > 0 {
+			duration = max(duration, response.Segments[n-1].End)
+		}
+	}
+
+	confidence := 0.0
+	for _, word := range words {
+		confidence += word.Confidence
+	}
+	if len(words) > 0 {
+		confidence /= float64(len(words))
+	}
+
+	return &models.STTResult{
+		Text:       text,
+		Language:   language,
+		Confidence: confidence,
+		Duration:   duration,
+		Words:      words,
+		CreatedAt:  time.Now(),
+	}
+}
+
+// mergeWhisperWords は Whisper の単語（トークン）を単語にまとめる
+// 単語の前に空白が付く形式の場合は、空白で始まらない断片を前の単語につなげる。句読点だけの断片は単語にしない
+func mergeWhisperWords(pieces []localWhisperWord) []models.WordInfo {
+	spaced := false
+	for _, piece := range pieces {
+		if r, _ := utf8.DecodeRuneInString(piece.Word); unicode.IsSpace(r) {
+			spaced = true
+			break
+		}
+	}
+
+	words := make([]models.WordInfo, 0, len(pieces))
+	for _, piece := range pieces {
+		text := strings.TrimSpace(piece.Word)
+		if text == "" {
+			continue
+		}
+		confidence := 1.0
+		if piece.Probability != nil {
+			confidence = *piece.Probability
+		}
+
+		r, _ := utf8.DecodeRuneInString(piece.Word)
+		continues := spaced && !unicode.IsSpace(r)
+		if len(words) > 0 && (continues || !hasLetterOrDigit(text)) {
+			last := &words[len(words)-1]
+			last.Word += text
+			last.EndTime = max(last.EndTime, piece.End)
+			last.Confidence = min(last.Confidence, confidence)
+			continue
+		}
+		if !hasLetterOrDigit(text) {
+			continue
+		}
+
+		words = append(words, models.WordInfo{
+			Word:       text,
+			StartTime:  piece.Start,
+			EndTime:    piece.End,
+			Confidence: confidence,
+		})
+	}
+	return words
+}
+
+// estimateSegmentWords はセグメントの時間を単語の文字数で割り振る（単語の時刻を返さないサーバー用）
+func estimateSegmentWords(segment localWhisperSegment) []models.WordInfo {
+	fields := strings.Fields(segment.Text)
+	confidence := 1.0
+	if segment.AvgLogprob != nil {
+		confidence = math.Exp(*segment.AvgLogprob)
+	}
+
+	total := 0
+	for _, field := range fields {
+		total += utf8.RuneCountInString(field)
+	}
+
+	words := make([]models.WordInfo, 0, len(fields))
+	position := 0
+	for _, field := range fields {
+		if !hasLetterOrDigit(field) {
+			continue
+		}
+		length := utf8.RuneCountInString(field)
+		start := segment.Start + (segment.End-segment.Start)*float64(position)/float64(total)
+		position += length
+		end := segment.Start + (segment.End-segment.Start)*float64(position)/float64(total)
+		words = append(words, models.WordInfo{
+			Word:       field,
+			StartTime:  start,
+			EndTime:    end,
+			Confidence: confidence,
+		})
+	}
+	return words
+}
+
+// hasLetterOrDigit は文字列に文字か数字が含まれるかどうかを返す
+func hasLetterOrDigit(text string) bool {
+	for _, r := range text {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// whisperLanguage は言語コードを Whisper の言語コードにする（例: en-US → en）
+func whisperLanguage(language string) string {
+	base, _, _ := strings.Cut(strings.ToLower(language), "-")
+	return base
+}
+
+// audioFilename は音声データの形式に合わせたファイル名を返す（サーバーはファイル名の拡張子で形式を判定することがある）
+func audioFilename(audioData []byte) string {
+	switch {
+	case len(audioData) >= 12 && string(audioData[0:4]) == "RIFF" && string(audioData[8:12]) == "WAVE":
+		return "audio.wav"
+	case bytes.HasPrefix(audioData, []byte("OggS")):
+		return "audio.ogg"
+	case bytes.HasPrefix(audioData, []byte{0x1A, 0x45, 0xDF, 0xA3}):
+		return "audio.webm"
+	}
+	return "audio.pcm"
+}
@@ -0,0 +1,213 @@
+package stt
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/clearclown/HaiLanGo/backend/internal/service/audio"
+	"github.com/clearclown/HaiLanGo/backend/pkg/retry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestLocalWhisperClient_WhisperCpp は whisper.cpp のサーバーのレスポンスのテスト
+func TestLocalWhisperClient_WhisperCpp(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/inference", r.URL.Path)
+		require.NoError(t, r.ParseMultipartForm(1<<20))
+		assert.Equal(t, "verbose_json", r.FormValue("response_format"))
+		assert.Equal(t, "en", r.FormValue("language"))
+		assert.Empty(t, r.FormValue("model"))
+
+		file, header, err := r.FormFile("file")
+		require.NoError(t, err)
+		data, _ := io.ReadAll(file)
+		assert.Equal(t, "audio.wav", header.Filename)
+		assert.Equal(t, 44+3200, len(data))
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{
+			"task": "transcribe", "language": "english", "duration": 1.5,
+			"text": " Hello, world!",
+			"segments": [{
+				"id": 0, "text": " Hello, world!", "start": 0.0, "end": 1.2,
+				"words": [
+					{"word": " Hello", "start": 0.0, "end": 0.5, "probability": 0.9},
+					{"word": ",", "start": 0.5, "end": 0.52, "probability": 0.8},
+					{"word": " wor", "start": 0.6, "end": 0.8, "probability": 0.7},
+					{"word": "ld", "start": 0.8, "end": 1.1, "probability": 0.95},
+					{"word": "!", "start": 1.1, "end": 1.12, "probability": 0.99}
+				]
+			}]
+		}`))
+	}))
+	defer server.Close()
+
+	client := NewLocalWhisperClient(server.URL + "/")
+	result, err := client.Recognize(context.Background(), audio.WrapPCM(make([]byte, 3200), 16000), "en-US")
+	require.NoError(t, err)
+
+	assert.Equal(t, "Hello, world!", result.Text)
+	assert.Equal(t, "en-US", result.Language)
+	assert.Equal(t, 1.5, result.Duration)
+
+	// 空白で始まらない断片と句読点は前の単語につなげる
+	require.Len(t, result.Words, 2)
+	assert.Equal(t, "Hello,", result.Words[0].Word)
+	assert.Equal(t, 0.0, result.Words[0].StartTime)
+	assert.Equal(t, 0.52, result.Words[0].EndTime)
+	assert.Equal(t, 0.8, result.Words[0].Confidence)
+	assert.Equal(t, "world!", result.Words[1].Word)
+	assert.Equal(t, 0.6, result.Words[1].StartTime)
+	assert.Equal(t, 1.12, result.Words[1].EndTime)
+	assert.Equal(t, 0.7, result.Words[1].Confidence)
+	assert.InDelta(t, 0.75, result.Confidence, 0.001)
+}
+
+// TestLocalWhisperClient_OpenAICompatible は faster-whisper などの OpenAI 互換のサーバーのレスポンスのテスト
+func TestLocalWhisperClient_OpenAICompatible(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/audio/transcriptions", r.URL.Path)
+		require.NoError(t, r.ParseMultipartForm(1<<20))
+		assert.Equal(t, "Systran/faster-whisper-small", r.FormValue("model"))
+		assert.Equal(t, []string{"word", "segment"}, r.MultipartForm.Value["timestamp_granularities[]"])
+		assert.Equal(t, "ru", r.FormValue("language"))
+
+		w.Write([]byte(`{
+			"text": "Здравствуйте, мир", "language": "ru", "duration": 2.0,
+			"words": [
+				{"word": "Здравствуйте,", "start": 0.1, "end": 0.9},
+				{"word": "мир", "start": 1.0, "end": 1.4}
+			]
+		}`))
+	}))
+	defer server.Close()
+
+	client := NewLocalWhisperClient(server.URL)
+	client.SetPath("v1/audio/transcriptions")
+	client.SetModel("Systran/faster-whisper-small")
+	result, err := client.Recognize(context.Background(), []byte("test audio"), "ru-RU")
+	require.NoError(t, err)
+
+	assert.Equal(t, "Здравствуйте, мир", result.Text)
+	require.Len(t, result.Words, 2)
+	assert.Equal(t, "Здравствуйте,", result.Words[0].Word)
+	assert.Equal(t, "мир", result.Words[1].Word)
+	assert.Equal(t, 1.4, result.Words[1].EndTime)
+	assert.Equal(t, 1.0, result.Words[1].Confidence)
+}
+
+// TestLocalWhisperClient_SegmentsOnly は単語の時刻を返さないサーバーのテスト
+func TestLocalWhisperClient_SegmentsOnly(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{
+			"text": "",
+			"segments": [
+				{"text": " Good morning", "start": 0.0, "end": 1.1, "avg_logprob": -0.1},
+				{"text": " everyone.", "start": 1.5, "end": 2.0, "avg_logprob": -0.2}
+			]
+		}`))
+	}))
+	defer server.Close()
+
+	result, err := NewLocalWhisperClient(server.URL).Recognize(context.Background(), []byte("test audio"), "en")
+	require.NoError(t, err)
+
+	assert.Equal(t, "Good morning everyone.", result.Text)
+	assert.Equal(t, 2.0, result.Duration)
+
+	// セグメントの時間を文字数で割り振る
+	require.Len(t, result.Words, 3)
+	assert.Equal(t, "Good", result.Words[0].Word)
+	assert.InDelta(t, 0.4, result.Words[0].EndTime, 0.001)
+	assert.Equal(t, "morning", result.Words[1].Word)
+	assert.InDelta(t, 1.1, result.Words[1].EndTime, 0.001)
+	assert.Equal(t, "everyone.", result.Words[2].Word)
+	assert.Equal(t, 1.5, result.Words[2].StartTime)
+	assert.InDelta(t, 0.905, result.Words[0].Confidence, 0.001)
+}
+
+// TestLocalWhisperClient_Errors はサーバーのエラーのテスト
+func TestLocalWhisperClient_Errors(t *testing.T) {
+	ctx := context.Background()
+	fastRetry := retry.Config{MaxRetries: 2, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond, Multiplier: 1}
+
+	t.Run("混雑している場合はリトライする", func(t *testing.T) {
+		attempts := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			attempts++
+			if attempts == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.Write([]byte(`{"text": "Hello", "words": [{"word": "Hello", "start": 0, "end": 0.5}]}`))
+		}))
+		defer server.Close()
+
+		client := NewLocalWhisperClient(server.URL)
+		client.retry = fastRetry
+		result, err := client.Recognize(ctx, []byte("test audio"), "en")
+		require.NoError(t, err)
+		assert.Equal(t, "Hello", result.Text)
+		assert.Equal(t, 2, attempts)
+	})
+
+	t.Run("リクエストのエラーはリトライしない", func(t *testing.T) {
+		attempts := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			attempts++
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error": "failed to read WAV file"}`))
+		}))
+		defer server.Close()
+
+		client := NewLocalWhisperClient(server.URL)
+		client.retry = fastRetry
+		_, err := client.Recognize(ctx, []byte("test audio"), "en")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to read WAV file")
+		assert.Equal(t, 1, attempts)
+	})
+
+	t.Run("レスポンスのエラー", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"error": "no model loaded"}`))
+		}))
+		defer server.Close()
+
+		_, err := NewLocalWhisperClient(server.URL).Recognize(ctx, []byte("test audio"), "en")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "no model loaded")
+	})
+
+	t.Run("空の音声データ", func(t *testing.T) {
+		_, err := NewLocalWhisperClient("http://localhost:0").Recognize(ctx, []byte{}, "en")
+		require.Error(t, err)
+	})
+}
+
+// TestSTTClientFactory_LocalWhisper はローカルのWhisperサーバーを選ぶ設定のテスト
+func TestSTTClientFactory_LocalWhisper(t *testing.T) {
+	t.Setenv("STT_PROVIDER", ProviderLocalWhisper)
+	t.Setenv("LOCAL_WHISPER_URL", "http://whisper:8080")
+	t.Setenv("LOCAL_WHISPER_PATH", "/v1/audio/transcriptions")
+
+	// Google のAPIキーがあってもローカルのサーバーを使う
+	client, ok := NewSTTClient(false, "test-api-key").(*LocalWhisperClient)
+	require.True(t, ok)
+	assert.Equal(t, "http://whisper:8080", client.baseURL)
+	assert.Equal(t, "/v1/audio/transcriptions", client.path)
+	assert.IsType(t, &BatchStreamingClient{}, NewStreamingSTTClient(false, ""))
+
+	// テストではモックを使う
+	assert.IsType(t, &MockSTTClient{}, NewSTTClient(true, ""))
+
+	// URLがない場合は Google に送らず、モックの結果も返さずにエラーにする
+	t.Setenv("LOCAL_WHISPER_URL", "")
+	_, err := NewSTTClient(false, "test-api-key").Recognize(context.Background(), audio.WrapPCM(make([]byte, 3200), 16000), "en-US")
+	assert.ErrorIs(t, err, ErrLocalWhisperURLNotSet)
+}
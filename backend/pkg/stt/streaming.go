@@ -0,0 +1,388 @@
+package stt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/clearclown/HaiLanGo/backend/internal/models"
+	"github.com/clearclown/HaiLanGo/backend/internal/service/audio"
+)
+
+// DefaultStreamSampleRate はストリーミングで送る音声のデフォルトのサンプリングレート
+const DefaultStreamSampleRate = 16000
+
+// ErrStreamClosed は送信を終えた（または中断した）ストリームに音声を送った場合のエラー
+var ErrStreamClosed = errors.New("ストリームは終了しています")
+
+// StreamConfig はストリーミング音声認識の設定
+type StreamConfig struct {
+	Language   string // 言語コード
+	SampleRate int    // 送る音声（16bit・モノラルのリトルエンディアンのPCM）のサンプリングレート
+}
+
+// StreamResult はストリーミング音声認識の結果
+type StreamResult struct {
+	Text    string            // 認識されたテキスト（途中結果は後で変わることがある）
+	IsFinal bool              // 最終結果かどうか
+	Result  *models.STTResult // 最終結果の詳細（IsFinal の場合のみ）
+}
+
+// RecognizeStream はストリーミング音声認識のストリーム
+type RecognizeStream interface {
+	// Send は音声のチャンクを送る
+	Send(chunk []byte) error
+	// CloseSend は音声の送信を終える（この後 Recv で最終結果を受け取る）
+	CloseSend() error
+	// Recv は次の認識結果を返す。最終結果の後は io.EOF を返す
+	Recv() (*StreamResult, error)
+	// Close はストリームを中断する
+	Close() error
+}
+
+// StreamingSTTClient はストリーミング音声認識クライアントのインターフェース
+type StreamingSTTClient interface {
+	// StartStream はストリーミング音声認識を開始する
+	StartStream(ctx context.Context, config StreamConfig) (RecognizeStream, error)
+}
+
+// NewStreamingSTTClient は NewSTTClient と同じ方法でプロバイダーを選び、ストリーミングSTTクライアントを返す
+// ストリーミングに対応していないクライアントは、受け取った音声をまとめて認識する BatchStreamingClient で包む
+func NewStreamingSTTClient(useMock bool, apiKey string) StreamingSTTClient {
+	client := NewSTTClient(useMock, apiKey)
+	if mock, ok := client.(*MockSTTClient); ok {
+		return &MockStreamingSTTClient{mock: mock}
+	}
+	streaming := NewBatchStreamingClient(client)
+	// ブラウザで録音したOgg・WebMのOpusはffmpegでデコードする
+	streaming.processor.SetFFmpegDecoder(audio.NewFFmpegDecoder(os.Getenv("FFMPEG_PATH")))
+	return streaming
+}
+
+// streamResults はストリームの認識結果のキュー
+// 途中結果は最新のものだけを残し、最終結果を受け取った後は io.EOF を返す
+type streamResults struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	interim *StreamResult
+	final   *StreamResult
+	err     error
+	done    bool // 最終結果（またはエラー）を Recv で返した
+	closed  bool
+}
+
+func newStreamResults() *streamResults {
+	r := &streamResults{}
+	r.cond = sync.NewCond(&r.mu)
+	return r
+}
+
+// pushInterim は途中結果を入れる（まだ受け取られていない途中結果は置き換える）
+func (r *streamResults) pushInterim(text string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.final != nil || r.err != nil || r.closed {
+		return
+	}
+	r.interim = &StreamResult{Text: text}
+	r.cond.Broadcast()
+}
+
+// finish は最終結果またはエラーを入れる
+func (r *streamResults) finish(result *models.STTResult, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.final != nil || r.err != nil {
+		return
+	}
+	if err != nil {
+		r.err = err
+	} else {
+		r.final = &StreamResult{Text: result.Text, IsFinal: true, Result: result}
+	}
+	r.cond.Broadcast()
+}
+
+// close はストリームを中断し、待っている Recv を終わらせる
+func (r *streamResults) close() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.closed = true
+	r.cond.Broadcast()
+}
+
+// recv は次の認識結果を待って返す
+func (r *streamResults) recv() (*StreamResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for {
+		switch {
+		case r.done:
+			return nil, io.EOF
+		case r.interim != nil:
+			result := r.interim
+			r.interim = nil
+			return result, nil
+		case r.err != nil:
+			r.done = true
+			return nil, r.err
+		case r.final != nil:
+			r.done = true
+			return r.final, nil
+		case r.closed:
+			return nil, ErrStreamClosed
+		}
+		r.cond.Wait()
+	}
+}
+
+// MockStreamingSTTClient はモックのストリーミングSTTクライアント
+// 受け取った音声の長さに応じて、モックの認識結果の単語を途中結果として順に返す
+type MockStreamingSTTClient struct {
+	mock *MockSTTClient
+}
+
+// NewMockStreamingSTTClient は新しいモックのストリーミングSTTクライアントを作成する
+func NewMockStreamingSTTClient() *MockStreamingSTTClient {
+	return &MockStreamingSTTClient{mock: NewMockSTTClient()}
+}
+
+// StartStream はモックのストリーミング音声認識を開始する
+func (m *MockStreamingSTTClient) StartStream(ctx context.Context, config StreamConfig) (RecognizeStream, error) {
+	sampleRate := config.SampleRate
+	if sampleRate <= 0 {
+		sampleRate = DefaultStreamSampleRate
+	}
+	return &mockStream{
+		ctx:        ctx,
+		client:     m.mock,
+		language:   config.Language,
+		sampleRate: sampleRate,
+		results:    newStreamResults(),
+	}, nil
+}
+
+// mockStream はモックのストリーム
+type mockStream struct {
+	ctx        context.Context
+	client     *MockSTTClient
+	language   string
+	sampleRate int
+	results    *streamResults
+
+	mu       sync.Mutex
+	audio    []byte
+	lastText string
+	sendDone bool
+}
+
+// Send は音声のチャンクを受け取り、受け取った音声の長さまでに話し終えた単語を途中結果として返す
+func (s *mockStream) Send(chunk []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.sendDone {
+		return ErrStreamClosed
+	}
+	s.audio = append(s.audio, chunk...)
+
+	result, err := s.client.Recognize(s.ctx, s.audio, s.language)
+	if err != nil {
+		return nil
+	}
+	received := float64(len(s.audio)/2) / float64(s.sampleRate)
+	var words []string
+	for _, word := range result.Words {
+		if word.EndTime <= received {
+			words = append(words, word.Word)
+		}
+	}
+	if text := strings.Join(words, " "); text != "" && text != s.lastText {
+		s.lastText = text
+		s.results.pushInterim(text)
+	}
+	return nil
+}
+
+// CloseSend は受け取った音声全体の認識結果を最終結果にする
+func (s *mockStream) CloseSend() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.sendDone {
+		return nil
+	}
+	s.sendDone = true
+
+	result, err := s.client.Recognize(s.ctx, s.audio, s.language)
+	if err == nil {
+		result.Duration = float64(len(s.audio)/2) / float64(s.sampleRate)
+	}
+	s.results.finish(result, err)
+	return nil
+}
+
+// Recv は次の認識結果を返す
+func (s *mockStream) Recv() (*StreamResult, error) {
+	return s.results.recv()
+}
+
+// Close はストリームを中断する
+func (s *mockStream) Close() error {
+	s.mu.Lock()
+	s.sendDone = true
+	s.mu.Unlock()
+	s.results.close()
+	return nil
+}
+
+const (
+	// DefaultInterimInterval は BatchStreamingClient が途中結果を認識する音声の最小の間隔（秒）
+	DefaultInterimInterval = 1.0
+	// DefaultInterimGrowth は BatchStreamingClient が途中結果を認識する間隔の、前に認識した音声の長さに対する割合
+	DefaultInterimGrowth = 0.5
+)
+
+// BatchStreamingClient はストリーミングに対応していない STTClient でストリーミング音声認識を行う
+// 音声が増えるたびにそれまでの音声全体を認識して途中結果にし、送信を終えたら最終結果を認識する
+// 途中結果を認識する間隔は InterimInterval 秒か、前に認識した音声の InterimGrowth 倍の長い方にする
+// （音声が長くなっても、認識する音声の合計が送った音声の長さに比例するようにする）
+// 音声は audio.AudioProcessor で16kHzのWAVにしてから認識する
+type BatchStreamingClient struct {
+	client          STTClient
+	processor       *audio.AudioProcessor
+	InterimInterval float64
+	InterimGrowth   float64
+}
+
+// NewBatchStreamingClient は STTClient を包んだストリーミングSTTクライアントを作成する
+func NewBatchStreamingClient(client STTClient) *BatchStreamingClient {
+	return &BatchStreamingClient{
+		client:          client,
+		processor:       audio.NewAudioProcessor(),
+		InterimInterval: DefaultInterimInterval,
+		InterimGrowth:   DefaultInterimGrowth,
+	}
+}
+
+// StartStream はストリーミング音声認識を開始する
+func (b *BatchStreamingClient) StartStream(ctx context.Context, config StreamConfig) (RecognizeStream, error) {
+	sampleRate := config.SampleRate
+	if sampleRate <= 0 {
+		sampleRate = DefaultStreamSampleRate
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	return &batchStream{
+		ctx:        ctx,
+		cancel:     cancel,
+		client:     b.client,
+		processor:  b.processor,
+		language:   config.Language,
+		sampleRate: sampleRate,
+		interval:   int(b.InterimInterval * float64(sampleRate) * 2),
+		growth:     b.InterimGrowth,
+		results:    newStreamResults(),
+	}, nil
+}
+
+// batchStream は BatchStreamingClient のストリーム
+type batchStream struct {
+	ctx        context.Context
+	cancel     context.CancelFunc
+	client     STTClient
+	processor  *audio.AudioProcessor
+	language   string
+	sampleRate int
+	interval   int     // 途中結果を認識する音声の最小の間隔（バイト）
+	growth     float64 // 途中結果を認識する間隔の、前に認識した音声の長さに対する割合
+	results    *streamResults
+
+	mu          sync.Mutex
+	audio       []byte
+	recognized  int  // 最後に途中結果を認識した音声の長さ（バイト）
+	recognizing bool // 途中結果を認識中
+	sendDone    bool
+	wg          sync.WaitGroup
+}
+
+// Send は音声のチャンクを受け取り、前の途中結果から音声が十分に増えていれば途中結果を認識する
+// 途中結果の認識は別のゴルーチンで行い、認識中は新しい認識を始めない
+func (s *batchStream) Send(chunk []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.sendDone {
+		return ErrStreamClosed
+	}
+	s.audio = append(s.audio, chunk...)
+	interval := max(s.interval, int(float64(s.recognized)*s.growth))
+	if s.recognizing || s.interval <= 0 || len(s.audio)-s.recognized < interval {
+		return nil
+	}
+
+	s.recognizing = true
+	s.recognized = len(s.audio)
+	wav := audio.WrapPCM(s.audio, s.sampleRate)
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		result, err := s.recognize(wav)
+		s.mu.Lock()
+		s.recognizing = false
+		s.mu.Unlock()
+		if err == nil && result.Text != "" {
+			s.results.pushInterim(result.Text)
+		}
+	}()
+	return nil
+}
+
+// CloseSend は途中結果の認識を待ち、音声全体を認識して最終結果にする
+func (s *batchStream) CloseSend() error {
+	s.mu.Lock()
+	if s.sendDone {
+		s.mu.Unlock()
+		return nil
+	}
+	s.sendDone = true
+	wav := audio.WrapPCM(s.audio, s.sampleRate)
+	empty := len(s.audio) == 0
+	s.mu.Unlock()
+
+	s.wg.Wait()
+	if empty {
+		s.results.finish(nil, fmt.Errorf("音声データが空です"))
+		return nil
+	}
+	result, err := s.recognize(wav)
+	s.results.finish(result, err)
+	return nil
+}
+
+// recognize はWAVを16kHzに変換して認識する
+func (s *batchStream) recognize(wav []byte) (*models.STTResult, error) {
+	if s.sampleRate != audio.TargetSampleRate {
+		converted, err := s.processor.ConvertSampleRate(wav, audio.TargetSampleRate)
+		if err != nil {
+			return nil, err
+		}
+		wav = converted
+	}
+	return s.client.Recognize(s.ctx, wav, s.language)
+}
+
+// Recv は次の認識結果を返す
+func (s *batchStream) Recv() (*StreamResult, error) {
+	return s.results.recv()
+}
+
+// Close はストリームを中断する
+func (s *batchStream) Close() error {
+	s.mu.Lock()
+	s.sendDone = true
+	s.mu.Unlock()
+	s.cancel()
+	s.results.close()
+	return nil
+}
@@ -0,0 +1,186 @@
+package stt
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/clearclown/HaiLanGo/backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// silence は指定した秒数の無音のPCM（16kHz・16bit）を返す
+func silence(seconds float64) []byte {
+	return make([]byte, int(seconds*DefaultStreamSampleRate)*2)
+}
+
+// TestMockStreamingSTTClient はモックのストリーミングSTTクライアントのテスト
+func TestMockStreamingSTTClient(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("受け取った音声までに話し終えた単語を途中結果にする", func(t *testing.T) {
+		stream, err := NewMockStreamingSTTClient().StartStream(ctx, StreamConfig{Language: "en"})
+		require.NoError(t, err)
+		defer stream.Close()
+
+		// 0.3秒ではまだ単語を話し終えていない
+		require.NoError(t, stream.Send(silence(0.3)))
+		require.NoError(t, stream.Send(silence(0.3)))
+		result, err := stream.Recv()
+		require.NoError(t, err)
+		assert.False(t, result.IsFinal)
+		assert.Equal(t, "Hello", result.Text)
+
+		require.NoError(t, stream.Send(silence(0.6)))
+		result, err = stream.Recv()
+		require.NoError(t, err)
+		assert.Equal(t, "Hello world", result.Text)
+
+		require.NoError(t, stream.Send(silence(0.3)))
+		require.NoError(t, stream.CloseSend())
+		assert.ErrorIs(t, stream.Send(silence(0.1)), ErrStreamClosed)
+
+		result, err = stream.Recv()
+		require.NoError(t, err)
+		assert.True(t, result.IsFinal)
+		assert.Equal(t, "Hello, world!", result.Text)
+		require.NotNil(t, result.Result)
+		assert.InDelta(t, 1.5, result.Result.Duration, 0.001)
+
+		_, err = stream.Recv()
+		assert.ErrorIs(t, err, io.EOF)
+	})
+
+	t.Run("受け取られていない途中結果は最新のものだけを返す", func(t *testing.T) {
+		stream, err := NewMockStreamingSTTClient().StartStream(ctx, StreamConfig{Language: "en"})
+		require.NoError(t, err)
+		defer stream.Close()
+
+		require.NoError(t, stream.Send(silence(0.6)))
+		require.NoError(t, stream.Send(silence(0.6)))
+		require.NoError(t, stream.CloseSend())
+
+		result, err := stream.Recv()
+		require.NoError(t, err)
+		assert.False(t, result.IsFinal)
+		assert.Equal(t, "Hello world", result.Text)
+
+		result, err = stream.Recv()
+		require.NoError(t, err)
+		assert.True(t, result.IsFinal)
+	})
+
+	t.Run("中断すると待っている Recv が終わる", func(t *testing.T) {
+		stream, err := NewMockStreamingSTTClient().StartStream(ctx, StreamConfig{Language: "en"})
+		require.NoError(t, err)
+
+		done := make(chan error, 1)
+		go func() {
+			_, err := stream.Recv()
+			done <- err
+		}()
+		require.NoError(t, stream.Close())
+
+		select {
+		case err := <-done:
+			assert.ErrorIs(t, err, ErrStreamClosed)
+		case <-time.After(time.Second):
+			t.Fatal("Recv が終わらない")
+		}
+	})
+}
+
+// recordingSTTClient は受け取った音声を記録し、呼び出した回数を認識結果にするSTTクライアント
+type recordingSTTClient struct {
+	mu     sync.Mutex
+	sizes  []int
+	header []string
+}
+
+func (c *recordingSTTClient) Recognize(ctx context.Context, audioData []byte, language string) (*models.STTResult, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sizes = append(c.sizes, len(audioData))
+	c.header = append(c.header, string(audioData[:4]))
+	return &models.STTResult{Text: fmt.Sprintf("call %d", len(c.sizes)), Language: language}, nil
+}
+
+// TestBatchStreamingClient はストリーミングに対応していないクライアントを包んだストリーミングのテスト
+func TestBatchStreamingClient(t *testing.T) {
+	ctx := context.Background()
+	client := &recordingSTTClient{}
+	streaming := NewBatchStreamingClient(client)
+	streaming.InterimInterval = 0.5
+
+	stream, err := streaming.StartStream(ctx, StreamConfig{Language: "en", SampleRate: 16000})
+	require.NoError(t, err)
+	defer stream.Close()
+
+	// 0.5秒分の音声が溜まったら途中結果を認識する
+	require.NoError(t, stream.Send(silence(0.25)))
+	require.NoError(t, stream.Send(silence(0.25)))
+	result, err := stream.Recv()
+	require.NoError(t, err)
+	assert.False(t, result.IsFinal)
+	assert.Equal(t, "call 1", result.Text)
+
+	require.NoError(t, stream.Send(silence(0.25)))
+	require.NoError(t, stream.CloseSend())
+
+	var final *StreamResult
+	for {
+		result, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		if result.IsFinal {
+			final = result
+		}
+	}
+	require.NotNil(t, final)
+	assert.Equal(t, "call 2", final.Text)
+
+	// 送った音声全体をWAVにして認識する
+	assert.Equal(t, []int{44 + 16000, 44 + 24000}, client.sizes)
+	assert.Equal(t, []string{"RIFF", "RIFF"}, client.header)
+}
+
+// TestBatchStreamingClient_Throttle は音声が長くなるほど途中結果の間隔を空け、16kHzに変換して認識することをテスト
+func TestBatchStreamingClient_Throttle(t *testing.T) {
+	ctx := context.Background()
+	client := &recordingSTTClient{}
+	streaming := NewBatchStreamingClient(client)
+
+	stream, err := streaming.StartStream(ctx, StreamConfig{Language: "en", SampleRate: 48000})
+	require.NoError(t, err)
+	defer stream.Close()
+
+	second := make([]byte, 48000*2)
+	// 1秒・2秒・3秒は1秒ごとに認識し、その後は前に認識した音声の半分（1.5秒）増えるまで認識しない
+	for i := 0; i < 5; i++ {
+		require.NoError(t, stream.Send(second))
+		if i != 3 {
+			result, err := stream.Recv()
+			require.NoError(t, err)
+			assert.False(t, result.IsFinal)
+		}
+	}
+	require.NoError(t, stream.CloseSend())
+	result, err := stream.Recv()
+	require.NoError(t, err)
+	assert.True(t, result.IsFinal)
+
+	assert.Equal(t, []int{44 + 32000, 44 + 64000, 44 + 96000, 44 + 160000, 44 + 160000}, client.sizes)
+}
+
+// TestStreamingSTTClientFactory はストリーミングSTTクライアントファクトリーのテスト
+func TestStreamingSTTClientFactory(t *testing.T) {
+	assert.IsType(t, &MockStreamingSTTClient{}, NewStreamingSTTClient(true, "test-api-key"))
+	assert.IsType(t, &MockStreamingSTTClient{}, NewStreamingSTTClient(false, ""))
+	assert.IsType(t, &BatchStreamingClient{}, NewStreamingSTTClient(false, "test-api-key"))
+}
@@ -0,0 +1,110 @@
+package translate
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/clearclown/HaiLanGo/backend/pkg/cache"
+)
+
+// DefaultCacheTTL は翻訳結果のキャッシュ期間
+const DefaultCacheTTL = 30 * 24 * time.Hour
+
+// CachedClient は翻訳結果をキャッシュする翻訳クライアント
+// テキストごとにキャッシュし、キャッシュにないテキストのみをまとめて翻訳する
+type CachedClient struct {
+	client Client
+	cache  cache.Cache
+	ttl    time.Duration
+}
+
+// NewCachedClient は新しいキャッシュ付き翻訳クライアントを作成する
+func NewCachedClient(client Client, c cache.Cache, ttl time.Duration) *CachedClient {
+	if ttl <= 0 {
+		ttl = DefaultCacheTTL
+	}
+	return &CachedClient{client: client, cache: c, ttl: ttl}
+}
+
+// Translate はテキストを翻訳する
+func (c *CachedClient) Translate(ctx context.Context, texts []string, sourceLang, targetLang string) ([]Translation, error) {
+	if sameLanguage(sourceLang, targetLang) {
+		return untranslated(texts, sourceLang), nil
+	}
+
+	results := make([]Translation, len(texts))
+	var missing []int
+	var missingTexts []string
+	for i, text := range texts {
+		if text == "" {
+			continue
+		}
+		if cached, ok := c.get(ctx, cacheKey(text, sourceLang, targetLang)); ok {
+			results[i] = cached
+			continue
+		}
+		missing = append(missing, i)
+		missingTexts = append(missingTexts, text)
+	}
+	if len(missing) == 0 {
+		return results, nil
+	}
+
+	translated, err := c.client.Translate(ctx, missingTexts, sourceLang, targetLang)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, index := range missing {
+		if i >= len(translated) {
+			break
+		}
+		results[index] = translated[i]
+		// 翻訳できなかったテキストは、後でプロバイダーが利用可能になった場合に備えてキャッシュしない
+		if translated[i].Text != "" {
+			c.set(ctx, cacheKey(texts[index], sourceLang, targetLang), translated[i])
+		}
+	}
+
+	return results, nil
+}
+
+// get はキャッシュから翻訳結果を取得する
+func (c *CachedClient) get(ctx context.Context, key string) (Translation, bool) {
+	data, err := c.cache.Get(ctx, key)
+	if err != nil {
+		return Translation{}, false
+	}
+
+	var translation Translation
+	if err := json.Unmarshal(data, &translation); err != nil {
+		return Translation{}, false
+	}
+	return translation, true
+}
+
+// set は翻訳結果をキャッシュに保存する
+func (c *CachedClient) set(ctx context.Context, key string, translation Translation) {
+	data, err := json.Marshal(translation)
+	if err != nil {
+		return
+	}
+	if err := c.cache.Set(ctx, key, data, c.ttl); err != nil {
+		log.Printf("Failed to cache translation: %v", err)
+	}
+}
+
+// cacheKey は翻訳結果のキャッシュキーを生成する
+func cacheKey(text, sourceLang, targetLang string) string {
+	hash := sha256.New()
+	hash.Write([]byte(normalizeLanguage(sourceLang)))
+	hash.Write([]byte{0})
+	hash.Write([]byte(normalizeLanguage(targetLang)))
+	hash.Write([]byte{0})
+	hash.Write([]byte(text))
+	return "translate:" + hex.EncodeToString(hash.Sum(nil))
+}
@@ -0,0 +1,82 @@
+package translate
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+)
+
+// NewTranslateClient は環境変数に基づいて適切な翻訳クライアントを返す
+// TRANSLATE_PROVIDERS（カンマ区切り、デフォルト "google,glossary"）の順にフォールバックする
+// 認証情報のないプロバイダーは除外し、利用可能なプロバイダーがない場合はモックを返す
+func NewTranslateClient() (Client, error) {
+	// モック使用の判定
+	useMocks := os.Getenv("USE_MOCK_APIS") == "true" ||
+		os.Getenv("TEST_USE_MOCKS") == "true"
+
+	if useMocks {
+		return NewMockClient(), nil
+	}
+
+	chain := os.Getenv("TRANSLATE_PROVIDERS")
+	if chain == "" {
+		chain = string(ProviderGoogle) + "," + string(ProviderGlossary)
+	}
+
+	var providers []ProviderClient
+	for _, name := range strings.Split(chain, ",") {
+		provider := Provider(strings.TrimSpace(name))
+		if provider == "" {
+			continue
+		}
+
+		client, err := newProviderClient(provider)
+		if err != nil {
+			return nil, err
+		}
+		if client == nil {
+			continue
+		}
+
+		providers = append(providers, ProviderClient{Provider: provider, Client: client})
+	}
+
+	if len(providers) == 0 {
+		// 利用可能なプロバイダーがない場合は自動的にモックを使用
+		return NewMockClient(), nil
+	}
+
+	return NewFallbackClient(providers...), nil
+}
+
+// newProviderClient はプロバイダーのクライアントを作成する
+// 認証情報が設定されていない場合は nil を返す
+func newProviderClient(provider Provider) (Client, error) {
+	switch provider {
+	case ProviderGoogle:
+		apiKey := os.Getenv("GOOGLE_TRANSLATE_API_KEY")
+		if apiKey == "" {
+			return nil, nil
+		}
+		return NewGoogleClient(apiKey), nil
+
+	case ProviderGlossary:
+		dir := os.Getenv("TRANSLATE_GLOSSARY_DIR")
+		if dir == "" {
+			return nil, nil
+		}
+		glossary := NewGlossary()
+		if err := glossary.LoadDir(dir); err != nil {
+			log.Printf("failed to load translation glossary from %s: %v", dir, err)
+			return nil, nil
+		}
+		return NewGlossaryClient(glossary), nil
+
+	case ProviderMock:
+		return NewMockClient(), nil
+
+	default:
+		return nil, fmt.Errorf("unsupported translation provider: %s", provider)
+	}
+}
@@ -0,0 +1,102 @@
+package translate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+)
+
+// ProviderClient はプロバイダー名付きの翻訳クライアント
+type ProviderClient struct {
+	Provider Provider
+	Client   Client
+}
+
+// FallbackClient は複数の翻訳プロバイダーを優先順に試す複合クライアント
+// エラーの場合は次のプロバイダーにフォールバックし、
+// 一部のテキストが翻訳できなかった場合はそのテキストのみ次のプロバイダーで翻訳する
+type FallbackClient struct {
+	providers []ProviderClient
+}
+
+// NewFallbackClient は新しいフォールバッククライアントを作成する
+func NewFallbackClient(providers ...ProviderClient) *FallbackClient {
+	return &FallbackClient{providers: providers}
+}
+
+// Providers はフォールバック順のプロバイダー一覧を返す
+func (f *FallbackClient) Providers() []Provider {
+	providers := make([]Provider, 0, len(f.providers))
+	for _, p := range f.providers {
+		providers = append(providers, p.Provider)
+	}
+	return providers
+}
+
+// Translate はテキストを翻訳する
+func (f *FallbackClient) Translate(ctx context.Context, texts []string, sourceLang, targetLang string) ([]Translation, error) {
+	if len(f.providers) == 0 {
+		return nil, errors.New("no translation providers configured")
+	}
+	if sameLanguage(sourceLang, targetLang) {
+		return untranslated(texts, sourceLang), nil
+	}
+
+	results := make([]Translation, len(texts))
+	pending := make([]int, 0, len(texts)) // 未翻訳のテキストの位置
+	for i, text := range texts {
+		if text == "" {
+			continue
+		}
+		pending = append(pending, i)
+	}
+
+	var lastErr error
+	translatedCount := 0
+	for _, p := range f.providers {
+		if len(pending) == 0 {
+			break
+		}
+
+		batch := make([]string, len(pending))
+		for i, index := range pending {
+			batch[i] = texts[index]
+		}
+
+		translated, err := p.Client.Translate(ctx, batch, sourceLang, targetLang)
+		if err != nil {
+			// キャンセルされた場合は他のプロバイダーを試さない
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			log.Printf("Translation provider (%s) failed: %v. Trying fallback...", p.Provider, err)
+			lastErr = err
+			continue
+		}
+
+		remaining := pending[:0]
+		for i, index := range pending {
+			if i >= len(translated) || translated[i].Text == "" {
+				remaining = append(remaining, index)
+				continue
+			}
+			result := translated[i]
+			if result.Provider == "" {
+				result.Provider = p.Provider
+			}
+			results[index] = result
+			translatedCount++
+		}
+		pending = remaining
+	}
+
+	if translatedCount == 0 && len(pending) > 0 {
+		if lastErr != nil {
+			return nil, fmt.Errorf("all translation providers failed: %w", lastErr)
+		}
+		return nil, ErrNoTranslation
+	}
+
+	return results, nil
+}
@@ -0,0 +1,248 @@
+package translate
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Glossary は言語の組ごとの単語→訳語のローカル辞書
+type Glossary struct {
+	mu      sync.RWMutex
+	entries map[string]map[string]string // "翻訳元:翻訳先" -> 単語（小文字） -> 訳語
+	maxLen  map[string]int               // "翻訳元:翻訳先" -> 見出し語の最大文字数（分かち書きしない言語の最長一致に使用）
+}
+
+// NewGlossary は空のローカル辞書を作成する
+func NewGlossary() *Glossary {
+	return &Glossary{
+		entries: make(map[string]map[string]string),
+		maxLen:  make(map[string]int),
+	}
+}
+
+// Add は見出し語と訳語を登録する
+func (g *Glossary) Add(sourceLang, targetLang, word, gloss string) {
+	word = strings.ToLower(strings.TrimSpace(word))
+	gloss = strings.TrimSpace(gloss)
+	if word == "" || gloss == "" {
+		return
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	pair := glossaryPair(sourceLang, targetLang)
+	if g.entries[pair] == nil {
+		g.entries[pair] = make(map[string]string)
+	}
+	g.entries[pair][word] = gloss
+	g.maxLen[pair] = max(g.maxLen[pair], len([]rune(word)))
+}
+
+// LoadFile はTSVファイル（1行に「見出し語<TAB>訳語」）から見出し語を読み込む
+// 空行と "#" で始まる行は無視する
+func (g *Glossary) LoadFile(path, sourceLang, targetLang string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open glossary: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		word, gloss, ok := strings.Cut(text, "\t")
+		if !ok {
+			return fmt.Errorf("invalid glossary entry at %s:%d", path, line)
+		}
+		g.Add(sourceLang, targetLang, word, gloss)
+	}
+
+	return scanner.Err()
+}
+
+// LoadDir はディレクトリ内の "{翻訳元}-{翻訳先}.tsv" ファイル（例: ru-ja.tsv）をすべて読み込む
+func (g *Glossary) LoadDir(dir string) error {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.tsv"))
+	if err != nil {
+		return err
+	}
+
+	for _, path := range paths {
+		name := strings.TrimSuffix(filepath.Base(path), ".tsv")
+		sourceLang, targetLang, ok := strings.Cut(name, "-")
+		if !ok || sourceLang == "" || targetLang == "" {
+			continue
+		}
+		if err := g.LoadFile(path, sourceLang, targetLang); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// lookup は見出し語の訳語を返す
+func (g *Glossary) lookup(pair, word string) (string, bool) {
+	gloss, ok := g.entries[pair][strings.ToLower(word)]
+	return gloss, ok
+}
+
+// glossaryPair は言語の組のキーを返す
+func glossaryPair(sourceLang, targetLang string) string {
+	return normalizeLanguage(sourceLang) + ":" + normalizeLanguage(targetLang)
+}
+
+// GlossaryClient はローカル辞書による逐語訳（グロス）の翻訳クライアント
+// 文としての翻訳はできないが、翻訳APIが使えない場合のフォールバックとして単語ごとの訳語を並べる
+// 辞書にない単語は原文のまま残し、1語も訳せなかったテキストの訳は空とする
+type GlossaryClient struct {
+	glossary *Glossary
+}
+
+// NewGlossaryClient は新しい逐語訳クライアントを作成する
+func NewGlossaryClient(glossary *Glossary) *GlossaryClient {
+	return &GlossaryClient{glossary: glossary}
+}
+
+// Translate はテキストを逐語訳する
+func (c *GlossaryClient) Translate(ctx context.Context, texts []string, sourceLang, targetLang string) ([]Translation, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if sameLanguage(sourceLang, targetLang) {
+		return untranslated(texts, sourceLang), nil
+	}
+	if sourceLang == "" {
+		// 言語の自動検出には対応しない
+		return nil, fmt.Errorf("%w: source language is required for glossing", ErrUnsupportedLanguagePair)
+	}
+
+	c.glossary.mu.RLock()
+	defer c.glossary.mu.RUnlock()
+
+	pair := glossaryPair(sourceLang, targetLang)
+	if len(c.glossary.entries[pair]) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedLanguagePair, pair)
+	}
+
+	results := make([]Translation, len(texts))
+	found := false
+	for i, text := range texts {
+		gloss := c.gloss(pair, text, isUnspacedLanguage(targetLang))
+		if gloss != "" {
+			found = true
+		}
+		results[i] = Translation{Text: gloss, SourceLanguage: sourceLang, Provider: ProviderGlossary}
+	}
+
+	if !found {
+		return nil, ErrNoTranslation
+	}
+	return results, nil
+}
+
+// gloss はテキストの単語を訳語に置き換える（1語も見つからない場合は空文字列）
+// 分かち書きしない文字（漢字・かななど）は辞書の見出し語との最長一致で区切る
+func (c *GlossaryClient) gloss(pair, text string, unspacedTarget bool) string {
+	runes := []rune(text)
+	maxLen := c.glossary.maxLen[pair]
+
+	var pieces []string
+	found := false
+	unknownRun := false // 直前の語が辞書にない分かち書きしない文字か（続く文字と1語にまとめる）
+	for i := 0; i < len(runes); {
+		r := runes[i]
+
+		switch {
+		case unicode.IsSpace(r):
+			unknownRun = false
+			i++
+
+		case isUnspacedRune(r):
+			end := i + 1
+			gloss, ok := "", false
+			for n := min(maxLen, len(runes)-i); n > 0; n-- {
+				if g, hit := c.glossary.lookup(pair, string(runes[i:i+n])); hit {
+					gloss, ok, end = g, true, i+n
+					break
+				}
+			}
+			switch {
+			case ok:
+				found = true
+				pieces = append(pieces, gloss)
+			case unknownRun:
+				pieces[len(pieces)-1] += string(runes[i:end])
+			default:
+				pieces = append(pieces, string(runes[i:end]))
+			}
+			unknownRun = !ok
+			i = end
+
+		case isWordRune(r):
+			end := i + 1
+			for end < len(runes) && (isWordRune(runes[end]) && !isUnspacedRune(runes[end]) ||
+				(runes[end] == '\'' || runes[end] == '-') && end+1 < len(runes) && isWordRune(runes[end+1])) {
+				end++
+			}
+			word := string(runes[i:end])
+			if gloss, ok := c.glossary.lookup(pair, word); ok {
+				found = true
+				pieces = append(pieces, gloss)
+			} else {
+				pieces = append(pieces, word)
+			}
+			unknownRun = false
+			i = end
+
+		default:
+			// 句読点は直前の語に付ける
+			if len(pieces) > 0 {
+				pieces[len(pieces)-1] += string(r)
+			} else {
+				pieces = append(pieces, string(r))
+			}
+			unknownRun = false
+			i++
+		}
+	}
+
+	if !found {
+		return ""
+	}
+	if unspacedTarget {
+		return strings.Join(pieces, "")
+	}
+	return strings.Join(pieces, " ")
+}
+
+// isWordRune は単語を構成する文字（文字・数字・結合文字）かを判定する
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r)
+}
+
+// isUnspacedRune は分かち書きしない文字（漢字・かな・タイ文字）かを判定する
+func isUnspacedRune(r rune) bool {
+	return unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hiragana, r) ||
+		unicode.Is(unicode.Katakana, r) || unicode.Is(unicode.Thai, r) || r == 'ー' || r == '々'
+}
+
+// isUnspacedLanguage は単語を空白で区切らない言語かを判定する
+func isUnspacedLanguage(lang string) bool {
+	switch normalizeLanguage(lang) {
+	case "ja", "zh", "th":
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,158 @@
+package translate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/clearclown/HaiLanGo/backend/pkg/retry"
+)
+
+// googleMaxSegments はGoogle Cloud Translation API（v2）の1リクエストあたりのテキスト数の上限
+const googleMaxSegments = 128
+
+// errGoogleRetryable はリトライ可能なAPIエラー（レート制限・サーバーエラー）
+var errGoogleRetryable = errors.New("temporary Google Translation API error")
+
+// GoogleClient はGoogle Cloud Translation API（v2, APIキー認証）のクライアント
+type GoogleClient struct {
+	apiKey     string
+	endpoint   string
+	httpClient *http.Client
+	retry      retry.Config
+}
+
+// NewGoogleClient は新しいGoogle翻訳クライアントを作成する
+func NewGoogleClient(apiKey string) *GoogleClient {
+	return &GoogleClient{
+		apiKey:     apiKey,
+		endpoint:   "https://translation.googleapis.com/language/translate/v2",
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		retry: retry.Config{
+			MaxRetries:     3,
+			InitialBackoff: 1 * time.Second,
+			MaxBackoff:     10 * time.Second,
+			Multiplier:     2.0,
+		},
+	}
+}
+
+// SetEndpoint はAPIのエンドポイントを変更する（テスト用）
+func (g *GoogleClient) SetEndpoint(endpoint string) {
+	g.endpoint = endpoint
+}
+
+// googleRequest はGoogle翻訳APIのリクエストボディ
+type googleRequest struct {
+	Q      []string `json:"q"`
+	Source string   `json:"source,omitempty"`
+	Target string   `json:"target"`
+	Format string   `json:"format"`
+}
+
+// googleResponse はGoogle翻訳APIのレスポンス
+type googleResponse struct {
+	Data struct {
+		Translations []struct {
+			TranslatedText         string `json:"translatedText"`
+			DetectedSourceLanguage string `json:"detectedSourceLanguage"`
+		} `json:"translations"`
+	} `json:"data"`
+}
+
+// Translate はテキストを翻訳する
+func (g *GoogleClient) Translate(ctx context.Context, texts []string, sourceLang, targetLang string) ([]Translation, error) {
+	if sameLanguage(sourceLang, targetLang) {
+		return untranslated(texts, sourceLang), nil
+	}
+
+	results := make([]Translation, 0, len(texts))
+	for start := 0; start < len(texts); start += googleMaxSegments {
+		end := min(start+googleMaxSegments, len(texts))
+
+		var batch []Translation
+		err := retry.Do(ctx, g.retry, func(ctx context.Context) error {
+			var err error
+			batch, err = g.callAPI(ctx, texts[start:end], sourceLang, targetLang)
+			return err
+		}, func(err error) bool {
+			return errors.Is(err, errGoogleRetryable)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("Google Translation API call failed: %w", err)
+		}
+		results = append(results, batch...)
+	}
+
+	return results, nil
+}
+
+// callAPI はGoogle翻訳APIを呼び出す
+func (g *GoogleClient) callAPI(ctx context.Context, texts []string, sourceLang, targetLang string) ([]Translation, error) {
+	body, err := json.Marshal(googleRequest{
+		Q:      texts,
+		Source: normalizeLanguage(sourceLang),
+		Target: normalizeLanguage(targetLang),
+		Format: "text",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	endpoint := g.endpoint + "?key=" + url.QueryEscape(g.apiKey)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := g.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errGoogleRetryable, err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return nil, fmt.Errorf("%w: status %d: %s", errGoogleRetryable, resp.StatusCode, respBody)
+	case resp.StatusCode == http.StatusBadRequest:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedLanguagePair, respBody)
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("API error: status %d: %s", resp.StatusCode, respBody)
+	}
+
+	var parsed googleResponse
+	if err := json.Unmarshal(respBody, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	if len(parsed.Data.Translations) != len(texts) {
+		return nil, fmt.Errorf("unexpected number of translations: got %d, want %d", len(parsed.Data.Translations), len(texts))
+	}
+
+	results := make([]Translation, len(texts))
+	for i, t := range parsed.Data.Translations {
+		source := sourceLang
+		if source == "" {
+			source = t.DetectedSourceLanguage
+		}
+		results[i] = Translation{
+			Text:           html.UnescapeString(t.TranslatedText),
+			SourceLanguage: source,
+			Provider:       ProviderGoogle,
+		}
+	}
+
+	return results, nil
+}
@@ -0,0 +1,72 @@
+package translate
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// mockTranslations はモックの翻訳データ（翻訳元言語:翻訳先言語 -> テキスト -> 訳）
+var mockTranslations = map[string]map[string]string{
+	"ru:ja": {
+		"Здравствуйте!":                   "こんにちは！",
+		"Как дела?":                       "元気ですか？",
+		"Здравствуйте! Как дела?":         "こんにちは！元気ですか？",
+		"Спасибо, хорошо.":                "ありがとう、元気です。",
+		"А у вас?":                        "あなたは？",
+		"До свидания!":                    "さようなら！",
+		"Меня зовут Анна.":                "私の名前はアンナです。",
+		"Очень приятно!":                  "はじめまして！",
+		"Где находится станция?":          "駅はどこですか？",
+		"Сколько это стоит?":              "これはいくらですか？",
+		"Я не понимаю.":                   "わかりません。",
+		"Говорите медленнее, пожалуйста.": "もっとゆっくり話してください。",
+	},
+	"en:ja": {
+		"Hello!":            "こんにちは！",
+		"How are you?":      "元気ですか？",
+		"Thank you.":        "ありがとう。",
+		"Good morning.":     "おはようございます。",
+		"Nice to meet you.": "はじめまして。",
+	},
+	"ja:en": {
+		"こんにちは！":     "Hello!",
+		"元気ですか？":     "How are you?",
+		"ありがとう。":     "Thank you.",
+		"おはようございます。": "Good morning.",
+	},
+}
+
+// MockClient はモックの翻訳クライアント
+// 登録済みの文はその訳を、それ以外は "[翻訳先言語] 原文" を返す（同じ入力には常に同じ結果）
+type MockClient struct{}
+
+// NewMockClient は新しいモック翻訳クライアントを作成する
+func NewMockClient() *MockClient {
+	return &MockClient{}
+}
+
+// Translate はテキストを翻訳する
+func (m *MockClient) Translate(ctx context.Context, texts []string, sourceLang, targetLang string) ([]Translation, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if targetLang == "" {
+		return nil, fmt.Errorf("%w: target language is required", ErrUnsupportedLanguagePair)
+	}
+	if sameLanguage(sourceLang, targetLang) {
+		return untranslated(texts, sourceLang), nil
+	}
+
+	table := mockTranslations[normalizeLanguage(sourceLang)+":"+normalizeLanguage(targetLang)]
+	results := make([]Translation, len(texts))
+	for i, text := range texts {
+		translated, ok := table[strings.TrimSpace(text)]
+		if !ok {
+			translated = fmt.Sprintf("[%s] %s", normalizeLanguage(targetLang), strings.TrimSpace(text))
+		}
+		results[i] = Translation{Text: translated, SourceLanguage: sourceLang, Provider: ProviderMock}
+	}
+
+	return results, nil
+}
@@ -0,0 +1,73 @@
+package translate
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+// Provider は翻訳プロバイダーの種類
+type Provider string
+
+const (
+	ProviderGoogle   Provider = "google"
+	ProviderGlossary Provider = "glossary"
+	ProviderMock     Provider = "mock"
+)
+
+var (
+	// ErrUnsupportedLanguagePair はプロバイダーが言語の組に対応していない場合のエラー
+	ErrUnsupportedLanguagePair = errors.New("unsupported language pair")
+
+	// ErrNoTranslation は翻訳結果が得られなかった場合のエラー
+	ErrNoTranslation = errors.New("no translation available")
+)
+
+// Translation は翻訳結果
+type Translation struct {
+	Text           string   `json:"text"`                      // 翻訳後のテキスト（翻訳できなかった場合は空）
+	SourceLanguage string   `json:"source_language,omitempty"` // 翻訳元の言語（自動検出した場合は検出結果）
+	Provider       Provider `json:"provider,omitempty"`        // 翻訳したプロバイダー
+}
+
+// Client は翻訳クライアントのインターフェース
+type Client interface {
+	// Translate はテキストを翻訳する（結果はtextsと同じ順序）
+	// sourceLangが空の場合はプロバイダーが自動検出する
+	Translate(ctx context.Context, texts []string, sourceLang, targetLang string) ([]Translation, error)
+}
+
+// TranslateText は1つのテキストを翻訳する
+func TranslateText(ctx context.Context, client Client, text, sourceLang, targetLang string) (string, error) {
+	results, err := client.Translate(ctx, []string{text}, sourceLang, targetLang)
+	if err != nil {
+		return "", err
+	}
+	if len(results) == 0 || results[0].Text == "" {
+		return "", ErrNoTranslation
+	}
+	return results[0].Text, nil
+}
+
+// normalizeLanguage は言語コードを比較用に正規化する（"ja-JP" -> "ja"）
+func normalizeLanguage(lang string) string {
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	if i := strings.IndexAny(lang, "-_"); i >= 0 {
+		lang = lang[:i]
+	}
+	return lang
+}
+
+// sameLanguage は翻訳元と翻訳先が同じ言語かを判定する
+func sameLanguage(sourceLang, targetLang string) bool {
+	return sourceLang != "" && normalizeLanguage(sourceLang) == normalizeLanguage(targetLang)
+}
+
+// untranslated は翻訳せずにテキストをそのまま返す（翻訳元と翻訳先が同じ言語の場合）
+func untranslated(texts []string, sourceLang string) []Translation {
+	results := make([]Translation, len(texts))
+	for i, text := range texts {
+		results[i] = Translation{Text: text, SourceLanguage: sourceLang}
+	}
+	return results
+}
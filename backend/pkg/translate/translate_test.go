@@ -0,0 +1,224 @@
+package translate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/clearclown/HaiLanGo/backend/pkg/cache"
+	"github.com/clearclown/HaiLanGo/backend/pkg/retry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMockClient(t *testing.T) {
+	client := NewMockClient()
+
+	results, err := client.Translate(context.Background(), []string{"Как дела?", "Новое слово"}, "ru", "ja")
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, "元気ですか？", results[0].Text)
+	assert.Equal(t, "[ja] Новое слово", results[1].Text)
+	assert.Equal(t, ProviderMock, results[1].Provider)
+
+	// 同じ言語の場合は翻訳しない
+	results, err = client.Translate(context.Background(), []string{"こんにちは"}, "ja-JP", "ja")
+	require.NoError(t, err)
+	assert.Equal(t, "こんにちは", results[0].Text)
+}
+
+func TestGlossaryClient(t *testing.T) {
+	glossary := NewGlossary()
+	glossary.Add("ru", "ja", "Как", "どう")
+	glossary.Add("ru", "ja", "дела", "調子")
+	glossary.Add("ja", "en", "日本", "Japan")
+	glossary.Add("ja", "en", "日本語", "Japanese")
+	glossary.Add("ja", "en", "勉強", "study")
+	client := NewGlossaryClient(glossary)
+
+	results, err := client.Translate(context.Background(), []string{"Как дела?", "Спасибо"}, "ru", "ja")
+	require.NoError(t, err)
+	assert.Equal(t, "どう調子?", results[0].Text)
+	assert.Equal(t, "", results[1].Text)
+	assert.Equal(t, ProviderGlossary, results[0].Provider)
+
+	// 分かち書きしない言語は最長一致で区切る
+	results, err = client.Translate(context.Background(), []string{"日本語を勉強する"}, "ja", "en")
+	require.NoError(t, err)
+	assert.Equal(t, "Japanese を study する", results[0].Text)
+
+	_, err = client.Translate(context.Background(), []string{"Спасибо"}, "ru", "ja")
+	assert.ErrorIs(t, err, ErrNoTranslation)
+
+	_, err = client.Translate(context.Background(), []string{"hello"}, "en", "ja")
+	assert.ErrorIs(t, err, ErrUnsupportedLanguagePair)
+}
+
+func TestGlossary_LoadDir(t *testing.T) {
+	dir := t.TempDir()
+	content := "# ロシア語-日本語\nспасибо\tありがとう\n\nпока\tじゃあね\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "ru-ja.tsv"), []byte(content), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("ignored"), 0o644))
+
+	glossary := NewGlossary()
+	require.NoError(t, glossary.LoadDir(dir))
+
+	text, err := TranslateText(context.Background(), NewGlossaryClient(glossary), "Спасибо!", "ru", "ja")
+	require.NoError(t, err)
+	assert.Equal(t, "ありがとう!", text)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "en-ja.tsv"), []byte("broken line\n"), 0o644))
+	assert.Error(t, NewGlossary().LoadDir(dir))
+}
+
+// stubClient はテスト用の翻訳クライアント
+type stubClient struct {
+	translate func(texts []string) ([]Translation, error)
+	calls     [][]string
+}
+
+func (s *stubClient) Translate(ctx context.Context, texts []string, sourceLang, targetLang string) ([]Translation, error) {
+	s.calls = append(s.calls, texts)
+	return s.translate(texts)
+}
+
+func TestFallbackClient(t *testing.T) {
+	failing := &stubClient{translate: func(texts []string) ([]Translation, error) {
+		return nil, errors.New("rate limit")
+	}}
+	partial := &stubClient{translate: func(texts []string) ([]Translation, error) {
+		results := make([]Translation, len(texts))
+		for i, text := range texts {
+			if text == "known" {
+				results[i] = Translation{Text: "既知"}
+			}
+		}
+		return results, nil
+	}}
+	last := &stubClient{translate: func(texts []string) ([]Translation, error) {
+		results := make([]Translation, len(texts))
+		for i := range texts {
+			results[i] = Translation{Text: "最後"}
+		}
+		return results, nil
+	}}
+
+	client := NewFallbackClient(
+		ProviderClient{Provider: ProviderGoogle, Client: failing},
+		ProviderClient{Provider: ProviderGlossary, Client: partial},
+		ProviderClient{Provider: ProviderMock, Client: last},
+	)
+	assert.Equal(t, []Provider{ProviderGoogle, ProviderGlossary, ProviderMock}, client.Providers())
+
+	results, err := client.Translate(context.Background(), []string{"known", "", "unknown"}, "ru", "ja")
+	require.NoError(t, err)
+	assert.Equal(t, Translation{Text: "既知", Provider: ProviderGlossary}, results[0])
+	assert.Equal(t, Translation{}, results[1])
+	assert.Equal(t, Translation{Text: "最後", Provider: ProviderMock}, results[2])
+
+	// 翻訳できなかったテキストのみ次のプロバイダーに渡す
+	assert.Equal(t, [][]string{{"unknown"}}, last.calls)
+
+	_, err = NewFallbackClient(ProviderClient{Provider: ProviderGoogle, Client: failing}).
+		Translate(context.Background(), []string{"text"}, "ru", "ja")
+	assert.ErrorContains(t, err, "rate limit")
+}
+
+func TestCachedClient(t *testing.T) {
+	stub := &stubClient{translate: func(texts []string) ([]Translation, error) {
+		results := make([]Translation, len(texts))
+		for i, text := range texts {
+			if text != "untranslatable" {
+				results[i] = Translation{Text: "訳:" + text, Provider: ProviderMock}
+			}
+		}
+		return results, nil
+	}}
+	client := NewCachedClient(stub, cache.NewMockCache(), time.Hour)
+	ctx := context.Background()
+
+	results, err := client.Translate(ctx, []string{"a", "b", "untranslatable"}, "ru", "ja")
+	require.NoError(t, err)
+	assert.Equal(t, "訳:a", results[0].Text)
+
+	results, err = client.Translate(ctx, []string{"b", "c", "untranslatable"}, "ru", "ja")
+	require.NoError(t, err)
+	assert.Equal(t, "訳:b", results[0].Text)
+	assert.Equal(t, "訳:c", results[1].Text)
+	assert.Equal(t, ProviderMock, results[0].Provider)
+
+	// キャッシュにないテキストのみ翻訳し、翻訳できなかったテキストはキャッシュしない
+	assert.Equal(t, [][]string{{"a", "b", "untranslatable"}, {"c", "untranslatable"}}, stub.calls)
+
+	// 言語の組が異なる場合は別のキャッシュ
+	_, err = client.Translate(ctx, []string{"a"}, "ru", "en")
+	require.NoError(t, err)
+	assert.Len(t, stub.calls, 3)
+}
+
+func TestGoogleClient(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		assert.Equal(t, "test-key", r.URL.Query().Get("key"))
+		if attempts == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+
+		var req googleRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, "ru", req.Source)
+		assert.Equal(t, "ja", req.Target)
+		assert.Equal(t, "text", req.Format)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"data":{"translations":[{"translatedText":"こんにちは &amp; さようなら"},{"translatedText":"元気ですか？"}]}}`))
+	}))
+	defer server.Close()
+
+	client := NewGoogleClient("test-key")
+	client.SetEndpoint(server.URL)
+	client.retry = retry.Config{MaxRetries: 2, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond, Multiplier: 1}
+
+	results, err := client.Translate(context.Background(), []string{"Здравствуйте и до свидания", "Как дела?"}, "ru-RU", "ja")
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, "こんにちは & さようなら", results[0].Text)
+	assert.Equal(t, ProviderGoogle, results[1].Provider)
+	assert.Equal(t, 2, attempts)
+}
+
+func TestNewTranslateClient(t *testing.T) {
+	t.Setenv("USE_MOCK_APIS", "true")
+	client, err := NewTranslateClient()
+	require.NoError(t, err)
+	assert.IsType(t, &MockClient{}, client)
+
+	t.Setenv("USE_MOCK_APIS", "false")
+	t.Setenv("TEST_USE_MOCKS", "")
+	t.Setenv("GOOGLE_TRANSLATE_API_KEY", "key")
+	t.Setenv("TRANSLATE_GLOSSARY_DIR", t.TempDir())
+	t.Setenv("TRANSLATE_PROVIDERS", "google, glossary")
+	client, err = NewTranslateClient()
+	require.NoError(t, err)
+	require.IsType(t, &FallbackClient{}, client)
+	assert.Equal(t, []Provider{ProviderGoogle, ProviderGlossary}, client.(*FallbackClient).Providers())
+
+	// 認証情報がない場合はモック
+	t.Setenv("GOOGLE_TRANSLATE_API_KEY", "")
+	t.Setenv("TRANSLATE_PROVIDERS", "google")
+	client, err = NewTranslateClient()
+	require.NoError(t, err)
+	assert.IsType(t, &MockClient{}, client)
+
+	t.Setenv("TRANSLATE_PROVIDERS", "unknown")
+	_, err = NewTranslateClient()
+	assert.Error(t, err)
+}